@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/calendar"
+	"fastfunds/internal/config"
+	"fastfunds/internal/events"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/service"
+	"fastfunds/internal/statements"
+	"fastfunds/internal/util"
+	"fmt"
+	"os"
+	"time"
+)
+
+const usage = `usage: fastfunds [command]
+
+Without a command the API server starts. Commands:
+  create-api-key <name>          issue an admin API key and print it once
+  verify-audit-log [head-hash]   check the audit log hash chain; with a head
+                                 printed by an earlier run, also check that
+                                 no entries were cut from the end since
+  ach-file                       write out unwritten NACHA files, then create
+                                 one of the pending payouts now
+  ach-returns <file>             reverse the payouts returned in a NACHA
+                                 return file
+  statement <account> <from> <to> [format]
+                                 write the account's statement from one date
+                                 to another (both inclusive, YYYY-MM-DD) to
+                                 stdout; format is csv (default), json, ofx,
+                                 camt053 or mt940
+  reconcile                      check every balance against the transaction
+                                 history; exits non-zero on a discrepancy
+  close-day [YYYY-MM-DD]         close the business day, or every business
+                                 day that has ended since the last close`
+
+// auditLogBatch is how many entries verify-audit-log reads at a time.
+const auditLogBatch = 1000
+
+// commandServices are the services commands use, configured as the server
+// configures them.
+type commandServices struct {
+	accounts       *service.AccountService
+	transactions   *service.TransactionService
+	payouts        *service.PayoutService
+	reconciliation *service.ReconciliationService
+	businessDays   *service.BusinessDayService
+}
+
+// runCommand runs a one-off administrative command instead of the server.
+// Commands act as an admin: whoever can run them already has the database.
+func runCommand(db *sql.DB, services commandServices, args []string) error {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "cli", Role: auth.RoleAdmin})
+
+	switch args[0] {
+	case "create-api-key":
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+		key, err := service.NewAPIKeyService(db, repository.NewPostgresAPIKeyRepository(db),
+			service.WithAPIKeyAuditLog(repository.NewPostgresAuditLogRepository(db))).
+			CreateAPIKey(ctx, &models.CreateAPIKeyRequest{Name: args[1], Role: auth.RoleAdmin})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "API key %d (%s): %s\n", key.ID, key.Name, key.Key)
+		return nil
+	case "verify-audit-log":
+		if len(args) > 2 {
+			return errors.New(usage)
+		}
+		var knownHead string
+		if len(args) == 2 {
+			knownHead = args[1]
+		}
+		return verifyAuditLog(repository.NewPostgresAuditLogRepository(db), knownHead)
+	case "ach-file":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		return createACHFile(ctx, services.payouts)
+	case "ach-returns":
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+		return processACHReturns(ctx, services.payouts, args[1])
+	case "statement":
+		if len(args) != 4 && len(args) != 5 {
+			return errors.New(usage)
+		}
+		format := "csv"
+		if len(args) == 5 {
+			format = args[4]
+		}
+		return exportStatement(ctx, services, args[1], args[2], args[3], format)
+	case "reconcile":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		return reconcile(ctx, services.reconciliation)
+	case "close-day":
+		if len(args) > 2 {
+			return errors.New(usage)
+		}
+		var day string
+		if len(args) == 2 {
+			day = args[1]
+		}
+		return closeDay(ctx, services.businessDays, day)
+	default:
+		return errors.New(usage)
+	}
+}
+
+// verifyAuditLog walks the audit log from the first entry and prints the
+// head hash. The chain alone can't show that the newest entries were
+// deleted, so keep the printed head outside the database and pass it to later
+// runs: it must still be in the chain.
+func verifyAuditLog(log repository.AuditLogRepository, knownHead string) error {
+	v := audit.NewVerifier()
+	seenHead := knownHead == "" || knownHead == audit.GenesisHash
+	var after int64
+	for {
+		batch, err := log.ListAfter(after, auditLogBatch)
+		if err != nil {
+			return fmt.Errorf("couldn't read audit log: %w", err)
+		}
+		for _, e := range batch {
+			if err := v.Check(e); err != nil {
+				return err
+			}
+			if e.Hash == knownHead {
+				seenHead = true
+			}
+			after = e.ID
+		}
+		if len(batch) < auditLogBatch {
+			break
+		}
+	}
+	if !seenHead {
+		return fmt.Errorf("audit log verified up to %s but head %s is missing; entries were removed from the end", v.Head(), knownHead)
+	}
+
+	fmt.Fprintf(os.Stdout, "audit log OK: %d entries, head %s\n", v.Count(), v.Head())
+	return nil
+}
+
+func createACHFile(ctx context.Context, payouts *service.PayoutService) error {
+	n, err := payouts.WriteFiles()
+	if err != nil {
+		return fmt.Errorf("couldn't write ACH files: %w", err)
+	}
+	if n > 0 {
+		fmt.Fprintf(os.Stdout, "wrote %d earlier ACH files\n", n)
+	}
+
+	file, err := payouts.CreateFile(ctx)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		fmt.Fprintln(os.Stdout, "no payouts pending")
+		return nil
+	}
+	if file.WrittenAt == nil {
+		return fmt.Errorf("ACH file %s was created but couldn't be written; run ach-file again", file.FileName)
+	}
+	fmt.Fprintf(os.Stdout, "ACH file %s: %d payouts, %s\n", file.FileName, file.EntryCount, util.PenniesToDecimalString(file.TotalPennies))
+	return nil
+}
+
+func processACHReturns(ctx context.Context, payouts *service.PayoutService, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := payouts.ProcessReturns(ctx, f)
+	if err != nil {
+		return err
+	}
+	for _, r := range result.Returns {
+		line := fmt.Sprintf("%s %s %s %s", r.TraceNumber, r.ReturnCode, util.PenniesToDecimalString(r.AmountPennies), r.Status)
+		if r.Error != "" {
+			line += ": " + r.Error
+		}
+		fmt.Fprintln(os.Stdout, line)
+	}
+	return nil
+}
+
+// exportStatement writes a statement to stdout. The dates are whole UTC
+// days, as for the statement endpoint.
+func exportStatement(ctx context.Context, services commandServices, accountNumber, fromDate, toDate, formatName string) error {
+	format, err := statements.Lookup(formatName)
+	if err != nil {
+		return err
+	}
+	from, err := time.Parse(time.DateOnly, fromDate)
+	if err != nil {
+		return fmt.Errorf("invalid from date %q", fromDate)
+	}
+	to, err := time.Parse(time.DateOnly, toDate)
+	if err != nil {
+		return fmt.Errorf("invalid to date %q", toDate)
+	}
+	accountID, err := services.accounts.ResolveAccountNumber(accountNumber)
+	if err != nil {
+		return err
+	}
+	return services.transactions.WriteStatement(ctx, accountID, from, to.AddDate(0, 0, 1), format.New(os.Stdout))
+}
+
+func reconcile(ctx context.Context, reconciliation *service.ReconciliationService) error {
+	result, err := reconciliation.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range result.Discrepancies {
+		fmt.Fprintf(os.Stdout, "%s: balance %s, expected %s from its history (%s)\n", d.AccountNumber,
+			util.PenniesToDecimalString(d.BalancePennies), util.PenniesToDecimalString(d.ExpectedBalancePennies),
+			util.PenniesToDecimalString(d.DifferencePennies))
+	}
+	fmt.Fprintf(os.Stdout, "%d accounts, total balance %s, total opened with %s\n", result.AccountsChecked,
+		util.PenniesToDecimalString(result.TotalBalancePennies), util.PenniesToDecimalString(result.TotalInitialPennies))
+	if !result.OK {
+		return fmt.Errorf("balances don't reconcile: %d discrepancies, total conserved: %t", len(result.Discrepancies), result.Conserved)
+	}
+	fmt.Fprintln(os.Stdout, "balances reconcile")
+	return nil
+}
+
+func closeDay(ctx context.Context, businessDays *service.BusinessDayService, day string) error {
+	var closed []*models.BusinessDay
+	if day == "" {
+		var err error
+		if closed, err = businessDays.CloseDue(ctx); err != nil {
+			return err
+		}
+	} else {
+		d, err := time.Parse(calendar.DateLayout, day)
+		if err != nil {
+			return fmt.Errorf("invalid day %q", day)
+		}
+		closed1, err := businessDays.CloseDay(ctx, d)
+		if err != nil {
+			return err
+		}
+		closed = append(closed, closed1)
+	}
+	if len(closed) == 0 {
+		fmt.Fprintln(os.Stdout, "no business day to close")
+	}
+	for _, d := range closed {
+		fmt.Fprintf(os.Stdout, "closed %s: %d transfers, debits %s, credits %s\n", d.Day, d.TransferCount,
+			util.PenniesToDecimalString(d.TotalDebits), util.PenniesToDecimalString(d.TotalCredits))
+	}
+	return nil
+}
+
+// loadJWTKeys builds the JWT key set from configuration. It is empty when
+// JWT authentication isn't configured.
+func loadJWTKeys(cfg *config.Config) (*auth.KeySet, error) {
+	keys := auth.NewKeySet()
+	if cfg.JWTHS256Secret != "" {
+		if err := keys.SetHMACSecret([]byte(cfg.JWTHS256Secret)); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.JWTPublicKeyPath != "" {
+		if err := keys.LoadPublicKeyFile(cfg.JWTPublicKeyPath); err != nil {
+			return nil, fmt.Errorf("JWT_PUBLIC_KEY_PATH: %w", err)
+		}
+	}
+	if cfg.JWTJWKSPath != "" {
+		if err := keys.LoadJWKSFile(cfg.JWTJWKSPath); err != nil {
+			return nil, fmt.Errorf("JWT_JWKS_PATH: %w", err)
+		}
+	}
+	return keys, nil
+}
+
+// newEventPublisher builds the domain event publisher from configuration. It
+// is nil when publishing isn't configured.
+func newEventPublisher(cfg *config.Config) (events.Publisher, error) {
+	switch cfg.EventPublisher {
+	case "stdout":
+		return events.NewWriterPublisher(os.Stdout), nil
+	case "file":
+		p, err := events.NewFilePublisher(cfg.EventFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("EVENT_FILE_PATH: %w", err)
+		}
+		return p, nil
+	case "webhook":
+		return events.NewWebhookPublisher(cfg.EventWebhookURL, nil), nil
+	}
+	return nil, nil
+}
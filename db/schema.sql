@@ -1,27 +1,527 @@
 -- PostgreSQL schema for FastFunds
 
+-- Chart of accounts: the general ledger accounts financial reports are
+-- grouped by. Every account posts to one of them.
+CREATE TABLE ledger_accounts (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('asset', 'liability', 'equity', 'income', 'expense')),
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Account products. Changing a product's terms adds a version; accounts
+-- keep the version they were opened on until migrated to a later one.
+CREATE TABLE products (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('checking', 'savings', 'business', 'escrow', 'internal')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE product_versions (
+    product_code TEXT NOT NULL REFERENCES products(code),
+    version INTEGER NOT NULL,
+    currency TEXT NOT NULL, -- ISO 4217, the default for new accounts
+    overdraft_limit BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0), -- pennies
+    unlimited_overdraft BOOLEAN NOT NULL DEFAULT false,
+    monthly_fee BIGINT NOT NULL DEFAULT 0 CHECK (monthly_fee >= 0),
+    transfer_fee BIGINT NOT NULL DEFAULT 0 CHECK (transfer_fee >= 0),
+    interest_rate_bps INTEGER NOT NULL DEFAULT 0 CHECK (interest_rate_bps >= 0), -- yearly
+    max_transfer BIGINT CHECK (max_transfer > 0), -- NULL: no limit
+    daily_transfer_limit BIGINT CHECK (daily_transfer_limit > 0), -- NULL: no limit
+    ledger_code TEXT NOT NULL REFERENCES ledger_accounts(code), -- the default for new accounts
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_code, version)
+);
+
 CREATE TABLE accounts (
-    account_id INTEGER PRIMARY KEY,
-    balance BIGINT NOT NULL DEFAULT 0 -- pennies
+    account_id SERIAL PRIMARY KEY, -- internal only
+    account_number TEXT NOT NULL UNIQUE, -- external, carries check digits
+    holder_name TEXT NOT NULL DEFAULT '',
+    balance BIGINT NOT NULL DEFAULT 0, -- pennies
+    -- the balance the account was opened with; reconciliation checks that
+    -- balance = initial_balance + completed transfers in - out
+    initial_balance BIGINT NOT NULL DEFAULT 0,
+    -- the ledger account it posts to in the chart of accounts
+    ledger_code TEXT NOT NULL REFERENCES ledger_accounts(code),
+    -- the product version whose terms apply, and the account's currency
+    product_code TEXT NOT NULL,
+    product_version INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (product_code, product_version) REFERENCES product_versions(product_code, version)
 );
 
+CREATE INDEX IF NOT EXISTS idx_accounts_ledger_code ON accounts(ledger_code);
+CREATE INDEX IF NOT EXISTS idx_accounts_product ON accounts(product_code, product_version);
+
 CREATE TABLE transactions (
     id SERIAL PRIMARY KEY,
     source_account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE RESTRICT,
     destination_account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE RESTRICT,
     amount BIGINT NOT NULL, -- pennies
     status TEXT NOT NULL,
+    initiated_by TEXT NOT NULL DEFAULT '',
+    reviewed_by TEXT NOT NULL DEFAULT '',
+    review_note TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ, -- set while pending_approval
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ -- when funds moved; statements are ordered by it
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_source ON transactions(source_account_id, completed_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_destination ON transactions(destination_account_id, completed_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
+
+CREATE TABLE customers (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers(LOWER(email)) WHERE email <> '';
+
+CREATE TABLE account_holders (
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
+    customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'joint', 'authorized_user')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, customer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_holders_customer ON account_holders(customer_id);
+
+-- Sanctions screening hits; a transfer with an unresolved case stays 'held'
+CREATE TABLE screening_cases (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE RESTRICT,
+    transaction_id INTEGER REFERENCES transactions(id) ON DELETE RESTRICT,
+    screened_name TEXT NOT NULL,
+    entry_uid TEXT NOT NULL,
+    listed_name TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open', -- open, cleared, confirmed
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_screening_cases_account_entry ON screening_cases(account_id, entry_uid);
+CREATE INDEX IF NOT EXISTS idx_screening_cases_transaction ON screening_cases(transaction_id);
+
+-- Payout destinations at other banks; details are validated before insert
+CREATE TABLE external_accounts (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('iban', 'uk', 'us')),
+    label TEXT NOT NULL DEFAULT '',
+    holder_name TEXT NOT NULL,
+    iban TEXT NOT NULL DEFAULT '',
+    bic TEXT NOT NULL DEFAULT '',
+    sort_code TEXT NOT NULL DEFAULT '',
+    account_number TEXT NOT NULL DEFAULT '',
+    routing_number TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (customer_id, type, iban, sort_code, routing_number, account_number)
+);
+
+-- API keys; only a SHA-256 hash of each key is stored
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('customer', 'operator', 'admin', 'auditor')),
+    customer_id INTEGER REFERENCES customers(id), -- set only for the customer role
+    prefix TEXT NOT NULL UNIQUE, -- public lookup part of the key
+    hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    CHECK ((role = 'customer') = (customer_id IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_active_name ON api_keys(name) WHERE revoked_at IS NULL;
+
+-- Token buckets shared by every replica when RATE_LIMIT_STORE=postgres
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Append-only audit log. Each row's hash covers its contents and the hash of
+-- the row before it; `go run . verify-audit-log` walks the chain.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    actor TEXT NOT NULL,
+    actor_role TEXT NOT NULL DEFAULT '',
+    auth_method TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    before JSONB,
+    after JSONB,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+-- Transactional outbox. Domain events are inserted with the change they
+-- describe and published by the relay; published_at is set once accepted.
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(next_attempt_at, id) WHERE published_at IS NULL;
+
+-- Webhook subscriptions. The secret signs deliveries, so it is stored as is.
+CREATE TABLE webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One delivery per subscription and event; the event is stored as sent.
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type TEXT NOT NULL,
+    event JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at, id) WHERE status = 'pending';
+
+CREATE TABLE webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status_code INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id);
+
+-- Per-account feed of transfer status changes and the balance changes they
+-- cause, streamed by GET /accounts/:account_number/events. Rows are written
+-- with the transfer, and each insert notifies account_activity on commit.
+CREATE TABLE account_activity (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    type TEXT NOT NULL CHECK (type IN ('transfer', 'balance')),
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    status TEXT NOT NULL,
+    amount BIGINT NOT NULL, -- negative when the account is the source
+    counterparty_account_number TEXT NOT NULL,
+    balance BIGINT,         -- after the change; balance rows only
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_activity_account ON account_activity(account_id, id);
+
+CREATE FUNCTION account_activity_notify() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('account_activity', NEW.account_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER account_activity_notify AFTER INSERT ON account_activity
+    FOR EACH ROW EXECUTE FUNCTION account_activity_notify();
+
+-- Bulk transfer files. Rows are validated on upload and, once the import is
+-- confirmed, executed in line order by a background worker.
+CREATE TABLE transfer_imports (
+    id SERIAL PRIMARY KEY,
+    file_name TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'validated' CHECK (status IN ('validated', 'processing', 'completed')),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Transfers are made on behalf of whoever confirmed the import
+    confirmed_by TEXT NOT NULL DEFAULT '',
+    confirmed_role TEXT NOT NULL DEFAULT '',
+    confirmed_auth_method TEXT NOT NULL DEFAULT '',
+    confirmed_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE TABLE transfer_import_rows (
+    import_id INTEGER NOT NULL REFERENCES transfer_imports(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    source_account_number TEXT NOT NULL,
+    destination_account_number TEXT NOT NULL,
+    amount_text TEXT NOT NULL,  -- as written in the file
+    amount BIGINT NOT NULL,     -- pennies; 0 when invalid
+    reference TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN ('valid', 'invalid', 'pending', 'created', 'failed')),
+    error TEXT NOT NULL DEFAULT '',
+    transaction_id INTEGER REFERENCES transactions(id),
+    transaction_status TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (import_id, line)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_import_rows_pending ON transfer_import_rows(import_id, line) WHERE status = 'pending';
+
+-- pain.001 messages accepted for execution. A message ID can be used once
+-- per initiator; group_status is set once every transaction has been tried.
+CREATE TABLE payment_initiations (
+    id SERIAL PRIMARY KEY,
+    message_id TEXT NOT NULL,
+    initiated_by TEXT NOT NULL,
+    transactions INT NOT NULL,
+    group_status TEXT CHECK (group_status IN ('ACSC', 'PDNG', 'PART', 'RJCT')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    UNIQUE (initiated_by, message_id)
+);
+
+-- NACHA files of ACH payouts. content is kept until written_at is set, so
+-- a file that couldn't be written to the output directory is retried.
+CREATE TABLE ach_files (
+    id SERIAL PRIMARY KEY,
+    file_name TEXT NOT NULL UNIQUE,
+    content TEXT NOT NULL,
+    entry_count INT NOT NULL,
+    total BIGINT NOT NULL, -- pennies
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    written_at TIMESTAMPTZ
+);
+
+-- ACH credits to customers' US bank accounts. The receiver's details are
+-- copied from the external account when the payout is made.
+CREATE TABLE payouts (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE RESTRICT,
+    external_account_id INTEGER NOT NULL,
+    routing_number TEXT NOT NULL,
+    receiver_account_number TEXT NOT NULL,
+    receiver_name TEXT NOT NULL,
+    amount BIGINT NOT NULL, -- pennies
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'returned')),
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+    file_id INTEGER REFERENCES ach_files(id),
+    trace_number TEXT UNIQUE,
+    return_code TEXT NOT NULL DEFAULT '',
+    return_transaction_id INTEGER REFERENCES transactions(id),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    returned_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_pending ON payouts(id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_payouts_account ON payouts(account_id);
+
+-- Every account's balance at taken_at, counting the transfers completed
+-- before it. Balances as of a past time are computed forward from the latest
+-- snapshot before that time instead of backward from the current balance.
+CREATE TABLE balance_snapshots (
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
+    taken_at TIMESTAMPTZ NOT NULL,
+    balance BIGINT NOT NULL, -- pennies
+    PRIMARY KEY (account_id, taken_at)
+);
+
+-- Closed business days. A day runs from the previous business day's cut-off
+-- to its own; once closed, no transfer may complete or change inside it.
+CREATE TABLE business_days (
+    day DATE PRIMARY KEY,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL UNIQUE,
+    transfer_count INTEGER NOT NULL DEFAULT 0,
+    total_debits BIGINT NOT NULL DEFAULT 0, -- pennies, summed over daily_balances
+    total_credits BIGINT NOT NULL DEFAULT 0,
+    closed_by TEXT NOT NULL,
+    closed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Each account's balances and movements over a closed business day.
+CREATE TABLE daily_balances (
+    day DATE NOT NULL REFERENCES business_days(day),
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id) ON DELETE RESTRICT,
+    opening_balance BIGINT NOT NULL, -- pennies
+    debits BIGINT NOT NULL,
+    debit_count INTEGER NOT NULL,
+    credits BIGINT NOT NULL,
+    credit_count INTEGER NOT NULL,
+    closing_balance BIGINT NOT NULL,
+    PRIMARY KEY (day, account_id)
+);
+
+-- Refuses transfers completing, or completed transfers changing, before the
+-- end of the last closed business day.
+CREATE FUNCTION transactions_closed_day() RETURNS trigger AS $$
+DECLARE
+    closed_until TIMESTAMPTZ := (SELECT MAX(ends_at) FROM business_days);
+BEGIN
+    IF closed_until IS NULL THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.completed_at < closed_until THEN
+        RAISE EXCEPTION 'transaction % is in a closed business day', OLD.id USING ERRCODE = 'FF001';
+    END IF;
+    IF NEW.completed_at < closed_until THEN
+        RAISE EXCEPTION 'business day of % is closed', NEW.completed_at USING ERRCODE = 'FF001';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_closed_day
+    BEFORE INSERT OR UPDATE OF source_account_id, destination_account_id, amount, status, completed_at ON transactions
+    FOR EACH ROW EXECUTE FUNCTION transactions_closed_day();
+
+-- Escrow sub-accounts, one per buyer and seller pair, opened on the escrow
+-- product the first time the pair uses escrow.
+CREATE TABLE escrow_accounts (
+    buyer_account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    seller_account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    account_id INTEGER NOT NULL UNIQUE REFERENCES accounts(account_id),
+    PRIMARY KEY (buyer_account_id, seller_account_id)
+);
+
+-- Amounts held in a pair's escrow account until released to the seller or
+-- refunded to the buyer. A settlement whose transfer was rejected, expired
+-- or failed can be made again.
+CREATE TABLE escrows (
+    id SERIAL PRIMARY KEY,
+    buyer_account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    seller_account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    escrow_account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    amount BIGINT NOT NULL CHECK (amount > 0), -- pennies
+    status TEXT NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'released', 'refunded')),
+    deadline TIMESTAMPTZ NOT NULL,
+    deadline_action TEXT NOT NULL CHECK (deadline_action IN ('release', 'refund')),
+    disputed_at TIMESTAMPTZ, -- set: only settled on request
+    dispute_reason TEXT NOT NULL DEFAULT '',
+    funding_transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+    settlement_transaction_id INTEGER UNIQUE REFERENCES transactions(id),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    settled_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrows_due ON escrows(deadline) WHERE status = 'held' AND disputed_at IS NULL;
+
+-- Customers' claims against completed transfers out of their accounts.
+-- The amount is credited from the suspense account when the dispute opens
+-- and, once it is won or lost, moved back into the suspense account from
+-- the counterparty or the customer.
+CREATE TABLE disputes (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id), -- the transfer's source
+    counterparty_account_id INTEGER NOT NULL REFERENCES accounts(account_id), -- its destination
+    amount BIGINT NOT NULL CHECK (amount > 0), -- pennies
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'under_review', 'won', 'lost')),
+    provisional_transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+    settlement_transaction_id INTEGER UNIQUE REFERENCES transactions(id),
+    opened_by TEXT NOT NULL,
+    resolved_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_disputes_status ON disputes(status);
+CREATE INDEX IF NOT EXISTS idx_disputes_account ON disputes(account_id);
+
+CREATE TABLE dispute_notes (
+    id SERIAL PRIMARY KEY,
+    dispute_id INTEGER NOT NULL REFERENCES disputes(id),
+    author TEXT NOT NULL,
+    note TEXT NOT NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
-CREATE INDEX IF NOT EXISTS idx_transactions_source ON transactions(source_account_id);
-CREATE INDEX IF NOT EXISTS idx_transactions_destination ON transactions(destination_account_id);
+CREATE INDEX IF NOT EXISTS idx_dispute_notes_dispute ON dispute_notes(dispute_id);
+
+-- Monthly fees charged and interest paid, at most one of each per account
+-- and month, recorded with the transfer that made it.
+CREATE TABLE account_charges (
+    account_id INTEGER NOT NULL REFERENCES accounts(account_id),
+    kind TEXT NOT NULL CHECK (kind IN ('monthly_fee', 'interest')),
+    month DATE NOT NULL, -- its first day
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+    PRIMARY KEY (account_id, kind, month)
+);
 
 -- Seed data
 
-INSERT INTO accounts (account_id, balance) VALUES
-    (123,  10023),
-    (456,  5000);
+INSERT INTO ledger_accounts (code, name, type, description) VALUES
+    ('1000', 'Settlement bank',    'asset',     'Funds held at the settlement bank'),
+    ('2000', 'Customer deposits',  'liability', 'Customer account balances'),
+    ('2100', 'Escrow deposits',    'liability', 'Funds held in escrow'),
+    ('3000', 'Opening balances',   'equity',    'Offsets the balances accounts were opened with'),
+    ('4000', 'Fee income',         'income',    ''),
+    ('5000', 'Operating expenses', 'expense',   '');
+
+INSERT INTO products (code, name, type) VALUES
+    ('checking', 'Checking account', 'checking'),
+    ('savings',  'Savings account',  'savings'),
+    ('business', 'Business account', 'business'),
+    ('escrow',   'Escrow account',   'escrow'),
+    ('internal', 'Internal account', 'internal');
+
+INSERT INTO product_versions (product_code, version, currency, overdraft_limit, unlimited_overdraft, monthly_fee, transfer_fee, interest_rate_bps, max_transfer, daily_transfer_limit, ledger_code) VALUES
+    ('checking', 1, 'GBP', 0,      false, 0,   0,  0,   NULL,    NULL,    '2000'),
+    ('savings',  1, 'GBP', 0,      false, 0,   0,  150, NULL,    500000,  '2000'),
+    ('business', 1, 'GBP', 100000, false, 500, 20, 0,   NULL,    NULL,    '2000'),
+    ('escrow',   1, 'GBP', 0,      false, 0,   0,  0,   NULL,    NULL,    '2100'),
+    ('internal', 1, 'GBP', 0,      true,  0,   0,  0,   NULL,    NULL,    '1000');
+
+INSERT INTO accounts (account_id, account_number, holder_name, balance, initial_balance, ledger_code, product_code, product_version, currency) VALUES
+    (123, 'FF17FAST4821930576', 'Alice Example', 10023, 11023, '2000', 'checking', 1, 'GBP'),
+    (456, 'FF14FAST7305618249', 'Bob Example',   5000,  4000,  '2000', 'checking', 1, 'GBP');
+
+SELECT setval(pg_get_serial_sequence('accounts', 'account_id'), (SELECT MAX(account_id) FROM accounts));
+
+INSERT INTO customers (name, email) VALUES
+    ('Alice Example', 'alice@example.com'),
+    ('Bob Example',   'bob@example.com');
+
+INSERT INTO account_holders (account_id, customer_id, role) VALUES
+    (123, 1, 'owner'),
+    (456, 2, 'owner'),
+    (456, 1, 'authorized_user');
 
-INSERT INTO transactions (source_account_id, destination_account_id, amount, status)
-VALUES (123, 456, 1000, 'completed');
+INSERT INTO transactions (source_account_id, destination_account_id, amount, status, completed_at)
+VALUES (123, 456, 1000, 'completed', NOW());
@@ -1,22 +1,31 @@
 package handlers
 
 import (
+	"errors"
 	"fastfunds/internal/models"
 	"fastfunds/internal/service"
+	"fastfunds/internal/util"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 )
 
 func NewAccountHandler(accountService service.IAccountService) *AccountHandler {
 	return &AccountHandler{
 		accountService: accountService,
+		keepAlive:      15 * time.Second,
 	}
 }
 
 type AccountHandler struct {
 	accountService service.IAccountService
+	// keepAlive is how long an event stream may stay silent before a
+	// comment is sent, so proxies do not close it as idle.
+	keepAlive time.Duration
 }
 
 // CreateAccount godoc
@@ -24,8 +33,9 @@ type AccountHandler struct {
 // @Accept json
 // @Produce json
 // @Param request body models.CreateAccountRequest true "Create account payload"
-// @Success 201
+// @Success 201 {object} models.AccountView
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /accounts [post]
 // @Tags accounts
 // Process account creation
@@ -36,38 +46,184 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
 		return
 	}
-	if err := h.accountService.CreateAccount(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	account, err := h.accountService.CreateAccount(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	c.JSON(http.StatusCreated, account)
+}
+
+// resolveAccount maps the account_number path value to the internal account ID,
+// writing a 400 for malformed numbers and a 404 for unknown ones.
+func (h *AccountHandler) resolveAccount(c *gin.Context) (int, bool) {
+	return resolveAccountNumber(c, h.accountService)
+}
+
+func resolveAccountNumber(c *gin.Context, accountService service.IAccountService) (int, bool) {
+	accountID, err := accountService.ResolveAccountNumber(c.Param("account_number"))
+	if err == nil {
+		return accountID, true
+	}
+	if errors.Is(err, util.ErrInvalidAccountNumber) || errors.Is(err, util.ErrAccountNumberCheckDigits) {
+		respondError(c, http.StatusBadRequest, err)
+	} else {
+		respondError(c, http.StatusNotFound, err)
+	}
+	return 0, false
 }
 
 // GetAccount godoc
-// @Summary Get account information by ID
+// @Summary Get account information by account number
 // @Produce json
-// @Param account_id path int true "Account ID"
+// @Param account_number path string true "Account number"
 // @Success 200 {object} models.AccountView
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
-// @Router /accounts/{account_id} [get]
+// @Router /accounts/{account_number} [get]
 // @Tags accounts
 // Get account
 func (h *AccountHandler) GetAccount(c *gin.Context) {
-	accountIDStr := c.Param("account_id")
+	accountID, ok := h.resolveAccount(c)
+	if !ok {
+		return
+	}
 
-	accountID, err := strconv.Atoi(accountIDStr)
+	account, err := h.accountService.GetAccount(c.Request.Context(), accountID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account_id format"})
+		respondError(c, http.StatusNotFound, err)
 		return
 	}
 
-	account, err := h.accountService.GetAccount(accountID)
+	c.JSON(http.StatusOK, account)
+}
+
+// AddHolder godoc
+// @Summary Link a customer to an account
+// @Accept json
+// @Produce json
+// @Param account_number path string true "Account number"
+// @Param request body models.AddAccountHolderRequest true "Holder payload (role: owner, joint, authorized_user)"
+// @Success 201 {object} models.AccountHolder
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /accounts/{account_number}/holders [post]
+// @Tags accounts
+func (h *AccountHandler) AddHolder(c *gin.Context) {
+	accountID, ok := h.resolveAccount(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddAccountHolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	holder, err := h.accountService.AddHolder(c.Request.Context(), accountID, &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
+	c.JSON(http.StatusCreated, holder)
+}
+
+// RemoveHolder godoc
+// @Summary Unlink a customer from an account
+// @Param account_number path string true "Account number"
+// @Param customer_id path int true "Customer ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /accounts/{account_number}/holders/{customer_id} [delete]
+// @Tags accounts
+func (h *AccountHandler) RemoveHolder(c *gin.Context) {
+	accountID, ok := h.resolveAccount(c)
+	if !ok {
+		return
+	}
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return
+	}
+
+	if err := h.accountService.RemoveHolder(c.Request.Context(), accountID, customerID); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// StreamEvents godoc
+// @Summary Stream account activity
+// @Description Server-sent events: `transfer` when a transfer touching the account is created or changes status, and `balance` when one moves funds.
+// @Description Each event's id can be sent back as Last-Event-ID (or last_event_id) on reconnect to resume; without it the stream starts with the next change.
+// @Produce text/event-stream
+// @Param account_number path string true "Account number"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query int false "Same as Last-Event-ID, for clients that cannot set headers"
+// @Success 200 {object} models.AccountActivity "data of each event"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /accounts/{account_number}/events [get]
+// @Tags accounts
+func (h *AccountHandler) StreamEvents(c *gin.Context) {
+	accountID, ok := h.resolveAccount(c)
+	if !ok {
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		afterID = id
+	}
+
+	ctx := c.Request.Context()
+	stream, err := h.accountService.StreamActivity(ctx, accountID, afterID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for {
+		batch, err := stream.Next(ctx, h.keepAlive)
+		if err != nil {
+			// The client reconnects with the last ID it saw
+			return
+		}
+		if len(batch) == 0 {
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		for _, a := range batch {
+			c.Render(-1, sse.Event{Id: strconv.FormatInt(a.ID, 10), Event: a.Type, Data: a})
+		}
+		c.Writer.Flush()
+	}
 }
@@ -2,34 +2,155 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"fastfunds/internal/util"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
+// testAccountNumber is a valid number under the default scheme.
+const testAccountNumber = "FF17FAST4821930576"
+
 type mockAccountService struct {
-	createFn func(*models.CreateAccountRequest) error
-	getFn    func(int) (*models.AccountView, error)
+	createFn       func(*models.CreateAccountRequest) (*models.AccountView, error)
+	resolveFn      func(string) (int, error)
+	getFn          func(int) (*models.AccountView, error)
+	addHolderFn    func(int, *models.AddAccountHolderRequest) (*models.AccountHolder, error)
+	removeHolderFn func(int, int) error
+	streamFn       func(int, int64) (service.ActivityStream, error)
 }
 
-func (m *mockAccountService) CreateAccount(req *models.CreateAccountRequest) error {
+func (m *mockAccountService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.AccountView, error) {
 	if m.createFn != nil {
 		return m.createFn(req)
 	}
-	return nil
+	return nil, nil
+}
+
+// ResolveAccountNumber defaults to real check-digit validation, resolving
+// every valid number to account 1.
+func (m *mockAccountService) ResolveAccountNumber(number string) (int, error) {
+	if m.resolveFn != nil {
+		return m.resolveFn(number)
+	}
+	if err := util.DefaultAccountNumberScheme().Validate(number); err != nil {
+		return 0, err
+	}
+	return 1, nil
 }
-func (m *mockAccountService) GetAccount(id int) (*models.AccountView, error) {
+func (m *mockAccountService) GetAccount(ctx context.Context, id int) (*models.AccountView, error) {
 	if m.getFn != nil {
 		return m.getFn(id)
 	}
 	return nil, nil
 }
 
+func (m *mockAccountService) AddHolder(ctx context.Context, id int, req *models.AddAccountHolderRequest) (*models.AccountHolder, error) {
+	if m.addHolderFn != nil {
+		return m.addHolderFn(id, req)
+	}
+	return nil, nil
+}
+func (m *mockAccountService) RemoveHolder(ctx context.Context, accountID, customerID int) error {
+	if m.removeHolderFn != nil {
+		return m.removeHolderFn(accountID, customerID)
+	}
+	return nil
+}
+
+func (m *mockAccountService) StreamActivity(ctx context.Context, accountID int, lastEventID int64) (service.ActivityStream, error) {
+	return m.streamFn(accountID, lastEventID)
+}
+
+// fakeActivityStream returns its batches in turn, then ends.
+type fakeActivityStream struct {
+	batches [][]*models.AccountActivity
+	closed  bool
+}
+
+func (f *fakeActivityStream) Next(ctx context.Context, wait time.Duration) ([]*models.AccountActivity, error) {
+	if len(f.batches) == 0 {
+		return nil, context.Canceled
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func (f *fakeActivityStream) Close() { f.closed = true }
+
+func TestStreamEventsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	balance := int64(800)
+	cases := []struct {
+		name       string
+		path       string
+		header     string
+		mockErr    error
+		wantCode   int
+		wantLastID int64
+		wantBody   []string
+	}{
+		{"invalid account number", "/accounts/FF00FAST0000000000/events", "", nil, http.StatusBadRequest, 0, []string{"error"}},
+		{"invalid last event id", "/accounts/" + testAccountNumber + "/events", "abc", nil, http.StatusBadRequest, 0, []string{"Invalid Last-Event-ID"}},
+		{"forbidden", "/accounts/" + testAccountNumber + "/events", "", service.ErrForbidden, http.StatusForbidden, 0, []string{"forbidden"}},
+		{"resume from header", "/accounts/" + testAccountNumber + "/events", "41", nil, http.StatusOK, 41, []string{
+			": keep-alive\n\n",
+			"id:42\nevent:transfer\ndata:{\"id\":42,\"type\":\"transfer\"",
+			"id:43\nevent:balance\ndata:{\"id\":43,\"type\":\"balance\"",
+			`"balance_pennies":800`,
+		}},
+		{"resume from query", "/accounts/" + testAccountNumber + "/events?last_event_id=7", "", nil, http.StatusOK, 7, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &fakeActivityStream{batches: [][]*models.AccountActivity{
+				nil,
+				{
+					{ID: 42, Type: models.ActivityTransfer, TransactionID: 9, Status: "completed", AmountPennies: -200},
+					{ID: 43, Type: models.ActivityBalance, TransactionID: 9, Status: "completed", AmountPennies: -200, BalancePennies: &balance},
+				},
+			}}
+			var gotLastID int64
+			mockSvc := &mockAccountService{streamFn: func(accountID int, lastEventID int64) (service.ActivityStream, error) {
+				gotLastID = lastEventID
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return stream, nil
+			}}
+			r := gin.Default()
+			r.GET("/accounts/:account_number/events", NewAccountHandler(mockSvc).StreamEvents)
+
+			req := httptest.NewRequest("GET", tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Last-Event-ID", tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantCode, w.Code)
+			for _, want := range tc.wantBody {
+				assert.Contains(t, w.Body.String(), want)
+			}
+			if tc.wantCode == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.Equal(t, tc.wantLastID, gotLastID)
+				assert.True(t, stream.closed)
+			}
+		})
+	}
+}
+
 func TestCreateAccountHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	cases := []struct {
@@ -40,12 +161,17 @@ func TestCreateAccountHandler(t *testing.T) {
 		wantBody string
 	}{
 		{"invalid json", "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
-		{"service error", models.CreateAccountRequest{AccountID: 1, InitialBalance: "10.00"}, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
-		{"success", models.CreateAccountRequest{AccountID: 2, InitialBalance: "20.00"}, nil, http.StatusCreated, ""},
+		{"service error", models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "10.00"}, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"success", models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "20.00"}, nil, http.StatusCreated, `"account_number":"` + testAccountNumber + `"`},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			mockSvc := &mockAccountService{createFn: func(req *models.CreateAccountRequest) error { return tc.mockErr }}
+			mockSvc := &mockAccountService{createFn: func(req *models.CreateAccountRequest) (*models.AccountView, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.AccountView{AccountID: 1, AccountNumber: testAccountNumber, HolderName: req.HolderName, CurrentBalance: req.InitialBalance}, nil
+			}}
 			h := NewAccountHandler(mockSvc)
 			r := gin.Default()
 			r.POST("/accounts", h.CreateAccount)
@@ -77,9 +203,11 @@ func TestGetAccountHandler(t *testing.T) {
 		wantCode int
 		wantBody string
 	}{
-		{"bad id", "abc", nil, nil, http.StatusBadRequest, "Invalid account_id format"},
-		{"not found", "123", nil, assert.AnError, http.StatusNotFound, assert.AnError.Error()},
-		{"success", "1", &models.AccountView{AccountID: 1, CurrentBalance: "10.00"}, nil, http.StatusOK, "10.00"},
+		{"bad format", "abc", nil, nil, http.StatusBadRequest, "invalid account number format"},
+		{"bad check digits", "FF18FAST4821930576", nil, nil, http.StatusBadRequest, "invalid account number check digits"},
+		{"not found", testAccountNumber, nil, assert.AnError, http.StatusNotFound, assert.AnError.Error()},
+		{"not a holder", testAccountNumber, nil, service.ErrForbidden, http.StatusForbidden, "forbidden"},
+		{"success", testAccountNumber, &models.AccountView{AccountID: 1, AccountNumber: testAccountNumber, CurrentBalance: "10.00"}, nil, http.StatusOK, "10.00"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -91,7 +219,7 @@ func TestGetAccountHandler(t *testing.T) {
 			}}
 			h := NewAccountHandler(mockSvc)
 			r := gin.Default()
-			r.GET("/accounts/:account_id", h.GetAccount)
+			r.GET("/accounts/:account_number", h.GetAccount)
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", "/accounts/"+tc.param, nil)
 			r.ServeHTTP(w, req)
@@ -102,3 +230,81 @@ func TestGetAccountHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAccountHandler_UnknownNumber(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAccountService{resolveFn: func(string) (int, error) { return 0, errors.New("account not found") }}
+	h := NewAccountHandler(mockSvc)
+	r := gin.Default()
+	r.GET("/accounts/:account_number", h.GetAccount)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/accounts/"+testAccountNumber, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "account not found")
+}
+
+func TestAddHolderHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		param    string
+		body     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad account number", "abc", `{"customer_id":1,"role":"owner"}`, nil, http.StatusBadRequest, "invalid account number format"},
+		{"invalid json", testAccountNumber, "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", testAccountNumber, `{"customer_id":1,"role":"boss"}`, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"success", testAccountNumber, `{"customer_id":7,"role":"joint"}`, nil, http.StatusCreated, `"role":"joint"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockAccountService{addHolderFn: func(id int, req *models.AddAccountHolderRequest) (*models.AccountHolder, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.AccountHolder{AccountID: id, CustomerID: req.CustomerID, Role: req.Role}, nil
+			}}
+			h := NewAccountHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/accounts/:account_number/holders", h.AddHolder)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/accounts/"+tc.param+"/holders", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestRemoveHolderHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad account number", "/accounts/abc/holders/1", nil, http.StatusBadRequest, "invalid account number format"},
+		{"bad customer id", "/accounts/" + testAccountNumber + "/holders/abc", nil, http.StatusBadRequest, "Invalid customer_id format"},
+		{"service error", "/accounts/" + testAccountNumber + "/holders/2", assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"success", "/accounts/" + testAccountNumber + "/holders/2", nil, http.StatusNoContent, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockAccountService{removeHolderFn: func(int, int) error { return tc.mockErr }}
+			h := NewAccountHandler(mockSvc)
+			r := gin.Default()
+			r.DELETE("/accounts/:account_number/holders/:customer_id", h.RemoveHolder)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
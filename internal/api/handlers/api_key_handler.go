@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewAPIKeyHandler(apiKeyService service.IAPIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type APIKeyHandler struct {
+	apiKeyService service.IAPIKeyService
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description The key is returned only in this response; store it securely.
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "Key name"
+// @Success 201 {object} models.IssuedAPIKey
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/api-keys [post]
+// @Tags admin
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/api-keys [get]
+// @Tags admin
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	list, err := h.apiKeyService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Issues a new secret for the key; the old one stops working immediately.
+// @Produce json
+// @Param api_key_id path int true "API key ID"
+// @Success 200 {object} models.IssuedAPIKey
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/api-keys/{api_key_id}/rotate [post]
+// @Tags admin
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	keyID, err := strconv.Atoi(c.Param("api_key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid api_key_id format"})
+		return
+	}
+
+	key, err := h.apiKeyService.RotateAPIKey(c.Request.Context(), keyID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Param api_key_id path int true "API key ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/api-keys/{api_key_id} [delete]
+// @Tags admin
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.Atoi(c.Param("api_key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid api_key_id format"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), keyID); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
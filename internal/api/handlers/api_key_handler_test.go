@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPIKeyService struct {
+	createFn func(*models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error)
+	listFn   func() ([]*models.APIKey, error)
+	rotateFn func(int) (*models.IssuedAPIKey, error)
+	revokeFn func(int) error
+}
+
+func (m *mockAPIKeyService) CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error) {
+	if m.createFn != nil {
+		return m.createFn(req)
+	}
+	return nil, nil
+}
+func (m *mockAPIKeyService) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	if m.listFn != nil {
+		return m.listFn()
+	}
+	return nil, nil
+}
+func (m *mockAPIKeyService) RotateAPIKey(ctx context.Context, id int) (*models.IssuedAPIKey, error) {
+	if m.rotateFn != nil {
+		return m.rotateFn(id)
+	}
+	return nil, nil
+}
+func (m *mockAPIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	if m.revokeFn != nil {
+		return m.revokeFn(id)
+	}
+	return nil
+}
+
+func TestCreateAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		body     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"invalid json", "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", `{"name":""}`, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"success", `{"name":"ops"}`, nil, http.StatusCreated, `"key":"ffk_`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockAPIKeyService{createFn: func(req *models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.IssuedAPIKey{APIKey: &models.APIKey{ID: 1, Name: req.Name, Hash: "secret-hash"}, Key: "ffk_abcdef012345_x"}, nil
+			}}
+			h := NewAPIKeyHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/admin/api-keys", h.CreateAPIKey)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admin/api-keys", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+			assert.NotContains(t, w.Body.String(), "secret-hash")
+		})
+	}
+}
+
+func TestRotateAndRevokeAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		mockErr  error
+		wantCode int
+	}{
+		{"rotate bad id", "POST", "/admin/api-keys/abc/rotate", nil, http.StatusBadRequest},
+		{"rotate error", "POST", "/admin/api-keys/1/rotate", assert.AnError, http.StatusBadRequest},
+		{"rotate", "POST", "/admin/api-keys/1/rotate", nil, http.StatusOK},
+		{"revoke bad id", "DELETE", "/admin/api-keys/abc", nil, http.StatusBadRequest},
+		{"revoke error", "DELETE", "/admin/api-keys/1", assert.AnError, http.StatusBadRequest},
+		{"revoke", "DELETE", "/admin/api-keys/1", nil, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockAPIKeyService{
+				rotateFn: func(id int) (*models.IssuedAPIKey, error) {
+					if tc.mockErr != nil {
+						return nil, tc.mockErr
+					}
+					return &models.IssuedAPIKey{APIKey: &models.APIKey{ID: id}, Key: "ffk_new"}, nil
+				},
+				revokeFn: func(int) error { return tc.mockErr },
+			}
+			h := NewAPIKeyHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/admin/api-keys/:api_key_id/rotate", h.RotateAPIKey)
+			r.DELETE("/admin/api-keys/:api_key_id", h.RevokeAPIKey)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+		})
+	}
+}
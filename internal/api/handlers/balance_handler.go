@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fastfunds/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewBalanceHandler(accountService service.IAccountService, balanceService service.IBalanceService) *BalanceHandler {
+	return &BalanceHandler{
+		accountService: accountService,
+		balanceService: balanceService,
+		nowFn:          time.Now,
+	}
+}
+
+type BalanceHandler struct {
+	accountService service.IAccountService
+	balanceService service.IBalanceService
+	nowFn          func() time.Time
+}
+
+// asOf reads the as_of query parameter: a date (UTC), meaning the end of that
+// day, or an RFC 3339 time. It defaults to now.
+func (h *BalanceHandler) asOf(c *gin.Context) (time.Time, bool) {
+	if c.Query("as_of") == "" {
+		return h.nowFn(), true
+	}
+	asOf, err := parseStatementTime(c.Query("as_of"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid as_of"})
+		return time.Time{}, false
+	}
+	return asOf, true
+}
+
+// GetBalance godoc
+// @Summary Get an account's balance at a point in time
+// @Description Counts every transfer completed before as_of. as_of takes a date (UTC), meaning the end of that day, or an RFC 3339 time, and defaults to now.
+// @Produce json
+// @Param account_number path string true "Account number"
+// @Param as_of query string false "Point in time"
+// @Success 200 {object} models.AccountBalance
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /accounts/{account_number}/balance [get]
+// @Tags accounts
+func (h *BalanceHandler) GetBalance(c *gin.Context) {
+	accountID, ok := resolveAccountNumber(c, h.accountService)
+	if !ok {
+		return
+	}
+	asOf, ok := h.asOf(c)
+	if !ok {
+		return
+	}
+
+	balance, err := h.balanceService.BalanceAt(c.Request.Context(), accountID, asOf)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// ListBalances godoc
+// @Summary Get every account's balance at a point in time
+// @Description Lists the accounts open at as_of with their balances then, as for a single account.
+// @Produce json
+// @Param as_of query string false "Point in time"
+// @Success 200 {array} models.AccountBalance
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /balances [get]
+// @Tags accounts
+func (h *BalanceHandler) ListBalances(c *gin.Context) {
+	asOf, ok := h.asOf(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.balanceService.ListBalancesAt(c.Request.Context(), asOf)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
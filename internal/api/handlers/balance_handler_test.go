@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBalanceService struct {
+	balanceAtFn func(int, time.Time) (*models.AccountBalance, error)
+	listFn      func(time.Time) ([]*models.AccountBalance, error)
+}
+
+func (m *mockBalanceService) BalanceAt(ctx context.Context, accountID int, asOf time.Time) (*models.AccountBalance, error) {
+	return m.balanceAtFn(accountID, asOf)
+}
+
+func (m *mockBalanceService) ListBalancesAt(ctx context.Context, asOf time.Time) ([]*models.AccountBalance, error) {
+	return m.listFn(asOf)
+}
+
+func TestGetBalanceHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var gotAsOf time.Time
+	mockSvc := &mockBalanceService{balanceAtFn: func(id int, asOf time.Time) (*models.AccountBalance, error) {
+		gotAsOf = asOf
+		if asOf.Year() < 2024 {
+			return nil, errors.New("account was opened after as_of")
+		}
+		return &models.AccountBalance{AccountNumber: testAccountNumber, AsOf: asOf.Format(time.RFC3339), Balance: "10.00"}, nil
+	}}
+	h := NewBalanceHandler(&mockAccountService{}, mockSvc)
+	h.nowFn = func() time.Time { return time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC) }
+	r := gin.Default()
+	r.GET("/accounts/:account_number/balance", h.GetBalance)
+
+	cases := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantAsOf time.Time
+	}{
+		{"now", "", http.StatusOK, time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC)},
+		{"month end", "?as_of=2024-01-31", http.StatusOK, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"time", "?as_of=2024-01-31T12:00:00Z", http.StatusOK, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"not open", "?as_of=2023-01-01", http.StatusBadRequest, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/accounts/"+testAccountNumber+"/balance"+tc.query, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.True(t, tc.wantAsOf.Equal(gotAsOf), gotAsOf)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/accounts/"+testAccountNumber+"/balance?as_of=yesterday", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid as_of")
+}
+
+func TestListBalancesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockBalanceService{listFn: func(asOf time.Time) ([]*models.AccountBalance, error) {
+		return []*models.AccountBalance{{AccountNumber: testAccountNumber, Balance: "1.00", BalancePennies: 100}}, nil
+	}}
+	h := NewBalanceHandler(&mockAccountService{}, mockSvc)
+	r := gin.Default()
+	r.GET("/balances", h.ListBalances)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/balances?as_of=2024-01-31", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"balance_pennies":100`)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fastfunds/internal/calendar"
+	"fastfunds/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewBusinessDayHandler(businessDayService service.IBusinessDayService) *BusinessDayHandler {
+	return &BusinessDayHandler{
+		businessDayService: businessDayService,
+	}
+}
+
+type BusinessDayHandler struct {
+	businessDayService service.IBusinessDayService
+}
+
+func parseDay(c *gin.Context) (time.Time, bool) {
+	d, err := time.Parse(calendar.DateLayout, c.Param("day"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day format, expected YYYY-MM-DD"})
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// CloseBusinessDay godoc
+// @Summary Close a business day
+// @Description Records every account's balances and debits and credits over the day, and refuses any later transfer completing inside it. The day must have passed its cut-off, and the business day before it must be closed.
+// @Produce json
+// @Param day path string true "Business day, YYYY-MM-DD"
+// @Success 200 {object} models.BusinessDay
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /business-days/{day}/close [post]
+// @Tags business days
+func (h *BusinessDayHandler) CloseBusinessDay(c *gin.Context) {
+	d, ok := parseDay(c)
+	if !ok {
+		return
+	}
+
+	day, err := h.businessDayService.CloseDay(c.Request.Context(), d)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, day)
+}
+
+// GetBusinessDay godoc
+// @Summary Get a closed business day
+// @Produce json
+// @Param day path string true "Business day, YYYY-MM-DD"
+// @Success 200 {object} models.BusinessDay
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /business-days/{day} [get]
+// @Tags business days
+func (h *BusinessDayHandler) GetBusinessDay(c *gin.Context) {
+	d, ok := parseDay(c)
+	if !ok {
+		return
+	}
+
+	day, err := h.businessDayService.GetBusinessDay(c.Request.Context(), d)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, day)
+}
+
+// ListDailyBalances godoc
+// @Summary List account balances over a closed business day
+// @Produce json
+// @Param day path string true "Business day, YYYY-MM-DD"
+// @Success 200 {array} models.DailyBalance
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /business-days/{day}/balances [get]
+// @Tags business days
+func (h *BusinessDayHandler) ListDailyBalances(c *gin.Context) {
+	d, ok := parseDay(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.businessDayService.ListDailyBalances(c.Request.Context(), d)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
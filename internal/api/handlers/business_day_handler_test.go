@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBusinessDayService struct {
+	closed map[string]*models.BusinessDay
+}
+
+func (m *mockBusinessDayService) CloseDay(ctx context.Context, d time.Time) (*models.BusinessDay, error) {
+	day := d.Format("2006-01-02")
+	if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		return nil, errors.New(day + " is not a business day")
+	}
+	m.closed[day] = &models.BusinessDay{Day: day, TransferCount: 1}
+	return m.closed[day], nil
+}
+
+func (m *mockBusinessDayService) GetBusinessDay(ctx context.Context, d time.Time) (*models.BusinessDay, error) {
+	day, ok := m.closed[d.Format("2006-01-02")]
+	if !ok {
+		return nil, errors.New("business day not closed")
+	}
+	return day, nil
+}
+
+func (m *mockBusinessDayService) ListDailyBalances(ctx context.Context, d time.Time) ([]*models.DailyBalance, error) {
+	if d.Year() < 2024 {
+		return nil, service.ErrForbidden
+	}
+	if _, err := m.GetBusinessDay(ctx, d); err != nil {
+		return nil, err
+	}
+	return []*models.DailyBalance{{Day: d.Format("2006-01-02"), AccountNumber: testAccountNumber, ClosingBalance: 100}}, nil
+}
+
+func TestBusinessDayHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBusinessDayHandler(&mockBusinessDayService{closed: map[string]*models.BusinessDay{}})
+	r := gin.Default()
+	r.POST("/business-days/:day/close", h.CloseBusinessDay)
+	r.GET("/business-days/:day", h.GetBusinessDay)
+	r.GET("/business-days/:day/balances", h.ListDailyBalances)
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"not closed", "GET", "/business-days/2024-03-01", http.StatusNotFound, "business day not closed"},
+		{"close", "POST", "/business-days/2024-03-01/close", http.StatusOK, `"day":"2024-03-01"`},
+		{"get", "GET", "/business-days/2024-03-01", http.StatusOK, `"transfer_count":1`},
+		{"balances", "GET", "/business-days/2024-03-01/balances", http.StatusOK, testAccountNumber},
+		{"weekend", "POST", "/business-days/2024-03-02/close", http.StatusBadRequest, "not a business day"},
+		{"bad day", "POST", "/business-days/03-01-2024/close", http.StatusBadRequest, "Invalid day format"},
+		{"forbidden", "GET", "/business-days/2023-03-01/balances", http.StatusForbidden, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewCustomerHandler(customerService service.ICustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: customerService,
+	}
+}
+
+type CustomerHandler struct {
+	customerService service.ICustomerService
+}
+
+// CreateCustomer godoc
+// @Summary Create customer
+// @Accept json
+// @Produce json
+// @Param request body models.CustomerRequest true "Customer payload"
+// @Success 201 {object} models.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /customers [post]
+// @Tags customers
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+	var req models.CustomerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	customer, err := h.customerService.CreateCustomer(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, customer)
+}
+
+// GetCustomer godoc
+// @Summary Get customer by ID
+// @Produce json
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {object} models.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{customer_id} [get]
+// @Tags customers
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return
+	}
+
+	customer, err := h.customerService.GetCustomer(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// UpdateCustomer godoc
+// @Summary Update customer
+// @Accept json
+// @Produce json
+// @Param customer_id path int true "Customer ID"
+// @Param request body models.CustomerRequest true "Customer payload"
+// @Success 200 {object} models.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /customers/{customer_id} [put]
+// @Tags customers
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return
+	}
+
+	var req models.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	customer, err := h.customerService.UpdateCustomer(c.Request.Context(), customerID, &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// ListCustomers godoc
+// @Summary List customers
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /customers [get]
+// @Tags customers
+func (h *CustomerHandler) ListCustomers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit format"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset format"})
+		return
+	}
+
+	list, err := h.customerService.ListCustomers(c.Request.Context(), limit, offset)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ListCustomerAccounts godoc
+// @Summary List the accounts a customer holds
+// @Produce json
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {array} models.CustomerAccountView
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{customer_id}/accounts [get]
+// @Tags customers
+func (h *CustomerHandler) ListCustomerAccounts(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return
+	}
+
+	accounts, err := h.customerService.ListCustomerAccounts(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
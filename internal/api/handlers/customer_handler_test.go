@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCustomerService struct {
+	createFn       func(*models.CustomerRequest) (*models.Customer, error)
+	getFn          func(int) (*models.Customer, error)
+	updateFn       func(int, *models.CustomerRequest) (*models.Customer, error)
+	listFn         func(int, int) ([]*models.Customer, error)
+	listAccountsFn func(int) ([]*models.CustomerAccountView, error)
+}
+
+func (m *mockCustomerService) CreateCustomer(ctx context.Context, req *models.CustomerRequest) (*models.Customer, error) {
+	if m.createFn != nil {
+		return m.createFn(req)
+	}
+	return nil, nil
+}
+func (m *mockCustomerService) GetCustomer(ctx context.Context, id int) (*models.Customer, error) {
+	if m.getFn != nil {
+		return m.getFn(id)
+	}
+	return nil, nil
+}
+func (m *mockCustomerService) UpdateCustomer(ctx context.Context, id int, req *models.CustomerRequest) (*models.Customer, error) {
+	if m.updateFn != nil {
+		return m.updateFn(id, req)
+	}
+	return nil, nil
+}
+func (m *mockCustomerService) ListCustomers(ctx context.Context, limit, offset int) ([]*models.Customer, error) {
+	if m.listFn != nil {
+		return m.listFn(limit, offset)
+	}
+	return nil, nil
+}
+func (m *mockCustomerService) ListCustomerAccounts(ctx context.Context, id int) ([]*models.CustomerAccountView, error) {
+	if m.listAccountsFn != nil {
+		return m.listAccountsFn(id)
+	}
+	return nil, nil
+}
+
+func newCustomerRouter(svc *mockCustomerService) *gin.Engine {
+	h := NewCustomerHandler(svc)
+	r := gin.Default()
+	r.POST("/customers", h.CreateCustomer)
+	r.GET("/customers", h.ListCustomers)
+	r.GET("/customers/:customer_id", h.GetCustomer)
+	r.PUT("/customers/:customer_id", h.UpdateCustomer)
+	r.GET("/customers/:customer_id/accounts", h.ListCustomerAccounts)
+	return r
+}
+
+func TestCustomerHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ok := &mockCustomerService{
+		createFn: func(req *models.CustomerRequest) (*models.Customer, error) {
+			return &models.Customer{ID: 1, Name: req.Name}, nil
+		},
+		getFn: func(id int) (*models.Customer, error) { return &models.Customer{ID: id, Name: "Jane"}, nil },
+		updateFn: func(id int, req *models.CustomerRequest) (*models.Customer, error) {
+			return &models.Customer{ID: id, Name: req.Name}, nil
+		},
+		listFn: func(limit, offset int) ([]*models.Customer, error) {
+			assert.Equal(t, 10, limit)
+			assert.Equal(t, 20, offset)
+			return []*models.Customer{{ID: 1}}, nil
+		},
+		listAccountsFn: func(id int) ([]*models.CustomerAccountView, error) {
+			return []*models.CustomerAccountView{{AccountID: 123, Role: models.HolderRoleJoint}}, nil
+		},
+	}
+	failing := &mockCustomerService{
+		createFn:       func(*models.CustomerRequest) (*models.Customer, error) { return nil, assert.AnError },
+		getFn:          func(int) (*models.Customer, error) { return nil, assert.AnError },
+		updateFn:       func(int, *models.CustomerRequest) (*models.Customer, error) { return nil, assert.AnError },
+		listFn:         func(int, int) ([]*models.Customer, error) { return nil, assert.AnError },
+		listAccountsFn: func(int) ([]*models.CustomerAccountView, error) { return nil, assert.AnError },
+	}
+
+	cases := []struct {
+		name     string
+		svc      *mockCustomerService
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"create invalid json", ok, "POST", "/customers", "notjson", http.StatusBadRequest, "Invalid JSON format"},
+		{"create service error", failing, "POST", "/customers", `{"name":""}`, http.StatusBadRequest, assert.AnError.Error()},
+		{"create success", ok, "POST", "/customers", `{"name":"Jane"}`, http.StatusCreated, `"name":"Jane"`},
+		{"get bad id", ok, "GET", "/customers/abc", "", http.StatusBadRequest, "Invalid customer_id format"},
+		{"get not found", failing, "GET", "/customers/9", "", http.StatusNotFound, assert.AnError.Error()},
+		{"get success", ok, "GET", "/customers/3", "", http.StatusOK, `"id":3`},
+		{"update bad id", ok, "PUT", "/customers/abc", `{"name":"x"}`, http.StatusBadRequest, "Invalid customer_id format"},
+		{"update invalid json", ok, "PUT", "/customers/3", "notjson", http.StatusBadRequest, "Invalid JSON format"},
+		{"update service error", failing, "PUT", "/customers/3", `{"name":"x"}`, http.StatusBadRequest, assert.AnError.Error()},
+		{"update success", ok, "PUT", "/customers/3", `{"name":"Janet"}`, http.StatusOK, `"name":"Janet"`},
+		{"list bad limit", ok, "GET", "/customers?limit=x", "", http.StatusBadRequest, "Invalid limit format"},
+		{"list bad offset", ok, "GET", "/customers?offset=x", "", http.StatusBadRequest, "Invalid offset format"},
+		{"list service error", failing, "GET", "/customers", "", http.StatusBadRequest, assert.AnError.Error()},
+		{"list success", ok, "GET", "/customers?limit=10&offset=20", "", http.StatusOK, `"id":1`},
+		{"accounts bad id", ok, "GET", "/customers/abc/accounts", "", http.StatusBadRequest, "Invalid customer_id format"},
+		{"accounts not found", failing, "GET", "/customers/9/accounts", "", http.StatusNotFound, assert.AnError.Error()},
+		{"accounts success", ok, "GET", "/customers/3/accounts", "", http.StatusOK, `"role":"joint"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newCustomerRouter(tc.svc)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewDisputeHandler(disputeService service.IDisputeService) *DisputeHandler {
+	return &DisputeHandler{disputeService: disputeService}
+}
+
+type DisputeHandler struct {
+	disputeService service.IDisputeService
+}
+
+// OpenDispute godoc
+// @Summary Dispute a transfer
+// @Description Opens a dispute on a completed transfer out of the caller's account and credits the account the amount from the suspense account straight away. A credit held for screening or waiting for approval must complete before the dispute can be resolved. A transfer can be disputed once.
+// @Accept json
+// @Produce json
+// @Param request body models.DisputeRequest true "Dispute"
+// @Success 201 {object} models.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /disputes [post]
+// @Tags disputes
+func (h *DisputeHandler) OpenDispute(c *gin.Context) {
+	var req models.DisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	d, err := h.disputeService.OpenDispute(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, d)
+}
+
+// ListDisputes godoc
+// @Summary List disputes
+// @Produce json
+// @Param status query string false "Filter by status (open, under_review, won, lost)"
+// @Success 200 {array} models.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /disputes [get]
+// @Tags disputes
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	list, err := h.disputeService.ListDisputes(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetDispute godoc
+// @Summary Get a dispute with its notes
+// @Description provisional_status and settlement_status are the statuses of the credit from the suspense account and of the transfer that settled the dispute.
+// @Produce json
+// @Param dispute_id path int true "Dispute ID"
+// @Success 200 {object} models.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /disputes/{dispute_id} [get]
+// @Tags disputes
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	id, ok := disputeID(c)
+	if !ok {
+		return
+	}
+
+	d, err := h.disputeService.GetDispute(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+// AddDisputeNote godoc
+// @Summary Add evidence to a dispute
+// @Description Adds a note to a dispute that hasn't been resolved.
+// @Accept json
+// @Produce json
+// @Param dispute_id path int true "Dispute ID"
+// @Param request body models.DisputeNoteRequest true "Note"
+// @Success 201 {object} models.DisputeNote
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /disputes/{dispute_id}/notes [post]
+// @Tags disputes
+func (h *DisputeHandler) AddDisputeNote(c *gin.Context) {
+	id, ok := disputeID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DisputeNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	n, err := h.disputeService.AddNote(c.Request.Context(), id, req.Note)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, n)
+}
+
+// ReviewDispute godoc
+// @Summary Put a dispute under review
+// @Produce json
+// @Param dispute_id path int true "Dispute ID"
+// @Success 200 {object} models.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /disputes/{dispute_id}/review [post]
+// @Tags disputes
+func (h *DisputeHandler) ReviewDispute(c *gin.Context) {
+	id, ok := disputeID(c)
+	if !ok {
+		return
+	}
+
+	d, err := h.disputeService.ReviewDispute(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+// ResolveDispute godoc
+// @Summary Resolve a dispute
+// @Description Settles a dispute once its provisional credit has completed. A won dispute charges the counterparty back and a lost one reverses the credit, each by a transfer into the suspense account. The transfer completes even if it overdraws the account charged, beyond any overdraft limit: the shortfall is what the customer or counterparty owes. A dispute whose settlement transfer was rejected, expired or failed can be resolved again.
+// @Accept json
+// @Produce json
+// @Param dispute_id path int true "Dispute ID"
+// @Param request body models.DisputeResolution true "Outcome"
+// @Success 200 {object} models.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /disputes/{dispute_id}/resolve [post]
+// @Tags disputes
+func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
+	id, ok := disputeID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DisputeResolution
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	d, err := h.disputeService.ResolveDispute(c.Request.Context(), id, req.Outcome)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+func disputeID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("dispute_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute_id format"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDisputeService struct{}
+
+func (m *mockDisputeService) dispute(id int, status string) (*models.Dispute, error) {
+	if id != 1 {
+		return nil, errors.New("dispute not found")
+	}
+	return &models.Dispute{ID: id, TransactionID: 10, AmountPennies: 400, Status: status}, nil
+}
+
+func (m *mockDisputeService) OpenDispute(ctx context.Context, req *models.DisputeRequest) (*models.Dispute, error) {
+	if req.Reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	return m.dispute(1, models.DisputeOpen)
+}
+
+func (m *mockDisputeService) GetDispute(ctx context.Context, id int) (*models.Dispute, error) {
+	return m.dispute(id, models.DisputeOpen)
+}
+
+func (m *mockDisputeService) ListDisputes(ctx context.Context, status string) ([]*models.Dispute, error) {
+	if status == "closed" {
+		return nil, errors.New("status must be open, under_review, won or lost")
+	}
+	d, _ := m.dispute(1, models.DisputeOpen)
+	return []*models.Dispute{d}, nil
+}
+
+func (m *mockDisputeService) AddNote(ctx context.Context, id int, note string) (*models.DisputeNote, error) {
+	if _, err := m.dispute(id, models.DisputeOpen); err != nil {
+		return nil, err
+	}
+	return &models.DisputeNote{ID: 1, DisputeID: id, Note: note}, nil
+}
+
+func (m *mockDisputeService) ReviewDispute(ctx context.Context, id int) (*models.Dispute, error) {
+	return m.dispute(id, models.DisputeUnderReview)
+}
+
+func (m *mockDisputeService) ResolveDispute(ctx context.Context, id int, outcome string) (*models.Dispute, error) {
+	if outcome != models.DisputeWon && outcome != models.DisputeLost {
+		return nil, errors.New("outcome must be won or lost")
+	}
+	return m.dispute(id, outcome)
+}
+
+func TestDisputeHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewDisputeHandler(&mockDisputeService{})
+	r := gin.Default()
+	r.POST("/disputes", h.OpenDispute)
+	r.GET("/disputes", h.ListDisputes)
+	r.GET("/disputes/:dispute_id", h.GetDispute)
+	r.POST("/disputes/:dispute_id/notes", h.AddDisputeNote)
+	r.POST("/disputes/:dispute_id/review", h.ReviewDispute)
+	r.POST("/disputes/:dispute_id/resolve", h.ResolveDispute)
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"open", "POST", "/disputes", `{"transaction_id":10,"reason":"not received"}`, http.StatusCreated, `"status":"open"`},
+		{"open invalid", "POST", "/disputes", `{"transaction_id":10}`, http.StatusBadRequest, "reason is required"},
+		{"bad json", "POST", "/disputes", `{`, http.StatusBadRequest, "Invalid JSON format"},
+		{"list", "GET", "/disputes?status=open", "", http.StatusOK, `"transaction_id":10`},
+		{"list bad status", "GET", "/disputes?status=closed", "", http.StatusBadRequest, "status must be"},
+		{"get", "GET", "/disputes/1", "", http.StatusOK, `"amount_pennies":400`},
+		{"not found", "GET", "/disputes/2", "", http.StatusNotFound, "dispute not found"},
+		{"bad id", "GET", "/disputes/abc", "", http.StatusBadRequest, "Invalid dispute_id format"},
+		{"note", "POST", "/disputes/1/notes", `{"note":"receipt attached"}`, http.StatusCreated, `"note":"receipt attached"`},
+		{"note bad json", "POST", "/disputes/1/notes", `note`, http.StatusBadRequest, "Invalid JSON format"},
+		{"review", "POST", "/disputes/1/review", "", http.StatusOK, `"status":"under_review"`},
+		{"resolve", "POST", "/disputes/1/resolve", `{"outcome":"won"}`, http.StatusOK, `"status":"won"`},
+		{"resolve invalid", "POST", "/disputes/1/resolve", `{"outcome":"maybe"}`, http.StatusBadRequest, "outcome must be won or lost"},
+		{"resolve unknown", "POST", "/disputes/2/resolve", `{"outcome":"lost"}`, http.StatusBadRequest, "dispute not found"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"errors"
+	"fastfunds/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError writes a service error with the given status, except that
+// authorization failures always map to 401 and 403, and errors of a service
+// kind to 404, 409 and 500.
+func respondError(c *gin.Context, status int, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		status = http.StatusUnauthorized
+	case errors.Is(err, service.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrFailedPrecondition):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInternal):
+		status = http.StatusInternalServerError
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewEscrowHandler(escrowService service.IEscrowService) *EscrowHandler {
+	return &EscrowHandler{escrowService: escrowService}
+}
+
+type EscrowHandler struct {
+	escrowService service.IEscrowService
+}
+
+// CreateEscrow godoc
+// @Summary Pay into escrow for a seller
+// @Description Moves the amount from the buyer's account into the escrow account of the buyer and seller, with the rules of POST /transactions, opening it the first time the pair uses escrow. At the deadline (RFC 3339) the escrow is released to the seller, or refunded to the buyer when deadline_action is refund, unless it is disputed. A transfer held for screening or waiting for approval leaves the escrow unfunded until it completes.
+// @Accept json
+// @Produce json
+// @Param request body models.EscrowRequest true "Escrow"
+// @Success 201 {object} models.Escrow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /escrows [post]
+// @Tags escrows
+func (h *EscrowHandler) CreateEscrow(c *gin.Context) {
+	var req models.EscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	e, err := h.escrowService.CreateEscrow(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, e)
+}
+
+// GetEscrow godoc
+// @Summary Get an escrow
+// @Description status is held until the escrow is released or refunded. funding_status and settlement_status are the statuses of the transfers into and out of escrow.
+// @Produce json
+// @Param escrow_id path int true "Escrow ID"
+// @Success 200 {object} models.Escrow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /escrows/{escrow_id} [get]
+// @Tags escrows
+func (h *EscrowHandler) GetEscrow(c *gin.Context) {
+	id, ok := escrowID(c)
+	if !ok {
+		return
+	}
+
+	e, err := h.escrowService.GetEscrow(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e)
+}
+
+// ReleaseEscrow godoc
+// @Summary Release an escrow to the seller
+// @Description Settles a held escrow, disputed or not, once its funding transfer has completed. An escrow whose settlement transfer was rejected, expired or failed can be settled again.
+// @Produce json
+// @Param escrow_id path int true "Escrow ID"
+// @Success 200 {object} models.Escrow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /escrows/{escrow_id}/release [post]
+// @Tags escrows
+func (h *EscrowHandler) ReleaseEscrow(c *gin.Context) {
+	id, ok := escrowID(c)
+	if !ok {
+		return
+	}
+
+	e, err := h.escrowService.ReleaseEscrow(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e)
+}
+
+// RefundEscrow godoc
+// @Summary Refund an escrow to the buyer
+// @Description Settles a held escrow, disputed or not, once its funding transfer has completed. An escrow whose settlement transfer was rejected, expired or failed can be settled again.
+// @Produce json
+// @Param escrow_id path int true "Escrow ID"
+// @Success 200 {object} models.Escrow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /escrows/{escrow_id}/refund [post]
+// @Tags escrows
+func (h *EscrowHandler) RefundEscrow(c *gin.Context) {
+	id, ok := escrowID(c)
+	if !ok {
+		return
+	}
+
+	e, err := h.escrowService.RefundEscrow(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e)
+}
+
+// DisputeEscrow godoc
+// @Summary Dispute an escrow
+// @Description Stops a held escrow from being settled at its deadline. It stays held until it is released or refunded on request.
+// @Accept json
+// @Produce json
+// @Param escrow_id path int true "Escrow ID"
+// @Param request body models.EscrowDisputeRequest true "Dispute"
+// @Success 200 {object} models.Escrow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /escrows/{escrow_id}/dispute [post]
+// @Tags escrows
+func (h *EscrowHandler) DisputeEscrow(c *gin.Context) {
+	id, ok := escrowID(c)
+	if !ok {
+		return
+	}
+
+	var req models.EscrowDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	e, err := h.escrowService.DisputeEscrow(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e)
+}
+
+func escrowID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("escrow_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid escrow_id format"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEscrowService struct{}
+
+func (m *mockEscrowService) escrow(id int, status string) (*models.Escrow, error) {
+	if id != 1 {
+		return nil, errors.New("escrow not found")
+	}
+	return &models.Escrow{ID: id, AmountPennies: 400, Status: status}, nil
+}
+
+func (m *mockEscrowService) CreateEscrow(ctx context.Context, req *models.EscrowRequest) (*models.Escrow, error) {
+	if req.Deadline == "" {
+		return nil, errors.New("deadline must be an RFC 3339 time")
+	}
+	return m.escrow(1, models.EscrowHeld)
+}
+
+func (m *mockEscrowService) GetEscrow(ctx context.Context, id int) (*models.Escrow, error) {
+	return m.escrow(id, models.EscrowHeld)
+}
+
+func (m *mockEscrowService) DisputeEscrow(ctx context.Context, id int, reason string) (*models.Escrow, error) {
+	e, err := m.escrow(id, models.EscrowHeld)
+	if err == nil {
+		e.DisputeReason = reason
+	}
+	return e, err
+}
+
+func (m *mockEscrowService) ReleaseEscrow(ctx context.Context, id int) (*models.Escrow, error) {
+	return m.escrow(id, models.EscrowReleased)
+}
+
+func (m *mockEscrowService) RefundEscrow(ctx context.Context, id int) (*models.Escrow, error) {
+	return m.escrow(id, models.EscrowRefunded)
+}
+
+func TestEscrowHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewEscrowHandler(&mockEscrowService{})
+	r := gin.Default()
+	r.POST("/escrows", h.CreateEscrow)
+	r.GET("/escrows/:escrow_id", h.GetEscrow)
+	r.POST("/escrows/:escrow_id/release", h.ReleaseEscrow)
+	r.POST("/escrows/:escrow_id/refund", h.RefundEscrow)
+	r.POST("/escrows/:escrow_id/dispute", h.DisputeEscrow)
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"create", "POST", "/escrows", `{"buyer_account_number":"A","seller_account_number":"B","amount":"4.00","deadline":"2024-03-08T12:00:00Z"}`,
+			http.StatusCreated, `"status":"held"`},
+		{"create invalid", "POST", "/escrows", `{"buyer_account_number":"A"}`, http.StatusBadRequest, "deadline must be"},
+		{"bad json", "POST", "/escrows", `{`, http.StatusBadRequest, "Invalid JSON format"},
+		{"get", "GET", "/escrows/1", "", http.StatusOK, `"amount_pennies":400`},
+		{"not found", "GET", "/escrows/2", "", http.StatusNotFound, "escrow not found"},
+		{"bad id", "GET", "/escrows/abc", "", http.StatusBadRequest, "Invalid escrow_id format"},
+		{"release", "POST", "/escrows/1/release", "", http.StatusOK, `"status":"released"`},
+		{"refund", "POST", "/escrows/1/refund", "", http.StatusOK, `"status":"refunded"`},
+		{"refund unknown", "POST", "/escrows/2/refund", "", http.StatusBadRequest, "escrow not found"},
+		{"dispute", "POST", "/escrows/1/dispute", `{"reason":"not delivered"}`, http.StatusOK, `"dispute_reason":"not delivered"`},
+		{"dispute bad json", "POST", "/escrows/1/dispute", `reason`, http.StatusBadRequest, "Invalid JSON format"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
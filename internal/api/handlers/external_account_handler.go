@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewExternalAccountHandler(externalAccountService service.IExternalAccountService) *ExternalAccountHandler {
+	return &ExternalAccountHandler{
+		externalAccountService: externalAccountService,
+	}
+}
+
+type ExternalAccountHandler struct {
+	externalAccountService service.IExternalAccountService
+}
+
+// ValidateDetails godoc
+// @Summary Validate bank details without registering them
+// @Accept json
+// @Produce json
+// @Param request body models.ExternalAccountRequest true "Bank details (type: iban, uk, us)"
+// @Success 200 {object} models.ExternalAccount
+// @Failure 400 {object} map[string]string
+// @Router /external-accounts/validate [post]
+// @Tags external-accounts
+func (h *ExternalAccountHandler) ValidateDetails(c *gin.Context) {
+	var req models.ExternalAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	details, err := h.externalAccountService.ValidateDetails(&req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// RegisterExternalAccount godoc
+// @Summary Register a payout destination for a customer
+// @Accept json
+// @Produce json
+// @Param customer_id path int true "Customer ID"
+// @Param request body models.ExternalAccountRequest true "Bank details (type: iban, uk, us)"
+// @Success 201 {object} models.ExternalAccount
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /customers/{customer_id}/external-accounts [post]
+// @Tags external-accounts
+func (h *ExternalAccountHandler) RegisterExternalAccount(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return
+	}
+
+	var req models.ExternalAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	external, err := h.externalAccountService.RegisterExternalAccount(c.Request.Context(), customerID, &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, external)
+}
+
+// ListExternalAccounts godoc
+// @Summary List a customer's payout destinations
+// @Produce json
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {array} models.ExternalAccount
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{customer_id}/external-accounts [get]
+// @Tags external-accounts
+func (h *ExternalAccountHandler) ListExternalAccounts(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return
+	}
+
+	list, err := h.externalAccountService.ListExternalAccounts(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetExternalAccount godoc
+// @Summary Get a customer's payout destination
+// @Produce json
+// @Param customer_id path int true "Customer ID"
+// @Param external_account_id path int true "External account ID"
+// @Success 200 {object} models.ExternalAccount
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{customer_id}/external-accounts/{external_account_id} [get]
+// @Tags external-accounts
+func (h *ExternalAccountHandler) GetExternalAccount(c *gin.Context) {
+	customerID, externalID, ok := externalAccountParams(c)
+	if !ok {
+		return
+	}
+
+	external, err := h.externalAccountService.GetExternalAccount(c.Request.Context(), customerID, externalID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, external)
+}
+
+// RemoveExternalAccount godoc
+// @Summary Remove a customer's payout destination
+// @Param customer_id path int true "Customer ID"
+// @Param external_account_id path int true "External account ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /customers/{customer_id}/external-accounts/{external_account_id} [delete]
+// @Tags external-accounts
+func (h *ExternalAccountHandler) RemoveExternalAccount(c *gin.Context) {
+	customerID, externalID, ok := externalAccountParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.externalAccountService.RemoveExternalAccount(c.Request.Context(), customerID, externalID); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func externalAccountParams(c *gin.Context) (int, int, bool) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer_id format"})
+		return 0, 0, false
+	}
+	externalID, err := strconv.Atoi(c.Param("external_account_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid external_account_id format"})
+		return 0, 0, false
+	}
+	return customerID, externalID, true
+}
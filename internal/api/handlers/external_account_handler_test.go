@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockExternalAccountService struct {
+	validateFn func(*models.ExternalAccountRequest) (*models.ExternalAccount, error)
+	registerFn func(int, *models.ExternalAccountRequest) (*models.ExternalAccount, error)
+	getFn      func(int, int) (*models.ExternalAccount, error)
+	listFn     func(int) ([]*models.ExternalAccount, error)
+	removeFn   func(int, int) error
+}
+
+func (m *mockExternalAccountService) ValidateDetails(req *models.ExternalAccountRequest) (*models.ExternalAccount, error) {
+	if m.validateFn != nil {
+		return m.validateFn(req)
+	}
+	return nil, nil
+}
+func (m *mockExternalAccountService) RegisterExternalAccount(ctx context.Context, customerID int, req *models.ExternalAccountRequest) (*models.ExternalAccount, error) {
+	if m.registerFn != nil {
+		return m.registerFn(customerID, req)
+	}
+	return nil, nil
+}
+func (m *mockExternalAccountService) GetExternalAccount(ctx context.Context, customerID, id int) (*models.ExternalAccount, error) {
+	if m.getFn != nil {
+		return m.getFn(customerID, id)
+	}
+	return nil, nil
+}
+func (m *mockExternalAccountService) ListExternalAccounts(ctx context.Context, customerID int) ([]*models.ExternalAccount, error) {
+	if m.listFn != nil {
+		return m.listFn(customerID)
+	}
+	return nil, nil
+}
+func (m *mockExternalAccountService) RemoveExternalAccount(ctx context.Context, customerID, id int) error {
+	if m.removeFn != nil {
+		return m.removeFn(customerID, id)
+	}
+	return nil
+}
+
+func TestRegisterExternalAccountHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		body     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad customer id", "/customers/abc/external-accounts", `{}`, nil, http.StatusBadRequest, "Invalid customer_id format"},
+		{"invalid json", "/customers/1/external-accounts", "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", "/customers/1/external-accounts", `{"type":"iban"}`, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"success", "/customers/1/external-accounts", `{"type":"iban","holder_name":"Jane","iban":"GB82WEST12345698765432"}`, nil, http.StatusCreated, `"iban":"GB82WEST12345698765432"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockExternalAccountService{registerFn: func(customerID int, req *models.ExternalAccountRequest) (*models.ExternalAccount, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.ExternalAccount{ID: 1, CustomerID: customerID, Type: req.Type, IBAN: req.IBAN}, nil
+			}}
+			h := NewExternalAccountHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/customers/:customer_id/external-accounts", h.RegisterExternalAccount)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tc.path, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestValidateDetailsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockExternalAccountService{validateFn: func(req *models.ExternalAccountRequest) (*models.ExternalAccount, error) {
+		if req.Type != "uk" {
+			return nil, assert.AnError
+		}
+		return &models.ExternalAccount{Type: req.Type, SortCode: "089999"}, nil
+	}}
+	h := NewExternalAccountHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/external-accounts/validate", h.ValidateDetails)
+
+	for body, wantCode := range map[string]int{
+		`{"type":"uk","sort_code":"08-99-99"}`: http.StatusOK,
+		`{"type":"us"}`:                        http.StatusBadRequest,
+		"notjson":                              http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/external-accounts/validate", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, wantCode, w.Code, body)
+	}
+}
+
+func TestGetExternalAccountHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad customer id", "/customers/abc/external-accounts/1", nil, http.StatusBadRequest, "Invalid customer_id format"},
+		{"bad id", "/customers/1/external-accounts/abc", nil, http.StatusBadRequest, "Invalid external_account_id format"},
+		{"not found", "/customers/1/external-accounts/2", assert.AnError, http.StatusNotFound, assert.AnError.Error()},
+		{"success", "/customers/1/external-accounts/2", nil, http.StatusOK, `"id":2`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockExternalAccountService{getFn: func(customerID, id int) (*models.ExternalAccount, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.ExternalAccount{ID: id, CustomerID: customerID}, nil
+			}}
+			h := NewExternalAccountHandler(mockSvc)
+			r := gin.Default()
+			r.GET("/customers/:customer_id/external-accounts/:external_account_id", h.GetExternalAccount)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestRemoveExternalAccountHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		mockErr  error
+		wantCode int
+	}{
+		{"bad id", "/customers/1/external-accounts/abc", nil, http.StatusBadRequest},
+		{"service error", "/customers/1/external-accounts/2", assert.AnError, http.StatusBadRequest},
+		{"success", "/customers/1/external-accounts/2", nil, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockExternalAccountService{removeFn: func(int, int) error { return tc.mockErr }}
+			h := NewExternalAccountHandler(mockSvc)
+			r := gin.Default()
+			r.DELETE("/customers/:customer_id/external-accounts/:external_account_id", h.RemoveExternalAccount)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fastfunds/internal/imports"
+	"fastfunds/internal/service"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileBytes bounds an uploaded transfer file.
+const maxImportFileBytes = 10 << 20
+
+func NewImportHandler(importService service.IImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+type ImportHandler struct {
+	importService service.IImportService
+}
+
+// CreateImport godoc
+// @Summary Upload a transfer file for a dry run
+// @Description Accepts a CSV with a header naming the columns source, destination, amount and optionally reference, either as the "file" field of a multipart form or as a text/csv body. Every row is validated as POST /transactions would; nothing is transferred until the import is confirmed. The response is the dry-run report.
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Param file formData file false "Transfer CSV"
+// @Success 201 {object} models.TransferImport
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /imports [post]
+// @Tags imports
+func (h *ImportHandler) CreateImport(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileBytes)
+
+	var body io.Reader = c.Request.Body
+	fileName := ""
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			if !errors.As(err, new(*http.MaxBytesError)) {
+				err = errors.New("file is required")
+			}
+			respondUploadError(c, err)
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "couldn't read file"})
+			return
+		}
+		defer f.Close()
+		body, fileName = f, fh.Filename
+	}
+
+	imp, err := h.importService.CreateImport(c.Request.Context(), fileName, body)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, imp)
+}
+
+// respondUploadError answers 413 when the upload was too large and as
+// respondError with 400 otherwise.
+func respondUploadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file is larger than %d bytes", tooLarge.Limit)})
+		return
+	}
+	respondError(c, http.StatusBadRequest, err)
+}
+
+// GetImport godoc
+// @Summary Get a transfer import's progress
+// @Description row_statuses counts the rows in each status: valid or invalid after the dry run, then pending, created or failed once confirmed.
+// @Produce json
+// @Param import_id path int true "Import ID"
+// @Success 200 {object} models.TransferImport
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /imports/{import_id} [get]
+// @Tags imports
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	id, ok := importID(c)
+	if !ok {
+		return
+	}
+
+	imp, err := h.importService.GetImport(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, imp)
+}
+
+// ConfirmImport godoc
+// @Summary Execute a validated transfer import
+// @Description Queues every row for execution on behalf of the caller; GET /imports/{import_id} shows progress. Imports with invalid rows can't be confirmed.
+// @Produce json
+// @Param import_id path int true "Import ID"
+// @Success 202 {object} models.TransferImport
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /imports/{import_id}/confirm [post]
+// @Tags imports
+func (h *ImportHandler) ConfirmImport(c *gin.Context) {
+	id, ok := importID(c)
+	if !ok {
+		return
+	}
+
+	imp, err := h.importService.ConfirmImport(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, imp)
+}
+
+// GetImportResults godoc
+// @Summary Download a transfer import's results
+// @Description A CSV with one line per row: its status, the transfer created for it and its status, or why it is invalid or failed.
+// @Produce text/csv
+// @Param import_id path int true "Import ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /imports/{import_id}/results [get]
+// @Tags imports
+func (h *ImportHandler) GetImportResults(c *gin.Context) {
+	id, ok := importID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.importService.ListImportRows(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-results.csv"`, id))
+	c.Status(http.StatusOK)
+	if err := imports.WriteResultsCSV(c.Writer, rows); err != nil {
+		c.Error(err)
+	}
+}
+
+func importID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("import_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import_id format"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockImportService struct {
+	createFn func(fileName, body string) (*models.TransferImport, error)
+	rowsFn   func(int) ([]*models.TransferImportRow, error)
+}
+
+func (m *mockImportService) CreateImport(ctx context.Context, fileName string, r io.Reader) (*models.TransferImport, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.createFn(fileName, string(b))
+}
+func (m *mockImportService) GetImport(ctx context.Context, id int) (*models.TransferImport, error) {
+	return nil, nil
+}
+func (m *mockImportService) ListImportRows(ctx context.Context, id int) ([]*models.TransferImportRow, error) {
+	return m.rowsFn(id)
+}
+func (m *mockImportService) ConfirmImport(ctx context.Context, id int) (*models.TransferImport, error) {
+	return nil, nil
+}
+
+func multipartBody(t *testing.T, field, fileName, content string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fw, err := w.CreateFormFile(field, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(fw, content)
+	w.Close()
+	return &buf, w.FormDataContentType()
+}
+
+func TestCreateImportHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const csvFile = "source,destination,amount\nA,B,1.00\n"
+	mockSvc := &mockImportService{createFn: func(fileName, body string) (*models.TransferImport, error) {
+		if body != csvFile {
+			return nil, errors.New(`missing column "destination"`)
+		}
+		if fileName == "forbidden.csv" {
+			return nil, service.ErrForbidden
+		}
+		return &models.TransferImport{ID: 1, FileName: fileName, Status: models.TransferImportValidated}, nil
+	}}
+	h := NewImportHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/imports", h.CreateImport)
+
+	upload, uploadType := multipartBody(t, "file", "payouts.csv", csvFile)
+	noFile, noFileType := multipartBody(t, "other", "payouts.csv", csvFile)
+	forbidden, forbiddenType := multipartBody(t, "file", "forbidden.csv", csvFile)
+	cases := []struct {
+		name        string
+		body        io.Reader
+		contentType string
+		wantCode    int
+		wantBody    string
+	}{
+		{"csv body", strings.NewReader(csvFile), "text/csv", http.StatusCreated, `"status":"validated"`},
+		{"multipart", upload, uploadType, http.StatusCreated, `"file_name":"payouts.csv"`},
+		{"multipart without file", noFile, noFileType, http.StatusBadRequest, "file is required"},
+		{"invalid file", strings.NewReader("source,amount\n"), "text/csv", http.StatusBadRequest, "missing column"},
+		{"forbidden", forbidden, forbiddenType, http.StatusForbidden, "forbidden"},
+		{"too large", strings.NewReader(strings.Repeat("x", maxImportFileBytes+1)), "text/csv", http.StatusRequestEntityTooLarge, "file is larger than"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/imports", tc.body)
+			req.Header.Set("Content-Type", tc.contentType)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestGetImportResultsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	transactionID := 31
+	mockSvc := &mockImportService{rowsFn: func(id int) ([]*models.TransferImportRow, error) {
+		if id != 1 {
+			return nil, errors.New("transfer import not found")
+		}
+		return []*models.TransferImportRow{{Line: 2, SourceAccountNumber: "A", DestinationAccountNumber: "B", Amount: "1.00",
+			Status: models.ImportRowCreated, TransactionID: &transactionID, TransactionStatus: models.TransactionStatusCompleted}}, nil
+	}}
+	h := NewImportHandler(mockSvc)
+	r := gin.Default()
+	r.GET("/imports/:import_id/results", h.GetImportResults)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/imports/1/results", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="import-1-results.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "2,A,B,1.00,,created,31,completed,\n")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/imports/2/results", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/imports/x/results", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
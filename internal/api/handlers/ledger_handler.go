@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewLedgerHandler(ledgerService service.ILedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+		nowFn:         time.Now,
+	}
+}
+
+type LedgerHandler struct {
+	ledgerService service.ILedgerService
+	nowFn         func() time.Time
+}
+
+// CreateLedgerAccount godoc
+// @Summary Add an account to the chart of accounts
+// @Description type is asset, liability, equity, income or expense. Accounts are posted to it by passing its code as ledger_code when they are created.
+// @Accept json
+// @Produce json
+// @Param request body models.LedgerAccountRequest true "Ledger account payload"
+// @Success 201 {object} models.LedgerAccount
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /ledger-accounts [post]
+// @Tags ledger
+func (h *LedgerHandler) CreateLedgerAccount(c *gin.Context) {
+	var req models.LedgerAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	l, err := h.ledgerService.CreateLedgerAccount(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, l)
+}
+
+// ListLedgerAccounts godoc
+// @Summary List the chart of accounts
+// @Produce json
+// @Success 200 {array} models.LedgerAccount
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /ledger-accounts [get]
+// @Tags ledger
+func (h *LedgerHandler) ListLedgerAccounts(c *gin.Context) {
+	list, err := h.ledgerService.ListLedgerAccounts(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetLedgerAccount godoc
+// @Summary Get a ledger account
+// @Produce json
+// @Param code path string true "Ledger account code"
+// @Success 200 {object} models.LedgerAccount
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /ledger-accounts/{code} [get]
+// @Tags ledger
+func (h *LedgerHandler) GetLedgerAccount(c *gin.Context) {
+	l, err := h.ledgerService.GetLedgerAccount(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, l)
+}
+
+// period reads the from and to query parameters: a date (UTC) or an RFC 3339
+// time, a date for to including that whole day. from defaults to the
+// beginning of the ledger and to to now.
+func (h *LedgerHandler) period(c *gin.Context) (from, to time.Time, ok bool) {
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = parseStatementTime(v, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return from, to, false
+		}
+	}
+	to = h.nowFn()
+	if v := c.Query("to"); v != "" {
+		if to, err = parseStatementTime(v, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return from, to, false
+		}
+	}
+	return from, to, true
+}
+
+// TrialBalance godoc
+// @Summary Trial balance
+// @Description Lists every ledger account's debits and credits from from (inclusive) to to (exclusive), and its balance at to in the debit or credit column. from and to take a date (UTC) or an RFC 3339 time; a date for to includes that whole day. from defaults to the beginning of the ledger, to to now.
+// @Produce json
+// @Param from query string false "Start of the period"
+// @Param to query string false "End of the period"
+// @Success 200 {object} models.TrialBalance
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /reports/trial-balance [get]
+// @Tags ledger
+func (h *LedgerHandler) TrialBalance(c *gin.Context) {
+	from, to, ok := h.period(c)
+	if !ok {
+		return
+	}
+
+	tb, err := h.ledgerService.TrialBalance(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tb)
+}
+
+// BalanceSheet godoc
+// @Summary Balance sheet
+// @Description Assets, liabilities and equity at to. Income less expenses before from is shown as retained earnings, and over the period as net income. from and to are as for the trial balance.
+// @Produce json
+// @Param from query string false "Start of the period"
+// @Param to query string false "End of the period"
+// @Success 200 {object} models.BalanceSheet
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /reports/balance-sheet [get]
+// @Tags ledger
+func (h *LedgerHandler) BalanceSheet(c *gin.Context) {
+	from, to, ok := h.period(c)
+	if !ok {
+		return
+	}
+
+	bs, err := h.ledgerService.BalanceSheet(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bs)
+}
+
+// IncomeStatement godoc
+// @Summary Income statement
+// @Description Income and expenses from from to to, and the net income. from and to are as for the trial balance.
+// @Produce json
+// @Param from query string false "Start of the period"
+// @Param to query string false "End of the period"
+// @Success 200 {object} models.IncomeStatement
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /reports/income-statement [get]
+// @Tags ledger
+func (h *LedgerHandler) IncomeStatement(c *gin.Context) {
+	from, to, ok := h.period(c)
+	if !ok {
+		return
+	}
+
+	is, err := h.ledgerService.IncomeStatement(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, is)
+}
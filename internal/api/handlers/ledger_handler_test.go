@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLedgerService struct {
+	from, to time.Time
+}
+
+func (m *mockLedgerService) CreateLedgerAccount(ctx context.Context, req *models.LedgerAccountRequest) (*models.LedgerAccount, error) {
+	if req.Type != models.LedgerTypeIncome {
+		return nil, errors.New("type must be asset, liability, equity, income or expense")
+	}
+	return &models.LedgerAccount{Code: req.Code, Name: req.Name, Type: req.Type}, nil
+}
+
+func (m *mockLedgerService) GetLedgerAccount(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	if code != "2000" {
+		return nil, errors.New("ledger account not found")
+	}
+	return &models.LedgerAccount{Code: code, Name: "Customer deposits", Type: models.LedgerTypeLiability}, nil
+}
+
+func (m *mockLedgerService) ListLedgerAccounts(ctx context.Context) ([]*models.LedgerAccount, error) {
+	return []*models.LedgerAccount{{Code: "2000", Name: "Customer deposits", Type: models.LedgerTypeLiability}}, nil
+}
+
+func (m *mockLedgerService) TrialBalance(ctx context.Context, from, to time.Time) (*models.TrialBalance, error) {
+	m.from, m.to = from, to
+	return &models.TrialBalance{Balanced: true}, nil
+}
+
+func (m *mockLedgerService) BalanceSheet(ctx context.Context, from, to time.Time) (*models.BalanceSheet, error) {
+	m.from, m.to = from, to
+	return &models.BalanceSheet{TotalAssets: 7000}, nil
+}
+
+func (m *mockLedgerService) IncomeStatement(ctx context.Context, from, to time.Time) (*models.IncomeStatement, error) {
+	m.from, m.to = from, to
+	return &models.IncomeStatement{NetIncome: 300}, nil
+}
+
+func TestLedgerAccountHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewLedgerHandler(&mockLedgerService{})
+	r := gin.Default()
+	r.POST("/ledger-accounts", h.CreateLedgerAccount)
+	r.GET("/ledger-accounts", h.ListLedgerAccounts)
+	r.GET("/ledger-accounts/:code", h.GetLedgerAccount)
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"create", "POST", "/ledger-accounts", `{"code":"4100","name":"Interchange","type":"income"}`, http.StatusCreated, `"code":"4100"`},
+		{"bad type", "POST", "/ledger-accounts", `{"code":"4100","name":"Interchange","type":"revenue"}`, http.StatusBadRequest, "type must be"},
+		{"bad json", "POST", "/ledger-accounts", `{`, http.StatusBadRequest, "Invalid JSON format"},
+		{"list", "GET", "/ledger-accounts", "", http.StatusOK, "Customer deposits"},
+		{"get", "GET", "/ledger-accounts/2000", "", http.StatusOK, `"type":"liability"`},
+		{"not found", "GET", "/ledger-accounts/9999", "", http.StatusNotFound, "ledger account not found"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestLedgerReportHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockLedgerService{}
+	h := NewLedgerHandler(mockSvc)
+	now := time.Date(2024, 4, 15, 12, 0, 0, 0, time.UTC)
+	h.nowFn = func() time.Time { return now }
+	r := gin.Default()
+	r.GET("/reports/trial-balance", h.TrialBalance)
+	r.GET("/reports/balance-sheet", h.BalanceSheet)
+	r.GET("/reports/income-statement", h.IncomeStatement)
+
+	cases := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{"trial balance", "/reports/trial-balance?from=2024-03-01&to=2024-03-31", http.StatusOK, `"balanced":true`,
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"balance sheet", "/reports/balance-sheet", http.StatusOK, `"total_assets_pennies":7000`, time.Time{}, now},
+		{"income statement", "/reports/income-statement?from=2024-04-01T09:00:00Z", http.StatusOK, `"net_income_pennies":300`,
+			time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), now},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+			assert.True(t, tc.wantFrom.Equal(mockSvc.from), mockSvc.from)
+			assert.True(t, tc.wantTo.Equal(mockSvc.to), mockSvc.to)
+		})
+	}
+
+	for _, q := range []string{"from=March", "to=2024-13-01"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/reports/trial-balance?"+q, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, q)
+	}
+}
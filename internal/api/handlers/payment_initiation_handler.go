@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fastfunds/internal/iso20022"
+	"fastfunds/internal/service"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPaymentInitiationBytes bounds an uploaded pain.001 message.
+const maxPaymentInitiationBytes = 10 << 20
+
+func NewPaymentInitiationHandler(initiationService service.IPaymentInitiationService) *PaymentInitiationHandler {
+	return &PaymentInitiationHandler{
+		initiationService: initiationService,
+	}
+}
+
+type PaymentInitiationHandler struct {
+	initiationService service.IPaymentInitiationService
+}
+
+// InitiatePayments godoc
+// @Summary Execute an ISO 20022 pain.001 credit transfer initiation
+// @Description Accepts a pain.001.001.03 document. Each CdtTrfTxInf is made as a transfer from the payment's DbtrAcct to its CdtrAcct, with the rules of POST /transactions, before the response is sent. InstdAmt must be in the DbtrAcct's currency. The response is a pain.002.001.03 status report: ACSC for transfers made, PDNG for transfers held or waiting for approval, RJCT with a reason for the rest. A message that breaks the schema rules, or whose MsgId was used before, is rejected whole with status 422 and nothing moves.
+// @Accept application/xml
+// @Produce application/xml
+// @Param request body string true "pain.001 document"
+// @Success 200 {string} string "pain.002 status report"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {string} string "pain.002 rejecting the message"
+// @Router /payment-initiations [post]
+// @Tags payments
+func (h *PaymentInitiationHandler) InitiatePayments(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPaymentInitiationBytes)
+
+	report, err := h.initiationService.Initiate(c.Request.Context(), c.Request.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("message is larger than %d bytes", tooLarge.Limit)})
+			return
+		}
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	status := http.StatusOK
+	if report.GroupStatus == iso20022.StatusRejected && len(report.Payments) == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.Data(status, "application/xml", buf.Bytes())
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/iso20022"
+	"fastfunds/internal/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPaymentInitiationService struct {
+	initiateFn func(body string) (*iso20022.StatusReport, error)
+}
+
+func (m *mockPaymentInitiationService) Initiate(ctx context.Context, r io.Reader) (*iso20022.StatusReport, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.initiateFn(string(b))
+}
+
+func TestInitiatePaymentsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockPaymentInitiationService{initiateFn: func(body string) (*iso20022.StatusReport, error) {
+		report := &iso20022.StatusReport{MessageID: "PSR-1", CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), OriginalMessageID: body}
+		switch body {
+		case "ok":
+			report.Payments = []*iso20022.PaymentStatus{{OriginalPaymentInfoID: "B1",
+				Transactions: []*iso20022.TransactionStatus{{OriginalEndToEndID: "E1", Status: iso20022.StatusSettled}}}}
+			report.Summarize()
+		case "duplicate":
+			report.Reject(iso20022.Reason{Code: iso20022.ReasonDuplicateMessage})
+		case "forbidden":
+			return nil, service.ErrForbidden
+		default:
+			return nil, errors.New("invalid XML: EOF")
+		}
+		return report, nil
+	}}
+	h := NewPaymentInitiationHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/payment-initiations", h.InitiatePayments)
+
+	cases := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"accepted", "ok", http.StatusOK, "<GrpSts>ACSC</GrpSts>"},
+		{"rejected", "duplicate", http.StatusUnprocessableEntity, "<Cd>DU01</Cd>"},
+		{"not xml", "garbage", http.StatusBadRequest, "invalid XML"},
+		{"forbidden", "forbidden", http.StatusForbidden, "forbidden"},
+		{"too large", strings.Repeat("x", maxPaymentInitiationBytes+1), http.StatusRequestEntityTooLarge, "message is larger than"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/payment-initiations", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/xml")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
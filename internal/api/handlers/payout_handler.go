@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReturnFileBytes bounds an uploaded NACHA return file.
+const maxReturnFileBytes = 10 << 20
+
+func NewPayoutHandler(payoutService service.IPayoutService) *PayoutHandler {
+	return &PayoutHandler{
+		payoutService: payoutService,
+	}
+}
+
+type PayoutHandler struct {
+	payoutService service.IPayoutService
+}
+
+// CreatePayout godoc
+// @Summary Pay out to a US bank account over ACH
+// @Description Debits the source account into the ACH clearing account, with the rules of POST /transactions, and queues the payout for the next daily NACHA file. The external account must be a US account registered by customer_id, who must hold the source account; customers may leave customer_id out. A transfer held for screening or waiting for approval leaves the payout pending until it completes.
+// @Accept json
+// @Produce json
+// @Param request body models.PayoutRequest true "Payout"
+// @Success 201 {object} models.Payout
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /payouts [post]
+// @Tags payouts
+func (h *PayoutHandler) CreatePayout(c *gin.Context) {
+	var req models.PayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	payout, err := h.payoutService.CreatePayout(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payout)
+}
+
+// GetPayout godoc
+// @Summary Get a payout
+// @Description status is pending until the payout is written to a NACHA file, then sent, or returned if the receiving bank sent it back. transaction_status is the status of the transfer that funds it.
+// @Produce json
+// @Param payout_id path int true "Payout ID"
+// @Success 200 {object} models.Payout
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payouts/{payout_id} [get]
+// @Tags payouts
+func (h *PayoutHandler) GetPayout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("payout_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout_id format"})
+		return
+	}
+
+	payout, err := h.payoutService.GetPayout(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payout)
+}
+
+// ProcessReturns godoc
+// @Summary Process a NACHA return file
+// @Description Accepts a return file from the ODFI, either as the "file" field of a multipart form or as the request body. Each returned payout is marked returned and its amount transferred from the ACH clearing account back to the account it was paid from. Returns already processed or not matching a sent payout are skipped and reported, so a file can safely be uploaded twice.
+// @Accept multipart/form-data
+// @Accept text/plain
+// @Produce json
+// @Param file formData file false "NACHA return file"
+// @Success 200 {object} models.ACHReturnResult
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /ach/returns [post]
+// @Tags payouts
+func (h *PayoutHandler) ProcessReturns(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxReturnFileBytes)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			if !errors.As(err, new(*http.MaxBytesError)) {
+				err = errors.New("file is required")
+			}
+			respondUploadError(c, err)
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "couldn't read file"})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	result, err := h.payoutService.ProcessReturns(c.Request.Context(), body)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
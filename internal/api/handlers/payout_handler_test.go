@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPayoutService struct {
+	createFn         func(*models.PayoutRequest) (*models.Payout, error)
+	getFn            func(int) (*models.Payout, error)
+	processReturnsFn func(body string) (*models.ACHReturnResult, error)
+}
+
+func (m *mockPayoutService) CreatePayout(ctx context.Context, req *models.PayoutRequest) (*models.Payout, error) {
+	return m.createFn(req)
+}
+
+func (m *mockPayoutService) GetPayout(ctx context.Context, id int) (*models.Payout, error) {
+	return m.getFn(id)
+}
+
+func (m *mockPayoutService) ProcessReturns(ctx context.Context, r io.Reader) (*models.ACHReturnResult, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.processReturnsFn(string(b))
+}
+
+func TestCreatePayoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockPayoutService{createFn: func(req *models.PayoutRequest) (*models.Payout, error) {
+		switch req.ExternalAccountID {
+		case 5:
+			return &models.Payout{ID: 1, ExternalAccountID: 5, Status: models.PayoutPending, AmountPennies: 250}, nil
+		case 6:
+			return nil, service.ErrForbidden
+		}
+		return nil, errors.New("external account not found")
+	}}
+	h := NewPayoutHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/payouts", h.CreatePayout)
+
+	cases := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"created", `{"source_account_number":"A","external_account_id":5,"amount":"2.50"}`, http.StatusCreated, `"status":"pending"`},
+		{"forbidden", `{"external_account_id":6}`, http.StatusForbidden, "forbidden"},
+		{"rejected", `{"external_account_id":9}`, http.StatusBadRequest, "external account not found"},
+		{"bad json", `{`, http.StatusBadRequest, "Invalid JSON format"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/payouts", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestGetPayoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockPayoutService{getFn: func(id int) (*models.Payout, error) {
+		if id == 1 {
+			return &models.Payout{ID: 1, Status: models.PayoutSent, TraceNumber: "091000010000001"}, nil
+		}
+		return nil, errors.New("payout not found")
+	}}
+	h := NewPayoutHandler(mockSvc)
+	r := gin.Default()
+	r.GET("/payouts/:payout_id", h.GetPayout)
+
+	for path, want := range map[string]int{"/payouts/1": http.StatusOK, "/payouts/2": http.StatusNotFound, "/payouts/x": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, path)
+	}
+}
+
+func TestProcessReturnsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockPayoutService{processReturnsFn: func(body string) (*models.ACHReturnResult, error) {
+		if body != "returns" {
+			return nil, errors.New("file is empty")
+		}
+		return &models.ACHReturnResult{Returns: []*models.ACHReturnOutcome{
+			{TraceNumber: "091000010000001", ReturnCode: "R01", Status: models.ACHReturnReversed},
+		}}, nil
+	}}
+	h := NewPayoutHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/ach/returns", h.ProcessReturns)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ach/returns", strings.NewReader("returns"))
+	req.Header.Set("Content-Type", "text/plain")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"reversed"`)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "returns.ach")
+	fw.Write([]byte("returns"))
+	mw.Close()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ach/returns", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ach/returns", strings.NewReader(""))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "file is empty")
+}
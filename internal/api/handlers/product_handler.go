@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewProductHandler(productService service.IProductService) *ProductHandler {
+	return &ProductHandler{productService: productService}
+}
+
+type ProductHandler struct {
+	productService service.IProductService
+}
+
+// CreateProduct godoc
+// @Summary Add an account product
+// @Description type is checking, savings, business, escrow or internal. The terms become version 1; ledger_code defaults to 2000.
+// @Accept json
+// @Produce json
+// @Param request body models.ProductRequest true "Product payload"
+// @Success 201 {object} models.Product
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /products [post]
+// @Tags products
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req models.ProductRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	p, err := h.productService.CreateProduct(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, p)
+}
+
+// ListProducts godoc
+// @Summary List account products with their current terms
+// @Produce json
+// @Success 200 {array} models.Product
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /products [get]
+// @Tags products
+func (h *ProductHandler) ListProducts(c *gin.Context) {
+	list, err := h.productService.ListProducts(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetProduct godoc
+// @Summary Get an account product with its current terms
+// @Produce json
+// @Param code path string true "Product code"
+// @Success 200 {object} models.Product
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/{code} [get]
+// @Tags products
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	p, err := h.productService.GetProduct(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// UpdateProductTerms godoc
+// @Summary Change a product's terms
+// @Description Adds a version of the terms. New accounts are opened on it; existing accounts keep their version until the product is migrated.
+// @Accept json
+// @Produce json
+// @Param code path string true "Product code"
+// @Param request body models.ProductTerms true "New terms"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /products/{code}/terms [put]
+// @Tags products
+func (h *ProductHandler) UpdateProductTerms(c *gin.Context) {
+	var terms models.ProductTerms
+
+	if err := c.ShouldBindJSON(&terms); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	p, err := h.productService.UpdateTerms(c.Request.Context(), c.Param("code"), &terms)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// ListProductVersions godoc
+// @Summary List every version of a product's terms
+// @Produce json
+// @Param code path string true "Product code"
+// @Success 200 {array} models.ProductVersion
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/{code}/versions [get]
+// @Tags products
+func (h *ProductHandler) ListProductVersions(c *gin.Context) {
+	list, err := h.productService.ListVersions(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// MigrateAccounts godoc
+// @Summary Move a product's accounts onto its current terms
+// @Description Accounts keep their currency and ledger account.
+// @Produce json
+// @Param code path string true "Product code"
+// @Success 200 {object} models.ProductMigration
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /products/{code}/migrate [post]
+// @Tags products
+func (h *ProductHandler) MigrateAccounts(c *gin.Context) {
+	m, err := h.productService.MigrateAccounts(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, m)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProductService struct{}
+
+var errProductNotFound = errors.New("product not found")
+
+func (m *mockProductService) CreateProduct(ctx context.Context, req *models.ProductRequest) (*models.Product, error) {
+	if req.Type != models.ProductTypeSavings {
+		return nil, errors.New("type must be checking, savings, business, escrow or internal")
+	}
+	return &models.Product{Code: req.Code, Name: req.Name, Type: req.Type, Version: 1, Terms: req.Terms}, nil
+}
+
+func (m *mockProductService) UpdateTerms(ctx context.Context, code string, terms *models.ProductTerms) (*models.Product, error) {
+	if code != "checking" {
+		return nil, errProductNotFound
+	}
+	return &models.Product{Code: code, Version: 2, Terms: *terms}, nil
+}
+
+func (m *mockProductService) MigrateAccounts(ctx context.Context, code string) (*models.ProductMigration, error) {
+	return &models.ProductMigration{ProductCode: code, Version: 2, AccountsMigrated: 3}, nil
+}
+
+func (m *mockProductService) GetProduct(ctx context.Context, code string) (*models.Product, error) {
+	if code != "checking" {
+		return nil, errProductNotFound
+	}
+	return &models.Product{Code: code, Name: "Checking", Type: models.ProductTypeChecking, Version: 1}, nil
+}
+
+func (m *mockProductService) ListProducts(ctx context.Context) ([]*models.Product, error) {
+	return []*models.Product{{Code: "checking", Name: "Checking", Type: models.ProductTypeChecking, Version: 1}}, nil
+}
+
+func (m *mockProductService) ListVersions(ctx context.Context, code string) ([]*models.ProductVersion, error) {
+	if code != "checking" {
+		return nil, errProductNotFound
+	}
+	return []*models.ProductVersion{{Version: 1}, {Version: 2}}, nil
+}
+
+func TestProductHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewProductHandler(&mockProductService{})
+	r := gin.Default()
+	r.POST("/products", h.CreateProduct)
+	r.GET("/products", h.ListProducts)
+	r.GET("/products/:code", h.GetProduct)
+	r.PUT("/products/:code/terms", h.UpdateProductTerms)
+	r.GET("/products/:code/versions", h.ListProductVersions)
+	r.POST("/products/:code/migrate", h.MigrateAccounts)
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"create", "POST", "/products", `{"code":"junior","name":"Junior saver","type":"savings","terms":{"currency":"GBP","interest_rate_bps":250}}`,
+			http.StatusCreated, `"interest_rate_bps":250`},
+		{"bad type", "POST", "/products", `{"code":"junior","name":"Junior saver","type":"junior"}`, http.StatusBadRequest, "type must be"},
+		{"bad json", "POST", "/products", `{`, http.StatusBadRequest, "Invalid JSON format"},
+		{"list", "GET", "/products", "", http.StatusOK, `"code":"checking"`},
+		{"get", "GET", "/products/checking", "", http.StatusOK, `"type":"checking"`},
+		{"not found", "GET", "/products/premier", "", http.StatusNotFound, "product not found"},
+		{"update terms", "PUT", "/products/checking/terms", `{"currency":"GBP","overdraft_limit_pennies":5000}`,
+			http.StatusOK, `"overdraft_limit_pennies":5000`},
+		{"update unknown", "PUT", "/products/premier/terms", `{"currency":"GBP"}`, http.StatusBadRequest, "product not found"},
+		{"versions", "GET", "/products/checking/versions", "", http.StatusOK, `"version":2`},
+		{"versions not found", "GET", "/products/premier/versions", "", http.StatusNotFound, "product not found"},
+		{"migrate", "POST", "/products/checking/migrate", "", http.StatusOK, `"accounts_migrated":3`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
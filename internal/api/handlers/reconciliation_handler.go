@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fastfunds/internal/service"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewReconciliationHandler(reconciliationService service.IReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+type ReconciliationHandler struct {
+	reconciliationService service.IReconciliationService
+}
+
+// Reconcile godoc
+// @Summary Reconcile balances now
+// @Description Recomputes every account's balance from its initial balance and completed transfers, and checks that the total balance equals the total the accounts were opened with. Discrepancies are reported in the result, not as an error.
+// @Produce json
+// @Success 200 {object} models.Reconciliation
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/reconciliation [post]
+// @Tags admin
+func (h *ReconciliationHandler) Reconcile(c *gin.Context) {
+	result, err := h.reconciliationService.Reconcile(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetReconciliation godoc
+// @Summary Get the latest reconciliation
+// @Produce json
+// @Success 200 {object} models.Reconciliation
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/reconciliation [get]
+// @Tags admin
+func (h *ReconciliationHandler) GetReconciliation(c *gin.Context) {
+	result, err := h.reconciliationService.LastReconciliation(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Metrics godoc
+// @Summary Metrics in the Prometheus text format
+// @Description Gauges from the latest balance reconciliation; empty until the first run. Served without authentication on METRICS_ADDR, not on the API's port.
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+// @Tags admin
+func (h *ReconciliationHandler) Metrics(c *gin.Context) {
+	result, err := h.reconciliationService.LastReconciliation(c.Request.Context())
+	if err != nil && !errors.Is(err, service.ErrNotReconciled) {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var b strings.Builder
+	if result != nil {
+		gauge := func(name, help string, value any) {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+		}
+		checkedAt, _ := time.Parse(time.RFC3339, result.CheckedAt)
+		gauge("fastfunds_reconciliation_timestamp_seconds", "When balances were last reconciled.", checkedAt.Unix())
+		gauge("fastfunds_reconciliation_accounts", "Accounts checked by the last reconciliation.", result.AccountsChecked)
+		gauge("fastfunds_reconciliation_discrepancies", "Accounts whose balance disagrees with their transaction history.", len(result.Discrepancies))
+		gauge("fastfunds_reconciliation_total_balance_pennies", "Sum of all account balances.", result.TotalBalancePennies)
+		gauge("fastfunds_reconciliation_expected_total_balance_pennies", "Sum of all initial balances, which the total balance must equal.", result.TotalInitialPennies)
+		conserved := 0
+		if result.Conserved {
+			conserved = 1
+		}
+		gauge("fastfunds_reconciliation_conserved", "1 if the total balance equals the total initial balance.", conserved)
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
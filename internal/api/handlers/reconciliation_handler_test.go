@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReconciliationService struct {
+	last *models.Reconciliation
+	err  error
+}
+
+func (m *mockReconciliationService) Reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	return m.last, m.err
+}
+
+func (m *mockReconciliationService) LastReconciliation(ctx context.Context) (*models.Reconciliation, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.last == nil {
+		return nil, service.ErrNotReconciled
+	}
+	return m.last, nil
+}
+
+func TestReconciliationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockReconciliationService{}
+	h := NewReconciliationHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/admin/reconciliation", h.Reconcile)
+	r.GET("/admin/reconciliation", h.GetReconciliation)
+	r.GET("/metrics", h.Metrics)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/admin/reconciliation")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve("GET", "/metrics")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	mockSvc.last = &models.Reconciliation{CheckedAt: "2024-03-01T17:00:00Z", AccountsChecked: 2, TotalBalancePennies: 1050,
+		TotalInitialPennies: 1000, Discrepancies: []*models.BalanceDiscrepancy{{AccountNumber: "B", DifferencePennies: 50}}}
+	w = serve("POST", "/admin/reconciliation")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"difference_pennies":50`)
+
+	w = serve("GET", "/metrics")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, w.Body.String(), "# TYPE fastfunds_reconciliation_discrepancies gauge\nfastfunds_reconciliation_discrepancies 1\n")
+	assert.Contains(t, w.Body.String(), "fastfunds_reconciliation_timestamp_seconds 1709312400\n")
+	assert.Contains(t, w.Body.String(), "fastfunds_reconciliation_conserved 0\n")
+
+	mockSvc.err = service.ErrForbidden
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/metrics").Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/admin/reconciliation").Code)
+}
+
+// scraperCheckingReconciliations answers only callers with a principal, as
+// the real service does.
+type scraperCheckingReconciliations struct{ mockReconciliationService }
+
+func (m *scraperCheckingReconciliations) LastReconciliation(ctx context.Context) (*models.Reconciliation, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, service.ErrUnauthenticated
+	}
+	if p.Role != auth.RoleAuditor {
+		return nil, service.ErrForbidden
+	}
+	return m.mockReconciliationService.LastReconciliation(ctx)
+}
+
+func TestSetupMetricsRoutes_NoCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	SetupMetricsRoutes(r, &scraperCheckingReconciliations{mockReconciliationService{last: &models.Reconciliation{
+		CheckedAt: "2024-03-01T17:00:00Z", AccountsChecked: 2, Conserved: true}}})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "fastfunds_reconciliation_conserved 1\n")
+}
@@ -1,21 +1,173 @@
 package handlers
 
 import (
+	"fastfunds/internal/api/middleware"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/ratelimit"
 	"fastfunds/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RateLimits configures the limits SetupRoutes applies. A zero Limit turns
+// that limit off.
+type RateLimits struct {
+	Limiter ratelimit.Limiter
+	// PerIP is checked before authentication, so it also slows down
+	// credential guessing.
+	PerIP        ratelimit.Limit
+	PerPrincipal ratelimit.Limit
+	// Transfers is an additional per-principal limit on POST /transactions.
+	Transfers ratelimit.Limit
+}
+
+// SetupRoutes registers the API behind request IDs, rate limiting and
+// authentication. Routes added to the router outside of it, such as Swagger,
+// stay public.
 func SetupRoutes(
 	router *gin.Engine,
+	authenticator auth.Authenticator,
+	limits RateLimits,
 	accountService *service.AccountService,
 	transactionService *service.TransactionService,
+	screeningService *service.ScreeningService,
+	customerService *service.CustomerService,
+	externalAccountService *service.ExternalAccountService,
+	apiKeyService *service.APIKeyService,
+	webhookService *service.WebhookService,
+	importService *service.ImportService,
+	paymentInitiationService *service.PaymentInitiationService,
+	payoutService *service.PayoutService,
+	reconciliationService *service.ReconciliationService,
+	balanceService *service.BalanceService,
+	businessDayService *service.BusinessDayService,
+	ledgerService *service.LedgerService,
+	productService *service.ProductService,
+	escrowService *service.EscrowService,
+	disputeService *service.DisputeService,
 ) {
 	accountHandler := NewAccountHandler(accountService)
 	transactionHandler := NewTransactionHandler(transactionService)
+	screeningHandler := NewScreeningHandler(screeningService)
+	customerHandler := NewCustomerHandler(customerService)
+	externalAccountHandler := NewExternalAccountHandler(externalAccountService)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyService)
+	webhookHandler := NewWebhookHandler(webhookService)
+	importHandler := NewImportHandler(importService)
+	statementHandler := NewStatementHandler(accountService, transactionService)
+	paymentInitiationHandler := NewPaymentInitiationHandler(paymentInitiationService)
+	payoutHandler := NewPayoutHandler(payoutService)
+	reconciliationHandler := NewReconciliationHandler(reconciliationService)
+	balanceHandler := NewBalanceHandler(accountService, balanceService)
+	businessDayHandler := NewBusinessDayHandler(businessDayService)
+	ledgerHandler := NewLedgerHandler(ledgerService)
+	productHandler := NewProductHandler(productService)
+	escrowHandler := NewEscrowHandler(escrowService)
+	disputeHandler := NewDisputeHandler(disputeService)
+
+	api := router.Group("/",
+		middleware.RequestInfo(),
+		middleware.RateLimit(limits.Limiter, limits.PerIP, "ip", middleware.ByIP),
+		middleware.Authenticate(authenticator),
+		middleware.RateLimit(limits.Limiter, limits.PerPrincipal, "principal", middleware.ByPrincipal),
+	)
+
+	api.POST("/accounts", accountHandler.CreateAccount)
+
+	api.GET("/accounts/:account_number", accountHandler.GetAccount)
+	api.GET("/accounts/:account_number/events", accountHandler.StreamEvents)
+	api.GET("/accounts/:account_number/statement", statementHandler.GetStatement)
+	api.GET("/accounts/:account_number/balance", balanceHandler.GetBalance)
+	api.GET("/balances", balanceHandler.ListBalances)
+
+	api.POST("/business-days/:day/close", businessDayHandler.CloseBusinessDay)
+	api.GET("/business-days/:day", businessDayHandler.GetBusinessDay)
+	api.GET("/business-days/:day/balances", businessDayHandler.ListDailyBalances)
+
+	api.POST("/ledger-accounts", ledgerHandler.CreateLedgerAccount)
+	api.GET("/ledger-accounts", ledgerHandler.ListLedgerAccounts)
+	api.GET("/ledger-accounts/:code", ledgerHandler.GetLedgerAccount)
+	api.GET("/reports/trial-balance", ledgerHandler.TrialBalance)
+	api.GET("/reports/balance-sheet", ledgerHandler.BalanceSheet)
+	api.GET("/reports/income-statement", ledgerHandler.IncomeStatement)
+
+	api.POST("/products", productHandler.CreateProduct)
+	api.GET("/products", productHandler.ListProducts)
+	api.GET("/products/:code", productHandler.GetProduct)
+	api.PUT("/products/:code/terms", productHandler.UpdateProductTerms)
+	api.GET("/products/:code/versions", productHandler.ListProductVersions)
+	api.POST("/products/:code/migrate", productHandler.MigrateAccounts)
+
+	api.POST("/escrows", escrowHandler.CreateEscrow)
+	api.GET("/escrows/:escrow_id", escrowHandler.GetEscrow)
+	api.POST("/escrows/:escrow_id/release", escrowHandler.ReleaseEscrow)
+	api.POST("/escrows/:escrow_id/refund", escrowHandler.RefundEscrow)
+	api.POST("/escrows/:escrow_id/dispute", escrowHandler.DisputeEscrow)
 
-	router.POST("/accounts", accountHandler.CreateAccount)
+	api.POST("/disputes", disputeHandler.OpenDispute)
+	api.GET("/disputes", disputeHandler.ListDisputes)
+	api.GET("/disputes/:dispute_id", disputeHandler.GetDispute)
+	api.POST("/disputes/:dispute_id/notes", disputeHandler.AddDisputeNote)
+	api.POST("/disputes/:dispute_id/review", disputeHandler.ReviewDispute)
+	api.POST("/disputes/:dispute_id/resolve", disputeHandler.ResolveDispute)
+
+	api.POST("/accounts/:account_number/holders", accountHandler.AddHolder)
+	api.DELETE("/accounts/:account_number/holders/:customer_id", accountHandler.RemoveHolder)
+	api.POST("/transactions", middleware.RateLimit(limits.Limiter, limits.Transfers, "transfers", middleware.ByPrincipal), transactionHandler.SubmitTransaction)
+	api.GET("/transactions", transactionHandler.ListTransactions)
+	api.GET("/transactions/:transaction_id", transactionHandler.GetTransaction)
+	api.POST("/transactions/:transaction_id/approve", transactionHandler.ApproveTransaction)
+	api.POST("/transactions/:transaction_id/reject", transactionHandler.RejectTransaction)
+
+	api.POST("/payment-initiations", middleware.RateLimit(limits.Limiter, limits.Transfers, "transfers", middleware.ByPrincipal), paymentInitiationHandler.InitiatePayments)
+
+	api.POST("/payouts", middleware.RateLimit(limits.Limiter, limits.Transfers, "transfers", middleware.ByPrincipal), payoutHandler.CreatePayout)
+	api.GET("/payouts/:payout_id", payoutHandler.GetPayout)
+	api.POST("/ach/returns", payoutHandler.ProcessReturns)
+
+	api.POST("/imports", importHandler.CreateImport)
+	api.GET("/imports/:import_id", importHandler.GetImport)
+	api.POST("/imports/:import_id/confirm", importHandler.ConfirmImport)
+	api.GET("/imports/:import_id/results", importHandler.GetImportResults)
+
+	api.POST("/customers", customerHandler.CreateCustomer)
+	api.GET("/customers", customerHandler.ListCustomers)
+	api.GET("/customers/:customer_id", customerHandler.GetCustomer)
+	api.PUT("/customers/:customer_id", customerHandler.UpdateCustomer)
+	api.GET("/customers/:customer_id/accounts", customerHandler.ListCustomerAccounts)
+	api.POST("/customers/:customer_id/external-accounts", externalAccountHandler.RegisterExternalAccount)
+	api.GET("/customers/:customer_id/external-accounts", externalAccountHandler.ListExternalAccounts)
+	api.GET("/customers/:customer_id/external-accounts/:external_account_id", externalAccountHandler.GetExternalAccount)
+	api.DELETE("/customers/:customer_id/external-accounts/:external_account_id", externalAccountHandler.RemoveExternalAccount)
+	api.POST("/external-accounts/validate", externalAccountHandler.ValidateDetails)
+
+	api.GET("/screening/cases", screeningHandler.ListCases)
+	api.GET("/screening/cases/:case_id", screeningHandler.GetCase)
+	api.POST("/screening/cases/:case_id/clear", screeningHandler.ClearCase)
+	api.POST("/screening/cases/:case_id/confirm", screeningHandler.ConfirmCase)
+
+	api.POST("/admin/api-keys", apiKeyHandler.CreateAPIKey)
+	api.GET("/admin/api-keys", apiKeyHandler.ListAPIKeys)
+	api.POST("/admin/api-keys/:api_key_id/rotate", apiKeyHandler.RotateAPIKey)
+	api.DELETE("/admin/api-keys/:api_key_id", apiKeyHandler.RevokeAPIKey)
+	api.POST("/admin/reconciliation", reconciliationHandler.Reconcile)
+	api.GET("/admin/reconciliation", reconciliationHandler.GetReconciliation)
+
+	api.POST("/webhooks", webhookHandler.CreateSubscription)
+	api.GET("/webhooks", webhookHandler.ListSubscriptions)
+	api.GET("/webhooks/:subscription_id", webhookHandler.GetSubscription)
+	api.DELETE("/webhooks/:subscription_id", webhookHandler.DeleteSubscription)
+	api.GET("/webhooks/:subscription_id/deliveries", webhookHandler.ListDeliveries)
+	api.GET("/webhooks/:subscription_id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+	api.POST("/webhooks/:subscription_id/deliveries/:delivery_id/replay", webhookHandler.ReplayDelivery)
+}
 
-	router.GET("/accounts/:account_id", accountHandler.GetAccount)
-	router.POST("/transactions", transactionHandler.SubmitTransaction)
+// SetupMetricsRoutes registers GET /metrics without authentication, for a
+// Prometheus scraper. It belongs on its own listener kept off the public
+// network: requests read the latest reconciliation as an auditor.
+func SetupMetricsRoutes(router *gin.Engine, reconciliationService service.IReconciliationService) {
+	scraper := &auth.Principal{Subject: "metrics", Role: auth.RoleAuditor}
+	router.GET("/metrics", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), scraper))
+	}, NewReconciliationHandler(reconciliationService).Metrics)
 }
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewScreeningHandler(screeningService service.IScreeningService) *ScreeningHandler {
+	return &ScreeningHandler{
+		screeningService: screeningService,
+	}
+}
+
+type ScreeningHandler struct {
+	screeningService service.IScreeningService
+}
+
+// ListCases godoc
+// @Summary List sanctions screening cases
+// @Produce json
+// @Param status query string false "Filter by status (open, cleared, confirmed)"
+// @Success 200 {array} models.ScreeningCase
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /screening/cases [get]
+// @Tags screening
+func (h *ScreeningHandler) ListCases(c *gin.Context) {
+	cases, err := h.screeningService.ListCases(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cases)
+}
+
+// GetCase godoc
+// @Summary Get sanctions screening case by ID
+// @Produce json
+// @Param case_id path int true "Case ID"
+// @Success 200 {object} models.ScreeningCase
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /screening/cases/{case_id} [get]
+// @Tags screening
+func (h *ScreeningHandler) GetCase(c *gin.Context) {
+	caseID, err := strconv.Atoi(c.Param("case_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case_id format"})
+		return
+	}
+
+	sc, err := h.screeningService.GetCase(c.Request.Context(), caseID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sc)
+}
+
+// ClearCase godoc
+// @Summary Clear a screening case as a false positive
+// @Accept json
+// @Produce json
+// @Param case_id path int true "Case ID"
+// @Param request body models.ResolveScreeningCaseRequest true "Resolution note"
+// @Success 200 {object} models.ScreeningCase
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /screening/cases/{case_id}/clear [post]
+// @Tags screening
+func (h *ScreeningHandler) ClearCase(c *gin.Context) {
+	h.resolve(c, h.screeningService.ClearCase)
+}
+
+// ConfirmCase godoc
+// @Summary Confirm a screening case as a true match
+// @Accept json
+// @Produce json
+// @Param case_id path int true "Case ID"
+// @Param request body models.ResolveScreeningCaseRequest true "Resolution note"
+// @Success 200 {object} models.ScreeningCase
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /screening/cases/{case_id}/confirm [post]
+// @Tags screening
+func (h *ScreeningHandler) ConfirmCase(c *gin.Context) {
+	h.resolve(c, h.screeningService.ConfirmCase)
+}
+
+func (h *ScreeningHandler) resolve(c *gin.Context, fn func(context.Context, int, string) (*models.ScreeningCase, error)) {
+	caseID, err := strconv.Atoi(c.Param("case_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case_id format"})
+		return
+	}
+
+	var req models.ResolveScreeningCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	sc, err := fn(c.Request.Context(), caseID, req.Note)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sc)
+}
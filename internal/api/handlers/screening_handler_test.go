@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fastfunds/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockScreeningService struct {
+	listFn    func(string) ([]*models.ScreeningCase, error)
+	getFn     func(int) (*models.ScreeningCase, error)
+	clearFn   func(int, string) (*models.ScreeningCase, error)
+	confirmFn func(int, string) (*models.ScreeningCase, error)
+}
+
+func (m *mockScreeningService) ListCases(ctx context.Context, status string) ([]*models.ScreeningCase, error) {
+	if m.listFn != nil {
+		return m.listFn(status)
+	}
+	return nil, nil
+}
+func (m *mockScreeningService) GetCase(ctx context.Context, id int) (*models.ScreeningCase, error) {
+	if m.getFn != nil {
+		return m.getFn(id)
+	}
+	return nil, nil
+}
+func (m *mockScreeningService) ClearCase(ctx context.Context, id int, note string) (*models.ScreeningCase, error) {
+	if m.clearFn != nil {
+		return m.clearFn(id, note)
+	}
+	return nil, nil
+}
+func (m *mockScreeningService) ConfirmCase(ctx context.Context, id int, note string) (*models.ScreeningCase, error) {
+	if m.confirmFn != nil {
+		return m.confirmFn(id, note)
+	}
+	return nil, nil
+}
+
+func TestListCasesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		query    string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"service error", "?status=bogus", assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"success", "?status=open", nil, http.StatusOK, `"entry_uid":"42"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockScreeningService{listFn: func(status string) ([]*models.ScreeningCase, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return []*models.ScreeningCase{{ID: 1, EntryUID: "42", Status: status}}, nil
+			}}
+			h := NewScreeningHandler(mockSvc)
+			r := gin.Default()
+			r.GET("/screening/cases", h.ListCases)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/screening/cases"+tc.query, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestGetCaseHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		param    string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad id", "abc", nil, http.StatusBadRequest, "Invalid case_id format"},
+		{"not found", "9", assert.AnError, http.StatusNotFound, assert.AnError.Error()},
+		{"success", "1", nil, http.StatusOK, `"id":1`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockScreeningService{getFn: func(id int) (*models.ScreeningCase, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.ScreeningCase{ID: id}, nil
+			}}
+			h := NewScreeningHandler(mockSvc)
+			r := gin.Default()
+			r.GET("/screening/cases/:case_id", h.GetCase)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/screening/cases/"+tc.param, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestResolveCaseHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		body     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad id", "/screening/cases/abc/clear", `{"note":"x"}`, nil, http.StatusBadRequest, "Invalid case_id format"},
+		{"invalid json", "/screening/cases/1/clear", "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", "/screening/cases/1/confirm", `{"note":"x"}`, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"clear", "/screening/cases/1/clear", `{"note":"dob differs"}`, nil, http.StatusOK, `"status":"cleared"`},
+		{"confirm", "/screening/cases/1/confirm", `{"note":"match"}`, nil, http.StatusOK, `"status":"confirmed"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolve := func(status string) func(int, string) (*models.ScreeningCase, error) {
+				return func(id int, note string) (*models.ScreeningCase, error) {
+					if tc.mockErr != nil {
+						return nil, tc.mockErr
+					}
+					return &models.ScreeningCase{ID: id, Status: status, Note: note}, nil
+				}
+			}
+			mockSvc := &mockScreeningService{
+				clearFn:   resolve(models.ScreeningCaseCleared),
+				confirmFn: resolve(models.ScreeningCaseConfirmed),
+			}
+			h := NewScreeningHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/screening/cases/:case_id/clear", h.ClearCase)
+			r.POST("/screening/cases/:case_id/confirm", h.ConfirmCase)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tc.path, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"fastfunds/internal/service"
+	"fastfunds/internal/statements"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const statementDateLayout = "2006-01-02"
+
+func NewStatementHandler(accountService service.IAccountService, transactionService service.ITransactionService) *StatementHandler {
+	return &StatementHandler{
+		accountService:     accountService,
+		transactionService: transactionService,
+		nowFn:              time.Now,
+	}
+}
+
+type StatementHandler struct {
+	accountService     service.IAccountService
+	transactionService service.ITransactionService
+	nowFn              func() time.Time
+}
+
+// GetStatement godoc
+// @Summary Download an account statement
+// @Description The statement is in the account's currency. It covers from (inclusive) to to (exclusive) and lists the opening balance, every completed transfer with the running balance after it, and the closing balance. from and to take a date (UTC) or an RFC 3339 time; a date for to includes that whole day. to defaults to now.
+// @Produce text/csv
+// @Produce json
+// @Produce application/x-ofx
+// @Produce application/xml
+// @Produce text/plain
+// @Param account_number path string true "Account number"
+// @Param from query string true "Start of the period"
+// @Param to query string false "End of the period"
+// @Param format query string false "csv (default), json, ofx, camt053 or mt940"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /accounts/{account_number}/statement [get]
+// @Tags accounts
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	accountID, ok := resolveAccountNumber(c, h.accountService)
+	if !ok {
+		return
+	}
+
+	format, err := statements.Lookup(c.DefaultQuery("format", "csv"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if c.Query("from") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is required"})
+		return
+	}
+	from, err := parseStatementTime(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to := h.nowFn()
+	if c.Query("to") != "" {
+		if to, err = parseStatementTime(c.Query("to"), true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+	}
+
+	out := &statementResponse{c: c, contentType: format.ContentType,
+		fileName: fmt.Sprintf("statement-%s-%s-%s.%s", c.Param("account_number"),
+			from.UTC().Format(statementDateLayout), to.UTC().Format(statementDateLayout), format.Extension)}
+	err = h.transactionService.WriteStatement(c.Request.Context(), accountID, from, to, format.New(out))
+	if err == nil {
+		return
+	}
+	if out.started {
+		// The status line has gone; the statement ends without its closing
+		// balance, which is how clients tell it is incomplete.
+		c.Error(err)
+		return
+	}
+	respondError(c, http.StatusBadRequest, err)
+}
+
+// parseStatementTime reads a date or an RFC 3339 time. A date marks the
+// start of that day, or its end when end is set.
+func parseStatementTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(statementDateLayout, value); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid time")
+	}
+	return t, nil
+}
+
+// statementResponse holds back the headers until the statement's first
+// bytes arrive, so errors found before then can still be sent as JSON.
+type statementResponse struct {
+	c           *gin.Context
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (r *statementResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.c.Header("Content-Type", r.contentType)
+		r.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.fileName))
+		r.c.Status(http.StatusOK)
+	}
+	return r.c.Writer.Write(p)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"fastfunds/internal/statements"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStatementHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accounts := &mockAccountService{resolveFn: func(number string) (int, error) {
+		if number != testAccountNumber {
+			return 0, errors.New("account not found")
+		}
+		return 100, nil
+	}}
+	var gotFrom, gotTo time.Time
+	transactions := &mockTransactionService{statementFn: func(id int, from, to time.Time, w statements.Writer) error {
+		gotFrom, gotTo = from, to
+		if from.Year() == 2023 {
+			return service.ErrForbidden
+		}
+		s := &models.Statement{AccountNumber: testAccountNumber, From: from, To: to, OpeningBalancePennies: 1000, ClosingBalancePennies: 1000}
+		if err := w.Begin(s); err != nil {
+			return err
+		}
+		return w.End(s)
+	}}
+	h := NewStatementHandler(accounts, transactions)
+	h.nowFn = func() time.Time { return time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC) }
+	r := gin.Default()
+	r.GET("/accounts/:account_number/statement", h.GetStatement)
+
+	base := "/accounts/" + testAccountNumber + "/statement"
+	cases := []struct {
+		name        string
+		url         string
+		wantCode    int
+		wantBody    string
+		wantFrom    time.Time
+		wantTo      time.Time
+		wantType    string
+		wantDispose string
+	}{
+		{"csv by date", base + "?from=2024-01-01&to=2024-01-31", http.StatusOK, "Closing balance,,,10.00",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			"text/csv", `attachment; filename="statement-FF17FAST4821930576-2024-01-01-2024-02-01.csv"`},
+		{"ofx to now", base + "?from=2024-03-01T09:00:00Z&format=ofx", http.StatusOK, "<BALAMT>10.00</BALAMT>",
+			time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+			"application/x-ofx", `attachment; filename="statement-FF17FAST4821930576-2024-03-01-2024-03-05.ofx"`},
+		{"missing from", base, http.StatusBadRequest, "from is required", time.Time{}, time.Time{}, "", ""},
+		{"bad from", base + "?from=yesterday", http.StatusBadRequest, "invalid from", time.Time{}, time.Time{}, "", ""},
+		{"bad format", base + "?from=2024-01-01&format=pdf", http.StatusBadRequest, "unknown statement format", time.Time{}, time.Time{}, "", ""},
+		{"forbidden", base + "?from=2023-01-01", http.StatusForbidden, "forbidden", time.Time{}, time.Time{}, "application/json; charset=utf-8", ""},
+		{"unknown account", "/accounts/FF14FAST7305618249/statement?from=2024-01-01", http.StatusNotFound, "account not found", time.Time{}, time.Time{}, "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotFrom, gotTo = time.Time{}, time.Time{}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tc.url, nil))
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+			if tc.wantCode == http.StatusOK {
+				assert.True(t, tc.wantFrom.Equal(gotFrom), "from %v", gotFrom)
+				assert.True(t, tc.wantTo.Equal(gotTo), "to %v", gotTo)
+			}
+			if tc.wantType != "" {
+				assert.Equal(t, tc.wantType, w.Header().Get("Content-Type"))
+			}
+			assert.Equal(t, tc.wantDispose, w.Header().Get("Content-Disposition"))
+		})
+	}
+}
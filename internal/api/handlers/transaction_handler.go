@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
+	"fastfunds/internal/auth"
 	"fastfunds/internal/models"
 	"fastfunds/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,8 +26,14 @@ func NewTransactionHandler(transactionService service.ITransactionService) *Tran
 // @Accept json
 // @Produce json
 // @Param request body models.TransactionRequest true "Transaction payload"
-// @Success 200
+// @Success 201 {object} models.Transaction
+// @Success 202 {object} models.Transaction "Held for screening review or pending approval"
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string "Unknown account"
+// @Failure 409 {object} map[string]string "Refused by the source account's balance or terms"
+// @Failure 429 {object} map[string]string "Rate limited; see Retry-After"
+// @Failure 500 {object} map[string]string
 // @Router /transactions [post]
 // @Tags transactions
 func (h *TransactionHandler) SubmitTransaction(c *gin.Context) {
@@ -35,10 +44,131 @@ func (h *TransactionHandler) SubmitTransaction(c *gin.Context) {
 		return
 	}
 
-	if err := h.transactionService.ProcessTransaction(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// The authenticated caller is the initiator, whatever the body claims
+	if p, ok := auth.FromContext(c.Request.Context()); ok {
+		req.InitiatedBy = p.Subject
+	}
+
+	transaction, err := h.transactionService.ProcessTransaction(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	switch transaction.Status {
+	case models.TransactionStatusHeld, models.TransactionStatusPendingApproval:
+		c.JSON(http.StatusAccepted, transaction) // 202
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction) // 201
+}
+
+// GetTransaction godoc
+// @Summary Get transaction by ID
+// @Produce json
+// @Param transaction_id path int true "Transaction ID"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /transactions/{transaction_id} [get]
+// @Tags transactions
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction_id format"})
+		return
+	}
+
+	transaction, err := h.transactionService.GetTransaction(c.Request.Context(), transactionID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// ListTransactions godoc
+// @Summary List transactions by status
+// @Produce json
+// @Param status query string true "Status, e.g. pending_approval"
+// @Success 200 {array} models.Transaction
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /transactions [get]
+// @Tags transactions
+func (h *TransactionHandler) ListTransactions(c *gin.Context) {
+	list, err := h.transactionService.ListTransactions(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ApproveTransaction godoc
+// @Summary Approve a pending transfer
+// @Description The approver must differ from the initiator. Funds move on approval.
+// @Accept json
+// @Produce json
+// @Param transaction_id path int true "Transaction ID"
+// @Param request body models.ReviewTransactionRequest true "Approver"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Not pending approval, expired, or refused by the source account's balance or terms"
+// @Failure 500 {object} map[string]string
+// @Router /transactions/{transaction_id}/approve [post]
+// @Tags transactions
+func (h *TransactionHandler) ApproveTransaction(c *gin.Context) {
+	h.review(c, h.transactionService.ApproveTransaction)
+}
+
+// RejectTransaction godoc
+// @Summary Reject a pending transfer
+// @Accept json
+// @Produce json
+// @Param transaction_id path int true "Transaction ID"
+// @Param request body models.ReviewTransactionRequest true "Approver"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Not pending approval, expired, or refused by the source account's balance or terms"
+// @Failure 500 {object} map[string]string
+// @Router /transactions/{transaction_id}/reject [post]
+// @Tags transactions
+func (h *TransactionHandler) RejectTransaction(c *gin.Context) {
+	h.review(c, h.transactionService.RejectTransaction)
+}
+
+func (h *TransactionHandler) review(c *gin.Context, fn func(context.Context, int, string, string) (*models.Transaction, error)) {
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction_id format"})
+		return
+	}
+
+	var req models.ReviewTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	if p, ok := auth.FromContext(c.Request.Context()); ok {
+		req.Approver = p.Subject
+	}
+
+	transaction, err := fn(c.Request.Context(), transactionID, req.Approver, req.Note)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.Status(http.StatusCreated) // 201
+	c.JSON(http.StatusOK, transaction)
 }
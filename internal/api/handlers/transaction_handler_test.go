@@ -2,43 +2,103 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"fastfunds/internal/statements"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockTransactionService struct {
-	processFn func(*models.TransactionRequest) error
+	processFn   func(*models.TransactionRequest) (*models.Transaction, error)
+	getFn       func(int) (*models.Transaction, error)
+	listFn      func(string) ([]*models.Transaction, error)
+	approveFn   func(int, string, string) (*models.Transaction, error)
+	rejectFn    func(int, string, string) (*models.Transaction, error)
+	statementFn func(int, time.Time, time.Time, statements.Writer) error
 }
 
-func (m *mockTransactionService) ProcessTransaction(req *models.TransactionRequest) error {
+func (m *mockTransactionService) ProcessTransaction(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error) {
 	if m.processFn != nil {
 		return m.processFn(req)
 	}
+	return nil, nil
+}
+
+func (m *mockTransactionService) GetTransaction(ctx context.Context, id int) (*models.Transaction, error) {
+	if m.getFn != nil {
+		return m.getFn(id)
+	}
+	return nil, nil
+}
+
+func (m *mockTransactionService) ListTransactions(ctx context.Context, status string) ([]*models.Transaction, error) {
+	if m.listFn != nil {
+		return m.listFn(status)
+	}
+	return nil, nil
+}
+
+func (m *mockTransactionService) ListAccountTransactions(ctx context.Context, accountID, beforeID, limit int) ([]*models.Transaction, error) {
+	return nil, nil
+}
+
+func (m *mockTransactionService) ApproveTransaction(ctx context.Context, id int, approver, note string) (*models.Transaction, error) {
+	if m.approveFn != nil {
+		return m.approveFn(id, approver, note)
+	}
+	return nil, nil
+}
+
+func (m *mockTransactionService) RejectTransaction(ctx context.Context, id int, approver, note string) (*models.Transaction, error) {
+	if m.rejectFn != nil {
+		return m.rejectFn(id, approver, note)
+	}
+	return nil, nil
+}
+
+func (m *mockTransactionService) WriteStatement(ctx context.Context, accountID int, from, to time.Time, w statements.Writer) error {
+	if m.statementFn != nil {
+		return m.statementFn(accountID, from, to, w)
+	}
 	return nil
 }
 
 func TestSubmitTransactionHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	cases := []struct {
-		name     string
-		body     interface{}
-		mockErr  error
-		wantCode int
-		wantBody string
+		name       string
+		body       interface{}
+		mockStatus string
+		mockErr    error
+		wantCode   int
+		wantBody   string
 	}{
-		{"invalid json", "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
-		{"service error", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "10.00"}, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
-		{"success", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "20.00"}, nil, http.StatusCreated, ""},
+		{"invalid json", "notjson", "", nil, http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "10.00"}, "", assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"unknown account", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "10.00"}, "", fmt.Errorf("%w: destination account not found", service.ErrNotFound), http.StatusNotFound, "destination account not found"},
+		{"refused", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "10.00"}, "", fmt.Errorf("%w: insufficient funds", service.ErrFailedPrecondition), http.StatusConflict, "insufficient funds"},
+		{"internal", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "10.00"}, "", fmt.Errorf("%w: couldn't lock accounts", service.ErrInternal), http.StatusInternalServerError, "couldn't lock accounts"},
+		{"success", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "20.00"}, models.TransactionStatusCompleted, nil, http.StatusCreated, `"status":"completed"`},
+		{"held", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "20.00"}, models.TransactionStatusHeld, nil, http.StatusAccepted, `"status":"held"`},
+		{"pending approval", models.TransactionRequest{SourceAccountNumber: testAccountNumber, DestinationAccountNumber: "FF14FAST7305618249", Amount: "20000.00", InitiatedBy: "alice"}, models.TransactionStatusPendingApproval, nil, http.StatusAccepted, `"status":"pending_approval"`},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			mockSvc := &mockTransactionService{processFn: func(req *models.TransactionRequest) error { return tc.mockErr }}
+			mockSvc := &mockTransactionService{processFn: func(req *models.TransactionRequest) (*models.Transaction, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.Transaction{ID: 7, Status: tc.mockStatus}, nil
+			}}
 			h := NewTransactionHandler(mockSvc)
 			r := gin.Default()
 			r.POST("/transactions", h.SubmitTransaction)
@@ -59,3 +119,104 @@ func TestSubmitTransactionHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTransactionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		param    string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad id", "abc", nil, http.StatusBadRequest, "Invalid transaction_id format"},
+		{"not found", "9", assert.AnError, http.StatusNotFound, assert.AnError.Error()},
+		{"success", "4", nil, http.StatusOK, `"id":4`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockTransactionService{getFn: func(id int) (*models.Transaction, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.Transaction{ID: id}, nil
+			}}
+			h := NewTransactionHandler(mockSvc)
+			r := gin.Default()
+			r.GET("/transactions/:transaction_id", h.GetTransaction)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/transactions/"+tc.param, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestListTransactionsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockTransactionService{listFn: func(status string) ([]*models.Transaction, error) {
+		if status == "bogus" {
+			return nil, assert.AnError
+		}
+		return []*models.Transaction{{ID: 1, Status: status}}, nil
+	}}
+	h := NewTransactionHandler(mockSvc)
+	r := gin.Default()
+	r.GET("/transactions", h.ListTransactions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transactions?status=bogus", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/transactions?status=pending_approval", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending_approval"`)
+}
+
+func TestReviewTransactionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		body     string
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{"bad id", "/transactions/abc/approve", `{"approver":"bob"}`, nil, http.StatusBadRequest, "Invalid transaction_id format"},
+		{"invalid json", "/transactions/1/approve", "notjson", nil, http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", "/transactions/1/approve", `{"approver":"alice"}`, assert.AnError, http.StatusBadRequest, assert.AnError.Error()},
+		{"approve", "/transactions/1/approve", `{"approver":"bob"}`, nil, http.StatusOK, `"status":"completed"`},
+		{"reject", "/transactions/1/reject", `{"approver":"bob","note":"wrong payee"}`, nil, http.StatusOK, `"status":"rejected"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			review := func(status string) func(int, string, string) (*models.Transaction, error) {
+				return func(id int, approver, note string) (*models.Transaction, error) {
+					if tc.mockErr != nil {
+						return nil, tc.mockErr
+					}
+					return &models.Transaction{ID: id, Status: status, ReviewedBy: approver, ReviewNote: note}, nil
+				}
+			}
+			mockSvc := &mockTransactionService{
+				approveFn: review(models.TransactionStatusCompleted),
+				rejectFn:  review(models.TransactionStatusRejected),
+			}
+			h := NewTransactionHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/transactions/:transaction_id/approve", h.ApproveTransaction)
+			r.POST("/transactions/:transaction_id/reject", h.RejectTransaction)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tc.path, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewWebhookHandler(webhookService service.IWebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+type WebhookHandler struct {
+	webhookService service.IWebhookService
+}
+
+// CreateSubscription godoc
+// @Summary Register a webhook endpoint
+// @Description Events of the listed types are POSTed to the URL, signed with the secret. A secret is generated when none is given; it is returned only in this response.
+// @Accept json
+// @Produce json
+// @Param request body models.WebhookSubscriptionRequest true "Endpoint, event types and optional secret"
+// @Success 201 {object} models.CreatedWebhookSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /webhooks [post]
+// @Tags webhooks
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req models.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	sub, err := h.webhookService.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListSubscriptions godoc
+// @Summary List webhook subscriptions
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /webhooks [get]
+// @Tags webhooks
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	list, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetSubscription godoc
+// @Summary Get webhook subscription by ID
+// @Produce json
+// @Param subscription_id path int true "Subscription ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{subscription_id} [get]
+// @Tags webhooks
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	subID, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.webhookService.GetSubscription(c.Request.Context(), subID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// DeleteSubscription godoc
+// @Summary Delete a webhook subscription
+// @Description Also deletes its delivery log; pending deliveries are not sent.
+// @Param subscription_id path int true "Subscription ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{subscription_id} [delete]
+// @Tags webhooks
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	subID, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), subID); err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary List a subscription's latest deliveries
+// @Produce json
+// @Param subscription_id path int true "Subscription ID"
+// @Param status query string false "Filter by status (pending, delivered, dead)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /webhooks/{subscription_id}/deliveries [get]
+// @Tags webhooks
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	subID, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.webhookService.ListDeliveries(c.Request.Context(), subID, c.Query("status"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetDelivery godoc
+// @Summary Get a delivery with its attempt log
+// @Produce json
+// @Param subscription_id path int true "Subscription ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{subscription_id}/deliveries/{delivery_id} [get]
+// @Tags webhooks
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	subID, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryID(c)
+	if !ok {
+		return
+	}
+
+	d, err := h.webhookService.GetDelivery(c.Request.Context(), subID, deliveryID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+// ReplayDelivery godoc
+// @Summary Send a delivery again
+// @Description Requeues a delivered or dead-lettered delivery with a fresh set of retries.
+// @Produce json
+// @Param subscription_id path int true "Subscription ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /webhooks/{subscription_id}/deliveries/{delivery_id}/replay [post]
+// @Tags webhooks
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	subID, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryID(c)
+	if !ok {
+		return
+	}
+
+	d, err := h.webhookService.ReplayDelivery(c.Request.Context(), subID, deliveryID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, d)
+}
+
+func subscriptionID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription_id format"})
+		return 0, false
+	}
+	return id, true
+}
+
+func deliveryID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery_id format"})
+		return 0, false
+	}
+	return id, true
+}
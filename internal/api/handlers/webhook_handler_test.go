@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWebhookService struct {
+	createFn func(*models.WebhookSubscriptionRequest) (*models.CreatedWebhookSubscription, error)
+	getFn    func(int) (*models.WebhookSubscription, error)
+	replayFn func(int, int64) (*models.WebhookDelivery, error)
+}
+
+func (m *mockWebhookService) CreateSubscription(ctx context.Context, req *models.WebhookSubscriptionRequest) (*models.CreatedWebhookSubscription, error) {
+	return m.createFn(req)
+}
+func (m *mockWebhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return nil, nil
+}
+func (m *mockWebhookService) GetSubscription(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	return m.getFn(id)
+}
+func (m *mockWebhookService) DeleteSubscription(ctx context.Context, id int) error {
+	return nil
+}
+func (m *mockWebhookService) ListDeliveries(ctx context.Context, subscriptionID int, status string) ([]*models.WebhookDelivery, error) {
+	return nil, nil
+}
+func (m *mockWebhookService) GetDelivery(ctx context.Context, subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	return nil, nil
+}
+func (m *mockWebhookService) ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	return m.replayFn(subscriptionID, id)
+}
+
+func TestCreateSubscriptionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockWebhookService{createFn: func(req *models.WebhookSubscriptionRequest) (*models.CreatedWebhookSubscription, error) {
+		if req.URL == "" {
+			return nil, errors.New("url must be an absolute http or https URL")
+		}
+		sub := &models.WebhookSubscription{ID: 1, URL: req.URL, EventTypes: req.EventTypes, Secret: "whsec_abc"}
+		return &models.CreatedWebhookSubscription{WebhookSubscription: sub, Secret: sub.Secret}, nil
+	}}
+	h := NewWebhookHandler(mockSvc)
+	r := gin.Default()
+	r.POST("/webhooks", h.CreateSubscription)
+
+	cases := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"invalid json", "notjson", http.StatusBadRequest, "Invalid JSON format"},
+		{"service error", `{"event_types":["AccountCreated"]}`, http.StatusBadRequest, "url must be"},
+		{"success shows the secret once", `{"url":"https://example.com","event_types":["AccountCreated"]}`, http.StatusCreated, `"secret":"whsec_abc"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestGetSubscriptionHandler_HidesSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockWebhookService{getFn: func(id int) (*models.WebhookSubscription, error) {
+		if id != 1 {
+			return nil, errors.New("webhook subscription not found")
+		}
+		return &models.WebhookSubscription{ID: 1, URL: "https://example.com", Secret: "whsec_abc"}, nil
+	}}
+	h := NewWebhookHandler(mockSvc)
+	r := gin.Default()
+	r.GET("/webhooks/:subscription_id", h.GetSubscription)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "whsec_abc")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReplayDeliveryHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		path     string
+		mockErr  error
+		wantCode int
+	}{
+		{"invalid delivery id", "/webhooks/1/deliveries/x/replay", nil, http.StatusBadRequest},
+		{"already pending", "/webhooks/1/deliveries/5/replay", errors.New("webhook delivery is already pending"), http.StatusBadRequest},
+		{"forbidden", "/webhooks/1/deliveries/5/replay", service.ErrForbidden, http.StatusForbidden},
+		{"success", "/webhooks/1/deliveries/5/replay", nil, http.StatusAccepted},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockWebhookService{replayFn: func(subID int, id int64) (*models.WebhookDelivery, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				return &models.WebhookDelivery{ID: id, SubscriptionID: subID, Status: models.WebhookDeliveryPending}, nil
+			}}
+			h := NewWebhookHandler(mockSvc)
+			r := gin.Default()
+			r.POST("/webhooks/:subscription_id/deliveries/:delivery_id/replay", h.ReplayDelivery)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", tc.path, nil))
+			assert.Equal(t, tc.wantCode, w.Code)
+		})
+	}
+}
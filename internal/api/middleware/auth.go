@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"errors"
+	"fastfunds/internal/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalKey is the gin context key the authenticated principal is stored
+// under, alongside the request context.
+const PrincipalKey = "principal"
+
+// Authenticate rejects requests the authenticator can't identify and puts
+// the principal into the request context for handlers and services.
+func Authenticate(a auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := a.Authenticate(c.Request)
+		if err != nil {
+			msg := "invalid credentials"
+			if errors.Is(err, auth.ErrNoCredentials) {
+				msg = "authentication required"
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="fastfunds"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+
+		c.Set(PrincipalKey, p)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fastfunds/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type authenticatorFunc func(*http.Request) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(r *http.Request) (*auth.Principal, error) { return f(r) }
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{"no credentials", auth.ErrNoCredentials, http.StatusUnauthorized, "authentication required"},
+		{"rejected", auth.ErrInvalidCredentials, http.StatusUnauthorized, "invalid credentials"},
+		{"authenticated", nil, http.StatusOK, "alice"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := authenticatorFunc(func(*http.Request) (*auth.Principal, error) {
+				if tc.err != nil {
+					return nil, tc.err
+				}
+				return &auth.Principal{Subject: "alice", Method: auth.MethodJWT}, nil
+			})
+			r := gin.New()
+			r.GET("/", Authenticate(a), func(c *gin.Context) {
+				p, _ := auth.FromContext(c.Request.Context())
+				c.String(http.StatusOK, p.Subject)
+			})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+			if tc.err != nil {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fastfunds/internal/auth"
+	"fastfunds/internal/ratelimit"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rate limit response headers, from the IETF RateLimit header fields draft.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// KeyFunc picks the bucket a request counts against. An empty key skips the
+// limit for that request.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP keys requests on the client address.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByPrincipal keys requests on the authenticated caller.
+func ByPrincipal(c *gin.Context) string {
+	p, ok := auth.FromContext(c.Request.Context())
+	if !ok {
+		return ""
+	}
+	return p.Key()
+}
+
+// RateLimit rejects requests over limit with 429 and a Retry-After header.
+// Buckets are named scope plus the request key, so the same caller can have
+// separate limits for different routes. When several limits apply, the
+// RateLimit-* headers describe the one with the fewest requests left. If the
+// limiter fails the request is let through.
+func RateLimit(limiter ratelimit.Limiter, limit ratelimit.Limit, scope string, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), scope+":"+k, limit)
+		if err != nil {
+			log.Print("rate limiter unavailable, allowing request:", err)
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, res)
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func setRateLimitHeaders(c *gin.Context, res ratelimit.Result) {
+	h := c.Writer.Header()
+	if prev := h.Get(HeaderRateLimitRemaining); prev != "" {
+		if n, err := strconv.Atoi(prev); err == nil && n <= res.Remaining {
+			return
+		}
+	}
+	h.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+	h.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+	h.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
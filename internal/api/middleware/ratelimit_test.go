@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("db down")
+}
+
+func withPrincipal(p *auth.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.NewMemoryLimiter()
+	r := gin.New()
+	r.GET("/", RateLimit(limiter, ratelimit.Limit{Requests: 2, Period: time.Minute}, "ip", ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "30", w.Header().Get(HeaderRateLimitReset))
+
+	do("10.0.0.1:1234")
+	w = do("10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "rate limit exceeded")
+
+	assert.Equal(t, http.StatusOK, do("10.0.0.2:1234").Code)
+}
+
+func TestRateLimit_PerPrincipalAndScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.NewMemoryLimiter()
+	loose := RateLimit(limiter, ratelimit.Limit{Requests: 10, Period: time.Minute}, "principal", ByPrincipal)
+	strict := RateLimit(limiter, ratelimit.Limit{Requests: 1, Period: time.Minute}, "transfers", ByPrincipal)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r := gin.New()
+	alice := withPrincipal(&auth.Principal{Subject: "alice", Method: auth.MethodJWT})
+	bob := withPrincipal(&auth.Principal{Subject: "bob", Method: auth.MethodAPIKey, APIKeyID: 4})
+	r.GET("/alice", alice, loose, ok)
+	r.POST("/alice", alice, loose, strict, ok)
+	r.POST("/bob", bob, loose, strict, ok)
+	r.GET("/anonymous", loose, ok)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The stricter limit's headers win
+	w := do("POST", "/alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+
+	assert.Equal(t, http.StatusTooManyRequests, do("POST", "/alice").Code)
+	// The general limit still has room
+	w = do("GET", "/alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "7", w.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, http.StatusOK, do("POST", "/bob").Code)
+
+	// Unauthenticated requests aren't counted per principal
+	w = do("GET", "/anonymous")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderRateLimitLimit))
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", RateLimit(failingLimiter{}, ratelimit.Limit{Requests: 1, Period: time.Minute}, "ip", ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
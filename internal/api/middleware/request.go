@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fastfunds/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID carries the request ID in both directions.
+const HeaderRequestID = "X-Request-ID"
+
+// RequestInfo gives every request an ID and records it with the client IP
+// for the audit log. A well-formed X-Request-ID from the client is kept so
+// calls can be traced across services; otherwise a random one is assigned.
+// The ID is echoed in the response.
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !audit.ValidRequestID(id) {
+			id = audit.NewRequestID()
+		}
+		c.Header(HeaderRequestID, id)
+
+		ctx := audit.WithRequest(c.Request.Context(), audit.RequestInfo{ID: id, IP: c.ClientIP()})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fastfunds/internal/audit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var got audit.RequestInfo
+	r.GET("/", RequestInfo(), func(c *gin.Context) {
+		got, _ = audit.RequestFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	do := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if id != "" {
+			req.Header.Set(HeaderRequestID, id)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("trace-42.a_b")
+	assert.Equal(t, "trace-42.a_b", w.Header().Get(HeaderRequestID))
+	assert.Equal(t, audit.RequestInfo{ID: "trace-42.a_b", IP: "10.0.0.1"}, got)
+
+	for _, bad := range []string{"", "has space", "new\nline", strings.Repeat("a", 129)} {
+		w = do(bad)
+		assert.Len(t, w.Header().Get(HeaderRequestID), 32, "for %q", bad)
+		assert.Equal(t, w.Header().Get(HeaderRequestID), got.ID)
+	}
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fastfunds/internal/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GenesisHash is the PrevHash of the first entry in the log.
+var GenesisHash = strings.Repeat("0", 64)
+
+// timeLayout matches the microsecond precision Postgres stores, so a
+// timestamp hashes the same before and after a round trip.
+const timeLayout = "2006-01-02T15:04:05.000000Z"
+
+// Canonical re-encodes a JSON document with sorted object keys and no
+// insignificant whitespace. Numbers keep their literal form. JSONB reorders
+// keys and drops whitespace, so hashes are computed over this form rather
+// than the bytes first written. Empty input and null return nil.
+func Canonical(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// hashedEntry fixes the fields, and their order, that an entry's hash covers.
+type hashedEntry struct {
+	PrevHash   string          `json:"prev_hash"`
+	OccurredAt string          `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	ActorRole  string          `json:"actor_role"`
+	AuthMethod string          `json:"auth_method"`
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
+
+// Hash returns the SHA-256 of the entry's canonical form, hex encoded.
+func Hash(e *models.AuditEntry) (string, error) {
+	before, err := Canonical(e.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := Canonical(e.After)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(hashedEntry{
+		PrevHash:   e.PrevHash,
+		OccurredAt: e.OccurredAt.UTC().Format(timeLayout),
+		Actor:      e.Actor,
+		ActorRole:  e.ActorRole,
+		AuthMethod: e.AuthMethod,
+		RequestID:  e.RequestID,
+		IP:         e.IP,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Before:     nullable(before),
+		After:      nullable(after),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func nullable(raw json.RawMessage) json.RawMessage {
+	if raw == nil {
+		return json.RawMessage("null")
+	}
+	return raw
+}
+
+// Link chains e onto the entry whose hash is prevHash and sets e.Hash.
+func Link(e *models.AuditEntry, prevHash string) error {
+	e.PrevHash = prevHash
+	e.OccurredAt = e.OccurredAt.Truncate(time.Microsecond)
+	h, err := Hash(e)
+	if err != nil {
+		return err
+	}
+	e.Hash = h
+	return nil
+}
+
+// ChainError reports the first entry at which verification failed.
+type ChainError struct {
+	ID     int64
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit log entry %d: %s", e.ID, e.Reason)
+}
+
+// Verifier checks entries in log order against the chain so far.
+type Verifier struct {
+	head  string
+	count int
+}
+
+func NewVerifier() *Verifier {
+	return &Verifier{head: GenesisHash}
+}
+
+// Check verifies that e follows the previous entry and that its contents
+// match its hash. It returns a *ChainError on the first mismatch.
+func (v *Verifier) Check(e *models.AuditEntry) error {
+	if e.PrevHash != v.head {
+		return &ChainError{ID: e.ID, Reason: "prev_hash does not match the preceding entry; entries were removed or reordered"}
+	}
+	h, err := Hash(e)
+	if err != nil {
+		return &ChainError{ID: e.ID, Reason: "unreadable contents: " + err.Error()}
+	}
+	if h != e.Hash {
+		return &ChainError{ID: e.ID, Reason: "hash does not match the contents; the entry was modified"}
+	}
+	v.head = e.Hash
+	v.count++
+	return nil
+}
+
+// Head is the hash of the last verified entry, or GenesisHash.
+func (v *Verifier) Head() string {
+	return v.head
+}
+
+// Count is the number of entries verified.
+func (v *Verifier) Count() int {
+	return v.count
+}
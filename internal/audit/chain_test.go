@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{`{"b": 1, "a": {"d": [1, 2.50], "c": null}}`, `{"a":{"c":null,"d":[1,2.50]},"b":1}`},
+		{`{"big": 12345678901234567890}`, `{"big":12345678901234567890}`},
+		{`null`, ``},
+		{``, ``},
+	}
+	for _, tc := range cases {
+		got, err := Canonical(json.RawMessage(tc.in))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, string(got))
+	}
+
+	_, err := Canonical(json.RawMessage(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestNewEntry(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ops", Role: auth.RoleOperator, Method: auth.MethodAPIKey})
+	ctx = WithRequest(ctx, RequestInfo{ID: "req-1", IP: "203.0.113.9"})
+
+	e, err := NewEntry(ctx, ActionCustomerUpdate, EntityCustomer, 7,
+		&models.Customer{ID: 7, Name: "Jane"}, map[string]string{"name": "Jane Doe"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "ops", e.Actor)
+	assert.Equal(t, auth.RoleOperator, e.ActorRole)
+	assert.Equal(t, auth.MethodAPIKey, e.AuthMethod)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.Equal(t, "203.0.113.9", e.IP)
+	assert.Equal(t, "7", e.EntityID)
+	assert.Equal(t, `{"name":"Jane Doe"}`, string(e.After))
+	assert.Equal(t, e.OccurredAt, e.OccurredAt.Truncate(time.Microsecond))
+
+	var none *models.Customer
+	e, err = NewEntry(context.Background(), ActionCustomerCreate, EntityCustomer, 8, none, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, SystemActor, e.Actor)
+	assert.Nil(t, e.Before)
+	assert.Nil(t, e.After)
+}
+
+// chain builds n linked entries.
+func chain(t *testing.T, n int) []*models.AuditEntry {
+	t.Helper()
+	prev := GenesisHash
+	var list []*models.AuditEntry
+	for i := 1; i <= n; i++ {
+		e, err := NewEntry(context.Background(), ActionAccountDebit, EntityAccount, 100,
+			map[string]int{"balance": 1000}, map[string]int{"balance": 1000 - i})
+		if err == nil {
+			err = Link(e, prev)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.ID = int64(i)
+		prev = e.Hash
+		list = append(list, e)
+	}
+	return list
+}
+
+func verify(entries []*models.AuditEntry) (*Verifier, error) {
+	v := NewVerifier()
+	for _, e := range entries {
+		if err := v.Check(e); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+func TestVerifier(t *testing.T) {
+	t.Run("intact", func(t *testing.T) {
+		entries := chain(t, 3)
+		v, err := verify(entries)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, v.Count())
+		assert.Equal(t, entries[2].Hash, v.Head())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		v, err := verify(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, GenesisHash, v.Head())
+	})
+
+	t.Run("survives_jsonb_round_trip", func(t *testing.T) {
+		entries := chain(t, 2)
+		// JSONB reorders keys and adds whitespace on output
+		entries[1].Before = json.RawMessage(`{"balance": 1000}`)
+		entries[1].OccurredAt = entries[1].OccurredAt.In(time.FixedZone("X", 3600))
+		_, err := verify(entries)
+		assert.NoError(t, err)
+	})
+
+	tamper := []struct {
+		name   string
+		change func([]*models.AuditEntry) []*models.AuditEntry
+		failAt int64
+	}{
+		{"modified_after", func(l []*models.AuditEntry) []*models.AuditEntry {
+			l[1].After = json.RawMessage(`{"balance":5}`)
+			return l
+		}, 2},
+		{"modified_actor", func(l []*models.AuditEntry) []*models.AuditEntry {
+			l[0].Actor = "someone else"
+			return l
+		}, 1},
+		{"rehashed_without_relinking", func(l []*models.AuditEntry) []*models.AuditEntry {
+			l[1].After = json.RawMessage(`{"balance":5}`)
+			l[1].Hash, _ = Hash(l[1])
+			return l
+		}, 3},
+		{"removed", func(l []*models.AuditEntry) []*models.AuditEntry {
+			return append(l[:1], l[2:]...)
+		}, 3},
+		{"reordered", func(l []*models.AuditEntry) []*models.AuditEntry {
+			l[1], l[2] = l[2], l[1]
+			return l
+		}, 3},
+	}
+	for _, tc := range tamper {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verify(tc.change(chain(t, 3)))
+			var chainErr *ChainError
+			if assert.True(t, errors.As(err, &chainErr), "got %v", err) {
+				assert.Equal(t, tc.failAt, chainErr.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package audit builds and verifies the hash-chained audit log.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fmt"
+	"time"
+)
+
+// SystemActor is recorded for changes made without a principal, such as the
+// background expiry of pending approvals.
+const SystemActor = "system"
+
+// Actions recorded in the audit log.
+const (
+	ActionAccountCreate         = "account.create"
+	ActionAccountDebit          = "account.debit"
+	ActionAccountCredit         = "account.credit"
+	ActionHolderAdd             = "account_holder.add"
+	ActionHolderRemove          = "account_holder.remove"
+	ActionTransactionCreate     = "transaction.create"
+	ActionTransactionApprove    = "transaction.approve"
+	ActionTransactionReject     = "transaction.reject"
+	ActionTransactionResume     = "transaction.resume"
+	ActionTransactionExpire     = "transaction.expire"
+	ActionScreeningCaseOpen     = "screening_case.open"
+	ActionScreeningCaseClear    = "screening_case.clear"
+	ActionScreeningCaseConfirm  = "screening_case.confirm"
+	ActionCustomerCreate        = "customer.create"
+	ActionCustomerUpdate        = "customer.update"
+	ActionExternalAccountCreate = "external_account.create"
+	ActionExternalAccountDelete = "external_account.delete"
+	ActionAPIKeyCreate          = "api_key.create"
+	ActionAPIKeyRotate          = "api_key.rotate"
+	ActionAPIKeyRevoke          = "api_key.revoke"
+	ActionWebhookCreate         = "webhook_subscription.create"
+	ActionWebhookDelete         = "webhook_subscription.delete"
+	ActionWebhookReplay         = "webhook_delivery.replay"
+	ActionTransferImportCreate  = "transfer_import.create"
+	ActionTransferImportConfirm = "transfer_import.confirm"
+	ActionPayoutCreate          = "payout.create"
+	ActionPayoutReturn          = "payout.return"
+	ActionACHFileCreate         = "ach_file.create"
+	ActionBusinessDayClose      = "business_day.close"
+	ActionLedgerAccountCreate   = "ledger_account.create"
+	ActionProductCreate         = "product.create"
+	ActionProductUpdate         = "product.update"
+	ActionProductMigrate        = "product.migrate"
+	ActionEscrowCreate          = "escrow.create"
+	ActionEscrowDispute         = "escrow.dispute"
+	ActionEscrowRelease         = "escrow.release"
+	ActionEscrowRefund          = "escrow.refund"
+	ActionDisputeOpen           = "dispute.open"
+	ActionDisputeReview         = "dispute.review"
+	ActionDisputeNote           = "dispute.note"
+	ActionDisputeResolve        = "dispute.resolve"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityAccount         = "account"
+	EntityTransaction     = "transaction"
+	EntityScreeningCase   = "screening_case"
+	EntityCustomer        = "customer"
+	EntityExternalAccount = "external_account"
+	EntityAPIKey          = "api_key"
+	EntityWebhook         = "webhook_subscription"
+	EntityWebhookDelivery = "webhook_delivery"
+	EntityTransferImport  = "transfer_import"
+	EntityPayout          = "payout"
+	EntityACHFile         = "ach_file"
+	EntityBusinessDay     = "business_day"
+	EntityLedgerAccount   = "ledger_account"
+	EntityProduct         = "product"
+	EntityEscrow          = "escrow"
+	EntityDispute         = "dispute"
+)
+
+// RequestInfo identifies the HTTP request or gRPC call a change was made in.
+type RequestInfo struct {
+	ID string
+	IP string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a copy of ctx carrying info.
+func WithRequest(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey{}, info)
+}
+
+// RequestFromContext returns the request info stored by WithRequest, if any.
+func RequestFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestKey{}).(RequestInfo)
+	return info, ok
+}
+
+// NewEntry describes a change to an entity on behalf of the caller in ctx.
+// before and after are marshalled to JSON; nil means the entity didn't exist
+// before or doesn't exist after. PrevHash and Hash are set when the entry is
+// appended.
+func NewEntry(ctx context.Context, action, entityType string, entityID any, before, after any) (*models.AuditEntry, error) {
+	e := &models.AuditEntry{
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+		Actor:      SystemActor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		e.Actor = p.Subject
+		e.ActorRole = p.Role
+		e.AuthMethod = p.Method
+	}
+	if info, ok := RequestFromContext(ctx); ok {
+		e.RequestID = info.ID
+		e.IP = info.IP
+	}
+
+	var err error
+	if e.Before, err = marshalState(before); err != nil {
+		return nil, err
+	}
+	if e.After, err = marshalState(after); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func marshalState(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Canonical(raw)
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fastfunds/internal/models"
+	"net/http"
+	"strings"
+)
+
+// APIKeyPrefix starts every key so they are recognizable in logs and
+// secret scanners.
+const APIKeyPrefix = "ffk_"
+
+// APIKeyHeader is an alternative to "Authorization: Bearer <key>".
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyStore looks up stored keys by their public lookup prefix.
+type APIKeyStore interface {
+	GetByPrefix(prefix string) (*models.APIKey, error)
+}
+
+// GenerateAPIKey returns a new key as "ffk_<lookup>_<secret>" along with its
+// lookup prefix and the hash to store. The plaintext key is never stored.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	lookup := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err = rand.Read(lookup); err != nil {
+		return "", "", "", err
+	}
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(lookup)
+	key = APIKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes a full key for storage. Keys carry 256 bits of entropy,
+// so a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseAPIKey returns the lookup prefix of a well-formed key.
+func parseAPIKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// APIKeyAuthenticator accepts keys sent as a bearer token or in X-API-Key.
+type APIKeyAuthenticator struct {
+	store APIKeyStore
+}
+
+func NewAPIKeyAuthenticator(store APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		if token, ok := bearerToken(r); ok && strings.HasPrefix(token, APIKeyPrefix) {
+			key = token
+		}
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	stored, err := a.store.GetByPrefix(prefix)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(HashAPIKey(key))) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	if stored.RevokedAt != nil {
+		return nil, errors.New("api key revoked")
+	}
+
+	var customerID int
+	if stored.CustomerID != nil {
+		customerID = *stored.CustomerID
+	}
+	if !validRoleBinding(stored.Role, customerID) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{Subject: stored.Name, Method: MethodAPIKey, Role: stored.Role, CustomerID: customerID, APIKeyID: stored.ID}, nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(h, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fastfunds/internal/models"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore map[string]*models.APIKey
+
+func (s fakeStore) GetByPrefix(prefix string) (*models.APIKey, error) {
+	if k, ok := s[prefix]; ok {
+		return k, nil
+	}
+	return nil, errors.New("api key not found")
+}
+
+func requestWith(header, value string) *http.Request {
+	r, _ := http.NewRequest("GET", "/", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	revokedKey, revokedPrefix, revokedHash, _ := GenerateAPIKey()
+	customerKey, customerPrefix, customerHash, _ := GenerateAPIKey()
+	unboundKey, unboundPrefix, unboundHash, _ := GenerateAPIKey()
+	revokedAt := "2026-01-01T00:00:00Z"
+	customerID := 7
+
+	a := NewAPIKeyAuthenticator(fakeStore{
+		prefix:         {ID: 1, Name: "ops-bot", Role: RoleOperator, Prefix: prefix, Hash: hash},
+		revokedPrefix:  {ID: 2, Name: "old", Role: RoleOperator, Prefix: revokedPrefix, Hash: revokedHash, RevokedAt: &revokedAt},
+		customerPrefix: {ID: 3, Name: "app", Role: RoleCustomer, CustomerID: &customerID, Prefix: customerPrefix, Hash: customerHash},
+		unboundPrefix:  {ID: 4, Name: "broken", Role: RoleCustomer, Prefix: unboundPrefix, Hash: unboundHash},
+	})
+
+	cases := []struct {
+		name    string
+		header  string
+		value   string
+		wantErr error
+	}{
+		{"bearer", "Authorization", "Bearer " + key, nil},
+		{"x_api_key", APIKeyHeader, key, nil},
+		{"none", "", "", ErrNoCredentials},
+		{"jwt_is_not_ours", "Authorization", "Bearer eyJhbGciOi", ErrNoCredentials},
+		{"wrong_secret", APIKeyHeader, APIKeyPrefix + prefix + "_nope", ErrInvalidCredentials},
+		{"malformed", APIKeyHeader, "ffk_short", ErrInvalidCredentials},
+		{"unknown_prefix", APIKeyHeader, APIKeyPrefix + "000000000000_secret", ErrInvalidCredentials},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.Authenticate(requestWith(tc.header, tc.value))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &Principal{Subject: "ops-bot", Method: MethodAPIKey, Role: RoleOperator, APIKeyID: 1}, p)
+		})
+	}
+
+	_, err = a.Authenticate(requestWith(APIKeyHeader, revokedKey))
+	assert.EqualError(t, err, "api key revoked")
+
+	p, err := a.Authenticate(requestWith(APIKeyHeader, customerKey))
+	assert.NoError(t, err)
+	assert.Equal(t, RoleCustomer, p.Role)
+	assert.Equal(t, 7, p.CustomerID)
+
+	_, err = a.Authenticate(requestWith(APIKeyHeader, unboundKey))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "OKP", "crv": "Ed25519", "kid": "ed-1", "x": base64.RawURLEncoding.EncodeToString(edPub)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+
+	keys := NewKeySet()
+	assert.Error(t, keys.SetHMACSecret([]byte("short")))
+	assert.NoError(t, keys.SetHMACSecret(secret))
+	assert.NoError(t, keys.AddJWKS(jwks))
+	a := NewJWTAuthenticator(keys, "https://issuer.example", "fastfunds")
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+		tok := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			tok.Header["kid"] = kid
+		}
+		s, err := tok.SignedString(key)
+		assert.NoError(t, err)
+		return s
+	}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "alice", "role": RoleOperator, "iss": "https://issuer.example", "aud": "fastfunds", "exp": time.Now().Add(time.Hour).Unix()}
+	}
+	with := func(k string, v interface{}) jwt.MapClaims {
+		c := valid()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+	otherRSA, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	cases := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"hs256", sign(jwt.SigningMethodHS256, "", secret, valid()), true},
+		{"rs256_by_kid", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, valid()), true},
+		{"rs256_single_key_no_kid", sign(jwt.SigningMethodRS256, "", rsaKey, valid()), true},
+		{"eddsa", sign(jwt.SigningMethodEdDSA, "ed-1", edPriv, valid()), true},
+		{"rs256_wrong_key", sign(jwt.SigningMethodRS256, "rsa-1", otherRSA, valid()), false},
+		{"unknown_kid", sign(jwt.SigningMethodRS256, "rsa-9", rsaKey, valid()), false},
+		{"hs384_not_allowed", sign(jwt.SigningMethodHS384, "", secret, valid()), false},
+		{"expired", sign(jwt.SigningMethodHS256, "", secret, with("exp", time.Now().Add(-time.Hour).Unix())), false},
+		{"missing_exp", sign(jwt.SigningMethodHS256, "", secret, with("exp", nil)), false},
+		{"missing_sub", sign(jwt.SigningMethodHS256, "", secret, with("sub", nil)), false},
+		{"wrong_issuer", sign(jwt.SigningMethodHS256, "", secret, with("iss", "https://evil.example")), false},
+		{"wrong_audience", sign(jwt.SigningMethodHS256, "", secret, with("aud", "other")), false},
+		{"missing_role", sign(jwt.SigningMethodHS256, "", secret, with("role", nil)), false},
+		{"unknown_role", sign(jwt.SigningMethodHS256, "", secret, with("role", "root")), false},
+		{"customer_without_customer_id", sign(jwt.SigningMethodHS256, "", secret, with("role", RoleCustomer)), false},
+		{"operator_with_customer_id", sign(jwt.SigningMethodHS256, "", secret, with("customer_id", 7)), false},
+		{"garbage", "not.a.jwt", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.Authenticate(requestWith("Authorization", "Bearer "+tc.token))
+			if !tc.ok {
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", p.Subject)
+			assert.Equal(t, MethodJWT, p.Method)
+			assert.Equal(t, RoleOperator, p.Role)
+		})
+	}
+
+	customerClaims := with("role", RoleCustomer)
+	customerClaims["customer_id"] = 7
+	p, err := a.Authenticate(requestWith("Authorization", "Bearer "+sign(jwt.SigningMethodHS256, "", secret, customerClaims)))
+	assert.NoError(t, err)
+	assert.Equal(t, &Principal{Subject: "alice", Method: MethodJWT, Role: RoleCustomer, CustomerID: 7}, p)
+
+	_, err = a.Authenticate(requestWith("", ""))
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestAddPublicKeyPEM(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	keys := NewKeySet()
+	assert.True(t, keys.Empty())
+	assert.NoError(t, keys.AddPublicKeyPEM("", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	assert.False(t, keys.Empty())
+	assert.Error(t, keys.AddPublicKeyPEM("", []byte("nope")))
+}
+
+func TestChain(t *testing.T) {
+	key, prefix, hash, _ := GenerateAPIKey()
+	chain := Chain{NewAPIKeyAuthenticator(fakeStore{prefix: {ID: 1, Name: "bot", Role: RoleAdmin, Hash: hash}}), NewJWTAuthenticator(NewKeySet(), "", "")}
+
+	p, err := chain.Authenticate(requestWith("Authorization", "Bearer "+key))
+	assert.NoError(t, err)
+	assert.Equal(t, "bot", p.Subject)
+
+	_, err = chain.Authenticate(requestWith("Authorization", "Bearer a.b.c"))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = chain.Authenticate(requestWith("", ""))
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestPrincipalContext(t *testing.T) {
+	r := requestWith("", "")
+	_, ok := FromContext(r.Context())
+	assert.False(t, ok)
+
+	ctx := WithPrincipal(r.Context(), &Principal{Subject: "alice"})
+	p, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", p.Subject)
+}
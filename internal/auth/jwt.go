@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the locally configured keys JWTs are verified against.
+// Asymmetric keys are indexed by "kid"; a token without a kid is accepted
+// only when exactly one key of its algorithm is configured.
+type KeySet struct {
+	hmac    []byte
+	rsa     map[string]*rsa.PublicKey
+	ed25519 map[string]ed25519.PublicKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{
+		rsa:     map[string]*rsa.PublicKey{},
+		ed25519: map[string]ed25519.PublicKey{},
+	}
+}
+
+// Empty reports whether no keys are configured.
+func (ks *KeySet) Empty() bool {
+	return len(ks.hmac) == 0 && len(ks.rsa) == 0 && len(ks.ed25519) == 0
+}
+
+// SetHMACSecret enables HS256 with the given shared secret.
+func (ks *KeySet) SetHMACSecret(secret []byte) error {
+	if len(secret) < 32 {
+		return errors.New("HS256 secret must be at least 32 bytes")
+	}
+	ks.hmac = secret
+	return nil
+}
+
+// AddPublicKeyPEM adds an RSA or Ed25519 public key in PKIX PEM form.
+func (ks *KeySet) AddPublicKeyPEM(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("no PEM block found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		ks.rsa[kid] = k
+	case ed25519.PublicKey:
+		ks.ed25519[kid] = k
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// LoadPublicKeyFile reads a PEM public key file, registered without a kid.
+func (ks *KeySet) LoadPublicKeyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return ks.AddPublicKeyPEM("", data)
+}
+
+// LoadJWKSFile reads a JSON Web Key Set file.
+func (ks *KeySet) LoadJWKSFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return ks.AddJWKS(data)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+}
+
+// AddJWKS adds the RSA and Ed25519 signing keys of a JWKS document. Keys
+// for other uses or of other types are skipped.
+func (ks *KeySet) AddJWKS(data []byte) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return fmt.Errorf("key %q: invalid modulus", k.Kid)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil || len(e) == 0 || len(e) > 4 {
+				return fmt.Errorf("key %q: invalid exponent", k.Kid)
+			}
+			ks.rsa[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "OKP" && k.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return fmt.Errorf("key %q: invalid Ed25519 key", k.Kid)
+			}
+			ks.ed25519[k.Kid] = ed25519.PublicKey(x)
+		}
+	}
+	return nil
+}
+
+func (ks *KeySet) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if len(ks.hmac) == 0 {
+			return nil, errors.New("HS256 not configured")
+		}
+		return ks.hmac, nil
+	case jwt.SigningMethodRS256.Alg():
+		return pick(ks.rsa, kid)
+	case jwt.SigningMethodEdDSA.Alg():
+		return pick(ks.ed25519, kid)
+	}
+	return nil, fmt.Errorf("unsupported algorithm %s", token.Method.Alg())
+}
+
+func pick[K any](keys map[string]K, kid string) (interface{}, error) {
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// JWTAuthenticator verifies bearer JWTs signed with HS256, RS256 or EdDSA.
+// Tokens must carry "exp", "sub" and "role", plus "customer_id" for the
+// customer role; "iss" and "aud" are checked when configured.
+type JWTAuthenticator struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(keys *KeySet, issuer, audience string) *JWTAuthenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &JWTAuthenticator{keys: keys, parser: jwt.NewParser(opts...)}
+}
+
+// Claims are the JWT claims FastFunds reads.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role       string `json:"role"`
+	CustomerID int    `json:"customer_id,omitempty"`
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.HasPrefix(token, APIKeyPrefix) {
+		return nil, ErrNoCredentials
+	}
+
+	var claims Claims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.keys.keyFor); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if claims.Subject == "" || !validRoleBinding(claims.Role, claims.CustomerID) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{Subject: claims.Subject, Method: MethodJWT, Role: claims.Role, CustomerID: claims.CustomerID}, nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Authentication methods recorded on a Principal.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Roles a principal can hold.
+const (
+	RoleCustomer = "customer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleAuditor  = "auditor"
+)
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleOperator, RoleAdmin, RoleAuditor:
+		return true
+	}
+	return false
+}
+
+// validRoleBinding reports whether customerID fits role: customers act for
+// exactly one customer, other roles for none.
+func validRoleBinding(role string, customerID int) bool {
+	if role == RoleCustomer {
+		return customerID > 0
+	}
+	return ValidRole(role) && customerID == 0
+}
+
+var (
+	// ErrNoCredentials means the request carried nothing this authenticator
+	// understands, so the next one may try.
+	ErrNoCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials means credentials were present but rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller: the API key name or the JWT "sub" claim.
+	Subject string
+	Method  string
+	Role    string
+	// CustomerID is the customer a customer-role principal acts for.
+	CustomerID int
+	// APIKeyID is set for API key callers.
+	APIKeyID int
+}
+
+// Key identifies the caller across requests, e.g. for rate limiting. API
+// keys are keyed on their ID so renaming a key doesn't change it.
+func (p *Principal) Key() string {
+	if p.APIKeyID != 0 {
+		return "key:" + strconv.Itoa(p.APIKeyID)
+	}
+	return p.Method + ":" + p.Subject
+}
+
+// Authenticator resolves the caller of a request. It returns ErrNoCredentials
+// when the request has no credentials it recognizes.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries each authenticator in order until one recognizes the request.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by WithPrincipal, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
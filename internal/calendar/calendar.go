@@ -0,0 +1,124 @@
+// Package calendar decides which days are business days and which business
+// day a moment belongs to. Weekends and listed holidays are not business
+// days; each business day ends at a cut-off time, and anything after it
+// belongs to the next business day.
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DateLayout is how days are written in holiday files and the API.
+const DateLayout = "2006-01-02"
+
+// Calendar is a business-day calendar in UTC. The zero value has no
+// holidays and a midnight cut-off.
+type Calendar struct {
+	holidays map[string]bool
+	// Cutoff is when a business day ends, as the time after midnight.
+	Cutoff time.Duration
+}
+
+// New returns a calendar with the given cut-off and holidays.
+func New(cutoff time.Duration, holidays ...time.Time) *Calendar {
+	c := &Calendar{holidays: map[string]bool{}, Cutoff: cutoff}
+	for _, h := range holidays {
+		c.holidays[h.UTC().Format(DateLayout)] = true
+	}
+	return c
+}
+
+// ReadHolidays reads one date per line, written YYYY-MM-DD. Anything after
+// the date, such as the holiday's name, is ignored, as are blank lines and
+// lines starting with '#'.
+func ReadHolidays(r io.Reader) ([]time.Time, error) {
+	var days []time.Time
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		field, _, _ := strings.Cut(line, " ")
+		d, err := time.Parse(DateLayout, strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %q is not a YYYY-MM-DD date", n, field)
+		}
+		days = append(days, d)
+	}
+	return days, scanner.Err()
+}
+
+// LoadHolidays reads a holiday file; see ReadHolidays.
+func LoadHolidays(path string) ([]time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadHolidays(f)
+}
+
+// Day returns midnight UTC of t's date.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsBusinessDay reports whether d's date is neither a weekend nor a holiday.
+func (c *Calendar) IsBusinessDay(d time.Time) bool {
+	d = d.UTC()
+	if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.holidays[d.Format(DateLayout)]
+}
+
+// Next returns the first business day after d's date.
+func (c *Calendar) Next(d time.Time) time.Time {
+	d = Day(d).AddDate(0, 0, 1)
+	for !c.IsBusinessDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// Previous returns the last business day before d's date.
+func (c *Calendar) Previous(d time.Time) time.Time {
+	d = Day(d).AddDate(0, 0, -1)
+	for !c.IsBusinessDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// End returns when business day d ends: its cut-off.
+func (c *Calendar) End(d time.Time) time.Time {
+	return Day(d).Add(c.Cutoff)
+}
+
+// Start returns when business day d starts: the previous business day's
+// cut-off, so postings over a weekend or holiday belong to d.
+func (c *Calendar) Start(d time.Time) time.Time {
+	return c.End(c.Previous(d))
+}
+
+// DayOf returns the business day a posting at t belongs to: the first whose
+// end is after t.
+func (c *Calendar) DayOf(t time.Time) time.Time {
+	d := Day(t)
+	if !c.IsBusinessDay(d) || !t.Before(c.End(d)) {
+		d = c.Next(d)
+	}
+	return d
+}
+
+// LastEnded returns the latest business day that has ended by t.
+func (c *Calendar) LastEnded(t time.Time) time.Time {
+	return c.Previous(c.DayOf(t))
+}
@@ -0,0 +1,56 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse(DateLayout, s)
+	return d
+}
+
+func at(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func TestReadHolidays(t *testing.T) {
+	days, err := ReadHolidays(strings.NewReader("# UK bank holidays\n2024-12-25 Christmas Day\n\n 2024-12-26\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{date("2024-12-25"), date("2024-12-26")}, days)
+
+	_, err = ReadHolidays(strings.NewReader("2024-12-25\n25/12/2024 Christmas\n"))
+	assert.EqualError(t, err, `line 2: "25/12/2024" is not a YYYY-MM-DD date`)
+}
+
+func TestCalendar(t *testing.T) {
+	// Christmas 2024 is a Wednesday.
+	c := New(17*time.Hour, date("2024-12-25"), date("2024-12-26"))
+
+	assert.True(t, c.IsBusinessDay(date("2024-12-24")))
+	assert.False(t, c.IsBusinessDay(date("2024-12-25")))
+	assert.False(t, c.IsBusinessDay(date("2024-12-28")))
+	assert.Equal(t, date("2024-12-27"), c.Next(date("2024-12-24")))
+	assert.Equal(t, date("2024-12-24"), c.Previous(date("2024-12-27")))
+	assert.Equal(t, date("2024-12-20"), c.Previous(date("2024-12-23")))
+
+	assert.Equal(t, at("2024-12-27T17:00:00Z"), c.End(date("2024-12-27")))
+	assert.Equal(t, at("2024-12-24T17:00:00Z"), c.Start(date("2024-12-27")))
+
+	for posted, want := range map[string]string{
+		"2024-12-24T16:59:59Z": "2024-12-24",
+		"2024-12-24T17:00:00Z": "2024-12-27", // the cut-off starts the next business day
+		"2024-12-25T10:00:00Z": "2024-12-27",
+		"2024-12-28T10:00:00Z": "2024-12-30",
+	} {
+		assert.Equal(t, date(want), c.DayOf(at(posted)), posted)
+	}
+
+	assert.Equal(t, date("2024-12-23"), c.LastEnded(at("2024-12-24T16:00:00Z")))
+	assert.Equal(t, date("2024-12-24"), c.LastEnded(at("2024-12-24T17:00:00Z")))
+	assert.Equal(t, date("2024-12-24"), c.LastEnded(at("2024-12-27T09:00:00Z")))
+}
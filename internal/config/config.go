@@ -0,0 +1,359 @@
+package config
+
+import (
+	"fastfunds/internal/nacha"
+	"fastfunds/internal/ratelimit"
+	"fastfunds/internal/util"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds settings read from the environment at startup.
+type Config struct {
+	DatabaseURL string
+
+	// GRPCAddr is where the gRPC API listens, next to the REST API on :8080.
+	GRPCAddr string
+
+	// MetricsAddr is where GET /metrics is served without authentication,
+	// apart from the REST API so it can be kept off the public network.
+	MetricsAddr string
+
+	// SanctionsListPath points at a CSV or OFAC SDN XML file. Screening is
+	// disabled when empty.
+	SanctionsListPath  string
+	ScreeningThreshold float64
+
+	// ApprovalThresholdPennies is the amount above which a transfer needs a
+	// second person's approval. Zero disables approvals.
+	ApprovalThresholdPennies int64
+	ApprovalTTL              time.Duration
+
+	// Account numbers are generated and validated with this scheme. Changing
+	// it after accounts exist makes their numbers fail validation.
+	AccountNumberFormat   string
+	AccountNumberCountry  string
+	AccountNumberBankCode string
+	AccountNumberDigits   int
+
+	// SortCodeRulesPath points at Vocalink's valacdos.txt. UK bank details
+	// are only format-checked when empty.
+	SortCodeRulesPath string
+
+	// JWT bearer tokens are accepted when at least one key is configured.
+	// API keys are always accepted.
+	JWTHS256Secret   string
+	JWTPublicKeyPath string // PEM, RSA or Ed25519
+	JWTJWKSPath      string
+	JWTIssuer        string
+	JWTAudience      string
+
+	// RateLimitStore is "memory" (per replica) or "postgres" (shared).
+	RateLimitStore        string
+	RateLimitPerIP        ratelimit.Limit
+	RateLimitPerPrincipal ratelimit.Limit
+	RateLimitTransfers    ratelimit.Limit
+	// TrustedProxies may set X-Forwarded-For. With none, the client IP is
+	// the connection's remote address.
+	TrustedProxies []string
+
+	// EventPublisher is "stdout", "file" or "webhook", where domain events
+	// go besides webhook subscriptions. None when empty.
+	EventPublisher     string
+	EventFilePath      string
+	EventWebhookURL    string
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	// OutboxRetention is how long published events are kept.
+	OutboxRetention time.Duration
+
+	// WebhookMaxAttempts is how many times a subscription delivery is tried
+	// before it is dead-lettered.
+	WebhookMaxAttempts  int
+	WebhookPollInterval time.Duration
+
+	// ActivityRetention is how long account activity is kept, and so how far
+	// back an event stream can resume.
+	ActivityRetention time.Duration
+
+	// ImportPollInterval is how often confirmed transfer imports are checked
+	// for rows to execute when there is nothing to do.
+	ImportPollInterval time.Duration
+
+	// StatementBankID is written on account statements.
+	StatementBankID string
+
+	// ACHClearingAccount is the account number payouts are moved into until
+	// their NACHA file settles. ACH payouts are disabled when empty.
+	ACHClearingAccount string
+	ACHOriginator      nacha.Originator
+	// ACHOutputDir is where NACHA files are written for pick-up.
+	ACHOutputDir string
+	// ACHFileTime is when the day's NACHA file is created, as the time after
+	// midnight UTC.
+	ACHFileTime time.Duration
+
+	// ReconciliationInterval is how often balances are checked against the
+	// transaction history. Zero turns the scheduled check off.
+	ReconciliationInterval time.Duration
+
+	// BalanceSnapshotInterval is how often every balance is snapshotted for
+	// point-in-time queries. Zero turns snapshots off.
+	BalanceSnapshotInterval time.Duration
+
+	// HolidaysPath lists the holidays of the business-day calendar, one
+	// YYYY-MM-DD date per line. Weekends are never business days.
+	HolidaysPath string
+	// BusinessDayCutoff is when a business day ends, as the time after
+	// midnight UTC. Transfers after it belong to the next business day.
+	BusinessDayCutoff time.Duration
+	// EndOfDayClose closes each business day automatically after its
+	// cut-off.
+	EndOfDayClose bool
+
+	// EscrowInterval is how often escrows past their deadline are released
+	// or refunded. Zero turns deadlines off.
+	EscrowInterval time.Duration
+
+	// DisputeSuspenseAccount is the account number disputed amounts are
+	// credited from and settled back into, usually on the internal product.
+	// Disputes are disabled when empty.
+	DisputeSuspenseAccount string
+
+	// FeeIncomeAccount is the account number transfer and monthly fees are
+	// charged into, and InterestExpenseAccount the one interest is paid out
+	// of, usually on the internal product. Fees or interest aren't charged
+	// when empty.
+	FeeIncomeAccount       string
+	InterestExpenseAccount string
+}
+
+func Load() (*Config, error) {
+	cfg := &Config{
+		DatabaseURL:       os.Getenv("DATABASE_URL"),
+		GRPCAddr:          os.Getenv("GRPC_ADDR"),
+		MetricsAddr:       os.Getenv("METRICS_ADDR"),
+		SanctionsListPath: os.Getenv("SANCTIONS_LIST_PATH"),
+
+		AccountNumberFormat:   os.Getenv("ACCOUNT_NUMBER_FORMAT"),
+		AccountNumberCountry:  os.Getenv("ACCOUNT_NUMBER_COUNTRY"),
+		AccountNumberBankCode: os.Getenv("ACCOUNT_NUMBER_BANK_CODE"),
+
+		SortCodeRulesPath: os.Getenv("SORT_CODE_RULES_PATH"),
+
+		JWTHS256Secret:   os.Getenv("JWT_HS256_SECRET"),
+		JWTPublicKeyPath: os.Getenv("JWT_PUBLIC_KEY_PATH"),
+		JWTJWKSPath:      os.Getenv("JWT_JWKS_PATH"),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+
+		RateLimitStore: os.Getenv("RATE_LIMIT_STORE"),
+
+		EventPublisher:  os.Getenv("EVENT_PUBLISHER"),
+		EventFilePath:   os.Getenv("EVENT_FILE_PATH"),
+		EventWebhookURL: os.Getenv("EVENT_WEBHOOK_URL"),
+
+		StatementBankID: os.Getenv("STATEMENT_BANK_ID"),
+
+		ACHClearingAccount: os.Getenv("ACH_CLEARING_ACCOUNT"),
+		ACHOriginator: nacha.Originator{
+			ImmediateDestination:     os.Getenv("ACH_IMMEDIATE_DESTINATION"),
+			ImmediateDestinationName: os.Getenv("ACH_IMMEDIATE_DESTINATION_NAME"),
+			ImmediateOrigin:          os.Getenv("ACH_IMMEDIATE_ORIGIN"),
+			ImmediateOriginName:      os.Getenv("ACH_IMMEDIATE_ORIGIN_NAME"),
+			CompanyName:              os.Getenv("ACH_COMPANY_NAME"),
+			CompanyID:                os.Getenv("ACH_COMPANY_ID"),
+			ODFI:                     os.Getenv("ACH_ODFI"),
+		},
+		ACHOutputDir: os.Getenv("ACH_OUTPUT_DIR"),
+
+		HolidaysPath: os.Getenv("HOLIDAYS_PATH"),
+
+		DisputeSuspenseAccount: os.Getenv("DISPUTE_SUSPENSE_ACCOUNT"),
+
+		FeeIncomeAccount:       os.Getenv("FEE_INCOME_ACCOUNT"),
+		InterestExpenseAccount: os.Getenv("INTEREST_EXPENSE_ACCOUNT"),
+	}
+
+	if cfg.GRPCAddr == "" {
+		cfg.GRPCAddr = ":9090"
+	}
+	if cfg.MetricsAddr == "" {
+		cfg.MetricsAddr = ":9100"
+	}
+
+	var err error
+	if cfg.ScreeningThreshold, err = envFloat("SCREENING_THRESHOLD", 0); err != nil {
+		return nil, err
+	}
+
+	if v := os.Getenv("APPROVAL_THRESHOLD"); v != "" {
+		if cfg.ApprovalThresholdPennies, err = util.DecimalStringToPennies(v); err != nil {
+			return nil, fmt.Errorf("invalid APPROVAL_THRESHOLD: %w", err)
+		}
+	}
+	if cfg.ApprovalTTL, err = envDuration("APPROVAL_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.AccountNumberDigits, err = envInt("ACCOUNT_NUMBER_DIGITS", 0); err != nil {
+		return nil, err
+	}
+
+	switch cfg.RateLimitStore {
+	case "":
+		cfg.RateLimitStore = "memory"
+	case "memory", "postgres":
+	default:
+		return nil, fmt.Errorf("invalid RATE_LIMIT_STORE %q, want memory or postgres", cfg.RateLimitStore)
+	}
+	if cfg.RateLimitPerIP, err = envLimit("RATE_LIMIT_PER_IP", "300/m"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimitPerPrincipal, err = envLimit("RATE_LIMIT_PER_PRINCIPAL", "120/m"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimitTransfers, err = envLimit("RATE_LIMIT_TRANSFERS", "10/m"); err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, p)
+		}
+	}
+
+	switch cfg.EventPublisher {
+	case "", "stdout":
+	case "file":
+		if cfg.EventFilePath == "" {
+			return nil, fmt.Errorf("EVENT_FILE_PATH is required when EVENT_PUBLISHER=file")
+		}
+	case "webhook":
+		if cfg.EventWebhookURL == "" {
+			return nil, fmt.Errorf("EVENT_WEBHOOK_URL is required when EVENT_PUBLISHER=webhook")
+		}
+	default:
+		return nil, fmt.Errorf("invalid EVENT_PUBLISHER %q, want stdout, file or webhook", cfg.EventPublisher)
+	}
+	if cfg.OutboxPollInterval, err = envDuration("OUTBOX_POLL_INTERVAL", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.OutboxBatchSize, err = envInt("OUTBOX_BATCH_SIZE", 100); err != nil {
+		return nil, err
+	}
+	if cfg.OutboxRetention, err = envDuration("OUTBOX_RETENTION", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookMaxAttempts, err = envInt("WEBHOOK_MAX_ATTEMPTS", 10); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookPollInterval, err = envDuration("WEBHOOK_POLL_INTERVAL", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ActivityRetention, err = envDuration("ACCOUNT_ACTIVITY_RETENTION", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.ImportPollInterval, err = envDuration("TRANSFER_IMPORT_POLL_INTERVAL", time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.StatementBankID == "" {
+		cfg.StatementBankID = "FASTFUNDS"
+	}
+
+	if cfg.ACHClearingAccount != "" {
+		if err := cfg.ACHOriginator.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid ACH originator settings: %w", err)
+		}
+	}
+	if cfg.ACHOutputDir == "" {
+		cfg.ACHOutputDir = "ach"
+	}
+	if cfg.ACHFileTime, err = envTimeOfDay("ACH_FILE_TIME", 16*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.ReconciliationInterval, err = envDuration("RECONCILIATION_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.BalanceSnapshotInterval, err = envDuration("BALANCE_SNAPSHOT_INTERVAL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.BusinessDayCutoff, err = envTimeOfDay("BUSINESS_DAY_CUTOFF", 17*time.Hour); err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("END_OF_DAY_CLOSE"); v != "" {
+		if cfg.EndOfDayClose, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid END_OF_DAY_CLOSE: %w", err)
+		}
+	}
+	if cfg.EscrowInterval, err = envDuration("ESCROW_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func envLimit(key, def string) (ratelimit.Limit, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		v = def
+	}
+	l, err := ratelimit.ParseLimit(v)
+	if err != nil {
+		return ratelimit.Limit{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return l, nil
+}
+
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// envTimeOfDay reads a time of day written as HH:MM and returns it as the
+// time after midnight.
+func envTimeOfDay(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: want a time such as 16:00", key, v)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func envFloat(key string, def float64) (float64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
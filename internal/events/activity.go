@@ -0,0 +1,141 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// ActivityChannel is the Postgres channel that account_activity inserts
+// notify on commit. The payload is the account ID.
+const ActivityChannel = "account_activity"
+
+// ActivityHub wakes the streams following an account when it has new
+// activity. Wake-ups carry no data: streams read what is new from the
+// database, so a coalesced or spurious wake-up loses nothing.
+type ActivityHub struct {
+	mu   sync.Mutex
+	subs map[int]map[chan struct{}]struct{}
+}
+
+func NewActivityHub() *ActivityHub {
+	return &ActivityHub{subs: make(map[int]map[chan struct{}]struct{})}
+}
+
+// Subscribe returns a channel that is signalled when accountID may have new
+// activity, and a function that unsubscribes. Signals sent while the last
+// one is unread are merged into it.
+func (h *ActivityHub) Subscribe(accountID int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[accountID] == nil {
+		h.subs[accountID] = make(map[chan struct{}]struct{})
+	}
+	h.subs[accountID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[accountID], ch)
+		if len(h.subs[accountID]) == 0 {
+			delete(h.subs, accountID)
+		}
+	}
+}
+
+// Notify wakes the subscribers of accountID.
+func (h *ActivityHub) Notify(accountID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[accountID] {
+		wake(ch)
+	}
+}
+
+// NotifyAll wakes every subscriber, for when notifications may have been missed.
+func (h *ActivityHub) NotifyAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subs {
+		for ch := range subs {
+			wake(ch)
+		}
+	}
+}
+
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// ListenActivity relays notifications on ActivityChannel to hub until ctx
+// ends. It holds one connection from db's pool and reconnects after errors.
+// Notifications sent while disconnected are lost, so every subscriber is
+// woken once listening starts.
+func ListenActivity(ctx context.Context, db *sql.DB, hub *ActivityHub) {
+	for {
+		err := listenActivity(ctx, db, hub)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Print("account activity listener stopped, reconnecting: ", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func listenActivity(ctx context.Context, db *sql.DB, hub *ActivityHub) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("unsupported driver connection %T", driverConn)
+			return listenErr
+		}
+		listenErr = waitForActivity(ctx, c.Conn(), hub)
+		// The connection is still listening, so it must not go back to the pool
+		return driver.ErrBadConn
+	})
+	return listenErr
+}
+
+func waitForActivity(ctx context.Context, conn *pgx.Conn, hub *ActivityHub) error {
+	if _, err := conn.Exec(ctx, "LISTEN "+ActivityChannel); err != nil {
+		return err
+	}
+	hub.NotifyAll()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if accountID, err := strconv.Atoi(n.Payload); err == nil {
+			hub.Notify(accountID)
+		} else {
+			hub.NotifyAll()
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func woken(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestActivityHub(t *testing.T) {
+	hub := NewActivityHub()
+	a, cancelA := hub.Subscribe(1)
+	b, cancelB := hub.Subscribe(1)
+	other, cancelOther := hub.Subscribe(2)
+	defer cancelB()
+	defer cancelOther()
+
+	hub.Notify(1)
+	hub.Notify(1)
+	assert.True(t, woken(a))
+	assert.False(t, woken(a), "wake-ups coalesce")
+	assert.True(t, woken(b))
+	assert.False(t, woken(other))
+
+	cancelA()
+	hub.Notify(1)
+	assert.False(t, woken(a), "unsubscribed")
+	assert.True(t, woken(b))
+
+	hub.NotifyAll()
+	assert.True(t, woken(b))
+	assert.True(t, woken(other))
+}
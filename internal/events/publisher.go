@@ -0,0 +1,131 @@
+// Package events builds domain events and publishes them to downstream
+// systems.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fastfunds/internal/models"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Aggregate types events are about.
+const (
+	AggregateAccount     = "account"
+	AggregateTransaction = "transaction"
+)
+
+// Types lists the event types that can be subscribed to.
+var Types = []string{models.EventAccountCreated, models.EventTransferCompleted, models.EventTransferFailed}
+
+// Publisher delivers an event downstream. Returning nil means the event was
+// accepted and won't be offered again. Delivery is at least once, so an
+// event may be published more than once after a crash or timeout.
+type Publisher interface {
+	Publish(ctx context.Context, e *models.Event) error
+}
+
+// New builds an event of type eventType about one aggregate. payload is
+// marshalled to JSON. ID and OccurredAt are set when it is appended to the
+// outbox.
+func New(eventType, aggregateType string, aggregateID any, payload any) (*models.Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Event{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   fmt.Sprint(aggregateID),
+		Payload:       raw,
+	}, nil
+}
+
+// WriterPublisher writes each event as one line of JSON.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewFilePublisher appends events to the file at path, creating it if needed.
+// Each event is synced to disk before it counts as published.
+func NewFilePublisher(path string) (*WriterPublisher, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterPublisher(f), nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, e *models.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if f, ok := p.w.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		return f.Sync()
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it is closable.
+func (p *WriterPublisher) Close() error {
+	if c, ok := p.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// WebhookPublisher POSTs each event as JSON to a fixed URL. Any 2xx response
+// counts as accepted.
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookPublisher posts to url with client, or with a client that times
+// out after 10 seconds when client is nil.
+func NewWebhookPublisher(url string, client *http.Client) *WebhookPublisher {
+	if client == nil {
+		client = NewWebhookClient()
+	}
+	return &WebhookPublisher{url: url, client: client}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, e *models.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = PostWebhook(ctx, p.client, p.url, "", e.ID, e.Type, body, time.Now())
+	return err
+}
+
+// Fanout publishes each event to every publisher in turn. An event counts as
+// published only once all of them accept it, so after a failure the ones
+// that already accepted it get it again.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, e *models.Event) error {
+	for _, p := range f {
+		if err := p.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
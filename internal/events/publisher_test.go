@@ -0,0 +1,107 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fastfunds/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testEvent(t *testing.T) *models.Event {
+	t.Helper()
+	e, err := New(models.EventTransferCompleted, AggregateTransaction, 31, models.TransferPayload{TransactionID: 31, AmountPennies: 250, Status: "completed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.ID = 7
+	return e
+}
+
+func TestNew(t *testing.T) {
+	e := testEvent(t)
+	assert.Equal(t, "31", e.AggregateID)
+	assert.JSONEq(t, `{"transaction_id":31,"source_account_number":"","destination_account_number":"","amount_pennies":250,"status":"completed"}`, string(e.Payload))
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWriterPublisher(&buf)
+	assert.NoError(t, p.Publish(context.Background(), testEvent(t)))
+	assert.NoError(t, p.Publish(context.Background(), testEvent(t)))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var got models.Event
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+		assert.Equal(t, int64(7), got.ID)
+		assert.Equal(t, models.EventTransferCompleted, got.Type)
+	}
+}
+
+func TestFilePublisherAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for range 2 {
+		p, err := NewFilePublisher(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, p.Publish(context.Background(), testEvent(t)))
+		assert.NoError(t, p.Close())
+	}
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(b), "\n"))
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := NewWebhookPublisher(srv.URL, nil)
+	assert.NoError(t, p.Publish(context.Background(), testEvent(t)))
+	if assert.NotNil(t, got) {
+		assert.Equal(t, http.MethodPost, got.Method)
+		assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+		assert.Equal(t, "7", got.Header.Get(HeaderEventID))
+		assert.Equal(t, models.EventTransferCompleted, got.Header.Get(HeaderEventType))
+		assert.Contains(t, string(body), `"aggregate_id":"31"`)
+	}
+
+	status = http.StatusServiceUnavailable
+	assert.EqualError(t, p.Publish(context.Background(), testEvent(t)), "webhook returned 503 Service Unavailable")
+}
+
+type failingPublisher struct{ calls int }
+
+func (p *failingPublisher) Publish(context.Context, *models.Event) error {
+	p.calls++
+	return errors.New("down")
+}
+
+func TestFanout(t *testing.T) {
+	var buf bytes.Buffer
+	failing := &failingPublisher{}
+	f := Fanout{NewWriterPublisher(&buf), failing}
+
+	assert.EqualError(t, f.Publish(context.Background(), testEvent(t)), "down")
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	body := []byte(`{"id":7}`)
+	sig := Sign("s3cret-s3cret-s3cret", now.Unix(), body)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	assert.True(t, strings.HasPrefix(sig, "v1="))
+	assert.Len(t, sig, len("v1=")+64)
+	assert.NoError(t, VerifySignature("s3cret-s3cret-s3cret", sig, ts, body, 5*time.Minute, now.Add(time.Minute)))
+
+	assert.Error(t, VerifySignature("other-secret-other", sig, ts, body, 5*time.Minute, now), "wrong secret")
+	assert.Error(t, VerifySignature("s3cret-s3cret-s3cret", sig, ts, []byte(`{"id":8}`), 5*time.Minute, now), "changed body")
+	assert.Error(t, VerifySignature("s3cret-s3cret-s3cret", sig, "1760000001", body, 5*time.Minute, now), "changed timestamp")
+	assert.Error(t, VerifySignature("s3cret-s3cret-s3cret", sig, ts, body, 5*time.Minute, now.Add(6*time.Minute)), "stale")
+	assert.Error(t, VerifySignature("s3cret-s3cret-s3cret", strings.TrimPrefix(sig, "v1="), ts, body, 5*time.Minute, now), "no version")
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	assert.NoError(t, err)
+	b, _ := GenerateSecret()
+	assert.True(t, strings.HasPrefix(a, SecretPrefix))
+	assert.NotEqual(t, a, b)
+}
+
+func TestPostWebhook_Signed(t *testing.T) {
+	const secret = "whsec_test-secret-value"
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = VerifySignature(secret, r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, time.Minute, time.Now())
+		assert.Equal(t, "9", r.Header.Get(HeaderEventID))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	code, err := PostWebhook(context.Background(), NewWebhookClient(), srv.URL, secret, 9, "AccountCreated", []byte(`{"id":9}`), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.NoError(t, verifyErr)
+}
+
+func TestPostWebhook_DoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { followed = true }))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	code, err := PostWebhook(context.Background(), NewWebhookClient(), srv.URL, "secret", 1, "AccountCreated", []byte(`{}`), time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, code)
+	assert.False(t, followed)
+}
+
+func TestPostWebhook_Unsigned(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { header = r.Header }))
+	defer srv.Close()
+
+	_, err := PostWebhook(context.Background(), NewWebhookClient(), srv.URL, "", 1, "AccountCreated", []byte(`{}`), time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, header.Get(HeaderSignature))
+	assert.Empty(t, header.Get(HeaderTimestamp))
+}
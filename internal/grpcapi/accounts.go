@@ -0,0 +1,55 @@
+package grpcapi
+
+import (
+	"context"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	fastfundsv1 "fastfunds/proto/fastfunds/v1"
+
+	"google.golang.org/grpc/codes"
+)
+
+type accountServer struct {
+	fastfundsv1.UnimplementedAccountServiceServer
+	accounts service.IAccountService
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, req *fastfundsv1.CreateAccountRequest) (*fastfundsv1.Account, error) {
+	account, err := s.accounts.CreateAccount(ctx, &models.CreateAccountRequest{
+		HolderName:     req.GetHolderName(),
+		InitialBalance: req.GetInitialBalance(),
+	})
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, err)
+	}
+	return toAccount(account), nil
+}
+
+func (s *accountServer) GetAccount(ctx context.Context, req *fastfundsv1.GetAccountRequest) (*fastfundsv1.Account, error) {
+	accountID, err := resolveAccount(s.accounts, req.GetAccountNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accounts.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, statusError(codes.NotFound, err)
+	}
+	return toAccount(account), nil
+}
+
+func toAccount(v *models.AccountView) *fastfundsv1.Account {
+	a := &fastfundsv1.Account{
+		AccountNumber:  v.AccountNumber,
+		HolderName:     v.HolderName,
+		CurrentBalance: v.CurrentBalance,
+	}
+	for _, h := range v.Holders {
+		a.Holders = append(a.Holders, &fastfundsv1.AccountHolder{
+			CustomerId: int64(h.CustomerID),
+			Name:       h.Name,
+			Role:       h.Role,
+		})
+	}
+	return a
+}
@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"errors"
+	"fastfunds/internal/service"
+	"fastfunds/internal/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError converts a service error to a status with the given code,
+// except that authorization failures always map to Unauthenticated and
+// PermissionDenied, and errors of a service kind to NotFound,
+// FailedPrecondition and Internal. Each RPC passes the code matching the
+// HTTP status its REST endpoint answers with: 400 is InvalidArgument, 404
+// NotFound.
+func statusError(code codes.Code, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		code = codes.Unauthenticated
+	case errors.Is(err, service.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, service.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, service.ErrFailedPrecondition):
+		code = codes.FailedPrecondition
+	case errors.Is(err, service.ErrInternal):
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
+// resolveAccount maps an account number to the internal account ID, with
+// InvalidArgument for malformed numbers and NotFound for unknown ones.
+func resolveAccount(accounts service.IAccountService, number string) (int, error) {
+	accountID, err := accounts.ResolveAccountNumber(number)
+	if err == nil {
+		return accountID, nil
+	}
+	if errors.Is(err, util.ErrInvalidAccountNumber) || errors.Is(err, util.ErrAccountNumberCheckDigits) {
+		return 0, statusError(codes.InvalidArgument, err)
+	}
+	return 0, statusError(codes.NotFound, err)
+}
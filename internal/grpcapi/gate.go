@@ -0,0 +1,159 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/api/handlers"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/ratelimit"
+	fastfundsv1 "fastfunds/proto/fastfunds/v1"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys. Credentials travel in "authorization" as over HTTP, e.g.
+// "Bearer ff_...".
+const (
+	MetadataAuthorization = "authorization"
+	MetadataRequestID     = "x-request-id"
+	MetadataRetryAfter    = "retry-after"
+)
+
+// gate applies to every call what the REST middleware applies to every
+// request. Limits share their buckets with REST, so a caller has one budget
+// across both APIs.
+type gate struct {
+	authenticator auth.Authenticator
+	limits        handlers.RateLimits
+}
+
+func (g *gate) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := g.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *gate) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.admit(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// admit assigns the request ID, checks the limits and authenticates the
+// caller, returning the context the service sees.
+func (g *gate) admit(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := firstValue(md, MetadataRequestID)
+	if !audit.ValidRequestID(id) {
+		id = audit.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, id))
+	ip := peerIP(ctx)
+	ctx = audit.WithRequest(ctx, audit.RequestInfo{ID: id, IP: ip})
+
+	if err := g.allow(ctx, g.limits.PerIP, "ip", "ip:"+ip); err != nil {
+		return nil, err
+	}
+
+	p, err := g.authenticate(ctx, md)
+	if err != nil {
+		return nil, err
+	}
+	ctx = auth.WithPrincipal(ctx, p)
+
+	if err := g.allow(ctx, g.limits.PerPrincipal, "principal", p.Key()); err != nil {
+		return nil, err
+	}
+	if method == fastfundsv1.TransactionService_CreateTransaction_FullMethodName {
+		if err := g.allow(ctx, g.limits.Transfers, "transfers", p.Key()); err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+// authenticate hands the call's credentials to the HTTP authenticator as
+// the headers of an equivalent request.
+func (g *gate) authenticate(ctx context.Context, md metadata.MD) (*auth.Principal, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "couldn't read credentials")
+	}
+	for _, v := range md.Get(MetadataAuthorization) {
+		r.Header.Add("Authorization", v)
+	}
+
+	p, err := g.authenticator.Authenticate(r)
+	if err != nil {
+		if errors.Is(err, auth.ErrNoCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return p, nil
+}
+
+// allow counts the call against limit. Rejected calls get ResourceExhausted
+// and a retry-after trailer in seconds. If the limiter fails the call is let
+// through.
+func (g *gate) allow(ctx context.Context, limit ratelimit.Limit, scope, key string) error {
+	if g.limits.Limiter == nil || !limit.Enabled() {
+		return nil
+	}
+
+	res, err := g.limits.Limiter.Allow(ctx, scope+":"+key, limit)
+	if err != nil {
+		log.Print("rate limiter unavailable, allowing call:", err)
+		return nil
+	}
+	if !res.Allowed {
+		retryAfter := int(math.Ceil(res.RetryAfter.Seconds()))
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(MetadataRetryAfter, strconv.Itoa(retryAfter)))
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// serverStream replaces the context of a stream with the admitted one.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
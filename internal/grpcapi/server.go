@@ -0,0 +1,31 @@
+// Package grpcapi serves accounts and transactions over gRPC, next to the
+// REST API. Calls go through the same services, authentication and rate
+// limits, and errors map to the status codes matching the REST responses.
+package grpcapi
+
+import (
+	"fastfunds/internal/api/handlers"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/service"
+	fastfundsv1 "fastfunds/proto/fastfunds/v1"
+
+	"google.golang.org/grpc"
+)
+
+// NewServer returns a gRPC server with the account and transaction services
+// registered behind request IDs, rate limiting and authentication.
+func NewServer(
+	authenticator auth.Authenticator,
+	limits handlers.RateLimits,
+	accountService service.IAccountService,
+	transactionService service.ITransactionService,
+) *grpc.Server {
+	g := &gate{authenticator: authenticator, limits: limits}
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(g.unary),
+		grpc.StreamInterceptor(g.stream),
+	)
+	fastfundsv1.RegisterAccountServiceServer(s, &accountServer{accounts: accountService})
+	fastfundsv1.RegisterTransactionServiceServer(s, &transactionServer{accounts: accountService, transactions: transactionService})
+	return s
+}
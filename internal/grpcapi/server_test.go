@@ -0,0 +1,242 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/api/handlers"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/ratelimit"
+	"fastfunds/internal/service"
+	"fastfunds/internal/util"
+	fastfundsv1 "fastfunds/proto/fastfunds/v1"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testAccountNumber = "FF17FAST4821930576"
+
+// tokenAuthenticator accepts "Bearer op" as an operator and "Bearer alice"
+// as customer 7.
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(r *http.Request) (*auth.Principal, error) {
+	switch r.Header.Get("Authorization") {
+	case "":
+		return nil, auth.ErrNoCredentials
+	case "Bearer op":
+		return &auth.Principal{Subject: "ops", Method: auth.MethodJWT, Role: auth.RoleOperator}, nil
+	case "Bearer alice":
+		return &auth.Principal{Subject: "alice", Method: auth.MethodJWT, Role: auth.RoleCustomer, CustomerID: 7}, nil
+	}
+	return nil, auth.ErrInvalidCredentials
+}
+
+// fakeAccounts resolves every valid account number to account 1. Calls
+// without a function set panic through the nil embedded interface.
+type fakeAccounts struct {
+	service.IAccountService
+	getFn func(ctx context.Context, id int) (*models.AccountView, error)
+}
+
+func (f *fakeAccounts) ResolveAccountNumber(number string) (int, error) {
+	if err := util.DefaultAccountNumberScheme().Validate(number); err != nil {
+		return 0, err
+	}
+	if number != testAccountNumber {
+		return 0, errors.New("account not found")
+	}
+	return 1, nil
+}
+
+func (f *fakeAccounts) GetAccount(ctx context.Context, id int) (*models.AccountView, error) {
+	return f.getFn(ctx, id)
+}
+
+type fakeTransactions struct {
+	service.ITransactionService
+	processFn func(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error)
+	historyFn func(accountID, beforeID, limit int) ([]*models.Transaction, error)
+}
+
+func (f *fakeTransactions) ProcessTransaction(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error) {
+	return f.processFn(ctx, req)
+}
+
+func (f *fakeTransactions) ListAccountTransactions(ctx context.Context, accountID, beforeID, limit int) ([]*models.Transaction, error) {
+	return f.historyFn(accountID, beforeID, limit)
+}
+
+// dial serves s over an in-memory listener and returns a connected client.
+func dial(t *testing.T, s *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), MetadataAuthorization, "Bearer "+token)
+}
+
+func TestGetAccount(t *testing.T) {
+	accounts := &fakeAccounts{getFn: func(ctx context.Context, id int) (*models.AccountView, error) {
+		p, _ := auth.FromContext(ctx)
+		if p.Role == auth.RoleCustomer {
+			return nil, service.ErrForbidden
+		}
+		return &models.AccountView{AccountID: id, AccountNumber: testAccountNumber, HolderName: "Alice Example", CurrentBalance: "100.23",
+			Holders: []*models.AccountHolder{{CustomerID: 7, Name: "Alice Example", Role: models.HolderRoleOwner}}}, nil
+	}}
+	client := fastfundsv1.NewAccountServiceClient(dial(t, NewServer(tokenAuthenticator{}, handlers.RateLimits{}, accounts, &fakeTransactions{})))
+
+	var header metadata.MD
+	got, err := client.GetAccount(withToken("op"), &fastfundsv1.GetAccountRequest{AccountNumber: testAccountNumber}, grpc.Header(&header))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "100.23", got.GetCurrentBalance())
+		assert.Equal(t, int64(7), got.GetHolders()[0].GetCustomerId())
+		assert.Len(t, header.Get(MetadataRequestID), 1)
+	}
+
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		number string
+		want   codes.Code
+	}{
+		{"no credentials", context.Background(), testAccountNumber, codes.Unauthenticated},
+		{"bad credentials", withToken("nope"), testAccountNumber, codes.Unauthenticated},
+		{"forbidden", withToken("alice"), testAccountNumber, codes.PermissionDenied},
+		{"malformed number", withToken("op"), "FF00FAST0000000000", codes.InvalidArgument},
+		{"unknown number", withToken("op"), "FF14FAST7305618249", codes.NotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.GetAccount(tc.ctx, &fastfundsv1.GetAccountRequest{AccountNumber: tc.number})
+			assert.Equal(t, tc.want, status.Code(err))
+		})
+	}
+}
+
+func TestCreateTransaction_InitiatorIsCaller(t *testing.T) {
+	transactions := &fakeTransactions{processFn: func(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error) {
+		if req.Amount == "0" {
+			return nil, errors.New("invalid amount format")
+		}
+		expires := "2026-01-02T11:00:00Z"
+		return &models.Transaction{ID: 5, AmountPennies: 1250, Status: models.TransactionStatusPendingApproval,
+			InitiatedBy: req.InitiatedBy, ExpiresAt: &expires}, nil
+	}}
+	client := fastfundsv1.NewTransactionServiceClient(dial(t, NewServer(tokenAuthenticator{}, handlers.RateLimits{}, &fakeAccounts{}, transactions)))
+
+	got, err := client.CreateTransaction(withToken("op"), &fastfundsv1.CreateTransactionRequest{Amount: "12.50"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ops", got.GetInitiatedBy())
+		assert.Equal(t, models.TransactionStatusPendingApproval, got.GetStatus())
+		assert.Equal(t, "2026-01-02T11:00:00Z", got.GetExpiresAt())
+	}
+
+	_, err = client.CreateTransaction(withToken("op"), &fastfundsv1.CreateTransactionRequest{Amount: "0"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "invalid amount format", status.Convert(err).Message())
+}
+
+func TestGetTransactionHistory_StreamsEveryPage(t *testing.T) {
+	// 250 transfers with IDs 250 down to 1
+	var befores []int
+	transactions := &fakeTransactions{historyFn: func(accountID, beforeID, limit int) ([]*models.Transaction, error) {
+		befores = append(befores, beforeID)
+		next := 250
+		if beforeID != 0 {
+			next = beforeID - 1
+		}
+		var page []*models.Transaction
+		for id := next; id > 0 && len(page) < limit; id-- {
+			page = append(page, &models.Transaction{ID: id})
+		}
+		return page, nil
+	}}
+	client := fastfundsv1.NewTransactionServiceClient(dial(t, NewServer(tokenAuthenticator{}, handlers.RateLimits{}, &fakeAccounts{}, transactions)))
+
+	stream, err := client.GetTransactionHistory(withToken("op"), &fastfundsv1.GetTransactionHistoryRequest{AccountNumber: testAccountNumber})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var ids []int64
+	for {
+		tr, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		ids = append(ids, tr.GetId())
+	}
+	assert.Len(t, ids, 250)
+	assert.Equal(t, int64(250), ids[0])
+	assert.Equal(t, int64(1), ids[249])
+	assert.Equal(t, []int{0, 151, 51}, befores)
+
+	stream, _ = client.GetTransactionHistory(withToken("op"), &fastfundsv1.GetTransactionHistoryRequest{AccountNumber: testAccountNumber, BeforeId: -1})
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRateLimit(t *testing.T) {
+	transactions := &fakeTransactions{processFn: func(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error) {
+		return &models.Transaction{ID: 1}, nil
+	}}
+	limits := handlers.RateLimits{
+		Limiter:   ratelimit.NewMemoryLimiter(),
+		Transfers: ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}
+	client := fastfundsv1.NewTransactionServiceClient(dial(t, NewServer(tokenAuthenticator{}, limits, &fakeAccounts{}, transactions)))
+
+	_, err := client.CreateTransaction(withToken("op"), &fastfundsv1.CreateTransactionRequest{})
+	assert.NoError(t, err)
+
+	var trailer metadata.MD
+	_, err = client.CreateTransaction(withToken("op"), &fastfundsv1.CreateTransactionRequest{}, grpc.Trailer(&trailer))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, trailer.Get(MetadataRetryAfter))
+
+	// Other callers have their own bucket
+	_, err = client.CreateTransaction(withToken("alice"), &fastfundsv1.CreateTransactionRequest{})
+	assert.NoError(t, err)
+}
+
+func TestStatusError(t *testing.T) {
+	assert.Equal(t, codes.NotFound, status.Code(statusError(codes.NotFound, errors.New("transaction not found"))))
+	assert.Equal(t, codes.PermissionDenied, status.Code(statusError(codes.NotFound, service.ErrForbidden)))
+	assert.Equal(t, codes.Unauthenticated, status.Code(statusError(codes.InvalidArgument, service.ErrUnauthenticated)))
+	st := status.Convert(statusError(codes.InvalidArgument, errors.New("insufficient funds")))
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "insufficient funds", st.Message())
+
+	st = status.Convert(statusError(codes.InvalidArgument, fmt.Errorf("%w: insufficient funds", service.ErrFailedPrecondition)))
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, codes.NotFound, status.Code(statusError(codes.InvalidArgument, service.ErrNotFound)))
+	assert.Equal(t, codes.Internal, status.Code(statusError(codes.InvalidArgument, service.ErrInternal)))
+}
@@ -0,0 +1,150 @@
+package grpcapi
+
+import (
+	"context"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/service"
+	fastfundsv1 "fastfunds/proto/fastfunds/v1"
+	"math"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// historyPageSize is how many transfers GetTransactionHistory reads at a time.
+const historyPageSize = 100
+
+type transactionServer struct {
+	fastfundsv1.UnimplementedTransactionServiceServer
+	accounts     service.IAccountService
+	transactions service.ITransactionService
+}
+
+func (s *transactionServer) CreateTransaction(ctx context.Context, req *fastfundsv1.CreateTransactionRequest) (*fastfundsv1.Transaction, error) {
+	r := &models.TransactionRequest{
+		SourceAccountNumber:      req.GetSourceAccountNumber(),
+		DestinationAccountNumber: req.GetDestinationAccountNumber(),
+		Amount:                   req.GetAmount(),
+	}
+	// The authenticated caller is the initiator
+	if p, ok := auth.FromContext(ctx); ok {
+		r.InitiatedBy = p.Subject
+	}
+
+	t, err := s.transactions.ProcessTransaction(ctx, r)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, err)
+	}
+	return toTransaction(t), nil
+}
+
+func (s *transactionServer) GetTransaction(ctx context.Context, req *fastfundsv1.GetTransactionRequest) (*fastfundsv1.Transaction, error) {
+	id, err := transactionID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.transactions.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, statusError(codes.NotFound, err)
+	}
+	return toTransaction(t), nil
+}
+
+func (s *transactionServer) ListTransactions(req *fastfundsv1.ListTransactionsRequest, stream grpc.ServerStreamingServer[fastfundsv1.Transaction]) error {
+	list, err := s.transactions.ListTransactions(stream.Context(), req.GetStatus())
+	if err != nil {
+		return statusError(codes.InvalidArgument, err)
+	}
+	for _, t := range list {
+		if err := stream.Send(toTransaction(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTransactionHistory reads the account's transfers a page at a time, so
+// a long history is never held in memory at once.
+func (s *transactionServer) GetTransactionHistory(req *fastfundsv1.GetTransactionHistoryRequest, stream grpc.ServerStreamingServer[fastfundsv1.Transaction]) error {
+	ctx := stream.Context()
+	accountID, err := resolveAccount(s.accounts, req.GetAccountNumber())
+	if err != nil {
+		return err
+	}
+	if req.GetBeforeId() < 0 || req.GetBeforeId() > math.MaxInt32 {
+		return status.Error(codes.InvalidArgument, "invalid before_id")
+	}
+	beforeID := int(req.GetBeforeId())
+
+	for {
+		page, err := s.transactions.ListAccountTransactions(ctx, accountID, beforeID, historyPageSize)
+		if err != nil {
+			return statusError(codes.InvalidArgument, err)
+		}
+		for _, t := range page {
+			if err := stream.Send(toTransaction(t)); err != nil {
+				return err
+			}
+		}
+		if len(page) < historyPageSize {
+			return nil
+		}
+		beforeID = page[len(page)-1].ID
+	}
+}
+
+func (s *transactionServer) ApproveTransaction(ctx context.Context, req *fastfundsv1.ReviewTransactionRequest) (*fastfundsv1.Transaction, error) {
+	return s.review(ctx, req, s.transactions.ApproveTransaction)
+}
+
+func (s *transactionServer) RejectTransaction(ctx context.Context, req *fastfundsv1.ReviewTransactionRequest) (*fastfundsv1.Transaction, error) {
+	return s.review(ctx, req, s.transactions.RejectTransaction)
+}
+
+func (s *transactionServer) review(ctx context.Context, req *fastfundsv1.ReviewTransactionRequest, fn func(context.Context, int, string, string) (*models.Transaction, error)) (*fastfundsv1.Transaction, error) {
+	id, err := transactionID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	var approver string
+	if p, ok := auth.FromContext(ctx); ok {
+		approver = p.Subject
+	}
+
+	t, err := fn(ctx, id, approver, req.GetNote())
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, err)
+	}
+	return toTransaction(t), nil
+}
+
+// transactionID converts a wire ID, rejecting values no transfer can have;
+// IDs are SERIAL columns.
+func transactionID(id int64) (int, error) {
+	if id <= 0 || id > math.MaxInt32 {
+		return 0, status.Error(codes.InvalidArgument, "invalid transaction_id")
+	}
+	return int(id), nil
+}
+
+func toTransaction(t *models.Transaction) *fastfundsv1.Transaction {
+	pb := &fastfundsv1.Transaction{
+		Id:                       int64(t.ID),
+		SourceAccountNumber:      t.SourceAccountNumber,
+		DestinationAccountNumber: t.DestinationAccountNumber,
+		AmountPennies:            t.AmountPennies,
+		Status:                   t.Status,
+		InitiatedBy:              t.InitiatedBy,
+		ReviewedBy:               t.ReviewedBy,
+		ReviewNote:               t.ReviewNote,
+		CreatedAt:                t.CreatedAt,
+	}
+	if t.ExpiresAt != nil {
+		pb.ExpiresAt = *t.ExpiresAt
+	}
+	return pb
+}
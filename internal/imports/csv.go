@@ -0,0 +1,126 @@
+// Package imports reads bulk transfer files and writes their results.
+package imports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fastfunds/internal/models"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns of a transfer CSV. The header names them, in any order and case;
+// reference may be left out.
+const (
+	ColumnSource      = "source"
+	ColumnDestination = "destination"
+	ColumnAmount      = "amount"
+	ColumnReference   = "reference"
+)
+
+// MaxReferenceLength is the longest reference a row may carry.
+const MaxReferenceLength = 140
+
+// ParseCSV reads the rows of a transfer CSV. Rows come back as they are in
+// the file; checking them is up to the caller, except for a reference that
+// is too long. It fails on a malformed file or one with more than maxRows
+// rows.
+func ParseCSV(r io.Reader, maxRows int) ([]*models.TransferImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	cols := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets often save with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "" {
+			continue
+		}
+		if _, dup := cols[name]; dup {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		cols[name] = i
+	}
+	for _, name := range []string{ColumnSource, ColumnDestination, ColumnAmount} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var rows []*models.TransferImportRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows) == maxRows {
+			return nil, fmt.Errorf("file has more than %d rows", maxRows)
+		}
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		line, _ := cr.FieldPos(0)
+		row := &models.TransferImportRow{
+			Line:                     line,
+			SourceAccountNumber:      field(ColumnSource),
+			DestinationAccountNumber: field(ColumnDestination),
+			Amount:                   field(ColumnAmount),
+			Reference:                field(ColumnReference),
+		}
+		if len(row.Reference) > MaxReferenceLength {
+			row.Reference = row.Reference[:MaxReferenceLength]
+			row.Status = models.ImportRowInvalid
+			row.Error = fmt.Sprintf("reference is longer than %d characters", MaxReferenceLength)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file has no rows")
+	}
+	return rows, nil
+}
+
+// ResultsHeader is the header of the results file.
+var ResultsHeader = []string{"line", ColumnSource, ColumnDestination, ColumnAmount, ColumnReference, "status", "transaction_id", "transaction_status", "error"}
+
+// WriteResultsCSV writes one line per row with its outcome so far.
+func WriteResultsCSV(w io.Writer, rows []*models.TransferImportRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ResultsHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		var transactionID string
+		if r.TransactionID != nil {
+			transactionID = strconv.Itoa(*r.TransactionID)
+		}
+		record := []string{
+			strconv.Itoa(r.Line), r.SourceAccountNumber, r.DestinationAccountNumber, r.Amount, r.Reference,
+			r.Status, transactionID, r.TransactionStatus, r.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,75 @@
+package imports
+
+import (
+	"bytes"
+	"fastfunds/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSV(t *testing.T) {
+	in := "\ufeffAmount, Source ,destination,reference\n" +
+		"12.50,FF17FAST4821930576,FF14FAST7305618249,Invoice 1041\n" +
+		"\n" +
+		"\"1,000.00\",FF14FAST7305618249,FF17FAST4821930576\n"
+
+	rows, err := ParseCSV(strings.NewReader(in), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, &models.TransferImportRow{
+			Line: 2, SourceAccountNumber: "FF17FAST4821930576", DestinationAccountNumber: "FF14FAST7305618249",
+			Amount: "12.50", Reference: "Invoice 1041",
+		}, rows[0])
+		assert.Equal(t, 4, rows[1].Line)
+		assert.Equal(t, "1,000.00", rows[1].Amount)
+		assert.Empty(t, rows[1].Reference)
+	}
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	cases := []struct {
+		name, in, wantErr string
+	}{
+		{"empty", "", "file is empty"},
+		{"header only", "source,destination,amount\n", "file has no rows"},
+		{"missing column", "source,amount\nA,1\n", `missing column "destination"`},
+		{"duplicate column", "source,destination,amount,Amount\n", `duplicate column "amount"`},
+		{"too many rows", "source,destination,amount\nA,B,1\nA,B,2\nA,B,3\n", "file has more than 2 rows"},
+		{"malformed", "source,destination,amount\nA,\"B,1\n", "invalid CSV"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(tc.in), 2)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCSV_LongReference(t *testing.T) {
+	in := "source,destination,amount,reference\nA,B,1," + strings.Repeat("x", MaxReferenceLength+1) + "\n"
+	rows, err := ParseCSV(strings.NewReader(in), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportRowInvalid, rows[0].Status)
+	assert.Equal(t, "reference is longer than 140 characters", rows[0].Error)
+	assert.Len(t, rows[0].Reference, MaxReferenceLength)
+}
+
+func TestWriteResultsCSV(t *testing.T) {
+	id := 31
+	rows := []*models.TransferImportRow{
+		{Line: 2, SourceAccountNumber: "A", DestinationAccountNumber: "B", Amount: "1.00", Reference: "x, y",
+			Status: models.ImportRowCreated, TransactionID: &id, TransactionStatus: models.TransactionStatusCompleted},
+		{Line: 3, SourceAccountNumber: "A", DestinationAccountNumber: "C", Amount: "2.00",
+			Status: models.ImportRowFailed, Error: "insufficient funds"},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteResultsCSV(&buf, rows))
+	assert.Equal(t, "line,source,destination,amount,reference,status,transaction_id,transaction_status,error\n"+
+		"2,A,B,1.00,\"x, y\",created,31,completed,\n"+
+		"3,A,C,2.00,,failed,,,insufficient funds\n", buf.String())
+}
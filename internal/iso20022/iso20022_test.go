@@ -0,0 +1,134 @@
+package iso20022
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>PAY-2024-0001</MsgId>
+      <CreDtTm>2024-03-01T09:30:00.123+01:00</CreDtTm>
+      <NbOfTxs>2</NbOfTxs>
+      <CtrlSum>1012.5</CtrlSum>
+      <InitgPty><Nm>Acme Ltd</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>BATCH-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <NbOfTxs>2</NbOfTxs>
+      <ReqdExctnDt>2024-03-01</ReqdExctnDt>
+      <Dbtr><Nm>Acme Ltd</Nm></Dbtr>
+      <DbtrAcct><Id><Othr><Id>FF17FAST4821930576</Id></Othr></Id></DbtrAcct>
+      <DbtrAgt><FinInstnId><BIC>FASTGB2L</BIC></FinInstnId></DbtrAgt>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-1</InstrId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="GBP">12.50</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>FF14FAST7305618249</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="GBP">1000</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>FF14FAST7305618249</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestParsePain001(t *testing.T) {
+	doc, err := ParsePain001(strings.NewReader(testPain001))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "PAY-2024-0001", doc.GrpHdr.MsgID)
+	assert.Equal(t, 2, doc.Transactions())
+	p := doc.PmtInf[0]
+	assert.Equal(t, "FF17FAST4821930576", p.DbtrAcct.Number())
+	assert.Equal(t, "FF14FAST7305618249", p.CdtTrfTxInf[0].CdtrAcct.Number())
+	assert.Equal(t, "FF14FAST7305618249", p.CdtTrfTxInf[1].CdtrAcct.Number())
+	assert.Equal(t, Amount{Ccy: "GBP", Value: "12.50"}, p.CdtTrfTxInf[0].Amount)
+	assert.Equal(t, "I-1", p.CdtTrfTxInf[0].InstrID)
+}
+
+func TestParsePain001_Errors(t *testing.T) {
+	_, err := ParsePain001(strings.NewReader(""))
+	assert.EqualError(t, err, "file is empty")
+	_, err = ParsePain001(strings.NewReader("<Document"))
+	assert.ErrorContains(t, err, "invalid XML")
+	_, err = ParsePain001(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"/>`))
+	assert.EqualError(t, err, "not a pain.001.001.03 document")
+}
+
+func TestParsePain001_Validation(t *testing.T) {
+	cases := []struct {
+		name, old, new, want string
+	}{
+		{"missing msg id", "<MsgId>PAY-2024-0001</MsgId>", "", "GrpHdr/MsgId is required"},
+		{"long msg id", "PAY-2024-0001", strings.Repeat("x", 36), "GrpHdr/MsgId is longer than 35 characters"},
+		{"bad creation time", "2024-03-01T09:30:00.123+01:00", "2024-03-01", "GrpHdr/CreDtTm must be an ISO date and time"},
+		{"count mismatch", "<NbOfTxs>2</NbOfTxs>\n      <CtrlSum>", "<NbOfTxs>3</NbOfTxs>\n      <CtrlSum>", "GrpHdr/NbOfTxs is 3 but there are 2 transactions"},
+		{"control sum mismatch", "<CtrlSum>1012.5</CtrlSum>", "<CtrlSum>1012.51</CtrlSum>", "GrpHdr/CtrlSum is 1012.51 but the amounts add up to 1012.50"},
+		{"bad method", "<PmtMtd>TRF</PmtMtd>", "<PmtMtd>DD</PmtMtd>", "PmtInf[1]/PmtMtd must be TRF, CHK or TRA"},
+		{"missing debtor account", "<DbtrAcct><Id><Othr><Id>FF17FAST4821930576</Id></Othr></Id></DbtrAcct>", "", "PmtInf[1]/DbtrAcct/Id is required"},
+		{"bad amount", ">12.50<", ">12,50<", "PmtInf[1]/CdtTrfTxInf[1]/Amt/InstdAmt must be a decimal"},
+		{"zero amount", ">12.50<", ">0.00<", "PmtInf[1]/CdtTrfTxInf[1]/Amt/InstdAmt must be more than zero"},
+		{"bad currency", `Ccy="GBP">12.50`, `Ccy="gbp">12.50`, "PmtInf[1]/CdtTrfTxInf[1]/Amt/InstdAmt/@Ccy must be an ISO 4217 code"},
+		{"missing end to end id", "<EndToEndId>E2E-2</EndToEndId>", "", "PmtInf[1]/CdtTrfTxInf[2]/PmtId/EndToEndId is required"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := strings.Replace(testPain001, tc.old, tc.new, 1)
+			doc, err := ParsePain001(strings.NewReader(in))
+			var verr *ValidationError
+			if assert.True(t, errors.As(err, &verr), "got %v", err) {
+				assert.Contains(t, verr.Problems, tc.want)
+				assert.NotNil(t, doc)
+			}
+		})
+	}
+}
+
+func TestStatusReport(t *testing.T) {
+	doc, err := ParsePain001(strings.NewReader(testPain001))
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := NewStatusReport("PSR-1", time.Date(2024, 3, 1, 9, 31, 0, 0, time.UTC), doc)
+	r.Payments[0].Transactions[0].Status = StatusSettled
+	r.Payments[0].Transactions[0].ServicerRef = "31"
+	r.Payments[0].Transactions[1].Status = StatusRejected
+	r.Payments[0].Transactions[1].Reasons = []Reason{{Code: ReasonInsufficientFunds, Info: strings.Repeat("x", 200)}}
+	r.Summarize()
+	assert.Equal(t, StatusPartial, r.GroupStatus)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Write(&buf))
+	out := buf.String()
+	assert.Contains(t, out, `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">`)
+	assert.Contains(t, out, "<OrgnlMsgId>PAY-2024-0001</OrgnlMsgId>")
+	assert.Contains(t, out, "<OrgnlMsgNmId>pain.001.001.03</OrgnlMsgNmId>")
+	assert.Contains(t, out, "<GrpSts>PART</GrpSts>")
+	assert.Contains(t, out, "<OrgnlEndToEndId>E2E-1</OrgnlEndToEndId>\n        <TxSts>ACSC</TxSts>\n        <AcctSvcrRef>31</AcctSvcrRef>")
+	assert.Contains(t, out, "<Cd>AM04</Cd>")
+	assert.Contains(t, out, "<AddtlInf>"+strings.Repeat("x", 105)+"</AddtlInf>")
+
+	r.Reject(Reason{Code: ReasonDuplicateMessage})
+	buf.Reset()
+	assert.NoError(t, r.Write(&buf))
+	assert.Contains(t, buf.String(), "<GrpSts>RJCT</GrpSts>")
+	assert.NotContains(t, buf.String(), "OrgnlPmtInfAndSts")
+}
+
+func TestCombineStatuses(t *testing.T) {
+	assert.Equal(t, StatusSettled, combineStatuses([]string{StatusSettled, StatusSettled}))
+	assert.Equal(t, StatusPending, combineStatuses([]string{StatusSettled, StatusPending}))
+	assert.Equal(t, StatusPartial, combineStatuses([]string{StatusSettled, StatusPending, StatusRejected}))
+	assert.Equal(t, StatusRejected, combineStatuses([]string{StatusRejected}))
+}
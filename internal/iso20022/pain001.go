@@ -0,0 +1,251 @@
+// Package iso20022 reads pain.001 customer credit transfer initiations and
+// writes the pain.002 status reports that answer them.
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Pain001Version is the pain.001 version accepted.
+	Pain001Version   = "pain.001.001.03"
+	Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:" + Pain001Version
+)
+
+// Pain001 is a customer credit transfer initiation. Only the elements
+// needed to make and report on the transfers are read.
+type Pain001 struct {
+	XMLName xml.Name
+	GrpHdr  GroupHeader   `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PmtInf  []PaymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type GroupHeader struct {
+	MsgID    string     `xml:"MsgId"`
+	CreDtTm  string     `xml:"CreDtTm"`
+	NbOfTxs  string     `xml:"NbOfTxs"`
+	CtrlSum  string     `xml:"CtrlSum"`
+	InitgPty *PartyName `xml:"InitgPty"`
+}
+
+type PartyName struct {
+	Nm string `xml:"Nm"`
+}
+
+type PaymentInfo struct {
+	PmtInfID    string                 `xml:"PmtInfId"`
+	PmtMtd      string                 `xml:"PmtMtd"`
+	NbOfTxs     string                 `xml:"NbOfTxs"`
+	CtrlSum     string                 `xml:"CtrlSum"`
+	ReqdExctnDt string                 `xml:"ReqdExctnDt"`
+	Dbtr        *PartyName             `xml:"Dbtr"`
+	DbtrAcct    *CashAccount           `xml:"DbtrAcct"`
+	DbtrAgt     *FinancialInstitution  `xml:"DbtrAgt"`
+	CdtTrfTxInf []CreditTransferTxInfo `xml:"CdtTrfTxInf"`
+}
+
+type FinancialInstitution struct {
+	BIC string `xml:"FinInstnId>BIC"`
+}
+
+type CashAccount struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+	Ccy   string `xml:"Ccy"`
+}
+
+// Number is the account identifier, whichever form it was given in.
+func (a *CashAccount) Number() string {
+	if a == nil {
+		return ""
+	}
+	if a.IBAN != "" {
+		return strings.TrimSpace(a.IBAN)
+	}
+	return strings.TrimSpace(a.Other)
+}
+
+type CreditTransferTxInfo struct {
+	InstrID    string       `xml:"PmtId>InstrId"`
+	EndToEndID string       `xml:"PmtId>EndToEndId"`
+	Amount     Amount       `xml:"Amt>InstdAmt"`
+	Cdtr       *PartyName   `xml:"Cdtr"`
+	CdtrAcct   *CashAccount `xml:"CdtrAcct"`
+}
+
+type Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+var (
+	numericText  = regexp.MustCompile(`^[0-9]{1,15}$`)
+	decimalText  = regexp.MustCompile(`^[0-9]{1,18}(\.[0-9]{1,5})?$`)
+	currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+	isoDateTime  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+)
+
+// ValidationError lists every way a document breaks the pain.001 schema
+// rules.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid " + Pain001Version + ": " + strings.Join(e.Problems, "; ")
+}
+
+// ParsePain001 reads a pain.001 document. Input that is not XML, or not
+// pain.001, is an error. A document that breaks the schema rules is
+// returned along with a *ValidationError, so the rejection can still name
+// the message.
+func ParsePain001(r io.Reader) (*Pain001, error) {
+	var doc Pain001
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is empty")
+		}
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+	if doc.XMLName.Local != "Document" || doc.XMLName.Space != Pain001Namespace {
+		return nil, errors.New("not a " + Pain001Version + " document")
+	}
+	if problems := doc.validate(); len(problems) > 0 {
+		return &doc, &ValidationError{Problems: problems}
+	}
+	return &doc, nil
+}
+
+// Transactions returns how many CdtTrfTxInf the document holds.
+func (d *Pain001) Transactions() int {
+	n := 0
+	for i := range d.PmtInf {
+		n += len(d.PmtInf[i].CdtTrfTxInf)
+	}
+	return n
+}
+
+func (d *Pain001) validate() []string {
+	var problems []string
+	problems = checkText(problems, "GrpHdr/MsgId", d.GrpHdr.MsgID, 35)
+	if !validDateTime(d.GrpHdr.CreDtTm) {
+		problems = append(problems, "GrpHdr/CreDtTm must be an ISO date and time")
+	}
+	if d.GrpHdr.InitgPty == nil {
+		problems = append(problems, "GrpHdr/InitgPty is required")
+	}
+	if len(d.PmtInf) == 0 {
+		problems = append(problems, "at least one PmtInf is required")
+	}
+
+	total := new(big.Rat)
+	for i := range d.PmtInf {
+		p := &d.PmtInf[i]
+		where := fmt.Sprintf("PmtInf[%d]", i+1)
+		problems = checkText(problems, where+"/PmtInfId", p.PmtInfID, 35)
+		switch p.PmtMtd {
+		case "TRF", "CHK", "TRA":
+		default:
+			problems = append(problems, where+"/PmtMtd must be TRF, CHK or TRA")
+		}
+		if _, err := time.Parse(time.DateOnly, p.ReqdExctnDt); err != nil {
+			problems = append(problems, where+"/ReqdExctnDt must be an ISO date")
+		}
+		if p.Dbtr == nil {
+			problems = append(problems, where+"/Dbtr is required")
+		}
+		if p.DbtrAcct.Number() == "" {
+			problems = append(problems, where+"/DbtrAcct/Id is required")
+		}
+		if p.DbtrAgt == nil {
+			problems = append(problems, where+"/DbtrAgt is required")
+		}
+		if len(p.CdtTrfTxInf) == 0 {
+			problems = append(problems, where+" needs at least one CdtTrfTxInf")
+		}
+
+		sum := new(big.Rat)
+		for j := range p.CdtTrfTxInf {
+			tx := &p.CdtTrfTxInf[j]
+			txWhere := fmt.Sprintf("%s/CdtTrfTxInf[%d]", where, j+1)
+			problems = checkText(problems, txWhere+"/PmtId/EndToEndId", tx.EndToEndID, 35)
+			if tx.InstrID != "" {
+				problems = checkText(problems, txWhere+"/PmtId/InstrId", tx.InstrID, 35)
+			}
+			if !currencyCode.MatchString(tx.Amount.Ccy) {
+				problems = append(problems, txWhere+"/Amt/InstdAmt/@Ccy must be an ISO 4217 code")
+			}
+			amount, ok := parseDecimal(tx.Amount.Value)
+			switch {
+			case !ok:
+				problems = append(problems, txWhere+"/Amt/InstdAmt must be a decimal")
+			case amount.Sign() <= 0:
+				problems = append(problems, txWhere+"/Amt/InstdAmt must be more than zero")
+			default:
+				sum.Add(sum, amount)
+			}
+		}
+		total.Add(total, sum)
+		problems = checkCount(problems, where, p.NbOfTxs, p.CtrlSum, len(p.CdtTrfTxInf), sum, false)
+	}
+	return checkCount(problems, "GrpHdr", d.GrpHdr.NbOfTxs, d.GrpHdr.CtrlSum, d.Transactions(), total, true)
+}
+
+func checkText(problems []string, field, value string, max int) []string {
+	switch n := len([]rune(strings.TrimSpace(value))); {
+	case n == 0:
+		return append(problems, field+" is required")
+	case n > max:
+		return append(problems, fmt.Sprintf("%s is longer than %d characters", field, max))
+	}
+	return problems
+}
+
+// checkCount compares the optional NbOfTxs and CtrlSum of a group or
+// payment with what it holds. The group's NbOfTxs is mandatory.
+func checkCount(problems []string, where, nbOfTxs, ctrlSum string, count int, sum *big.Rat, required bool) []string {
+	if nbOfTxs != "" || required {
+		switch {
+		case !numericText.MatchString(nbOfTxs):
+			problems = append(problems, where+"/NbOfTxs must be a number")
+		case nbOfTxs != strconv.Itoa(count):
+			problems = append(problems, fmt.Sprintf("%s/NbOfTxs is %s but there are %d transactions", where, nbOfTxs, count))
+		}
+	}
+	if ctrlSum != "" {
+		want, ok := parseDecimal(ctrlSum)
+		switch {
+		case !ok:
+			problems = append(problems, where+"/CtrlSum must be a decimal")
+		case want.Cmp(sum) != 0:
+			problems = append(problems, fmt.Sprintf("%s/CtrlSum is %s but the amounts add up to %s", where, ctrlSum, sum.FloatString(2)))
+		}
+	}
+	return problems
+}
+
+// parseDecimal reads an xs:decimal with the digit limits ISO 20022 puts
+// on amounts.
+func parseDecimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if !decimalText.MatchString(s) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+func validDateTime(s string) bool {
+	if !isoDateTime.MatchString(s) {
+		return false
+	}
+	_, err := time.Parse("2006-01-02T15:04:05", s[:19])
+	return err == nil
+}
@@ -0,0 +1,231 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	Pain002Version   = "pain.002.001.03"
+	Pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:" + Pain002Version
+)
+
+// Transaction, payment and group statuses used in a report.
+const (
+	StatusSettled  = "ACSC" // funds moved
+	StatusPending  = "PDNG" // held for screening or waiting for approval
+	StatusRejected = "RJCT"
+	StatusPartial  = "PART" // some transactions rejected, others not
+)
+
+// ISO 20022 status reason codes used in a report.
+const (
+	ReasonInvalidFileFormat = "FF01"
+	ReasonDuplicateMessage  = "DU01"
+	ReasonIncorrectAccount  = "AC01"
+	ReasonInsufficientFunds = "AM04"
+	ReasonInvalidCurrency   = "AM03"
+	ReasonInvalidAmount     = "AM12"
+	ReasonInvalidDate       = "DT01"
+	ReasonNotAllowed        = "AG01"
+	ReasonNarrative         = "NARR"
+)
+
+// maxAdditionalInfo is the length limit on AddtlInf.
+const maxAdditionalInfo = 105
+
+// Reason explains a status. Info is free text, cut to fit.
+type Reason struct {
+	Code string
+	Info string
+}
+
+// StatusReport is a pain.002 answering one pain.001. Set the transaction
+// statuses, then Summarize to derive the payment and group statuses.
+type StatusReport struct {
+	MessageID         string
+	CreatedAt         time.Time
+	OriginalMessageID string
+	OriginalNbOfTxs   string
+	OriginalCtrlSum   string
+	GroupStatus       string
+	GroupReasons      []Reason
+	Payments          []*PaymentStatus
+}
+
+type PaymentStatus struct {
+	OriginalPaymentInfoID string
+	Status                string
+	Reasons               []Reason
+	Transactions          []*TransactionStatus
+}
+
+type TransactionStatus struct {
+	OriginalInstrID    string
+	OriginalEndToEndID string
+	Status             string
+	Reasons            []Reason
+	// ServicerRef is the ID of the transfer made, as camt.053 reports it.
+	ServicerRef string
+}
+
+// NewStatusReport starts the report answering doc, with one entry per
+// payment and transaction and no statuses set.
+func NewStatusReport(messageID string, createdAt time.Time, doc *Pain001) *StatusReport {
+	r := &StatusReport{
+		MessageID:         messageID,
+		CreatedAt:         createdAt,
+		OriginalMessageID: doc.GrpHdr.MsgID,
+		OriginalNbOfTxs:   doc.GrpHdr.NbOfTxs,
+		OriginalCtrlSum:   doc.GrpHdr.CtrlSum,
+	}
+	for i := range doc.PmtInf {
+		p := &PaymentStatus{OriginalPaymentInfoID: doc.PmtInf[i].PmtInfID}
+		for _, tx := range doc.PmtInf[i].CdtTrfTxInf {
+			p.Transactions = append(p.Transactions, &TransactionStatus{
+				OriginalInstrID:    tx.InstrID,
+				OriginalEndToEndID: tx.EndToEndID,
+			})
+		}
+		r.Payments = append(r.Payments, p)
+	}
+	return r
+}
+
+// Reject marks the whole message rejected without reporting on its
+// payments.
+func (r *StatusReport) Reject(reasons ...Reason) {
+	r.GroupStatus = StatusRejected
+	r.GroupReasons = reasons
+	r.Payments = nil
+}
+
+// Summarize sets each payment's status from its transactions, and the
+// group's from all of them.
+func (r *StatusReport) Summarize() {
+	var all []string
+	for _, p := range r.Payments {
+		var statuses []string
+		for _, tx := range p.Transactions {
+			statuses = append(statuses, tx.Status)
+		}
+		p.Status = combineStatuses(statuses)
+		all = append(all, statuses...)
+	}
+	r.GroupStatus = combineStatuses(all)
+}
+
+func combineStatuses(statuses []string) string {
+	combined := ""
+	rejected := false
+	for _, s := range statuses {
+		rejected = rejected || s == StatusRejected
+		if combined == "" {
+			combined = s
+		} else if combined != s {
+			combined = StatusPending
+		}
+	}
+	if combined == StatusPending && rejected {
+		return StatusPartial
+	}
+	return combined
+}
+
+type pain002Document struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Report  pain002StsRpt `xml:"CstmrPmtStsRpt"`
+}
+
+type pain002StsRpt struct {
+	MsgID   string             `xml:"GrpHdr>MsgId"`
+	CreDtTm string             `xml:"GrpHdr>CreDtTm"`
+	Group   pain002GroupStatus `xml:"OrgnlGrpInfAndSts"`
+	Pmts    []pain002PmtStatus `xml:"OrgnlPmtInfAndSts"`
+}
+
+type pain002GroupStatus struct {
+	OrgnlMsgID   string          `xml:"OrgnlMsgId"`
+	OrgnlMsgNmID string          `xml:"OrgnlMsgNmId"`
+	OrgnlNbOfTxs string          `xml:"OrgnlNbOfTxs,omitempty"`
+	OrgnlCtrlSum string          `xml:"OrgnlCtrlSum,omitempty"`
+	GrpSts       string          `xml:"GrpSts"`
+	StsRsnInf    []pain002Reason `xml:"StsRsnInf"`
+}
+
+type pain002PmtStatus struct {
+	OrgnlPmtInfID string            `xml:"OrgnlPmtInfId"`
+	PmtInfSts     string            `xml:"PmtInfSts,omitempty"`
+	StsRsnInf     []pain002Reason   `xml:"StsRsnInf"`
+	Txs           []pain002TxStatus `xml:"TxInfAndSts"`
+}
+
+type pain002TxStatus struct {
+	OrgnlInstrID    string          `xml:"OrgnlInstrId,omitempty"`
+	OrgnlEndToEndID string          `xml:"OrgnlEndToEndId"`
+	TxSts           string          `xml:"TxSts"`
+	StsRsnInf       []pain002Reason `xml:"StsRsnInf"`
+	AcctSvcrRef     string          `xml:"AcctSvcrRef,omitempty"`
+}
+
+type pain002Reason struct {
+	Code     string `xml:"Rsn>Cd"`
+	AddtlInf string `xml:"AddtlInf,omitempty"`
+}
+
+func pain002Reasons(reasons []Reason) []pain002Reason {
+	var out []pain002Reason
+	for _, r := range reasons {
+		info := []rune(r.Info)
+		if len(info) > maxAdditionalInfo {
+			info = info[:maxAdditionalInfo]
+		}
+		out = append(out, pain002Reason{Code: r.Code, AddtlInf: string(info)})
+	}
+	return out
+}
+
+// Write writes the report as a pain.002 document.
+func (r *StatusReport) Write(w io.Writer) error {
+	doc := pain002Document{
+		Xmlns: Pain002Namespace,
+		Report: pain002StsRpt{
+			MsgID:   r.MessageID,
+			CreDtTm: r.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Group: pain002GroupStatus{
+				OrgnlMsgID:   r.OriginalMessageID,
+				OrgnlMsgNmID: Pain001Version,
+				OrgnlNbOfTxs: r.OriginalNbOfTxs,
+				OrgnlCtrlSum: r.OriginalCtrlSum,
+				GrpSts:       r.GroupStatus,
+				StsRsnInf:    pain002Reasons(r.GroupReasons),
+			},
+		},
+	}
+	for _, p := range r.Payments {
+		ps := pain002PmtStatus{OrgnlPmtInfID: p.OriginalPaymentInfoID, PmtInfSts: p.Status, StsRsnInf: pain002Reasons(p.Reasons)}
+		for _, tx := range p.Transactions {
+			ps.Txs = append(ps.Txs, pain002TxStatus{
+				OrgnlInstrID:    tx.OriginalInstrID,
+				OrgnlEndToEndID: tx.OriginalEndToEndID,
+				TxSts:           tx.Status,
+				StsRsnInf:       pain002Reasons(tx.Reasons),
+				AcctSvcrRef:     tx.ServicerRef,
+			})
+		}
+		doc.Report.Pmts = append(doc.Report.Pmts, ps)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
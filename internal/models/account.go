@@ -1,16 +1,53 @@
 package models
 
 type Account struct {
-	AccountID      int   `json:"account_id"`
-	CurrentBalance int64 `json:"current_balance"`
+	AccountID      int    `json:"account_id"`
+	AccountNumber  string `json:"account_number"`
+	HolderName     string `json:"holder_name"`
+	CurrentBalance int64  `json:"current_balance"`
+	LedgerCode     string `json:"ledger_code"`
+	ProductCode    string `json:"product_code"`
+	ProductVersion int    `json:"product_version"`
+	Currency       string `json:"currency"`
+	// Terms are those of the account's product version.
+	Terms ProductTerms `json:"-"`
 }
 
+// AccountView exposes only the external account number; the internal ID
+// never leaves the service.
 type AccountView struct {
-	AccountID      int    `json:"account_id"`
-	CurrentBalance string `json:"current_balance"`
+	AccountID      int              `json:"-"`
+	AccountNumber  string           `json:"account_number"`
+	HolderName     string           `json:"holder_name"`
+	CurrentBalance string           `json:"current_balance"`
+	LedgerCode     string           `json:"ledger_code"`
+	ProductCode    string           `json:"product_code"`
+	ProductVersion int              `json:"product_version"`
+	Currency       string           `json:"currency"`
+	Terms          *ProductTerms    `json:"terms,omitempty"`
+	Holders        []*AccountHolder `json:"holders,omitempty"`
 }
 
+// CreateAccountRequest no longer carries an ID: the internal ID and the
+// external account number are both assigned by the server.
 type CreateAccountRequest struct {
-	AccountID      int    `json:"account_id"`
+	HolderName     string `json:"holder_name"`
 	InitialBalance string `json:"initial_balance"`
+	// ProductCode is the product the account is opened on, by default
+	// checking. Its current version's terms apply, and its currency and
+	// ledger account are used unless given.
+	ProductCode string `json:"product_code,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	// LedgerCode is the ledger account it posts to.
+	LedgerCode string `json:"ledger_code,omitempty"`
+}
+
+// AccountBalance is an account's balance at AsOf: every transfer completed
+// before then counted, none after.
+type AccountBalance struct {
+	AccountID      int    `json:"-"`
+	AccountNumber  string `json:"account_number"`
+	AsOf           string `json:"as_of"`
+	Balance        string `json:"balance"`
+	BalancePennies int64  `json:"balance_pennies"`
 }
@@ -0,0 +1,20 @@
+package models
+
+const (
+	ActivityTransfer = "transfer" // a transfer touching the account was created or changed status
+	ActivityBalance  = "balance"  // a transfer moved funds in or out of the account
+)
+
+// AccountActivity is one entry in an account's activity feed. AmountPennies
+// is negative when the account is the source of the transfer.
+type AccountActivity struct {
+	ID                        int64  `json:"id"`
+	AccountID                 int    `json:"-"`
+	Type                      string `json:"type"`
+	TransactionID             int    `json:"transaction_id"`
+	Status                    string `json:"status"`
+	AmountPennies             int64  `json:"amount_pennies"`
+	CounterpartyAccountNumber string `json:"counterparty_account_number"`
+	BalancePennies            *int64 `json:"balance_pennies,omitempty"` // balance entries only
+	OccurredAt                string `json:"occurred_at"`
+}
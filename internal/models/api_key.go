@@ -0,0 +1,29 @@
+package models
+
+// APIKey is a stored API key. Only the hash of the key is kept; the
+// plaintext is returned once, when the key is created or rotated.
+type APIKey struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	Role       string  `json:"role"`
+	CustomerID *int    `json:"customer_id,omitempty"`
+	Prefix     string  `json:"prefix"`
+	Hash       string  `json:"-"`
+	CreatedAt  string  `json:"created_at"`
+	RotatedAt  *string `json:"rotated_at,omitempty"`
+	RevokedAt  *string `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKeyRequest names a key and the role it acts with. Keys with the
+// customer role also name the customer they act for.
+type CreateAPIKeyRequest struct {
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	CustomerID *int   `json:"customer_id,omitempty"`
+}
+
+// IssuedAPIKey carries the plaintext key, shown only in this response.
+type IssuedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
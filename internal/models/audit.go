@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry is one row of the append-only audit log. Hash covers every other
+// field except ID, and PrevHash is the hash of the entry before it, so
+// changing or removing a row breaks the chain from that point on.
+type AuditEntry struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Actor is the principal's subject, or "system" for background jobs.
+	Actor      string          `json:"actor"`
+	ActorRole  string          `json:"actor_role,omitempty"`
+	AuthMethod string          `json:"auth_method,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
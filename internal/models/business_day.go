@@ -0,0 +1,29 @@
+package models
+
+// BusinessDay is a closed business day. It runs from StartsAt, the previous
+// business day's cut-off, to EndsAt, its own. Every transfer is one debit
+// and one credit, so TotalDebits and TotalCredits agree when the books do.
+type BusinessDay struct {
+	Day           string `json:"day"`
+	StartsAt      string `json:"starts_at"`
+	EndsAt        string `json:"ends_at"`
+	TransferCount int    `json:"transfer_count"`
+	TotalDebits   int64  `json:"total_debits_pennies"`
+	TotalCredits  int64  `json:"total_credits_pennies"`
+	ClosedBy      string `json:"closed_by"`
+	ClosedAt      string `json:"closed_at"`
+}
+
+// DailyBalance is an account's balances and movements over a closed
+// business day. ClosingBalance is OpeningBalance less Debits plus Credits.
+type DailyBalance struct {
+	Day            string `json:"day"`
+	AccountID      int    `json:"-"`
+	AccountNumber  string `json:"account_number"`
+	OpeningBalance int64  `json:"opening_balance_pennies"`
+	Debits         int64  `json:"debits_pennies"`
+	DebitCount     int    `json:"debit_count"`
+	Credits        int64  `json:"credits_pennies"`
+	CreditCount    int    `json:"credit_count"`
+	ClosingBalance int64  `json:"closing_balance_pennies"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// Monthly account charges.
+const (
+	ChargeMonthlyFee = "monthly_fee"
+	ChargeInterest   = "interest"
+)
+
+// ChargeableAccount is an account whose terms have a monthly fee or an
+// interest rate, with its balance at the end of a month and which of that
+// month's charges it has had.
+type ChargeableAccount struct {
+	AccountID       int
+	MonthlyFee      int64 // pennies
+	InterestRateBPS int
+	BalancePennies  int64
+	FeeCharged      bool
+	InterestPaid    bool
+}
@@ -0,0 +1,51 @@
+package models
+
+const (
+	HolderRoleOwner          = "owner"
+	HolderRoleJoint          = "joint"
+	HolderRoleAuthorizedUser = "authorized_user"
+)
+
+type Customer struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type CustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// AccountHolder links a customer to an account with a role.
+type AccountHolder struct {
+	AccountID  int    `json:"-"`
+	CustomerID int    `json:"customer_id"`
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type AddAccountHolderRequest struct {
+	CustomerID int    `json:"customer_id"`
+	Role       string `json:"role"`
+}
+
+// AccountHolding is an account together with one holder's role on it.
+type AccountHolding struct {
+	Account *Account
+	Role    string
+}
+
+// CustomerAccountView is an account as seen from one of its holders.
+type CustomerAccountView struct {
+	AccountID      int    `json:"-"`
+	AccountNumber  string `json:"account_number"`
+	HolderName     string `json:"holder_name"`
+	CurrentBalance string `json:"current_balance"`
+	Role           string `json:"role"`
+}
@@ -0,0 +1,57 @@
+package models
+
+const (
+	DisputeOpen        = "open"         // provisionally credited, waiting for review
+	DisputeUnderReview = "under_review" // evidence is being gathered
+	DisputeWon         = "won"          // the counterparty was debited; the credit stands
+	DisputeLost        = "lost"         // the provisional credit was reversed
+)
+
+// Dispute is a customer's claim against a completed transfer out of their
+// account. The account is credited the amount from the suspense account by
+// ProvisionalTransactionID when the dispute opens. Settling it transfers
+// the amount into the suspense account by SettlementTransactionID: from the
+// counterparty if the customer won, or back from the customer if they lost.
+type Dispute struct {
+	ID                        int            `json:"id"`
+	TransactionID             int            `json:"transaction_id"`
+	AccountID                 int            `json:"-"`
+	AccountNumber             string         `json:"account_number"`
+	CounterpartyAccountID     int            `json:"-"`
+	CounterpartyAccountNumber string         `json:"counterparty_account_number"`
+	AmountPennies             int64          `json:"amount_pennies"`
+	Reason                    string         `json:"reason"`
+	Status                    string         `json:"status"`
+	ProvisionalTransactionID  int            `json:"provisional_transaction_id"`
+	ProvisionalStatus         string         `json:"provisional_status"`
+	SettlementTransactionID   *int           `json:"settlement_transaction_id,omitempty"`
+	SettlementStatus          string         `json:"settlement_status,omitempty"`
+	OpenedBy                  string         `json:"opened_by"`
+	ResolvedBy                string         `json:"resolved_by,omitempty"`
+	CreatedAt                 string         `json:"created_at"`
+	ResolvedAt                *string        `json:"resolved_at,omitempty"`
+	Notes                     []*DisputeNote `json:"notes,omitempty"`
+}
+
+// DisputeNote is a piece of evidence or a remark added to a dispute.
+type DisputeNote struct {
+	ID        int    `json:"id"`
+	DisputeID int    `json:"dispute_id"`
+	Author    string `json:"author"`
+	Note      string `json:"note"`
+	CreatedAt string `json:"created_at"`
+}
+
+type DisputeRequest struct {
+	TransactionID int    `json:"transaction_id"`
+	Reason        string `json:"reason"`
+}
+
+type DisputeNoteRequest struct {
+	Note string `json:"note"`
+}
+
+// DisputeResolution settles a dispute as won or lost.
+type DisputeResolution struct {
+	Outcome string `json:"outcome"`
+}
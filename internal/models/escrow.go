@@ -0,0 +1,58 @@
+package models
+
+const (
+	EscrowHeld     = "held"     // in the escrow account, waiting to be settled
+	EscrowReleased = "released" // transferred to the seller
+	EscrowRefunded = "refunded" // transferred back to the buyer
+)
+
+// What happens to an escrow at its deadline, unless it is disputed.
+const (
+	EscrowDeadlineRelease = "release"
+	EscrowDeadlineRefund  = "refund"
+)
+
+// EscrowProductCode is the product escrow accounts are opened on.
+const EscrowProductCode = "escrow"
+
+// Escrow is an amount a buyer has paid into the escrow account of their
+// pair with a seller, by FundingTransactionID. It is released to the seller
+// or refunded to the buyer by SettlementTransactionID, on request or at the
+// deadline. A disputed escrow is only settled on request.
+type Escrow struct {
+	ID                      int     `json:"id"`
+	BuyerAccountID          int     `json:"-"`
+	BuyerAccountNumber      string  `json:"buyer_account_number"`
+	SellerAccountID         int     `json:"-"`
+	SellerAccountNumber     string  `json:"seller_account_number"`
+	EscrowAccountID         int     `json:"-"`
+	EscrowAccountNumber     string  `json:"escrow_account_number"`
+	AmountPennies           int64   `json:"amount_pennies"`
+	Status                  string  `json:"status"`
+	Deadline                string  `json:"deadline"`
+	DeadlineAction          string  `json:"deadline_action"`
+	DisputedAt              *string `json:"disputed_at,omitempty"`
+	DisputeReason           string  `json:"dispute_reason,omitempty"`
+	FundingTransactionID    int     `json:"funding_transaction_id"`
+	FundingStatus           string  `json:"funding_status"`
+	SettlementTransactionID *int    `json:"settlement_transaction_id,omitempty"`
+	SettlementStatus        string  `json:"settlement_status,omitempty"`
+	CreatedBy               string  `json:"created_by"`
+	CreatedAt               string  `json:"created_at"`
+	SettledAt               *string `json:"settled_at,omitempty"`
+}
+
+// EscrowRequest pays Amount from the buyer's account into escrow for the
+// seller. Deadline is an RFC 3339 time; DeadlineAction is release (the
+// default) or refund.
+type EscrowRequest struct {
+	BuyerAccountNumber  string `json:"buyer_account_number"`
+	SellerAccountNumber string `json:"seller_account_number"`
+	Amount              string `json:"amount"`
+	Deadline            string `json:"deadline"`
+	DeadlineAction      string `json:"deadline_action,omitempty"`
+}
+
+type EscrowDisputeRequest struct {
+	Reason string `json:"reason"`
+}
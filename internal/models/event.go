@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types written to the outbox.
+const (
+	EventAccountCreated    = "AccountCreated"
+	EventTransferCompleted = "TransferCompleted"
+	EventTransferFailed    = "TransferFailed" // failed, rejected or expired without moving funds
+)
+
+// Event is a domain event written to the outbox in the same DB transaction as
+// the change it describes. ID is stable across redeliveries, so consumers can
+// use it to deduplicate.
+type Event struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"-"`
+}
+
+// AccountCreatedPayload is the payload of an AccountCreated event.
+type AccountCreatedPayload struct {
+	AccountNumber  string `json:"account_number"`
+	HolderName     string `json:"holder_name"`
+	BalancePennies int64  `json:"balance_pennies"`
+}
+
+// TransferPayload is the payload of TransferCompleted and TransferFailed
+// events. Status tells a failed transfer from a rejected or expired one.
+type TransferPayload struct {
+	TransactionID            int    `json:"transaction_id"`
+	SourceAccountNumber      string `json:"source_account_number"`
+	DestinationAccountNumber string `json:"destination_account_number"`
+	AmountPennies            int64  `json:"amount_pennies"`
+	Status                   string `json:"status"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// External account types, by the details that identify them.
+const (
+	ExternalAccountTypeIBAN = "iban" // international: IBAN, optionally a BIC
+	ExternalAccountTypeUK   = "uk"   // UK sort code and account number
+	ExternalAccountTypeUS   = "us"   // US ABA routing number and account number
+)
+
+// ExternalAccount is a payout destination held at another bank, registered
+// by a customer. Only the fields for its Type are set.
+type ExternalAccount struct {
+	ID            int    `json:"id"`
+	CustomerID    int    `json:"customer_id"`
+	Type          string `json:"type"`
+	Label         string `json:"label,omitempty"`
+	HolderName    string `json:"holder_name"`
+	IBAN          string `json:"iban,omitempty"`
+	BIC           string `json:"bic,omitempty"`
+	SortCode      string `json:"sort_code,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	RoutingNumber string `json:"routing_number,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type ExternalAccountRequest struct {
+	Type          string `json:"type"`
+	Label         string `json:"label"`
+	HolderName    string `json:"holder_name"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+	SortCode      string `json:"sort_code"`
+	AccountNumber string `json:"account_number"`
+	RoutingNumber string `json:"routing_number"`
+}
@@ -0,0 +1,114 @@
+package models
+
+// Ledger account types. Assets and expenses normally carry debit balances,
+// the others credit balances.
+const (
+	LedgerTypeAsset     = "asset"
+	LedgerTypeLiability = "liability"
+	LedgerTypeEquity    = "equity"
+	LedgerTypeIncome    = "income"
+	LedgerTypeExpense   = "expense"
+)
+
+// Ledger accounts every chart of accounts has. Customer accounts post to
+// customer deposits unless told otherwise; opening balances offsets the
+// balances accounts are opened with, so the books balance.
+const (
+	LedgerCodeCustomerDeposits = "2000"
+	LedgerCodeOpeningBalances  = "3000"
+)
+
+// LedgerAccount is an account in the chart of accounts. Accounts post to
+// it, and reports show their combined balances under it.
+type LedgerAccount struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type LedgerAccountRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// LedgerTotals are a ledger account's movements up to To, split at From.
+// A transfer debits its source and credits its destination; an account's
+// initial balance is credited to it when it is opened.
+type LedgerTotals struct {
+	Code string
+	Name string
+	Type string
+	// InitialBefore and InitialDuring are the initial balances of the
+	// accounts opened before From and between From and To.
+	InitialBefore int64
+	InitialDuring int64
+	// NetBefore is credits less debits before From.
+	NetBefore int64
+	// Debits and Credits are the transfers between From and To.
+	Debits  int64
+	Credits int64
+}
+
+// TrialBalance lists every ledger account's debits and credits over a
+// period and its balance at the end, in the debit or credit column. The
+// columns' totals agree when the books balance.
+type TrialBalance struct {
+	From         string              `json:"from,omitempty"`
+	To           string              `json:"to"`
+	Lines        []*TrialBalanceLine `json:"lines"`
+	TotalDebits  int64               `json:"total_debit_balances_pennies"`
+	TotalCredits int64               `json:"total_credit_balances_pennies"`
+	Balanced     bool                `json:"balanced"`
+}
+
+type TrialBalanceLine struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Debits        int64  `json:"debits_pennies"`
+	Credits       int64  `json:"credits_pennies"`
+	DebitBalance  int64  `json:"debit_balance_pennies"`
+	CreditBalance int64  `json:"credit_balance_pennies"`
+}
+
+// ReportLine is a ledger account's balance in a report, positive on its
+// normal side.
+type ReportLine struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Balance int64  `json:"balance_pennies"`
+}
+
+// BalanceSheet shows assets, liabilities and equity at To. Earnings, income
+// less expenses, are part of equity: RetainedEarnings up to From and
+// NetIncome over the period. Assets equal liabilities plus equity when the
+// books balance.
+type BalanceSheet struct {
+	From             string        `json:"from,omitempty"`
+	To               string        `json:"to"`
+	Assets           []*ReportLine `json:"assets"`
+	Liabilities      []*ReportLine `json:"liabilities"`
+	Equity           []*ReportLine `json:"equity"`
+	RetainedEarnings int64         `json:"retained_earnings_pennies"`
+	NetIncome        int64         `json:"net_income_pennies"`
+	TotalAssets      int64         `json:"total_assets_pennies"`
+	TotalLiabilities int64         `json:"total_liabilities_pennies"`
+	TotalEquity      int64         `json:"total_equity_pennies"`
+	Balanced         bool          `json:"balanced"`
+}
+
+// IncomeStatement shows each income and expense account's movement over a
+// period.
+type IncomeStatement struct {
+	From          string        `json:"from,omitempty"`
+	To            string        `json:"to"`
+	Income        []*ReportLine `json:"income"`
+	Expenses      []*ReportLine `json:"expenses"`
+	TotalIncome   int64         `json:"total_income_pennies"`
+	TotalExpenses int64         `json:"total_expenses_pennies"`
+	NetIncome     int64         `json:"net_income_pennies"`
+}
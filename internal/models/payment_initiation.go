@@ -0,0 +1,14 @@
+package models
+
+// PaymentInitiation records a pain.001 message that was accepted for
+// execution, so the same message is never executed twice. GroupStatus is
+// the pain.002 group status, empty until every transaction has been tried.
+type PaymentInitiation struct {
+	ID           int     `json:"id"`
+	MessageID    string  `json:"message_id"`
+	InitiatedBy  string  `json:"initiated_by"`
+	Transactions int     `json:"transactions"`
+	GroupStatus  string  `json:"group_status,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+	CompletedAt  *string `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,79 @@
+package models
+
+const (
+	PayoutPending  = "pending"  // debited into the clearing account, not yet in a file
+	PayoutSent     = "sent"     // written to an ACH file
+	PayoutReturned = "returned" // the receiving bank sent it back; see ReturnCode
+)
+
+// Payout is an ACH credit to a customer's US bank account. The amount is
+// first moved from the customer's account into the ACH clearing account by
+// TransactionID, and leaves the clearing account when the file is settled.
+// The receiver's details are copied from the external account when the
+// payout is made.
+type Payout struct {
+	ID                  int     `json:"id"`
+	AccountID           int     `json:"-"`
+	AccountNumber       string  `json:"account_number"`
+	ExternalAccountID   int     `json:"external_account_id"`
+	RoutingNumber       string  `json:"routing_number"`
+	ReceiverAccount     string  `json:"receiver_account_number"`
+	ReceiverName        string  `json:"receiver_name"`
+	AmountPennies       int64   `json:"amount_pennies"`
+	Status              string  `json:"status"`
+	TransactionID       int     `json:"transaction_id"`
+	TransactionStatus   string  `json:"transaction_status"`
+	FileID              *int    `json:"ach_file_id,omitempty"`
+	TraceNumber         string  `json:"trace_number,omitempty"`
+	ReturnCode          string  `json:"return_code,omitempty"`
+	ReturnTransactionID *int    `json:"return_transaction_id,omitempty"`
+	CreatedBy           string  `json:"created_by"`
+	CreatedAt           string  `json:"created_at"`
+	SentAt              *string `json:"sent_at,omitempty"`
+	ReturnedAt          *string `json:"returned_at,omitempty"`
+}
+
+// PayoutRequest pays from an account to an external account registered by
+// CustomerID, who must hold the account. Customers may leave CustomerID
+// out.
+type PayoutRequest struct {
+	SourceAccountNumber string `json:"source_account_number"`
+	CustomerID          int    `json:"customer_id"`
+	ExternalAccountID   int    `json:"external_account_id"`
+	Amount              string `json:"amount"`
+}
+
+// ACHFile is a NACHA file of pending payouts. Content is kept so a file
+// can be written out again if writing it to the output directory failed.
+type ACHFile struct {
+	ID           int     `json:"id"`
+	FileName     string  `json:"file_name"`
+	Content      string  `json:"-"`
+	EntryCount   int     `json:"entry_count"`
+	TotalPennies int64   `json:"total_pennies"`
+	CreatedAt    string  `json:"created_at"`
+	WrittenAt    *string `json:"written_at,omitempty"`
+}
+
+// ACHReturnResult reports what was done with each entry of a return file.
+type ACHReturnResult struct {
+	Returns []*ACHReturnOutcome `json:"returns"`
+}
+
+const (
+	ACHReturnReversed = "reversed" // payout marked returned and the amount credited back
+	ACHReturnSkipped  = "skipped"  // nothing to do; see Error
+)
+
+type ACHReturnOutcome struct {
+	TraceNumber   string `json:"trace_number"`
+	ReturnCode    string `json:"return_code"`
+	AmountPennies int64  `json:"amount_pennies"`
+	PayoutID      int    `json:"payout_id,omitempty"`
+	Status        string `json:"status"`
+	TransactionID int    `json:"transaction_id,omitempty"`
+	// TransactionStatus is pending_approval when the reversal is above the
+	// approval threshold.
+	TransactionStatus string `json:"transaction_status,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
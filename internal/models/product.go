@@ -0,0 +1,67 @@
+package models
+
+// Product types.
+const (
+	ProductTypeChecking = "checking"
+	ProductTypeSavings  = "savings"
+	ProductTypeBusiness = "business"
+	ProductTypeEscrow   = "escrow"
+	ProductTypeInternal = "internal"
+)
+
+// DefaultProductCode is the product accounts are opened on when none is
+// given.
+const DefaultProductCode = "checking"
+
+// Product is an account product with its current terms. Changing the terms
+// adds a version; accounts keep the version they were opened on until they
+// are migrated.
+type Product struct {
+	Code      string       `json:"code"`
+	Name      string       `json:"name"`
+	Type      string       `json:"type"`
+	Version   int          `json:"version"`
+	Terms     ProductTerms `json:"terms"`
+	CreatedAt string       `json:"created_at"`
+	UpdatedAt string       `json:"updated_at"` // when the current version was added
+}
+
+// ProductTerms are what a product version offers. Amounts are in pennies.
+// Currency and LedgerCode are defaults for new accounts. TransferFee is
+// charged on each transfer out; MonthlyFee and interest are charged once a
+// month by ChargeService.
+type ProductTerms struct {
+	Currency           string `json:"currency"`
+	OverdraftLimit     int64  `json:"overdraft_limit_pennies"`
+	UnlimitedOverdraft bool   `json:"unlimited_overdraft"`
+	MonthlyFee         int64  `json:"monthly_fee_pennies"`
+	TransferFee        int64  `json:"transfer_fee_pennies"`
+	InterestRateBPS    int    `json:"interest_rate_bps"` // yearly, in hundredths of a percent
+	// MaxTransfer and DailyTransferLimit cap a single transfer out and the
+	// transfers out over a UTC day; nil means no limit.
+	MaxTransfer        *int64 `json:"max_transfer_pennies,omitempty"`
+	DailyTransferLimit *int64 `json:"daily_transfer_limit_pennies,omitempty"`
+	LedgerCode         string `json:"ledger_code"`
+}
+
+// ProductVersion is one version of a product's terms.
+type ProductVersion struct {
+	Version   int          `json:"version"`
+	Terms     ProductTerms `json:"terms"`
+	CreatedAt string       `json:"created_at"`
+}
+
+type ProductRequest struct {
+	Code  string       `json:"code"`
+	Name  string       `json:"name"`
+	Type  string       `json:"type"`
+	Terms ProductTerms `json:"terms"`
+}
+
+// ProductMigration is the outcome of moving a product's accounts onto its
+// current version.
+type ProductMigration struct {
+	ProductCode      string `json:"product_code"`
+	Version          int    `json:"version"`
+	AccountsMigrated int64  `json:"accounts_migrated"`
+}
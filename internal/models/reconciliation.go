@@ -0,0 +1,35 @@
+package models
+
+// AccountBalanceCheck is an account's stored balance next to the one its
+// history gives: the initial balance plus completed transfers in, less
+// completed transfers out.
+type AccountBalanceCheck struct {
+	AccountID              int
+	AccountNumber          string
+	InitialBalancePennies  int64
+	BalancePennies         int64
+	ExpectedBalancePennies int64
+}
+
+// Reconciliation is the outcome of checking every account's balance against
+// the transaction history. Transfers only move money between accounts, so
+// the total balance must equal the total the accounts were opened with.
+type Reconciliation struct {
+	CheckedAt            string                `json:"checked_at"`
+	AccountsChecked      int                   `json:"accounts_checked"`
+	Discrepancies        []*BalanceDiscrepancy `json:"discrepancies"`
+	TotalBalancePennies  int64                 `json:"total_balance_pennies"`
+	TotalInitialPennies  int64                 `json:"total_initial_balance_pennies"`
+	Conserved            bool                  `json:"conserved"`
+	OK                   bool                  `json:"ok"`
+	DurationMilliseconds int64                 `json:"duration_ms"`
+}
+
+// BalanceDiscrepancy is an account whose balance has drifted from its
+// history. DifferencePennies is the balance less the expected balance.
+type BalanceDiscrepancy struct {
+	AccountNumber          string `json:"account_number"`
+	BalancePennies         int64  `json:"balance_pennies"`
+	ExpectedBalancePennies int64  `json:"expected_balance_pennies"`
+	DifferencePennies      int64  `json:"difference_pennies"`
+}
@@ -0,0 +1,28 @@
+package models
+
+const (
+	ScreeningCaseOpen      = "open"
+	ScreeningCaseCleared   = "cleared"   // false positive
+	ScreeningCaseConfirmed = "confirmed" // true match
+)
+
+// ScreeningCase records a sanctions list hit against an account holder.
+// TransactionID is set when the hit was raised by a transfer, which stays held
+// until the case is resolved.
+type ScreeningCase struct {
+	ID            int     `json:"id"`
+	AccountID     int     `json:"account_id"`
+	TransactionID *int    `json:"transaction_id,omitempty"`
+	ScreenedName  string  `json:"screened_name"`
+	EntryUID      string  `json:"entry_uid"`
+	ListedName    string  `json:"listed_name"`
+	Score         float64 `json:"score"`
+	Status        string  `json:"status"`
+	Note          string  `json:"note,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+	ResolvedAt    *string `json:"resolved_at,omitempty"`
+}
+
+type ResolveScreeningCaseRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Statement describes an account statement for the period [From, To).
+// Both balances are known before the first line is written.
+type Statement struct {
+	AccountNumber         string
+	HolderName            string
+	Currency              string // ISO 4217
+	BankID                string
+	From                  time.Time
+	To                    time.Time
+	GeneratedAt           time.Time
+	OpeningBalancePennies int64
+	ClosingBalancePennies int64
+}
+
+// StatementLine is a completed transfer as it affected the account.
+// AmountPennies is negative for debits; BalancePennies is the running
+// balance after it.
+type StatementLine struct {
+	TransactionID             int
+	PostedAt                  time.Time
+	AmountPennies             int64
+	BalancePennies            int64
+	CounterpartyAccountNumber string
+}
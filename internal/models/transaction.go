@@ -1,16 +1,43 @@
 package models
 
+const (
+	TransactionStatusCompleted       = "completed"
+	TransactionStatusHeld            = "held"             // blocked by an open screening case
+	TransactionStatusPendingApproval = "pending_approval" // above the approval threshold, awaiting a second person
+	TransactionStatusRejected        = "rejected"         // screening hit confirmed or approver declined
+	TransactionStatusExpired         = "expired"          // not approved before expires_at
+	TransactionStatusFailed          = "failed"           // released but could no longer settle
+)
+
 type Transaction struct {
-	ID                   int    `json:"id"`
-	SourceAccountID      int    `json:"source_account_id"`
-	DestinationAccountID int    `json:"destination_account_id"`
-	AmountPennies        int64  `json:"amount_pennies"`
-	Status               string `json:"status"`
-	CreatedAt            string `json:"created_at"`
+	ID                       int     `json:"id"`
+	SourceAccountID          int     `json:"-"`
+	DestinationAccountID     int     `json:"-"`
+	SourceAccountNumber      string  `json:"source_account_number"`
+	DestinationAccountNumber string  `json:"destination_account_number"`
+	AmountPennies            int64   `json:"amount_pennies"`
+	Status                   string  `json:"status"`
+	InitiatedBy              string  `json:"initiated_by,omitempty"`
+	ReviewedBy               string  `json:"reviewed_by,omitempty"`
+	ReviewNote               string  `json:"review_note,omitempty"`
+	ExpiresAt                *string `json:"expires_at,omitempty"`
+	CreatedAt                string  `json:"created_at"`
+	CompletedAt              *string `json:"completed_at,omitempty"` // when funds moved
 }
 
+// TransactionRequest identifies accounts by their external account numbers.
+// The internal IDs are filled in once the numbers are resolved, or set
+// directly by in-process callers.
 type TransactionRequest struct {
-	SourceAccountID      int    `json:"source_account_id"`
-	DestinationAccountID int    `json:"destination_account_id"`
-	Amount               string `json:"amount"`
+	SourceAccountNumber      string `json:"source_account_number"`
+	DestinationAccountNumber string `json:"destination_account_number"`
+	SourceAccountID          int    `json:"-"`
+	DestinationAccountID     int    `json:"-"`
+	Amount                   string `json:"amount"`
+	InitiatedBy              string `json:"initiated_by"`
+}
+
+type ReviewTransactionRequest struct {
+	Approver string `json:"approver"`
+	Note     string `json:"note"`
 }
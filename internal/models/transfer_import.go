@@ -0,0 +1,60 @@
+package models
+
+const (
+	TransferImportValidated  = "validated" // dry run done, waiting for confirmation
+	TransferImportProcessing = "processing"
+	TransferImportCompleted  = "completed"
+)
+
+const (
+	ImportRowValid   = "valid"
+	ImportRowInvalid = "invalid"
+	ImportRowPending = "pending" // confirmed, not yet executed
+	ImportRowCreated = "created" // the transfer was created; see TransactionStatus
+	ImportRowFailed  = "failed"
+)
+
+// TransferImport is a file of transfers uploaded in one go. It is validated
+// on upload and executed row by row in the background once confirmed.
+type TransferImport struct {
+	ID       int    `json:"id"`
+	FileName string `json:"file_name"`
+	Status   string `json:"status"`
+	RowCount int    `json:"row_count"`
+	// RowStatuses counts the rows in each status, for progress.
+	RowStatuses map[string]int `json:"row_statuses"`
+	// TotalPennies is the sum of the valid rows.
+	TotalPennies int64   `json:"total_pennies"`
+	CreatedBy    string  `json:"created_by"`
+	CreatedAt    string  `json:"created_at"`
+	ConfirmedBy  string  `json:"confirmed_by,omitempty"`
+	ConfirmedAt  *string `json:"confirmed_at,omitempty"`
+	CompletedAt  *string `json:"completed_at,omitempty"`
+	// Rows are only returned with the dry-run report.
+	Rows []*TransferImportRow `json:"rows,omitempty"`
+}
+
+// TransferImportRow is one transfer in an import. Line is its line in the
+// file, where the header is line 1.
+type TransferImportRow struct {
+	ImportID                 int    `json:"-"`
+	Line                     int    `json:"line"`
+	SourceAccountNumber      string `json:"source"`
+	DestinationAccountNumber string `json:"destination"`
+	Amount                   string `json:"amount"`
+	AmountPennies            int64  `json:"amount_pennies"` // 0 when the amount is invalid
+	Reference                string `json:"reference,omitempty"`
+	Status                   string `json:"status"`
+	Error                    string `json:"error,omitempty"`
+	TransactionID            *int   `json:"transaction_id,omitempty"`
+	TransactionStatus        string `json:"transaction_status,omitempty"`
+}
+
+// DueTransferImportRow is a confirmed row waiting to be executed, with who
+// confirmed its import: the transfer is made on their behalf.
+type DueTransferImportRow struct {
+	*TransferImportRow
+	ConfirmedBy         string
+	ConfirmedRole       string
+	ConfirmedAuthMethod string
+}
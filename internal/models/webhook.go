@@ -0,0 +1,68 @@
+package models
+
+import "encoding/json"
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead" // gave up after the last retry; can be replayed
+)
+
+// WebhookSubscription receives the events of the listed types. The secret
+// signs every delivery and is returned only when the subscription is created.
+type WebhookSubscription struct {
+	ID         int      `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"-"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// WebhookSubscriptionRequest registers an endpoint. A secret is generated
+// when none is given.
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+// CreatedWebhookSubscription carries the signing secret, shown only in this
+// response.
+type CreatedWebhookSubscription struct {
+	*WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one subscription.
+type WebhookDelivery struct {
+	ID             int64                     `json:"id"`
+	SubscriptionID int                       `json:"subscription_id"`
+	EventID        int64                     `json:"event_id"`
+	EventType      string                    `json:"event_type"`
+	Event          json.RawMessage           `json:"event" swaggertype:"object"`
+	Status         string                    `json:"status"`
+	Attempts       int                       `json:"attempts"`
+	NextAttemptAt  *string                   `json:"next_attempt_at,omitempty"` // while pending
+	LastStatusCode *int                      `json:"last_status_code,omitempty"`
+	LastError      string                    `json:"last_error,omitempty"`
+	CreatedAt      string                    `json:"created_at"`
+	DeliveredAt    *string                   `json:"delivered_at,omitempty"`
+	AttemptLog     []*WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookDeliveryAttempt is one request made for a delivery. StatusCode is
+// nil when no response arrived.
+type WebhookDeliveryAttempt struct {
+	AttemptedAt string `json:"attempted_at"`
+	StatusCode  *int   `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// DueWebhookDelivery is a pending delivery claimed for sending, with where
+// to send it.
+type DueWebhookDelivery struct {
+	*WebhookDelivery
+	URL    string
+	Secret string
+}
@@ -0,0 +1,227 @@
+// Package nacha writes NACHA ACH files of PPD credits and reads the return
+// files an ODFI sends back for them.
+package nacha
+
+import (
+	"bufio"
+	"errors"
+	"fastfunds/internal/util"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	recordLength   = 94
+	blockingFactor = 10
+
+	// serviceClassCredits marks a batch that only contains credits.
+	serviceClassCredits = "220"
+	// transactionCodeCheckingCredit is a credit to a checking account.
+	transactionCodeCheckingCredit = "22"
+
+	// MaxAmountPennies is the largest amount an entry can carry.
+	MaxAmountPennies = 9999999999
+)
+
+var (
+	routingNumber = regexp.MustCompile(`^[0-9]{9}$`)
+	odfiID        = regexp.MustCompile(`^[0-9]{8}$`)
+	dfiAccount    = regexp.MustCompile(`^[0-9A-Za-z -]{1,17}$`)
+)
+
+// Originator identifies who sends the file and through which bank.
+type Originator struct {
+	// ImmediateDestination is the routing number of the ACH operator or
+	// bank the file is delivered to.
+	ImmediateDestination     string
+	ImmediateDestinationName string
+	// ImmediateOrigin is usually "1" and the company's EIN.
+	ImmediateOrigin     string
+	ImmediateOriginName string
+	CompanyName         string
+	CompanyID           string
+	// ODFI is the first eight digits of the originating bank's routing
+	// number. Trace numbers start with it.
+	ODFI string
+}
+
+// Validate reports the first field that can't be written to a file.
+func (o *Originator) Validate() error {
+	switch {
+	case !routingNumber.MatchString(o.ImmediateDestination):
+		return errors.New("immediate destination must be a 9-digit routing number")
+	case o.ImmediateOrigin == "" || len(o.ImmediateOrigin) > 10:
+		return errors.New("immediate origin must be 1 to 10 characters")
+	case o.CompanyName == "":
+		return errors.New("company name is required")
+	case o.CompanyID == "" || len(o.CompanyID) > 10:
+		return errors.New("company ID must be 1 to 10 characters")
+	case !odfiID.MatchString(o.ODFI):
+		return errors.New("ODFI must be the first 8 digits of a routing number")
+	}
+	return nil
+}
+
+// Entry is one credit to a receiver's account.
+type Entry struct {
+	RoutingNumber string
+	AccountNumber string
+	AmountPennies int64
+	// IndividualID is the originator's reference for the entry; it comes
+	// back on returns.
+	IndividualID   string
+	IndividualName string
+	// Sequence is the last seven digits of the trace number. It must be
+	// unique among the originator's entries for the day.
+	Sequence int
+}
+
+// TraceNumber is the entry's trace number as written to the file.
+func (o *Originator) TraceNumber(e *Entry) string {
+	return fmt.Sprintf("%s%07d", o.ODFI, e.Sequence%10000000)
+}
+
+// ValidateEntry reports whether e can be written to a file.
+func ValidateEntry(e *Entry) error {
+	switch {
+	case util.ValidateABARoutingNumber(e.RoutingNumber) != nil:
+		return errors.New("invalid routing number")
+	case !dfiAccount.MatchString(e.AccountNumber):
+		return errors.New("account number must be 1 to 17 letters or digits")
+	case e.AmountPennies <= 0 || e.AmountPennies > MaxAmountPennies:
+		return errors.New("amount must be between 0.01 and 99999999.99")
+	}
+	return nil
+}
+
+// text formats s as an alphanumeric field: upper case, printable ASCII,
+// left-justified and space-filled to width.
+func text(s string, width int) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if b.Len() == width {
+			break
+		}
+		if r < ' ' || r > '~' {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + strings.Repeat(" ", width-b.Len())
+}
+
+// number formats n as a numeric field: right-justified and zero-filled.
+func number(n int64, width int) string {
+	s := fmt.Sprintf("%0*d", width, n)
+	return s[len(s)-width:]
+}
+
+// WriteFile writes a file with one PPD credit batch holding entries. The
+// modifier tells apart files created on the same day: A-Z, then 0-9.
+// Entries are written in the order given.
+func WriteFile(w io.Writer, o *Originator, created, effective time.Time, modifier byte, entries []Entry) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return errors.New("a file needs at least one entry")
+	}
+
+	var records []string
+	records = append(records, "1"+"01"+
+		" "+o.ImmediateDestination+
+		fmt.Sprintf("%10s", o.ImmediateOrigin)+
+		created.Format("060102")+created.Format("1504")+
+		string(modifier)+"094"+number(blockingFactor, 2)+"1"+
+		text(o.ImmediateDestinationName, 23)+
+		text(o.ImmediateOriginName, 23)+
+		text("", 8))
+
+	const batchNumber = 1
+	records = append(records, "5"+serviceClassCredits+
+		text(o.CompanyName, 16)+
+		text("", 20)+
+		text(o.CompanyID, 10)+
+		"PPD"+
+		text("PAYOUT", 10)+
+		created.Format("060102")+
+		effective.Format("060102")+
+		"   "+"1"+o.ODFI+number(batchNumber, 7))
+
+	var hash, total int64
+	for i := range entries {
+		e := &entries[i]
+		if err := ValidateEntry(e); err != nil {
+			return fmt.Errorf("entry %s: %w", e.IndividualID, err)
+		}
+		rdfi, _ := strconv.ParseInt(e.RoutingNumber[:8], 10, 64)
+		hash += rdfi
+		total += e.AmountPennies
+		records = append(records, "6"+transactionCodeCheckingCredit+
+			e.RoutingNumber+
+			text(e.AccountNumber, 17)+
+			number(e.AmountPennies, 10)+
+			text(e.IndividualID, 15)+
+			text(e.IndividualName, 22)+
+			"  "+"0"+
+			o.TraceNumber(e))
+	}
+
+	count := int64(len(entries))
+	records = append(records, "8"+serviceClassCredits+
+		number(count, 6)+
+		number(hash, 10)+
+		number(0, 12)+
+		number(total, 12)+
+		text(o.CompanyID, 10)+
+		text("", 19)+text("", 6)+
+		o.ODFI+number(batchNumber, 7))
+
+	// The file control counts itself when working out the blocks
+	blocks := (int64(len(records)) + 1 + blockingFactor - 1) / blockingFactor
+	records = append(records, "9"+
+		number(1, 6)+
+		number(blocks, 6)+
+		number(count, 8)+
+		number(hash, 10)+
+		number(0, 12)+
+		number(total, 12)+
+		text("", 39))
+	for int64(len(records)) < blocks*blockingFactor {
+		records = append(records, strings.Repeat("9", recordLength))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, r := range records {
+		if len(r) != recordLength {
+			return fmt.Errorf("internal error: record %q is %d characters", r[:1], len(r))
+		}
+		bw.WriteString(r)
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// FileIDModifier returns the modifier for the nth file of a day, counting
+// from zero.
+func FileIDModifier(n int) (byte, error) {
+	const modifiers = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	if n < 0 || n >= len(modifiers) {
+		return 0, errors.New("no more than 36 files can be created a day")
+	}
+	return modifiers[n], nil
+}
+
+// NextBankingDay is the first weekday after t, the earliest effective
+// entry date for a file created at t.
+func NextBankingDay(t time.Time) time.Time {
+	d := t.AddDate(0, 0, 1)
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
@@ -0,0 +1,121 @@
+package nacha
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOriginator() *Originator {
+	return &Originator{
+		ImmediateDestination:     "091000019",
+		ImmediateDestinationName: "Wells Fargo",
+		ImmediateOrigin:          "1234567890",
+		ImmediateOriginName:      "FastFunds",
+		CompanyName:              "FastFunds Ltd",
+		CompanyID:                "1234567890",
+		ODFI:                     "09100001",
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	created := time.Date(2024, 3, 1, 17, 5, 0, 0, time.UTC)
+	entries := []Entry{
+		{RoutingNumber: "021000021", AccountNumber: "12345678", AmountPennies: 1250, IndividualID: "PAYOUT-1", IndividualName: "Jane Doe", Sequence: 1},
+		{RoutingNumber: "011000015", AccountNumber: "987654321", AmountPennies: 100000, IndividualID: "PAYOUT-2", IndividualName: "Zoë Smith-Ålvarez the Third", Sequence: 2},
+	}
+	var buf bytes.Buffer
+	err := WriteFile(&buf, testOriginator(), created, NextBankingDay(created), 'A', entries)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 10)
+	for _, l := range lines {
+		assert.Len(t, l, recordLength)
+	}
+	assert.Equal(t, "101 09100001912345678902403011705A094101WELLS FARGO            FASTFUNDS                      ", lines[0])
+	// Effective date skips the weekend
+	assert.Equal(t, "5220FASTFUNDS LTD                       1234567890PPDPAYOUT    240301240304   1091000010000001", lines[1])
+	assert.Equal(t, "62202100002112345678         0000001250PAYOUT-1       JANE DOE                0091000010000001", lines[2])
+	assert.Equal(t, "ZO  SMITH- LVAREZ THE ", lines[3][54:76])
+	// Entry hash: 02100002 + 01100001
+	assert.Equal(t, "822000000200032000030000000000000000001012501234567890                         091000010000001", lines[4])
+	assert.Equal(t, "9000001000001000000020003200003000000000000000000101250                                       ", lines[5])
+	assert.Equal(t, strings.Repeat("9", recordLength), lines[9])
+}
+
+func TestWriteFile_Errors(t *testing.T) {
+	now := time.Now()
+	entry := Entry{RoutingNumber: "021000021", AccountNumber: "1", AmountPennies: 1, IndividualID: "P-1"}
+
+	err := WriteFile(&bytes.Buffer{}, testOriginator(), now, now, 'A', nil)
+	assert.EqualError(t, err, "a file needs at least one entry")
+
+	o := testOriginator()
+	o.ODFI = "123"
+	err = WriteFile(&bytes.Buffer{}, o, now, now, 'A', []Entry{entry})
+	assert.EqualError(t, err, "ODFI must be the first 8 digits of a routing number")
+
+	bad := entry
+	bad.RoutingNumber = "021000022"
+	err = WriteFile(&bytes.Buffer{}, testOriginator(), now, now, 'A', []Entry{bad})
+	assert.EqualError(t, err, "entry P-1: invalid routing number")
+
+	bad = entry
+	bad.AmountPennies = MaxAmountPennies + 1
+	err = WriteFile(&bytes.Buffer{}, testOriginator(), now, now, 'A', []Entry{bad})
+	assert.EqualError(t, err, "entry P-1: amount must be between 0.01 and 99999999.99")
+}
+
+func TestFileIDModifier(t *testing.T) {
+	m, err := FileIDModifier(0)
+	assert.NoError(t, err)
+	assert.Equal(t, byte('A'), m)
+	m, _ = FileIDModifier(26)
+	assert.Equal(t, byte('0'), m)
+	_, err = FileIDModifier(36)
+	assert.Error(t, err)
+}
+
+func TestParseReturns(t *testing.T) {
+	pad := func(s string) string { return s + strings.Repeat(" ", recordLength-len(s)) }
+	file := strings.Join([]string{
+		pad("101 0910000191234567890240305"),
+		pad("5220FASTFUNDS LTD"),
+		pad("62609100001912345678         0000001250PAYOUT-1       JANE DOE                1021000020000001"),
+		pad("799R01091000010000001      02100002"),
+		pad("62609100001912345678         0000000500PAYOUT-3       JOHN DOE                1021000020000002"),
+		pad("798C01091000010000003      02100002"),
+		pad("8220"),
+		pad("9000001"),
+		strings.Repeat("9", recordLength),
+	}, "\r\n") + "\r\n"
+
+	returns, err := ParseReturns(strings.NewReader(file))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []Return{{Code: "R01", OriginalTrace: "091000010000001", AmountPennies: 1250, IndividualID: "PAYOUT-1"}}, returns)
+}
+
+func TestParseReturns_Errors(t *testing.T) {
+	pad := func(s string) string { return s + strings.Repeat(" ", recordLength-len(s)) }
+
+	_, err := ParseReturns(strings.NewReader(""))
+	assert.EqualError(t, err, "file is empty")
+
+	_, err = ParseReturns(strings.NewReader("101 short\n"))
+	assert.EqualError(t, err, "line 1: record is 9 characters, not 94")
+
+	_, err = ParseReturns(strings.NewReader(pad("799R01091000010000001") + "\n"))
+	assert.EqualError(t, err, "line 1: addenda without an entry")
+
+	entry := pad("62609100001912345678         0000001250PAYOUT-1")
+	_, err = ParseReturns(strings.NewReader(entry + "\n"))
+	assert.EqualError(t, err, "entry PAYOUT-1 has no return addenda")
+}
@@ -0,0 +1,87 @@
+package nacha
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Return is an entry the receiving bank sent back.
+type Return struct {
+	// Code is the R-code explaining the return, such as R01 for
+	// insufficient funds or R03 for no account.
+	Code string
+	// OriginalTrace is the trace number of the entry being returned.
+	OriginalTrace string
+	AmountPennies int64
+	IndividualID  string
+}
+
+// ParseReturns reads the returned entries from a NACHA return file. Each
+// entry detail record must be followed by a return addenda (type 99);
+// notifications of change (type 98) are skipped.
+func ParseReturns(r io.Reader) ([]Return, error) {
+	var (
+		returns []Return
+		pending *Return
+		lineNo  int
+		seen    bool
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		seen = true
+		if len(line) != recordLength {
+			return nil, fmt.Errorf("line %d: record is %d characters, not %d", lineNo, len(line), recordLength)
+		}
+		switch line[0] {
+		case '6':
+			if pending != nil {
+				return nil, fmt.Errorf("line %d: entry %s has no return addenda", lineNo-1, pending.IndividualID)
+			}
+			amount, err := strconv.ParseInt(line[29:39], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid amount %q", lineNo, line[29:39])
+			}
+			pending = &Return{
+				AmountPennies: amount,
+				IndividualID:  strings.TrimSpace(line[39:54]),
+			}
+		case '7':
+			if pending == nil {
+				return nil, fmt.Errorf("line %d: addenda without an entry", lineNo)
+			}
+			switch line[1:3] {
+			case "99":
+				pending.Code = line[3:6]
+				pending.OriginalTrace = line[6:21]
+				returns = append(returns, *pending)
+			case "98":
+				// Notification of change: nothing was returned
+			default:
+				return nil, fmt.Errorf("line %d: unexpected addenda type %s", lineNo, line[1:3])
+			}
+			pending = nil
+		case '1', '5', '8', '9':
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %q", lineNo, line[0])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !seen {
+		return nil, errors.New("file is empty")
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("entry %s has no return addenda", pending.IndividualID)
+	}
+	return returns, nil
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Period, refilled continuously, with bursts of up
+// to Requests. The zero Limit is unlimited.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// ParseLimit reads limits such as "120/m", "10/30s" or "5000/h". "off" and
+// "0" disable the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	n, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, want <requests>/<period>", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+
+	var period time.Duration
+	switch per {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		if period, err = time.ParseDuration(per); err != nil || period <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: bad period", s)
+		}
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// Result is the outcome of one request against a limit.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed. It is
+	// zero when the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests per key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is the token bucket state shared by the in-memory and Postgres
+// limiters.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func fullBucket(now time.Time, l Limit) bucket {
+	return bucket{tokens: float64(l.Requests), updated: now}
+}
+
+// take refills the bucket up to now and spends a token if one is available.
+func (b *bucket) take(now time.Time, l Limit) Result {
+	rate := float64(l.Requests) / l.Period.Seconds()
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(l.Requests), b.tokens+elapsed*rate)
+		b.updated = now
+	}
+
+	res := Result{Limit: l.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(l.Requests) - b.tokens) / rate)
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// sweepInterval is how often the in-memory limiter drops idle buckets.
+const sweepInterval = time.Minute
+
+// MemoryLimiter keeps token buckets in process. Each replica enforces its
+// own limit.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	nowFn     func() time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	period time.Duration
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*memoryBucket), nowFn: time.Now}
+}
+
+func (m *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.nowFn()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: fullBucket(now, limit)}
+		m.buckets[key] = b
+	}
+	b.period = limit.Period
+	return b.take(now, limit), nil
+}
+
+// sweep drops buckets idle for a full period; they would be full anyway.
+func (m *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"120/m", Limit{120, time.Minute}, false},
+		{"10/30s", Limit{10, 30 * time.Second}, false},
+		{"5000/h", Limit{5000, time.Hour}, false},
+		{"off", Limit{}, false},
+		{"0", Limit{}, false},
+		{"120", Limit{}, true},
+		{"x/m", Limit{}, true},
+		{"-1/m", Limit{}, true},
+		{"10/fortnight", Limit{}, true},
+		{"10/0s", Limit{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseLimit(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	assert.Equal(t, "off", Limit{}.String())
+	assert.Equal(t, "10/1m0s", Limit{10, time.Minute}.String())
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemoryLimiter()
+	m.nowFn = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second} // one token per second
+
+	// A new bucket starts full and allows a burst of Requests
+	for i := 2; i >= 0; i-- {
+		res, err := m.Allow(ctx, "a", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, _ := m.Allow(ctx, "a", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	// Other keys have their own bucket
+	res, _ = m.Allow(ctx, "b", limit)
+	assert.True(t, res.Allowed)
+
+	// Tokens refill continuously
+	now = now.Add(1500 * time.Millisecond)
+	res, _ = m.Allow(ctx, "a", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	res, _ = m.Allow(ctx, "a", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// ...but never beyond the burst size
+	now = now.Add(time.Hour)
+	res, _ = m.Allow(ctx, "a", limit)
+	assert.Equal(t, 2, res.Remaining)
+
+	// Idle buckets are swept
+	now = now.Add(2 * time.Hour)
+	m.Allow(ctx, "c", limit)
+	assert.Len(t, m.buckets, 1)
+
+	res, _ = m.Allow(ctx, "a", Limit{})
+	assert.True(t, res.Allowed)
+}
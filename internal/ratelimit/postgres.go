@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresLimiter keeps token buckets in the rate_limit_buckets table so
+// every replica enforces one shared limit. Timestamps come from the database
+// clock, so replica clock skew doesn't matter.
+type PostgresLimiter struct {
+	db *sql.DB
+}
+
+func NewPostgresLimiter(db *sql.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (p *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	// The upsert creates a full bucket on first use and locks the row, so
+	// concurrent requests for the same key queue up here.
+	var b bucket
+	var now time.Time
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES ($1, $2, NOW())
+		 ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+		 RETURNING tokens, updated_at, NOW()`,
+		key, limit.Requests,
+	).Scan(&b.tokens, &b.updated, &now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := b.take(now, limit)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3 WHERE key = $1`,
+		key, b.tokens, b.updated,
+	); err != nil {
+		return Result{}, err
+	}
+	return res, tx.Commit()
+}
+
+// Prune deletes buckets untouched for longer than idle and returns how many
+// were deleted. Pick idle at least as long as the longest limit period.
+func (p *PostgresLimiter) Prune(ctx context.Context, idle time.Duration) (int64, error) {
+	res, err := p.db.ExecContext(ctx,
+		`DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)`,
+		idle.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
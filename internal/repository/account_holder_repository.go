@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresAccountHolderRepository(db *sql.DB) *PostgresAccountHolderRepository {
+	return &PostgresAccountHolderRepository{db: db}
+}
+
+type PostgresAccountHolderRepository struct {
+	db *sql.DB
+}
+
+func (r *PostgresAccountHolderRepository) AddTx(tx *sql.Tx, h *models.AccountHolder) error {
+	err := tx.QueryRow(
+		`INSERT INTO account_holders (account_id, customer_id, role) VALUES ($1, $2, $3)
+		 RETURNING created_at`,
+		h.AccountID, h.CustomerID, h.Role,
+	).Scan(&h.CreatedAt)
+	if isUniqueViolation(err) {
+		return errors.New("customer already holds this account")
+	}
+	return err
+}
+
+func (r *PostgresAccountHolderRepository) RemoveTx(tx *sql.Tx, accountID, customerID int) error {
+	res, err := tx.Exec(
+		`DELETE FROM account_holders WHERE account_id = $1 AND customer_id = $2`, accountID, customerID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("account holder not found")
+	}
+	return nil
+}
+
+func (r *PostgresAccountHolderRepository) ListByAccount(accountID int) ([]*models.AccountHolder, error) {
+	rows, err := r.db.Query(
+		`SELECT h.account_id, h.customer_id, c.name, h.role, h.created_at
+		 FROM account_holders h
+		 JOIN customers c ON c.id = h.customer_id
+		 WHERE h.account_id = $1
+		 ORDER BY h.created_at, h.customer_id`, accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.AccountHolder
+	for rows.Next() {
+		h := &models.AccountHolder{}
+		if err := rows.Scan(&h.AccountID, &h.CustomerID, &h.Name, &h.Role, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
+
+// ListAccountsByCustomer returns every account the customer holds together
+// with the customer's role on it.
+func (r *PostgresAccountHolderRepository) ListAccountsByCustomer(customerID int) ([]*models.AccountHolding, error) {
+	rows, err := r.db.Query(
+		`SELECT a.account_id, a.account_number, a.holder_name, a.balance, h.role
+		 FROM account_holders h
+		 JOIN accounts a ON a.account_id = h.account_id
+		 WHERE h.customer_id = $1
+		 ORDER BY a.account_id`, customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.AccountHolding
+	for rows.Next() {
+		h := &models.AccountHolding{Account: &models.Account{}}
+		if err := rows.Scan(&h.Account.AccountID, &h.Account.AccountNumber, &h.Account.HolderName, &h.Account.CurrentBalance, &h.Role); err != nil {
+			return nil, err
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
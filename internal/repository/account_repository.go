@@ -14,41 +14,66 @@ type PostgresAccountRepository struct {
 	db *sql.DB
 }
 
-func (r *PostgresAccountRepository) Create(account *models.Account) error {
-	return r.db.QueryRow(
-		`INSERT INTO accounts (account_id, balance) VALUES ($1, $2) RETURNING account_id`,
-		account.AccountID, account.CurrentBalance,
-	).Scan(&account.AccountID)
-}
+// ErrDuplicateAccountNumber is returned by CreateTx when the generated account
+// number is already taken, so the caller can retry with a fresh one.
+var ErrDuplicateAccountNumber = errors.New("account number already exists")
 
-// lock to void lost update issue
-func (r *PostgresAccountRepository) SelectTx(tx *sql.Tx, id int) (*models.Account, error) {
+var ErrAccountNotFound = errors.New("account not found")
+
+// accountSelect selects accounts with the terms of their product version.
+const accountSelect = `SELECT a.account_id, a.account_number, a.holder_name, a.balance, a.ledger_code,
+	a.product_code, a.product_version, a.currency, ` + productTermsColumns + `
+	FROM accounts a
+	JOIN product_versions v ON v.product_code = a.product_code AND v.version = a.product_version`
+
+func scanAccount(row rowScanner) (*models.Account, error) {
 	acc := &models.Account{}
-	row := tx.QueryRow(
-		`SELECT account_id, balance FROM accounts WHERE account_id = $1 FOR UPDATE`, id,
-	)
-	if err := row.Scan(&acc.AccountID, &acc.CurrentBalance); err != nil {
+	dest := append([]any{&acc.AccountID, &acc.AccountNumber, &acc.HolderName, &acc.CurrentBalance, &acc.LedgerCode,
+		&acc.ProductCode, &acc.ProductVersion, &acc.Currency}, productTermsDest(&acc.Terms)...)
+	if err := row.Scan(dest...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("account not found")
+			return nil, ErrAccountNotFound
 		}
 		return nil, err
 	}
 	return acc, nil
 }
 
-func (r *PostgresAccountRepository) GetByID(id int) (*models.Account, error) {
-	acc := &models.Account{}
-	row := r.db.QueryRow(
-		`SELECT account_id, balance FROM accounts WHERE account_id = $1`, id,
-	)
-	if err := row.Scan(&acc.AccountID, &acc.CurrentBalance); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("account not found")
-		} else {
-			return nil, err
+// CreateTx inserts the account under a savepoint, so a duplicate number
+// leaves tx usable for another attempt.
+func (r *PostgresAccountRepository) CreateTx(tx *sql.Tx, account *models.Account) error {
+	if _, err := tx.Exec(`SAVEPOINT create_account`); err != nil {
+		return err
+	}
+	err := tx.QueryRow(
+		`INSERT INTO accounts (account_number, holder_name, balance, initial_balance, ledger_code, product_code, product_version, currency)
+		 VALUES ($1, $2, $3, $3, $4, $5, $6, $7) RETURNING account_id`,
+		account.AccountNumber, account.HolderName, account.CurrentBalance, account.LedgerCode,
+		account.ProductCode, account.ProductVersion, account.Currency,
+	).Scan(&account.AccountID)
+	if isForeignKeyViolation(err) {
+		return ErrLedgerAccountNotFound
+	}
+	if isUniqueViolation(err) {
+		if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT create_account`); err != nil {
+			return err
 		}
+		return ErrDuplicateAccountNumber
 	}
-	return acc, nil
+	return err
+}
+
+// lock to void lost update issue
+func (r *PostgresAccountRepository) SelectTx(tx *sql.Tx, id int) (*models.Account, error) {
+	return scanAccount(tx.QueryRow(accountSelect+` WHERE a.account_id = $1 FOR UPDATE OF a`, id))
+}
+
+func (r *PostgresAccountRepository) GetByID(id int) (*models.Account, error) {
+	return scanAccount(r.db.QueryRow(accountSelect+` WHERE a.account_id = $1`, id))
+}
+
+func (r *PostgresAccountRepository) GetByNumber(number string) (*models.Account, error) {
+	return scanAccount(r.db.QueryRow(accountSelect+` WHERE a.account_number = $1`, number))
 }
 
 func (r *PostgresAccountRepository) UpdateTx(tx *sql.Tx, account *models.Account) error {
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"fastfunds/internal/models"
+	"time"
+)
+
+func NewPostgresActivityRepository(db *sql.DB) *PostgresActivityRepository {
+	return &PostgresActivityRepository{db: db}
+}
+
+type PostgresActivityRepository struct {
+	db *sql.DB
+}
+
+const activityColumns = `id, account_id, type, transaction_id, status, amount, counterparty_account_number, balance, occurred_at`
+
+func scanActivity(row rowScanner) (*models.AccountActivity, error) {
+	a := &models.AccountActivity{}
+	err := row.Scan(&a.ID, &a.AccountID, &a.Type, &a.TransactionID, &a.Status, &a.AmountPennies,
+		&a.CounterpartyAccountNumber, &a.BalancePennies, &a.OccurredAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AppendTx writes a in tx. A trigger notifies listeners once tx commits.
+func (r *PostgresActivityRepository) AppendTx(tx *sql.Tx, a *models.AccountActivity) error {
+	return tx.QueryRow(
+		`INSERT INTO account_activity (account_id, type, transaction_id, status, amount, counterparty_account_number, balance)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, occurred_at`,
+		a.AccountID, a.Type, a.TransactionID, a.Status, a.AmountPennies, a.CounterpartyAccountNumber, a.BalancePennies,
+	).Scan(&a.ID, &a.OccurredAt)
+}
+
+// ListAfter returns up to limit of the account's entries with an ID above afterID, oldest first.
+func (r *PostgresActivityRepository) ListAfter(accountID int, afterID int64, limit int) ([]*models.AccountActivity, error) {
+	rows, err := r.db.Query(
+		`SELECT `+activityColumns+` FROM account_activity
+		 WHERE account_id = $1 AND id > $2
+		 ORDER BY id
+		 LIMIT $3`,
+		accountID, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.AccountActivity
+	for rows.Next() {
+		a, err := scanActivity(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// LatestID returns the ID of the account's newest entry, or 0 if it has none.
+func (r *PostgresActivityRepository) LatestID(accountID int) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(
+		`SELECT COALESCE(MAX(id), 0) FROM account_activity WHERE account_id = $1`, accountID,
+	).Scan(&id)
+	return id, err
+}
+
+// Prune deletes entries older than olderThan and returns how many were deleted.
+func (r *PostgresActivityRepository) Prune(olderThan time.Duration) (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM account_activity WHERE occurred_at < NOW() - make_interval(secs => $1)`,
+		olderThan.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+const apiKeyColumns = `id, name, role, customer_id, prefix, hash, created_at, rotated_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	k := &models.APIKey{}
+	if err := row.Scan(&k.ID, &k.Name, &k.Role, &k.CustomerID, &k.Prefix, &k.Hash, &k.CreatedAt, &k.RotatedAt, &k.RevokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("api key not found")
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+func (r *PostgresAPIKeyRepository) CreateTx(tx *sql.Tx, k *models.APIKey) error {
+	err := tx.QueryRow(
+		`INSERT INTO api_keys (name, role, customer_id, prefix, hash) VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at`,
+		k.Name, k.Role, k.CustomerID, k.Prefix, k.Hash,
+	).Scan(&k.ID, &k.CreatedAt)
+	if isUniqueViolation(err) {
+		return errors.New("api key name already in use")
+	}
+	return err
+}
+
+func (r *PostgresAPIKeyRepository) GetByPrefix(prefix string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix,
+	))
+}
+
+func (r *PostgresAPIKeyRepository) List() ([]*models.APIKey, error) {
+	rows, err := r.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+// SelectTx locks the key until tx ends.
+func (r *PostgresAPIKeyRepository) SelectTx(tx *sql.Tx, id int) (*models.APIKey, error) {
+	return scanAPIKey(tx.QueryRow(
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 FOR UPDATE`, id,
+	))
+}
+
+// RotateTx replaces the key material of an active key; the old key stops
+// working once tx commits.
+func (r *PostgresAPIKeyRepository) RotateTx(tx *sql.Tx, id int, prefix, hash string) (*models.APIKey, error) {
+	return scanAPIKey(tx.QueryRow(
+		`UPDATE api_keys SET prefix = $2, hash = $3, rotated_at = NOW()
+		 WHERE id = $1 AND revoked_at IS NULL
+		 RETURNING `+apiKeyColumns,
+		id, prefix, hash,
+	))
+}
+
+func (r *PostgresAPIKeyRepository) RevokeTx(tx *sql.Tx, id int) (*models.APIKey, error) {
+	return scanAPIKey(tx.QueryRow(
+		`UPDATE api_keys SET revoked_at = NOW()
+		 WHERE id = $1 AND revoked_at IS NULL
+		 RETURNING `+apiKeyColumns,
+		id,
+	))
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresAuditLogRepository(db *sql.DB) *PostgresAuditLogRepository {
+	return &PostgresAuditLogRepository{db: db}
+}
+
+type PostgresAuditLogRepository struct {
+	db *sql.DB
+}
+
+// auditLogLock is the advisory lock key ("auditlog" in ASCII) that
+// serializes appends, so each entry links to the one committed before it.
+const auditLogLock int64 = 0x61756469746c6f67
+
+const auditLogColumns = `id, occurred_at, actor, actor_role, auth_method, request_id, ip, action, entity_type, entity_id, before, after, prev_hash, hash`
+
+func scanAuditEntry(row rowScanner) (*models.AuditEntry, error) {
+	e := &models.AuditEntry{}
+	var before, after []byte
+	err := row.Scan(&e.ID, &e.OccurredAt, &e.Actor, &e.ActorRole, &e.AuthMethod, &e.RequestID, &e.IP,
+		&e.Action, &e.EntityType, &e.EntityID, &before, &after, &e.PrevHash, &e.Hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("audit log entry not found")
+		}
+		return nil, err
+	}
+	e.Before, e.After = before, after
+	return e, nil
+}
+
+// AppendTx chains e onto the last entry and inserts it in tx. The advisory
+// lock is held until tx ends, so call it as the last statement before
+// committing.
+func (r *PostgresAuditLogRepository) AppendTx(tx *sql.Tx, e *models.AuditEntry) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, auditLogLock); err != nil {
+		return err
+	}
+
+	prev := audit.GenesisHash
+	err := tx.QueryRow(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := audit.Link(e, prev); err != nil {
+		return err
+	}
+
+	return tx.QueryRow(
+		`INSERT INTO audit_log (occurred_at, actor, actor_role, auth_method, request_id, ip, action, entity_type, entity_id, before, after, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING id`,
+		e.OccurredAt, e.Actor, e.ActorRole, e.AuthMethod, e.RequestID, e.IP, e.Action, e.EntityType, e.EntityID,
+		jsonParam(e.Before), jsonParam(e.After), e.PrevHash, e.Hash,
+	).Scan(&e.ID)
+}
+
+// ListAfter returns up to limit entries with IDs above afterID, oldest first.
+func (r *PostgresAuditLogRepository) ListAfter(afterID int64, limit int) ([]*models.AuditEntry, error) {
+	rows, err := r.db.Query(
+		`SELECT `+auditLogColumns+` FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// jsonParam passes a JSON document as text, or NULL when it is empty.
+func jsonParam(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"time"
+)
+
+func NewPostgresBalanceRepository(db *sql.DB) *PostgresBalanceRepository {
+	return &PostgresBalanceRepository{db: db}
+}
+
+type PostgresBalanceRepository struct {
+	db *sql.DB
+}
+
+// ErrAccountNotOpen is returned by BalanceAt for a time before the account
+// was opened.
+var ErrAccountNotOpen = errors.New("account was not open yet")
+
+// movementOf is the net effect on account a of the completed transfers in
+// the given range of completed_at.
+const movementOf = `COALESCE((
+	SELECT SUM(CASE WHEN t.destination_account_id = a.account_id THEN t.amount ELSE -t.amount END)
+	FROM transactions t
+	WHERE (t.source_account_id = a.account_id OR t.destination_account_id = a.account_id)
+	  AND t.status = 'completed' AND `
+
+// balanceAtSelect selects each account's balance just before $1: forward
+// from its latest snapshot at or before $1, or, without one, backward from
+// its current balance.
+const balanceAtSelect = `SELECT a.account_id, a.account_number, a.created_at <= $1,
+	CASE WHEN s.taken_at IS NULL
+		THEN a.balance - ` + movementOf + `t.completed_at >= $1), 0)
+		ELSE s.balance + ` + movementOf + `t.completed_at >= s.taken_at AND t.completed_at < $1), 0)
+	END
+	FROM accounts a
+	LEFT JOIN LATERAL (
+		SELECT taken_at, balance FROM balance_snapshots
+		WHERE account_id = a.account_id AND taken_at <= $1
+		ORDER BY taken_at DESC LIMIT 1
+	) s ON true`
+
+// BalanceAt returns the account's balance just before at.
+func (r *PostgresBalanceRepository) BalanceAt(accountID int, at time.Time) (*models.AccountBalance, error) {
+	b := &models.AccountBalance{}
+	var open bool
+	err := r.db.QueryRow(balanceAtSelect+` WHERE a.account_id = $2`, at, accountID).
+		Scan(&b.AccountID, &b.AccountNumber, &open, &b.BalancePennies)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("account not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !open {
+		return nil, ErrAccountNotOpen
+	}
+	return b, nil
+}
+
+// ListBalancesAt returns the balance just before at of every account open
+// then, in account order.
+func (r *PostgresBalanceRepository) ListBalancesAt(at time.Time) ([]*models.AccountBalance, error) {
+	rows, err := r.db.Query(balanceAtSelect+` WHERE a.created_at <= $1 ORDER BY a.account_id`, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.AccountBalance
+	for rows.Next() {
+		b := &models.AccountBalance{}
+		var open bool
+		if err := rows.Scan(&b.AccountID, &b.AccountNumber, &open, &b.BalancePennies); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
+
+// CreateSnapshots records the balance just before at of every account open
+// then, computed back from the current balances, and returns how many were
+// recorded. Accounts that already have a snapshot at at are left alone, so a
+// snapshot can be retried.
+func (r *PostgresBalanceRepository) CreateSnapshots(at time.Time) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO balance_snapshots (account_id, taken_at, balance)
+		 SELECT a.account_id, $1, a.balance - `+movementOf+`t.completed_at >= $1), 0)
+		 FROM accounts a WHERE a.created_at <= $1
+		 ON CONFLICT (account_id, taken_at) DO NOTHING`,
+		at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func NewPostgresBusinessDayRepository(db *sql.DB) *PostgresBusinessDayRepository {
+	return &PostgresBusinessDayRepository{db: db}
+}
+
+type PostgresBusinessDayRepository struct {
+	db *sql.DB
+}
+
+var (
+	// ErrBusinessDayClosed is returned when a transfer would complete, or a
+	// completed transfer would change, inside a closed business day.
+	ErrBusinessDayClosed = errors.New("business day is closed")
+	// ErrBusinessDayAlreadyClosed is returned by CloseTx for a day that is
+	// closed already.
+	ErrBusinessDayAlreadyClosed = errors.New("business day is already closed")
+	// ErrBusinessDayNotClosed is returned by Get for a day that isn't closed.
+	ErrBusinessDayNotClosed = errors.New("business day is not closed")
+)
+
+// isBusinessDayClosed reports whether err comes from the trigger guarding
+// closed business days.
+func isBusinessDayClosed(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "FF001"
+}
+
+const businessDayColumns = `day::text, starts_at, ends_at, transfer_count, total_debits, total_credits, closed_by, closed_at`
+
+func scanBusinessDay(row rowScanner) (*models.BusinessDay, error) {
+	d := &models.BusinessDay{}
+	err := row.Scan(&d.Day, &d.StartsAt, &d.EndsAt, &d.TransferCount, &d.TotalDebits, &d.TotalCredits, &d.ClosedBy, &d.ClosedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBusinessDayNotClosed
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *PostgresBusinessDayRepository) Get(day string) (*models.BusinessDay, error) {
+	return scanBusinessDay(r.db.QueryRow(`SELECT `+businessDayColumns+` FROM business_days WHERE day = $1`, day))
+}
+
+// LastClosed returns the latest closed day, or nil before the first close.
+func (r *PostgresBusinessDayRepository) LastClosed() (*models.BusinessDay, error) {
+	d, err := scanBusinessDay(r.db.QueryRow(`SELECT ` + businessDayColumns + ` FROM business_days ORDER BY day DESC LIMIT 1`))
+	if errors.Is(err, ErrBusinessDayNotClosed) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// CloseTx closes the business day from start to end under the name closedBy:
+// it records every account open by end with its balances and movements over
+// the day, snapshots the closing balances, and totals the day. Writes to
+// transactions are blocked until tx ends, so transfers in flight either
+// finish first or are refused by the closed-day trigger.
+func (r *PostgresBusinessDayRepository) CloseTx(tx *sql.Tx, day string, start, end time.Time, closedBy string) (*models.BusinessDay, error) {
+	if _, err := tx.Exec(`LOCK TABLE transactions IN SHARE MODE`); err != nil {
+		return nil, err
+	}
+	_, err := tx.Exec(
+		`INSERT INTO business_days (day, starts_at, ends_at, closed_by) VALUES ($1, $2, $3, $4)`,
+		day, start, end, closedBy,
+	)
+	if isUniqueViolation(err) {
+		return nil, ErrBusinessDayAlreadyClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO daily_balances (day, account_id, opening_balance, debits, debit_count, credits, credit_count, closing_balance)
+		 SELECT $1, a.account_id, c.closing + d.debits - d.credits, d.debits, d.debit_count, d.credits, d.credit_count, c.closing
+		 FROM accounts a
+		 CROSS JOIN LATERAL (SELECT a.balance - `+movementOf+`t.completed_at >= $3), 0) AS closing) c
+		 CROSS JOIN LATERAL (
+		     SELECT COALESCE(SUM(t.amount) FILTER (WHERE t.source_account_id = a.account_id), 0) AS debits,
+		            COUNT(*) FILTER (WHERE t.source_account_id = a.account_id) AS debit_count,
+		            COALESCE(SUM(t.amount) FILTER (WHERE t.destination_account_id = a.account_id), 0) AS credits,
+		            COUNT(*) FILTER (WHERE t.destination_account_id = a.account_id) AS credit_count
+		     FROM transactions t
+		     WHERE (t.source_account_id = a.account_id OR t.destination_account_id = a.account_id)
+		       AND t.status = 'completed' AND t.completed_at >= $2 AND t.completed_at < $3
+		 ) d
+		 WHERE a.created_at < $3`,
+		day, start, end,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO balance_snapshots (account_id, taken_at, balance)
+		 SELECT account_id, $2, closing_balance FROM daily_balances WHERE day = $1
+		 ON CONFLICT (account_id, taken_at) DO NOTHING`,
+		day, end,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanBusinessDay(tx.QueryRow(
+		`UPDATE business_days SET
+		   transfer_count = (SELECT COUNT(*) FROM transactions
+		                     WHERE status = 'completed' AND completed_at >= $2 AND completed_at < $3),
+		   total_debits = (SELECT COALESCE(SUM(debits), 0) FROM daily_balances WHERE day = $1),
+		   total_credits = (SELECT COALESCE(SUM(credits), 0) FROM daily_balances WHERE day = $1)
+		 WHERE day = $1
+		 RETURNING `+businessDayColumns,
+		day, start, end,
+	))
+}
+
+// ListDailyBalances returns the day's balances in account order.
+func (r *PostgresBusinessDayRepository) ListDailyBalances(day string) ([]*models.DailyBalance, error) {
+	rows, err := r.db.Query(
+		`SELECT b.day::text, b.account_id, a.account_number, b.opening_balance, b.debits, b.debit_count,
+		   b.credits, b.credit_count, b.closing_balance
+		 FROM daily_balances b JOIN accounts a ON a.account_id = b.account_id
+		 WHERE b.day = $1 ORDER BY b.account_id`,
+		day,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.DailyBalance
+	for rows.Next() {
+		b := &models.DailyBalance{}
+		if err := rows.Scan(&b.Day, &b.AccountID, &b.AccountNumber, &b.OpeningBalance, &b.Debits, &b.DebitCount,
+			&b.Credits, &b.CreditCount, &b.ClosingBalance); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
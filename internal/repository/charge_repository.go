@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"time"
+)
+
+func NewPostgresChargeRepository(db *sql.DB) *PostgresChargeRepository {
+	return &PostgresChargeRepository{db: db}
+}
+
+type PostgresChargeRepository struct {
+	db *sql.DB
+}
+
+// ErrAlreadyCharged is returned by RecordTx when the account has already
+// had that charge for the month.
+var ErrAlreadyCharged = errors.New("account was already charged for the month")
+
+// ListChargeable returns the accounts open before the end of month whose
+// terms have a monthly fee or an interest rate, in account order, with their
+// balance at the end of month.
+func (r *PostgresChargeRepository) ListChargeable(month time.Time) ([]*models.ChargeableAccount, error) {
+	rows, err := r.db.Query(
+		`SELECT b.account_id, v.monthly_fee, v.interest_rate_bps, b.balance,
+		   EXISTS (SELECT 1 FROM account_charges c WHERE c.account_id = b.account_id AND c.month = $2 AND c.kind = 'monthly_fee'),
+		   EXISTS (SELECT 1 FROM account_charges c WHERE c.account_id = b.account_id AND c.month = $2 AND c.kind = 'interest')
+		 FROM (`+balanceAtSelect+` WHERE a.created_at < $1) b (account_id, account_number, open, balance)
+		 JOIN accounts a ON a.account_id = b.account_id
+		 JOIN product_versions v ON v.product_code = a.product_code AND v.version = a.product_version
+		 WHERE v.monthly_fee > 0 OR v.interest_rate_bps > 0
+		 ORDER BY b.account_id`,
+		month.AddDate(0, 1, 0), month.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.ChargeableAccount
+	for rows.Next() {
+		c := &models.ChargeableAccount{}
+		if err := rows.Scan(&c.AccountID, &c.MonthlyFee, &c.InterestRateBPS, &c.BalancePennies, &c.FeeCharged, &c.InterestPaid); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// RecordTx records that the account had a charge of kind for month, made by
+// the transfer transactionID, in that transfer's DB transaction.
+func (r *PostgresChargeRepository) RecordTx(tx *sql.Tx, accountID int, kind string, month time.Time, transactionID int) error {
+	_, err := tx.Exec(
+		`INSERT INTO account_charges (account_id, kind, month, transaction_id) VALUES ($1, $2, $3, $4)`,
+		accountID, kind, month.Format(time.DateOnly), transactionID,
+	)
+	if isUniqueViolation(err) {
+		return ErrAlreadyCharged
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresCustomerRepository(db *sql.DB) *PostgresCustomerRepository {
+	return &PostgresCustomerRepository{db: db}
+}
+
+type PostgresCustomerRepository struct {
+	db *sql.DB
+}
+
+func scanCustomer(row rowScanner) (*models.Customer, error) {
+	c := &models.Customer{}
+	if err := row.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *PostgresCustomerRepository) CreateTx(tx *sql.Tx, c *models.Customer) error {
+	err := tx.QueryRow(
+		`INSERT INTO customers (name, email, phone) VALUES ($1, $2, $3)
+		 RETURNING id, created_at, updated_at`,
+		c.Name, c.Email, c.Phone,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return errors.New("email already in use")
+	}
+	return err
+}
+
+func (r *PostgresCustomerRepository) GetByID(id int) (*models.Customer, error) {
+	return scanCustomer(r.db.QueryRow(
+		`SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE id = $1`, id,
+	))
+}
+
+// SelectTx locks the customer until tx ends.
+func (r *PostgresCustomerRepository) SelectTx(tx *sql.Tx, id int) (*models.Customer, error) {
+	return scanCustomer(tx.QueryRow(
+		`SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE id = $1 FOR UPDATE`, id,
+	))
+}
+
+func (r *PostgresCustomerRepository) UpdateTx(tx *sql.Tx, c *models.Customer) error {
+	err := tx.QueryRow(
+		`UPDATE customers SET name = $2, email = $3, phone = $4, updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING created_at, updated_at`,
+		c.ID, c.Name, c.Email, c.Phone,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("customer not found")
+	}
+	if isUniqueViolation(err) {
+		return errors.New("email already in use")
+	}
+	return err
+}
+
+func (r *PostgresCustomerRepository) List(limit, offset int) ([]*models.Customer, error) {
+	rows, err := r.db.Query(
+		`SELECT id, name, email, phone, created_at, updated_at FROM customers
+		 ORDER BY id
+		 LIMIT $1 OFFSET $2`, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Customer
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresCustomerRepository) Exists(id int) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresDisputeRepository(db *sql.DB) *PostgresDisputeRepository {
+	return &PostgresDisputeRepository{db: db}
+}
+
+type PostgresDisputeRepository struct {
+	db *sql.DB
+}
+
+var (
+	ErrDisputeNotFound  = errors.New("dispute not found")
+	ErrDuplicateDispute = errors.New("the transaction is already disputed")
+	// ErrDisputeNotOpen is returned when a dispute can't be put under
+	// review because it already is, or has been resolved.
+	ErrDisputeNotOpen = errors.New("dispute is not open")
+	// ErrDisputeResolved is returned when a dispute can't be resolved or
+	// added to any more, for example because a concurrent call resolved it.
+	ErrDisputeResolved = errors.New("dispute is already resolved")
+)
+
+const disputeColumns = `d.id, d.transaction_id, d.account_id, a.account_number, d.counterparty_account_id,
+	c.account_number, d.amount, d.reason, d.status, d.provisional_transaction_id, p.status,
+	d.settlement_transaction_id, COALESCE(st.status, ''), d.opened_by, d.resolved_by, d.created_at, d.resolved_at`
+
+const disputeFrom = `disputes d
+	JOIN accounts a ON a.account_id = d.account_id
+	JOIN accounts c ON c.account_id = d.counterparty_account_id
+	JOIN transactions p ON p.id = d.provisional_transaction_id
+	LEFT JOIN transactions st ON st.id = d.settlement_transaction_id`
+
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+	d := &models.Dispute{}
+	err := row.Scan(&d.ID, &d.TransactionID, &d.AccountID, &d.AccountNumber, &d.CounterpartyAccountID,
+		&d.CounterpartyAccountNumber, &d.AmountPennies, &d.Reason, &d.Status, &d.ProvisionalTransactionID,
+		&d.ProvisionalStatus, &d.SettlementTransactionID, &d.SettlementStatus, &d.OpenedBy, &d.ResolvedBy,
+		&d.CreatedAt, &d.ResolvedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// CreateTx inserts an open dispute in the DB transaction of its provisional
+// credit, and sets its ID.
+func (r *PostgresDisputeRepository) CreateTx(tx *sql.Tx, d *models.Dispute) error {
+	err := tx.QueryRow(
+		`INSERT INTO disputes (transaction_id, account_id, counterparty_account_id, amount, reason,
+		  provisional_transaction_id, opened_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, status, created_at`,
+		d.TransactionID, d.AccountID, d.CounterpartyAccountID, d.AmountPennies, d.Reason,
+		d.ProvisionalTransactionID, d.OpenedBy,
+	).Scan(&d.ID, &d.Status, &d.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicateDispute
+	}
+	return err
+}
+
+func (r *PostgresDisputeRepository) Get(id int) (*models.Dispute, error) {
+	return scanDispute(r.db.QueryRow(`SELECT `+disputeColumns+` FROM `+disputeFrom+` WHERE d.id = $1`, id))
+}
+
+// List returns disputes newest first, only those in status unless it is
+// empty.
+func (r *PostgresDisputeRepository) List(status string) ([]*models.Dispute, error) {
+	rows, err := r.db.Query(
+		`SELECT `+disputeColumns+` FROM `+disputeFrom+`
+		 WHERE $1 = '' OR d.status = $1
+		 ORDER BY d.created_at DESC, d.id DESC`,
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Dispute
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// ListNotes returns a dispute's notes, oldest first.
+func (r *PostgresDisputeRepository) ListNotes(disputeID int) ([]*models.DisputeNote, error) {
+	rows, err := r.db.Query(
+		`SELECT id, dispute_id, author, note, created_at FROM dispute_notes
+		 WHERE dispute_id = $1 ORDER BY created_at, id`,
+		disputeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.DisputeNote
+	for rows.Next() {
+		n := &models.DisputeNote{}
+		if err := rows.Scan(&n.ID, &n.DisputeID, &n.Author, &n.Note, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+// AddNoteTx inserts a note on an unresolved dispute and sets its ID.
+func (r *PostgresDisputeRepository) AddNoteTx(tx *sql.Tx, n *models.DisputeNote) error {
+	err := tx.QueryRow(
+		`INSERT INTO dispute_notes (dispute_id, author, note)
+		 SELECT id, $2, $3 FROM disputes WHERE id = $1 AND status IN ('open', 'under_review')
+		 RETURNING id, created_at`,
+		n.DisputeID, n.Author, n.Note,
+	).Scan(&n.ID, &n.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDisputeResolved
+	}
+	return err
+}
+
+// ReviewTx moves an open dispute under review.
+func (r *PostgresDisputeRepository) ReviewTx(tx *sql.Tx, id int) error {
+	res, err := tx.Exec(`UPDATE disputes SET status = 'under_review' WHERE id = $1 AND status = 'open'`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrDisputeNotOpen
+	}
+	return nil
+}
+
+// ResolveTx records the transfer that settled a dispute as won or lost, in
+// that transfer's DB transaction, and returns when. The dispute must be
+// unresolved, or its last settlement transfer rejected, expired or failed.
+func (r *PostgresDisputeRepository) ResolveTx(tx *sql.Tx, id int, status, resolvedBy string, transactionID int) (string, error) {
+	var resolvedAt string
+	err := tx.QueryRow(
+		`UPDATE disputes d SET status = $2, resolved_by = $3, settlement_transaction_id = $4, resolved_at = NOW()
+		 WHERE d.id = $1 AND (d.status IN ('open', 'under_review') OR EXISTS (
+		   SELECT 1 FROM transactions t
+		   WHERE t.id = d.settlement_transaction_id AND t.status IN ('rejected', 'expired', 'failed')
+		 ))
+		 RETURNING resolved_at`,
+		id, status, resolvedBy, transactionID,
+	).Scan(&resolvedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrDisputeResolved
+	}
+	return resolvedAt, err
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"time"
+)
+
+func NewPostgresEscrowRepository(db *sql.DB) *PostgresEscrowRepository {
+	return &PostgresEscrowRepository{db: db}
+}
+
+type PostgresEscrowRepository struct {
+	db *sql.DB
+}
+
+var (
+	ErrEscrowNotFound         = errors.New("escrow not found")
+	ErrEscrowAccountNotFound  = errors.New("escrow account not found")
+	ErrDuplicateEscrowAccount = errors.New("the pair already has an escrow account")
+	// ErrEscrowNotHeld is returned when an escrow can't be settled or
+	// disputed any more, for example because a concurrent call settled it.
+	ErrEscrowNotHeld = errors.New("escrow is not held")
+)
+
+const escrowColumns = `e.id, e.buyer_account_id, b.account_number, e.seller_account_id, s.account_number,
+	e.escrow_account_id, x.account_number, e.amount, e.status, e.deadline, e.deadline_action,
+	e.disputed_at, e.dispute_reason, e.funding_transaction_id, f.status, e.settlement_transaction_id,
+	COALESCE(st.status, ''), e.created_by, e.created_at, e.settled_at`
+
+const escrowFrom = `escrows e
+	JOIN accounts b ON b.account_id = e.buyer_account_id
+	JOIN accounts s ON s.account_id = e.seller_account_id
+	JOIN accounts x ON x.account_id = e.escrow_account_id
+	JOIN transactions f ON f.id = e.funding_transaction_id
+	LEFT JOIN transactions st ON st.id = e.settlement_transaction_id`
+
+func scanEscrow(row rowScanner) (*models.Escrow, error) {
+	e := &models.Escrow{}
+	err := row.Scan(&e.ID, &e.BuyerAccountID, &e.BuyerAccountNumber, &e.SellerAccountID, &e.SellerAccountNumber,
+		&e.EscrowAccountID, &e.EscrowAccountNumber, &e.AmountPennies, &e.Status, &e.Deadline, &e.DeadlineAction,
+		&e.DisputedAt, &e.DisputeReason, &e.FundingTransactionID, &e.FundingStatus, &e.SettlementTransactionID,
+		&e.SettlementStatus, &e.CreatedBy, &e.CreatedAt, &e.SettledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEscrowNotFound
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetAccountID returns the ID of the pair's escrow account.
+func (r *PostgresEscrowRepository) GetAccountID(buyerAccountID, sellerAccountID int) (int, error) {
+	var id int
+	err := r.db.QueryRow(
+		`SELECT account_id FROM escrow_accounts WHERE buyer_account_id = $1 AND seller_account_id = $2`,
+		buyerAccountID, sellerAccountID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrEscrowAccountNotFound
+	}
+	return id, err
+}
+
+// CreateAccountTx records accountID as the pair's escrow account.
+func (r *PostgresEscrowRepository) CreateAccountTx(tx *sql.Tx, buyerAccountID, sellerAccountID, accountID int) error {
+	_, err := tx.Exec(
+		`INSERT INTO escrow_accounts (buyer_account_id, seller_account_id, account_id) VALUES ($1, $2, $3)`,
+		buyerAccountID, sellerAccountID, accountID,
+	)
+	if isUniqueViolation(err) {
+		return ErrDuplicateEscrowAccount
+	}
+	return err
+}
+
+// CreateTx inserts a held escrow in the DB transaction of the transfer that
+// funds it, and sets its ID.
+func (r *PostgresEscrowRepository) CreateTx(tx *sql.Tx, e *models.Escrow) error {
+	return tx.QueryRow(
+		`INSERT INTO escrows (buyer_account_id, seller_account_id, escrow_account_id, amount, deadline,
+		  deadline_action, funding_transaction_id, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, status, created_at`,
+		e.BuyerAccountID, e.SellerAccountID, e.EscrowAccountID, e.AmountPennies, e.Deadline,
+		e.DeadlineAction, e.FundingTransactionID, e.CreatedBy,
+	).Scan(&e.ID, &e.Status, &e.CreatedAt)
+}
+
+func (r *PostgresEscrowRepository) Get(id int) (*models.Escrow, error) {
+	return scanEscrow(r.db.QueryRow(`SELECT `+escrowColumns+` FROM `+escrowFrom+` WHERE e.id = $1`, id))
+}
+
+// ListDue returns up to limit funded, undisputed escrows still held at
+// their deadline, earliest deadline first.
+func (r *PostgresEscrowRepository) ListDue(now time.Time, limit int) ([]*models.Escrow, error) {
+	rows, err := r.db.Query(
+		`SELECT `+escrowColumns+` FROM `+escrowFrom+`
+		 WHERE e.status = 'held' AND e.disputed_at IS NULL AND e.deadline <= $1 AND f.status = 'completed'
+		 ORDER BY e.deadline, e.id
+		 LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Escrow
+	for rows.Next() {
+		e, err := scanEscrow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// DisputeTx marks a held, undisputed escrow disputed and returns when.
+func (r *PostgresEscrowRepository) DisputeTx(tx *sql.Tx, id int, reason string) (string, error) {
+	var disputedAt string
+	err := tx.QueryRow(
+		`UPDATE escrows SET disputed_at = NOW(), dispute_reason = $2
+		 WHERE id = $1 AND status = 'held' AND disputed_at IS NULL
+		 RETURNING disputed_at`,
+		id, reason,
+	).Scan(&disputedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrEscrowNotHeld
+	}
+	return disputedAt, err
+}
+
+// SettleTx records the transfer that released or refunded an escrow, in
+// that transfer's DB transaction. The escrow must be held, or its last
+// settlement transfer rejected, expired or failed.
+func (r *PostgresEscrowRepository) SettleTx(tx *sql.Tx, id int, status string, transactionID int) (string, error) {
+	var settledAt string
+	err := tx.QueryRow(
+		`UPDATE escrows e SET status = $2, settlement_transaction_id = $3, settled_at = NOW()
+		 WHERE e.id = $1 AND (e.status = 'held' OR EXISTS (
+		   SELECT 1 FROM transactions t
+		   WHERE t.id = e.settlement_transaction_id AND t.status IN ('rejected', 'expired', 'failed')
+		 ))
+		 RETURNING settled_at`,
+		id, status, transactionID,
+	).Scan(&settledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrEscrowNotHeld
+	}
+	return settledAt, err
+}
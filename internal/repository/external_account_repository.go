@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresExternalAccountRepository(db *sql.DB) *PostgresExternalAccountRepository {
+	return &PostgresExternalAccountRepository{db: db}
+}
+
+type PostgresExternalAccountRepository struct {
+	db *sql.DB
+}
+
+const externalAccountColumns = `id, customer_id, type, label, holder_name, iban, bic, sort_code, account_number, routing_number, created_at`
+
+func scanExternalAccount(row rowScanner) (*models.ExternalAccount, error) {
+	e := &models.ExternalAccount{}
+	err := row.Scan(&e.ID, &e.CustomerID, &e.Type, &e.Label, &e.HolderName, &e.IBAN, &e.BIC,
+		&e.SortCode, &e.AccountNumber, &e.RoutingNumber, &e.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("external account not found")
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *PostgresExternalAccountRepository) CreateTx(tx *sql.Tx, e *models.ExternalAccount) error {
+	err := tx.QueryRow(
+		`INSERT INTO external_accounts (customer_id, type, label, holder_name, iban, bic, sort_code, account_number, routing_number)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id, created_at`,
+		e.CustomerID, e.Type, e.Label, e.HolderName, e.IBAN, e.BIC, e.SortCode, e.AccountNumber, e.RoutingNumber,
+	).Scan(&e.ID, &e.CreatedAt)
+	if isUniqueViolation(err) {
+		return errors.New("external account already registered")
+	}
+	return err
+}
+
+func (r *PostgresExternalAccountRepository) GetByID(customerID, id int) (*models.ExternalAccount, error) {
+	return scanExternalAccount(r.db.QueryRow(
+		`SELECT `+externalAccountColumns+` FROM external_accounts WHERE customer_id = $1 AND id = $2`, customerID, id,
+	))
+}
+
+func (r *PostgresExternalAccountRepository) ListByCustomer(customerID int) ([]*models.ExternalAccount, error) {
+	rows, err := r.db.Query(
+		`SELECT `+externalAccountColumns+` FROM external_accounts WHERE customer_id = $1 ORDER BY id`, customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.ExternalAccount
+	for rows.Next() {
+		e, err := scanExternalAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// DeleteTx removes the external account and returns it as it was.
+func (r *PostgresExternalAccountRepository) DeleteTx(tx *sql.Tx, customerID, id int) (*models.ExternalAccount, error) {
+	return scanExternalAccount(tx.QueryRow(
+		`DELETE FROM external_accounts WHERE customer_id = $1 AND id = $2 RETURNING `+externalAccountColumns, customerID, id,
+	))
+}
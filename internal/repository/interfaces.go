@@ -3,11 +3,13 @@ package repository
 import (
 	"database/sql"
 	"fastfunds/internal/models"
+	"time"
 )
 
 type AccountRepository interface {
-	Create(account *models.Account) error
+	CreateTx(tx *sql.Tx, account *models.Account) error
 	GetByID(id int) (*models.Account, error)
+	GetByNumber(number string) (*models.Account, error)
 	SelectTx(tx *sql.Tx, id int) (*models.Account, error)
 	UpdateTx(tx *sql.Tx, account *models.Account) error
 	Exists(id int) (bool, error)
@@ -17,4 +19,177 @@ type TransactionRepository interface {
 	CreateTx(tx *sql.Tx, transaction *models.Transaction) error
 	GetByID(id int) (*models.Transaction, error)
 	GetByAccountID(accountID int) ([]*models.Transaction, error)
+	ListByAccount(accountID, beforeID, limit int) ([]*models.Transaction, error)
+	ListByStatus(status string) ([]*models.Transaction, error)
+	SelectTx(tx *sql.Tx, id int) (*models.Transaction, error)
+	UpdateStatusTx(tx *sql.Tx, id int, status string) error
+	ReviewTx(tx *sql.Tx, id int, status, reviewer, note string) error
+	ExpirePendingTx(tx *sql.Tx, now time.Time) ([]*models.Transaction, error)
+	BalanceAtTx(tx *sql.Tx, accountID int, at time.Time) (int64, error)
+	EachCompletedTx(tx *sql.Tx, accountID int, from, to time.Time, fn func(*models.Transaction) error) error
+	SumOutgoingSince(accountID int, since time.Time) (int64, error)
+}
+
+type ScreeningCaseRepository interface {
+	Create(c *models.ScreeningCase) error
+	CreateTx(tx *sql.Tx, c *models.ScreeningCase) error
+	GetByID(id int) (*models.ScreeningCase, error)
+	SelectTx(tx *sql.Tx, id int) (*models.ScreeningCase, error)
+	List(status string) ([]*models.ScreeningCase, error)
+	IsClearedTx(tx *sql.Tx, accountID int, entryUID string) (bool, error)
+	ResolveTx(tx *sql.Tx, id int, status, note string) error
+	ClearOpenTx(tx *sql.Tx, accountID int, entryUID, note string) ([]*models.ScreeningCase, error)
+	StatusCountsTx(tx *sql.Tx, transactionID int) (map[string]int, error)
+}
+
+type CustomerRepository interface {
+	CreateTx(tx *sql.Tx, c *models.Customer) error
+	GetByID(id int) (*models.Customer, error)
+	SelectTx(tx *sql.Tx, id int) (*models.Customer, error)
+	UpdateTx(tx *sql.Tx, c *models.Customer) error
+	List(limit, offset int) ([]*models.Customer, error)
+	Exists(id int) (bool, error)
+}
+
+type AccountHolderRepository interface {
+	AddTx(tx *sql.Tx, h *models.AccountHolder) error
+	RemoveTx(tx *sql.Tx, accountID, customerID int) error
+	ListByAccount(accountID int) ([]*models.AccountHolder, error)
+	ListAccountsByCustomer(customerID int) ([]*models.AccountHolding, error)
+}
+
+type ExternalAccountRepository interface {
+	CreateTx(tx *sql.Tx, e *models.ExternalAccount) error
+	GetByID(customerID, id int) (*models.ExternalAccount, error)
+	ListByCustomer(customerID int) ([]*models.ExternalAccount, error)
+	DeleteTx(tx *sql.Tx, customerID, id int) (*models.ExternalAccount, error)
+}
+
+type APIKeyRepository interface {
+	CreateTx(tx *sql.Tx, k *models.APIKey) error
+	GetByPrefix(prefix string) (*models.APIKey, error)
+	List() ([]*models.APIKey, error)
+	SelectTx(tx *sql.Tx, id int) (*models.APIKey, error)
+	RotateTx(tx *sql.Tx, id int, prefix, hash string) (*models.APIKey, error)
+	RevokeTx(tx *sql.Tx, id int) (*models.APIKey, error)
+}
+
+type AuditLogRepository interface {
+	AppendTx(tx *sql.Tx, e *models.AuditEntry) error
+	ListAfter(afterID int64, limit int) ([]*models.AuditEntry, error)
+}
+
+type OutboxRepository interface {
+	AppendTx(tx *sql.Tx, e *models.Event) error
+	ClaimTx(tx *sql.Tx, limit int, lease time.Duration) ([]*models.Event, error)
+	MarkPublishedTx(tx *sql.Tx, id int64) error
+	MarkFailedTx(tx *sql.Tx, id int64, lastError string, retryAfter time.Duration) error
+	PrunePublished(olderThan time.Duration) (int64, error)
+}
+
+type WebhookRepository interface {
+	CreateSubscriptionTx(tx *sql.Tx, s *models.WebhookSubscription) error
+	GetSubscription(id int) (*models.WebhookSubscription, error)
+	ListSubscriptions() ([]*models.WebhookSubscription, error)
+	DeleteSubscriptionTx(tx *sql.Tx, id int) (*models.WebhookSubscription, error)
+	EnqueueEvent(e *models.Event) (int64, error)
+	ListDeliveries(subscriptionID int, status string, limit int) ([]*models.WebhookDelivery, error)
+	GetDelivery(subscriptionID int, id int64) (*models.WebhookDelivery, error)
+	ClaimDueTx(tx *sql.Tx, limit int, lease time.Duration) ([]*models.DueWebhookDelivery, error)
+	RecordAttemptTx(tx *sql.Tx, id int64, a *models.WebhookDeliveryAttempt, status string, retryAfter time.Duration) error
+	ReplayDeliveryTx(tx *sql.Tx, subscriptionID int, id int64) (*models.WebhookDelivery, error)
+}
+
+type ActivityRepository interface {
+	AppendTx(tx *sql.Tx, a *models.AccountActivity) error
+	ListAfter(accountID int, afterID int64, limit int) ([]*models.AccountActivity, error)
+	LatestID(accountID int) (int64, error)
+	Prune(olderThan time.Duration) (int64, error)
+}
+
+type TransferImportRepository interface {
+	CreateTx(tx *sql.Tx, imp *models.TransferImport, rows []*models.TransferImportRow) error
+	Get(id int) (*models.TransferImport, error)
+	ListRows(importID int) ([]*models.TransferImportRow, error)
+	ConfirmTx(tx *sql.Tx, id int, by, role, authMethod string) (*models.TransferImport, error)
+	ListDue(limit int) ([]*models.DueTransferImportRow, error)
+	CompleteRowTx(tx *sql.Tx, importID, line int, t *models.Transaction) error
+	FailRow(importID, line int, reason string) error
+	CompleteImports() (int64, error)
+}
+
+type PaymentInitiationRepository interface {
+	Create(p *models.PaymentInitiation) error
+	Complete(id int, groupStatus string) error
+}
+
+type PayoutRepository interface {
+	CreateTx(tx *sql.Tx, p *models.Payout) error
+	Get(id int) (*models.Payout, error)
+	GetByTrace(traceNumber string) (*models.Payout, error)
+	ListDueTx(tx *sql.Tx) ([]*models.Payout, error)
+	CountFilesTx(tx *sql.Tx, prefix string) (int, error)
+	CreateFileTx(tx *sql.Tx, f *models.ACHFile) error
+	MarkSentTx(tx *sql.Tx, id, fileID int, traceNumber string) error
+	ListUnwrittenFiles() ([]*models.ACHFile, error)
+	MarkFileWritten(id int) error
+	ReturnTx(tx *sql.Tx, id int, returnCode string, transactionID int) error
+}
+
+type ReconciliationRepository interface {
+	EachBalanceTx(tx *sql.Tx, fn func(*models.AccountBalanceCheck) error) error
+}
+
+type BalanceRepository interface {
+	BalanceAt(accountID int, at time.Time) (*models.AccountBalance, error)
+	ListBalancesAt(at time.Time) ([]*models.AccountBalance, error)
+	CreateSnapshots(at time.Time) (int64, error)
+}
+
+type BusinessDayRepository interface {
+	Get(day string) (*models.BusinessDay, error)
+	LastClosed() (*models.BusinessDay, error)
+	CloseTx(tx *sql.Tx, day string, start, end time.Time, closedBy string) (*models.BusinessDay, error)
+	ListDailyBalances(day string) ([]*models.DailyBalance, error)
+}
+
+type LedgerRepository interface {
+	CreateTx(tx *sql.Tx, l *models.LedgerAccount) error
+	Get(code string) (*models.LedgerAccount, error)
+	List() ([]*models.LedgerAccount, error)
+	Totals(from, to time.Time) ([]*models.LedgerTotals, error)
+}
+
+type ProductRepository interface {
+	CreateTx(tx *sql.Tx, p *models.Product) (*models.Product, error)
+	AddVersionTx(tx *sql.Tx, code string, terms *models.ProductTerms) (*models.Product, *models.Product, error)
+	Get(code string) (*models.Product, error)
+	List() ([]*models.Product, error)
+	ListVersions(code string) ([]*models.ProductVersion, error)
+	MigrateTx(tx *sql.Tx, code string, version int) (int64, error)
+}
+
+type EscrowRepository interface {
+	GetAccountID(buyerAccountID, sellerAccountID int) (int, error)
+	CreateAccountTx(tx *sql.Tx, buyerAccountID, sellerAccountID, accountID int) error
+	CreateTx(tx *sql.Tx, e *models.Escrow) error
+	Get(id int) (*models.Escrow, error)
+	ListDue(now time.Time, limit int) ([]*models.Escrow, error)
+	DisputeTx(tx *sql.Tx, id int, reason string) (string, error)
+	SettleTx(tx *sql.Tx, id int, status string, transactionID int) (string, error)
+}
+
+type DisputeRepository interface {
+	CreateTx(tx *sql.Tx, d *models.Dispute) error
+	Get(id int) (*models.Dispute, error)
+	List(status string) ([]*models.Dispute, error)
+	ListNotes(disputeID int) ([]*models.DisputeNote, error)
+	AddNoteTx(tx *sql.Tx, n *models.DisputeNote) error
+	ReviewTx(tx *sql.Tx, id int) error
+	ResolveTx(tx *sql.Tx, id int, status, resolvedBy string, transactionID int) (string, error)
+}
+
+type ChargeRepository interface {
+	ListChargeable(month time.Time) ([]*models.ChargeableAccount, error)
+	RecordTx(tx *sql.Tx, accountID int, kind string, month time.Time, transactionID int) error
 }
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"time"
+)
+
+func NewPostgresLedgerRepository(db *sql.DB) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{db: db}
+}
+
+type PostgresLedgerRepository struct {
+	db *sql.DB
+}
+
+var (
+	ErrLedgerAccountNotFound  = errors.New("ledger account not found")
+	ErrDuplicateLedgerAccount = errors.New("ledger account code already exists")
+)
+
+const ledgerAccountColumns = `code, name, type, description, created_at`
+
+func scanLedgerAccount(row rowScanner) (*models.LedgerAccount, error) {
+	l := &models.LedgerAccount{}
+	if err := row.Scan(&l.Code, &l.Name, &l.Type, &l.Description, &l.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLedgerAccountNotFound
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+func (r *PostgresLedgerRepository) CreateTx(tx *sql.Tx, l *models.LedgerAccount) error {
+	err := tx.QueryRow(
+		`INSERT INTO ledger_accounts (code, name, type, description) VALUES ($1, $2, $3, $4) RETURNING created_at`,
+		l.Code, l.Name, l.Type, l.Description,
+	).Scan(&l.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicateLedgerAccount
+	}
+	return err
+}
+
+func (r *PostgresLedgerRepository) Get(code string) (*models.LedgerAccount, error) {
+	return scanLedgerAccount(r.db.QueryRow(
+		`SELECT `+ledgerAccountColumns+` FROM ledger_accounts WHERE code = $1`, code,
+	))
+}
+
+// List returns the chart of accounts in code order.
+func (r *PostgresLedgerRepository) List() ([]*models.LedgerAccount, error) {
+	rows, err := r.db.Query(`SELECT ` + ledgerAccountColumns + ` FROM ledger_accounts ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.LedgerAccount
+	for rows.Next() {
+		l, err := scanLedgerAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// Totals returns every ledger account's totals up to to, split at from, in
+// code order. Each completed transfer is a debit to its source's ledger
+// account and a credit to its destination's.
+func (r *PostgresLedgerRepository) Totals(from, to time.Time) ([]*models.LedgerTotals, error) {
+	rows, err := r.db.Query(`
+		SELECT l.code, l.name, l.type,
+			COALESCE(o.initial_before, 0), COALESCE(o.initial_during, 0),
+			COALESCE(m.net_before, 0), COALESCE(m.debits, 0), COALESCE(m.credits, 0)
+		FROM ledger_accounts l
+		LEFT JOIN (
+			SELECT ledger_code,
+				SUM(initial_balance) FILTER (WHERE created_at < $1) AS initial_before,
+				SUM(initial_balance) FILTER (WHERE created_at >= $1 AND created_at < $2) AS initial_during
+			FROM accounts
+			GROUP BY ledger_code
+		) o ON o.ledger_code = l.code
+		LEFT JOIN (
+			SELECT a.ledger_code,
+				SUM(p.amount) FILTER (WHERE t.completed_at < $1) AS net_before,
+				SUM(-p.amount) FILTER (WHERE t.completed_at >= $1 AND p.amount < 0) AS debits,
+				SUM(p.amount) FILTER (WHERE t.completed_at >= $1 AND p.amount > 0) AS credits
+			FROM transactions t
+			CROSS JOIN LATERAL (VALUES
+				(t.source_account_id, -t.amount),
+				(t.destination_account_id, t.amount)
+			) p(account_id, amount)
+			JOIN accounts a ON a.account_id = p.account_id
+			WHERE t.status = 'completed' AND t.completed_at < $2
+			GROUP BY a.ledger_code
+		) m ON m.ledger_code = l.code
+		ORDER BY l.code`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.LedgerTotals
+	for rows.Next() {
+		t := &models.LedgerTotals{}
+		if err := rows.Scan(&t.Code, &t.Name, &t.Type, &t.InitialBefore, &t.InitialDuring,
+			&t.NetBefore, &t.Debits, &t.Credits); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"sort"
+	"time"
+)
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+const outboxColumns = `e.id, e.type, e.aggregate_type, e.aggregate_id, e.payload, e.occurred_at, e.attempts`
+
+func scanEvent(row rowScanner) (*models.Event, error) {
+	e := &models.Event{}
+	var payload []byte
+	err := row.Scan(&e.ID, &e.Type, &e.AggregateType, &e.AggregateID, &payload, &e.OccurredAt, &e.Attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("event not found")
+		}
+		return nil, err
+	}
+	e.Payload = payload
+	return e, nil
+}
+
+// AppendTx writes e to the outbox in tx, so it is published only if tx commits.
+func (r *PostgresOutboxRepository) AppendTx(tx *sql.Tx, e *models.Event) error {
+	return tx.QueryRow(
+		`INSERT INTO outbox_events (type, aggregate_type, aggregate_id, payload)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, occurred_at`,
+		e.Type, e.AggregateType, e.AggregateID, jsonParam(e.Payload),
+	).Scan(&e.ID, &e.OccurredAt)
+}
+
+// ClaimTx claims up to limit unpublished events that are due, oldest first,
+// by making them due again only after lease. Rows locked by another relay
+// are skipped, so relays can run side by side; once tx commits, the lease
+// keeps the events from them while they are published.
+func (r *PostgresOutboxRepository) ClaimTx(tx *sql.Tx, limit int, lease time.Duration) ([]*models.Event, error) {
+	rows, err := tx.Query(
+		`WITH due AS (
+		   SELECT id FROM outbox_events
+		   WHERE published_at IS NULL AND next_attempt_at <= NOW()
+		   ORDER BY id
+		   LIMIT $1
+		   FOR UPDATE SKIP LOCKED
+		 )
+		 UPDATE outbox_events e SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 FROM due
+		 WHERE e.id = due.id
+		 RETURNING `+outboxColumns,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING has no order
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (r *PostgresOutboxRepository) MarkPublishedTx(tx *sql.Tx, id int64) error {
+	_, err := tx.Exec(
+		`UPDATE outbox_events SET published_at = NOW(), attempts = attempts + 1, last_error = '' WHERE id = $1`, id,
+	)
+	return err
+}
+
+// MarkFailedTx records a failed publish and makes the event due again after retryAfter.
+func (r *PostgresOutboxRepository) MarkFailedTx(tx *sql.Tx, id int64, lastError string, retryAfter time.Duration) error {
+	_, err := tx.Exec(
+		`UPDATE outbox_events
+		 SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + make_interval(secs => $3)
+		 WHERE id = $1`,
+		id, lastError, retryAfter.Seconds(),
+	)
+	return err
+}
+
+// PrunePublished deletes events published more than olderThan ago and
+// returns how many were deleted.
+func (r *PostgresOutboxRepository) PrunePublished(olderThan time.Duration) (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM outbox_events WHERE published_at < NOW() - make_interval(secs => $1)`,
+		olderThan.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
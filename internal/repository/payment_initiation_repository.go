@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresPaymentInitiationRepository(db *sql.DB) *PostgresPaymentInitiationRepository {
+	return &PostgresPaymentInitiationRepository{db: db}
+}
+
+type PostgresPaymentInitiationRepository struct {
+	db *sql.DB
+}
+
+// ErrDuplicatePaymentMessage is returned by Create when the initiator has
+// already sent a message with the same ID.
+var ErrDuplicatePaymentMessage = errors.New("payment message ID already used")
+
+// Create claims the message ID for its initiator before any of its
+// transfers are made.
+func (r *PostgresPaymentInitiationRepository) Create(p *models.PaymentInitiation) error {
+	err := r.db.QueryRow(
+		`INSERT INTO payment_initiations (message_id, initiated_by, transactions)
+		 VALUES ($1, $2, $3)
+		 RETURNING id, created_at`,
+		p.MessageID, p.InitiatedBy, p.Transactions,
+	).Scan(&p.ID, &p.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicatePaymentMessage
+	}
+	return err
+}
+
+// Complete records the group status reported once every transaction has
+// been tried.
+func (r *PostgresPaymentInitiationRepository) Complete(id int, groupStatus string) error {
+	_, err := r.db.Exec(
+		`UPDATE payment_initiations SET group_status = $2, completed_at = NOW() WHERE id = $1`,
+		id, groupStatus,
+	)
+	return err
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresPayoutRepository(db *sql.DB) *PostgresPayoutRepository {
+	return &PostgresPayoutRepository{db: db}
+}
+
+type PostgresPayoutRepository struct {
+	db *sql.DB
+}
+
+// ErrPayoutNotSent is returned by ReturnTx when the payout is not waiting
+// for settlement, for example because its return was already processed.
+var ErrPayoutNotSent = errors.New("payout is not sent")
+
+const payoutColumns = `p.id, p.account_id, a.account_number, p.external_account_id, p.routing_number,
+	p.receiver_account_number, p.receiver_name, p.amount, p.status, p.transaction_id, t.status,
+	p.file_id, COALESCE(p.trace_number, ''), p.return_code, p.return_transaction_id, p.created_by,
+	p.created_at, p.sent_at, p.returned_at`
+
+const payoutFrom = `payouts p
+	JOIN accounts a ON a.account_id = p.account_id
+	JOIN transactions t ON t.id = p.transaction_id`
+
+func scanPayout(row rowScanner) (*models.Payout, error) {
+	p := &models.Payout{}
+	err := row.Scan(&p.ID, &p.AccountID, &p.AccountNumber, &p.ExternalAccountID, &p.RoutingNumber,
+		&p.ReceiverAccount, &p.ReceiverName, &p.AmountPennies, &p.Status, &p.TransactionID, &p.TransactionStatus,
+		&p.FileID, &p.TraceNumber, &p.ReturnCode, &p.ReturnTransactionID, &p.CreatedBy,
+		&p.CreatedAt, &p.SentAt, &p.ReturnedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("payout not found")
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func scanPayouts(rows *sql.Rows) ([]*models.Payout, error) {
+	defer rows.Close()
+	var list []*models.Payout
+	for rows.Next() {
+		p, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// CreateTx inserts a pending payout in the DB transaction of the transfer
+// that funds it, and sets its ID.
+func (r *PostgresPayoutRepository) CreateTx(tx *sql.Tx, p *models.Payout) error {
+	return tx.QueryRow(
+		`INSERT INTO payouts (account_id, external_account_id, routing_number, receiver_account_number,
+		  receiver_name, amount, transaction_id, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, status, created_at`,
+		p.AccountID, p.ExternalAccountID, p.RoutingNumber, p.ReceiverAccount,
+		p.ReceiverName, p.AmountPennies, p.TransactionID, p.CreatedBy,
+	).Scan(&p.ID, &p.Status, &p.CreatedAt)
+}
+
+func (r *PostgresPayoutRepository) Get(id int) (*models.Payout, error) {
+	return scanPayout(r.db.QueryRow(`SELECT `+payoutColumns+` FROM `+payoutFrom+` WHERE p.id = $1`, id))
+}
+
+func (r *PostgresPayoutRepository) GetByTrace(traceNumber string) (*models.Payout, error) {
+	return scanPayout(r.db.QueryRow(`SELECT `+payoutColumns+` FROM `+payoutFrom+` WHERE p.trace_number = $1`, traceNumber))
+}
+
+// ListDueTx locks the pending payouts whose transfer has completed, oldest
+// first. Payouts locked by another batch are skipped.
+func (r *PostgresPayoutRepository) ListDueTx(tx *sql.Tx) ([]*models.Payout, error) {
+	rows, err := tx.Query(
+		`SELECT ` + payoutColumns + ` FROM ` + payoutFrom + `
+		 WHERE p.status = 'pending' AND t.status = 'completed'
+		 ORDER BY p.id
+		 FOR UPDATE OF p SKIP LOCKED`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanPayouts(rows)
+}
+
+// CountFilesTx returns how many files have names starting with prefix.
+func (r *PostgresPayoutRepository) CountFilesTx(tx *sql.Tx, prefix string) (int, error) {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM ach_files WHERE file_name LIKE $1 || '%'`, prefix).Scan(&n)
+	return n, err
+}
+
+// CreateFileTx inserts a file and sets its ID.
+func (r *PostgresPayoutRepository) CreateFileTx(tx *sql.Tx, f *models.ACHFile) error {
+	return tx.QueryRow(
+		`INSERT INTO ach_files (file_name, content, entry_count, total) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		f.FileName, f.Content, f.EntryCount, f.TotalPennies,
+	).Scan(&f.ID, &f.CreatedAt)
+}
+
+// MarkSentTx records that a payout was written to a file under the trace
+// number.
+func (r *PostgresPayoutRepository) MarkSentTx(tx *sql.Tx, id, fileID int, traceNumber string) error {
+	_, err := tx.Exec(
+		`UPDATE payouts SET status = 'sent', file_id = $2, trace_number = $3, sent_at = NOW()
+		 WHERE id = $1 AND status = 'pending'`,
+		id, fileID, traceNumber,
+	)
+	return err
+}
+
+// ListUnwrittenFiles returns the files not yet written out, oldest first.
+func (r *PostgresPayoutRepository) ListUnwrittenFiles() ([]*models.ACHFile, error) {
+	rows, err := r.db.Query(
+		`SELECT id, file_name, content, entry_count, total, created_at, written_at
+		 FROM ach_files WHERE written_at IS NULL ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.ACHFile
+	for rows.Next() {
+		f := &models.ACHFile{}
+		if err := rows.Scan(&f.ID, &f.FileName, &f.Content, &f.EntryCount, &f.TotalPennies, &f.CreatedAt, &f.WrittenAt); err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresPayoutRepository) MarkFileWritten(id int) error {
+	_, err := r.db.Exec(`UPDATE ach_files SET written_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// ReturnTx marks a sent payout returned in the DB transaction of the
+// transfer that credits it back, so a return is only reversed once.
+func (r *PostgresPayoutRepository) ReturnTx(tx *sql.Tx, id int, returnCode string, transactionID int) error {
+	res, err := tx.Exec(
+		`UPDATE payouts SET status = 'returned', return_code = $2, return_transaction_id = $3, returned_at = NOW()
+		 WHERE id = $1 AND status = 'sent'`,
+		id, returnCode, transactionID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPayoutNotSent
+	}
+	return nil
+}
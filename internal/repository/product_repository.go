@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
+	return &PostgresProductRepository{db: db}
+}
+
+type PostgresProductRepository struct {
+	db *sql.DB
+}
+
+var (
+	ErrProductNotFound  = errors.New("product not found")
+	ErrDuplicateProduct = errors.New("product code already exists")
+)
+
+// productTermsColumns are a product version's terms, from product_versions
+// aliased v.
+const productTermsColumns = `v.currency, v.overdraft_limit, v.unlimited_overdraft, v.monthly_fee, v.transfer_fee,
+	v.interest_rate_bps, v.max_transfer, v.daily_transfer_limit, v.ledger_code`
+
+func productTermsDest(t *models.ProductTerms) []any {
+	return []any{&t.Currency, &t.OverdraftLimit, &t.UnlimitedOverdraft, &t.MonthlyFee, &t.TransferFee,
+		&t.InterestRateBPS, &t.MaxTransfer, &t.DailyTransferLimit, &t.LedgerCode}
+}
+
+// productSelect selects products with their current version.
+const productSelect = `SELECT p.code, p.name, p.type, p.created_at, v.version, v.created_at, ` + productTermsColumns + `
+	FROM products p
+	JOIN LATERAL (
+		SELECT * FROM product_versions WHERE product_code = p.code ORDER BY version DESC LIMIT 1
+	) v ON true`
+
+func scanProduct(row rowScanner) (*models.Product, error) {
+	p := &models.Product{}
+	dest := append([]any{&p.Code, &p.Name, &p.Type, &p.CreatedAt, &p.Version, &p.UpdatedAt}, productTermsDest(&p.Terms)...)
+	if err := row.Scan(dest...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// insertVersionTx adds the next version of the product's terms. The caller
+// holds the product's row lock.
+func insertVersionTx(tx *sql.Tx, code string, t *models.ProductTerms) error {
+	_, err := tx.Exec(
+		`INSERT INTO product_versions (product_code, version, currency, overdraft_limit, unlimited_overdraft,
+			monthly_fee, transfer_fee, interest_rate_bps, max_transfer, daily_transfer_limit, ledger_code)
+		 SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		 FROM product_versions WHERE product_code = $1`,
+		code, t.Currency, t.OverdraftLimit, t.UnlimitedOverdraft, t.MonthlyFee, t.TransferFee,
+		t.InterestRateBPS, t.MaxTransfer, t.DailyTransferLimit, t.LedgerCode,
+	)
+	if isForeignKeyViolation(err) {
+		return ErrLedgerAccountNotFound
+	}
+	return err
+}
+
+// CreateTx adds the product with its terms as version 1.
+func (r *PostgresProductRepository) CreateTx(tx *sql.Tx, p *models.Product) (*models.Product, error) {
+	_, err := tx.Exec(`INSERT INTO products (code, name, type) VALUES ($1, $2, $3)`, p.Code, p.Name, p.Type)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicateProduct
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := insertVersionTx(tx, p.Code, &p.Terms); err != nil {
+		return nil, err
+	}
+	return scanProduct(tx.QueryRow(productSelect+` WHERE p.code = $1`, p.Code))
+}
+
+// AddVersionTx makes terms the product's next version and returns the
+// product as it was and as it is now.
+func (r *PostgresProductRepository) AddVersionTx(tx *sql.Tx, code string, terms *models.ProductTerms) (*models.Product, *models.Product, error) {
+	before, err := scanProduct(tx.QueryRow(productSelect+` WHERE p.code = $1 FOR UPDATE OF p`, code))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := insertVersionTx(tx, code, terms); err != nil {
+		return nil, nil, err
+	}
+	after, err := scanProduct(tx.QueryRow(productSelect+` WHERE p.code = $1`, code))
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (r *PostgresProductRepository) Get(code string) (*models.Product, error) {
+	return scanProduct(r.db.QueryRow(productSelect+` WHERE p.code = $1`, code))
+}
+
+// List returns every product with its current version, in code order.
+func (r *PostgresProductRepository) List() ([]*models.Product, error) {
+	rows, err := r.db.Query(productSelect + ` ORDER BY p.code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// ListVersions returns every version of the product's terms, oldest first.
+func (r *PostgresProductRepository) ListVersions(code string) ([]*models.ProductVersion, error) {
+	rows, err := r.db.Query(
+		`SELECT v.version, v.created_at, `+productTermsColumns+`
+		 FROM product_versions v WHERE v.product_code = $1 ORDER BY v.version`, code,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.ProductVersion
+	for rows.Next() {
+		pv := &models.ProductVersion{}
+		if err := rows.Scan(append([]any{&pv.Version, &pv.CreatedAt}, productTermsDest(&pv.Terms)...)...); err != nil {
+			return nil, err
+		}
+		list = append(list, pv)
+	}
+	return list, rows.Err()
+}
+
+// MigrateTx moves the product's accounts on versions before version onto
+// it, and returns how many moved.
+func (r *PostgresProductRepository) MigrateTx(tx *sql.Tx, code string, version int) (int64, error) {
+	res, err := tx.Exec(
+		`UPDATE accounts SET product_version = $2 WHERE product_code = $1 AND product_version < $2`, code, version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"database/sql"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresReconciliationRepository(db *sql.DB) *PostgresReconciliationRepository {
+	return &PostgresReconciliationRepository{db: db}
+}
+
+type PostgresReconciliationRepository struct {
+	db *sql.DB
+}
+
+// EachBalanceTx calls fn with every account's stored and recomputed balance,
+// in account order. Run it in a repeatable-read transaction: transfers
+// update balances and complete in one transaction, so a snapshot always
+// agrees with itself. An error from fn stops the scan.
+func (r *PostgresReconciliationRepository) EachBalanceTx(tx *sql.Tx, fn func(*models.AccountBalanceCheck) error) error {
+	rows, err := tx.Query(
+		`WITH movements AS (
+		     SELECT destination_account_id AS account_id, amount FROM transactions WHERE status = 'completed'
+		     UNION ALL
+		     SELECT source_account_id, -amount FROM transactions WHERE status = 'completed'
+		 ), net AS (
+		     SELECT account_id, SUM(amount) AS amount FROM movements GROUP BY account_id
+		 )
+		 SELECT a.account_id, a.account_number, a.initial_balance, a.balance,
+		        a.initial_balance + COALESCE(n.amount, 0)
+		 FROM accounts a LEFT JOIN net n ON n.account_id = a.account_id
+		 ORDER BY a.account_id`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := &models.AccountBalanceCheck{}
+		if err := rows.Scan(&c.AccountID, &c.AccountNumber, &c.InitialBalancePennies, &c.BalancePennies, &c.ExpectedBalancePennies); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresScreeningCaseRepository(db *sql.DB) *PostgresScreeningCaseRepository {
+	return &PostgresScreeningCaseRepository{db: db}
+}
+
+type PostgresScreeningCaseRepository struct {
+	db *sql.DB
+}
+
+const screeningCaseColumns = `id, account_id, transaction_id, screened_name, entry_uid, listed_name, score, status, note, created_at, resolved_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanScreeningCase(row rowScanner) (*models.ScreeningCase, error) {
+	c := &models.ScreeningCase{}
+	err := row.Scan(&c.ID, &c.AccountID, &c.TransactionID, &c.ScreenedName, &c.EntryUID,
+		&c.ListedName, &c.Score, &c.Status, &c.Note, &c.CreatedAt, &c.ResolvedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("screening case not found")
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+const insertScreeningCase = `INSERT INTO screening_cases (account_id, transaction_id, screened_name, entry_uid, listed_name, score, status)
+	 VALUES ($1, $2, $3, $4, $5, $6, $7)
+	 RETURNING id, created_at`
+
+func (r *PostgresScreeningCaseRepository) Create(c *models.ScreeningCase) error {
+	return r.db.QueryRow(insertScreeningCase,
+		c.AccountID, c.TransactionID, c.ScreenedName, c.EntryUID, c.ListedName, c.Score, c.Status,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *PostgresScreeningCaseRepository) CreateTx(tx *sql.Tx, c *models.ScreeningCase) error {
+	return tx.QueryRow(insertScreeningCase,
+		c.AccountID, c.TransactionID, c.ScreenedName, c.EntryUID, c.ListedName, c.Score, c.Status,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *PostgresScreeningCaseRepository) GetByID(id int) (*models.ScreeningCase, error) {
+	return scanScreeningCase(r.db.QueryRow(
+		`SELECT `+screeningCaseColumns+` FROM screening_cases WHERE id = $1`, id,
+	))
+}
+
+func (r *PostgresScreeningCaseRepository) SelectTx(tx *sql.Tx, id int) (*models.ScreeningCase, error) {
+	return scanScreeningCase(tx.QueryRow(
+		`SELECT `+screeningCaseColumns+` FROM screening_cases WHERE id = $1 FOR UPDATE`, id,
+	))
+}
+
+// List returns cases in the given status, or all cases when status is empty.
+func (r *PostgresScreeningCaseRepository) List(status string) ([]*models.ScreeningCase, error) {
+	rows, err := r.db.Query(
+		`SELECT `+screeningCaseColumns+` FROM screening_cases
+		 WHERE $1 = '' OR status = $1
+		 ORDER BY id DESC`, status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.ScreeningCase
+	for rows.Next() {
+		c, err := scanScreeningCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// IsClearedTx reports whether a hit on this list entry was already cleared as
+// a false positive for the account.
+func (r *PostgresScreeningCaseRepository) IsClearedTx(tx *sql.Tx, accountID int, entryUID string) (bool, error) {
+	var cleared bool
+	err := tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM screening_cases WHERE account_id = $1 AND entry_uid = $2 AND status = $3)`,
+		accountID, entryUID, models.ScreeningCaseCleared,
+	).Scan(&cleared)
+	return cleared, err
+}
+
+func (r *PostgresScreeningCaseRepository) ResolveTx(tx *sql.Tx, id int, status, note string) error {
+	res, err := tx.Exec(
+		`UPDATE screening_cases SET status = $2, note = $3, resolved_at = NOW() WHERE id = $1`,
+		id, status, note,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("screening case not found")
+	}
+	return nil
+}
+
+// ClearOpenTx clears every open case for the account and list entry and
+// returns them as cleared.
+func (r *PostgresScreeningCaseRepository) ClearOpenTx(tx *sql.Tx, accountID int, entryUID, note string) ([]*models.ScreeningCase, error) {
+	rows, err := tx.Query(
+		`UPDATE screening_cases SET status = $4, note = $5, resolved_at = NOW()
+		 WHERE account_id = $1 AND entry_uid = $2 AND status = $3
+		 RETURNING `+screeningCaseColumns,
+		accountID, entryUID, models.ScreeningCaseOpen, models.ScreeningCaseCleared, note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.ScreeningCase
+	for rows.Next() {
+		c, err := scanScreeningCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// StatusCountsTx counts a transaction's cases by status.
+func (r *PostgresScreeningCaseRepository) StatusCountsTx(tx *sql.Tx, transactionID int) (map[string]int, error) {
+	rows, err := tx.Query(
+		`SELECT status, COUNT(*) FROM screening_cases WHERE transaction_id = $1 GROUP BY status`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
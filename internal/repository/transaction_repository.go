@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fastfunds/internal/models"
+	"time"
 )
 
 func NewPostgresTransactionRepository(db *sql.DB) *PostgresTransactionRepository {
@@ -14,22 +15,16 @@ type PostgresTransactionRepository struct {
 	db *sql.DB
 }
 
-func (r *PostgresTransactionRepository) CreateTx(tx *sql.Tx, t *models.Transaction) error {
-	return tx.QueryRow(
-		`INSERT INTO transactions (source_account_id, destination_account_id, amount, status)
-         VALUES ($1, $2, $3, $4)
-		 RETURNING id, created_at`,
-		t.SourceAccountID, t.DestinationAccountID, t.AmountPennies, t.Status,
-	).Scan(&t.ID, &t.CreatedAt)
-}
+const transactionColumns = `id, source_account_id, destination_account_id,
+	(SELECT account_number FROM accounts WHERE account_id = source_account_id),
+	(SELECT account_number FROM accounts WHERE account_id = destination_account_id),
+	amount, status, initiated_by, reviewed_by, review_note, expires_at, created_at, completed_at`
 
-func (r *PostgresTransactionRepository) GetByID(id int) (*models.Transaction, error) {
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
 	t := &models.Transaction{}
-	row := r.db.QueryRow(
-		`SELECT id, source_account_id, destination_account_id, amount, status, created_at
-		 FROM transactions WHERE id = $1`, id,
-	)
-	if err := row.Scan(&t.ID, &t.SourceAccountID, &t.DestinationAccountID, &t.AmountPennies, &t.Status, &t.CreatedAt); err != nil {
+	err := row.Scan(&t.ID, &t.SourceAccountID, &t.DestinationAccountID, &t.SourceAccountNumber, &t.DestinationAccountNumber, &t.AmountPennies, &t.Status,
+		&t.InitiatedBy, &t.ReviewedBy, &t.ReviewNote, &t.ExpiresAt, &t.CreatedAt, &t.CompletedAt)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("transaction not found")
 		}
@@ -38,9 +33,42 @@ func (r *PostgresTransactionRepository) GetByID(id int) (*models.Transaction, er
 	return t, nil
 }
 
+func scanTransactions(rows *sql.Rows) ([]*models.Transaction, error) {
+	defer rows.Close()
+
+	var list []*models.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresTransactionRepository) CreateTx(tx *sql.Tx, t *models.Transaction) error {
+	err := tx.QueryRow(
+		`INSERT INTO transactions (source_account_id, destination_account_id, amount, status, initiated_by, expires_at, completed_at)
+         VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $4 = 'completed' THEN NOW() END)
+		 RETURNING id, created_at, completed_at`,
+		t.SourceAccountID, t.DestinationAccountID, t.AmountPennies, t.Status, t.InitiatedBy, t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt, &t.CompletedAt)
+	if isBusinessDayClosed(err) {
+		return ErrBusinessDayClosed
+	}
+	return err
+}
+
+func (r *PostgresTransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	return scanTransaction(r.db.QueryRow(
+		`SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, id,
+	))
+}
+
 func (r *PostgresTransactionRepository) GetByAccountID(accountID int) ([]*models.Transaction, error) {
 	rows, err := r.db.Query(
-		`SELECT id, source_account_id, destination_account_id, amount, status, created_at
+		`SELECT `+transactionColumns+`
 		 FROM transactions
 		 WHERE source_account_id = $1 OR destination_account_id = $1
 		 ORDER BY id DESC`, accountID,
@@ -48,15 +76,151 @@ func (r *PostgresTransactionRepository) GetByAccountID(accountID int) ([]*models
 	if err != nil {
 		return nil, err
 	}
+	return scanTransactions(rows)
+}
+
+// ListByAccount returns up to limit of the account's transfers with an ID
+// below beforeID, newest first. A beforeID of 0 starts from the newest.
+func (r *PostgresTransactionRepository) ListByAccount(accountID, beforeID, limit int) ([]*models.Transaction, error) {
+	rows, err := r.db.Query(
+		`SELECT `+transactionColumns+`
+		 FROM transactions
+		 WHERE (source_account_id = $1 OR destination_account_id = $1) AND ($2 = 0 OR id < $2)
+		 ORDER BY id DESC
+		 LIMIT $3`, accountID, beforeID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+func (r *PostgresTransactionRepository) ListByStatus(status string) ([]*models.Transaction, error) {
+	rows, err := r.db.Query(
+		`SELECT `+transactionColumns+` FROM transactions WHERE status = $1 ORDER BY id DESC`, status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// lock so a held or pending transfer is settled at most once
+func (r *PostgresTransactionRepository) SelectTx(tx *sql.Tx, id int) (*models.Transaction, error) {
+	return scanTransaction(tx.QueryRow(
+		`SELECT `+transactionColumns+` FROM transactions WHERE id = $1 FOR UPDATE`, id,
+	))
+}
+
+func (r *PostgresTransactionRepository) UpdateStatusTx(tx *sql.Tx, id int, status string) error {
+	res, err := tx.Exec(
+		`UPDATE transactions SET status = $2, completed_at = CASE WHEN $2 = 'completed' THEN NOW() END WHERE id = $1`,
+		id, status,
+	)
+	if isBusinessDayClosed(err) {
+		return ErrBusinessDayClosed
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("transaction not found")
+	}
+	return nil
+}
+
+func (r *PostgresTransactionRepository) ReviewTx(tx *sql.Tx, id int, status, reviewer, note string) error {
+	res, err := tx.Exec(
+		`UPDATE transactions SET status = $2, reviewed_by = $3, review_note = $4,
+		 completed_at = CASE WHEN $2 = 'completed' THEN NOW() END
+		 WHERE id = $1`,
+		id, status, reviewer, note,
+	)
+	if isBusinessDayClosed(err) {
+		return ErrBusinessDayClosed
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("transaction not found")
+	}
+	return nil
+}
+
+// ExpirePendingTx marks pending approvals whose deadline has passed as
+// expired and returns them.
+func (r *PostgresTransactionRepository) ExpirePendingTx(tx *sql.Tx, now time.Time) ([]*models.Transaction, error) {
+	rows, err := tx.Query(
+		`UPDATE transactions SET status = $2 WHERE status = $1 AND expires_at <= $3 RETURNING `+transactionColumns,
+		models.TransactionStatusPendingApproval, models.TransactionStatusExpired, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// accountMovement is a completed transfer's effect on account $1.
+const accountMovement = `CASE WHEN destination_account_id = $1 THEN amount ELSE -amount END`
+
+// BalanceAtTx returns the account's balance just before at: its current
+// balance less every transfer completed since. Run it in the same
+// repeatable-read transaction as EachCompletedTx for a consistent statement.
+func (r *PostgresTransactionRepository) BalanceAtTx(tx *sql.Tx, accountID int, at time.Time) (int64, error) {
+	var balance int64
+	err := tx.QueryRow(
+		`SELECT a.balance - COALESCE((
+		     SELECT SUM(`+accountMovement+`) FROM transactions
+		     WHERE (source_account_id = $1 OR destination_account_id = $1)
+		       AND status = 'completed' AND completed_at >= $2
+		 ), 0)
+		 FROM accounts a WHERE a.account_id = $1`,
+		accountID, at,
+	).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.New("account not found")
+	}
+	return balance, err
+}
+
+// SumOutgoingSince returns the total of the account's transfers out created
+// at or after since that completed or may still complete.
+func (r *PostgresTransactionRepository) SumOutgoingSince(accountID int, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM transactions
+		 WHERE source_account_id = $1 AND created_at >= $2 AND status IN ($3, $4, $5)`,
+		accountID, since,
+		models.TransactionStatusCompleted, models.TransactionStatusHeld, models.TransactionStatusPendingApproval,
+	).Scan(&total)
+	return total, err
+}
+
+// EachCompletedTx calls fn with each of the account's transfers completed in
+// [from, to), in the order funds moved. Rows are read as fn consumes them, so
+// long periods are never held in memory; an error from fn stops the scan.
+func (r *PostgresTransactionRepository) EachCompletedTx(tx *sql.Tx, accountID int, from, to time.Time, fn func(*models.Transaction) error) error {
+	rows, err := tx.Query(
+		`SELECT `+transactionColumns+` FROM transactions
+		 WHERE (source_account_id = $1 OR destination_account_id = $1)
+		   AND status = 'completed' AND completed_at >= $2 AND completed_at < $3
+		 ORDER BY completed_at, id`,
+		accountID, from, to,
+	)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var list []*models.Transaction
 	for rows.Next() {
-		t := &models.Transaction{}
-		if err := rows.Scan(&t.ID, &t.SourceAccountID, &t.DestinationAccountID, &t.AmountPennies, &t.Status, &t.CreatedAt); err != nil {
-			return nil, err
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
 		}
-		list = append(list, t)
 	}
-	return list, rows.Err()
+	return rows.Err()
 }
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+)
+
+func NewPostgresTransferImportRepository(db *sql.DB) *PostgresTransferImportRepository {
+	return &PostgresTransferImportRepository{db: db}
+}
+
+type PostgresTransferImportRepository struct {
+	db *sql.DB
+}
+
+// ErrImportRowNotPending is returned by CompleteRowTx when another worker
+// already executed the row, so the caller's transfer must roll back.
+var ErrImportRowNotPending = errors.New("import row is not pending")
+
+const transferImportColumns = `id, file_name, status, created_by, created_at, confirmed_by, confirmed_at, completed_at`
+
+func scanTransferImport(row rowScanner) (*models.TransferImport, error) {
+	imp := &models.TransferImport{}
+	err := row.Scan(&imp.ID, &imp.FileName, &imp.Status, &imp.CreatedBy, &imp.CreatedAt,
+		&imp.ConfirmedBy, &imp.ConfirmedAt, &imp.CompletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("transfer import not found")
+		}
+		return nil, err
+	}
+	return imp, nil
+}
+
+const transferImportRowColumns = `r.import_id, r.line, r.source_account_number, r.destination_account_number, r.amount_text, r.amount,
+	r.reference, r.status, r.error, r.transaction_id, r.transaction_status`
+
+func scanTransferImportRow(row rowScanner, extra ...any) (*models.TransferImportRow, error) {
+	r := &models.TransferImportRow{}
+	dest := []any{&r.ImportID, &r.Line, &r.SourceAccountNumber, &r.DestinationAccountNumber, &r.Amount, &r.AmountPennies,
+		&r.Reference, &r.Status, &r.Error, &r.TransactionID, &r.TransactionStatus}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateTx inserts the import and its rows, and sets their IDs.
+func (r *PostgresTransferImportRepository) CreateTx(tx *sql.Tx, imp *models.TransferImport, rows []*models.TransferImportRow) error {
+	err := tx.QueryRow(
+		`INSERT INTO transfer_imports (file_name, created_by) VALUES ($1, $2) RETURNING id, status, created_at`,
+		imp.FileName, imp.CreatedBy,
+	).Scan(&imp.ID, &imp.Status, &imp.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO transfer_import_rows
+		 (import_id, line, source_account_number, destination_account_number, amount_text, amount, reference, status, error)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		row.ImportID = imp.ID
+		if _, err := stmt.Exec(row.ImportID, row.Line, row.SourceAccountNumber, row.DestinationAccountNumber,
+			row.Amount, row.AmountPennies, row.Reference, row.Status, row.Error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns an import with the number of rows in each status.
+func (r *PostgresTransferImportRepository) Get(id int) (*models.TransferImport, error) {
+	imp, err := scanTransferImport(r.db.QueryRow(`SELECT `+transferImportColumns+` FROM transfer_imports WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT status, COUNT(*), COALESCE(SUM(amount), 0) FROM transfer_import_rows WHERE import_id = $1 GROUP BY status`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imp.RowStatuses = map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		var total int64
+		if err := rows.Scan(&status, &count, &total); err != nil {
+			return nil, err
+		}
+		imp.RowStatuses[status] = count
+		imp.RowCount += count
+		if status != models.ImportRowInvalid {
+			imp.TotalPennies += total
+		}
+	}
+	return imp, rows.Err()
+}
+
+// ListRows returns an import's rows in line order.
+func (r *PostgresTransferImportRepository) ListRows(importID int) ([]*models.TransferImportRow, error) {
+	rows, err := r.db.Query(
+		`SELECT `+transferImportRowColumns+` FROM transfer_import_rows r WHERE r.import_id = $1 ORDER BY r.line`, importID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.TransferImportRow
+	for rows.Next() {
+		row, err := scanTransferImportRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+// ConfirmTx queues the valid rows of a validated import for execution on
+// behalf of the given principal, and returns the import as confirmed. It
+// fails if the import is not waiting for confirmation.
+func (r *PostgresTransferImportRepository) ConfirmTx(tx *sql.Tx, id int, by, role, authMethod string) (*models.TransferImport, error) {
+	imp, err := scanTransferImport(tx.QueryRow(
+		`UPDATE transfer_imports
+		 SET status = 'processing', confirmed_by = $2, confirmed_role = $3, confirmed_auth_method = $4, confirmed_at = NOW()
+		 WHERE id = $1 AND status = 'validated'
+		 RETURNING `+transferImportColumns,
+		id, by, role, authMethod,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE transfer_import_rows SET status = 'pending' WHERE import_id = $1 AND status = 'valid'`, id); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// ListDue returns up to limit pending rows, oldest import first and in line
+// order within an import. Rows are not locked: CompleteRowTx and FailRow
+// only change rows that are still pending.
+func (r *PostgresTransferImportRepository) ListDue(limit int) ([]*models.DueTransferImportRow, error) {
+	rows, err := r.db.Query(
+		`SELECT `+transferImportRowColumns+`, i.confirmed_by, i.confirmed_role, i.confirmed_auth_method
+		 FROM transfer_import_rows r JOIN transfer_imports i ON i.id = r.import_id
+		 WHERE r.status = 'pending'
+		 ORDER BY r.import_id, r.line
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.DueTransferImportRow
+	for rows.Next() {
+		due := &models.DueTransferImportRow{}
+		row, err := scanTransferImportRow(rows, &due.ConfirmedBy, &due.ConfirmedRole, &due.ConfirmedAuthMethod)
+		if err != nil {
+			return nil, err
+		}
+		due.TransferImportRow = row
+		list = append(list, due)
+	}
+	return list, rows.Err()
+}
+
+// CompleteRowTx records the transfer created for a pending row in the
+// transfer's own transaction, so a row is executed at most once.
+func (r *PostgresTransferImportRepository) CompleteRowTx(tx *sql.Tx, importID, line int, t *models.Transaction) error {
+	res, err := tx.Exec(
+		`UPDATE transfer_import_rows SET status = 'created', transaction_id = $3, transaction_status = $4
+		 WHERE import_id = $1 AND line = $2 AND status = 'pending'`,
+		importID, line, t.ID, t.Status,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrImportRowNotPending
+	}
+	return nil
+}
+
+// FailRow records why a pending row's transfer could not be created.
+func (r *PostgresTransferImportRepository) FailRow(importID, line int, reason string) error {
+	_, err := r.db.Exec(
+		`UPDATE transfer_import_rows SET status = 'failed', error = $3
+		 WHERE import_id = $1 AND line = $2 AND status = 'pending'`,
+		importID, line, reason,
+	)
+	return err
+}
+
+// CompleteImports marks processing imports with no pending rows left as
+// completed and returns how many there were.
+func (r *PostgresTransferImportRepository) CompleteImports() (int64, error) {
+	res, err := r.db.Exec(
+		`UPDATE transfer_imports i SET status = 'completed', completed_at = NOW()
+		 WHERE i.status = 'processing'
+		   AND NOT EXISTS (SELECT 1 FROM transfer_import_rows r WHERE r.import_id = i.id AND r.status = 'pending')`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
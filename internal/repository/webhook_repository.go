@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fastfunds/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+// Event types never contain commas, so the array travels as one string
+// rather than needing driver-specific array support.
+const webhookSubscriptionColumns = `id, url, array_to_string(event_types, ','), secret, created_at`
+
+func scanWebhookSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	s := &models.WebhookSubscription{}
+	var types string
+	if err := row.Scan(&s.ID, &s.URL, &types, &s.Secret, &s.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("webhook subscription not found")
+		}
+		return nil, err
+	}
+	s.EventTypes = strings.Split(types, ",")
+	return s, nil
+}
+
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.event, d.status, d.attempts,
+	CASE WHEN d.status = 'pending' THEN d.next_attempt_at END, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func scanWebhookDelivery(row rowScanner, extra ...any) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	var event []byte
+	dest := []any{&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &event, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("webhook delivery not found")
+		}
+		return nil, err
+	}
+	d.Event = event
+	return d, nil
+}
+
+func (r *PostgresWebhookRepository) CreateSubscriptionTx(tx *sql.Tx, s *models.WebhookSubscription) error {
+	return tx.QueryRow(
+		`INSERT INTO webhook_subscriptions (url, event_types, secret) VALUES ($1, string_to_array($2, ','), $3)
+		 RETURNING id, created_at`,
+		s.URL, strings.Join(s.EventTypes, ","), s.Secret,
+	).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *PostgresWebhookRepository) GetSubscription(id int) (*models.WebhookSubscription, error) {
+	return scanWebhookSubscription(r.db.QueryRow(
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id,
+	))
+}
+
+func (r *PostgresWebhookRepository) ListSubscriptions() ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(`SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.WebhookSubscription
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// DeleteSubscriptionTx deletes a subscription with its deliveries and
+// returns it as it was.
+func (r *PostgresWebhookRepository) DeleteSubscriptionTx(tx *sql.Tx, id int) (*models.WebhookSubscription, error) {
+	return scanWebhookSubscription(tx.QueryRow(
+		`DELETE FROM webhook_subscriptions WHERE id = $1 RETURNING `+webhookSubscriptionColumns, id,
+	))
+}
+
+// EnqueueEvent creates a pending delivery of e for every subscription to its
+// type and returns how many were created. An event already enqueued for a
+// subscription is skipped, so enqueueing it again is harmless.
+func (r *PostgresWebhookRepository) EnqueueEvent(e *models.Event) (int64, error) {
+	event, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.db.Exec(
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, event)
+		 SELECT id, $1, $2, $3 FROM webhook_subscriptions WHERE $2 = ANY(event_types)
+		 ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		e.ID, e.Type, string(event),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListDeliveries returns a subscription's most recent deliveries, newest first.
+func (r *PostgresWebhookRepository) ListDeliveries(subscriptionID int, status string, limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries d
+		 WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2)
+		 ORDER BY d.id DESC
+		 LIMIT $3`, subscriptionID, status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// GetDelivery returns a delivery with every attempt made for it.
+func (r *PostgresWebhookRepository) GetDelivery(subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRow(
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries d WHERE d.subscription_id = $1 AND d.id = $2`,
+		subscriptionID, id,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT attempted_at, status_code, error, duration_ms FROM webhook_delivery_attempts
+		 WHERE delivery_id = $1 ORDER BY id`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &models.WebhookDeliveryAttempt{}
+		if err := rows.Scan(&a.AttemptedAt, &a.StatusCode, &a.Error, &a.DurationMs); err != nil {
+			return nil, err
+		}
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+	return d, rows.Err()
+}
+
+// ClaimDueTx leases up to limit pending deliveries that are due, oldest
+// first, with their subscription's URL and secret: they aren't due again
+// until lease has passed, so other dispatchers skip them once tx commits.
+// Rows locked by another dispatcher are skipped.
+func (r *PostgresWebhookRepository) ClaimDueTx(tx *sql.Tx, limit int, lease time.Duration) ([]*models.DueWebhookDelivery, error) {
+	rows, err := tx.Query(
+		`WITH due AS (
+		   SELECT id FROM webhook_deliveries
+		   WHERE status = 'pending' AND next_attempt_at <= NOW()
+		   ORDER BY id
+		   LIMIT $1
+		   FOR UPDATE SKIP LOCKED
+		 )
+		 UPDATE webhook_deliveries d SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 FROM due, webhook_subscriptions s
+		 WHERE d.id = due.id AND s.id = d.subscription_id
+		 RETURNING `+webhookDeliveryColumns+`, s.url, s.secret`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.DueWebhookDelivery
+	for rows.Next() {
+		due := &models.DueWebhookDelivery{}
+		if due.WebhookDelivery, err = scanWebhookDelivery(rows, &due.URL, &due.Secret); err != nil {
+			return nil, err
+		}
+		list = append(list, due)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING has no order
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// RecordAttemptTx logs an attempt and moves the delivery to status. A
+// pending delivery is due again after retryAfter.
+func (r *PostgresWebhookRepository) RecordAttemptTx(tx *sql.Tx, id int64, a *models.WebhookDeliveryAttempt, status string, retryAfter time.Duration) error {
+	if _, err := tx.Exec(
+		`INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms) VALUES ($1, $2, $3, $4)`,
+		id, a.StatusCode, a.Error, a.DurationMs,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`UPDATE webhook_deliveries
+		 SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4,
+		     next_attempt_at = NOW() + make_interval(secs => $5),
+		     delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END
+		 WHERE id = $1`,
+		id, status, a.StatusCode, a.Error, retryAfter.Seconds(),
+	)
+	return err
+}
+
+// ReplayDeliveryTx makes a delivered or dead delivery pending again, due now,
+// with a fresh set of retries. Its attempt log is kept.
+func (r *PostgresWebhookRepository) ReplayDeliveryTx(tx *sql.Tx, subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	return scanWebhookDelivery(tx.QueryRow(
+		`UPDATE webhook_deliveries d
+		 SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		 WHERE d.subscription_id = $1 AND d.id = $2 AND d.status <> 'pending'
+		 RETURNING `+webhookDeliveryColumns,
+		subscriptionID, id,
+	))
+}
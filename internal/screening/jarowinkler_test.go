@@ -0,0 +1,44 @@
+package screening
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "", 0},
+		{"martha", "marhta", 0.9611},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.8133},
+		{"abc", "xyz", 0},
+		{"same", "same", 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.a+"_"+tc.b, func(t *testing.T) {
+			assert.InDelta(t, tc.want, JaroWinkler(tc.a, tc.b), 0.0001)
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"José  O'Neil, Jr.", "jose o neil jr"},
+		{"ACME TRADING LTD.", "acme trading"},
+		{"  Mr. Müller-Lüdenscheidt ", "muller ludenscheidt"},
+		{"!!!", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			assert.Equal(t, tc.want, Normalize(tc.in))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a single listed party with all the names it is known by.
+type Entry struct {
+	UID     string   `json:"uid"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Type    string   `json:"type,omitempty"`
+	Program string   `json:"program,omitempty"`
+}
+
+// List is an in-memory sanctions list.
+type List struct {
+	Entries []Entry
+}
+
+// LoadFile reads a sanctions list from disk. Files ending in .xml are parsed
+// as OFAC SDN XML, everything else as CSV.
+func LoadFile(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return ParseSDNXML(f)
+	}
+	return ParseCSV(f)
+}
+
+// ParseCSV reads a sanctions list in CSV form. Two layouts are accepted:
+//   - a file with a header row naming at least "name" (optionally "uid",
+//     "type", "program" and "aliases", the latter separated by ";")
+//   - OFAC's headerless sdn.csv (ent_num, SDN_Name, SDN_Type, Program, ...)
+func ParseCSV(r io.Reader) (*List, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid sanctions csv: %w", err)
+	}
+	if len(records) == 0 {
+		return &List{}, nil
+	}
+
+	cols := map[string]int{"uid": 0, "name": 1, "type": 2, "program": 3, "aliases": -1}
+	if header := headerColumns(records[0]); header != nil {
+		cols = header
+		records = records[1:]
+	}
+
+	list := &List{}
+	for i, rec := range records {
+		name := field(rec, cols["name"])
+		if name == "" {
+			continue
+		}
+		uid := field(rec, cols["uid"])
+		if uid == "" {
+			uid = fmt.Sprintf("row-%d", i+1)
+		}
+		e := Entry{
+			UID:     uid,
+			Name:    name,
+			Type:    nullable(field(rec, cols["type"])),
+			Program: nullable(field(rec, cols["program"])),
+		}
+		for _, a := range strings.Split(field(rec, cols["aliases"]), ";") {
+			if a = strings.TrimSpace(a); a != "" {
+				e.Aliases = append(e.Aliases, a)
+			}
+		}
+		list.Entries = append(list.Entries, e)
+	}
+	return list, nil
+}
+
+func headerColumns(rec []string) map[string]int {
+	cols := map[string]int{"uid": -1, "name": -1, "type": -1, "program": -1, "aliases": -1}
+	for i, h := range rec {
+		key := strings.ToLower(strings.TrimSpace(h))
+		if _, ok := cols[key]; ok {
+			cols[key] = i
+		}
+	}
+	if cols["name"] < 0 {
+		return nil
+	}
+	return cols
+}
+
+func field(rec []string, i int) string {
+	if i < 0 || i >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+// nullable maps OFAC's "-0-" placeholder to an empty string.
+func nullable(s string) string {
+	if s == "-0-" {
+		return ""
+	}
+	return s
+}
+
+type sdnList struct {
+	Entries []sdnEntry `xml:"sdnEntry"`
+}
+
+type sdnEntry struct {
+	UID       string   `xml:"uid"`
+	FirstName string   `xml:"firstName"`
+	LastName  string   `xml:"lastName"`
+	SDNType   string   `xml:"sdnType"`
+	Programs  []string `xml:"programList>program"`
+	AKAs      []struct {
+		FirstName string `xml:"firstName"`
+		LastName  string `xml:"lastName"`
+	} `xml:"akaList>aka"`
+}
+
+// ParseSDNXML reads the OFAC Specially Designated Nationals list in its XML
+// publication format.
+func ParseSDNXML(r io.Reader) (*List, error) {
+	var doc sdnList
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid sdn xml: %w", err)
+	}
+
+	list := &List{}
+	for _, s := range doc.Entries {
+		name := joinName(s.FirstName, s.LastName)
+		if name == "" {
+			continue
+		}
+		e := Entry{
+			UID:     s.UID,
+			Name:    name,
+			Type:    s.SDNType,
+			Program: strings.Join(s.Programs, ";"),
+		}
+		for _, aka := range s.AKAs {
+			if a := joinName(aka.FirstName, aka.LastName); a != "" {
+				e.Aliases = append(e.Aliases, a)
+			}
+		}
+		list.Entries = append(list.Entries, e)
+	}
+	if len(list.Entries) == 0 && len(doc.Entries) > 0 {
+		return nil, errors.New("sdn xml contains no named entries")
+	}
+	return list, nil
+}
+
+func joinName(first, last string) string {
+	return strings.TrimSpace(strings.TrimSpace(first) + " " + strings.TrimSpace(last))
+}
@@ -0,0 +1,53 @@
+package screening
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// noiseTokens are dropped before comparing names: honorifics and legal-form
+// suffixes vary between sources and only add false similarity.
+var noiseTokens = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "sir": true,
+	"ltd": true, "limited": true, "llc": true, "inc": true, "corp": true,
+	"co": true, "sa": true, "gmbh": true, "plc": true, "the": true,
+}
+
+// Normalize lowercases a name, strips diacritics and punctuation, drops noise
+// tokens and collapses whitespace. "José  O'Neil, Jr." becomes "jose o neil jr".
+func Normalize(name string) string {
+	return strings.Join(tokens(name), " ")
+}
+
+// sortedKey returns the normalized tokens in alphabetical order so that
+// "DOE, John" and "John Doe" compare equal.
+func sortedKey(name string) string {
+	t := tokens(name)
+	sort.Strings(t)
+	return strings.Join(t, " ")
+}
+
+func tokens(name string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining mark left over from decomposition; drop it
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	var out []string
+	for _, t := range strings.Fields(b.String()) {
+		if !noiseTokens[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package screening
+
+import "sort"
+
+// DefaultThreshold is the minimum Jaro-Winkler score reported as a hit.
+const DefaultThreshold = 0.92
+
+// Match is a list entry whose name or alias scored above the threshold.
+type Match struct {
+	EntryUID   string  `json:"entry_uid"`
+	ListedName string  `json:"listed_name"`
+	Score      float64 `json:"score"`
+}
+
+// Screener matches names against a List.
+type Screener interface {
+	Screen(name string) []Match
+}
+
+type indexedName struct {
+	entry  int
+	listed string
+	plain  string
+	sorted string
+}
+
+// ListScreener screens names against an in-memory list using normalized
+// Jaro-Winkler similarity.
+type ListScreener struct {
+	list      *List
+	names     []indexedName
+	threshold float64
+}
+
+// NewListScreener indexes list for screening. A threshold <= 0 uses DefaultThreshold.
+func NewListScreener(list *List, threshold float64) *ListScreener {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	s := &ListScreener{list: list, threshold: threshold}
+	for i, e := range list.Entries {
+		for _, n := range append([]string{e.Name}, e.Aliases...) {
+			plain := Normalize(n)
+			if plain == "" {
+				continue
+			}
+			s.names = append(s.names, indexedName{entry: i, listed: n, plain: plain, sorted: sortedKey(n)})
+		}
+	}
+	return s
+}
+
+// Screen returns the best match per list entry, highest score first.
+func (s *ListScreener) Screen(name string) []Match {
+	plain := Normalize(name)
+	if plain == "" {
+		return nil
+	}
+	sorted := sortedKey(name)
+
+	best := map[int]Match{}
+	for _, n := range s.names {
+		score := max(JaroWinkler(plain, n.plain), JaroWinkler(sorted, n.sorted))
+		if score < s.threshold {
+			continue
+		}
+		if m, ok := best[n.entry]; ok && m.Score >= score {
+			continue
+		}
+		best[n.entry] = Match{
+			EntryUID:   s.list.Entries[n.entry].UID,
+			ListedName: n.listed,
+			Score:      score,
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].EntryUID < matches[j].EntryUID
+	})
+	return matches
+}
@@ -0,0 +1,107 @@
+package screening
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const headerCSV = `uid,name,type,program,aliases
+1,Ivan Petrov,individual,UKRAINE-EO13661,Ivan Petroff;I. Petrov
+2,Acme Shipping Ltd,entity,IRAN,
+`
+
+const ofacCSV = `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0-
+173,"ANGLO-CARIBBEAN CO., LTD.",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,"a.k.a. AN-CAR"
+`
+
+const sdnXML = `<?xml version="1.0" standalone="yes"?>
+<sdnList xmlns="http://tempuri.org/sdnList.xsd">
+  <sdnEntry>
+    <uid>2674</uid>
+    <firstName>Abu</firstName>
+    <lastName>ABBAS</lastName>
+    <sdnType>Individual</sdnType>
+    <programList><program>SDGT</program></programList>
+    <akaList>
+      <aka><uid>201</uid><type>a.k.a.</type><category>strong</category><lastName>ZAYDAN</lastName><firstName>Muhammad</firstName></aka>
+    </akaList>
+  </sdnEntry>
+  <sdnEntry>
+    <uid>36</uid>
+    <lastName>AEROCARIBBEAN AIRLINES</lastName>
+    <sdnType>Entity</sdnType>
+    <programList><program>CUBA</program></programList>
+  </sdnEntry>
+</sdnList>`
+
+func TestParseCSV(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		list, err := ParseCSV(strings.NewReader(headerCSV))
+		assert.NoError(t, err)
+		assert.Len(t, list.Entries, 2)
+		assert.Equal(t, "1", list.Entries[0].UID)
+		assert.Equal(t, []string{"Ivan Petroff", "I. Petrov"}, list.Entries[0].Aliases)
+		assert.Empty(t, list.Entries[1].Aliases)
+	})
+	t.Run("ofac_positional", func(t *testing.T) {
+		list, err := ParseCSV(strings.NewReader(ofacCSV))
+		assert.NoError(t, err)
+		assert.Len(t, list.Entries, 2)
+		assert.Equal(t, "173", list.Entries[1].UID)
+		assert.Equal(t, "ANGLO-CARIBBEAN CO., LTD.", list.Entries[1].Name)
+		assert.Equal(t, "", list.Entries[1].Type)
+		assert.Equal(t, "CUBA", list.Entries[1].Program)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("uid,name\n\"unterminated,x\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSDNXML(t *testing.T) {
+	list, err := ParseSDNXML(strings.NewReader(sdnXML))
+	assert.NoError(t, err)
+	assert.Len(t, list.Entries, 2)
+	assert.Equal(t, "Abu ABBAS", list.Entries[0].Name)
+	assert.Equal(t, []string{"Muhammad ZAYDAN"}, list.Entries[0].Aliases)
+	assert.Equal(t, "SDGT", list.Entries[0].Program)
+	assert.Equal(t, "AEROCARIBBEAN AIRLINES", list.Entries[1].Name)
+
+	_, err = ParseSDNXML(strings.NewReader("<sdnList>"))
+	assert.Error(t, err)
+}
+
+func TestListScreener_Screen(t *testing.T) {
+	list, err := ParseCSV(strings.NewReader(headerCSV))
+	assert.NoError(t, err)
+	s := NewListScreener(list, 0)
+
+	cases := []struct {
+		name    string
+		in      string
+		wantUID string
+	}{
+		{"exact", "Ivan Petrov", "1"},
+		{"reordered_with_comma", "PETROV, Ivan", "1"},
+		{"alias_typo", "Ivan Petrof", "1"},
+		{"diacritics", "Iván Petróv", "1"},
+		{"legal_suffix_ignored", "ACME SHIPPING LIMITED", "2"},
+		{"no_match", "Maria Silva", ""},
+		{"empty", "   ", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := s.Screen(tc.in)
+			if tc.wantUID == "" {
+				assert.Empty(t, got)
+				return
+			}
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, tc.wantUID, got[0].EntryUID)
+				assert.GreaterOrEqual(t, got[0].Score, DefaultThreshold)
+			}
+		})
+	}
+}
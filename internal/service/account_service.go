@@ -1,70 +1,343 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/events"
 	"fastfunds/internal/models"
 	"fastfunds/internal/repository"
+	"fastfunds/internal/screening"
 	"fastfunds/internal/util"
+	"strings"
+	"time"
 )
 
-func NewAccountService(accountRepo repository.AccountRepository) *AccountService {
-	return &AccountService{
+// maxAccountNumberAttempts bounds retries when a generated account number collides.
+const maxAccountNumberAttempts = 5
+
+func NewAccountService(db *sql.DB, accountRepo repository.AccountRepository, opts ...func(*AccountService)) *AccountService {
+	s := &AccountService{
+		db:          db,
 		accountRepo: accountRepo,
 		money:       util.DefaultMoneyConverter{},
+		numbers:     util.DefaultAccountNumberScheme(),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.policy == nil {
+		s.policy = NewRolePolicy(s.holderRepo)
 	}
+	return s
 }
 
 // NewAccountServiceWithDeps allows injecting a MoneyConverter for testing.
-func NewAccountServiceWithDeps(accountRepo repository.AccountRepository, money util.MoneyConverter) *AccountService {
+func NewAccountServiceWithDeps(db *sql.DB, accountRepo repository.AccountRepository, money util.MoneyConverter, opts ...func(*AccountService)) *AccountService {
 	if money == nil {
 		money = util.DefaultMoneyConverter{}
 	}
-	return &AccountService{
+	s := &AccountService{
+		db:          db,
 		accountRepo: accountRepo,
 		money:       money,
+		numbers:     util.DefaultAccountNumberScheme(),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.policy == nil {
+		s.policy = NewRolePolicy(s.holderRepo)
+	}
+	return s
+}
+
+// WithAccountNumberScheme sets how account numbers are generated and validated.
+func WithAccountNumberScheme(scheme util.AccountNumberScheme) func(*AccountService) {
+	return func(s *AccountService) {
+		s.numbers = scheme
+	}
+}
+
+// WithAccountPolicy sets the authorization policy. By default a RolePolicy
+// backed by the account holders is used.
+func WithAccountPolicy(policy Policy) func(*AccountService) {
+	return func(s *AccountService) {
+		s.policy = policy
+	}
+}
+
+// WithAccountScreening screens holder names on account creation and opens a
+// case for every hit.
+func WithAccountScreening(screener screening.Screener, caseRepo repository.ScreeningCaseRepository) func(*AccountService) {
+	return func(s *AccountService) {
+		s.screener = screener
+		s.caseRepo = caseRepo
+	}
+}
+
+// WithAccountHolders enables linking customers to accounts and includes the
+// holders in GetAccount.
+func WithAccountHolders(holderRepo repository.AccountHolderRepository, customerRepo repository.CustomerRepository) func(*AccountService) {
+	return func(s *AccountService) {
+		s.holderRepo = holderRepo
+		s.customerRepo = customerRepo
+	}
+}
+
+// WithAccountProducts sets where the products accounts are opened on come
+// from. It is required to create accounts.
+func WithAccountProducts(productRepo repository.ProductRepository) func(*AccountService) {
+	return func(s *AccountService) {
+		s.productRepo = productRepo
+	}
+}
+
+// WithAccountAuditLog records account creation and holder changes in the
+// audit log.
+func WithAccountAuditLog(auditLog repository.AuditLogRepository) func(*AccountService) {
+	return func(s *AccountService) {
+		s.auditLog = auditLog
+	}
+}
+
+// WithAccountEvents writes an AccountCreated event to the outbox for every
+// new account.
+func WithAccountEvents(outbox repository.OutboxRepository) func(*AccountService) {
+	return func(s *AccountService) {
+		s.outbox = outbox
+	}
+}
+
+// WithAccountActivity enables StreamActivity. hub wakes streams when new
+// activity commits.
+func WithAccountActivity(activityRepo repository.ActivityRepository, hub *events.ActivityHub) func(*AccountService) {
+	return func(s *AccountService) {
+		s.activityRepo = activityRepo
+		s.activityHub = hub
 	}
 }
 
 type AccountService struct {
-	accountRepo repository.AccountRepository
-	money       util.MoneyConverter
+	db           *sql.DB
+	accountRepo  repository.AccountRepository
+	money        util.MoneyConverter
+	numbers      util.AccountNumberScheme
+	screener     screening.Screener
+	caseRepo     repository.ScreeningCaseRepository
+	holderRepo   repository.AccountHolderRepository
+	customerRepo repository.CustomerRepository
+	productRepo  repository.ProductRepository
+	auditLog     repository.AuditLogRepository
+	outbox       repository.OutboxRepository
+	activityRepo repository.ActivityRepository
+	activityHub  *events.ActivityHub
+	policy       Policy
+	beginFn      func() (*sql.Tx, error)
+	rollbackFn   func(*sql.Tx) error
+	commitFn     func(*sql.Tx) error
 }
 
-func (s *AccountService) CreateAccount(req *models.CreateAccountRequest) error {
-	if req.AccountID <= 0 {
-		return errors.New("invalid account_id")
+func (s *AccountService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.AccountView, error) {
+	if err := authorize(ctx, s.policy, ActionCreateAccount, Resource{}); err != nil {
+		return nil, err
+	}
+
+	req.HolderName = strings.TrimSpace(req.HolderName)
+	if req.HolderName == "" {
+		return nil, errors.New("holder name is required")
 	}
 
 	if req.InitialBalance == "" {
-		return errors.New("initial balance is required")
+		return nil, errors.New("initial balance is required")
 	}
 
 	pennies, err := s.money.DecimalStringToPennies(req.InitialBalance)
 	if err != nil {
-		return errors.New("invalid balance format")
+		return nil, errors.New("invalid balance format")
 	}
 
-	exists, err := s.accountRepo.Exists(req.AccountID)
+	account := &models.Account{
+		HolderName:     req.HolderName,
+		CurrentBalance: pennies,
+		LedgerCode:     req.LedgerCode,
+		ProductCode:    req.ProductCode,
+		Currency:       req.Currency,
+	}
+	if err := s.openAccount(ctx, account, nil); err != nil {
+		return nil, err
+	}
+
+	return &models.AccountView{
+		AccountID:      account.AccountID,
+		AccountNumber:  account.AccountNumber,
+		HolderName:     account.HolderName,
+		CurrentBalance: s.money.PenniesToDecimalString(account.CurrentBalance),
+		LedgerCode:     account.LedgerCode,
+		ProductCode:    account.ProductCode,
+		ProductVersion: account.ProductVersion,
+		Currency:       account.Currency,
+		Terms:          &account.Terms,
+	}, nil
+}
+
+// openAccount opens account on its product's current terms, as
+// CreateAccount does, and sets its ID and number. When onCreate is set it
+// is called in the account's DB transaction just before commit, and an
+// error from it rolls the account back.
+func (s *AccountService) openAccount(ctx context.Context, account *models.Account, onCreate func(*sql.Tx, *models.Account) error) error {
+	product, err := s.product(account.ProductCode)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return errors.New("account already exists")
+
+	// The product's current terms apply; its currency and ledger account
+	// are defaults.
+	account.ProductCode = product.Code
+	account.ProductVersion = product.Version
+	account.Terms = product.Terms
+	account.LedgerCode = strings.TrimSpace(account.LedgerCode)
+	account.Currency = strings.TrimSpace(account.Currency)
+	if account.LedgerCode == "" {
+		account.LedgerCode = product.Terms.LedgerCode
+	}
+	if account.Currency == "" {
+		account.Currency = product.Terms.Currency
+	}
+	if !util.IsCurrencyCode(account.Currency) {
+		return errors.New("currency must be an ISO 4217 code such as GBP")
 	}
 
-	account := &models.Account{
-		AccountID:      req.AccountID,
-		CurrentBalance: pennies,
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	// The internal ID comes from the database; the external number is random,
+	// so a collision is retried rather than checked up front.
+	for attempt := 1; ; attempt++ {
+		if account.AccountNumber, err = s.numbers.Generate(); err != nil {
+			return errors.New("couldn't generate account number")
+		}
+		err = s.accountRepo.CreateTx(tx, account)
+		if !errors.Is(err, repository.ErrDuplicateAccountNumber) {
+			break
+		}
+		if attempt == maxAccountNumberAttempts {
+			return errors.New("couldn't allocate a unique account number")
+		}
+	}
+	if errors.Is(err, repository.ErrLedgerAccountNotFound) {
+		return errors.New("unknown ledger_code")
+	}
+	if err != nil {
+		return err
+	}
+
+	// The account is still created on a hit; transfers touching it are held
+	// until the case is cleared.
+	var hits []*models.ScreeningCase
+	if s.screener != nil {
+		for _, m := range s.screener.Screen(account.HolderName) {
+			c := newScreeningCase(account, m, nil)
+			if err := s.caseRepo.CreateTx(tx, c); err != nil {
+				return errors.New("failed to record screening case")
+			}
+			hits = append(hits, c)
+		}
+	}
+
+	created := models.AccountCreatedPayload{
+		AccountNumber:  account.AccountNumber,
+		HolderName:     account.HolderName,
+		BalancePennies: account.CurrentBalance,
+	}
+	if err := recordEvent(tx, s.outbox, models.EventAccountCreated, events.AggregateAccount, account.AccountNumber, created); err != nil {
+		return err
+	}
+
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionAccountCreate, audit.EntityAccount, account.AccountID, nil, account); err != nil {
+		return err
+	}
+	for _, c := range hits {
+		if err := recordAudit(ctx, tx, s.auditLog, audit.ActionScreeningCaseOpen, audit.EntityScreeningCase, c.ID, nil, c); err != nil {
+			return err
+		}
 	}
 
-	return s.accountRepo.Create(account)
+	if onCreate != nil {
+		if err := onCreate(tx, account); err != nil {
+			return err
+		}
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
+
+// product returns the product with the given code, or the default product.
+func (s *AccountService) product(code string) (*models.Product, error) {
+	if s.productRepo == nil {
+		return nil, errors.New("account products are not configured")
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		code = models.DefaultProductCode
+	}
+	product, err := s.productRepo.Get(code)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		return nil, errors.New("unknown product_code")
+	}
+	if err != nil {
+		return nil, errors.New("couldn't get product")
+	}
+	return product, nil
+}
+
+// ResolveAccountNumber validates an external account number's check digits
+// and returns the internal account ID. Callers authorize the operation they
+// resolve the number for.
+func (s *AccountService) ResolveAccountNumber(number string) (int, error) {
+	account, err := s.resolveAccount(number)
+	if err != nil {
+		return 0, err
+	}
+	return account.AccountID, nil
+}
+
+// resolveAccount is ResolveAccountNumber returning the whole account.
+func (s *AccountService) resolveAccount(number string) (*models.Account, error) {
+	if err := s.numbers.Validate(number); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByNumber(s.numbers.Normalize(number))
+	if err != nil {
+		return nil, errors.New("account not found")
+	}
+	return account, nil
 }
 
-func (s *AccountService) GetAccount(accountID int) (*models.AccountView, error) {
+func (s *AccountService) GetAccount(ctx context.Context, accountID int) (*models.AccountView, error) {
 	if accountID <= 0 {
 		return nil, errors.New("invalid account_id")
 	}
 
+	if err := authorize(ctx, s.policy, ActionReadAccount, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return nil, err
+	}
+
 	account, err := s.accountRepo.GetByID(accountID)
 
 	if err != nil {
@@ -73,8 +346,227 @@ func (s *AccountService) GetAccount(accountID int) (*models.AccountView, error)
 
 	accountView := &models.AccountView{
 		AccountID:      account.AccountID,
+		AccountNumber:  account.AccountNumber,
+		HolderName:     account.HolderName,
 		CurrentBalance: s.money.PenniesToDecimalString(account.CurrentBalance),
+		LedgerCode:     account.LedgerCode,
+		ProductCode:    account.ProductCode,
+		ProductVersion: account.ProductVersion,
+		Currency:       account.Currency,
+		Terms:          &account.Terms,
+	}
+
+	if s.holderRepo != nil {
+		if accountView.Holders, err = s.holderRepo.ListByAccount(accountID); err != nil {
+			return nil, errors.New("couldn't get account holders")
+		}
 	}
 
 	return accountView, nil
 }
+
+// AddHolder links a customer to an account with the given role.
+func (s *AccountService) AddHolder(ctx context.Context, accountID int, req *models.AddAccountHolderRequest) (*models.AccountHolder, error) {
+	if s.holderRepo == nil {
+		return nil, errors.New("account holders are not enabled")
+	}
+
+	if accountID <= 0 {
+		return nil, errors.New("invalid account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageHolders, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return nil, err
+	}
+	if req.CustomerID <= 0 {
+		return nil, errors.New("invalid customer_id")
+	}
+
+	switch req.Role {
+	case models.HolderRoleOwner, models.HolderRoleJoint, models.HolderRoleAuthorizedUser:
+	default:
+		return nil, errors.New("invalid role")
+	}
+
+	exists, err := s.accountRepo.Exists(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("account not found")
+	}
+
+	customer, err := s.customerRepo.GetByID(req.CustomerID)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	holder := &models.AccountHolder{
+		AccountID:  accountID,
+		CustomerID: req.CustomerID,
+		Name:       customer.Name,
+		Role:       req.Role,
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.holderRepo.AddTx(tx, holder); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionHolderAdd, audit.EntityAccount, accountID, nil, holder); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return holder, nil
+}
+
+// RemoveHolder unlinks a customer from an account. The last owner cannot be removed.
+func (s *AccountService) RemoveHolder(ctx context.Context, accountID, customerID int) error {
+	if s.holderRepo == nil {
+		return errors.New("account holders are not enabled")
+	}
+
+	if accountID <= 0 {
+		return errors.New("invalid account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageHolders, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return err
+	}
+	if customerID <= 0 {
+		return errors.New("invalid customer_id")
+	}
+
+	holders, err := s.holderRepo.ListByAccount(accountID)
+	if err != nil {
+		return errors.New("couldn't get account holders")
+	}
+
+	var target *models.AccountHolder
+	owners := 0
+	for _, h := range holders {
+		if h.CustomerID == customerID {
+			target = h
+		}
+		if h.Role == models.HolderRoleOwner {
+			owners++
+		}
+	}
+	if target == nil {
+		return errors.New("account holder not found")
+	}
+	if target.Role == models.HolderRoleOwner && owners == 1 {
+		return errors.New("account must keep at least one owner")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.holderRepo.RemoveTx(tx, accountID, customerID); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionHolderRemove, audit.EntityAccount, accountID, target, nil); err != nil {
+		return err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
+
+// activityBatchSize bounds how many entries ActivityStream.Next returns at once.
+const activityBatchSize = 100
+
+// ActivityStream follows one account's activity feed.
+type ActivityStream interface {
+	// Next returns the entries after the last one returned, oldest first,
+	// waiting up to wait for some to commit. It returns an empty batch if
+	// none did.
+	Next(ctx context.Context, wait time.Duration) ([]*models.AccountActivity, error)
+	Close()
+}
+
+// StreamActivity follows the account's transfers and balance changes. With a
+// lastEventID of 0 the stream starts with the next change; otherwise it first
+// replays the retained entries after lastEventID.
+func (s *AccountService) StreamActivity(ctx context.Context, accountID int, lastEventID int64) (ActivityStream, error) {
+	if s.activityRepo == nil {
+		return nil, errors.New("account activity is not enabled")
+	}
+
+	if accountID <= 0 {
+		return nil, errors.New("invalid account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadAccount, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return nil, err
+	}
+	if lastEventID < 0 {
+		return nil, errors.New("invalid Last-Event-ID")
+	}
+
+	// Subscribe before reading, so nothing committed in between is missed
+	wake, cancel := s.activityHub.Subscribe(accountID)
+	if lastEventID == 0 {
+		latest, err := s.activityRepo.LatestID(accountID)
+		if err != nil {
+			cancel()
+			return nil, errors.New("couldn't read account activity")
+		}
+		lastEventID = latest
+	}
+
+	return &activityStream{
+		repo:      s.activityRepo,
+		accountID: accountID,
+		lastID:    lastEventID,
+		wake:      wake,
+		cancel:    cancel,
+	}, nil
+}
+
+type activityStream struct {
+	repo      repository.ActivityRepository
+	accountID int
+	lastID    int64
+	wake      <-chan struct{}
+	cancel    func()
+}
+
+func (st *activityStream) Next(ctx context.Context, wait time.Duration) ([]*models.AccountActivity, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		list, err := st.repo.ListAfter(st.accountID, st.lastID, activityBatchSize)
+		if err != nil {
+			return nil, errors.New("couldn't read account activity")
+		}
+		if len(list) > 0 {
+			st.lastID = list[len(list)-1].ID
+			return list, nil
+		}
+
+		select {
+		case <-st.wake:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (st *activityStream) Close() {
+	st.cancel()
+}
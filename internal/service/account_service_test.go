@@ -1,24 +1,32 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fastfunds/internal/events"
 	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/screening"
 	"fastfunds/internal/util"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 type mockAccountRepository struct {
-	createFn   func(*models.Account) error
-	getByIDFn  func(int) (*models.Account, error)
-	existsFn   func(int) (bool, error)
-	selectTxFn func(*sql.Tx, int) (*models.Account, error)
-	updateTxFn func(*sql.Tx, *models.Account) error
+	createFn      func(*models.Account) error
+	getByIDFn     func(int) (*models.Account, error)
+	getByNumberFn func(string) (*models.Account, error)
+	existsFn      func(int) (bool, error)
+	selectTxFn    func(*sql.Tx, int) (*models.Account, error)
+	updateTxFn    func(*sql.Tx, *models.Account) error
 }
 
-func (m *mockAccountRepository) Create(a *models.Account) error {
+func (m *mockAccountRepository) CreateTx(tx *sql.Tx, a *models.Account) error {
 	if m.createFn != nil {
 		return m.createFn(a)
 	}
@@ -32,6 +40,13 @@ func (m *mockAccountRepository) GetByID(id int) (*models.Account, error) {
 	return nil, nil
 }
 
+func (m *mockAccountRepository) GetByNumber(number string) (*models.Account, error) {
+	if m.getByNumberFn != nil {
+		return m.getByNumberFn(number)
+	}
+	return nil, nil
+}
+
 func (m *mockAccountRepository) Exists(id int) (bool, error) {
 	if m.existsFn != nil {
 		return m.existsFn(id)
@@ -53,6 +68,16 @@ func (m *mockAccountRepository) UpdateTx(tx *sql.Tx, a *models.Account) error {
 	return nil
 }
 
+// newTestAccountService stubs out the DB transaction functions.
+func newTestAccountService(repo repository.AccountRepository, money util.MoneyConverter, opts ...func(*AccountService)) *AccountService {
+	opts = append([]func(*AccountService){WithAccountProducts(newMockProductRepository())}, opts...)
+	s := NewAccountServiceWithDeps(&sql.DB{}, repo, money, opts...)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
 type mockMoneyConverter struct {
 	decFn func(string) (int64, error)
 	fmtFn func(int64) string
@@ -81,22 +106,22 @@ func TestCreateAccount(t *testing.T) {
 		wantErr string
 	}{
 		{
-			name:    "invalid_id",
-			req:     &models.CreateAccountRequest{AccountID: 0, InitialBalance: "10.00"},
+			name:    "empty_holder_name",
+			req:     &models.CreateAccountRequest{HolderName: "  ", InitialBalance: "10.00"},
 			money:   &mockMoneyConverter{},
 			repo:    &mockAccountRepository{},
-			wantErr: "invalid account_id",
+			wantErr: "holder name is required",
 		},
 		{
 			name:    "empty_balance",
-			req:     &models.CreateAccountRequest{AccountID: 1, InitialBalance: ""},
+			req:     &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: ""},
 			money:   &mockMoneyConverter{},
 			repo:    &mockAccountRepository{},
 			wantErr: "initial balance is required",
 		},
 		{
 			name: "invalid_balance_format",
-			req:  &models.CreateAccountRequest{AccountID: 1, InitialBalance: "abc"},
+			req:  &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "abc"},
 			money: &mockMoneyConverter{
 				decFn: func(s string) (int64, error) { return 0, errors.New("bad") },
 			},
@@ -104,50 +129,68 @@ func TestCreateAccount(t *testing.T) {
 			wantErr: "invalid balance format",
 		},
 		{
-			name: "exists_error",
-			req:  &models.CreateAccountRequest{AccountID: 2, InitialBalance: "1.00"},
+			name: "number_space_exhausted",
+			req:  &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"},
 			money: &mockMoneyConverter{
 				decFn: func(s string) (int64, error) { return 100, nil },
 			},
 			repo: &mockAccountRepository{
-				existsFn: func(int) (bool, error) { return false, errors.New("db") },
+				createFn: func(*models.Account) error { return repository.ErrDuplicateAccountNumber },
 			},
-			wantErr: "db",
+			wantErr: "couldn't allocate a unique account number",
 		},
 		{
-			name: "already_exists",
-			req:  &models.CreateAccountRequest{AccountID: 3, InitialBalance: "1.00"},
+			name: "create_error",
+			req:  &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"},
 			money: &mockMoneyConverter{
 				decFn: func(s string) (int64, error) { return 100, nil },
 			},
 			repo: &mockAccountRepository{
-				existsFn: func(int) (bool, error) { return true, nil },
+				createFn: func(*models.Account) error { return errors.New("fail") },
 			},
-			wantErr: "account already exists",
+			wantErr: "fail",
 		},
 		{
-			name: "create_error",
-			req:  &models.CreateAccountRequest{AccountID: 4, InitialBalance: "1.00"},
+			name: "unknown_ledger_code",
+			req:  &models.CreateAccountRequest{HolderName: "Fees", InitialBalance: "0", LedgerCode: "9999"},
 			money: &mockMoneyConverter{
-				decFn: func(s string) (int64, error) { return 100, nil },
+				decFn: func(s string) (int64, error) { return 0, nil },
 			},
 			repo: &mockAccountRepository{
-				existsFn: func(int) (bool, error) { return false, nil },
-				createFn: func(*models.Account) error { return errors.New("fail") },
+				createFn: func(*models.Account) error { return repository.ErrLedgerAccountNotFound },
 			},
-			wantErr: "fail",
+			wantErr: "unknown ledger_code",
+		},
+		{
+			name:    "unknown_product_code",
+			req:     &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "0", ProductCode: "premier"},
+			money:   &mockMoneyConverter{},
+			repo:    &mockAccountRepository{},
+			wantErr: "unknown product_code",
+		},
+		{
+			name:    "bad_currency",
+			req:     &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "0", Currency: "pounds"},
+			money:   &mockMoneyConverter{},
+			repo:    &mockAccountRepository{},
+			wantErr: "currency must be an ISO 4217 code such as GBP",
 		},
 		{
 			name: "success",
-			req:  &models.CreateAccountRequest{AccountID: 5, InitialBalance: "123.45"},
+			req:  &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "123.45"},
 			money: &mockMoneyConverter{
 				decFn: func(s string) (int64, error) { return 12345, nil },
 			},
 			repo: &mockAccountRepository{
-				existsFn: func(int) (bool, error) { return false, nil },
 				createFn: func(a *models.Account) error {
-					assert.Equal(t, 5, a.AccountID)
+					assert.NoError(t, util.DefaultAccountNumberScheme().Validate(a.AccountNumber))
+					assert.Equal(t, "Jane Doe", a.HolderName)
 					assert.Equal(t, int64(12345), a.CurrentBalance)
+					assert.Equal(t, models.LedgerCodeCustomerDeposits, a.LedgerCode)
+					assert.Equal(t, models.DefaultProductCode, a.ProductCode)
+					assert.Equal(t, 1, a.ProductVersion)
+					assert.Equal(t, "GBP", a.Currency)
+					a.AccountID = 5
 					return nil
 				},
 			},
@@ -156,18 +199,120 @@ func TestCreateAccount(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewAccountServiceWithDeps(tc.repo, tc.money)
-			err := svc.CreateAccount(tc.req)
+			svc := newTestAccountService(tc.repo, tc.money)
+			got, err := svc.CreateAccount(operatorCtx, tc.req)
 			if tc.wantErr != "" {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tc.wantErr)
 				return
 			}
 			assert.NoError(t, err)
+			assert.NotEmpty(t, got.AccountNumber)
 		})
 	}
 }
 
+func TestCreateAccount_RetriesDuplicateNumber(t *testing.T) {
+	var tried []string
+	repo := &mockAccountRepository{createFn: func(a *models.Account) error {
+		tried = append(tried, a.AccountNumber)
+		if len(tried) < 3 {
+			return repository.ErrDuplicateAccountNumber
+		}
+		a.AccountID = 7
+		return nil
+	}}
+	money := &mockMoneyConverter{decFn: func(s string) (int64, error) { return 100, nil }}
+
+	svc := newTestAccountService(repo, money)
+	got, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"})
+	assert.NoError(t, err)
+	assert.Len(t, tried, 3)
+	assert.Equal(t, tried[2], got.AccountNumber)
+	assert.Equal(t, 7, got.AccountID)
+}
+
+func TestResolveAccountNumber(t *testing.T) {
+	scheme := util.DefaultAccountNumberScheme()
+	valid, err := scheme.Generate()
+	assert.NoError(t, err)
+
+	repo := &mockAccountRepository{getByNumberFn: func(number string) (*models.Account, error) {
+		if number == valid {
+			return &models.Account{AccountID: 12, AccountNumber: number}, nil
+		}
+		return nil, errors.New("account not found")
+	}}
+	svc := newTestAccountService(repo, &mockMoneyConverter{})
+
+	_, err = svc.ResolveAccountNumber("12")
+	assert.ErrorIs(t, err, util.ErrInvalidAccountNumber)
+
+	// Flip one digit of the account part so only the check digits catch it.
+	last := valid[len(valid)-1]
+	flipped := valid[:len(valid)-1] + string('0'+(last-'0'+1)%10)
+	_, err = svc.ResolveAccountNumber(flipped)
+	assert.ErrorIs(t, err, util.ErrAccountNumberCheckDigits)
+
+	id, err := svc.ResolveAccountNumber(strings.ToLower(valid[:4]) + " " + valid[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, 12, id)
+
+	other, _ := scheme.Generate()
+	if other != valid {
+		_, err = svc.ResolveAccountNumber(other)
+		assert.EqualError(t, err, "account not found")
+	}
+}
+
+func TestCreateAccount_Screening(t *testing.T) {
+	repo := &mockAccountRepository{createFn: func(a *models.Account) error {
+		a.AccountID = 9
+		return nil
+	}}
+	money := &mockMoneyConverter{decFn: func(s string) (int64, error) { return 100, nil }}
+	screener := mockScreener{"Ivan Petrov": {{EntryUID: "42", ListedName: "PETROV, Ivan", Score: 0.97}}}
+
+	t.Run("hit_opens_case", func(t *testing.T) {
+		var created []*models.ScreeningCase
+		cases := &mockScreeningCaseRepo{createTxFn: func(_ *sql.Tx, c *models.ScreeningCase) error {
+			created = append(created, c)
+			return nil
+		}}
+		svc := newTestAccountService(repo, money, WithAccountScreening(screener, cases))
+		_, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Ivan Petrov", InitialBalance: "1.00"})
+		assert.NoError(t, err)
+		if assert.Len(t, created, 1) {
+			assert.Equal(t, 9, created[0].AccountID)
+			assert.Equal(t, "42", created[0].EntryUID)
+			assert.Equal(t, models.ScreeningCaseOpen, created[0].Status)
+			assert.Nil(t, created[0].TransactionID)
+		}
+	})
+
+	t.Run("no_hit", func(t *testing.T) {
+		cases := &mockScreeningCaseRepo{createTxFn: func(_ *sql.Tx, c *models.ScreeningCase) error {
+			t.Fatal("unexpected case")
+			return nil
+		}}
+		svc := newTestAccountService(repo, money, WithAccountScreening(screener, cases))
+		_, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Maria Silva", InitialBalance: "1.00"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("case_error", func(t *testing.T) {
+		cases := &mockScreeningCaseRepo{createTxFn: func(*sql.Tx, *models.ScreeningCase) error { return errors.New("db") }}
+		svc := newTestAccountService(repo, money, WithAccountScreening(screener, cases))
+		_, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Ivan Petrov", InitialBalance: "1.00"})
+		assert.EqualError(t, err, "failed to record screening case")
+	})
+}
+
+// mockScreener returns canned matches keyed by exact holder name.
+type mockScreener map[string][]screening.Match
+
+func (m mockScreener) Screen(name string) []screening.Match { return m[name] }
+
 func TestGetAccount(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -198,7 +343,7 @@ func TestGetAccount(t *testing.T) {
 			id:   33,
 			repo: &mockAccountRepository{
 				getByIDFn: func(id int) (*models.Account, error) {
-					return &models.Account{AccountID: id, CurrentBalance: -123}, nil
+					return &models.Account{AccountID: id, HolderName: "Jane Doe", CurrentBalance: -123}, nil
 				},
 			},
 			money: &mockMoneyConverter{
@@ -207,14 +352,14 @@ func TestGetAccount(t *testing.T) {
 					return "-1.23"
 				},
 			},
-			wantView: &models.AccountView{AccountID: 33, CurrentBalance: "-1.23"},
+			wantView: &models.AccountView{AccountID: 33, HolderName: "Jane Doe", CurrentBalance: "-1.23", Terms: &models.ProductTerms{}},
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewAccountServiceWithDeps(tc.repo, tc.money)
-			got, err := svc.GetAccount(tc.id)
+			svc := newTestAccountService(tc.repo, tc.money)
+			got, err := svc.GetAccount(operatorCtx, tc.id)
 			if tc.wantErr != "" {
 				assert.Nil(t, got)
 				assert.Contains(t, err.Error(), tc.wantErr)
@@ -225,3 +370,243 @@ func TestGetAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAccount_IncludesHolders(t *testing.T) {
+	repo := &mockAccountRepository{getByIDFn: func(id int) (*models.Account, error) {
+		return &models.Account{AccountID: id, HolderName: "Jane Doe", CurrentBalance: 100}, nil
+	}}
+	money := &mockMoneyConverter{fmtFn: func(int64) string { return "1.00" }}
+	holders := []*models.AccountHolder{
+		{AccountID: 5, CustomerID: 1, Name: "Jane Doe", Role: models.HolderRoleOwner},
+		{AccountID: 5, CustomerID: 2, Name: "John Doe", Role: models.HolderRoleJoint},
+	}
+
+	svc := newTestAccountService(repo, money, WithAccountHolders(&mockAccountHolderRepository{
+		listByAccountFn: func(int) ([]*models.AccountHolder, error) { return holders, nil },
+	}, &mockCustomerRepository{}))
+	got, err := svc.GetAccount(operatorCtx, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, holders, got.Holders)
+
+	svc = newTestAccountService(repo, money, WithAccountHolders(&mockAccountHolderRepository{
+		listByAccountFn: func(int) ([]*models.AccountHolder, error) { return nil, errors.New("db") },
+	}, &mockCustomerRepository{}))
+	_, err = svc.GetAccount(operatorCtx, 5)
+	assert.EqualError(t, err, "couldn't get account holders")
+}
+
+func TestAddHolder(t *testing.T) {
+	accounts := &mockAccountRepository{existsFn: func(id int) (bool, error) { return id == 5, nil }}
+	customers := &mockCustomerRepository{getByIDFn: func(id int) (*models.Customer, error) {
+		if id == 1 {
+			return &models.Customer{ID: 1, Name: "Jane Doe"}, nil
+		}
+		return nil, errors.New("customer not found")
+	}}
+
+	cases := []struct {
+		name      string
+		accountID int
+		req       *models.AddAccountHolderRequest
+		addErr    error
+		wantErr   string
+	}{
+		{"invalid_account", 0, &models.AddAccountHolderRequest{CustomerID: 1, Role: models.HolderRoleOwner}, nil, "invalid account_id"},
+		{"invalid_customer", 5, &models.AddAccountHolderRequest{CustomerID: 0, Role: models.HolderRoleOwner}, nil, "invalid customer_id"},
+		{"invalid_role", 5, &models.AddAccountHolderRequest{CustomerID: 1, Role: "boss"}, nil, "invalid role"},
+		{"account_not_found", 6, &models.AddAccountHolderRequest{CustomerID: 1, Role: models.HolderRoleOwner}, nil, "account not found"},
+		{"customer_not_found", 5, &models.AddAccountHolderRequest{CustomerID: 2, Role: models.HolderRoleOwner}, nil, "customer not found"},
+		{"duplicate", 5, &models.AddAccountHolderRequest{CustomerID: 1, Role: models.HolderRoleJoint}, errors.New("customer already holds this account"), "customer already holds this account"},
+		{"success", 5, &models.AddAccountHolderRequest{CustomerID: 1, Role: models.HolderRoleAuthorizedUser}, nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			holders := &mockAccountHolderRepository{addFn: func(*models.AccountHolder) error { return tc.addErr }}
+			svc := newTestAccountService(accounts, nil, WithAccountHolders(holders, customers))
+			got, err := svc.AddHolder(operatorCtx, tc.accountID, tc.req)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &models.AccountHolder{AccountID: 5, CustomerID: 1, Name: "Jane Doe", Role: models.HolderRoleAuthorizedUser}, got)
+		})
+	}
+
+	_, err := newTestAccountService(accounts, nil).AddHolder(operatorCtx, 5, &models.AddAccountHolderRequest{CustomerID: 1, Role: models.HolderRoleOwner})
+	assert.EqualError(t, err, "account holders are not enabled")
+}
+
+func TestRemoveHolder(t *testing.T) {
+	cases := []struct {
+		name       string
+		holders    []*models.AccountHolder
+		customerID int
+		wantErr    string
+	}{
+		{"invalid_customer", nil, 0, "invalid customer_id"},
+		{"not_a_holder", []*models.AccountHolder{{CustomerID: 1, Role: models.HolderRoleOwner}}, 2, "account holder not found"},
+		{"last_owner", []*models.AccountHolder{{CustomerID: 1, Role: models.HolderRoleOwner}, {CustomerID: 2, Role: models.HolderRoleJoint}}, 1, "account must keep at least one owner"},
+		{"one_of_two_owners", []*models.AccountHolder{{CustomerID: 1, Role: models.HolderRoleOwner}, {CustomerID: 2, Role: models.HolderRoleOwner}}, 1, ""},
+		{"joint_holder", []*models.AccountHolder{{CustomerID: 1, Role: models.HolderRoleOwner}, {CustomerID: 2, Role: models.HolderRoleJoint}}, 2, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			removed := false
+			holders := &mockAccountHolderRepository{
+				listByAccountFn: func(int) ([]*models.AccountHolder, error) { return tc.holders, nil },
+				removeFn: func(accountID, customerID int) error {
+					removed = true
+					assert.Equal(t, tc.customerID, customerID)
+					return nil
+				},
+			}
+			svc := newTestAccountService(&mockAccountRepository{}, nil, WithAccountHolders(holders, &mockCustomerRepository{}))
+			err := svc.RemoveHolder(operatorCtx, 5, tc.customerID)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				assert.False(t, removed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, removed)
+		})
+	}
+}
+
+// mockActivityRepository keeps the feed in memory, numbering entries from 1.
+type mockActivityRepository struct {
+	mu      sync.Mutex
+	entries []*models.AccountActivity
+}
+
+func (m *mockActivityRepository) AppendTx(tx *sql.Tx, a *models.AccountActivity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a.ID = int64(len(m.entries) + 1)
+	c := *a
+	m.entries = append(m.entries, &c)
+	return nil
+}
+
+func (m *mockActivityRepository) ListAfter(accountID int, afterID int64, limit int) ([]*models.AccountActivity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*models.AccountActivity
+	for _, a := range m.entries {
+		if a.AccountID == accountID && a.ID > afterID && len(list) < limit {
+			list = append(list, a)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockActivityRepository) LatestID(accountID int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var id int64
+	for _, a := range m.entries {
+		if a.AccountID == accountID {
+			id = a.ID
+		}
+	}
+	return id, nil
+}
+
+func (m *mockActivityRepository) Prune(olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockActivityRepository) add(accountID int, typ string) {
+	_ = m.AppendTx(nil, &models.AccountActivity{AccountID: accountID, Type: typ})
+}
+
+func activityIDs(list []*models.AccountActivity) []int64 {
+	ids := []int64{}
+	for _, a := range list {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func TestStreamActivity_Authorization(t *testing.T) {
+	activity := &mockActivityRepository{}
+	s := newTestAccountService(&mockAccountRepository{}, nil,
+		WithAccountPolicy(NewRolePolicy(testHolders())), WithAccountActivity(activity, events.NewActivityHub()))
+
+	stream, err := s.StreamActivity(ownerCtx, 100, 0)
+	if assert.NoError(t, err) {
+		stream.Close()
+	}
+	_, err = s.StreamActivity(strangerCtx, 100, 0)
+	assert.Equal(t, ErrForbidden, err)
+	_, err = s.StreamActivity(context.Background(), 100, 0)
+	assert.Equal(t, ErrUnauthenticated, err)
+	_, err = s.StreamActivity(operatorCtx, 100, -1)
+	assert.EqualError(t, err, "invalid Last-Event-ID")
+
+	_, err = newTestAccountService(&mockAccountRepository{}, nil).StreamActivity(operatorCtx, 100, 0)
+	assert.EqualError(t, err, "account activity is not enabled")
+}
+
+func TestStreamActivity_ResumesAfterLastEventID(t *testing.T) {
+	activity := &mockActivityRepository{}
+	activity.add(100, models.ActivityTransfer) // 1
+	activity.add(200, models.ActivityTransfer) // 2, another account
+	activity.add(100, models.ActivityTransfer) // 3
+	activity.add(100, models.ActivityBalance)  // 4
+	s := newTestAccountService(&mockAccountRepository{}, nil, WithAccountActivity(activity, events.NewActivityHub()))
+
+	stream, err := s.StreamActivity(operatorCtx, 100, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stream.Close()
+
+	got, err := stream.Next(context.Background(), time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, activityIDs(got))
+
+	got, err = stream.Next(context.Background(), time.Millisecond)
+	assert.NoError(t, err)
+	assert.Empty(t, got, "nothing new, so the wait times out")
+}
+
+func TestStreamActivity_StartsWithNextChange(t *testing.T) {
+	activity := &mockActivityRepository{}
+	activity.add(100, models.ActivityTransfer)
+	hub := events.NewActivityHub()
+	s := newTestAccountService(&mockAccountRepository{}, nil, WithAccountActivity(activity, hub))
+
+	stream, err := s.StreamActivity(operatorCtx, 100, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stream.Close()
+
+	type result struct {
+		list []*models.AccountActivity
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		list, err := stream.Next(context.Background(), time.Minute)
+		done <- result{list, err}
+	}()
+
+	activity.add(100, models.ActivityBalance)
+	hub.Notify(100)
+
+	select {
+	case r := <-done:
+		assert.NoError(t, r.err)
+		assert.Equal(t, []int64{2}, activityIDs(r.list))
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not woken by the notification")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stream.Next(ctx, time.Minute)
+	assert.ErrorIs(t, err, context.Canceled)
+}
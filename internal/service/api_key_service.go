@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"strings"
+)
+
+func NewAPIKeyService(db *sql.DB, apiKeyRepo repository.APIKeyRepository, opts ...func(*APIKeyService)) *APIKeyService {
+	s := &APIKeyService{
+		db:         db,
+		apiKeyRepo: apiKeyRepo,
+		generateFn: auth.GenerateAPIKey,
+		policy:     NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithAPIKeyPolicy sets the authorization policy.
+func WithAPIKeyPolicy(policy Policy) func(*APIKeyService) {
+	return func(s *APIKeyService) {
+		s.policy = policy
+	}
+}
+
+// WithAPIKeyAuditLog records issued, rotated and revoked keys in the audit
+// log. Key hashes are never logged.
+func WithAPIKeyAuditLog(auditLog repository.AuditLogRepository) func(*APIKeyService) {
+	return func(s *APIKeyService) {
+		s.auditLog = auditLog
+	}
+}
+
+type APIKeyService struct {
+	db         *sql.DB
+	apiKeyRepo repository.APIKeyRepository
+	generateFn func() (key, prefix, hash string, err error)
+	auditLog   repository.AuditLogRepository
+	policy     Policy
+	beginFn    func() (*sql.Tx, error)
+	rollbackFn func(*sql.Tx) error
+	commitFn   func(*sql.Tx) error
+}
+
+// CreateAPIKey issues a new key. The plaintext is only in the returned value.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error) {
+	if err := authorize(ctx, s.policy, ActionManageAPIKeys, Resource{}); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if !auth.ValidRole(req.Role) {
+		return nil, errors.New("invalid role")
+	}
+	if req.Role == auth.RoleCustomer {
+		if req.CustomerID == nil || *req.CustomerID <= 0 {
+			return nil, errors.New("customer_id is required for the customer role")
+		}
+	} else if req.CustomerID != nil {
+		return nil, errors.New("customer_id is only allowed for the customer role")
+	}
+
+	key, prefix, hash, err := s.generateFn()
+	if err != nil {
+		return nil, errors.New("couldn't generate api key")
+	}
+
+	k := &models.APIKey{Name: name, Role: req.Role, CustomerID: req.CustomerID, Prefix: prefix, Hash: hash}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.apiKeyRepo.CreateTx(tx, k); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionAPIKeyCreate, audit.EntityAPIKey, k.ID, nil, k); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return &models.IssuedAPIKey{APIKey: k, Key: key}, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	if err := authorize(ctx, s.policy, ActionReadAPIKeys, Resource{}); err != nil {
+		return nil, err
+	}
+
+	list, err := s.apiKeyRepo.List()
+	if err != nil {
+		return nil, errors.New("couldn't list api keys")
+	}
+	return list, nil
+}
+
+// RotateAPIKey replaces an active key's secret, invalidating the old one.
+func (s *APIKeyService) RotateAPIKey(ctx context.Context, id int) (*models.IssuedAPIKey, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid api_key_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageAPIKeys, Resource{}); err != nil {
+		return nil, err
+	}
+
+	key, prefix, hash, err := s.generateFn()
+	if err != nil {
+		return nil, errors.New("couldn't generate api key")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	before, err := s.apiKeyRepo.SelectTx(tx, id)
+	if err != nil {
+		return nil, errors.New("active api key not found")
+	}
+	k, err := s.apiKeyRepo.RotateTx(tx, id, prefix, hash)
+	if err != nil {
+		return nil, errors.New("active api key not found")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionAPIKeyRotate, audit.EntityAPIKey, id, before, k); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return &models.IssuedAPIKey{APIKey: k, Key: key}, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid api_key_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageAPIKeys, Resource{}); err != nil {
+		return err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	before, err := s.apiKeyRepo.SelectTx(tx, id)
+	if err != nil {
+		return errors.New("active api key not found")
+	}
+	k, err := s.apiKeyRepo.RevokeTx(tx, id)
+	if err != nil {
+		return errors.New("active api key not found")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionAPIKeyRevoke, audit.EntityAPIKey, id, before, k); err != nil {
+		return err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
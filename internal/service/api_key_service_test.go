@@ -0,0 +1,156 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPIKeyRepository struct {
+	createFn func(*models.APIKey) error
+	listFn   func() ([]*models.APIKey, error)
+	rotateFn func(int, string, string) (*models.APIKey, error)
+	revokeFn func(int) (*models.APIKey, error)
+}
+
+func (m *mockAPIKeyRepository) CreateTx(tx *sql.Tx, k *models.APIKey) error {
+	if m.createFn != nil {
+		return m.createFn(k)
+	}
+	return nil
+}
+
+func (m *mockAPIKeyRepository) GetByPrefix(prefix string) (*models.APIKey, error) {
+	return nil, errors.New("api key not found")
+}
+
+func (m *mockAPIKeyRepository) List() ([]*models.APIKey, error) {
+	if m.listFn != nil {
+		return m.listFn()
+	}
+	return nil, nil
+}
+
+func (m *mockAPIKeyRepository) SelectTx(tx *sql.Tx, id int) (*models.APIKey, error) {
+	return &models.APIKey{ID: id}, nil
+}
+
+func (m *mockAPIKeyRepository) RotateTx(tx *sql.Tx, id int, prefix, hash string) (*models.APIKey, error) {
+	if m.rotateFn != nil {
+		return m.rotateFn(id, prefix, hash)
+	}
+	return nil, nil
+}
+
+func (m *mockAPIKeyRepository) RevokeTx(tx *sql.Tx, id int) (*models.APIKey, error) {
+	if m.revokeFn != nil {
+		return m.revokeFn(id)
+	}
+	return nil, nil
+}
+
+func fixedKeyGenerator() (string, string, string, error) {
+	return "ffk_abcdef012345_secret", "abcdef012345", "hash", nil
+}
+
+// newTestAPIKeyService stubs out the DB transaction functions.
+func newTestAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	s := NewAPIKeyService(&sql.DB{}, repo)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	customerID := 7
+	cases := []struct {
+		name    string
+		req     *models.CreateAPIKeyRequest
+		repo    *mockAPIKeyRepository
+		gen     func() (string, string, string, error)
+		wantErr string
+	}{
+		{name: "missing_name", req: &models.CreateAPIKeyRequest{Name: " "}, repo: &mockAPIKeyRepository{}, gen: fixedKeyGenerator, wantErr: "name is required"},
+		{name: "invalid_role", req: &models.CreateAPIKeyRequest{Name: "ops", Role: "root"}, repo: &mockAPIKeyRepository{}, gen: fixedKeyGenerator, wantErr: "invalid role"},
+		{name: "customer_without_customer_id", req: &models.CreateAPIKeyRequest{Name: "app", Role: auth.RoleCustomer}, repo: &mockAPIKeyRepository{}, gen: fixedKeyGenerator, wantErr: "customer_id is required for the customer role"},
+		{name: "operator_with_customer_id", req: &models.CreateAPIKeyRequest{Name: "ops", Role: auth.RoleOperator, CustomerID: &customerID}, repo: &mockAPIKeyRepository{}, gen: fixedKeyGenerator, wantErr: "customer_id is only allowed for the customer role"},
+		{
+			name: "generate_error", req: &models.CreateAPIKeyRequest{Name: "ops", Role: auth.RoleOperator}, repo: &mockAPIKeyRepository{},
+			gen:     func() (string, string, string, error) { return "", "", "", errors.New("entropy") },
+			wantErr: "couldn't generate api key",
+		},
+		{
+			name: "duplicate_name", req: &models.CreateAPIKeyRequest{Name: "ops", Role: auth.RoleOperator}, gen: fixedKeyGenerator,
+			repo:    &mockAPIKeyRepository{createFn: func(*models.APIKey) error { return errors.New("api key name already in use") }},
+			wantErr: "api key name already in use",
+		},
+		{
+			name: "success", req: &models.CreateAPIKeyRequest{Name: " ops ", Role: auth.RoleOperator}, gen: fixedKeyGenerator,
+			repo: &mockAPIKeyRepository{createFn: func(k *models.APIKey) error {
+				assert.Equal(t, "ops", k.Name)
+				assert.Equal(t, auth.RoleOperator, k.Role)
+				assert.Equal(t, "abcdef012345", k.Prefix)
+				assert.Equal(t, "hash", k.Hash)
+				k.ID = 3
+				return nil
+			}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newTestAPIKeyService(tc.repo)
+			svc.generateFn = tc.gen
+			got, err := svc.CreateAPIKey(adminCtx, tc.req)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 3, got.ID)
+			assert.Equal(t, "ffk_abcdef012345_secret", got.Key)
+		})
+	}
+}
+
+func TestRotateAndRevokeAPIKey(t *testing.T) {
+	repo := &mockAPIKeyRepository{
+		rotateFn: func(id int, prefix, hash string) (*models.APIKey, error) {
+			if id != 3 {
+				return nil, errors.New("api key not found")
+			}
+			return &models.APIKey{ID: id, Prefix: prefix, Hash: hash}, nil
+		},
+		revokeFn: func(id int) (*models.APIKey, error) {
+			if id != 3 {
+				return nil, errors.New("api key not found")
+			}
+			return &models.APIKey{ID: id}, nil
+		},
+	}
+	svc := newTestAPIKeyService(repo)
+	svc.generateFn = fixedKeyGenerator
+
+	_, err := svc.RotateAPIKey(adminCtx, 0)
+	assert.EqualError(t, err, "invalid api_key_id")
+	_, err = svc.RotateAPIKey(adminCtx, 4)
+	assert.EqualError(t, err, "active api key not found")
+	got, err := svc.RotateAPIKey(adminCtx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "ffk_abcdef012345_secret", got.Key)
+	assert.Equal(t, "abcdef012345", got.Prefix)
+
+	assert.EqualError(t, svc.RevokeAPIKey(adminCtx, 0), "invalid api_key_id")
+	assert.EqualError(t, svc.RevokeAPIKey(adminCtx, 4), "active api key not found")
+	assert.NoError(t, svc.RevokeAPIKey(adminCtx, 3))
+
+	// Operators can't manage keys; auditors can only list them
+	assert.Equal(t, ErrForbidden, svc.RevokeAPIKey(operatorCtx, 3))
+	_, err = svc.ListAPIKeys(auditorCtx)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/repository"
+)
+
+// recordAudit appends an audit log entry for a change made in tx, so the
+// entry commits or rolls back with the change. Call it after the last write
+// of the transaction: appending takes the log's lock until commit. It does
+// nothing when the service has no audit log.
+func recordAudit(ctx context.Context, tx *sql.Tx, log repository.AuditLogRepository, action, entityType string, entityID, before, after any) error {
+	if log == nil {
+		return nil
+	}
+	e, err := audit.NewEntry(ctx, action, entityType, entityID, before, after)
+	if err == nil {
+		err = log.AppendTx(tx, e)
+	}
+	if err != nil {
+		return internalError("couldn't write audit log")
+	}
+	return nil
+}
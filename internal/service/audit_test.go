@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAuditLog collects appended entries and the transaction they were
+// appended in.
+type mockAuditLog struct {
+	entries []*models.AuditEntry
+	txs     []*sql.Tx
+	err     error
+}
+
+func (m *mockAuditLog) AppendTx(tx *sql.Tx, e *models.AuditEntry) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, e)
+	m.txs = append(m.txs, tx)
+	return nil
+}
+
+func (m *mockAuditLog) ListAfter(afterID int64, limit int) ([]*models.AuditEntry, error) {
+	return nil, nil
+}
+
+func (m *mockAuditLog) actions() []string {
+	var list []string
+	for _, e := range m.entries {
+		list = append(list, e.Action)
+	}
+	return list
+}
+
+func TestAudit_CreateAccount(t *testing.T) {
+	log := &mockAuditLog{}
+	repo := &mockAccountRepository{createFn: func(a *models.Account) error {
+		a.AccountID = 9
+		return nil
+	}}
+	money := &mockMoneyConverter{decFn: func(string) (int64, error) { return 100, nil }}
+	svc := newTestAccountService(repo, money, WithAccountAuditLog(log))
+
+	var committed bool
+	tx := &sql.Tx{}
+	svc.beginFn = func() (*sql.Tx, error) { return tx, nil }
+	svc.commitFn = func(*sql.Tx) error {
+		committed = true
+		return nil
+	}
+
+	_, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"})
+	assert.NoError(t, err)
+	assert.True(t, committed)
+	if assert.Len(t, log.entries, 1) {
+		e := log.entries[0]
+		assert.Same(t, tx, log.txs[0])
+		assert.Equal(t, audit.ActionAccountCreate, e.Action)
+		assert.Equal(t, "9", e.EntityID)
+		assert.Equal(t, "ops", e.Actor)
+		assert.Equal(t, "operator", e.ActorRole)
+		assert.Nil(t, e.Before)
+		assert.Contains(t, string(e.After), `"current_balance":100`)
+	}
+}
+
+func TestAudit_FailureAbortsChange(t *testing.T) {
+	log := &mockAuditLog{err: errors.New("db")}
+	repo := &mockAccountRepository{createFn: func(a *models.Account) error { return nil }}
+	money := &mockMoneyConverter{decFn: func(string) (int64, error) { return 100, nil }}
+	svc := newTestAccountService(repo, money, WithAccountAuditLog(log))
+	svc.commitFn = func(*sql.Tx) error {
+		t.Fatal("committed without an audit entry")
+		return nil
+	}
+
+	_, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"})
+	assert.EqualError(t, err, "couldn't write audit log")
+}
+
+func TestAudit_Transfer(t *testing.T) {
+	log := &mockAuditLog{}
+	accountRepo := &mockAccountRepo{SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+		return &models.Account{AccountID: id, CurrentBalance: 1000}, nil
+	}}
+	transactionRepo := &mockTransactionRepo{CreateTxFunc: func(tx *sql.Tx, t *models.Transaction) error {
+		t.ID = 31
+		return nil
+	}}
+	money := &transactionMockMoneyConverter{decFn: func(string) (int64, error) { return 250, nil }}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money, WithTransferAuditLog(log))
+	setTxnFns(ts)
+
+	_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.50"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{audit.ActionTransactionCreate, audit.ActionAccountDebit, audit.ActionAccountCredit}, log.actions())
+	if len(log.entries) == 3 {
+		debit, credit := log.entries[1], log.entries[2]
+		assert.Equal(t, "1", debit.EntityID)
+		assert.JSONEq(t, `{"balance":1000}`, string(debit.Before))
+		assert.JSONEq(t, `{"balance":750,"transaction_id":31}`, string(debit.After))
+		assert.Equal(t, "2", credit.EntityID)
+		assert.JSONEq(t, `{"balance":1000}`, string(credit.Before))
+		assert.JSONEq(t, `{"balance":1250,"transaction_id":31}`, string(credit.After))
+	}
+}
+
+func TestAudit_ReviewRecordsBeforeAndAfter(t *testing.T) {
+	log := &mockAuditLog{}
+	transactionRepo := &mockTransactionRepo{SelectTxFunc: func(tx *sql.Tx, id int) (*models.Transaction, error) {
+		return &models.Transaction{ID: id, AmountPennies: 100, Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice"}, nil
+	}}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, &mockAccountRepo{}, transactionRepo, nil, WithTransferAuditLog(log))
+	setTxnFns(ts)
+
+	_, err := ts.RejectTransaction(operatorCtx, 8, "bob", "not expected")
+	assert.NoError(t, err)
+	if assert.Len(t, log.entries, 1) {
+		e := log.entries[0]
+		assert.Equal(t, audit.ActionTransactionReject, e.Action)
+		assert.Contains(t, string(e.Before), `"status":"pending_approval"`)
+		assert.Contains(t, string(e.After), `"status":"rejected"`)
+		assert.Contains(t, string(e.After), `"reviewed_by":"bob"`)
+	}
+}
+
+func TestAudit_ExpiryIsRecordedAsSystem(t *testing.T) {
+	log := &mockAuditLog{}
+	transactionRepo := &mockTransactionRepo{ExpirePendingFunc: func(*sql.Tx, time.Time) ([]*models.Transaction, error) {
+		return []*models.Transaction{
+			{ID: 4, Status: models.TransactionStatusExpired},
+			{ID: 5, Status: models.TransactionStatusExpired},
+		}, nil
+	}}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, &mockAccountRepo{}, transactionRepo, nil, WithTransferAuditLog(log))
+	setTxnFns(ts)
+
+	_, err := ts.ExpirePendingApprovals(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, log.entries, 2) {
+		assert.Equal(t, audit.SystemActor, log.entries[0].Actor)
+		assert.Equal(t, "4", log.entries[0].EntityID)
+		assert.Contains(t, string(log.entries[1].Before), `"status":"pending_approval"`)
+		assert.Contains(t, string(log.entries[1].After), `"status":"expired"`)
+	}
+}
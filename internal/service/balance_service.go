@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"log"
+	"time"
+)
+
+// snapshotDelay is how long after a snapshot's time it is taken. A transfer
+// is stamped with its completion time before it commits, so snapshotting at
+// once could miss one completing at that instant.
+const snapshotDelay = time.Minute
+
+func NewBalanceService(balanceRepo repository.BalanceRepository, opts ...func(*BalanceService)) *BalanceService {
+	s := &BalanceService{
+		balanceRepo: balanceRepo,
+		policy:      NewRolePolicy(nil),
+		nowFn:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithBalancePolicy sets the authorization policy.
+func WithBalancePolicy(policy Policy) func(*BalanceService) {
+	return func(s *BalanceService) {
+		s.policy = policy
+	}
+}
+
+// BalanceService answers what accounts held at a past time, e.g. at
+// month-end. Only the current balance is stored, so past balances come from
+// the transaction history, starting from the periodic snapshots Run takes.
+type BalanceService struct {
+	balanceRepo repository.BalanceRepository
+	policy      Policy
+	nowFn       func() time.Time
+}
+
+// BalanceAt returns the account's balance at asOf: every transfer completed
+// before it is counted. asOf may not be in the future.
+func (s *BalanceService) BalanceAt(ctx context.Context, accountID int, asOf time.Time) (*models.AccountBalance, error) {
+	if accountID <= 0 {
+		return nil, errors.New("invalid account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadAccount, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return nil, err
+	}
+	if asOf.After(s.nowFn()) {
+		return nil, errors.New("as_of is in the future")
+	}
+
+	balance, err := s.balanceRepo.BalanceAt(accountID, asOf)
+	if errors.Is(err, repository.ErrAccountNotOpen) {
+		return nil, errors.New("account was opened after as_of")
+	}
+	if err != nil {
+		return nil, errors.New("couldn't compute balance")
+	}
+	return withAsOf(balance, asOf), nil
+}
+
+// ListBalancesAt returns the balance at asOf of every account open then.
+func (s *BalanceService) ListBalancesAt(ctx context.Context, asOf time.Time) ([]*models.AccountBalance, error) {
+	if err := authorize(ctx, s.policy, ActionListBalances, Resource{}); err != nil {
+		return nil, err
+	}
+	if asOf.After(s.nowFn()) {
+		return nil, errors.New("as_of is in the future")
+	}
+
+	list, err := s.balanceRepo.ListBalancesAt(asOf)
+	if err != nil {
+		return nil, errors.New("couldn't compute balances")
+	}
+	if list == nil {
+		list = []*models.AccountBalance{}
+	}
+	for _, b := range list {
+		withAsOf(b, asOf)
+	}
+	return list, nil
+}
+
+func withAsOf(b *models.AccountBalance, asOf time.Time) *models.AccountBalance {
+	b.AsOf = asOf.UTC().Format(time.RFC3339)
+	b.Balance = util.PenniesToDecimalString(b.BalancePennies)
+	return b
+}
+
+// Run snapshots every account's balance at each whole interval, e.g. at
+// midnight UTC for 24h, until ctx is done. The latest snapshot is taken at
+// start if it is missing.
+func (s *BalanceService) Run(ctx context.Context, interval time.Duration) {
+	at := s.nowFn().Add(-snapshotDelay).Truncate(interval)
+	for {
+		if n, err := s.balanceRepo.CreateSnapshots(at); err != nil {
+			log.Print("failed to snapshot balances:", err)
+		} else if n > 0 {
+			log.Printf("Snapshotted %d balances at %s", n, at.UTC().Format(time.RFC3339))
+		}
+
+		at = at.Add(interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(at.Add(snapshotDelay).Sub(s.nowFn())):
+		}
+	}
+}
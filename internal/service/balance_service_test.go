@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBalanceRepository struct {
+	balances  map[int]int64
+	openedAt  time.Time
+	snapshots []time.Time
+}
+
+func (m *mockBalanceRepository) BalanceAt(accountID int, at time.Time) (*models.AccountBalance, error) {
+	balance, ok := m.balances[accountID]
+	if !ok {
+		return nil, errors.New("account not found")
+	}
+	if at.Before(m.openedAt) {
+		return nil, repository.ErrAccountNotOpen
+	}
+	return &models.AccountBalance{AccountID: accountID, AccountNumber: "A", BalancePennies: balance}, nil
+}
+
+func (m *mockBalanceRepository) ListBalancesAt(at time.Time) ([]*models.AccountBalance, error) {
+	if at.Before(m.openedAt) {
+		return nil, nil
+	}
+	return []*models.AccountBalance{{AccountID: 100, AccountNumber: "A", BalancePennies: m.balances[100]}}, nil
+}
+
+func (m *mockBalanceRepository) CreateSnapshots(at time.Time) (int64, error) {
+	m.snapshots = append(m.snapshots, at)
+	return int64(len(m.balances)), nil
+}
+
+var testBalanceNow = time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC)
+
+func newTestBalanceService(repo *mockBalanceRepository) *BalanceService {
+	s := NewBalanceService(repo)
+	s.nowFn = func() time.Time { return testBalanceNow }
+	return s
+}
+
+func TestBalanceAt(t *testing.T) {
+	repo := &mockBalanceRepository{balances: map[int]int64{100: -1250}, openedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := newTestBalanceService(repo)
+	monthEnd := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	b, err := s.BalanceAt(operatorCtx, 100, monthEnd)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2024-02-01T00:00:00Z", b.AsOf)
+		assert.Equal(t, "-12.50", b.Balance)
+		assert.Equal(t, int64(-1250), b.BalancePennies)
+	}
+
+	_, err = s.BalanceAt(operatorCtx, 100, time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.EqualError(t, err, "account was opened after as_of")
+	_, err = s.BalanceAt(operatorCtx, 100, testBalanceNow.Add(time.Second))
+	assert.EqualError(t, err, "as_of is in the future")
+	_, err = s.BalanceAt(operatorCtx, 200, monthEnd)
+	assert.EqualError(t, err, "couldn't compute balance")
+	_, err = s.BalanceAt(operatorCtx, 0, monthEnd)
+	assert.EqualError(t, err, "invalid account_id")
+	_, err = s.BalanceAt(strangerCtx, 100, monthEnd)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestListBalancesAt(t *testing.T) {
+	repo := &mockBalanceRepository{balances: map[int]int64{100: 500}, openedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := newTestBalanceService(repo)
+
+	list, err := s.ListBalancesAt(auditorCtx, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if assert.NoError(t, err) && assert.Len(t, list, 1) {
+		assert.Equal(t, "5.00", list[0].Balance)
+		assert.Equal(t, "2024-02-01T00:00:00Z", list[0].AsOf)
+	}
+
+	list, err = s.ListBalancesAt(auditorCtx, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.NotNil(t, list)
+	assert.Empty(t, list)
+
+	_, err = s.ListBalancesAt(ownerCtx, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestBalanceSnapshots(t *testing.T) {
+	repo := &mockBalanceRepository{balances: map[int]int64{100: 500}}
+	s := newTestBalanceService(repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.Run(ctx, 24*time.Hour)
+	assert.Equal(t, []time.Time{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, repo.snapshots)
+
+	// Just after midnight the previous day's snapshot is taken until the
+	// delay has passed.
+	repo.snapshots = nil
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 2, 0, 0, 30, 0, time.UTC) }
+	s.Run(ctx, 24*time.Hour)
+	assert.Equal(t, []time.Time{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, repo.snapshots)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/calendar"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fmt"
+	"log"
+	"time"
+)
+
+// closeDelay is how long after a cut-off the scheduled close runs, so
+// transfers that started just before it have finished.
+const closeDelay = time.Minute
+
+func NewBusinessDayService(db *sql.DB, dayRepo repository.BusinessDayRepository, cal *calendar.Calendar, opts ...func(*BusinessDayService)) *BusinessDayService {
+	s := &BusinessDayService{
+		db:       db,
+		dayRepo:  dayRepo,
+		calendar: cal,
+		policy:   NewRolePolicy(nil),
+		nowFn:    time.Now,
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithBusinessDayPolicy sets the authorization policy.
+func WithBusinessDayPolicy(policy Policy) func(*BusinessDayService) {
+	return func(s *BusinessDayService) {
+		s.policy = policy
+	}
+}
+
+// WithBusinessDayAuditLog records closes in the audit log.
+func WithBusinessDayAuditLog(auditLog repository.AuditLogRepository) func(*BusinessDayService) {
+	return func(s *BusinessDayService) {
+		s.auditLog = auditLog
+	}
+}
+
+// BusinessDayService runs the end-of-day close. Closing a business day
+// records every account's balances and movements over it and freezes it:
+// from then on no transfer can complete, or change, inside it. Days are
+// closed in calendar order, each once its cut-off has passed.
+type BusinessDayService struct {
+	db         *sql.DB
+	dayRepo    repository.BusinessDayRepository
+	calendar   *calendar.Calendar
+	auditLog   repository.AuditLogRepository
+	policy     Policy
+	nowFn      func() time.Time
+	beginFn    func() (*sql.Tx, error)
+	rollbackFn func(*sql.Tx) error
+	commitFn   func(*sql.Tx) error
+}
+
+// CloseDay closes business day d. It must have ended, and the business day
+// before it must be closed unless no day has been closed yet.
+func (s *BusinessDayService) CloseDay(ctx context.Context, d time.Time) (*models.BusinessDay, error) {
+	if err := authorize(ctx, s.policy, ActionCloseBusinessDay, Resource{}); err != nil {
+		return nil, err
+	}
+	closedBy := audit.SystemActor
+	if p, ok := auth.FromContext(ctx); ok {
+		closedBy = p.Subject
+	}
+	return s.closeDay(ctx, calendar.Day(d), closedBy)
+}
+
+func (s *BusinessDayService) closeDay(ctx context.Context, d time.Time, closedBy string) (*models.BusinessDay, error) {
+	day := d.Format(calendar.DateLayout)
+	if !s.calendar.IsBusinessDay(d) {
+		return nil, fmt.Errorf("%s is not a business day", day)
+	}
+	end := s.calendar.End(d)
+	if s.nowFn().Before(end) {
+		return nil, fmt.Errorf("business day %s hasn't ended yet", day)
+	}
+	last, err := s.dayRepo.LastClosed()
+	if err != nil {
+		return nil, errors.New("couldn't read closed business days")
+	}
+	if last != nil {
+		if last.Day >= day {
+			return nil, repository.ErrBusinessDayAlreadyClosed
+		}
+		if prev := s.calendar.Previous(d).Format(calendar.DateLayout); last.Day != prev {
+			return nil, fmt.Errorf("business day %s must be closed first", prev)
+		}
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	closed, err := s.dayRepo.CloseTx(tx, day, s.calendar.Start(d), end, closedBy)
+	if errors.Is(err, repository.ErrBusinessDayAlreadyClosed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("couldn't close business day")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionBusinessDayClose, audit.EntityBusinessDay, day, nil, closed); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return closed, nil
+}
+
+// CloseDue closes every business day that ended, at least closeDelay ago,
+// since the last one closed, oldest first, and returns them. Before the
+// first close only the latest ended day is closed.
+func (s *BusinessDayService) CloseDue(ctx context.Context) ([]*models.BusinessDay, error) {
+	target := s.calendar.LastEnded(s.nowFn().Add(-closeDelay))
+	last, err := s.dayRepo.LastClosed()
+	if err != nil {
+		return nil, errors.New("couldn't read closed business days")
+	}
+	d := target
+	if last != nil {
+		lastDay, err := time.Parse(calendar.DateLayout, last.Day)
+		if err != nil {
+			return nil, err
+		}
+		d = s.calendar.Next(lastDay)
+	}
+
+	var closed []*models.BusinessDay
+	for ; !d.After(target); d = s.calendar.Next(d) {
+		day, err := s.closeDay(ctx, d, audit.SystemActor)
+		if err != nil {
+			return closed, err
+		}
+		closed = append(closed, day)
+	}
+	return closed, nil
+}
+
+// GetBusinessDay returns a closed business day with its totals.
+func (s *BusinessDayService) GetBusinessDay(ctx context.Context, d time.Time) (*models.BusinessDay, error) {
+	if err := authorize(ctx, s.policy, ActionReadBusinessDays, Resource{}); err != nil {
+		return nil, err
+	}
+	day, err := s.dayRepo.Get(calendar.Day(d).Format(calendar.DateLayout))
+	if errors.Is(err, repository.ErrBusinessDayNotClosed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("couldn't get business day")
+	}
+	return day, nil
+}
+
+// ListDailyBalances returns every account's balances over a closed business
+// day.
+func (s *BusinessDayService) ListDailyBalances(ctx context.Context, d time.Time) ([]*models.DailyBalance, error) {
+	if _, err := s.GetBusinessDay(ctx, d); err != nil {
+		return nil, err
+	}
+	list, err := s.dayRepo.ListDailyBalances(calendar.Day(d).Format(calendar.DateLayout))
+	if err != nil {
+		return nil, errors.New("couldn't list daily balances")
+	}
+	if list == nil {
+		list = []*models.DailyBalance{}
+	}
+	return list, nil
+}
+
+// Run closes the business days due at start and then after each cut-off,
+// until ctx is done.
+func (s *BusinessDayService) Run(ctx context.Context) {
+	for {
+		closed, err := s.CloseDue(ctx)
+		for _, d := range closed {
+			log.Printf("Closed business day %s: %d transfers", d.Day, d.TransferCount)
+		}
+		if err != nil {
+			log.Print("failed to close business day:", err)
+		}
+
+		now := s.nowFn()
+		next := s.calendar.End(s.calendar.DayOf(now)).Add(closeDelay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+		}
+	}
+}
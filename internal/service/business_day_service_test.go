@@ -0,0 +1,154 @@
+package service
+
+import (
+	"database/sql"
+	"fastfunds/internal/calendar"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBusinessDayRepository struct {
+	closed []*models.BusinessDay
+	starts []time.Time
+	ends   []time.Time
+}
+
+func (m *mockBusinessDayRepository) Get(day string) (*models.BusinessDay, error) {
+	for _, d := range m.closed {
+		if d.Day == day {
+			return d, nil
+		}
+	}
+	return nil, repository.ErrBusinessDayNotClosed
+}
+
+func (m *mockBusinessDayRepository) LastClosed() (*models.BusinessDay, error) {
+	if len(m.closed) == 0 {
+		return nil, nil
+	}
+	return m.closed[len(m.closed)-1], nil
+}
+
+func (m *mockBusinessDayRepository) CloseTx(tx *sql.Tx, day string, start, end time.Time, closedBy string) (*models.BusinessDay, error) {
+	d := &models.BusinessDay{Day: day, ClosedBy: closedBy, TransferCount: 2}
+	m.closed = append(m.closed, d)
+	m.starts = append(m.starts, start)
+	m.ends = append(m.ends, end)
+	return d, nil
+}
+
+func (m *mockBusinessDayRepository) ListDailyBalances(day string) ([]*models.DailyBalance, error) {
+	return []*models.DailyBalance{{Day: day, AccountNumber: "A", OpeningBalance: 100, Credits: 50, CreditCount: 1, ClosingBalance: 150}}, nil
+}
+
+// Friday 2024-03-01 is followed by a weekend and a holiday on Monday
+// 2024-03-04; testBusinessDayNow is Wednesday after the cut-off.
+var testBusinessDayNow = time.Date(2024, 3, 6, 17, 30, 0, 0, time.UTC)
+
+func newTestBusinessDayService(repo *mockBusinessDayRepository) *BusinessDayService {
+	cal := calendar.New(17*time.Hour, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC))
+	s := NewBusinessDayService(nil, repo, cal)
+	s.nowFn = func() time.Time { return testBusinessDayNow }
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+func testDay(s string) time.Time {
+	d, _ := time.Parse(calendar.DateLayout, s)
+	return d
+}
+
+func TestCloseDay(t *testing.T) {
+	repo := &mockBusinessDayRepository{}
+	s := newTestBusinessDayService(repo)
+
+	closed, err := s.CloseDay(operatorCtx, testDay("2024-03-01"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2024-03-01", closed.Day)
+		assert.Equal(t, "ops", closed.ClosedBy)
+		assert.Equal(t, time.Date(2024, 2, 29, 17, 0, 0, 0, time.UTC), repo.starts[0])
+		assert.Equal(t, time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC), repo.ends[0])
+	}
+
+	// Tuesday covers the weekend and the holiday.
+	_, err = s.CloseDay(operatorCtx, testDay("2024-03-05"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC), repo.starts[1])
+	}
+
+	_, err = s.CloseDay(operatorCtx, testDay("2024-03-05"))
+	assert.ErrorIs(t, err, repository.ErrBusinessDayAlreadyClosed)
+	_, err = s.CloseDay(operatorCtx, testDay("2024-03-01"))
+	assert.ErrorIs(t, err, repository.ErrBusinessDayAlreadyClosed)
+	_, err = s.CloseDay(operatorCtx, testDay("2024-03-04"))
+	assert.EqualError(t, err, "2024-03-04 is not a business day")
+	_, err = s.CloseDay(operatorCtx, testDay("2024-03-07"))
+	assert.EqualError(t, err, "business day 2024-03-07 hasn't ended yet")
+	_, err = s.CloseDay(auditorCtx, testDay("2024-03-06"))
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCloseDayOutOfOrder(t *testing.T) {
+	repo := &mockBusinessDayRepository{closed: []*models.BusinessDay{{Day: "2024-02-29"}}}
+	s := newTestBusinessDayService(repo)
+
+	_, err := s.CloseDay(operatorCtx, testDay("2024-03-05"))
+	assert.EqualError(t, err, "business day 2024-03-01 must be closed first")
+}
+
+func TestCloseDue(t *testing.T) {
+	repo := &mockBusinessDayRepository{closed: []*models.BusinessDay{{Day: "2024-02-29"}}}
+	s := newTestBusinessDayService(repo)
+
+	// Wednesday's cut-off was 30 minutes ago, so every day up to it is due.
+	closed, err := s.CloseDue(operatorCtx)
+	if assert.NoError(t, err) && assert.Len(t, closed, 3) {
+		assert.Equal(t, "2024-03-01", closed[0].Day)
+		assert.Equal(t, "2024-03-05", closed[1].Day)
+		assert.Equal(t, "2024-03-06", closed[2].Day)
+		assert.Equal(t, "system", closed[2].ClosedBy)
+	}
+
+	closed, err = s.CloseDue(operatorCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, closed)
+}
+
+func TestCloseDueFirstRun(t *testing.T) {
+	repo := &mockBusinessDayRepository{}
+	s := newTestBusinessDayService(repo)
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 6, 17, 0, 30, 0, time.UTC) }
+
+	// Within closeDelay of Wednesday's cut-off only Tuesday is due.
+	closed, err := s.CloseDue(operatorCtx)
+	if assert.NoError(t, err) && assert.Len(t, closed, 1) {
+		assert.Equal(t, "2024-03-05", closed[0].Day)
+	}
+}
+
+func TestGetBusinessDay(t *testing.T) {
+	repo := &mockBusinessDayRepository{closed: []*models.BusinessDay{{Day: "2024-03-01"}}}
+	s := newTestBusinessDayService(repo)
+
+	d, err := s.GetBusinessDay(auditorCtx, testDay("2024-03-01"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2024-03-01", d.Day)
+	}
+	_, err = s.GetBusinessDay(auditorCtx, testDay("2024-03-05"))
+	assert.ErrorIs(t, err, repository.ErrBusinessDayNotClosed)
+	_, err = s.GetBusinessDay(ownerCtx, testDay("2024-03-01"))
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	list, err := s.ListDailyBalances(operatorCtx, testDay("2024-03-01"))
+	if assert.NoError(t, err) && assert.Len(t, list, 1) {
+		assert.Equal(t, int64(150), list[0].ClosingBalance)
+	}
+	_, err = s.ListDailyBalances(operatorCtx, testDay("2024-03-05"))
+	assert.ErrorIs(t, err, repository.ErrBusinessDayNotClosed)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"log"
+	"time"
+)
+
+func NewChargeService(chargeRepo repository.ChargeRepository, transfers transferExecutor, opts ...func(*ChargeService)) *ChargeService {
+	s := &ChargeService{
+		chargeRepo: chargeRepo,
+		transfers:  transfers,
+		nowFn:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithMonthlyFees charges monthly fees into feeAccountID. Monthly fees
+// aren't charged without it.
+func WithMonthlyFees(feeAccountID int) func(*ChargeService) {
+	return func(s *ChargeService) {
+		s.feeAccountID = feeAccountID
+	}
+}
+
+// WithInterest pays interest out of interestAccountID. Interest isn't paid
+// without it.
+func WithInterest(interestAccountID int) func(*ChargeService) {
+	return func(s *ChargeService) {
+		s.interestAccountID = interestAccountID
+	}
+}
+
+// ChargeService charges each account the monthly fee in its terms and pays
+// it interest at its rate, once a month for the month just ended. Each
+// charge is a transfer of its own that the account's terms can't block, so
+// a fee may overdraw an account. An account is charged at most once per
+// month for each, however often a month is run.
+type ChargeService struct {
+	chargeRepo        repository.ChargeRepository
+	transfers         transferExecutor
+	feeAccountID      int
+	interestAccountID int
+	nowFn             func() time.Time
+}
+
+// ChargeMonth charges the month starting at month, in UTC: the full monthly
+// fee of every account opened before its end, and a twelfth of the yearly
+// interest on the balance at its end, rounded down, to every account in
+// credit. It returns how many charges were made. An account that can't be
+// charged is logged and skipped.
+func (s *ChargeService) ChargeMonth(ctx context.Context, month time.Time) (int, error) {
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	accounts, err := s.chargeRepo.ListChargeable(month)
+	if err != nil {
+		return 0, errors.New("couldn't list chargeable accounts")
+	}
+
+	system := auth.WithPrincipal(ctx, &auth.Principal{Subject: audit.SystemActor, Role: auth.RoleOperator})
+	charged := 0
+	for _, a := range accounts {
+		if s.feeAccountID != 0 && a.AccountID != s.feeAccountID && a.MonthlyFee > 0 && !a.FeeCharged {
+			if s.charge(system, a.AccountID, models.ChargeMonthlyFee, month, a.AccountID, s.feeAccountID, a.MonthlyFee) {
+				charged++
+			}
+		}
+		interest := a.BalancePennies * int64(a.InterestRateBPS) / (10000 * 12)
+		if s.interestAccountID != 0 && a.AccountID != s.interestAccountID && interest > 0 && !a.InterestPaid {
+			if s.charge(system, a.AccountID, models.ChargeInterest, month, s.interestAccountID, a.AccountID, interest) {
+				charged++
+			}
+		}
+	}
+	return charged, nil
+}
+
+// charge moves amountPennies from source to dest and records it as the
+// account's charge of kind for month, in the same DB transaction. It
+// reports whether the charge was made.
+func (s *ChargeService) charge(ctx context.Context, accountID int, kind string, month time.Time, source, dest int, amountPennies int64) bool {
+	_, err := s.transfers.settleTransfer(ctx, &models.TransactionRequest{
+		SourceAccountID:      source,
+		DestinationAccountID: dest,
+		Amount:               util.PenniesToDecimalString(amountPennies),
+		InitiatedBy:          audit.SystemActor,
+	}, func(tx *sql.Tx, t *models.Transaction) error {
+		return s.chargeRepo.RecordTx(tx, accountID, kind, month, t.ID)
+	})
+	if errors.Is(err, repository.ErrAlreadyCharged) {
+		return false
+	}
+	if err != nil {
+		log.Printf("failed to charge account %d its %s for %s: %v", accountID, kind, month.Format("2006-01"), err)
+		return false
+	}
+	return true
+}
+
+// Run charges the month just ended at start, if it hasn't been, and each
+// month as it ends, until ctx is cancelled. Like balance snapshots, a month
+// is charged a little after it ends, once transfers completing at the end
+// have committed.
+func (s *ChargeService) Run(ctx context.Context) {
+	now := s.nowFn().Add(-snapshotDelay).UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	for {
+		if n, err := s.ChargeMonth(ctx, month); err != nil {
+			log.Print("failed to charge monthly fees and interest:", err)
+		} else if n > 0 {
+			log.Printf("Made %d monthly fee and interest charges for %s", n, month.Format("2006-01"))
+		}
+
+		month = month.AddDate(0, 1, 0)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(month.AddDate(0, 1, 0).Add(snapshotDelay).Sub(s.nowFn())):
+		}
+	}
+}
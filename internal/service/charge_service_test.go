@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockChargeRepository lists the accounts of a fakeTransfers with the terms
+// in terms, keyed by account ID.
+type mockChargeRepository struct {
+	transfers *fakeTransfers
+	terms     map[int]models.ProductTerms
+	charged   map[string]int
+}
+
+func (m *mockChargeRepository) key(accountID int, kind string, month time.Time) string {
+	return fmt.Sprintf("%d/%s/%s", accountID, kind, month.Format("2006-01"))
+}
+
+func (m *mockChargeRepository) ListChargeable(month time.Time) ([]*models.ChargeableAccount, error) {
+	var list []*models.ChargeableAccount
+	for id := 1; id <= len(m.transfers.accounts); id++ {
+		a, _ := m.transfers.lookup("", id)
+		_, feeCharged := m.charged[m.key(id, models.ChargeMonthlyFee, month)]
+		_, interestPaid := m.charged[m.key(id, models.ChargeInterest, month)]
+		list = append(list, &models.ChargeableAccount{
+			AccountID:       id,
+			MonthlyFee:      m.terms[id].MonthlyFee,
+			InterestRateBPS: m.terms[id].InterestRateBPS,
+			BalancePennies:  a.CurrentBalance,
+			FeeCharged:      feeCharged,
+			InterestPaid:    interestPaid,
+		})
+	}
+	return list, nil
+}
+
+func (m *mockChargeRepository) RecordTx(tx *sql.Tx, accountID int, kind string, month time.Time, transactionID int) error {
+	k := m.key(accountID, kind, month)
+	if _, ok := m.charged[k]; ok {
+		return repository.ErrAlreadyCharged
+	}
+	m.charged[k] = transactionID
+	return nil
+}
+
+func TestChargeMonth(t *testing.T) {
+	transfers := newFakeTransfers()
+	repo := &mockChargeRepository{
+		transfers: transfers,
+		terms: map[int]models.ProductTerms{
+			1: {MonthlyFee: 150, InterestRateBPS: 600},
+			2: {InterestRateBPS: 100},
+			3: {MonthlyFee: 150},
+		},
+		charged: map[string]int{},
+	}
+	s := NewChargeService(repo, transfers, WithMonthlyFees(3), WithInterest(4))
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	n, err := s.ChargeMonth(context.Background(), month)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, n)
+	}
+	if assert.Len(t, transfers.made, 2) {
+		assert.Equal(t, 1, transfers.made[0].SourceAccountID)
+		assert.Equal(t, 3, transfers.made[0].DestinationAccountID)
+		assert.Equal(t, "1.50", transfers.made[0].Amount)
+		// 6% a year on £10.00 is 5p a month.
+		assert.Equal(t, 4, transfers.made[1].SourceAccountID)
+		assert.Equal(t, 1, transfers.made[1].DestinationAccountID)
+		assert.Equal(t, "0.05", transfers.made[1].Amount)
+	}
+	assert.Equal(t, []string{"system", "system"}, transfers.callers)
+	assert.Equal(t, int64(1000-150+5), transfers.accounts["SRC1"].CurrentBalance)
+	assert.Equal(t, 100, repo.charged["1/monthly_fee/2024-02"])
+	assert.Equal(t, 101, repo.charged["1/interest/2024-02"])
+
+	// A month is charged once.
+	n, err = s.ChargeMonth(context.Background(), month.Add(time.Hour))
+	if assert.NoError(t, err) {
+		assert.Zero(t, n)
+	}
+	assert.Len(t, transfers.made, 2)
+}
+
+func TestChargeMonth_NeedsTheAccountsToChargeInto(t *testing.T) {
+	transfers := newFakeTransfers()
+	repo := &mockChargeRepository{
+		transfers: transfers,
+		terms:     map[int]models.ProductTerms{1: {MonthlyFee: 150, InterestRateBPS: 600}},
+		charged:   map[string]int{},
+	}
+
+	n, err := NewChargeService(repo, transfers).ChargeMonth(context.Background(), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if assert.NoError(t, err) {
+		assert.Zero(t, n)
+	}
+	assert.Empty(t, transfers.made)
+}
+
+func TestChargeMonth_FeeOverdraws(t *testing.T) {
+	transfers := newFakeTransfers()
+	transfers.accounts["SRC2"].CurrentBalance = 100
+	repo := &mockChargeRepository{
+		transfers: transfers,
+		terms:     map[int]models.ProductTerms{2: {MonthlyFee: 150}},
+		charged:   map[string]int{},
+	}
+
+	n, err := NewChargeService(repo, transfers, WithMonthlyFees(3)).ChargeMonth(context.Background(), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, n)
+	}
+	assert.Equal(t, int64(-50), transfers.accounts["SRC2"].CurrentBalance)
+}
+
+type failingChargeRepository struct{ mockChargeRepository }
+
+func (m *failingChargeRepository) ListChargeable(month time.Time) ([]*models.ChargeableAccount, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestChargeMonth_ListFails(t *testing.T) {
+	s := NewChargeService(&failingChargeRepository{}, newFakeTransfers(), WithMonthlyFees(3))
+	_, err := s.ChargeMonth(context.Background(), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.EqualError(t, err, "couldn't list chargeable accounts")
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"net/mail"
+	"strings"
+)
+
+const (
+	defaultCustomerPageSize = 50
+	maxCustomerPageSize     = 200
+)
+
+func NewCustomerService(db *sql.DB, customerRepo repository.CustomerRepository, holderRepo repository.AccountHolderRepository, opts ...func(*CustomerService)) *CustomerService {
+	s := &CustomerService{
+		db:           db,
+		customerRepo: customerRepo,
+		holderRepo:   holderRepo,
+		money:        util.DefaultMoneyConverter{},
+		policy:       NewRolePolicy(holderRepo),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewCustomerServiceWithDeps allows injecting a MoneyConverter for testing.
+func NewCustomerServiceWithDeps(db *sql.DB, customerRepo repository.CustomerRepository, holderRepo repository.AccountHolderRepository, money util.MoneyConverter, opts ...func(*CustomerService)) *CustomerService {
+	if money == nil {
+		money = util.DefaultMoneyConverter{}
+	}
+	s := &CustomerService{
+		db:           db,
+		customerRepo: customerRepo,
+		holderRepo:   holderRepo,
+		money:        money,
+		policy:       NewRolePolicy(holderRepo),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithCustomerPolicy sets the authorization policy.
+func WithCustomerPolicy(policy Policy) func(*CustomerService) {
+	return func(s *CustomerService) {
+		s.policy = policy
+	}
+}
+
+// WithCustomerAuditLog records customer changes in the audit log.
+func WithCustomerAuditLog(auditLog repository.AuditLogRepository) func(*CustomerService) {
+	return func(s *CustomerService) {
+		s.auditLog = auditLog
+	}
+}
+
+type CustomerService struct {
+	db           *sql.DB
+	customerRepo repository.CustomerRepository
+	holderRepo   repository.AccountHolderRepository
+	money        util.MoneyConverter
+	auditLog     repository.AuditLogRepository
+	policy       Policy
+	beginFn      func() (*sql.Tx, error)
+	rollbackFn   func(*sql.Tx) error
+	commitFn     func(*sql.Tx) error
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, req *models.CustomerRequest) (*models.Customer, error) {
+	if err := authorize(ctx, s.policy, ActionWriteCustomer, Resource{}); err != nil {
+		return nil, err
+	}
+
+	customer, err := customerFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.customerRepo.CreateTx(tx, customer); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionCustomerCreate, audit.EntityCustomer, customer.ID, nil, customer); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id int) (*models.Customer, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid customer_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadCustomer, Resource{CustomerID: id}); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id int, req *models.CustomerRequest) (*models.Customer, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid customer_id")
+	}
+	if err := authorize(ctx, s.policy, ActionWriteCustomer, Resource{CustomerID: id}); err != nil {
+		return nil, err
+	}
+
+	customer, err := customerFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	customer.ID = id
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	before, err := s.customerRepo.SelectTx(tx, id)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	if err := s.customerRepo.UpdateTx(tx, customer); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionCustomerUpdate, audit.EntityCustomer, id, before, customer); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) ListCustomers(ctx context.Context, limit, offset int) ([]*models.Customer, error) {
+	if err := authorize(ctx, s.policy, ActionListCustomers, Resource{}); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultCustomerPageSize
+	}
+	if limit > maxCustomerPageSize {
+		limit = maxCustomerPageSize
+	}
+	if offset < 0 {
+		return nil, errors.New("invalid offset")
+	}
+
+	list, err := s.customerRepo.List(limit, offset)
+	if err != nil {
+		return nil, errors.New("couldn't list customers")
+	}
+	return list, nil
+}
+
+// ListCustomerAccounts returns every account the customer holds, with their role.
+func (s *CustomerService) ListCustomerAccounts(ctx context.Context, id int) ([]*models.CustomerAccountView, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid customer_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadCustomer, Resource{CustomerID: id}); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.customerRepo.Exists(id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("customer not found")
+	}
+
+	holdings, err := s.holderRepo.ListAccountsByCustomer(id)
+	if err != nil {
+		return nil, errors.New("couldn't list customer accounts")
+	}
+
+	views := make([]*models.CustomerAccountView, 0, len(holdings))
+	for _, h := range holdings {
+		views = append(views, &models.CustomerAccountView{
+			AccountID:      h.Account.AccountID,
+			AccountNumber:  h.Account.AccountNumber,
+			HolderName:     h.Account.HolderName,
+			CurrentBalance: s.money.PenniesToDecimalString(h.Account.CurrentBalance),
+			Role:           h.Role,
+		})
+	}
+	return views, nil
+}
+
+func customerFromRequest(req *models.CustomerRequest) (*models.Customer, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return nil, errors.New("invalid email")
+		}
+	}
+
+	return &models.Customer{
+		Name:  name,
+		Email: email,
+		Phone: strings.TrimSpace(req.Phone),
+	}, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCustomerRepository struct {
+	createFn  func(*models.Customer) error
+	getByIDFn func(int) (*models.Customer, error)
+	updateFn  func(*models.Customer) error
+	listFn    func(int, int) ([]*models.Customer, error)
+	existsFn  func(int) (bool, error)
+}
+
+func (m *mockCustomerRepository) CreateTx(tx *sql.Tx, c *models.Customer) error {
+	if m.createFn != nil {
+		return m.createFn(c)
+	}
+	return nil
+}
+
+func (m *mockCustomerRepository) GetByID(id int) (*models.Customer, error) {
+	if m.getByIDFn != nil {
+		return m.getByIDFn(id)
+	}
+	return nil, nil
+}
+
+func (m *mockCustomerRepository) SelectTx(tx *sql.Tx, id int) (*models.Customer, error) {
+	return m.GetByID(id)
+}
+
+func (m *mockCustomerRepository) UpdateTx(tx *sql.Tx, c *models.Customer) error {
+	if m.updateFn != nil {
+		return m.updateFn(c)
+	}
+	return nil
+}
+
+func (m *mockCustomerRepository) List(limit, offset int) ([]*models.Customer, error) {
+	if m.listFn != nil {
+		return m.listFn(limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *mockCustomerRepository) Exists(id int) (bool, error) {
+	if m.existsFn != nil {
+		return m.existsFn(id)
+	}
+	return false, nil
+}
+
+// newTestCustomerService stubs out the DB transaction functions.
+func newTestCustomerService(repo repository.CustomerRepository, holders repository.AccountHolderRepository, money util.MoneyConverter) *CustomerService {
+	s := NewCustomerServiceWithDeps(&sql.DB{}, repo, holders, money)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+type mockAccountHolderRepository struct {
+	addFn                    func(*models.AccountHolder) error
+	removeFn                 func(int, int) error
+	listByAccountFn          func(int) ([]*models.AccountHolder, error)
+	listAccountsByCustomerFn func(int) ([]*models.AccountHolding, error)
+}
+
+func (m *mockAccountHolderRepository) AddTx(tx *sql.Tx, h *models.AccountHolder) error {
+	if m.addFn != nil {
+		return m.addFn(h)
+	}
+	return nil
+}
+
+func (m *mockAccountHolderRepository) RemoveTx(tx *sql.Tx, accountID, customerID int) error {
+	if m.removeFn != nil {
+		return m.removeFn(accountID, customerID)
+	}
+	return nil
+}
+
+func (m *mockAccountHolderRepository) ListByAccount(accountID int) ([]*models.AccountHolder, error) {
+	if m.listByAccountFn != nil {
+		return m.listByAccountFn(accountID)
+	}
+	return nil, nil
+}
+
+func (m *mockAccountHolderRepository) ListAccountsByCustomer(customerID int) ([]*models.AccountHolding, error) {
+	if m.listAccountsByCustomerFn != nil {
+		return m.listAccountsByCustomerFn(customerID)
+	}
+	return nil, nil
+}
+
+func TestCreateCustomer(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     *models.CustomerRequest
+		repo    *mockCustomerRepository
+		wantErr string
+		want    *models.Customer
+	}{
+		{"missing_name", &models.CustomerRequest{Name: "  "}, &mockCustomerRepository{}, "name is required", nil},
+		{"invalid_email", &models.CustomerRequest{Name: "Jane", Email: "not-an-email"}, &mockCustomerRepository{}, "invalid email", nil},
+		{"display_name_email_rejected", &models.CustomerRequest{Name: "Jane", Email: "Jane <jane@example.com>"}, &mockCustomerRepository{}, "invalid email", nil},
+		{"repo_error", &models.CustomerRequest{Name: "Jane"}, &mockCustomerRepository{createFn: func(*models.Customer) error { return errors.New("email already in use") }}, "email already in use", nil},
+		{
+			name: "success_normalizes",
+			req:  &models.CustomerRequest{Name: " Jane Doe ", Email: " Jane@Example.COM ", Phone: " +44 20 "},
+			repo: &mockCustomerRepository{createFn: func(c *models.Customer) error {
+				c.ID = 7
+				return nil
+			}},
+			want: &models.Customer{ID: 7, Name: "Jane Doe", Email: "jane@example.com", Phone: "+44 20"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newTestCustomerService(tc.repo, &mockAccountHolderRepository{}, &mockMoneyConverter{})
+			got, err := svc.CreateCustomer(operatorCtx, tc.req)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestGetAndUpdateCustomer(t *testing.T) {
+	repo := &mockCustomerRepository{
+		getByIDFn: func(id int) (*models.Customer, error) {
+			if id == 1 {
+				return &models.Customer{ID: 1, Name: "Jane"}, nil
+			}
+			return nil, errors.New("customer not found")
+		},
+		updateFn: func(c *models.Customer) error {
+			if c.ID != 1 {
+				return errors.New("customer not found")
+			}
+			return nil
+		},
+	}
+	svc := newTestCustomerService(repo, &mockAccountHolderRepository{}, nil)
+
+	_, err := svc.GetCustomer(operatorCtx, 0)
+	assert.EqualError(t, err, "invalid customer_id")
+	_, err = svc.GetCustomer(operatorCtx, 2)
+	assert.EqualError(t, err, "customer not found")
+	got, err := svc.GetCustomer(operatorCtx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", got.Name)
+
+	_, err = svc.UpdateCustomer(operatorCtx, 0, &models.CustomerRequest{Name: "x"})
+	assert.EqualError(t, err, "invalid customer_id")
+	_, err = svc.UpdateCustomer(operatorCtx, 1, &models.CustomerRequest{})
+	assert.EqualError(t, err, "name is required")
+	_, err = svc.UpdateCustomer(operatorCtx, 2, &models.CustomerRequest{Name: "x"})
+	assert.EqualError(t, err, "customer not found")
+	got, err = svc.UpdateCustomer(operatorCtx, 1, &models.CustomerRequest{Name: "Janet"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Customer{ID: 1, Name: "Janet"}, got)
+}
+
+func TestListCustomers(t *testing.T) {
+	cases := []struct {
+		name      string
+		limit     int
+		offset    int
+		wantLimit int
+		wantErr   string
+		repoErr   error
+	}{
+		{"default_limit", 0, 0, defaultCustomerPageSize, "", nil},
+		{"capped_limit", 1000, 0, maxCustomerPageSize, "", nil},
+		{"explicit_limit", 10, 30, 10, "", nil},
+		{"negative_offset", 10, -1, 0, "invalid offset", nil},
+		{"repo_error", 10, 0, 10, "couldn't list customers", errors.New("db")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockCustomerRepository{listFn: func(limit, offset int) ([]*models.Customer, error) {
+				assert.Equal(t, tc.wantLimit, limit)
+				assert.Equal(t, tc.offset, offset)
+				return []*models.Customer{{ID: 1}}, tc.repoErr
+			}}
+			svc := newTestCustomerService(repo, &mockAccountHolderRepository{}, nil)
+			got, err := svc.ListCustomers(operatorCtx, tc.limit, tc.offset)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+		})
+	}
+}
+
+func TestListCustomerAccounts(t *testing.T) {
+	holders := &mockAccountHolderRepository{listAccountsByCustomerFn: func(id int) ([]*models.AccountHolding, error) {
+		return []*models.AccountHolding{
+			{Account: &models.Account{AccountID: 123, HolderName: "Jane", CurrentBalance: 1050}, Role: models.HolderRoleOwner},
+			{Account: &models.Account{AccountID: 456, HolderName: "Bob", CurrentBalance: -5}, Role: models.HolderRoleAuthorizedUser},
+		}, nil
+	}}
+	money := &mockMoneyConverter{fmtFn: func(p int64) string { return fakePennies(p) }}
+
+	t.Run("invalid_id", func(t *testing.T) {
+		svc := newTestCustomerService(&mockCustomerRepository{}, holders, money)
+		_, err := svc.ListCustomerAccounts(operatorCtx, 0)
+		assert.EqualError(t, err, "invalid customer_id")
+	})
+	t.Run("not_found", func(t *testing.T) {
+		svc := newTestCustomerService(&mockCustomerRepository{existsFn: func(int) (bool, error) { return false, nil }}, holders, money)
+		_, err := svc.ListCustomerAccounts(operatorCtx, 3)
+		assert.EqualError(t, err, "customer not found")
+	})
+	t.Run("success", func(t *testing.T) {
+		svc := newTestCustomerService(&mockCustomerRepository{existsFn: func(int) (bool, error) { return true, nil }}, holders, money)
+		got, err := svc.ListCustomerAccounts(operatorCtx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.CustomerAccountView{
+			{AccountID: 123, HolderName: "Jane", CurrentBalance: "p1050", Role: models.HolderRoleOwner},
+			{AccountID: 456, HolderName: "Bob", CurrentBalance: "p-5", Role: models.HolderRoleAuthorizedUser},
+		}, got)
+	})
+}
+
+func fakePennies(p int64) string {
+	return "p" + strconv.FormatInt(p, 10)
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"strings"
+)
+
+func NewDisputeService(db *sql.DB, disputeRepo repository.DisputeRepository, transactionRepo repository.TransactionRepository, transfers transferExecutor, opts ...func(*DisputeService)) *DisputeService {
+	s := &DisputeService{
+		db:              db,
+		disputeRepo:     disputeRepo,
+		transactionRepo: transactionRepo,
+		transfers:       transfers,
+		policy:          NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithDisputeSuspenseAccount enables disputes: provisional credits are paid
+// from the suspense account and settlements are paid back into it.
+func WithDisputeSuspenseAccount(accountID int) func(*DisputeService) {
+	return func(s *DisputeService) {
+		s.suspenseAccountID = accountID
+	}
+}
+
+// WithDisputePolicy sets the authorization policy.
+func WithDisputePolicy(policy Policy) func(*DisputeService) {
+	return func(s *DisputeService) {
+		s.policy = policy
+	}
+}
+
+// WithDisputeAuditLog records disputes, notes, reviews and resolutions in
+// the audit log. The transfers themselves are audited as they are made.
+func WithDisputeAuditLog(auditLog repository.AuditLogRepository) func(*DisputeService) {
+	return func(s *DisputeService) {
+		s.auditLog = auditLog
+	}
+}
+
+// DisputeService tracks customers' claims against transfers out of their
+// accounts. Opening a dispute credits the customer the amount from the
+// suspense account straight away, with ProcessTransaction's rules. Once
+// reviewed, the dispute is won and the counterparty is charged back, or lost
+// and the credit is reversed; either way the amount goes back into the
+// suspense account as a settlement the source's terms can't block.
+type DisputeService struct {
+	db                *sql.DB
+	disputeRepo       repository.DisputeRepository
+	transactionRepo   repository.TransactionRepository
+	transfers         transferExecutor
+	suspenseAccountID int
+	auditLog          repository.AuditLogRepository
+	policy            Policy
+	beginFn           func() (*sql.Tx, error)
+	rollbackFn        func(*sql.Tx) error
+	commitFn          func(*sql.Tx) error
+}
+
+var errDisputesNotConfigured = errors.New("disputes are not configured")
+
+// OpenDispute disputes a completed transfer out of the caller's account and
+// credits the account the amount from the suspense account. A credit held
+// for screening or waiting for approval must complete before the dispute
+// can be resolved.
+func (s *DisputeService) OpenDispute(ctx context.Context, req *models.DisputeRequest) (*models.Dispute, error) {
+	if req.TransactionID <= 0 {
+		return nil, errors.New("invalid transaction_id")
+	}
+	t, err := s.transactionRepo.GetByID(req.TransactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	if err := authorize(ctx, s.policy, ActionOpenDisputes, Resource{AccountIDs: []int{t.SourceAccountID}}); err != nil {
+		return nil, err
+	}
+	if s.suspenseAccountID == 0 {
+		return nil, errDisputesNotConfigured
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if t.Status != models.TransactionStatusCompleted {
+		return nil, errors.New("only completed transactions can be disputed")
+	}
+	if t.SourceAccountID == s.suspenseAccountID || t.DestinationAccountID == s.suspenseAccountID {
+		return nil, errors.New("dispute transfers can't be disputed")
+	}
+
+	// Customers can't debit the suspense account, so the credit is made by
+	// the system; the caller is still recorded as its initiator
+	p, _ := auth.FromContext(ctx)
+	system := auth.WithPrincipal(ctx, &auth.Principal{Subject: audit.SystemActor, Role: auth.RoleOperator})
+	d := &models.Dispute{
+		TransactionID:             t.ID,
+		AccountID:                 t.SourceAccountID,
+		AccountNumber:             t.SourceAccountNumber,
+		CounterpartyAccountID:     t.DestinationAccountID,
+		CounterpartyAccountNumber: t.DestinationAccountNumber,
+		AmountPennies:             t.AmountPennies,
+		Reason:                    reason,
+		OpenedBy:                  p.Subject,
+	}
+	_, err = s.transfers.processTransaction(system, &models.TransactionRequest{
+		SourceAccountID:      s.suspenseAccountID,
+		DestinationAccountID: t.SourceAccountID,
+		Amount:               util.PenniesToDecimalString(t.AmountPennies),
+		InitiatedBy:          p.Subject,
+	}, func(tx *sql.Tx, credit *models.Transaction) error {
+		d.ProvisionalTransactionID = credit.ID
+		d.ProvisionalStatus = credit.Status
+		if err := s.disputeRepo.CreateTx(tx, d); err != nil {
+			if errors.Is(err, repository.ErrDuplicateDispute) {
+				return err
+			}
+			return errors.New("couldn't create dispute")
+		}
+		return recordAudit(ctx, tx, s.auditLog, audit.ActionDisputeOpen, audit.EntityDispute, d.ID, nil, d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetDispute returns a dispute with its notes.
+func (s *DisputeService) GetDispute(ctx context.Context, id int) (*models.Dispute, error) {
+	d, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, s.policy, ActionReadDisputes, Resource{AccountIDs: []int{d.AccountID}}); err != nil {
+		return nil, err
+	}
+	d.Notes, err = s.disputeRepo.ListNotes(d.ID)
+	if err != nil {
+		return nil, errors.New("couldn't list dispute notes")
+	}
+	return d, nil
+}
+
+func (s *DisputeService) get(id int) (*models.Dispute, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid dispute_id")
+	}
+	d, err := s.disputeRepo.Get(id)
+	if err != nil {
+		return nil, repository.ErrDisputeNotFound
+	}
+	return d, nil
+}
+
+// ListDisputes returns disputes newest first, only those in status unless
+// it is empty.
+func (s *DisputeService) ListDisputes(ctx context.Context, status string) ([]*models.Dispute, error) {
+	if err := authorize(ctx, s.policy, ActionListDisputes, Resource{}); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", models.DisputeOpen, models.DisputeUnderReview, models.DisputeWon, models.DisputeLost:
+	default:
+		return nil, errors.New("status must be open, under_review, won or lost")
+	}
+	list, err := s.disputeRepo.List(status)
+	if err != nil {
+		return nil, errors.New("couldn't list disputes")
+	}
+	return list, nil
+}
+
+// AddNote adds evidence or a remark to an unresolved dispute.
+func (s *DisputeService) AddNote(ctx context.Context, id int, note string) (*models.DisputeNote, error) {
+	d, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, s.policy, ActionAddDisputeNotes, Resource{AccountIDs: []int{d.AccountID}}); err != nil {
+		return nil, err
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, errors.New("note is required")
+	}
+	if !disputeUnresolved(d.Status) {
+		return nil, errors.New("dispute is already " + d.Status)
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	p, _ := auth.FromContext(ctx)
+	n := &models.DisputeNote{DisputeID: d.ID, Author: p.Subject, Note: note}
+	err = s.disputeRepo.AddNoteTx(tx, n)
+	if errors.Is(err, repository.ErrDisputeResolved) {
+		return nil, errors.New("dispute was resolved meanwhile")
+	}
+	if err != nil {
+		return nil, errors.New("couldn't add dispute note")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionDisputeNote, audit.EntityDispute, d.ID, nil, n); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return n, nil
+}
+
+// ReviewDispute puts an open dispute under review.
+func (s *DisputeService) ReviewDispute(ctx context.Context, id int) (*models.Dispute, error) {
+	if err := authorize(ctx, s.policy, ActionResolveDisputes, Resource{}); err != nil {
+		return nil, err
+	}
+	d, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != models.DisputeOpen {
+		return nil, errors.New("dispute is already " + d.Status)
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	before := *d
+	err = s.disputeRepo.ReviewTx(tx, d.ID)
+	if errors.Is(err, repository.ErrDisputeNotOpen) {
+		return nil, errors.New("dispute was reviewed or resolved meanwhile")
+	}
+	if err != nil {
+		return nil, errors.New("couldn't review dispute")
+	}
+	d.Status = models.DisputeUnderReview
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionDisputeReview, audit.EntityDispute, d.ID, &before, d); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return d, nil
+}
+
+// ResolveDispute settles a dispute once its provisional credit has
+// completed. A won dispute charges the counterparty back and a lost one
+// reverses the credit, each by a transfer into the suspense account that
+// completes even if it overdraws the account charged: a customer who has
+// spent the credit, or a counterparty without the funds, owes the
+// shortfall. A dispute whose settlement transfer was rejected, expired or
+// failed can be resolved again.
+func (s *DisputeService) ResolveDispute(ctx context.Context, id int, outcome string) (*models.Dispute, error) {
+	if err := authorize(ctx, s.policy, ActionResolveDisputes, Resource{}); err != nil {
+		return nil, err
+	}
+	if outcome != models.DisputeWon && outcome != models.DisputeLost {
+		return nil, errors.New("outcome must be won or lost")
+	}
+	d, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.suspenseAccountID == 0 {
+		return nil, errDisputesNotConfigured
+	}
+	if !disputeUnresolved(d.Status) && !settlementFailed(d.SettlementStatus) {
+		return nil, errors.New("dispute is already " + d.Status)
+	}
+	switch d.ProvisionalStatus {
+	case models.TransactionStatusCompleted:
+	case models.TransactionStatusHeld, models.TransactionStatusPendingApproval:
+		return nil, errors.New("provisional credit hasn't completed yet")
+	default:
+		return nil, errors.New("provisional credit was " + d.ProvisionalStatus)
+	}
+
+	source := d.CounterpartyAccountID
+	if outcome == models.DisputeLost {
+		source = d.AccountID
+	}
+
+	p, _ := auth.FromContext(ctx)
+	before := *d
+	_, err = s.transfers.settleTransfer(ctx, &models.TransactionRequest{
+		SourceAccountID:      source,
+		DestinationAccountID: s.suspenseAccountID,
+		Amount:               util.PenniesToDecimalString(d.AmountPennies),
+		InitiatedBy:          p.Subject,
+	}, func(tx *sql.Tx, t *models.Transaction) error {
+		resolvedAt, err := s.disputeRepo.ResolveTx(tx, d.ID, outcome, p.Subject, t.ID)
+		if err != nil {
+			return err
+		}
+		d.Status = outcome
+		d.ResolvedBy = p.Subject
+		d.SettlementTransactionID = &t.ID
+		d.SettlementStatus = t.Status
+		d.ResolvedAt = &resolvedAt
+		return recordAudit(ctx, tx, s.auditLog, audit.ActionDisputeResolve, audit.EntityDispute, d.ID, &before, d)
+	})
+	if errors.Is(err, repository.ErrDisputeResolved) {
+		return nil, errors.New("dispute was resolved meanwhile")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func disputeUnresolved(status string) bool {
+	return status == models.DisputeOpen || status == models.DisputeUnderReview
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDisputeRepository struct {
+	disputes map[int]*models.Dispute
+	notes    []*models.DisputeNote
+}
+
+func newMockDisputeRepository() *mockDisputeRepository {
+	return &mockDisputeRepository{disputes: map[int]*models.Dispute{}}
+}
+
+func (m *mockDisputeRepository) CreateTx(tx *sql.Tx, d *models.Dispute) error {
+	for _, existing := range m.disputes {
+		if existing.TransactionID == d.TransactionID {
+			return repository.ErrDuplicateDispute
+		}
+	}
+	d.ID = len(m.disputes) + 1
+	d.Status = models.DisputeOpen
+	stored := *d
+	m.disputes[d.ID] = &stored
+	return nil
+}
+
+func (m *mockDisputeRepository) Get(id int) (*models.Dispute, error) {
+	d, ok := m.disputes[id]
+	if !ok {
+		return nil, repository.ErrDisputeNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *mockDisputeRepository) List(status string) ([]*models.Dispute, error) {
+	var list []*models.Dispute
+	for id := len(m.disputes); id > 0; id-- {
+		if d := m.disputes[id]; status == "" || d.Status == status {
+			copied := *d
+			list = append(list, &copied)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockDisputeRepository) ListNotes(disputeID int) ([]*models.DisputeNote, error) {
+	var list []*models.DisputeNote
+	for _, n := range m.notes {
+		if n.DisputeID == disputeID {
+			list = append(list, n)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockDisputeRepository) AddNoteTx(tx *sql.Tx, n *models.DisputeNote) error {
+	if !disputeUnresolved(m.disputes[n.DisputeID].Status) {
+		return repository.ErrDisputeResolved
+	}
+	n.ID = len(m.notes) + 1
+	m.notes = append(m.notes, n)
+	return nil
+}
+
+func (m *mockDisputeRepository) ReviewTx(tx *sql.Tx, id int) error {
+	d := m.disputes[id]
+	if d.Status != models.DisputeOpen {
+		return repository.ErrDisputeNotOpen
+	}
+	d.Status = models.DisputeUnderReview
+	return nil
+}
+
+func (m *mockDisputeRepository) ResolveTx(tx *sql.Tx, id int, status, resolvedBy string, transactionID int) (string, error) {
+	d := m.disputes[id]
+	if !disputeUnresolved(d.Status) && !settlementFailed(d.SettlementStatus) {
+		return "", repository.ErrDisputeResolved
+	}
+	at := "2024-03-04T00:00:00Z"
+	d.Status, d.ResolvedBy, d.SettlementTransactionID, d.SettlementStatus, d.ResolvedAt =
+		status, resolvedBy, &transactionID, models.TransactionStatusCompleted, &at
+	return at, nil
+}
+
+// testDisputedTransfers are the transfers disputes are opened on: 10 is a
+// completed transfer of 4.00 from customer 7's account 100 to DST.
+func testDisputedTransfers() *mockTransactionRepo {
+	return &mockTransactionRepo{GetByIDFunc: func(id int) (*models.Transaction, error) {
+		switch id {
+		case 10:
+			return &models.Transaction{ID: 10, SourceAccountID: 100, SourceAccountNumber: "CUST", DestinationAccountID: 3,
+				DestinationAccountNumber: "DST", AmountPennies: 400, Status: models.TransactionStatusCompleted}, nil
+		case 11:
+			return &models.Transaction{ID: 11, SourceAccountID: 100, DestinationAccountID: 3, AmountPennies: 400,
+				Status: models.TransactionStatusPendingApproval}, nil
+		case 12:
+			return &models.Transaction{ID: 12, SourceAccountID: 5, DestinationAccountID: 100, AmountPennies: 400,
+				Status: models.TransactionStatusCompleted}, nil
+		}
+		return nil, errors.New("transaction not found")
+	}}
+}
+
+func newTestDisputeService(repo *mockDisputeRepository, opts ...func(*DisputeService)) (*DisputeService, *fakeTransfers) {
+	transfers := newFakeTransfers()
+	transfers.accounts["DST"].CurrentBalance = 1000
+	transfers.accounts["CUST"] = &models.Account{AccountID: 100, AccountNumber: "CUST", CurrentBalance: 1000}
+	transfers.accounts["SUSP"] = &models.Account{AccountID: 5, AccountNumber: "SUSP", CurrentBalance: 100000}
+	opts = append([]func(*DisputeService){WithDisputePolicy(NewRolePolicy(testHolders())), WithDisputeSuspenseAccount(5)}, opts...)
+	s := NewDisputeService(&sql.DB{}, repo, testDisputedTransfers(), transfers, opts...)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s, transfers
+}
+
+func TestOpenDispute(t *testing.T) {
+	repo := newMockDisputeRepository()
+	s, transfers := newTestDisputeService(repo)
+
+	d, err := s.OpenDispute(ownerCtx, &models.DisputeRequest{TransactionID: 10, Reason: " not received "})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.DisputeOpen, d.Status)
+	assert.Equal(t, 100, d.AccountID)
+	assert.Equal(t, 3, d.CounterpartyAccountID)
+	assert.Equal(t, int64(400), d.AmountPennies)
+	assert.Equal(t, "not received", d.Reason)
+	assert.Equal(t, "alice", d.OpenedBy)
+	assert.Equal(t, models.TransactionStatusCompleted, d.ProvisionalStatus)
+	assert.Equal(t, &models.TransactionRequest{SourceAccountID: 5, DestinationAccountID: 100, Amount: "4.00", InitiatedBy: "alice"}, transfers.made[0])
+	assert.Equal(t, []string{"system"}, transfers.callers, "the customer can't debit the suspense account themselves")
+
+	cases := []struct {
+		name    string
+		req     *models.DisputeRequest
+		wantErr string
+	}{
+		{"already disputed", &models.DisputeRequest{TransactionID: 10, Reason: "again"}, "the transaction is already disputed"},
+		{"no reason", &models.DisputeRequest{TransactionID: 11, Reason: " "}, "reason is required"},
+		{"not completed", &models.DisputeRequest{TransactionID: 11, Reason: "wrong"}, "only completed transactions can be disputed"},
+		{"provisional credit", &models.DisputeRequest{TransactionID: 12, Reason: "wrong"}, "dispute transfers can't be disputed"},
+		{"unknown", &models.DisputeRequest{TransactionID: 99, Reason: "wrong"}, "transaction not found"},
+		{"bad id", &models.DisputeRequest{Reason: "wrong"}, "invalid transaction_id"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.OpenDispute(operatorCtx, tc.req)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+
+	_, err = s.OpenDispute(strangerCtx, &models.DisputeRequest{TransactionID: 10, Reason: "mine"})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	unconfigured, _ := newTestDisputeService(newMockDisputeRepository(), WithDisputeSuspenseAccount(0))
+	_, err = unconfigured.OpenDispute(ownerCtx, &models.DisputeRequest{TransactionID: 10, Reason: "not received"})
+	assert.EqualError(t, err, "disputes are not configured")
+}
+
+func TestDisputeNotesAndReview(t *testing.T) {
+	repo := newMockDisputeRepository()
+	s, _ := newTestDisputeService(repo)
+	d, err := s.OpenDispute(ownerCtx, &models.DisputeRequest{TransactionID: 10, Reason: "not received"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	n, err := s.AddNote(ownerCtx, d.ID, "courier tracking shows no delivery")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "alice", n.Author)
+	}
+	_, err = s.AddNote(ownerCtx, d.ID, " ")
+	assert.EqualError(t, err, "note is required")
+	_, err = s.AddNote(strangerCtx, d.ID, "me too")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = s.ReviewDispute(ownerCtx, d.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	reviewed, err := s.ReviewDispute(operatorCtx, d.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.DisputeUnderReview, reviewed.Status)
+	}
+	_, err = s.ReviewDispute(operatorCtx, d.ID)
+	assert.EqualError(t, err, "dispute is already under_review")
+	_, err = s.AddNote(operatorCtx, d.ID, "asked the merchant for proof of delivery")
+	assert.NoError(t, err)
+
+	got, err := s.GetDispute(ownerCtx, d.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, got.Notes, 2)
+	}
+	_, err = s.GetDispute(strangerCtx, d.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.GetDispute(operatorCtx, 99)
+	assert.ErrorIs(t, err, repository.ErrDisputeNotFound)
+
+	list, err := s.ListDisputes(auditorCtx, models.DisputeUnderReview)
+	if assert.NoError(t, err) {
+		assert.Len(t, list, 1)
+	}
+	_, err = s.ListDisputes(operatorCtx, "closed")
+	assert.EqualError(t, err, "status must be open, under_review, won or lost")
+	_, err = s.ListDisputes(ownerCtx, "")
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestResolveDispute(t *testing.T) {
+	repo := newMockDisputeRepository()
+	s, transfers := newTestDisputeService(repo)
+	d, err := s.OpenDispute(ownerCtx, &models.DisputeRequest{TransactionID: 10, Reason: "not received"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = s.ResolveDispute(ownerCtx, d.ID, models.DisputeWon)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.ResolveDispute(operatorCtx, d.ID, models.DisputeUnderReview)
+	assert.EqualError(t, err, "outcome must be won or lost")
+
+	repo.disputes[d.ID].ProvisionalStatus = models.TransactionStatusHeld
+	_, err = s.ResolveDispute(operatorCtx, d.ID, models.DisputeWon)
+	assert.EqualError(t, err, "provisional credit hasn't completed yet")
+	repo.disputes[d.ID].ProvisionalStatus = models.TransactionStatusCompleted
+
+	// Won: the counterparty is charged back into suspense
+	won, err := s.ResolveDispute(operatorCtx, d.ID, models.DisputeWon)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.DisputeWon, won.Status)
+		assert.Equal(t, "ops", won.ResolvedBy)
+		assert.NotNil(t, won.SettlementTransactionID)
+		assert.Equal(t, &models.TransactionRequest{SourceAccountID: 3, DestinationAccountID: 5, Amount: "4.00", InitiatedBy: "ops"},
+			transfers.made[len(transfers.made)-1])
+	}
+	_, err = s.ResolveDispute(operatorCtx, d.ID, models.DisputeLost)
+	assert.EqualError(t, err, "dispute is already won")
+	_, err = s.AddNote(ownerCtx, d.ID, "thanks")
+	assert.EqualError(t, err, "dispute is already won")
+
+	// A failed chargeback can be resolved again; lost reverses the credit
+	repo.disputes[d.ID].SettlementStatus = models.TransactionStatusFailed
+	lost, err := s.ResolveDispute(operatorCtx, d.ID, models.DisputeLost)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.DisputeLost, lost.Status)
+		assert.Equal(t, &models.TransactionRequest{SourceAccountID: 100, DestinationAccountID: 5, Amount: "4.00", InitiatedBy: "ops"},
+			transfers.made[len(transfers.made)-1])
+	}
+}
+
+// Settlements complete even when the account charged can't cover them.
+func TestResolveDispute_SettlementOverdraws(t *testing.T) {
+	cases := []struct {
+		name    string
+		outcome string
+		charged string
+	}{
+		{"lost after the credit was spent", models.DisputeLost, "CUST"},
+		{"won against a counterparty without funds", models.DisputeWon, "DST"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newMockDisputeRepository()
+			s, transfers := newTestDisputeService(repo)
+			d, err := s.OpenDispute(ownerCtx, &models.DisputeRequest{TransactionID: 10, Reason: "not received"})
+			if !assert.NoError(t, err) {
+				return
+			}
+			transfers.accounts[tc.charged].CurrentBalance = 100
+
+			resolved, err := s.ResolveDispute(operatorCtx, d.ID, tc.outcome)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.outcome, resolved.Status)
+				assert.Equal(t, models.TransactionStatusCompleted, resolved.SettlementStatus)
+			}
+			assert.Equal(t, int64(-300), transfers.accounts[tc.charged].CurrentBalance, "the shortfall is owed")
+			assert.Equal(t, int64(100000+400), transfers.accounts["SUSP"].CurrentBalance)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import "errors"
+
+var (
+	// ErrNotFound is wrapped by errors for a resource that doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrFailedPrecondition is wrapped by errors for a valid request the
+	// current state doesn't allow, such as a transfer without the funds.
+	ErrFailedPrecondition = errors.New("failed precondition")
+	// ErrInternal is wrapped by errors the caller can't fix, such as a
+	// failed query.
+	ErrInternal = errors.New("internal error")
+)
+
+// kindError is an error with its own message that errors.Is matches
+// against one of the kinds above.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Unwrap() error { return e.kind }
+
+func notFound(msg string) error { return &kindError{kind: ErrNotFound, msg: msg} }
+
+func failedPrecondition(msg string) error {
+	return &kindError{kind: ErrFailedPrecondition, msg: msg}
+}
+
+func internalError(msg string) error { return &kindError{kind: ErrInternal, msg: msg} }
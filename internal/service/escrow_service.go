@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"log"
+	"strings"
+	"time"
+)
+
+// escrowBatchSize bounds the escrows settled per SettleDue call.
+const escrowBatchSize = 100
+
+// accountOpener finds and opens accounts for services that keep accounts
+// of their own. AccountService implements it.
+type accountOpener interface {
+	resolveAccount(number string) (*models.Account, error)
+	openAccount(ctx context.Context, account *models.Account, onCreate func(*sql.Tx, *models.Account) error) error
+}
+
+func NewEscrowService(db *sql.DB, escrowRepo repository.EscrowRepository, accounts accountOpener, transfers transferExecutor, opts ...func(*EscrowService)) *EscrowService {
+	s := &EscrowService{
+		db:         db,
+		escrowRepo: escrowRepo,
+		accounts:   accounts,
+		transfers:  transfers,
+		policy:     NewRolePolicy(nil),
+		nowFn:      time.Now,
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithEscrowPolicy sets the authorization policy.
+func WithEscrowPolicy(policy Policy) func(*EscrowService) {
+	return func(s *EscrowService) {
+		s.policy = policy
+	}
+}
+
+// WithEscrowAuditLog records escrows, disputes and settlements in the audit
+// log. The transfers themselves are audited as they are made.
+func WithEscrowAuditLog(auditLog repository.AuditLogRepository) func(*EscrowService) {
+	return func(s *EscrowService) {
+		s.auditLog = auditLog
+	}
+}
+
+// EscrowService holds buyers' payments until the goods are delivered. Each
+// buyer and seller pair has an escrow account, opened on the escrow product
+// the first time they use escrow. An escrow is a transfer from the buyer
+// into it, and is settled by a transfer out to the seller (release) or back
+// to the buyer (refund), all made with ProcessTransaction's rules. A
+// disputed escrow is not settled at its deadline.
+type EscrowService struct {
+	db         *sql.DB
+	escrowRepo repository.EscrowRepository
+	accounts   accountOpener
+	transfers  transferExecutor
+	auditLog   repository.AuditLogRepository
+	policy     Policy
+	nowFn      func() time.Time
+	beginFn    func() (*sql.Tx, error)
+	rollbackFn func(*sql.Tx) error
+	commitFn   func(*sql.Tx) error
+}
+
+// CreateEscrow moves the amount from the buyer's account into the pair's
+// escrow account. A transfer held for screening or waiting for approval
+// leaves the escrow unfunded, and it can't be settled, until it completes.
+func (s *EscrowService) CreateEscrow(ctx context.Context, req *models.EscrowRequest) (*models.Escrow, error) {
+	buyer, err := s.accounts.resolveAccount(req.BuyerAccountNumber)
+	if err != nil {
+		return nil, errors.New("buyer account not found")
+	}
+	if err := authorize(ctx, s.policy, ActionCreateEscrows, Resource{AccountIDs: []int{buyer.AccountID}}); err != nil {
+		return nil, err
+	}
+	seller, err := s.accounts.resolveAccount(req.SellerAccountNumber)
+	if err != nil {
+		return nil, errors.New("seller account not found")
+	}
+	if buyer.AccountID == seller.AccountID {
+		return nil, errors.New("buyer and seller accounts cannot be the same")
+	}
+	if buyer.Currency != seller.Currency {
+		return nil, errors.New("buyer and seller accounts are in different currencies")
+	}
+
+	amountPennies, err := util.DecimalStringToPennies(req.Amount)
+	if err != nil || amountPennies <= 0 {
+		return nil, errors.New("invalid amount format")
+	}
+	deadline, err := time.Parse(time.RFC3339, req.Deadline)
+	if err != nil {
+		return nil, errors.New("deadline must be an RFC 3339 time")
+	}
+	if !deadline.After(s.nowFn()) {
+		return nil, errors.New("deadline must be in the future")
+	}
+	action := req.DeadlineAction
+	if action == "" {
+		action = models.EscrowDeadlineRelease
+	}
+	if action != models.EscrowDeadlineRelease && action != models.EscrowDeadlineRefund {
+		return nil, errors.New("deadline_action must be release or refund")
+	}
+
+	escrowAccountID, err := s.escrowAccount(ctx, buyer, seller)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := auth.FromContext(ctx)
+	e := &models.Escrow{
+		BuyerAccountID:      buyer.AccountID,
+		BuyerAccountNumber:  buyer.AccountNumber,
+		SellerAccountID:     seller.AccountID,
+		SellerAccountNumber: seller.AccountNumber,
+		EscrowAccountID:     escrowAccountID,
+		Deadline:            deadline.UTC().Format(time.RFC3339),
+		DeadlineAction:      action,
+		CreatedBy:           p.Subject,
+	}
+	_, err = s.transfers.processTransaction(ctx, &models.TransactionRequest{
+		SourceAccountID:      buyer.AccountID,
+		DestinationAccountID: escrowAccountID,
+		Amount:               req.Amount,
+		InitiatedBy:          p.Subject,
+	}, func(tx *sql.Tx, t *models.Transaction) error {
+		e.EscrowAccountNumber = t.DestinationAccountNumber
+		e.AmountPennies = t.AmountPennies
+		e.FundingTransactionID = t.ID
+		e.FundingStatus = t.Status
+		if err := s.escrowRepo.CreateTx(tx, e); err != nil {
+			return errors.New("couldn't create escrow")
+		}
+		return recordAudit(ctx, tx, s.auditLog, audit.ActionEscrowCreate, audit.EntityEscrow, e.ID, nil, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// escrowAccount returns the ID of the pair's escrow account, opening it if
+// they have none.
+func (s *EscrowService) escrowAccount(ctx context.Context, buyer, seller *models.Account) (int, error) {
+	id, err := s.escrowRepo.GetAccountID(buyer.AccountID, seller.AccountID)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, repository.ErrEscrowAccountNotFound) {
+		return 0, errors.New("couldn't get escrow account")
+	}
+
+	account := &models.Account{
+		HolderName:  "Escrow " + buyer.AccountNumber + " / " + seller.AccountNumber,
+		ProductCode: models.EscrowProductCode,
+		Currency:    buyer.Currency,
+	}
+	err = s.accounts.openAccount(ctx, account, func(tx *sql.Tx, a *models.Account) error {
+		return s.escrowRepo.CreateAccountTx(tx, buyer.AccountID, seller.AccountID, a.AccountID)
+	})
+	if errors.Is(err, repository.ErrDuplicateEscrowAccount) {
+		// A concurrent escrow for the same pair opened it first
+		return s.escrowRepo.GetAccountID(buyer.AccountID, seller.AccountID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return account.AccountID, nil
+}
+
+func (s *EscrowService) GetEscrow(ctx context.Context, id int) (*models.Escrow, error) {
+	e, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, s.policy, ActionReadEscrows, Resource{AccountIDs: []int{e.BuyerAccountID, e.SellerAccountID}}); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *EscrowService) get(id int) (*models.Escrow, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid escrow_id")
+	}
+	e, err := s.escrowRepo.Get(id)
+	if err != nil {
+		return nil, repository.ErrEscrowNotFound
+	}
+	return e, nil
+}
+
+// DisputeEscrow stops a held escrow from being settled at its deadline. It
+// stays held until it is released or refunded on request.
+func (s *EscrowService) DisputeEscrow(ctx context.Context, id int, reason string) (*models.Escrow, error) {
+	e, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, s.policy, ActionDisputeEscrows, Resource{AccountIDs: []int{e.BuyerAccountID, e.SellerAccountID}}); err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if e.Status != models.EscrowHeld {
+		return nil, errors.New("escrow is already " + e.Status)
+	}
+	if e.DisputedAt != nil {
+		return nil, errors.New("escrow is already disputed")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	before := *e
+	disputedAt, err := s.escrowRepo.DisputeTx(tx, e.ID, reason)
+	if errors.Is(err, repository.ErrEscrowNotHeld) {
+		return nil, errors.New("escrow was settled or disputed meanwhile")
+	}
+	if err != nil {
+		return nil, errors.New("couldn't dispute escrow")
+	}
+	e.DisputedAt = &disputedAt
+	e.DisputeReason = reason
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionEscrowDispute, audit.EntityEscrow, e.ID, &before, e); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return e, nil
+}
+
+// ReleaseEscrow transfers the escrow to the seller, disputed or not.
+func (s *EscrowService) ReleaseEscrow(ctx context.Context, id int) (*models.Escrow, error) {
+	return s.settleByID(ctx, id, models.EscrowReleased)
+}
+
+// RefundEscrow transfers the escrow back to the buyer, disputed or not.
+func (s *EscrowService) RefundEscrow(ctx context.Context, id int) (*models.Escrow, error) {
+	return s.settleByID(ctx, id, models.EscrowRefunded)
+}
+
+func (s *EscrowService) settleByID(ctx context.Context, id int, status string) (*models.Escrow, error) {
+	if err := authorize(ctx, s.policy, ActionSettleEscrows, Resource{}); err != nil {
+		return nil, err
+	}
+	e, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.settle(ctx, e, status)
+}
+
+// settle makes the transfer that releases or refunds e and records it on
+// the escrow in the same DB transaction. An escrow whose last settlement
+// transfer was rejected, expired or failed can be settled again.
+func (s *EscrowService) settle(ctx context.Context, e *models.Escrow, status string) (*models.Escrow, error) {
+	if e.Status != models.EscrowHeld && !settlementFailed(e.SettlementStatus) {
+		return nil, errors.New("escrow is already " + e.Status)
+	}
+	switch e.FundingStatus {
+	case models.TransactionStatusCompleted:
+	case models.TransactionStatusHeld, models.TransactionStatusPendingApproval:
+		return nil, errors.New("escrow isn't funded yet")
+	default:
+		return nil, errors.New("escrow funding was " + e.FundingStatus)
+	}
+
+	destination, action := e.SellerAccountID, audit.ActionEscrowRelease
+	if status == models.EscrowRefunded {
+		destination, action = e.BuyerAccountID, audit.ActionEscrowRefund
+	}
+
+	p, _ := auth.FromContext(ctx)
+	before := *e
+	_, err := s.transfers.processTransaction(ctx, &models.TransactionRequest{
+		SourceAccountID:      e.EscrowAccountID,
+		DestinationAccountID: destination,
+		Amount:               util.PenniesToDecimalString(e.AmountPennies),
+		InitiatedBy:          p.Subject,
+	}, func(tx *sql.Tx, t *models.Transaction) error {
+		settledAt, err := s.escrowRepo.SettleTx(tx, e.ID, status, t.ID)
+		if err != nil {
+			return err
+		}
+		e.Status = status
+		e.SettlementTransactionID = &t.ID
+		e.SettlementStatus = t.Status
+		e.SettledAt = &settledAt
+		return recordAudit(ctx, tx, s.auditLog, action, audit.EntityEscrow, e.ID, &before, e)
+	})
+	if errors.Is(err, repository.ErrEscrowNotHeld) {
+		return nil, errors.New("escrow was settled meanwhile")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func settlementFailed(status string) bool {
+	switch status {
+	case models.TransactionStatusRejected, models.TransactionStatusExpired, models.TransactionStatusFailed:
+		return true
+	}
+	return false
+}
+
+// SettleDue releases or refunds, as each asks, the funded and undisputed
+// escrows whose deadline has passed, and returns how many it settled. An
+// escrow that can't be settled is logged and tried again next time.
+func (s *EscrowService) SettleDue(ctx context.Context) (int, error) {
+	due, err := s.escrowRepo.ListDue(s.nowFn(), escrowBatchSize)
+	if err != nil {
+		return 0, errors.New("couldn't list due escrows")
+	}
+
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: audit.SystemActor, Role: auth.RoleOperator})
+	settled := 0
+	for _, e := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		status := models.EscrowReleased
+		if e.DeadlineAction == models.EscrowDeadlineRefund {
+			status = models.EscrowRefunded
+		}
+		if _, err := s.settle(ctx, e, status); err != nil {
+			log.Printf("failed to settle escrow %d at its deadline: %v", e.ID, err)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+// Run settles due escrows every interval until ctx is cancelled.
+func (s *EscrowService) Run(ctx context.Context, interval time.Duration) {
+	for {
+		n, err := s.SettleDue(ctx)
+		if err != nil {
+			log.Print("failed to settle due escrows:", err)
+		} else if n > 0 {
+			log.Printf("settled %d escrows at their deadline", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEscrowRepository struct {
+	pairs   map[[2]int]int
+	escrows map[int]*models.Escrow
+}
+
+func newMockEscrowRepository() *mockEscrowRepository {
+	return &mockEscrowRepository{pairs: map[[2]int]int{}, escrows: map[int]*models.Escrow{}}
+}
+
+func (m *mockEscrowRepository) GetAccountID(buyerAccountID, sellerAccountID int) (int, error) {
+	id, ok := m.pairs[[2]int{buyerAccountID, sellerAccountID}]
+	if !ok {
+		return 0, repository.ErrEscrowAccountNotFound
+	}
+	return id, nil
+}
+
+func (m *mockEscrowRepository) CreateAccountTx(tx *sql.Tx, buyerAccountID, sellerAccountID, accountID int) error {
+	if _, ok := m.pairs[[2]int{buyerAccountID, sellerAccountID}]; ok {
+		return repository.ErrDuplicateEscrowAccount
+	}
+	m.pairs[[2]int{buyerAccountID, sellerAccountID}] = accountID
+	return nil
+}
+
+func (m *mockEscrowRepository) CreateTx(tx *sql.Tx, e *models.Escrow) error {
+	e.ID = len(m.escrows) + 1
+	e.Status = models.EscrowHeld
+	stored := *e
+	m.escrows[e.ID] = &stored
+	return nil
+}
+
+func (m *mockEscrowRepository) Get(id int) (*models.Escrow, error) {
+	e, ok := m.escrows[id]
+	if !ok {
+		return nil, repository.ErrEscrowNotFound
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (m *mockEscrowRepository) ListDue(now time.Time, limit int) ([]*models.Escrow, error) {
+	var list []*models.Escrow
+	for _, e := range m.escrows {
+		deadline, _ := time.Parse(time.RFC3339, e.Deadline)
+		if e.Status == models.EscrowHeld && e.DisputedAt == nil && !deadline.After(now) &&
+			e.FundingStatus == models.TransactionStatusCompleted {
+			copied := *e
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (m *mockEscrowRepository) DisputeTx(tx *sql.Tx, id int, reason string) (string, error) {
+	e := m.escrows[id]
+	if e.Status != models.EscrowHeld || e.DisputedAt != nil {
+		return "", repository.ErrEscrowNotHeld
+	}
+	at := "2024-03-02T00:00:00Z"
+	e.DisputedAt, e.DisputeReason = &at, reason
+	return at, nil
+}
+
+func (m *mockEscrowRepository) SettleTx(tx *sql.Tx, id int, status string, transactionID int) (string, error) {
+	e := m.escrows[id]
+	if e.Status != models.EscrowHeld && !settlementFailed(e.SettlementStatus) {
+		return "", repository.ErrEscrowNotHeld
+	}
+	at := "2024-03-03T00:00:00Z"
+	e.Status, e.SettlementTransactionID, e.SettlementStatus, e.SettledAt = status, &transactionID, models.TransactionStatusCompleted, &at
+	return at, nil
+}
+
+// fakeAccountOpener opens escrow accounts among the fake transfers'
+// accounts. The fake transfers don't move funds, so escrow accounts are
+// opened with enough to settle.
+type fakeAccountOpener struct {
+	transfers *fakeTransfers
+	opened    []*models.Account
+}
+
+func (f *fakeAccountOpener) resolveAccount(number string) (*models.Account, error) {
+	a, ok := f.transfers.accounts[number]
+	if !ok {
+		return nil, errors.New("account not found")
+	}
+	return a, nil
+}
+
+func (f *fakeAccountOpener) openAccount(ctx context.Context, account *models.Account, onCreate func(*sql.Tx, *models.Account) error) error {
+	account.AccountID = 50 + len(f.opened)
+	account.AccountNumber = "ESC" + string(rune('A'+len(f.opened)))
+	account.CurrentBalance = 100000
+	if err := onCreate(&sql.Tx{}, account); err != nil {
+		return err
+	}
+	f.opened = append(f.opened, account)
+	f.transfers.accounts[account.AccountNumber] = account
+	return nil
+}
+
+var testEscrowNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestEscrowService(repo *mockEscrowRepository) (*EscrowService, *fakeTransfers, *fakeAccountOpener) {
+	transfers := newFakeTransfers()
+	transfers.accounts["SRC1"].Currency = "GBP"
+	transfers.accounts["DST"].Currency = "GBP"
+	transfers.accounts["EUR"] = &models.Account{AccountID: 9, AccountNumber: "EUR", Currency: "EUR"}
+	accounts := &fakeAccountOpener{transfers: transfers}
+	s := NewEscrowService(&sql.DB{}, repo, accounts, transfers)
+	s.nowFn = func() time.Time { return testEscrowNow }
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s, transfers, accounts
+}
+
+func testEscrowRequest() *models.EscrowRequest {
+	return &models.EscrowRequest{BuyerAccountNumber: "SRC1", SellerAccountNumber: "DST", Amount: "4.00", Deadline: "2024-03-08T12:00:00Z"}
+}
+
+func TestCreateEscrow(t *testing.T) {
+	repo := newMockEscrowRepository()
+	s, transfers, accounts := newTestEscrowService(repo)
+
+	e, err := s.CreateEscrow(operatorCtx, testEscrowRequest())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.EscrowHeld, e.Status)
+	assert.Equal(t, models.EscrowDeadlineRelease, e.DeadlineAction)
+	assert.Equal(t, int64(400), e.AmountPennies)
+	assert.Equal(t, "ops", e.CreatedBy)
+	if assert.Len(t, accounts.opened, 1) {
+		assert.Equal(t, models.EscrowProductCode, accounts.opened[0].ProductCode)
+		assert.Equal(t, "GBP", accounts.opened[0].Currency)
+		assert.Equal(t, accounts.opened[0].AccountID, e.EscrowAccountID)
+	}
+	assert.Equal(t, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: e.EscrowAccountID, Amount: "4.00", InitiatedBy: "ops"}, transfers.made[0])
+
+	// The pair's escrow account is reused
+	req := testEscrowRequest()
+	req.DeadlineAction = models.EscrowDeadlineRefund
+	e2, err := s.CreateEscrow(operatorCtx, req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, e.EscrowAccountID, e2.EscrowAccountID)
+		assert.Len(t, accounts.opened, 1)
+	}
+
+	cases := []struct {
+		name    string
+		change  func(*models.EscrowRequest)
+		wantErr string
+	}{
+		{"unknown buyer", func(r *models.EscrowRequest) { r.BuyerAccountNumber = "NOPE" }, "buyer account not found"},
+		{"unknown seller", func(r *models.EscrowRequest) { r.SellerAccountNumber = "NOPE" }, "seller account not found"},
+		{"same account", func(r *models.EscrowRequest) { r.SellerAccountNumber = "SRC1" }, "buyer and seller accounts cannot be the same"},
+		{"different currencies", func(r *models.EscrowRequest) { r.SellerAccountNumber = "EUR" }, "buyer and seller accounts are in different currencies"},
+		{"bad amount", func(r *models.EscrowRequest) { r.Amount = "-1.00" }, "invalid amount format"},
+		{"bad deadline", func(r *models.EscrowRequest) { r.Deadline = "next week" }, "deadline must be an RFC 3339 time"},
+		{"past deadline", func(r *models.EscrowRequest) { r.Deadline = "2024-03-01T11:00:00Z" }, "deadline must be in the future"},
+		{"bad action", func(r *models.EscrowRequest) { r.DeadlineAction = "keep" }, "deadline_action must be release or refund"},
+		{"insufficient funds", func(r *models.EscrowRequest) { r.Amount = "20.00" }, "insufficient funds"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := testEscrowRequest()
+			tc.change(req)
+			_, err := s.CreateEscrow(operatorCtx, req)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+
+	_, err = s.CreateEscrow(auditorCtx, testEscrowRequest())
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.GetEscrow(auditorCtx, e.ID)
+	assert.NoError(t, err)
+	_, err = s.GetEscrow(strangerCtx, e.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestSettleEscrow(t *testing.T) {
+	repo := newMockEscrowRepository()
+	s, transfers, _ := newTestEscrowService(repo)
+	e, err := s.CreateEscrow(operatorCtx, testEscrowRequest())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = s.ReleaseEscrow(ownerCtx, e.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	released, err := s.ReleaseEscrow(operatorCtx, e.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.EscrowReleased, released.Status)
+		assert.NotNil(t, released.SettlementTransactionID)
+		assert.Equal(t, &models.TransactionRequest{SourceAccountID: e.EscrowAccountID, DestinationAccountID: 3, Amount: "4.00", InitiatedBy: "ops"},
+			transfers.made[len(transfers.made)-1])
+	}
+	_, err = s.RefundEscrow(operatorCtx, e.ID)
+	assert.EqualError(t, err, "escrow is already released")
+
+	// A rejected settlement can be made again
+	repo.escrows[e.ID].SettlementStatus = models.TransactionStatusRejected
+	refunded, err := s.RefundEscrow(operatorCtx, e.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.EscrowRefunded, refunded.Status)
+		assert.Equal(t, 1, transfers.made[len(transfers.made)-1].DestinationAccountID)
+	}
+
+	unfunded, err := s.CreateEscrow(operatorCtx, testEscrowRequest())
+	if assert.NoError(t, err) {
+		repo.escrows[unfunded.ID].FundingStatus = models.TransactionStatusPendingApproval
+		_, err = s.ReleaseEscrow(operatorCtx, unfunded.ID)
+		assert.EqualError(t, err, "escrow isn't funded yet")
+		repo.escrows[unfunded.ID].FundingStatus = models.TransactionStatusExpired
+		_, err = s.ReleaseEscrow(operatorCtx, unfunded.ID)
+		assert.EqualError(t, err, "escrow funding was expired")
+	}
+
+	_, err = s.ReleaseEscrow(operatorCtx, 99)
+	assert.ErrorIs(t, err, repository.ErrEscrowNotFound)
+}
+
+func TestDisputeAndSettleDueEscrows(t *testing.T) {
+	repo := newMockEscrowRepository()
+	s, transfers, _ := newTestEscrowService(repo)
+	var ids []int
+	for _, action := range []string{models.EscrowDeadlineRelease, models.EscrowDeadlineRefund, models.EscrowDeadlineRelease} {
+		req := testEscrowRequest()
+		req.Amount = "2.00"
+		req.DeadlineAction = action
+		e, err := s.CreateEscrow(operatorCtx, req)
+		if !assert.NoError(t, err) {
+			return
+		}
+		ids = append(ids, e.ID)
+	}
+
+	_, err := s.DisputeEscrow(operatorCtx, ids[2], " ")
+	assert.EqualError(t, err, "reason is required")
+	_, err = s.DisputeEscrow(strangerCtx, ids[2], "not delivered")
+	assert.ErrorIs(t, err, ErrForbidden)
+	disputed, err := s.DisputeEscrow(operatorCtx, ids[2], "not delivered")
+	if assert.NoError(t, err) {
+		assert.NotNil(t, disputed.DisputedAt)
+		assert.Equal(t, "not delivered", disputed.DisputeReason)
+	}
+	_, err = s.DisputeEscrow(operatorCtx, ids[2], "again")
+	assert.EqualError(t, err, "escrow is already disputed")
+
+	// Nothing is due before the deadline
+	n, err := s.SettleDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	made := len(transfers.made)
+	s.nowFn = func() time.Time { return testEscrowNow.Add(8 * 24 * time.Hour) }
+	n, err = s.SettleDue(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, n)
+	assert.Equal(t, models.EscrowReleased, repo.escrows[ids[0]].Status)
+	assert.Equal(t, models.EscrowRefunded, repo.escrows[ids[1]].Status)
+	assert.Equal(t, models.EscrowHeld, repo.escrows[ids[2]].Status, "a disputed escrow isn't settled at its deadline")
+	assert.Equal(t, []string{"system", "system"}, transfers.callers[made:])
+
+	// A disputed escrow is still settled on request
+	_, err = s.RefundEscrow(operatorCtx, ids[2])
+	assert.NoError(t, err)
+	_, err = s.DisputeEscrow(operatorCtx, ids[2], "too late")
+	assert.EqualError(t, err, "escrow is already refunded")
+}
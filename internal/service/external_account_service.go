@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"strings"
+)
+
+func NewExternalAccountService(
+	db *sql.DB,
+	externalRepo repository.ExternalAccountRepository,
+	customerRepo repository.CustomerRepository,
+	opts ...func(*ExternalAccountService),
+) *ExternalAccountService {
+	s := &ExternalAccountService{
+		db:           db,
+		externalRepo: externalRepo,
+		customerRepo: customerRepo,
+		policy:       NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithSortCodeRules enables Vocalink modulus checking of UK details. Without
+// it only the format of sort codes and account numbers is checked.
+func WithSortCodeRules(v *util.SortCodeValidator) func(*ExternalAccountService) {
+	return func(s *ExternalAccountService) {
+		s.sortCodes = v
+	}
+}
+
+// WithExternalAccountPolicy sets the authorization policy.
+func WithExternalAccountPolicy(policy Policy) func(*ExternalAccountService) {
+	return func(s *ExternalAccountService) {
+		s.policy = policy
+	}
+}
+
+// WithExternalAccountAuditLog records registrations and removals in the
+// audit log.
+func WithExternalAccountAuditLog(auditLog repository.AuditLogRepository) func(*ExternalAccountService) {
+	return func(s *ExternalAccountService) {
+		s.auditLog = auditLog
+	}
+}
+
+type ExternalAccountService struct {
+	db           *sql.DB
+	externalRepo repository.ExternalAccountRepository
+	customerRepo repository.CustomerRepository
+	sortCodes    *util.SortCodeValidator
+	auditLog     repository.AuditLogRepository
+	policy       Policy
+	beginFn      func() (*sql.Tx, error)
+	rollbackFn   func(*sql.Tx) error
+	commitFn     func(*sql.Tx) error
+}
+
+// ValidateDetails checks bank details without saving them and returns them
+// normalized, so clients can confirm what would be registered.
+func (s *ExternalAccountService) ValidateDetails(req *models.ExternalAccountRequest) (*models.ExternalAccount, error) {
+	e := &models.ExternalAccount{
+		Type:       strings.ToLower(strings.TrimSpace(req.Type)),
+		Label:      strings.TrimSpace(req.Label),
+		HolderName: strings.TrimSpace(req.HolderName),
+	}
+	if e.HolderName == "" {
+		return nil, errors.New("holder name is required")
+	}
+
+	switch e.Type {
+	case models.ExternalAccountTypeIBAN:
+		if err := util.ValidateIBAN(req.IBAN); err != nil {
+			return nil, err
+		}
+		e.IBAN = util.NormalizeIBAN(req.IBAN)
+		if req.BIC != "" {
+			if err := util.ValidateBIC(req.BIC); err != nil {
+				return nil, err
+			}
+			e.BIC = strings.ToUpper(strings.TrimSpace(req.BIC))
+		}
+	case models.ExternalAccountTypeUK:
+		if err := s.sortCodes.Validate(req.SortCode, req.AccountNumber); err != nil {
+			return nil, err
+		}
+		e.SortCode = util.NormalizeSortCode(req.SortCode)
+		e.AccountNumber = util.NormalizeUKAccountNumber(req.AccountNumber)
+	case models.ExternalAccountTypeUS:
+		if err := util.ValidateABARoutingNumber(req.RoutingNumber); err != nil {
+			return nil, err
+		}
+		e.RoutingNumber = strings.TrimSpace(req.RoutingNumber)
+		e.AccountNumber = strings.TrimSpace(req.AccountNumber)
+		if len(e.AccountNumber) < 4 || len(e.AccountNumber) > 17 || strings.Trim(e.AccountNumber, "0123456789") != "" {
+			return nil, errors.New("invalid US account number format")
+		}
+	default:
+		return nil, errors.New("invalid type")
+	}
+
+	return e, nil
+}
+
+func (s *ExternalAccountService) RegisterExternalAccount(ctx context.Context, customerID int, req *models.ExternalAccountRequest) (*models.ExternalAccount, error) {
+	if err := s.checkCustomer(ctx, ActionManageExternalAccounts, customerID); err != nil {
+		return nil, err
+	}
+
+	e, err := s.ValidateDetails(req)
+	if err != nil {
+		return nil, err
+	}
+	e.CustomerID = customerID
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.externalRepo.CreateTx(tx, e); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionExternalAccountCreate, audit.EntityExternalAccount, e.ID, nil, e); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return e, nil
+}
+
+func (s *ExternalAccountService) GetExternalAccount(ctx context.Context, customerID, id int) (*models.ExternalAccount, error) {
+	if customerID <= 0 {
+		return nil, errors.New("invalid customer_id")
+	}
+	if id <= 0 {
+		return nil, errors.New("invalid external_account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadExternalAccounts, Resource{CustomerID: customerID}); err != nil {
+		return nil, err
+	}
+
+	e, err := s.externalRepo.GetByID(customerID, id)
+	if err != nil {
+		return nil, errors.New("external account not found")
+	}
+	return e, nil
+}
+
+func (s *ExternalAccountService) ListExternalAccounts(ctx context.Context, customerID int) ([]*models.ExternalAccount, error) {
+	if err := s.checkCustomer(ctx, ActionReadExternalAccounts, customerID); err != nil {
+		return nil, err
+	}
+
+	list, err := s.externalRepo.ListByCustomer(customerID)
+	if err != nil {
+		return nil, errors.New("couldn't list external accounts")
+	}
+	return list, nil
+}
+
+func (s *ExternalAccountService) RemoveExternalAccount(ctx context.Context, customerID, id int) error {
+	if customerID <= 0 {
+		return errors.New("invalid customer_id")
+	}
+	if id <= 0 {
+		return errors.New("invalid external_account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageExternalAccounts, Resource{CustomerID: customerID}); err != nil {
+		return err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	removed, err := s.externalRepo.DeleteTx(tx, customerID, id)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionExternalAccountDelete, audit.EntityExternalAccount, id, removed, nil); err != nil {
+		return err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
+
+// checkCustomer authorizes action on the customer's external accounts and
+// checks that the customer exists.
+func (s *ExternalAccountService) checkCustomer(ctx context.Context, action Action, customerID int) error {
+	if customerID <= 0 {
+		return errors.New("invalid customer_id")
+	}
+	if err := authorize(ctx, s.policy, action, Resource{CustomerID: customerID}); err != nil {
+		return err
+	}
+
+	exists, err := s.customerRepo.Exists(customerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("customer not found")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockExternalAccountRepository struct {
+	createFn         func(*models.ExternalAccount) error
+	getByIDFn        func(int, int) (*models.ExternalAccount, error)
+	listByCustomerFn func(int) ([]*models.ExternalAccount, error)
+	deleteFn         func(int, int) error
+}
+
+func (m *mockExternalAccountRepository) CreateTx(tx *sql.Tx, e *models.ExternalAccount) error {
+	if m.createFn != nil {
+		return m.createFn(e)
+	}
+	return nil
+}
+
+func (m *mockExternalAccountRepository) GetByID(customerID, id int) (*models.ExternalAccount, error) {
+	if m.getByIDFn != nil {
+		return m.getByIDFn(customerID, id)
+	}
+	return nil, nil
+}
+
+func (m *mockExternalAccountRepository) ListByCustomer(customerID int) ([]*models.ExternalAccount, error) {
+	if m.listByCustomerFn != nil {
+		return m.listByCustomerFn(customerID)
+	}
+	return nil, nil
+}
+
+func (m *mockExternalAccountRepository) DeleteTx(tx *sql.Tx, customerID, id int) (*models.ExternalAccount, error) {
+	if m.deleteFn != nil {
+		if err := m.deleteFn(customerID, id); err != nil {
+			return nil, err
+		}
+	}
+	return &models.ExternalAccount{ID: id, CustomerID: customerID}, nil
+}
+
+// newTestExternalAccountService stubs out the DB transaction functions.
+func newTestExternalAccountService(repo repository.ExternalAccountRepository, customers repository.CustomerRepository, opts ...func(*ExternalAccountService)) *ExternalAccountService {
+	s := NewExternalAccountService(&sql.DB{}, repo, customers, opts...)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+func TestValidateDetails(t *testing.T) {
+	rules, err := util.ParseSortCodeRules(strings.NewReader(
+		"089000 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 1\n"))
+	assert.NoError(t, err)
+	svc := newTestExternalAccountService(&mockExternalAccountRepository{}, &mockCustomerRepository{}, WithSortCodeRules(rules))
+
+	cases := []struct {
+		name    string
+		req     models.ExternalAccountRequest
+		wantErr string
+		want    models.ExternalAccount
+	}{
+		{name: "missing_holder", req: models.ExternalAccountRequest{Type: "iban", IBAN: "GB82WEST12345698765432"}, wantErr: "holder name is required"},
+		{name: "invalid_type", req: models.ExternalAccountRequest{Type: "swift", HolderName: "Jane"}, wantErr: "invalid type"},
+		{
+			name: "iban",
+			req:  models.ExternalAccountRequest{Type: "IBAN", HolderName: " Jane ", IBAN: "gb82 west 1234 5698 7654 32", BIC: "westgb2l"},
+			want: models.ExternalAccount{Type: "iban", HolderName: "Jane", IBAN: "GB82WEST12345698765432", BIC: "WESTGB2L"},
+		},
+		{name: "iban_check_digits", req: models.ExternalAccountRequest{Type: "iban", HolderName: "Jane", IBAN: "GB83WEST12345698765432"}, wantErr: "invalid IBAN check digits"},
+		{name: "iban_bad_bic", req: models.ExternalAccountRequest{Type: "iban", HolderName: "Jane", IBAN: "GB82WEST12345698765432", BIC: "WEST"}, wantErr: "invalid BIC format"},
+		{
+			name: "uk",
+			req:  models.ExternalAccountRequest{Type: "uk", HolderName: "Jane", SortCode: "08-99-99", AccountNumber: "66374958"},
+			want: models.ExternalAccount{Type: "uk", HolderName: "Jane", SortCode: "089999", AccountNumber: "66374958"},
+		},
+		{name: "uk_modulus", req: models.ExternalAccountRequest{Type: "uk", HolderName: "Jane", SortCode: "089999", AccountNumber: "66374959"}, wantErr: "sort code and account number failed modulus check"},
+		{
+			name: "us",
+			req:  models.ExternalAccountRequest{Type: "us", HolderName: "Jane", RoutingNumber: "021000021", AccountNumber: "000123456789"},
+			want: models.ExternalAccount{Type: "us", HolderName: "Jane", RoutingNumber: "021000021", AccountNumber: "000123456789"},
+		},
+		{name: "us_routing", req: models.ExternalAccountRequest{Type: "us", HolderName: "Jane", RoutingNumber: "021000022", AccountNumber: "1234"}, wantErr: "invalid routing number check digit"},
+		{name: "us_account", req: models.ExternalAccountRequest{Type: "us", HolderName: "Jane", RoutingNumber: "021000021", AccountNumber: "12a4"}, wantErr: "invalid US account number format"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := svc.ValidateDetails(&tc.req)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &tc.want, got)
+		})
+	}
+}
+
+func TestRegisterExternalAccount(t *testing.T) {
+	req := &models.ExternalAccountRequest{Type: "iban", HolderName: "Jane", IBAN: "DE89370400440532013000"}
+	exists := &mockCustomerRepository{existsFn: func(id int) (bool, error) { return id == 1, nil }}
+
+	svc := newTestExternalAccountService(&mockExternalAccountRepository{}, exists)
+	_, err := svc.RegisterExternalAccount(operatorCtx, 0, req)
+	assert.EqualError(t, err, "invalid customer_id")
+	_, err = svc.RegisterExternalAccount(operatorCtx, 2, req)
+	assert.EqualError(t, err, "customer not found")
+
+	svc = newTestExternalAccountService(&mockExternalAccountRepository{createFn: func(*models.ExternalAccount) error {
+		return errors.New("external account already registered")
+	}}, exists)
+	_, err = svc.RegisterExternalAccount(operatorCtx, 1, req)
+	assert.EqualError(t, err, "external account already registered")
+
+	svc = newTestExternalAccountService(&mockExternalAccountRepository{createFn: func(e *models.ExternalAccount) error {
+		e.ID = 9
+		return nil
+	}}, exists)
+	got, err := svc.RegisterExternalAccount(operatorCtx, 1, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, got.ID)
+	assert.Equal(t, 1, got.CustomerID)
+}
+
+func TestGetAndRemoveExternalAccount(t *testing.T) {
+	repo := &mockExternalAccountRepository{
+		getByIDFn: func(customerID, id int) (*models.ExternalAccount, error) {
+			if customerID == 1 && id == 9 {
+				return &models.ExternalAccount{ID: 9, CustomerID: 1}, nil
+			}
+			return nil, errors.New("external account not found")
+		},
+		deleteFn: func(customerID, id int) error {
+			if customerID == 1 && id == 9 {
+				return nil
+			}
+			return errors.New("external account not found")
+		},
+	}
+	svc := newTestExternalAccountService(repo, &mockCustomerRepository{})
+
+	_, err := svc.GetExternalAccount(operatorCtx, 1, 0)
+	assert.EqualError(t, err, "invalid external_account_id")
+	_, err = svc.GetExternalAccount(operatorCtx, 2, 9)
+	assert.EqualError(t, err, "external account not found")
+	got, err := svc.GetExternalAccount(operatorCtx, 1, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, got.ID)
+
+	assert.EqualError(t, svc.RemoveExternalAccount(operatorCtx, 0, 9), "invalid customer_id")
+	assert.EqualError(t, svc.RemoveExternalAccount(operatorCtx, 2, 9), "external account not found")
+	assert.NoError(t, svc.RemoveExternalAccount(operatorCtx, 1, 9))
+}
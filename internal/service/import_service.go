@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/imports"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	maxImportRows          = 10000
+	maxImportFileName      = 255
+	defaultImportBatchSize = 50
+)
+
+// transferExecutor validates and makes the transfers of an import.
+// TransactionService implements it.
+type transferExecutor interface {
+	validateTransfer(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, *models.Account, error)
+	processTransaction(ctx context.Context, req *models.TransactionRequest, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error)
+	settleTransfer(ctx context.Context, req *models.TransactionRequest, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error)
+}
+
+func NewImportService(db *sql.DB, importRepo repository.TransferImportRepository, transfers transferExecutor, opts ...func(*ImportService)) *ImportService {
+	s := &ImportService{
+		db:         db,
+		importRepo: importRepo,
+		transfers:  transfers,
+		batchSize:  defaultImportBatchSize,
+		policy:     NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithImportPolicy sets the authorization policy.
+func WithImportPolicy(policy Policy) func(*ImportService) {
+	return func(s *ImportService) {
+		s.policy = policy
+	}
+}
+
+// WithImportAuditLog records uploads and confirmations in the audit log. The
+// transfers themselves are audited as they are made.
+func WithImportAuditLog(auditLog repository.AuditLogRepository) func(*ImportService) {
+	return func(s *ImportService) {
+		s.auditLog = auditLog
+	}
+}
+
+// ImportService runs bulk transfer files. An upload is a dry run: every row
+// is checked with ProcessTransaction's rules and nothing moves until the
+// import is confirmed. ProcessBatch then makes the transfers in line order,
+// on behalf of whoever confirmed it.
+type ImportService struct {
+	db         *sql.DB
+	importRepo repository.TransferImportRepository
+	transfers  transferExecutor
+	batchSize  int
+	auditLog   repository.AuditLogRepository
+	policy     Policy
+	beginFn    func() (*sql.Tx, error)
+	rollbackFn func(*sql.Tx) error
+	commitFn   func(*sql.Tx) error
+}
+
+// CreateImport reads a transfer CSV, validates every row and stores the
+// result for confirmation. The returned import carries the dry-run report.
+// A malformed file is rejected; invalid rows are reported with the reason.
+func (s *ImportService) CreateImport(ctx context.Context, fileName string, r io.Reader) (*models.TransferImport, error) {
+	if err := authorize(ctx, s.policy, ActionImportTransfers, Resource{}); err != nil {
+		return nil, err
+	}
+	p, _ := auth.FromContext(ctx)
+
+	fileName = strings.TrimSpace(fileName)
+	if fileName == "" {
+		fileName = "transfers.csv"
+	}
+	fileName = truncate(fileName, maxImportFileName)
+
+	rows, err := imports.ParseCSV(r, maxImportRows)
+	if err != nil {
+		return nil, err
+	}
+	s.validateRows(ctx, p.Subject, rows)
+
+	imp := &models.TransferImport{FileName: fileName, CreatedBy: p.Subject}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.importRepo.CreateTx(tx, imp, rows); err != nil {
+		return nil, errors.New("couldn't create transfer import")
+	}
+	summarizeImport(imp, rows)
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionTransferImportCreate, audit.EntityTransferImport, imp.ID, nil, imp); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	imp.Rows = rows
+	return imp, nil
+}
+
+// validateRows marks each row valid or invalid. Rows from the same source
+// account must be covered by its balance and overdraft together, since they
+// will be made one after the other.
+func (s *ImportService) validateRows(ctx context.Context, initiatedBy string, rows []*models.TransferImportRow) {
+	debited := map[int]int64{}
+	for _, row := range rows {
+		if row.Status == models.ImportRowInvalid {
+			continue
+		}
+		invalid := func(reason string) {
+			row.Status = models.ImportRowInvalid
+			row.Error = reason
+		}
+
+		switch {
+		case row.SourceAccountNumber == "":
+			invalid("source is required")
+			continue
+		case row.DestinationAccountNumber == "":
+			invalid("destination is required")
+			continue
+		}
+
+		t, source, err := s.transfers.validateTransfer(ctx, transferRequest(row, initiatedBy))
+		if err != nil {
+			invalid(err.Error())
+			continue
+		}
+
+		if !canDebit(source, debited[source.AccountID]+t.AmountPennies) {
+			invalid("insufficient funds for this and earlier rows from the same account")
+			continue
+		}
+		debited[source.AccountID] += t.AmountPennies
+
+		row.Status = models.ImportRowValid
+		row.AmountPennies = t.AmountPennies
+	}
+}
+
+func transferRequest(row *models.TransferImportRow, initiatedBy string) *models.TransactionRequest {
+	return &models.TransactionRequest{
+		SourceAccountNumber:      row.SourceAccountNumber,
+		DestinationAccountNumber: row.DestinationAccountNumber,
+		Amount:                   row.Amount,
+		InitiatedBy:              initiatedBy,
+	}
+}
+
+// summarizeImport sets the row counts and total of a new import.
+func summarizeImport(imp *models.TransferImport, rows []*models.TransferImportRow) {
+	imp.RowCount = len(rows)
+	imp.RowStatuses = map[string]int{}
+	imp.TotalPennies = 0
+	for _, row := range rows {
+		imp.RowStatuses[row.Status]++
+		imp.TotalPennies += row.AmountPennies
+	}
+}
+
+// GetImport returns an import with its progress: the number of rows in each
+// status.
+func (s *ImportService) GetImport(ctx context.Context, id int) (*models.TransferImport, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid import_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadTransferImports, Resource{}); err != nil {
+		return nil, err
+	}
+
+	imp, err := s.importRepo.Get(id)
+	if err != nil {
+		return nil, errors.New("transfer import not found")
+	}
+	return imp, nil
+}
+
+// ListImportRows returns every row of an import with its outcome so far.
+func (s *ImportService) ListImportRows(ctx context.Context, id int) ([]*models.TransferImportRow, error) {
+	if _, err := s.GetImport(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.importRepo.ListRows(id)
+	if err != nil {
+		return nil, errors.New("couldn't list transfer import rows")
+	}
+	return rows, nil
+}
+
+// ConfirmImport queues a validated import for execution on behalf of the
+// caller. Imports with invalid rows can't be confirmed, so a payout file is
+// never half made by accident: correct it and upload it again.
+func (s *ImportService) ConfirmImport(ctx context.Context, id int) (*models.TransferImport, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid import_id")
+	}
+	if err := authorize(ctx, s.policy, ActionImportTransfers, Resource{}); err != nil {
+		return nil, err
+	}
+	p, _ := auth.FromContext(ctx)
+
+	before, err := s.importRepo.Get(id)
+	if err != nil {
+		return nil, errors.New("transfer import not found")
+	}
+	if before.Status != models.TransferImportValidated {
+		return nil, errors.New("transfer import is already confirmed")
+	}
+	if before.RowStatuses[models.ImportRowInvalid] > 0 {
+		return nil, errors.New("transfer import has invalid rows; correct the file and upload it again")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	// Only validated imports are updated, so a concurrent confirmation loses
+	imp, err := s.importRepo.ConfirmTx(tx, id, p.Subject, p.Role, p.Method)
+	if err != nil {
+		return nil, errors.New("transfer import is already confirmed")
+	}
+	imp.RowCount = before.RowCount
+	imp.RowStatuses = map[string]int{models.ImportRowPending: before.RowStatuses[models.ImportRowValid]}
+	imp.TotalPennies = before.TotalPennies
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionTransferImportConfirm, audit.EntityTransferImport, id, before, imp); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return imp, nil
+}
+
+// ProcessBatch makes the transfers of one batch of confirmed rows and returns
+// how many rows it processed. A row whose transfer is refused, for example
+// because the balance changed since the dry run, is marked failed with the
+// reason. Imports with nothing left to do are then marked completed.
+func (s *ImportService) ProcessBatch(ctx context.Context) (int, error) {
+	due, err := s.importRepo.ListDue(s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, row := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.executeRow(ctx, row); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+
+	if _, err := s.importRepo.CompleteImports(); err != nil {
+		return processed, err
+	}
+	return processed, nil
+}
+
+// executeRow makes a row's transfer and marks the row in the same DB
+// transaction, so a row is never made twice even if two workers pick it up.
+func (s *ImportService) executeRow(ctx context.Context, row *models.DueTransferImportRow) error {
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{
+		Subject: row.ConfirmedBy,
+		Role:    row.ConfirmedRole,
+		Method:  row.ConfirmedAuthMethod,
+	})
+
+	_, err := s.transfers.processTransaction(ctx, transferRequest(row.TransferImportRow, row.ConfirmedBy),
+		func(tx *sql.Tx, t *models.Transaction) error {
+			return s.importRepo.CompleteRowTx(tx, row.ImportID, row.Line, t)
+		})
+	if errors.Is(err, repository.ErrImportRowNotPending) {
+		return nil
+	}
+	if err != nil {
+		return s.importRepo.FailRow(row.ImportID, row.Line, err.Error())
+	}
+	return nil
+}
+
+// Run processes confirmed imports until ctx is cancelled, polling every
+// interval when there is nothing to do.
+func (s *ImportService) Run(ctx context.Context, interval time.Duration) {
+	for {
+		n, err := s.ProcessBatch(ctx)
+		if err != nil {
+			log.Print("failed to process transfer imports:", err)
+		}
+		if err == nil && n == s.batchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
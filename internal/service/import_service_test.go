@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTransferImportRepository keeps imports and their rows in memory.
+type mockTransferImportRepository struct {
+	imports []*models.TransferImport
+	rows    []*models.TransferImportRow
+	// confirmedBy is the principal each import was confirmed by.
+	confirmedBy map[int]*auth.Principal
+}
+
+func (m *mockTransferImportRepository) CreateTx(tx *sql.Tx, imp *models.TransferImport, rows []*models.TransferImportRow) error {
+	imp.ID = len(m.imports) + 1
+	imp.Status = models.TransferImportValidated
+	stored := *imp
+	m.imports = append(m.imports, &stored)
+	for _, r := range rows {
+		r.ImportID = imp.ID
+		c := *r
+		m.rows = append(m.rows, &c)
+	}
+	return nil
+}
+
+func (m *mockTransferImportRepository) Get(id int) (*models.TransferImport, error) {
+	for _, imp := range m.imports {
+		if imp.ID != id {
+			continue
+		}
+		c := *imp
+		c.RowCount, c.TotalPennies, c.RowStatuses = 0, 0, map[string]int{}
+		for _, r := range m.rows {
+			if r.ImportID == id {
+				c.RowCount++
+				c.RowStatuses[r.Status]++
+				c.TotalPennies += r.AmountPennies
+			}
+		}
+		return &c, nil
+	}
+	return nil, errors.New("transfer import not found")
+}
+
+func (m *mockTransferImportRepository) ListRows(importID int) ([]*models.TransferImportRow, error) {
+	var list []*models.TransferImportRow
+	for _, r := range m.rows {
+		if r.ImportID == importID {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockTransferImportRepository) ConfirmTx(tx *sql.Tx, id int, by, role, authMethod string) (*models.TransferImport, error) {
+	for _, imp := range m.imports {
+		if imp.ID != id || imp.Status != models.TransferImportValidated {
+			continue
+		}
+		imp.Status = models.TransferImportProcessing
+		imp.ConfirmedBy = by
+		if m.confirmedBy == nil {
+			m.confirmedBy = map[int]*auth.Principal{}
+		}
+		m.confirmedBy[id] = &auth.Principal{Subject: by, Role: role, Method: authMethod}
+		for _, r := range m.rows {
+			if r.ImportID == id && r.Status == models.ImportRowValid {
+				r.Status = models.ImportRowPending
+			}
+		}
+		c := *imp
+		return &c, nil
+	}
+	return nil, errors.New("transfer import not found")
+}
+
+func (m *mockTransferImportRepository) ListDue(limit int) ([]*models.DueTransferImportRow, error) {
+	var list []*models.DueTransferImportRow
+	for _, r := range m.rows {
+		if r.Status != models.ImportRowPending || len(list) == limit {
+			continue
+		}
+		p := m.confirmedBy[r.ImportID]
+		list = append(list, &models.DueTransferImportRow{TransferImportRow: r, ConfirmedBy: p.Subject, ConfirmedRole: p.Role, ConfirmedAuthMethod: p.Method})
+	}
+	return list, nil
+}
+
+func (m *mockTransferImportRepository) find(importID, line int) *models.TransferImportRow {
+	for _, r := range m.rows {
+		if r.ImportID == importID && r.Line == line {
+			return r
+		}
+	}
+	return nil
+}
+
+func (m *mockTransferImportRepository) CompleteRowTx(tx *sql.Tx, importID, line int, t *models.Transaction) error {
+	r := m.find(importID, line)
+	if r == nil || r.Status != models.ImportRowPending {
+		return repository.ErrImportRowNotPending
+	}
+	r.Status = models.ImportRowCreated
+	r.TransactionID = &t.ID
+	r.TransactionStatus = t.Status
+	return nil
+}
+
+func (m *mockTransferImportRepository) FailRow(importID, line int, reason string) error {
+	if r := m.find(importID, line); r != nil && r.Status == models.ImportRowPending {
+		r.Status = models.ImportRowFailed
+		r.Error = reason
+	}
+	return nil
+}
+
+func (m *mockTransferImportRepository) CompleteImports() (int64, error) {
+	var n int64
+	for _, imp := range m.imports {
+		if imp.Status != models.TransferImportProcessing {
+			continue
+		}
+		pending := false
+		for _, r := range m.rows {
+			pending = pending || (r.ImportID == imp.ID && r.Status == models.ImportRowPending)
+		}
+		if !pending {
+			imp.Status = models.TransferImportCompleted
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeTransfers knows a few accounts by number and settles every transfer
+// it is asked to make, unless its amount is listed in fail.
+type fakeTransfers struct {
+	accounts map[string]*models.Account
+	fail     map[int64]error
+	made     []*models.TransactionRequest
+	callers  []string
+}
+
+func newFakeTransfers() *fakeTransfers {
+	return &fakeTransfers{accounts: map[string]*models.Account{
+		"SRC1": {AccountID: 1, AccountNumber: "SRC1", CurrentBalance: 1000, Currency: "GBP"},
+		"SRC2": {AccountID: 2, AccountNumber: "SRC2", CurrentBalance: 1000, Currency: "GBP"},
+		"DST":  {AccountID: 3, AccountNumber: "DST", Currency: "GBP"},
+		"ACH":  {AccountID: 4, AccountNumber: "ACH", CurrentBalance: 100000, Currency: "GBP"},
+	}}
+}
+
+// lookup finds an account by number, or by ID when no number is given.
+func (f *fakeTransfers) lookup(number string, id int) (*models.Account, bool) {
+	if number == "" {
+		for _, a := range f.accounts {
+			if a.AccountID == id {
+				return a, true
+			}
+		}
+		return nil, false
+	}
+	a, ok := f.accounts[number]
+	return a, ok
+}
+
+func (f *fakeTransfers) validateTransfer(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, *models.Account, error) {
+	if err := authorize(ctx, NewRolePolicy(nil), ActionDebitAccount, Resource{AccountIDs: []int{1}}); err != nil {
+		return nil, nil, err
+	}
+	source, ok := f.lookup(req.SourceAccountNumber, req.SourceAccountID)
+	if !ok {
+		return nil, nil, errors.New("source account not found")
+	}
+	if _, ok := f.lookup(req.DestinationAccountNumber, req.DestinationAccountID); !ok {
+		return nil, nil, errors.New("destination account not found")
+	}
+	amount, err := util.DecimalStringToPennies(req.Amount)
+	if err != nil || amount <= 0 {
+		return nil, nil, errors.New("invalid amount format")
+	}
+	if source.CurrentBalance < amount {
+		return nil, nil, errors.New("insufficient funds")
+	}
+	return &models.Transaction{AmountPennies: amount, Status: models.TransactionStatusCompleted}, source, nil
+}
+
+func (f *fakeTransfers) processTransaction(ctx context.Context, req *models.TransactionRequest, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error) {
+	t, _, err := f.validateTransfer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.fail[t.AmountPennies]; err != nil {
+		return nil, err
+	}
+	t.ID = 100 + len(f.made)
+	if onCreate != nil {
+		if err := onCreate(&sql.Tx{}, t); err != nil {
+			return nil, err
+		}
+	}
+	p, _ := auth.FromContext(ctx)
+	f.made = append(f.made, req)
+	f.callers = append(f.callers, p.Subject)
+	return t, nil
+}
+
+// settleTransfer moves the funds whatever the source's balance.
+func (f *fakeTransfers) settleTransfer(ctx context.Context, req *models.TransactionRequest, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error) {
+	source, ok := f.lookup(req.SourceAccountNumber, req.SourceAccountID)
+	if !ok {
+		return nil, errors.New("source account not found")
+	}
+	dest, ok := f.lookup(req.DestinationAccountNumber, req.DestinationAccountID)
+	if !ok {
+		return nil, errors.New("destination account not found")
+	}
+	amount, err := util.DecimalStringToPennies(req.Amount)
+	if err != nil || amount <= 0 {
+		return nil, errors.New("invalid amount format")
+	}
+	t := &models.Transaction{ID: 100 + len(f.made), AmountPennies: amount, Status: models.TransactionStatusCompleted}
+	if onCreate != nil {
+		if err := onCreate(&sql.Tx{}, t); err != nil {
+			return nil, err
+		}
+	}
+	source.CurrentBalance -= amount
+	dest.CurrentBalance += amount
+	p, _ := auth.FromContext(ctx)
+	f.made = append(f.made, req)
+	f.callers = append(f.callers, p.Subject)
+	return t, nil
+}
+
+func newTestImportService(repo *mockTransferImportRepository, transfers *fakeTransfers, opts ...func(*ImportService)) *ImportService {
+	s := NewImportService(&sql.DB{}, repo, transfers, opts...)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+const testImportCSV = `source,destination,amount,reference
+SRC1,DST,6.00,first
+SRC1,DST,5.00,second
+SRC2,DST,5.00
+SRC2,NOPE,1.00
+,DST,1.00
+SRC2,DST,abc
+`
+
+func TestCreateImport_DryRun(t *testing.T) {
+	repo := &mockTransferImportRepository{}
+	auditLog := &mockAuditLog{}
+	transfers := newFakeTransfers()
+	svc := newTestImportService(repo, transfers, WithImportAuditLog(auditLog))
+
+	imp, err := svc.CreateImport(operatorCtx, "payouts.csv", strings.NewReader(testImportCSV))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.TransferImportValidated, imp.Status)
+	assert.Equal(t, "ops", imp.CreatedBy)
+	assert.Equal(t, 6, imp.RowCount)
+	assert.Equal(t, map[string]int{models.ImportRowValid: 2, models.ImportRowInvalid: 4}, imp.RowStatuses)
+	assert.Equal(t, int64(1100), imp.TotalPennies)
+
+	var report []string
+	for _, r := range imp.Rows {
+		report = append(report, r.Status+" "+r.Error)
+	}
+	assert.Equal(t, []string{
+		"valid ",
+		"invalid insufficient funds for this and earlier rows from the same account",
+		"valid ",
+		"invalid destination account not found",
+		"invalid source is required",
+		"invalid invalid amount format",
+	}, report)
+	assert.Empty(t, transfers.made, "a dry run makes no transfers")
+	assert.Equal(t, []string{audit.ActionTransferImportCreate}, auditLog.actions())
+}
+
+func TestCreateImport_Errors(t *testing.T) {
+	svc := newTestImportService(&mockTransferImportRepository{}, newFakeTransfers())
+
+	_, err := svc.CreateImport(ownerCtx, "", strings.NewReader(testImportCSV))
+	assert.Equal(t, ErrForbidden, err)
+	_, err = svc.CreateImport(operatorCtx, "", strings.NewReader("source,amount\n"))
+	assert.EqualError(t, err, `missing column "destination"`)
+}
+
+func TestConfirmImport(t *testing.T) {
+	repo := &mockTransferImportRepository{}
+	svc := newTestImportService(repo, newFakeTransfers())
+
+	bad, _ := svc.CreateImport(operatorCtx, "", strings.NewReader(testImportCSV))
+	_, err := svc.ConfirmImport(operatorCtx, bad.ID)
+	assert.EqualError(t, err, "transfer import has invalid rows; correct the file and upload it again")
+
+	good, _ := svc.CreateImport(operatorCtx, "", strings.NewReader("source,destination,amount\nSRC1,DST,1.00\nSRC2,DST,2.00\n"))
+	_, err = svc.ConfirmImport(auditorCtx, good.ID)
+	assert.Equal(t, ErrForbidden, err)
+
+	imp, err := svc.ConfirmImport(operatorCtx, good.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.TransferImportProcessing, imp.Status)
+		assert.Equal(t, "ops", imp.ConfirmedBy)
+		assert.Equal(t, map[string]int{models.ImportRowPending: 2}, imp.RowStatuses)
+	}
+
+	_, err = svc.ConfirmImport(operatorCtx, good.ID)
+	assert.EqualError(t, err, "transfer import is already confirmed")
+	_, err = svc.ConfirmImport(operatorCtx, 99)
+	assert.EqualError(t, err, "transfer import not found")
+}
+
+func TestProcessBatch(t *testing.T) {
+	repo := &mockTransferImportRepository{}
+	transfers := newFakeTransfers()
+	transfers.fail = map[int64]error{200: errors.New("insufficient funds")}
+	svc := newTestImportService(repo, transfers)
+	svc.batchSize = 2
+
+	imp, _ := svc.CreateImport(operatorCtx, "", strings.NewReader("source,destination,amount\nSRC1,DST,1.00\nSRC2,DST,2.00\nSRC1,DST,3.00\n"))
+	confirmer := principalCtx(&auth.Principal{Subject: "checker", Role: auth.RoleOperator, Method: auth.MethodJWT})
+	if _, err := svc.ConfirmImport(confirmer, imp.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := svc.ProcessBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	got, _ := svc.GetImport(auditorCtx, imp.ID)
+	assert.Equal(t, models.TransferImportProcessing, got.Status)
+	assert.Equal(t, map[string]int{models.ImportRowCreated: 1, models.ImportRowFailed: 1, models.ImportRowPending: 1}, got.RowStatuses)
+
+	n, err = svc.ProcessBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, _ = svc.GetImport(auditorCtx, imp.ID)
+	assert.Equal(t, models.TransferImportCompleted, got.Status)
+
+	rows, err := svc.ListImportRows(auditorCtx, imp.ID)
+	if assert.NoError(t, err) && assert.Len(t, rows, 3) {
+		assert.Equal(t, models.ImportRowCreated, rows[0].Status)
+		assert.Equal(t, 100, *rows[0].TransactionID)
+		assert.Equal(t, models.TransactionStatusCompleted, rows[0].TransactionStatus)
+		assert.Equal(t, models.ImportRowFailed, rows[1].Status)
+		assert.Equal(t, "insufficient funds", rows[1].Error)
+		assert.Equal(t, models.ImportRowCreated, rows[2].Status)
+	}
+	// Transfers are made by, and initiated by, whoever confirmed the import
+	assert.Equal(t, []string{"checker", "checker"}, transfers.callers)
+	assert.Equal(t, "checker", transfers.made[0].InitiatedBy)
+}
+
+func TestProcessBatch_RowTakenByAnotherWorker(t *testing.T) {
+	repo := &mockTransferImportRepository{}
+	transfers := newFakeTransfers()
+	svc := newTestImportService(repo, transfers)
+
+	imp, _ := svc.CreateImport(operatorCtx, "", strings.NewReader("source,destination,amount\nSRC1,DST,1.00\n"))
+	svc.ConfirmImport(operatorCtx, imp.ID)
+	due, _ := repo.ListDue(10)
+	// Another worker gets there first
+	repo.CompleteRowTx(nil, imp.ID, 2, &models.Transaction{ID: 7, Status: models.TransactionStatusCompleted})
+
+	assert.NoError(t, svc.executeRow(context.Background(), due[0]))
+	assert.Empty(t, transfers.made)
+	assert.Equal(t, 7, *repo.rows[0].TransactionID)
+}
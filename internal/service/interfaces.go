@@ -1,12 +1,137 @@
 package service
 
-import "fastfunds/internal/models"
+import (
+	"context"
+	"fastfunds/internal/iso20022"
+	"fastfunds/internal/models"
+	"fastfunds/internal/statements"
+	"io"
+	"time"
+)
 
 type IAccountService interface {
-	CreateAccount(req *models.CreateAccountRequest) error
-	GetAccount(accountID int) (*models.AccountView, error)
+	CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.AccountView, error)
+	ResolveAccountNumber(number string) (int, error)
+	GetAccount(ctx context.Context, accountID int) (*models.AccountView, error)
+	AddHolder(ctx context.Context, accountID int, req *models.AddAccountHolderRequest) (*models.AccountHolder, error)
+	RemoveHolder(ctx context.Context, accountID, customerID int) error
+	StreamActivity(ctx context.Context, accountID int, lastEventID int64) (ActivityStream, error)
 }
 
 type ITransactionService interface {
-	ProcessTransaction(req *models.TransactionRequest) error
+	ProcessTransaction(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error)
+	GetTransaction(ctx context.Context, id int) (*models.Transaction, error)
+	ListTransactions(ctx context.Context, status string) ([]*models.Transaction, error)
+	ListAccountTransactions(ctx context.Context, accountID, beforeID, limit int) ([]*models.Transaction, error)
+	ApproveTransaction(ctx context.Context, id int, approver, note string) (*models.Transaction, error)
+	RejectTransaction(ctx context.Context, id int, approver, note string) (*models.Transaction, error)
+	WriteStatement(ctx context.Context, accountID int, from, to time.Time, w statements.Writer) error
+}
+
+type IScreeningService interface {
+	ListCases(ctx context.Context, status string) ([]*models.ScreeningCase, error)
+	GetCase(ctx context.Context, id int) (*models.ScreeningCase, error)
+	ClearCase(ctx context.Context, id int, note string) (*models.ScreeningCase, error)
+	ConfirmCase(ctx context.Context, id int, note string) (*models.ScreeningCase, error)
+}
+
+type ICustomerService interface {
+	CreateCustomer(ctx context.Context, req *models.CustomerRequest) (*models.Customer, error)
+	GetCustomer(ctx context.Context, id int) (*models.Customer, error)
+	UpdateCustomer(ctx context.Context, id int, req *models.CustomerRequest) (*models.Customer, error)
+	ListCustomers(ctx context.Context, limit, offset int) ([]*models.Customer, error)
+	ListCustomerAccounts(ctx context.Context, id int) ([]*models.CustomerAccountView, error)
+}
+
+type IExternalAccountService interface {
+	ValidateDetails(req *models.ExternalAccountRequest) (*models.ExternalAccount, error)
+	RegisterExternalAccount(ctx context.Context, customerID int, req *models.ExternalAccountRequest) (*models.ExternalAccount, error)
+	GetExternalAccount(ctx context.Context, customerID, id int) (*models.ExternalAccount, error)
+	ListExternalAccounts(ctx context.Context, customerID int) ([]*models.ExternalAccount, error)
+	RemoveExternalAccount(ctx context.Context, customerID, id int) error
+}
+
+type IAPIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RotateAPIKey(ctx context.Context, id int) (*models.IssuedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+type IWebhookService interface {
+	CreateSubscription(ctx context.Context, req *models.WebhookSubscriptionRequest) (*models.CreatedWebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id int) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, subscriptionID int, status string) ([]*models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, subscriptionID int, id int64) (*models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (*models.WebhookDelivery, error)
+}
+
+type IImportService interface {
+	CreateImport(ctx context.Context, fileName string, r io.Reader) (*models.TransferImport, error)
+	GetImport(ctx context.Context, id int) (*models.TransferImport, error)
+	ListImportRows(ctx context.Context, id int) ([]*models.TransferImportRow, error)
+	ConfirmImport(ctx context.Context, id int) (*models.TransferImport, error)
+}
+
+type IPaymentInitiationService interface {
+	Initiate(ctx context.Context, r io.Reader) (*iso20022.StatusReport, error)
+}
+
+type IPayoutService interface {
+	CreatePayout(ctx context.Context, req *models.PayoutRequest) (*models.Payout, error)
+	GetPayout(ctx context.Context, id int) (*models.Payout, error)
+	ProcessReturns(ctx context.Context, r io.Reader) (*models.ACHReturnResult, error)
+}
+
+type IReconciliationService interface {
+	Reconcile(ctx context.Context) (*models.Reconciliation, error)
+	LastReconciliation(ctx context.Context) (*models.Reconciliation, error)
+}
+
+type IBalanceService interface {
+	BalanceAt(ctx context.Context, accountID int, asOf time.Time) (*models.AccountBalance, error)
+	ListBalancesAt(ctx context.Context, asOf time.Time) ([]*models.AccountBalance, error)
+}
+
+type IBusinessDayService interface {
+	CloseDay(ctx context.Context, d time.Time) (*models.BusinessDay, error)
+	GetBusinessDay(ctx context.Context, d time.Time) (*models.BusinessDay, error)
+	ListDailyBalances(ctx context.Context, d time.Time) ([]*models.DailyBalance, error)
+}
+
+type ILedgerService interface {
+	CreateLedgerAccount(ctx context.Context, req *models.LedgerAccountRequest) (*models.LedgerAccount, error)
+	GetLedgerAccount(ctx context.Context, code string) (*models.LedgerAccount, error)
+	ListLedgerAccounts(ctx context.Context) ([]*models.LedgerAccount, error)
+	TrialBalance(ctx context.Context, from, to time.Time) (*models.TrialBalance, error)
+	BalanceSheet(ctx context.Context, from, to time.Time) (*models.BalanceSheet, error)
+	IncomeStatement(ctx context.Context, from, to time.Time) (*models.IncomeStatement, error)
+}
+
+type IProductService interface {
+	CreateProduct(ctx context.Context, req *models.ProductRequest) (*models.Product, error)
+	UpdateTerms(ctx context.Context, code string, terms *models.ProductTerms) (*models.Product, error)
+	MigrateAccounts(ctx context.Context, code string) (*models.ProductMigration, error)
+	GetProduct(ctx context.Context, code string) (*models.Product, error)
+	ListProducts(ctx context.Context) ([]*models.Product, error)
+	ListVersions(ctx context.Context, code string) ([]*models.ProductVersion, error)
+}
+
+type IEscrowService interface {
+	CreateEscrow(ctx context.Context, req *models.EscrowRequest) (*models.Escrow, error)
+	GetEscrow(ctx context.Context, id int) (*models.Escrow, error)
+	DisputeEscrow(ctx context.Context, id int, reason string) (*models.Escrow, error)
+	ReleaseEscrow(ctx context.Context, id int) (*models.Escrow, error)
+	RefundEscrow(ctx context.Context, id int) (*models.Escrow, error)
+}
+
+type IDisputeService interface {
+	OpenDispute(ctx context.Context, req *models.DisputeRequest) (*models.Dispute, error)
+	GetDispute(ctx context.Context, id int) (*models.Dispute, error)
+	ListDisputes(ctx context.Context, status string) ([]*models.Dispute, error)
+	AddNote(ctx context.Context, id int, note string) (*models.DisputeNote, error)
+	ReviewDispute(ctx context.Context, id int) (*models.Dispute, error)
+	ResolveDispute(ctx context.Context, id int, outcome string) (*models.Dispute, error)
 }
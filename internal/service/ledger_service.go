@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ledgerCode = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.-]{0,19}$`)
+
+var ledgerTypes = map[string]bool{
+	models.LedgerTypeAsset:     true,
+	models.LedgerTypeLiability: true,
+	models.LedgerTypeEquity:    true,
+	models.LedgerTypeIncome:    true,
+	models.LedgerTypeExpense:   true,
+}
+
+func NewLedgerService(db *sql.DB, ledgerRepo repository.LedgerRepository, opts ...func(*LedgerService)) *LedgerService {
+	s := &LedgerService{
+		db:         db,
+		ledgerRepo: ledgerRepo,
+		policy:     NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithLedgerPolicy sets the authorization policy.
+func WithLedgerPolicy(policy Policy) func(*LedgerService) {
+	return func(s *LedgerService) {
+		s.policy = policy
+	}
+}
+
+// WithLedgerAuditLog records changes to the chart of accounts in the audit
+// log.
+func WithLedgerAuditLog(auditLog repository.AuditLogRepository) func(*LedgerService) {
+	return func(s *LedgerService) {
+		s.auditLog = auditLog
+	}
+}
+
+// LedgerService keeps the chart of accounts and reports on the general
+// ledger. Every account posts to a ledger account; the reports add up the
+// transfers between them, so they always agree with the transaction history.
+type LedgerService struct {
+	db         *sql.DB
+	ledgerRepo repository.LedgerRepository
+	auditLog   repository.AuditLogRepository
+	policy     Policy
+	beginFn    func() (*sql.Tx, error)
+	rollbackFn func(*sql.Tx) error
+	commitFn   func(*sql.Tx) error
+}
+
+// CreateLedgerAccount adds an account to the chart of accounts.
+func (s *LedgerService) CreateLedgerAccount(ctx context.Context, req *models.LedgerAccountRequest) (*models.LedgerAccount, error) {
+	if err := authorize(ctx, s.policy, ActionManageLedger, Resource{}); err != nil {
+		return nil, err
+	}
+
+	l := &models.LedgerAccount{
+		Code:        strings.TrimSpace(req.Code),
+		Name:        strings.TrimSpace(req.Name),
+		Type:        req.Type,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if !ledgerCode.MatchString(l.Code) {
+		return nil, errors.New("code must be 1 to 20 letters, digits, dots or hyphens")
+	}
+	if l.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if !ledgerTypes[l.Type] {
+		return nil, errors.New("type must be asset, liability, equity, income or expense")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	if err := s.ledgerRepo.CreateTx(tx, l); err != nil {
+		if errors.Is(err, repository.ErrDuplicateLedgerAccount) {
+			return nil, err
+		}
+		return nil, errors.New("couldn't create ledger account")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionLedgerAccountCreate, audit.EntityLedgerAccount, l.Code, nil, l); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return l, nil
+}
+
+func (s *LedgerService) GetLedgerAccount(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	if err := authorize(ctx, s.policy, ActionReadLedger, Resource{}); err != nil {
+		return nil, err
+	}
+	l, err := s.ledgerRepo.Get(code)
+	if errors.Is(err, repository.ErrLedgerAccountNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("couldn't get ledger account")
+	}
+	return l, nil
+}
+
+// ListLedgerAccounts returns the chart of accounts in code order.
+func (s *LedgerService) ListLedgerAccounts(ctx context.Context) ([]*models.LedgerAccount, error) {
+	if err := authorize(ctx, s.policy, ActionReadLedger, Resource{}); err != nil {
+		return nil, err
+	}
+	list, err := s.ledgerRepo.List()
+	if err != nil {
+		return nil, errors.New("couldn't list ledger accounts")
+	}
+	if list == nil {
+		list = []*models.LedgerAccount{}
+	}
+	return list, nil
+}
+
+// TrialBalance reports every ledger account's debits and credits from from
+// to to, and its balance at to. A zero from starts at the first transfer.
+func (s *LedgerService) TrialBalance(ctx context.Context, from, to time.Time) (*models.TrialBalance, error) {
+	balances, err := s.ledgerBalances(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &models.TrialBalance{From: reportTime(from), To: reportTime(to), Lines: []*models.TrialBalanceLine{}}
+	for _, b := range balances {
+		line := &models.TrialBalanceLine{Code: b.Code, Name: b.Name, Type: b.Type, Debits: b.debits, Credits: b.credits}
+		if b.closing > 0 {
+			line.CreditBalance = b.closing
+		} else {
+			line.DebitBalance = -b.closing
+		}
+		tb.TotalDebits += line.DebitBalance
+		tb.TotalCredits += line.CreditBalance
+		tb.Lines = append(tb.Lines, line)
+	}
+	tb.Balanced = tb.TotalDebits == tb.TotalCredits
+	return tb, nil
+}
+
+// BalanceSheet reports assets, liabilities and equity at to, with the
+// earnings before from and over the period as part of equity.
+func (s *LedgerService) BalanceSheet(ctx context.Context, from, to time.Time) (*models.BalanceSheet, error) {
+	balances, err := s.ledgerBalances(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := &models.BalanceSheet{
+		From:        reportTime(from),
+		To:          reportTime(to),
+		Assets:      []*models.ReportLine{},
+		Liabilities: []*models.ReportLine{},
+		Equity:      []*models.ReportLine{},
+	}
+	for _, b := range balances {
+		line := &models.ReportLine{Code: b.Code, Name: b.Name, Balance: b.normal(b.closing)}
+		switch b.Type {
+		case models.LedgerTypeAsset:
+			bs.Assets = append(bs.Assets, line)
+			bs.TotalAssets += line.Balance
+		case models.LedgerTypeLiability:
+			bs.Liabilities = append(bs.Liabilities, line)
+			bs.TotalLiabilities += line.Balance
+		case models.LedgerTypeEquity:
+			bs.Equity = append(bs.Equity, line)
+			bs.TotalEquity += line.Balance
+		default:
+			// Credits less debits is earnings for income and expense alike.
+			bs.RetainedEarnings += b.opening
+			bs.NetIncome += b.closing - b.opening
+		}
+	}
+	bs.TotalEquity += bs.RetainedEarnings + bs.NetIncome
+	bs.Balanced = bs.TotalAssets == bs.TotalLiabilities+bs.TotalEquity
+	return bs, nil
+}
+
+// IncomeStatement reports each income and expense account's movement from
+// from to to.
+func (s *LedgerService) IncomeStatement(ctx context.Context, from, to time.Time) (*models.IncomeStatement, error) {
+	balances, err := s.ledgerBalances(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	is := &models.IncomeStatement{
+		From:     reportTime(from),
+		To:       reportTime(to),
+		Income:   []*models.ReportLine{},
+		Expenses: []*models.ReportLine{},
+	}
+	for _, b := range balances {
+		line := &models.ReportLine{Code: b.Code, Name: b.Name, Balance: b.normal(b.credits - b.debits)}
+		switch b.Type {
+		case models.LedgerTypeIncome:
+			is.Income = append(is.Income, line)
+			is.TotalIncome += line.Balance
+		case models.LedgerTypeExpense:
+			is.Expenses = append(is.Expenses, line)
+			is.TotalExpenses += line.Balance
+		}
+	}
+	is.NetIncome = is.TotalIncome - is.TotalExpenses
+	return is, nil
+}
+
+// ledgerBalance is a ledger account's balances and movements over a
+// period. Balances are credits less debits.
+type ledgerBalance struct {
+	*models.LedgerTotals
+	opening, debits, credits, closing int64
+}
+
+// normal returns v, credits less debits, on the account's normal side.
+func (b *ledgerBalance) normal(v int64) int64 {
+	if b.Type == models.LedgerTypeAsset || b.Type == models.LedgerTypeExpense {
+		return -v
+	}
+	return v
+}
+
+func (s *LedgerService) ledgerBalances(ctx context.Context, from, to time.Time) ([]*ledgerBalance, error) {
+	if err := authorize(ctx, s.policy, ActionReadLedger, Resource{}); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+	totals, err := s.ledgerRepo.Totals(from, to)
+	if err != nil {
+		return nil, errors.New("couldn't compute ledger totals")
+	}
+	return ledgerBalances(totals), nil
+}
+
+// ledgerBalances books each account's initial balance as a credit to its
+// ledger account and a debit to opening balances, so the books balance:
+// transfers only move money between accounts, but initial balances come
+// from outside.
+func ledgerBalances(totals []*models.LedgerTotals) []*ledgerBalance {
+	var list []*ledgerBalance
+	var opening *ledgerBalance
+	var initialBefore, initialDuring int64
+	for _, t := range totals {
+		b := &ledgerBalance{
+			LedgerTotals: t,
+			opening:      t.InitialBefore + t.NetBefore,
+			debits:       t.Debits,
+			credits:      t.Credits + t.InitialDuring,
+		}
+		if t.Code == models.LedgerCodeOpeningBalances {
+			opening = b
+		}
+		initialBefore += t.InitialBefore
+		initialDuring += t.InitialDuring
+		list = append(list, b)
+	}
+	if opening == nil && (initialBefore != 0 || initialDuring != 0) {
+		opening = &ledgerBalance{LedgerTotals: &models.LedgerTotals{
+			Code: models.LedgerCodeOpeningBalances, Name: "Opening balances", Type: models.LedgerTypeEquity,
+		}}
+		list = append(list, opening)
+	}
+	if opening != nil {
+		opening.opening -= initialBefore
+		opening.debits += initialDuring
+	}
+	for _, b := range list {
+		b.closing = b.opening + b.credits - b.debits
+	}
+	return list
+}
+
+func reportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
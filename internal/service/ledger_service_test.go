@@ -0,0 +1,175 @@
+package service
+
+import (
+	"database/sql"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLedgerRepository struct {
+	accounts map[string]*models.LedgerAccount
+	totals   []*models.LedgerTotals
+}
+
+func (m *mockLedgerRepository) CreateTx(tx *sql.Tx, l *models.LedgerAccount) error {
+	if _, ok := m.accounts[l.Code]; ok {
+		return repository.ErrDuplicateLedgerAccount
+	}
+	m.accounts[l.Code] = l
+	return nil
+}
+
+func (m *mockLedgerRepository) Get(code string) (*models.LedgerAccount, error) {
+	l, ok := m.accounts[code]
+	if !ok {
+		return nil, repository.ErrLedgerAccountNotFound
+	}
+	return l, nil
+}
+
+func (m *mockLedgerRepository) List() ([]*models.LedgerAccount, error) {
+	var list []*models.LedgerAccount
+	for _, l := range m.accounts {
+		list = append(list, l)
+	}
+	return list, nil
+}
+
+func (m *mockLedgerRepository) Totals(from, to time.Time) ([]*models.LedgerTotals, error) {
+	return m.totals, nil
+}
+
+func newTestLedgerService(repo *mockLedgerRepository) *LedgerService {
+	s := NewLedgerService(nil, repo)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+// testLedgerTotals: before the period 50.00 was paid in from the settlement
+// bank and a 1.00 fee charged; during it 20.00 more was paid in, a 3.00 fee
+// charged and an account opened with 10.00.
+func testLedgerTotals() []*models.LedgerTotals {
+	return []*models.LedgerTotals{
+		{Code: "1000", Name: "Settlement bank", Type: models.LedgerTypeAsset, NetBefore: -5000, Debits: 2000},
+		{Code: "2000", Name: "Customer deposits", Type: models.LedgerTypeLiability,
+			InitialBefore: 15000, InitialDuring: 1000, NetBefore: 4900, Debits: 300, Credits: 2000},
+		{Code: "3000", Name: "Opening balances", Type: models.LedgerTypeEquity},
+		{Code: "4000", Name: "Fee income", Type: models.LedgerTypeIncome, NetBefore: 100, Credits: 300},
+		{Code: "5000", Name: "Operating expenses", Type: models.LedgerTypeExpense},
+	}
+}
+
+var (
+	testLedgerFrom = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	testLedgerTo   = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestCreateLedgerAccount(t *testing.T) {
+	repo := &mockLedgerRepository{accounts: map[string]*models.LedgerAccount{}}
+	s := newTestLedgerService(repo)
+
+	l, err := s.CreateLedgerAccount(operatorCtx, &models.LedgerAccountRequest{Code: " 4100 ", Name: "Interchange", Type: models.LedgerTypeIncome})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "4100", l.Code)
+		assert.Contains(t, repo.accounts, "4100")
+	}
+
+	cases := []struct {
+		name    string
+		req     models.LedgerAccountRequest
+		wantErr string
+	}{
+		{"duplicate", models.LedgerAccountRequest{Code: "4100", Name: "Again", Type: models.LedgerTypeIncome}, "ledger account code already exists"},
+		{"bad code", models.LedgerAccountRequest{Code: "41 00", Name: "Fees", Type: models.LedgerTypeIncome}, "code must be 1 to 20 letters, digits, dots or hyphens"},
+		{"no name", models.LedgerAccountRequest{Code: "4200", Type: models.LedgerTypeIncome}, "name is required"},
+		{"bad type", models.LedgerAccountRequest{Code: "4200", Name: "Fees", Type: "revenue"}, "type must be asset, liability, equity, income or expense"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.CreateLedgerAccount(operatorCtx, &tc.req)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+
+	_, err = s.CreateLedgerAccount(auditorCtx, &models.LedgerAccountRequest{Code: "4200", Name: "Fees", Type: models.LedgerTypeIncome})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.GetLedgerAccount(auditorCtx, "4200")
+	assert.ErrorIs(t, err, repository.ErrLedgerAccountNotFound)
+}
+
+func TestTrialBalance(t *testing.T) {
+	s := newTestLedgerService(&mockLedgerRepository{totals: testLedgerTotals()})
+
+	tb, err := s.TrialBalance(auditorCtx, testLedgerFrom, testLedgerTo)
+	if !assert.NoError(t, err) || !assert.Len(t, tb.Lines, 5) {
+		return
+	}
+	assert.Equal(t, "2024-03-01T00:00:00Z", tb.From)
+	assert.Equal(t, &models.TrialBalanceLine{Code: "1000", Name: "Settlement bank", Type: models.LedgerTypeAsset,
+		Debits: 2000, DebitBalance: 7000}, tb.Lines[0])
+	assert.Equal(t, &models.TrialBalanceLine{Code: "2000", Name: "Customer deposits", Type: models.LedgerTypeLiability,
+		Debits: 300, Credits: 3000, CreditBalance: 22600}, tb.Lines[1])
+	assert.Equal(t, &models.TrialBalanceLine{Code: "3000", Name: "Opening balances", Type: models.LedgerTypeEquity,
+		Debits: 1000, DebitBalance: 16000}, tb.Lines[2])
+	assert.Equal(t, int64(400), tb.Lines[3].CreditBalance)
+	assert.Equal(t, int64(23000), tb.TotalDebits)
+	assert.Equal(t, int64(23000), tb.TotalCredits)
+	assert.True(t, tb.Balanced)
+
+	_, err = s.TrialBalance(auditorCtx, testLedgerTo, testLedgerFrom)
+	assert.EqualError(t, err, "from must be before to")
+	_, err = s.TrialBalance(ownerCtx, testLedgerFrom, testLedgerTo)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestTrialBalance_WithoutOpeningBalancesAccount(t *testing.T) {
+	totals := testLedgerTotals()
+	totals = append(totals[:2], totals[3:]...)
+	s := newTestLedgerService(&mockLedgerRepository{totals: totals})
+
+	tb, err := s.TrialBalance(auditorCtx, testLedgerFrom, testLedgerTo)
+	if assert.NoError(t, err) && assert.Len(t, tb.Lines, 5) {
+		assert.Equal(t, models.LedgerCodeOpeningBalances, tb.Lines[4].Code)
+		assert.True(t, tb.Balanced)
+	}
+}
+
+func TestBalanceSheet(t *testing.T) {
+	s := newTestLedgerService(&mockLedgerRepository{totals: testLedgerTotals()})
+
+	bs, err := s.BalanceSheet(operatorCtx, testLedgerFrom, testLedgerTo)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []*models.ReportLine{{Code: "1000", Name: "Settlement bank", Balance: 7000}}, bs.Assets)
+	assert.Equal(t, []*models.ReportLine{{Code: "2000", Name: "Customer deposits", Balance: 22600}}, bs.Liabilities)
+	assert.Equal(t, []*models.ReportLine{{Code: "3000", Name: "Opening balances", Balance: -16000}}, bs.Equity)
+	assert.Equal(t, int64(100), bs.RetainedEarnings)
+	assert.Equal(t, int64(300), bs.NetIncome)
+	assert.Equal(t, int64(7000), bs.TotalAssets)
+	assert.Equal(t, int64(22600), bs.TotalLiabilities)
+	assert.Equal(t, int64(-15600), bs.TotalEquity)
+	assert.True(t, bs.Balanced)
+}
+
+func TestIncomeStatement(t *testing.T) {
+	totals := testLedgerTotals()
+	totals[4].Debits = 50
+	totals[1].Credits += 50
+	s := newTestLedgerService(&mockLedgerRepository{totals: totals})
+
+	is, err := s.IncomeStatement(operatorCtx, time.Time{}, testLedgerTo)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, is.From)
+	assert.Equal(t, []*models.ReportLine{{Code: "4000", Name: "Fee income", Balance: 300}}, is.Income)
+	assert.Equal(t, []*models.ReportLine{{Code: "5000", Name: "Operating expenses", Balance: 50}}, is.Expenses)
+	assert.Equal(t, int64(250), is.NetIncome)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/events"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"log"
+	"time"
+)
+
+// recordEvent writes a domain event to the outbox in tx, so it is published
+// only if the change commits. It does nothing when the service has no outbox.
+func recordEvent(tx *sql.Tx, outbox repository.OutboxRepository, eventType, aggregateType string, aggregateID, payload any) error {
+	if outbox == nil {
+		return nil
+	}
+	e, err := events.New(eventType, aggregateType, aggregateID, payload)
+	if err == nil {
+		err = outbox.AppendTx(tx, e)
+	}
+	if err != nil {
+		return internalError("couldn't write event")
+	}
+	return nil
+}
+
+// Defaults for the outbox relay.
+const (
+	defaultRelayBatchSize      = 100
+	defaultRelayMaxBackoff     = 10 * time.Minute
+	defaultRelayPublishTimeout = 30 * time.Second
+	// relayLeaseMargin is added to the time a batch can take to publish, so
+	// its lease also covers marking the events.
+	relayLeaseMargin = time.Minute
+)
+
+func NewOutboxRelay(db *sql.DB, outboxRepo repository.OutboxRepository, publisher events.Publisher, opts ...func(*OutboxRelay)) *OutboxRelay {
+	r := &OutboxRelay{
+		db:             db,
+		outboxRepo:     outboxRepo,
+		publisher:      publisher,
+		batchSize:      defaultRelayBatchSize,
+		maxBackoff:     defaultRelayMaxBackoff,
+		publishTimeout: defaultRelayPublishTimeout,
+	}
+	r.beginFn = func() (*sql.Tx, error) { return r.db.Begin() }
+	r.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	r.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithRelayBatchSize sets how many events are claimed per batch.
+func WithRelayBatchSize(n int) func(*OutboxRelay) {
+	return func(r *OutboxRelay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithRelayPublishTimeout bounds how long publishing one event may take.
+func WithRelayPublishTimeout(d time.Duration) func(*OutboxRelay) {
+	return func(r *OutboxRelay) {
+		if d > 0 {
+			r.publishTimeout = d
+		}
+	}
+}
+
+// OutboxRelay publishes events from the outbox. A batch is claimed under a
+// lease and published with no DB transaction open. An event is marked
+// published only after the publisher accepts it, so a crash in between
+// publishes it again once the lease runs out: delivery is at least once. A
+// failed event is retried with exponential backoff and doesn't hold up the
+// others, so events may arrive out of order.
+type OutboxRelay struct {
+	db             *sql.DB
+	outboxRepo     repository.OutboxRepository
+	publisher      events.Publisher
+	batchSize      int
+	maxBackoff     time.Duration
+	publishTimeout time.Duration
+	beginFn        func() (*sql.Tx, error)
+	rollbackFn     func(*sql.Tx) error
+	commitFn       func(*sql.Tx) error
+}
+
+// RelayBatch publishes one batch of due events and returns how many it
+// claimed, published or not.
+func (r *OutboxRelay) RelayBatch(ctx context.Context) (int, error) {
+	batch, err := r.claim()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range batch {
+		publishCtx, cancel := context.WithTimeout(ctx, r.publishTimeout)
+		err := r.publisher.Publish(publishCtx, e)
+		cancel()
+		if err != nil {
+			log.Printf("failed to publish event %d (%s), attempt %d: %v", e.ID, e.Type, e.Attempts+1, err)
+			lastError := err.Error()
+			err = r.mark(func(tx *sql.Tx) error {
+				return r.outboxRepo.MarkFailedTx(tx, e.ID, lastError, r.backoff(e.Attempts))
+			})
+		} else {
+			err = r.mark(func(tx *sql.Tx) error { return r.outboxRepo.MarkPublishedTx(tx, e.ID) })
+		}
+		if err != nil {
+			log.Printf("failed to mark event %d: %v", e.ID, err)
+		}
+	}
+	return len(batch), nil
+}
+
+// claimLease is how long claimed events are kept from other relays: long
+// enough to publish a whole batch one after another. Events that were never
+// marked are published again after it.
+func (r *OutboxRelay) claimLease() time.Duration {
+	return time.Duration(r.batchSize)*r.publishTimeout + relayLeaseMargin
+}
+
+func (r *OutboxRelay) claim() ([]*models.Event, error) {
+	tx, err := r.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer r.rollbackFn(tx)
+
+	batch, err := r.outboxRepo.ClaimTx(tx, r.batchSize, r.claimLease())
+	if err != nil {
+		return nil, err
+	}
+	if err = r.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return batch, nil
+}
+
+// mark records the outcome of publishing an event in its own DB transaction.
+func (r *OutboxRelay) mark(fn func(*sql.Tx) error) error {
+	tx, err := r.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer r.rollbackFn(tx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err = r.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
+
+// backoff is the wait before retrying an event that has failed attempts
+// times before this one: 1s, 2s, 4s, ... up to maxBackoff.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	if attempts >= 30 {
+		return r.maxBackoff
+	}
+	return min(time.Second<<attempts, r.maxBackoff)
+}
+
+// Run relays events until ctx is cancelled. A full batch is followed straight
+// away by the next, even if some of its events failed; otherwise it waits
+// interval before polling again.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	for {
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			log.Print("failed to relay outbox events:", err)
+		}
+		if err == nil && n == r.batchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
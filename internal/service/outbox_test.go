@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOutbox keeps appended events in memory; claimed events stay
+// unpublished until marked.
+type mockOutbox struct {
+	events    []*models.Event
+	published map[int64]bool
+	failed    map[int64]time.Duration
+	err       error
+	// leased are the claimed events that aren't marked yet
+	leased map[int64]bool
+	lease  time.Duration
+}
+
+func (m *mockOutbox) AppendTx(tx *sql.Tx, e *models.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	e.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *mockOutbox) ClaimTx(tx *sql.Tx, limit int, lease time.Duration) ([]*models.Event, error) {
+	if m.leased == nil {
+		m.leased = map[int64]bool{}
+	}
+	m.lease = lease
+	var list []*models.Event
+	for _, e := range m.events {
+		if !m.published[e.ID] && !m.leased[e.ID] && len(list) < limit {
+			m.leased[e.ID] = true
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockOutbox) MarkPublishedTx(tx *sql.Tx, id int64) error {
+	if m.published == nil {
+		m.published = map[int64]bool{}
+	}
+	m.published[id] = true
+	delete(m.leased, id)
+	return nil
+}
+
+func (m *mockOutbox) MarkFailedTx(tx *sql.Tx, id int64, lastError string, retryAfter time.Duration) error {
+	if m.failed == nil {
+		m.failed = map[int64]time.Duration{}
+	}
+	m.failed[id] = retryAfter
+	delete(m.leased, id)
+	return nil
+}
+
+func (m *mockOutbox) PrunePublished(olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockOutbox) types() []string {
+	var list []string
+	for _, e := range m.events {
+		list = append(list, e.Type)
+	}
+	return list
+}
+
+type mockPublisher struct {
+	got       []int64
+	fail      map[int64]bool
+	onPublish func()
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, e *models.Event) error {
+	if m.onPublish != nil {
+		m.onPublish()
+	}
+	if m.fail[e.ID] {
+		return errors.New("unavailable")
+	}
+	m.got = append(m.got, e.ID)
+	return nil
+}
+
+func TestEvents_AccountCreated(t *testing.T) {
+	outbox := &mockOutbox{}
+	repo := &mockAccountRepository{createFn: func(a *models.Account) error {
+		a.AccountID = 9
+		return nil
+	}}
+	money := &mockMoneyConverter{decFn: func(string) (int64, error) { return 100, nil }}
+	svc := newTestAccountService(repo, money, WithAccountEvents(outbox))
+
+	view, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"})
+	assert.NoError(t, err)
+	if assert.Len(t, outbox.events, 1) {
+		e := outbox.events[0]
+		assert.Equal(t, models.EventAccountCreated, e.Type)
+		assert.Equal(t, view.AccountNumber, e.AggregateID)
+		assert.Contains(t, string(e.Payload), `"balance_pennies":100`)
+	}
+}
+
+func TestEvents_OutboxFailureAbortsChange(t *testing.T) {
+	outbox := &mockOutbox{err: errors.New("db")}
+	repo := &mockAccountRepository{createFn: func(a *models.Account) error { return nil }}
+	money := &mockMoneyConverter{decFn: func(string) (int64, error) { return 100, nil }}
+	svc := newTestAccountService(repo, money, WithAccountEvents(outbox))
+	svc.commitFn = func(*sql.Tx) error {
+		t.Fatal("committed without the event")
+		return nil
+	}
+
+	_, err := svc.CreateAccount(operatorCtx, &models.CreateAccountRequest{HolderName: "Jane Doe", InitialBalance: "1.00"})
+	assert.EqualError(t, err, "couldn't write event")
+}
+
+func TestEvents_Transfer(t *testing.T) {
+	outbox := &mockOutbox{}
+	accountRepo := &mockAccountRepo{SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+		return &models.Account{AccountID: id, AccountNumber: "N" + string(rune('0'+id)), CurrentBalance: 1000}, nil
+	}}
+	transactionRepo := &mockTransactionRepo{CreateTxFunc: func(tx *sql.Tx, t *models.Transaction) error {
+		t.ID = 31
+		return nil
+	}}
+	money := &transactionMockMoneyConverter{decFn: func(string) (int64, error) { return 250, nil }}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money, WithTransferEvents(outbox))
+	setTxnFns(ts)
+
+	_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.50"})
+	assert.NoError(t, err)
+	if assert.Len(t, outbox.events, 1) {
+		e := outbox.events[0]
+		assert.Equal(t, models.EventTransferCompleted, e.Type)
+		assert.Equal(t, "31", e.AggregateID)
+		assert.JSONEq(t, `{"transaction_id":31,"source_account_number":"N1","destination_account_number":"N2","amount_pennies":250,"status":"completed"}`, string(e.Payload))
+	}
+}
+
+func TestEvents_PendingTransferHasNoEventUntilReviewed(t *testing.T) {
+	outbox := &mockOutbox{}
+	balance := int64(1000)
+	accountRepo := &mockAccountRepo{SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+		return &models.Account{AccountID: id, CurrentBalance: balance}, nil
+	}}
+	transactionRepo := &mockTransactionRepo{SelectTxFunc: func(tx *sql.Tx, id int) (*models.Transaction, error) {
+		return &models.Transaction{ID: id, SourceAccountID: 1, DestinationAccountID: 2, AmountPennies: 500,
+			Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice"}, nil
+	}}
+	money := &transactionMockMoneyConverter{decFn: func(string) (int64, error) { return 500, nil }}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money,
+		WithTransferEvents(outbox), WithApprovalThreshold(100, time.Hour))
+	setTxnFns(ts)
+
+	_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "5.00", InitiatedBy: "alice"})
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.events)
+
+	// The source can no longer cover the amount, so approval fails the transfer
+	balance = 100
+	got, err := ts.ApproveTransaction(operatorCtx, 8, "bob", "")
+	assert.NoError(t, err)
+	assert.Equal(t, models.TransactionStatusFailed, got.Status)
+	assert.Equal(t, []string{models.EventTransferFailed}, outbox.types())
+}
+
+func TestEvents_Expiry(t *testing.T) {
+	outbox := &mockOutbox{}
+	transactionRepo := &mockTransactionRepo{ExpirePendingFunc: func(*sql.Tx, time.Time) ([]*models.Transaction, error) {
+		return []*models.Transaction{{ID: 4, Status: models.TransactionStatusExpired}}, nil
+	}}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, &mockAccountRepo{}, transactionRepo, nil, WithTransferEvents(outbox))
+	setTxnFns(ts)
+
+	_, err := ts.ExpirePendingApprovals(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, outbox.events, 1) {
+		assert.Equal(t, models.EventTransferFailed, outbox.events[0].Type)
+		assert.Contains(t, string(outbox.events[0].Payload), `"status":"expired"`)
+	}
+}
+
+func newTestRelay(outbox *mockOutbox, publisher *mockPublisher, opts ...func(*OutboxRelay)) (*OutboxRelay, *int) {
+	r := NewOutboxRelay(&sql.DB{}, outbox, publisher, opts...)
+	commits := 0
+	r.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	r.rollbackFn = func(*sql.Tx) error { return nil }
+	r.commitFn = func(*sql.Tx) error {
+		commits++
+		return nil
+	}
+	return r, &commits
+}
+
+func TestOutboxRelay(t *testing.T) {
+	outbox := &mockOutbox{}
+	for range 3 {
+		outbox.AppendTx(nil, &models.Event{Type: models.EventTransferCompleted})
+	}
+	publisher := &mockPublisher{fail: map[int64]bool{2: true}}
+	relay, commits := newTestRelay(outbox, publisher)
+
+	n, err := relay.RelayBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n, "the failed event was claimed too")
+	assert.Equal(t, 4, *commits, "the claim and each event commit on their own")
+	assert.Equal(t, []int64{1, 3}, publisher.got)
+	assert.Empty(t, outbox.leased)
+	assert.Equal(t, map[int64]time.Duration{2: time.Second}, outbox.failed)
+
+	// Only the failed event is offered again
+	publisher.fail = nil
+	n, err = relay.RelayBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1, 3, 2}, publisher.got)
+}
+
+func TestOutboxRelay_PublishesWithNoTransactionOpen(t *testing.T) {
+	outbox := &mockOutbox{}
+	for range 2 {
+		outbox.AppendTx(nil, &models.Event{Type: models.EventAccountCreated})
+	}
+	var open int
+	var openWhilePublishing []int
+	publisher := &mockPublisher{onPublish: func() { openWhilePublishing = append(openWhilePublishing, open) }}
+	relay, _ := newTestRelay(outbox, publisher, WithRelayPublishTimeout(5*time.Second))
+	relay.beginFn = func() (*sql.Tx, error) { open++; return &sql.Tx{}, nil }
+	relay.rollbackFn = func(*sql.Tx) error { open = 0; return nil }
+	relay.commitFn = func(*sql.Tx) error { open = 0; return nil }
+
+	n, err := relay.RelayBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{0, 0}, openWhilePublishing)
+	assert.Equal(t, 100*5*time.Second+relayLeaseMargin, outbox.lease, "long enough to publish a full batch")
+}
+
+func TestOutboxRelay_LeasedEventsAreNotClaimedAgain(t *testing.T) {
+	outbox := &mockOutbox{}
+	for range 2 {
+		outbox.AppendTx(nil, &models.Event{Type: models.EventAccountCreated})
+	}
+	first, _ := newTestRelay(outbox, &mockPublisher{})
+	second, _ := newTestRelay(outbox, &mockPublisher{})
+
+	// A relay that crashed before marking its batch keeps it leased
+	batch, err := first.claim()
+	assert.NoError(t, err)
+	assert.Len(t, batch, 2)
+	n, err := second.RelayBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestOutboxRelay_BatchSize(t *testing.T) {
+	outbox := &mockOutbox{}
+	for range 5 {
+		outbox.AppendTx(nil, &models.Event{Type: models.EventAccountCreated})
+	}
+	relay, _ := newTestRelay(outbox, &mockPublisher{}, WithRelayBatchSize(2))
+
+	n, err := relay.RelayBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestOutboxRelay_Backoff(t *testing.T) {
+	relay, _ := newTestRelay(&mockOutbox{}, &mockPublisher{})
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 8*time.Second, relay.backoff(3))
+	assert.Equal(t, defaultRelayMaxBackoff, relay.backoff(12))
+	assert.Equal(t, defaultRelayMaxBackoff, relay.backoff(100))
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/iso20022"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPaymentTransactions bounds a pain.001 message, since its transfers are
+// made while the client waits for the status report.
+const maxPaymentTransactions = 1000
+
+func NewPaymentInitiationService(initiationRepo repository.PaymentInitiationRepository, transfers transferExecutor, opts ...func(*PaymentInitiationService)) *PaymentInitiationService {
+	s := &PaymentInitiationService{
+		initiationRepo: initiationRepo,
+		transfers:      transfers,
+		policy:         NewRolePolicy(nil),
+		nowFn:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithPaymentInitiationPolicy sets the authorization policy.
+func WithPaymentInitiationPolicy(policy Policy) func(*PaymentInitiationService) {
+	return func(s *PaymentInitiationService) {
+		s.policy = policy
+	}
+}
+
+// PaymentInitiationService executes ISO 20022 pain.001 credit transfer
+// initiations. Each CdtTrfTxInf becomes a transfer made with
+// ProcessTransaction's rules on behalf of the caller, and the outcome is
+// reported as a pain.002.
+type PaymentInitiationService struct {
+	initiationRepo repository.PaymentInitiationRepository
+	transfers      transferExecutor
+	policy         Policy
+	nowFn          func() time.Time
+}
+
+// Initiate reads a pain.001 message and makes its transfers in document
+// order. A message that breaks the schema rules, or whose ID the caller
+// has used before, is rejected as a whole in the report and nothing moves.
+// Input that is not a pain.001 document is an error.
+func (s *PaymentInitiationService) Initiate(ctx context.Context, r io.Reader) (*iso20022.StatusReport, error) {
+	p, _ := auth.FromContext(ctx)
+	var customerID int
+	if p != nil {
+		customerID = p.CustomerID
+	}
+	if err := authorize(ctx, s.policy, ActionInitiatePayments, Resource{CustomerID: customerID}); err != nil {
+		return nil, err
+	}
+
+	doc, err := iso20022.ParsePain001(r)
+	var invalid *iso20022.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		return nil, err
+	}
+	now := s.nowFn()
+	report := iso20022.NewStatusReport("PSR"+now.UTC().Format("20060102150405.000000"), now, doc)
+	if invalid != nil {
+		var reasons []iso20022.Reason
+		for _, problem := range invalid.Problems {
+			reasons = append(reasons, iso20022.Reason{Code: iso20022.ReasonInvalidFileFormat, Info: problem})
+		}
+		report.Reject(reasons...)
+		return report, nil
+	}
+	if n := doc.Transactions(); n > maxPaymentTransactions {
+		report.Reject(iso20022.Reason{Code: iso20022.ReasonInvalidFileFormat,
+			Info: fmt.Sprintf("message has %d transactions; at most %d are accepted", n, maxPaymentTransactions)})
+		return report, nil
+	}
+
+	initiation := &models.PaymentInitiation{
+		MessageID:    strings.TrimSpace(doc.GrpHdr.MsgID),
+		InitiatedBy:  p.Subject,
+		Transactions: doc.Transactions(),
+	}
+	if err := s.initiationRepo.Create(initiation); err != nil {
+		if errors.Is(err, repository.ErrDuplicatePaymentMessage) {
+			report.Reject(iso20022.Reason{Code: iso20022.ReasonDuplicateMessage, Info: "message ID " + initiation.MessageID + " was already used"})
+			return report, nil
+		}
+		return nil, errors.New("couldn't record payment initiation")
+	}
+	report.MessageID = "PSR-" + strconv.Itoa(initiation.ID)
+
+	today := now.UTC().Format(time.DateOnly)
+	for i := range doc.PmtInf {
+		pmt := &doc.PmtInf[i]
+		for j := range pmt.CdtTrfTxInf {
+			status := report.Payments[i].Transactions[j]
+			// ISO dates compare correctly as strings
+			if pmt.ReqdExctnDt > today {
+				status.Status = iso20022.StatusRejected
+				status.Reasons = []iso20022.Reason{{Code: iso20022.ReasonInvalidDate, Info: "future-dated payments are not supported"}}
+				continue
+			}
+			s.execute(ctx, p.Subject, pmt, &pmt.CdtTrfTxInf[j], status)
+		}
+	}
+	report.Summarize()
+
+	if err := s.initiationRepo.Complete(initiation.ID, report.GroupStatus); err != nil {
+		// The transfers are made; only the bookkeeping is behind.
+		log.Printf("failed to complete payment initiation %d: %v", initiation.ID, err)
+	}
+	return report, nil
+}
+
+// execute makes one transfer and records its outcome in status. The
+// payment must be in the debtor account's currency.
+func (s *PaymentInitiationService) execute(ctx context.Context, initiatedBy string, pmt *iso20022.PaymentInfo, tx *iso20022.CreditTransferTxInfo, status *iso20022.TransactionStatus) {
+	req := &models.TransactionRequest{
+		SourceAccountNumber:      pmt.DbtrAcct.Number(),
+		DestinationAccountNumber: tx.CdtrAcct.Number(),
+		Amount:                   strings.TrimSpace(tx.Amount.Value),
+		InitiatedBy:              initiatedBy,
+	}
+	_, debtor, err := s.transfers.validateTransfer(ctx, req)
+	if err != nil {
+		status.Status = iso20022.StatusRejected
+		status.Reasons = []iso20022.Reason{{Code: paymentReasonCode(err), Info: err.Error()}}
+		return
+	}
+	if tx.Amount.Ccy != debtor.Currency {
+		status.Status = iso20022.StatusRejected
+		status.Reasons = []iso20022.Reason{{Code: iso20022.ReasonInvalidCurrency, Info: "the debtor account is in " + debtor.Currency}}
+		return
+	}
+
+	transaction, err := s.transfers.processTransaction(ctx, req, nil)
+	if err != nil {
+		status.Status = iso20022.StatusRejected
+		status.Reasons = []iso20022.Reason{{Code: paymentReasonCode(err), Info: err.Error()}}
+		return
+	}
+
+	status.ServicerRef = strconv.Itoa(transaction.ID)
+	switch transaction.Status {
+	case models.TransactionStatusCompleted:
+		status.Status = iso20022.StatusSettled
+	case models.TransactionStatusHeld, models.TransactionStatusPendingApproval:
+		status.Status = iso20022.StatusPending
+	default:
+		status.Status = iso20022.StatusRejected
+		status.Reasons = []iso20022.Reason{{Code: iso20022.ReasonNarrative, Info: "transfer " + transaction.Status}}
+	}
+}
+
+// paymentReasonCode maps a ProcessTransaction error to the ISO 20022 reason
+// a payment system would give for it.
+func paymentReasonCode(err error) string {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrForbidden):
+		return iso20022.ReasonNotAllowed
+	case msg == "insufficient funds":
+		return iso20022.ReasonInsufficientFunds
+	case msg == "accounts are in different currencies":
+		return iso20022.ReasonInvalidCurrency
+	case strings.Contains(msg, "account"):
+		return iso20022.ReasonIncorrectAccount
+	case strings.Contains(msg, "amount"):
+		return iso20022.ReasonInvalidAmount
+	}
+	return iso20022.ReasonNarrative
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"fastfunds/internal/iso20022"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockPaymentInitiationRepository struct {
+	created   []*models.PaymentInitiation
+	completed map[int]string
+}
+
+func (m *mockPaymentInitiationRepository) Create(p *models.PaymentInitiation) error {
+	for _, c := range m.created {
+		if c.InitiatedBy == p.InitiatedBy && c.MessageID == p.MessageID {
+			return repository.ErrDuplicatePaymentMessage
+		}
+	}
+	p.ID = len(m.created) + 1
+	m.created = append(m.created, p)
+	return nil
+}
+
+func (m *mockPaymentInitiationRepository) Complete(id int, groupStatus string) error {
+	if m.completed == nil {
+		m.completed = map[int]string{}
+	}
+	m.completed[id] = groupStatus
+	return nil
+}
+
+func testPain001(msgID, executionDate string, txs ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"><CstmrCdtTrfInitn>
+<GrpHdr><MsgId>` + msgID + `</MsgId><CreDtTm>2024-03-01T09:30:00</CreDtTm><NbOfTxs>` + string(rune('0'+len(txs))) + `</NbOfTxs><InitgPty><Nm>Acme</Nm></InitgPty></GrpHdr>
+<PmtInf><PmtInfId>B1</PmtInfId><PmtMtd>TRF</PmtMtd><ReqdExctnDt>` + executionDate + `</ReqdExctnDt><Dbtr><Nm>Acme</Nm></Dbtr>
+<DbtrAcct><Id><Othr><Id>SRC1</Id></Othr></Id></DbtrAcct><DbtrAgt><FinInstnId/></DbtrAgt>` + strings.Join(txs, "") + `</PmtInf>
+</CstmrCdtTrfInitn></Document>`
+}
+
+func testCreditTransfer(endToEndID, currency, amount, creditor string) string {
+	return `<CdtTrfTxInf><PmtId><EndToEndId>` + endToEndID + `</EndToEndId></PmtId><Amt><InstdAmt Ccy="` + currency + `">` + amount +
+		`</InstdAmt></Amt><CdtrAcct><Id><Othr><Id>` + creditor + `</Id></Othr></Id></CdtrAcct></CdtTrfTxInf>`
+}
+
+func TestInitiatePayments(t *testing.T) {
+	repo := &mockPaymentInitiationRepository{}
+	transfers := newFakeTransfers()
+	s := NewPaymentInitiationService(repo, transfers)
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	doc := testPain001("MSG-1", "2024-03-01",
+		testCreditTransfer("E1", "GBP", "6.00", "DST"),
+		testCreditTransfer("E2", "GBP", "50.00", "DST"),
+		testCreditTransfer("E3", "EUR", "1.00", "DST"),
+		testCreditTransfer("E4", "GBP", "1.00", "NOPE"),
+	)
+	report, err := s.Initiate(operatorCtx, strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.GroupStatus != iso20022.StatusPartial || report.MessageID != "PSR-1" {
+		t.Errorf("expected a partial report PSR-1, got %s %s", report.GroupStatus, report.MessageID)
+	}
+	want := []struct{ status, reason string }{
+		{iso20022.StatusSettled, ""},
+		{iso20022.StatusRejected, iso20022.ReasonInsufficientFunds},
+		{iso20022.StatusRejected, iso20022.ReasonInvalidCurrency},
+		{iso20022.StatusRejected, iso20022.ReasonIncorrectAccount},
+	}
+	for i, tx := range report.Payments[0].Transactions {
+		reason := ""
+		if len(tx.Reasons) > 0 {
+			reason = tx.Reasons[0].Code
+		}
+		if tx.Status != want[i].status || reason != want[i].reason {
+			t.Errorf("transaction %d: expected %s %s, got %s %s", i+1, want[i].status, want[i].reason, tx.Status, reason)
+		}
+	}
+	if report.Payments[0].Transactions[0].ServicerRef != "100" {
+		t.Errorf("expected the transfer ID as servicer reference, got %q", report.Payments[0].Transactions[0].ServicerRef)
+	}
+	if len(transfers.made) != 1 || transfers.made[0].InitiatedBy != "ops" || transfers.made[0].SourceAccountNumber != "SRC1" {
+		t.Errorf("expected one transfer from SRC1 initiated by ops, got %+v", transfers.made)
+	}
+	if repo.completed[1] != iso20022.StatusPartial {
+		t.Errorf("expected the initiation completed as PART, got %q", repo.completed[1])
+	}
+
+	// The same message ID is rejected without moving anything
+	report, err = s.Initiate(operatorCtx, strings.NewReader(doc))
+	if err != nil || report.GroupStatus != iso20022.StatusRejected || report.GroupReasons[0].Code != iso20022.ReasonDuplicateMessage {
+		t.Errorf("expected a duplicate rejection, got %+v, %v", report, err)
+	}
+	if len(transfers.made) != 1 {
+		t.Errorf("expected no more transfers, got %d", len(transfers.made))
+	}
+}
+
+func TestInitiatePayments_DebtorAccountCurrency(t *testing.T) {
+	transfers := newFakeTransfers()
+	transfers.accounts["SRC1"].Currency = "EUR"
+	s := NewPaymentInitiationService(&mockPaymentInitiationRepository{}, transfers)
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	report, err := s.Initiate(operatorCtx, strings.NewReader(testPain001("MSG-1", "2024-03-01",
+		testCreditTransfer("E1", "EUR", "1.00", "DST"),
+		testCreditTransfer("E2", "GBP", "1.00", "DST"),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txs := report.Payments[0].Transactions
+	if txs[0].Status != iso20022.StatusSettled {
+		t.Errorf("expected a payment in the debtor account's currency settled, got %s", txs[0].Status)
+	}
+	if txs[1].Status != iso20022.StatusRejected || txs[1].Reasons[0].Code != iso20022.ReasonInvalidCurrency ||
+		txs[1].Reasons[0].Info != "the debtor account is in EUR" {
+		t.Errorf("expected a payment in another currency rejected, got %+v", txs[1])
+	}
+	if len(transfers.made) != 1 {
+		t.Errorf("expected one transfer, got %d", len(transfers.made))
+	}
+}
+
+func TestInitiatePayments_Rejections(t *testing.T) {
+	repo := &mockPaymentInitiationRepository{}
+	transfers := newFakeTransfers()
+	s := NewPaymentInitiationService(repo, transfers)
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	report, err := s.Initiate(operatorCtx, strings.NewReader(testPain001("", "2024-03-01", testCreditTransfer("E1", "GBP", "1.00", "DST"))))
+	if err != nil || report.GroupStatus != iso20022.StatusRejected || report.GroupReasons[0].Code != iso20022.ReasonInvalidFileFormat {
+		t.Errorf("expected a format rejection, got %+v, %v", report, err)
+	}
+	if len(repo.created) != 0 {
+		t.Error("expected an invalid message not to be recorded")
+	}
+
+	report, err = s.Initiate(operatorCtx, strings.NewReader(testPain001("MSG-2", "2024-03-02", testCreditTransfer("E1", "GBP", "1.00", "DST"))))
+	if err != nil || report.GroupStatus != iso20022.StatusRejected || report.Payments[0].Transactions[0].Reasons[0].Code != iso20022.ReasonInvalidDate {
+		t.Errorf("expected a future-dated payment rejected, got %+v, %v", report, err)
+	}
+	if len(transfers.made) != 0 {
+		t.Errorf("expected no transfers, got %d", len(transfers.made))
+	}
+
+	if _, err := s.Initiate(operatorCtx, strings.NewReader("not xml")); err == nil {
+		t.Error("expected an error for input that is not XML")
+	}
+	if _, err := s.Initiate(auditorCtx, strings.NewReader(testPain001("MSG-3", "2024-03-01"))); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected auditors to be forbidden, got %v", err)
+	}
+}
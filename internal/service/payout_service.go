@@ -0,0 +1,437 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"fastfunds/internal/nacha"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+func NewPayoutService(
+	db *sql.DB,
+	payoutRepo repository.PayoutRepository,
+	externalRepo repository.ExternalAccountRepository,
+	holderRepo repository.AccountHolderRepository,
+	transfers transferExecutor,
+	opts ...func(*PayoutService),
+) *PayoutService {
+	s := &PayoutService{
+		db:           db,
+		payoutRepo:   payoutRepo,
+		externalRepo: externalRepo,
+		holderRepo:   holderRepo,
+		transfers:    transfers,
+		policy:       NewRolePolicy(nil),
+		nowFn:        time.Now,
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithACH enables payouts: amounts are moved into the clearing account and
+// paid out in NACHA files written to outputDir for the originator.
+func WithACH(originator nacha.Originator, clearingAccountID int, outputDir string) func(*PayoutService) {
+	return func(s *PayoutService) {
+		s.originator = &originator
+		s.clearingAccountID = clearingAccountID
+		s.outputDir = outputDir
+	}
+}
+
+// WithPayoutPolicy sets the authorization policy.
+func WithPayoutPolicy(policy Policy) func(*PayoutService) {
+	return func(s *PayoutService) {
+		s.policy = policy
+	}
+}
+
+// WithPayoutAuditLog records payouts, returns and files in the audit log.
+// The transfers themselves are audited as they are made.
+func WithPayoutAuditLog(auditLog repository.AuditLogRepository) func(*PayoutService) {
+	return func(s *PayoutService) {
+		s.auditLog = auditLog
+	}
+}
+
+// PayoutService pays customers out to their US bank accounts over ACH. A
+// payout is a transfer into the clearing account, made with
+// ProcessTransaction's rules. Once that transfer has completed, the payout
+// goes into the day's NACHA file; if the receiving bank returns it, the
+// amount is transferred back out of the clearing account.
+type PayoutService struct {
+	db                *sql.DB
+	payoutRepo        repository.PayoutRepository
+	externalRepo      repository.ExternalAccountRepository
+	holderRepo        repository.AccountHolderRepository
+	transfers         transferExecutor
+	originator        *nacha.Originator
+	clearingAccountID int
+	outputDir         string
+	auditLog          repository.AuditLogRepository
+	policy            Policy
+	nowFn             func() time.Time
+	beginFn           func() (*sql.Tx, error)
+	rollbackFn        func(*sql.Tx) error
+	commitFn          func(*sql.Tx) error
+}
+
+var errPayoutsNotConfigured = errors.New("ACH payouts are not configured")
+
+// CreatePayout debits the source account into the clearing account and
+// queues the payout for the next file. The external account must be a US
+// account registered by a holder of the source account. A transfer held for
+// screening or waiting for approval leaves the payout pending until it
+// completes.
+func (s *PayoutService) CreatePayout(ctx context.Context, req *models.PayoutRequest) (*models.Payout, error) {
+	p, _ := auth.FromContext(ctx)
+	customerID := req.CustomerID
+	if customerID == 0 && p != nil && p.Role == auth.RoleCustomer {
+		customerID = p.CustomerID
+	}
+	if customerID <= 0 {
+		return nil, errors.New("invalid customer_id")
+	}
+	if req.ExternalAccountID <= 0 {
+		return nil, errors.New("invalid external_account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionCreatePayouts, Resource{CustomerID: customerID}); err != nil {
+		return nil, err
+	}
+	if s.originator == nil {
+		return nil, errPayoutsNotConfigured
+	}
+
+	ext, err := s.externalRepo.GetByID(customerID, req.ExternalAccountID)
+	if err != nil {
+		return nil, errors.New("external account not found")
+	}
+	if ext.Type != models.ExternalAccountTypeUS {
+		return nil, errors.New("payouts can only be made to US bank accounts")
+	}
+
+	transfer := &models.TransactionRequest{
+		SourceAccountNumber:  req.SourceAccountNumber,
+		DestinationAccountID: s.clearingAccountID,
+		Amount:               req.Amount,
+		InitiatedBy:          p.Subject,
+	}
+	t, source, err := s.transfers.validateTransfer(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+	if t.AmountPennies > nacha.MaxAmountPennies {
+		return nil, fmt.Errorf("amount is above the ACH limit of %s", util.PenniesToDecimalString(nacha.MaxAmountPennies))
+	}
+	if err := s.checkHolder(source.AccountID, customerID); err != nil {
+		return nil, err
+	}
+
+	payout := &models.Payout{
+		AccountID:         source.AccountID,
+		AccountNumber:     source.AccountNumber,
+		ExternalAccountID: ext.ID,
+		RoutingNumber:     ext.RoutingNumber,
+		ReceiverAccount:   ext.AccountNumber,
+		ReceiverName:      ext.HolderName,
+		AmountPennies:     t.AmountPennies,
+		CreatedBy:         p.Subject,
+	}
+	if err := nacha.ValidateEntry(payoutEntry(payout)); err != nil {
+		return nil, err
+	}
+
+	_, err = s.transfers.processTransaction(ctx, transfer, func(tx *sql.Tx, t *models.Transaction) error {
+		payout.TransactionID = t.ID
+		payout.TransactionStatus = t.Status
+		if err := s.payoutRepo.CreateTx(tx, payout); err != nil {
+			return errors.New("couldn't create payout")
+		}
+		return recordAudit(ctx, tx, s.auditLog, audit.ActionPayoutCreate, audit.EntityPayout, payout.ID, nil, payout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// checkHolder makes sure money only leaves an account for one of its
+// holders' own bank accounts.
+func (s *PayoutService) checkHolder(accountID, customerID int) error {
+	holders, err := s.holderRepo.ListByAccount(accountID)
+	if err != nil {
+		return errors.New("couldn't get account holders")
+	}
+	for _, h := range holders {
+		if h.CustomerID == customerID {
+			return nil
+		}
+	}
+	return errors.New("the external account must belong to a holder of the source account")
+}
+
+func payoutEntry(p *models.Payout) *nacha.Entry {
+	return &nacha.Entry{
+		RoutingNumber:  p.RoutingNumber,
+		AccountNumber:  p.ReceiverAccount,
+		AmountPennies:  p.AmountPennies,
+		IndividualID:   "PAYOUT-" + strconv.Itoa(p.ID),
+		IndividualName: p.ReceiverName,
+		Sequence:       p.ID,
+	}
+}
+
+// GetPayout returns a payout with the status of its transfer.
+func (s *PayoutService) GetPayout(ctx context.Context, id int) (*models.Payout, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid payout_id")
+	}
+
+	payout, err := s.payoutRepo.Get(id)
+	if err != nil {
+		return nil, errors.New("payout not found")
+	}
+	if err := authorize(ctx, s.policy, ActionReadPayouts, Resource{AccountIDs: []int{payout.AccountID}}); err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// CreateFile puts every pending payout whose transfer has completed into a
+// new NACHA file, and writes it to the output directory. It returns nil
+// when there is nothing to pay out. The file is stored with its payouts
+// marked sent before it is written, so a payout is never in two files; a
+// file that can't be written is retried by WriteFiles.
+func (s *PayoutService) CreateFile(ctx context.Context) (*models.ACHFile, error) {
+	if s.originator == nil {
+		return nil, errPayoutsNotConfigured
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	due, err := s.payoutRepo.ListDueTx(tx)
+	if err != nil {
+		return nil, errors.New("couldn't list pending payouts")
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	now := s.nowFn().UTC()
+	prefix := "ach-" + now.Format("20060102") + "-"
+	n, err := s.payoutRepo.CountFilesTx(tx, prefix)
+	if err != nil {
+		return nil, errors.New("couldn't count today's ACH files")
+	}
+	modifier, err := nacha.FileIDModifier(n)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &models.ACHFile{FileName: prefix + string(modifier) + ".ach", EntryCount: len(due)}
+	entries := make([]nacha.Entry, len(due))
+	for i, p := range due {
+		entries[i] = *payoutEntry(p)
+		file.TotalPennies += p.AmountPennies
+	}
+	var buf bytes.Buffer
+	if err := nacha.WriteFile(&buf, s.originator, now, nacha.NextBankingDay(now), modifier, entries); err != nil {
+		return nil, err
+	}
+	file.Content = buf.String()
+
+	if err := s.payoutRepo.CreateFileTx(tx, file); err != nil {
+		return nil, errors.New("couldn't create ACH file")
+	}
+	for i, p := range due {
+		if err := s.payoutRepo.MarkSentTx(tx, p.ID, file.ID, s.originator.TraceNumber(&entries[i])); err != nil {
+			return nil, errors.New("couldn't mark payout sent")
+		}
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionACHFileCreate, audit.EntityACHFile, file.ID, nil, file); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+
+	if err := s.writeFile(file); err != nil {
+		log.Printf("failed to write ACH file %s: %v", file.FileName, err)
+	}
+	return file, nil
+}
+
+// WriteFiles writes out the files that were created but not written, and
+// returns how many it wrote.
+func (s *PayoutService) WriteFiles() (int, error) {
+	files, err := s.payoutRepo.ListUnwrittenFiles()
+	if err != nil {
+		return 0, err
+	}
+	written := 0
+	for _, f := range files {
+		if err := s.writeFile(f); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// writeFile writes a file under a temporary name and renames it into place,
+// so whatever picks files up from the directory never sees half a file.
+func (s *PayoutService) writeFile(f *models.ACHFile) error {
+	if err := os.MkdirAll(s.outputDir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.outputDir, ".tmp-"+f.FileName+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(f.Content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.outputDir, f.FileName)); err != nil {
+		return err
+	}
+
+	now := s.nowFn().UTC().Format(time.RFC3339Nano)
+	f.WrittenAt = &now
+	return s.payoutRepo.MarkFileWritten(f.ID)
+}
+
+// ProcessReturns reverses the payouts returned in a NACHA return file: each
+// is marked returned and its amount transferred from the clearing account
+// back to the account it was paid from, in one DB transaction. A return
+// already processed, or naming no payout of ours, is skipped and reported,
+// so the same file can safely be processed twice.
+func (s *PayoutService) ProcessReturns(ctx context.Context, r io.Reader) (*models.ACHReturnResult, error) {
+	if err := authorize(ctx, s.policy, ActionProcessACHReturns, Resource{}); err != nil {
+		return nil, err
+	}
+	if s.originator == nil {
+		return nil, errPayoutsNotConfigured
+	}
+
+	returns, err := nacha.ParseReturns(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := auth.FromContext(ctx)
+	result := &models.ACHReturnResult{Returns: []*models.ACHReturnOutcome{}}
+	for _, ret := range returns {
+		outcome := &models.ACHReturnOutcome{
+			TraceNumber:   ret.OriginalTrace,
+			ReturnCode:    ret.Code,
+			AmountPennies: ret.AmountPennies,
+			Status:        models.ACHReturnSkipped,
+		}
+		result.Returns = append(result.Returns, outcome)
+		if err := s.reverse(ctx, p.Subject, ret, outcome); err != nil {
+			outcome.Error = err.Error()
+		}
+	}
+	return result, nil
+}
+
+func (s *PayoutService) reverse(ctx context.Context, initiatedBy string, ret nacha.Return, outcome *models.ACHReturnOutcome) error {
+	payout, err := s.payoutRepo.GetByTrace(ret.OriginalTrace)
+	if err != nil {
+		return errors.New("no payout has this trace number")
+	}
+	outcome.PayoutID = payout.ID
+	if payout.Status != models.PayoutSent {
+		return errors.New("payout is already " + payout.Status)
+	}
+	if payout.AmountPennies != ret.AmountPennies {
+		return errors.New("returned amount doesn't match the payout")
+	}
+
+	before := *payout
+	t, err := s.transfers.processTransaction(ctx, &models.TransactionRequest{
+		SourceAccountID:      s.clearingAccountID,
+		DestinationAccountID: payout.AccountID,
+		Amount:               util.PenniesToDecimalString(payout.AmountPennies),
+		InitiatedBy:          initiatedBy,
+	}, func(tx *sql.Tx, t *models.Transaction) error {
+		if err := s.payoutRepo.ReturnTx(tx, payout.ID, ret.Code, t.ID); err != nil {
+			return err
+		}
+		payout.Status = models.PayoutReturned
+		payout.ReturnCode = ret.Code
+		payout.ReturnTransactionID = &t.ID
+		return recordAudit(ctx, tx, s.auditLog, audit.ActionPayoutReturn, audit.EntityPayout, payout.ID, &before, payout)
+	})
+	if errors.Is(err, repository.ErrPayoutNotSent) {
+		return errors.New("payout was already returned")
+	}
+	if err != nil {
+		return err
+	}
+	outcome.Status = models.ACHReturnReversed
+	outcome.TransactionID = t.ID
+	outcome.TransactionStatus = t.Status
+	return nil
+}
+
+// Run creates a file every day at the given time after midnight UTC until
+// ctx is cancelled. Files left unwritten by an earlier failure are written
+// first.
+func (s *PayoutService) Run(ctx context.Context, at time.Duration) {
+	for {
+		now := s.nowFn().UTC()
+		next := now.Truncate(24 * time.Hour).Add(at)
+		if !next.After(now) {
+			next = next.Add(24 * time.Hour)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+		}
+
+		if _, err := s.WriteFiles(); err != nil {
+			log.Print("failed to write ACH files:", err)
+		}
+		file, err := s.CreateFile(ctx)
+		if err != nil {
+			log.Print("failed to create ACH file:", err)
+		} else if file != nil {
+			log.Printf("created ACH file %s with %d payouts", file.FileName, file.EntryCount)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"fastfunds/internal/nacha"
+	"fastfunds/internal/repository"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPayoutRepository keeps payouts and files in memory. Every payout's
+// transfer counts as completed unless listed in incomplete.
+type mockPayoutRepository struct {
+	payouts    []*models.Payout
+	files      []*models.ACHFile
+	incomplete map[int]bool
+}
+
+func (m *mockPayoutRepository) CreateTx(tx *sql.Tx, p *models.Payout) error {
+	p.ID = len(m.payouts) + 1
+	p.Status = models.PayoutPending
+	c := *p
+	m.payouts = append(m.payouts, &c)
+	return nil
+}
+
+func (m *mockPayoutRepository) Get(id int) (*models.Payout, error) {
+	for _, p := range m.payouts {
+		if p.ID == id {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, errors.New("payout not found")
+}
+
+func (m *mockPayoutRepository) GetByTrace(traceNumber string) (*models.Payout, error) {
+	for _, p := range m.payouts {
+		if p.TraceNumber == traceNumber {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, errors.New("payout not found")
+}
+
+func (m *mockPayoutRepository) ListDueTx(tx *sql.Tx) ([]*models.Payout, error) {
+	var due []*models.Payout
+	for _, p := range m.payouts {
+		if p.Status == models.PayoutPending && !m.incomplete[p.ID] {
+			c := *p
+			due = append(due, &c)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockPayoutRepository) CountFilesTx(tx *sql.Tx, prefix string) (int, error) {
+	n := 0
+	for _, f := range m.files {
+		if strings.HasPrefix(f.FileName, prefix) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockPayoutRepository) CreateFileTx(tx *sql.Tx, f *models.ACHFile) error {
+	f.ID = len(m.files) + 1
+	c := *f
+	m.files = append(m.files, &c)
+	return nil
+}
+
+func (m *mockPayoutRepository) MarkSentTx(tx *sql.Tx, id, fileID int, traceNumber string) error {
+	for _, p := range m.payouts {
+		if p.ID == id {
+			p.Status = models.PayoutSent
+			p.FileID = &fileID
+			p.TraceNumber = traceNumber
+		}
+	}
+	return nil
+}
+
+func (m *mockPayoutRepository) ListUnwrittenFiles() ([]*models.ACHFile, error) {
+	var list []*models.ACHFile
+	for _, f := range m.files {
+		if f.WrittenAt == nil {
+			c := *f
+			list = append(list, &c)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockPayoutRepository) MarkFileWritten(id int) error {
+	for _, f := range m.files {
+		if f.ID == id {
+			now := "now"
+			f.WrittenAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockPayoutRepository) ReturnTx(tx *sql.Tx, id int, returnCode string, transactionID int) error {
+	for _, p := range m.payouts {
+		if p.ID == id && p.Status == models.PayoutSent {
+			p.Status = models.PayoutReturned
+			p.ReturnCode = returnCode
+			p.ReturnTransactionID = &transactionID
+			return nil
+		}
+	}
+	return repository.ErrPayoutNotSent
+}
+
+var testACHOriginator = nacha.Originator{
+	ImmediateDestination: "091000019",
+	ImmediateOrigin:      "1234567890",
+	CompanyName:          "FastFunds",
+	CompanyID:            "1234567890",
+	ODFI:                 "09100001",
+}
+
+// newTestPayoutService pays out from SRC1, held by customer 7, to customer
+// 7's external accounts: 5 is a US account and 6 a UK one.
+func newTestPayoutService(repo *mockPayoutRepository, transfers *fakeTransfers, outputDir string, opts ...func(*PayoutService)) *PayoutService {
+	external := &mockExternalAccountRepository{getByIDFn: func(customerID, id int) (*models.ExternalAccount, error) {
+		switch {
+		case customerID == 7 && id == 5:
+			return &models.ExternalAccount{ID: 5, CustomerID: 7, Type: models.ExternalAccountTypeUS, HolderName: "Jane Doe",
+				RoutingNumber: "021000021", AccountNumber: "12345678"}, nil
+		case customerID == 7 && id == 6:
+			return &models.ExternalAccount{ID: 6, CustomerID: 7, Type: models.ExternalAccountTypeUK, HolderName: "Jane Doe",
+				SortCode: "200000", AccountNumber: "55779911"}, nil
+		}
+		return nil, errors.New("external account not found")
+	}}
+	holders := &mockAccountHolderRepository{listByAccountFn: func(accountID int) ([]*models.AccountHolder, error) {
+		if accountID == 1 {
+			return []*models.AccountHolder{{AccountID: 1, CustomerID: 7, Role: models.HolderRoleOwner}}, nil
+		}
+		return nil, nil
+	}}
+	opts = append([]func(*PayoutService){WithACH(testACHOriginator, 4, outputDir)}, opts...)
+	s := NewPayoutService(&sql.DB{}, repo, external, holders, transfers, opts...)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestCreatePayout(t *testing.T) {
+	repo := &mockPayoutRepository{}
+	transfers := newFakeTransfers()
+	auditLog := &mockAuditLog{}
+	svc := newTestPayoutService(repo, transfers, t.TempDir(), WithPayoutAuditLog(auditLog))
+
+	p, err := svc.CreatePayout(operatorCtx, &models.PayoutRequest{
+		SourceAccountNumber: "SRC1", CustomerID: 7, ExternalAccountID: 5, Amount: "2.50",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, p.ID)
+	assert.Equal(t, models.PayoutPending, p.Status)
+	assert.Equal(t, int64(250), p.AmountPennies)
+	assert.Equal(t, "021000021", p.RoutingNumber)
+	assert.Equal(t, "12345678", p.ReceiverAccount)
+	assert.Equal(t, "Jane Doe", p.ReceiverName)
+	assert.Equal(t, 100, p.TransactionID)
+	assert.Equal(t, "ops", p.CreatedBy)
+
+	// The amount goes into the clearing account
+	if assert.Len(t, transfers.made, 1) {
+		assert.Equal(t, 4, transfers.made[0].DestinationAccountID)
+		assert.Equal(t, "ops", transfers.made[0].InitiatedBy)
+	}
+	assert.Equal(t, []string{audit.ActionPayoutCreate}, auditLog.actions())
+
+	got, err := svc.GetPayout(auditorCtx, p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, p.AmountPennies, got.AmountPennies)
+	_, err = svc.GetPayout(auditorCtx, 99)
+	assert.EqualError(t, err, "payout not found")
+}
+
+func TestCreatePayout_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		req  models.PayoutRequest
+		want string
+	}{
+		{"no customer", models.PayoutRequest{SourceAccountNumber: "SRC1", ExternalAccountID: 5, Amount: "1.00"}, "invalid customer_id"},
+		{"unknown external account", models.PayoutRequest{SourceAccountNumber: "SRC1", CustomerID: 7, ExternalAccountID: 9, Amount: "1.00"}, "external account not found"},
+		{"not a US account", models.PayoutRequest{SourceAccountNumber: "SRC1", CustomerID: 7, ExternalAccountID: 6, Amount: "1.00"}, "payouts can only be made to US bank accounts"},
+		{"not a holder", models.PayoutRequest{SourceAccountNumber: "SRC2", CustomerID: 7, ExternalAccountID: 5, Amount: "1.00"}, "the external account must belong to a holder of the source account"},
+		{"insufficient funds", models.PayoutRequest{SourceAccountNumber: "SRC1", CustomerID: 7, ExternalAccountID: 5, Amount: "10.01"}, "insufficient funds"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockPayoutRepository{}
+			transfers := newFakeTransfers()
+			svc := newTestPayoutService(repo, transfers, t.TempDir())
+			_, err := svc.CreatePayout(operatorCtx, &tc.req)
+			assert.EqualError(t, err, tc.want)
+			assert.Empty(t, transfers.made)
+			assert.Empty(t, repo.payouts)
+		})
+	}
+
+	svc := NewPayoutService(&sql.DB{}, &mockPayoutRepository{}, &mockExternalAccountRepository{}, &mockAccountHolderRepository{}, newFakeTransfers())
+	_, err := svc.CreatePayout(operatorCtx, &models.PayoutRequest{CustomerID: 7, ExternalAccountID: 5})
+	assert.Equal(t, errPayoutsNotConfigured, err)
+	_, err = svc.CreatePayout(auditorCtx, &models.PayoutRequest{CustomerID: 7, ExternalAccountID: 5})
+	assert.Equal(t, ErrForbidden, err)
+}
+
+func TestCreateFile(t *testing.T) {
+	dir := t.TempDir()
+	repo := &mockPayoutRepository{incomplete: map[int]bool{2: true}}
+	svc := newTestPayoutService(repo, newFakeTransfers(), dir)
+	for _, amount := range []string{"1.00", "2.00", "3.00"} {
+		if _, err := svc.CreatePayout(operatorCtx, &models.PayoutRequest{
+			SourceAccountNumber: "SRC1", CustomerID: 7, ExternalAccountID: 5, Amount: amount,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := svc.CreateFile(operatorCtx)
+	if !assert.NoError(t, err) || !assert.NotNil(t, file) {
+		return
+	}
+	assert.Equal(t, "ach-20240301-A.ach", file.FileName)
+	assert.Equal(t, 2, file.EntryCount)
+	assert.Equal(t, int64(400), file.TotalPennies)
+	assert.NotNil(t, file.WrittenAt)
+
+	content, err := os.ReadFile(filepath.Join(dir, file.FileName))
+	assert.NoError(t, err)
+	assert.Equal(t, file.Content, string(content))
+	assert.Contains(t, string(content), "PAYOUT-1       JANE DOE")
+	assert.NotContains(t, string(content), "PAYOUT-2")
+
+	// The payout whose transfer is still waiting stays pending
+	assert.Equal(t, models.PayoutSent, repo.payouts[0].Status)
+	assert.Equal(t, "091000010000001", repo.payouts[0].TraceNumber)
+	assert.Equal(t, models.PayoutPending, repo.payouts[1].Status)
+
+	file, err = svc.CreateFile(operatorCtx)
+	assert.NoError(t, err)
+	assert.Nil(t, file, "nothing left to pay out")
+
+	repo.incomplete = nil
+	file, _ = svc.CreateFile(operatorCtx)
+	if assert.NotNil(t, file) {
+		assert.Equal(t, "ach-20240301-B.ach", file.FileName)
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	repo := &mockPayoutRepository{files: []*models.ACHFile{{ID: 1, FileName: "ach-20240301-A.ach", Content: "101\n"}}}
+	svc := newTestPayoutService(repo, newFakeTransfers(), dir)
+
+	n, err := svc.WriteFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	content, _ := os.ReadFile(filepath.Join(dir, "ach-20240301-A.ach"))
+	assert.Equal(t, "101\n", string(content))
+	assert.NotNil(t, repo.files[0].WrittenAt)
+
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
+
+// testReturnFile returns the given traces, amounts and R-codes.
+func testReturnFile(returns ...nacha.Return) string {
+	pad := func(s string) string { return s + strings.Repeat(" ", 94-len(s)) }
+	var lines []string
+	for _, r := range returns {
+		lines = append(lines,
+			pad(fmt.Sprintf("626091000019123456789        %010dPAYOUT", r.AmountPennies)),
+			pad("799"+r.Code+r.OriginalTrace))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestProcessReturns(t *testing.T) {
+	repo := &mockPayoutRepository{}
+	transfers := newFakeTransfers()
+	auditLog := &mockAuditLog{}
+	svc := newTestPayoutService(repo, transfers, t.TempDir(), WithPayoutAuditLog(auditLog))
+	for _, amount := range []string{"1.00", "2.00"} {
+		if _, err := svc.CreatePayout(operatorCtx, &models.PayoutRequest{
+			SourceAccountNumber: "SRC1", CustomerID: 7, ExternalAccountID: 5, Amount: amount,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := svc.CreateFile(operatorCtx); err != nil {
+		t.Fatal(err)
+	}
+	transfers.made = nil
+
+	file := testReturnFile(
+		nacha.Return{Code: "R03", OriginalTrace: "091000010000001", AmountPennies: 100},
+		nacha.Return{Code: "R01", OriginalTrace: "091000010000002", AmountPennies: 999},
+		nacha.Return{Code: "R01", OriginalTrace: "091000019999999", AmountPennies: 100},
+	)
+	_, err := svc.ProcessReturns(ownerCtx, strings.NewReader(file))
+	assert.Equal(t, ErrForbidden, err)
+
+	result, err := svc.ProcessReturns(operatorCtx, strings.NewReader(file))
+	if !assert.NoError(t, err) || !assert.Len(t, result.Returns, 3) {
+		return
+	}
+	assert.Equal(t, models.ACHReturnReversed, result.Returns[0].Status)
+	assert.Equal(t, 1, result.Returns[0].PayoutID)
+	assert.Equal(t, "returned amount doesn't match the payout", result.Returns[1].Error)
+	assert.Equal(t, "no payout has this trace number", result.Returns[2].Error)
+
+	// The amount goes back from the clearing account to the payer
+	if assert.Len(t, transfers.made, 1) {
+		assert.Equal(t, 4, transfers.made[0].SourceAccountID)
+		assert.Equal(t, 1, transfers.made[0].DestinationAccountID)
+		assert.Equal(t, "1.00", transfers.made[0].Amount)
+	}
+	assert.Equal(t, models.PayoutReturned, repo.payouts[0].Status)
+	assert.Equal(t, "R03", repo.payouts[0].ReturnCode)
+	assert.Equal(t, models.PayoutSent, repo.payouts[1].Status)
+	assert.Contains(t, auditLog.actions(), audit.ActionPayoutReturn)
+
+	// Processing the same file again changes nothing
+	result, err = svc.ProcessReturns(operatorCtx, strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, models.ACHReturnSkipped, result.Returns[0].Status)
+	assert.Equal(t, "payout is already returned", result.Returns[0].Error)
+	assert.Len(t, transfers.made, 1)
+}
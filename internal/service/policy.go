@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/repository"
+)
+
+var (
+	// ErrUnauthenticated is returned when a call carries no principal.
+	ErrUnauthenticated = errors.New("authentication required")
+	// ErrForbidden is returned when the principal may not perform the action.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Action names an operation checked by a Policy.
+type Action string
+
+const (
+	ActionCreateAccount          Action = "account:create"
+	ActionReadAccount            Action = "account:read"
+	ActionManageHolders          Action = "account:manage_holders"
+	ActionDebitAccount           Action = "account:debit"
+	ActionReadTransaction        Action = "transaction:read"
+	ActionListTransactions       Action = "transaction:list"
+	ActionReviewTransaction      Action = "transaction:review"
+	ActionReadCustomer           Action = "customer:read"
+	ActionListCustomers          Action = "customer:list"
+	ActionWriteCustomer          Action = "customer:write"
+	ActionReadExternalAccounts   Action = "external_account:read"
+	ActionManageExternalAccounts Action = "external_account:manage"
+	ActionReadScreening          Action = "screening:read"
+	ActionResolveScreening       Action = "screening:resolve"
+	ActionReadAPIKeys            Action = "api_key:read"
+	ActionManageAPIKeys          Action = "api_key:manage"
+	ActionReadWebhooks           Action = "webhook:read"
+	ActionManageWebhooks         Action = "webhook:manage"
+	ActionImportTransfers        Action = "transfer_import:create"
+	ActionReadTransferImports    Action = "transfer_import:read"
+	ActionInitiatePayments       Action = "payment_initiation:create"
+	ActionCreatePayouts          Action = "payout:create"
+	ActionReadPayouts            Action = "payout:read"
+	ActionProcessACHReturns      Action = "ach:process_returns"
+	ActionReconcile              Action = "reconciliation:run"
+	ActionListBalances           Action = "balance:list"
+	ActionCloseBusinessDay       Action = "business_day:close"
+	ActionReadBusinessDays       Action = "business_day:read"
+	ActionManageLedger           Action = "ledger:manage"
+	ActionReadLedger             Action = "ledger:read"
+	ActionManageProducts         Action = "product:manage"
+	ActionReadProducts           Action = "product:read"
+	ActionCreateEscrows          Action = "escrow:create"
+	ActionReadEscrows            Action = "escrow:read"
+	ActionDisputeEscrows         Action = "escrow:dispute"
+	ActionSettleEscrows          Action = "escrow:settle"
+	ActionOpenDisputes           Action = "dispute:open"
+	ActionReadDisputes           Action = "dispute:read"
+	ActionListDisputes           Action = "dispute:list"
+	ActionAddDisputeNotes        Action = "dispute:add_note"
+	ActionResolveDisputes        Action = "dispute:resolve"
+	ActionReadReconciliation     Action = "reconciliation:read"
+)
+
+// Resource identifies what an action touches, for ownership checks.
+type Resource struct {
+	// CustomerID is the customer record the action reads or changes.
+	CustomerID int
+	// AccountIDs are the accounts involved; holding any one of them is enough.
+	AccountIDs []int
+}
+
+// Policy decides whether a principal may perform an action on a resource.
+type Policy interface {
+	Authorize(p *auth.Principal, action Action, res Resource) error
+}
+
+// rolePermissions lists the actions each role may perform. Customers are
+// further limited to resources they own.
+var rolePermissions = map[string]map[Action]bool{
+	auth.RoleCustomer: actionSet(
+		ActionReadAccount, ActionDebitAccount, ActionReadTransaction,
+		ActionReadCustomer, ActionReadExternalAccounts, ActionManageExternalAccounts,
+		ActionInitiatePayments, ActionCreatePayouts, ActionReadPayouts,
+		ActionCreateEscrows, ActionReadEscrows, ActionDisputeEscrows,
+		ActionOpenDisputes, ActionReadDisputes, ActionAddDisputeNotes,
+	),
+	auth.RoleOperator: actionSet(
+		ActionCreateAccount, ActionReadAccount, ActionManageHolders, ActionDebitAccount,
+		ActionReadTransaction, ActionListTransactions, ActionReviewTransaction,
+		ActionReadCustomer, ActionListCustomers, ActionWriteCustomer,
+		ActionReadExternalAccounts, ActionManageExternalAccounts,
+		ActionReadScreening, ActionResolveScreening,
+		ActionReadWebhooks, ActionManageWebhooks,
+		ActionImportTransfers, ActionReadTransferImports, ActionInitiatePayments,
+		ActionCreatePayouts, ActionReadPayouts, ActionProcessACHReturns,
+		ActionReadReconciliation, ActionListBalances,
+		ActionCloseBusinessDay, ActionReadBusinessDays,
+		ActionManageLedger, ActionReadLedger, ActionManageProducts, ActionReadProducts,
+		ActionCreateEscrows, ActionReadEscrows, ActionDisputeEscrows, ActionSettleEscrows,
+		ActionOpenDisputes, ActionReadDisputes, ActionListDisputes, ActionAddDisputeNotes,
+		ActionResolveDisputes,
+	),
+	auth.RoleAuditor: actionSet(
+		ActionReadAccount, ActionReadTransaction, ActionListTransactions,
+		ActionReadCustomer, ActionListCustomers, ActionReadExternalAccounts,
+		ActionReadScreening, ActionReadAPIKeys, ActionReadWebhooks,
+		ActionReadTransferImports, ActionReadPayouts, ActionReadReconciliation,
+		ActionListBalances, ActionReadBusinessDays, ActionReadLedger, ActionReadProducts,
+		ActionReadEscrows, ActionReadDisputes, ActionListDisputes,
+	),
+}
+
+func actionSet(actions ...Action) map[Action]bool {
+	set := make(map[Action]bool, len(actions))
+	for _, a := range actions {
+		set[a] = true
+	}
+	return set
+}
+
+func NewRolePolicy(holderRepo repository.AccountHolderRepository) *RolePolicy {
+	return &RolePolicy{holderRepo: holderRepo}
+}
+
+// RolePolicy grants actions by role. Admins may do anything. Customers may
+// only act on their own customer record and on accounts they hold; without
+// a holder repository they hold no accounts.
+type RolePolicy struct {
+	holderRepo repository.AccountHolderRepository
+}
+
+func (p *RolePolicy) Authorize(principal *auth.Principal, action Action, res Resource) error {
+	if principal == nil {
+		return ErrUnauthenticated
+	}
+	if principal.Role == auth.RoleAdmin {
+		return nil
+	}
+	if !rolePermissions[principal.Role][action] {
+		return ErrForbidden
+	}
+	if principal.Role != auth.RoleCustomer {
+		return nil
+	}
+
+	// A customer's action must name something they own
+	if res.CustomerID == 0 && len(res.AccountIDs) == 0 {
+		return ErrForbidden
+	}
+	if res.CustomerID != 0 && res.CustomerID != principal.CustomerID {
+		return ErrForbidden
+	}
+	if len(res.AccountIDs) > 0 {
+		held, err := p.holdsAny(principal.CustomerID, res.AccountIDs)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+func (p *RolePolicy) holdsAny(customerID int, accountIDs []int) (bool, error) {
+	if p.holderRepo == nil {
+		return false, nil
+	}
+	for _, accountID := range accountIDs {
+		holders, err := p.holderRepo.ListByAccount(accountID)
+		if err != nil {
+			return false, errors.New("couldn't get account holders")
+		}
+		for _, h := range holders {
+			if h.CustomerID == customerID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// authorize checks the principal carried by ctx against policy.
+func authorize(ctx context.Context, policy Policy, action Action, res Resource) error {
+	p, _ := auth.FromContext(ctx)
+	return policy.Authorize(p, action, res)
+}
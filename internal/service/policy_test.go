@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Principals used across the service tests. Customer 7 holds account 100;
+// customer 8 holds nothing.
+var (
+	adminCtx    = principalCtx(&auth.Principal{Subject: "root", Role: auth.RoleAdmin})
+	operatorCtx = principalCtx(&auth.Principal{Subject: "ops", Role: auth.RoleOperator})
+	auditorCtx  = principalCtx(&auth.Principal{Subject: "audit", Role: auth.RoleAuditor})
+	ownerCtx    = principalCtx(&auth.Principal{Subject: "alice", Role: auth.RoleCustomer, CustomerID: 7})
+	strangerCtx = principalCtx(&auth.Principal{Subject: "mallory", Role: auth.RoleCustomer, CustomerID: 8})
+)
+
+func principalCtx(p *auth.Principal) context.Context {
+	return auth.WithPrincipal(context.Background(), p)
+}
+
+// testHolders has customer 7 as the owner of account 100.
+func testHolders() *mockAccountHolderRepository {
+	return &mockAccountHolderRepository{listByAccountFn: func(accountID int) ([]*models.AccountHolder, error) {
+		if accountID == 100 {
+			return []*models.AccountHolder{{AccountID: 100, CustomerID: 7, Role: models.HolderRoleOwner}}, nil
+		}
+		return nil, nil
+	}}
+}
+
+func TestRolePolicy(t *testing.T) {
+	policy := NewRolePolicy(testHolders())
+	principals := map[string]context.Context{
+		"owner": ownerCtx, "stranger": strangerCtx, "operator": operatorCtx, "admin": adminCtx, "auditor": auditorCtx,
+	}
+
+	cases := []struct {
+		endpoint string
+		action   Action
+		res      Resource
+		allowed  []string
+	}{
+		{"POST /accounts", ActionCreateAccount, Resource{}, []string{"operator", "admin"}},
+		{"GET /accounts/:account_number", ActionReadAccount, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin", "auditor"}},
+		{"GET /accounts/:account_number/events", ActionReadAccount, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin", "auditor"}},
+		{"POST /accounts/:account_number/holders", ActionManageHolders, Resource{AccountIDs: []int{100}}, []string{"operator", "admin"}},
+		{"DELETE /accounts/:account_number/holders/:customer_id", ActionManageHolders, Resource{AccountIDs: []int{100}}, []string{"operator", "admin"}},
+		{"POST /transactions", ActionDebitAccount, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin"}},
+		{"POST /transactions from an unheld account", ActionDebitAccount, Resource{AccountIDs: []int{200}}, []string{"operator", "admin"}},
+		{"GET /transactions", ActionListTransactions, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"GET /transactions/:transaction_id", ActionReadTransaction, Resource{AccountIDs: []int{200, 100}}, []string{"owner", "operator", "admin", "auditor"}},
+		{"POST /transactions/:transaction_id/approve", ActionReviewTransaction, Resource{}, []string{"operator", "admin"}},
+		{"POST /transactions/:transaction_id/reject", ActionReviewTransaction, Resource{}, []string{"operator", "admin"}},
+		{"POST /customers", ActionWriteCustomer, Resource{}, []string{"operator", "admin"}},
+		{"GET /customers", ActionListCustomers, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"GET /customers/:customer_id", ActionReadCustomer, Resource{CustomerID: 7}, []string{"owner", "operator", "admin", "auditor"}},
+		{"PUT /customers/:customer_id", ActionWriteCustomer, Resource{CustomerID: 7}, []string{"operator", "admin"}},
+		{"GET /customers/:customer_id/accounts", ActionReadCustomer, Resource{CustomerID: 7}, []string{"owner", "operator", "admin", "auditor"}},
+		{"GET /customers/:customer_id/external-accounts", ActionReadExternalAccounts, Resource{CustomerID: 7}, []string{"owner", "operator", "admin", "auditor"}},
+		{"POST /customers/:customer_id/external-accounts", ActionManageExternalAccounts, Resource{CustomerID: 7}, []string{"owner", "operator", "admin"}},
+		{"DELETE /customers/:customer_id/external-accounts/:id", ActionManageExternalAccounts, Resource{CustomerID: 7}, []string{"owner", "operator", "admin"}},
+		{"GET /screening/cases", ActionReadScreening, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"POST /screening/cases/:case_id/clear", ActionResolveScreening, Resource{}, []string{"operator", "admin"}},
+		{"GET /admin/api-keys", ActionReadAPIKeys, Resource{}, []string{"admin", "auditor"}},
+		{"POST /admin/api-keys", ActionManageAPIKeys, Resource{}, []string{"admin"}},
+		{"POST /imports", ActionImportTransfers, Resource{}, []string{"operator", "admin"}},
+		{"GET /imports/:import_id", ActionReadTransferImports, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"POST /payment-initiations", ActionInitiatePayments, Resource{CustomerID: 7}, []string{"owner", "operator", "admin"}},
+		{"POST /payouts", ActionCreatePayouts, Resource{CustomerID: 7}, []string{"owner", "operator", "admin"}},
+		{"GET /payouts/:payout_id", ActionReadPayouts, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin", "auditor"}},
+		{"POST /ach/returns", ActionProcessACHReturns, Resource{}, []string{"operator", "admin"}},
+		{"POST /admin/reconciliation", ActionReconcile, Resource{}, []string{"admin"}},
+		{"GET /admin/reconciliation", ActionReadReconciliation, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"GET /balances", ActionListBalances, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"POST /business-days/:day/close", ActionCloseBusinessDay, Resource{}, []string{"operator", "admin"}},
+		{"GET /business-days/:day", ActionReadBusinessDays, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"POST /ledger-accounts", ActionManageLedger, Resource{}, []string{"operator", "admin"}},
+		{"GET /reports/trial-balance", ActionReadLedger, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"PUT /products/:code", ActionManageProducts, Resource{}, []string{"operator", "admin"}},
+		{"GET /products", ActionReadProducts, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"POST /escrows", ActionCreateEscrows, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin"}},
+		{"GET /escrows/:escrow_id", ActionReadEscrows, Resource{AccountIDs: []int{200, 100}}, []string{"owner", "operator", "admin", "auditor"}},
+		{"POST /escrows/:escrow_id/dispute", ActionDisputeEscrows, Resource{AccountIDs: []int{100, 200}}, []string{"owner", "operator", "admin"}},
+		{"POST /escrows/:escrow_id/release", ActionSettleEscrows, Resource{}, []string{"operator", "admin"}},
+		{"POST /disputes", ActionOpenDisputes, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin"}},
+		{"POST /disputes on someone else's transfer", ActionOpenDisputes, Resource{AccountIDs: []int{200}}, []string{"operator", "admin"}},
+		{"GET /disputes", ActionListDisputes, Resource{}, []string{"operator", "admin", "auditor"}},
+		{"GET /disputes/:dispute_id", ActionReadDisputes, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin", "auditor"}},
+		{"POST /disputes/:dispute_id/notes", ActionAddDisputeNotes, Resource{AccountIDs: []int{100}}, []string{"owner", "operator", "admin"}},
+		{"POST /disputes/:dispute_id/review", ActionResolveDisputes, Resource{}, []string{"operator", "admin"}},
+		{"POST /disputes/:dispute_id/resolve", ActionResolveDisputes, Resource{}, []string{"operator", "admin"}},
+	}
+	for _, tc := range cases {
+		for name, ctx := range principals {
+			t.Run(tc.endpoint+"/"+name, func(t *testing.T) {
+				want := ErrForbidden
+				for _, a := range tc.allowed {
+					if a == name {
+						want = nil
+					}
+				}
+				assert.Equal(t, want, authorize(ctx, policy, tc.action, tc.res))
+			})
+		}
+	}
+}
+
+func TestRolePolicy_EdgeCases(t *testing.T) {
+	policy := NewRolePolicy(testHolders())
+
+	assert.Equal(t, ErrUnauthenticated, authorize(context.Background(), policy, ActionReadAccount, Resource{AccountIDs: []int{100}}))
+	assert.Equal(t, ErrForbidden, authorize(principalCtx(&auth.Principal{Subject: "x", Role: "root"}), policy, ActionReadAccount, Resource{}))
+	// A customer's action must name something they own
+	assert.Equal(t, ErrForbidden, authorize(ownerCtx, policy, ActionReadCustomer, Resource{}))
+
+	failing := NewRolePolicy(&mockAccountHolderRepository{listByAccountFn: func(int) ([]*models.AccountHolder, error) {
+		return nil, errors.New("db down")
+	}})
+	assert.EqualError(t, authorize(ownerCtx, failing, ActionReadAccount, Resource{AccountIDs: []int{100}}), "couldn't get account holders")
+
+	noHolders := NewRolePolicy(nil)
+	assert.Equal(t, ErrForbidden, authorize(ownerCtx, noHolders, ActionReadAccount, Resource{AccountIDs: []int{100}}))
+	assert.NoError(t, authorize(ownerCtx, noHolders, ActionReadCustomer, Resource{CustomerID: 7}))
+}
+
+// TestServiceAuthorization checks that each service consults the policy with
+// the right resource before doing any work.
+func TestServiceAuthorization(t *testing.T) {
+	policy := NewRolePolicy(testHolders())
+	account := &models.Account{AccountID: 100, AccountNumber: "FF17FAST4821930576", CurrentBalance: 1000}
+	accounts := newTestAccountService(&mockAccountRepository{
+		getByIDFn: func(int) (*models.Account, error) { return account, nil },
+	}, &mockMoneyConverter{}, WithAccountPolicy(policy))
+
+	transfers := NewTransactionServiceWithDeps(&sql.DB{}, &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			return &models.Account{AccountID: id, CurrentBalance: 1000}, nil
+		},
+	}, &mockTransactionRepo{
+		CreateTxFunc: func(*sql.Tx, *models.Transaction) error { return nil },
+		GetByIDFunc: func(id int) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, SourceAccountID: 200, DestinationAccountID: 100}, nil
+		},
+	}, &transactionMockMoneyConverter{decFn: func(string) (int64, error) { return 200, nil }}, WithTransferPolicy(policy))
+	setTxnFns(transfers)
+
+	customers := NewCustomerService(&sql.DB{}, &mockCustomerRepository{
+		getByIDFn: func(id int) (*models.Customer, error) { return &models.Customer{ID: id}, nil },
+	}, testHolders(), WithCustomerPolicy(policy))
+
+	cases := []struct {
+		name    string
+		call    func(ctx context.Context) error
+		owner   error
+		outside error
+	}{
+		{"GetAccount", func(ctx context.Context) error {
+			_, err := accounts.GetAccount(ctx, 100)
+			return err
+		}, nil, ErrForbidden},
+		{"CreateAccount", func(ctx context.Context) error {
+			_, err := accounts.CreateAccount(ctx, &models.CreateAccountRequest{HolderName: "Alice", InitialBalance: "1.00"})
+			return err
+		}, ErrForbidden, ErrForbidden},
+		{"ProcessTransaction", func(ctx context.Context) error {
+			_, err := transfers.ProcessTransaction(ctx, &models.TransactionRequest{SourceAccountID: 100, DestinationAccountID: 200, Amount: "2.00"})
+			return err
+		}, nil, ErrForbidden},
+		{"ProcessTransaction into own account", func(ctx context.Context) error {
+			_, err := transfers.ProcessTransaction(ctx, &models.TransactionRequest{SourceAccountID: 200, DestinationAccountID: 100, Amount: "2.00"})
+			return err
+		}, ErrForbidden, ErrForbidden},
+		{"GetTransaction", func(ctx context.Context) error {
+			_, err := transfers.GetTransaction(ctx, 1)
+			return err
+		}, nil, ErrForbidden},
+		{"ListTransactions", func(ctx context.Context) error {
+			_, err := transfers.ListTransactions(ctx, models.TransactionStatusCompleted)
+			return err
+		}, ErrForbidden, ErrForbidden},
+		{"GetCustomer", func(ctx context.Context) error {
+			_, err := customers.GetCustomer(ctx, 7)
+			return err
+		}, nil, ErrForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.owner, tc.call(ownerCtx))
+			assert.Equal(t, tc.outside, tc.call(strangerCtx))
+			assert.Equal(t, ErrUnauthenticated, tc.call(context.Background()))
+		})
+	}
+}
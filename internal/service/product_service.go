@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"regexp"
+	"strings"
+)
+
+var productCode = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+var productTypes = map[string]bool{
+	models.ProductTypeChecking: true,
+	models.ProductTypeSavings:  true,
+	models.ProductTypeBusiness: true,
+	models.ProductTypeEscrow:   true,
+	models.ProductTypeInternal: true,
+}
+
+func NewProductService(db *sql.DB, productRepo repository.ProductRepository, opts ...func(*ProductService)) *ProductService {
+	s := &ProductService{
+		db:          db,
+		productRepo: productRepo,
+		policy:      NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithProductPolicy sets the authorization policy.
+func WithProductPolicy(policy Policy) func(*ProductService) {
+	return func(s *ProductService) {
+		s.policy = policy
+	}
+}
+
+// WithProductAuditLog records product changes and migrations in the audit
+// log.
+func WithProductAuditLog(auditLog repository.AuditLogRepository) func(*ProductService) {
+	return func(s *ProductService) {
+		s.auditLog = auditLog
+	}
+}
+
+// ProductService manages account products. A product's terms are never
+// changed in place: UpdateTerms adds a version, which applies to accounts
+// opened from then on and to existing accounts once they are migrated.
+type ProductService struct {
+	db          *sql.DB
+	productRepo repository.ProductRepository
+	auditLog    repository.AuditLogRepository
+	policy      Policy
+	beginFn     func() (*sql.Tx, error)
+	rollbackFn  func(*sql.Tx) error
+	commitFn    func(*sql.Tx) error
+}
+
+func (s *ProductService) CreateProduct(ctx context.Context, req *models.ProductRequest) (*models.Product, error) {
+	if err := authorize(ctx, s.policy, ActionManageProducts, Resource{}); err != nil {
+		return nil, err
+	}
+
+	p := &models.Product{
+		Code:  strings.TrimSpace(req.Code),
+		Name:  strings.TrimSpace(req.Name),
+		Type:  req.Type,
+		Terms: req.Terms,
+	}
+	if !productCode.MatchString(p.Code) {
+		return nil, errors.New("code must be 1 to 32 lower-case letters, digits, hyphens or underscores")
+	}
+	if p.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if !productTypes[p.Type] {
+		return nil, errors.New("type must be checking, savings, business, escrow or internal")
+	}
+	if err := checkProductTerms(&p.Terms); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	created, err := s.productRepo.CreateTx(tx, p)
+	if err != nil {
+		return nil, productError(err, "couldn't create product")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionProductCreate, audit.EntityProduct, created.Code, nil, created); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return created, nil
+}
+
+// UpdateTerms makes terms the product's next version.
+func (s *ProductService) UpdateTerms(ctx context.Context, code string, terms *models.ProductTerms) (*models.Product, error) {
+	if err := authorize(ctx, s.policy, ActionManageProducts, Resource{}); err != nil {
+		return nil, err
+	}
+	if err := checkProductTerms(terms); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	before, after, err := s.productRepo.AddVersionTx(tx, code, terms)
+	if err != nil {
+		return nil, productError(err, "couldn't update product")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionProductUpdate, audit.EntityProduct, code, before, after); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return after, nil
+}
+
+// MigrateAccounts moves every account on an earlier version of the product
+// onto its current version. Their currency and ledger account stay as they
+// are.
+func (s *ProductService) MigrateAccounts(ctx context.Context, code string) (*models.ProductMigration, error) {
+	if err := authorize(ctx, s.policy, ActionManageProducts, Resource{}); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.Get(code)
+	if err != nil {
+		return nil, productError(err, "couldn't get product")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	m := &models.ProductMigration{ProductCode: product.Code, Version: product.Version}
+	if m.AccountsMigrated, err = s.productRepo.MigrateTx(tx, product.Code, product.Version); err != nil {
+		return nil, errors.New("couldn't migrate accounts")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionProductMigrate, audit.EntityProduct, product.Code, nil, m); err != nil {
+		return nil, err
+	}
+	if err := s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return m, nil
+}
+
+func (s *ProductService) GetProduct(ctx context.Context, code string) (*models.Product, error) {
+	if err := authorize(ctx, s.policy, ActionReadProducts, Resource{}); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.Get(code)
+	if err != nil {
+		return nil, productError(err, "couldn't get product")
+	}
+	return product, nil
+}
+
+// ListProducts returns every product with its current terms.
+func (s *ProductService) ListProducts(ctx context.Context) ([]*models.Product, error) {
+	if err := authorize(ctx, s.policy, ActionReadProducts, Resource{}); err != nil {
+		return nil, err
+	}
+	list, err := s.productRepo.List()
+	if err != nil {
+		return nil, errors.New("couldn't list products")
+	}
+	if list == nil {
+		list = []*models.Product{}
+	}
+	return list, nil
+}
+
+// ListVersions returns every version of the product's terms, oldest first.
+func (s *ProductService) ListVersions(ctx context.Context, code string) ([]*models.ProductVersion, error) {
+	if err := authorize(ctx, s.policy, ActionReadProducts, Resource{}); err != nil {
+		return nil, err
+	}
+	list, err := s.productRepo.ListVersions(code)
+	if err != nil {
+		return nil, errors.New("couldn't list product versions")
+	}
+	if len(list) == 0 {
+		return nil, repository.ErrProductNotFound
+	}
+	return list, nil
+}
+
+func checkProductTerms(t *models.ProductTerms) error {
+	t.Currency = strings.TrimSpace(t.Currency)
+	t.LedgerCode = strings.TrimSpace(t.LedgerCode)
+	if !util.IsCurrencyCode(t.Currency) {
+		return errors.New("currency must be an ISO 4217 code such as GBP")
+	}
+	if t.OverdraftLimit < 0 || t.MonthlyFee < 0 || t.TransferFee < 0 || t.InterestRateBPS < 0 {
+		return errors.New("overdraft limit, fees and interest rate may not be negative")
+	}
+	if (t.MaxTransfer != nil && *t.MaxTransfer <= 0) || (t.DailyTransferLimit != nil && *t.DailyTransferLimit <= 0) {
+		return errors.New("transfer limits must be positive")
+	}
+	if t.LedgerCode == "" {
+		t.LedgerCode = models.LedgerCodeCustomerDeposits
+	}
+	return nil
+}
+
+// productError passes through the errors callers can act on and replaces
+// the rest with msg.
+func productError(err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrDuplicateProduct):
+		return err
+	case errors.Is(err, repository.ErrLedgerAccountNotFound):
+		return errors.New("unknown ledger_code")
+	}
+	return errors.New(msg)
+}
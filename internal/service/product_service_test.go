@@ -0,0 +1,178 @@
+package service
+
+import (
+	"database/sql"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProductRepository struct {
+	versions map[string][]*models.Product
+	accounts map[string][]int // product code to the versions of its accounts
+}
+
+// newMockProductRepository holds the seeded checking product.
+func newMockProductRepository() *mockProductRepository {
+	m := &mockProductRepository{versions: map[string][]*models.Product{}, accounts: map[string][]int{}}
+	m.versions[models.DefaultProductCode] = []*models.Product{{
+		Code: models.DefaultProductCode, Name: "Checking", Type: models.ProductTypeChecking, Version: 1,
+		Terms: models.ProductTerms{Currency: "GBP", LedgerCode: models.LedgerCodeCustomerDeposits},
+	}}
+	return m
+}
+
+func (m *mockProductRepository) CreateTx(tx *sql.Tx, p *models.Product) (*models.Product, error) {
+	if _, ok := m.versions[p.Code]; ok {
+		return nil, repository.ErrDuplicateProduct
+	}
+	if p.Terms.LedgerCode == "9999" {
+		return nil, repository.ErrLedgerAccountNotFound
+	}
+	created := *p
+	created.Version = 1
+	m.versions[p.Code] = []*models.Product{&created}
+	return &created, nil
+}
+
+func (m *mockProductRepository) AddVersionTx(tx *sql.Tx, code string, terms *models.ProductTerms) (*models.Product, *models.Product, error) {
+	before, err := m.Get(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	after := *before
+	after.Version++
+	after.Terms = *terms
+	m.versions[code] = append(m.versions[code], &after)
+	return before, &after, nil
+}
+
+func (m *mockProductRepository) Get(code string) (*models.Product, error) {
+	versions, ok := m.versions[code]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (m *mockProductRepository) List() ([]*models.Product, error) {
+	var list []*models.Product
+	for code := range m.versions {
+		p, _ := m.Get(code)
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+func (m *mockProductRepository) ListVersions(code string) ([]*models.ProductVersion, error) {
+	var list []*models.ProductVersion
+	for _, p := range m.versions[code] {
+		list = append(list, &models.ProductVersion{Version: p.Version, Terms: p.Terms})
+	}
+	return list, nil
+}
+
+func (m *mockProductRepository) MigrateTx(tx *sql.Tx, code string, version int) (int64, error) {
+	var n int64
+	for i, v := range m.accounts[code] {
+		if v < version {
+			m.accounts[code][i] = version
+			n++
+		}
+	}
+	return n, nil
+}
+
+func newTestProductService(repo *mockProductRepository) *ProductService {
+	s := NewProductService(nil, repo)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+func TestCreateProduct(t *testing.T) {
+	repo := newMockProductRepository()
+	s := newTestProductService(repo)
+
+	p, err := s.CreateProduct(operatorCtx, &models.ProductRequest{
+		Code: " junior ", Name: "Junior saver", Type: models.ProductTypeSavings,
+		Terms: models.ProductTerms{Currency: "GBP", InterestRateBPS: 250},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "junior", p.Code)
+		assert.Equal(t, 1, p.Version)
+		assert.Equal(t, models.LedgerCodeCustomerDeposits, p.Terms.LedgerCode)
+	}
+
+	negative, zero := int64(-1), int64(0)
+	cases := []struct {
+		name    string
+		req     models.ProductRequest
+		wantErr string
+	}{
+		{"duplicate", models.ProductRequest{Code: "junior", Name: "Again", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP"}}, "product code already exists"},
+		{"bad code", models.ProductRequest{Code: "Junior Saver", Name: "Junior", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP"}}, "code must be 1 to 32 lower-case letters, digits, hyphens or underscores"},
+		{"no name", models.ProductRequest{Code: "kids", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP"}}, "name is required"},
+		{"bad type", models.ProductRequest{Code: "kids", Name: "Kids", Type: "junior",
+			Terms: models.ProductTerms{Currency: "GBP"}}, "type must be checking, savings, business, escrow or internal"},
+		{"bad currency", models.ProductRequest{Code: "kids", Name: "Kids", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "pounds"}}, "currency must be an ISO 4217 code such as GBP"},
+		{"negative fee", models.ProductRequest{Code: "kids", Name: "Kids", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP", MonthlyFee: -100}}, "overdraft limit, fees and interest rate may not be negative"},
+		{"zero limit", models.ProductRequest{Code: "kids", Name: "Kids", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP", MaxTransfer: &zero}}, "transfer limits must be positive"},
+		{"negative limit", models.ProductRequest{Code: "kids", Name: "Kids", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP", DailyTransferLimit: &negative}}, "transfer limits must be positive"},
+		{"unknown ledger code", models.ProductRequest{Code: "kids", Name: "Kids", Type: models.ProductTypeSavings,
+			Terms: models.ProductTerms{Currency: "GBP", LedgerCode: "9999"}}, "unknown ledger_code"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.CreateProduct(operatorCtx, &tc.req)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+
+	_, err = s.CreateProduct(auditorCtx, &models.ProductRequest{Code: "kids", Name: "Kids", Type: models.ProductTypeSavings})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestUpdateProductTermsAndMigrate(t *testing.T) {
+	repo := newMockProductRepository()
+	repo.accounts[models.DefaultProductCode] = []int{1, 1}
+	s := newTestProductService(repo)
+
+	p, err := s.UpdateTerms(operatorCtx, models.DefaultProductCode, &models.ProductTerms{Currency: "GBP", OverdraftLimit: 5000})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, p.Version)
+	assert.Equal(t, int64(5000), p.Terms.OverdraftLimit)
+	assert.Equal(t, []int{1, 1}, repo.accounts[models.DefaultProductCode], "accounts keep their terms until migrated")
+
+	versions, err := s.ListVersions(auditorCtx, models.DefaultProductCode)
+	if assert.NoError(t, err) && assert.Len(t, versions, 2) {
+		assert.Equal(t, int64(0), versions[0].Terms.OverdraftLimit)
+	}
+
+	m, err := s.MigrateAccounts(operatorCtx, models.DefaultProductCode)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &models.ProductMigration{ProductCode: models.DefaultProductCode, Version: 2, AccountsMigrated: 2}, m)
+	}
+	assert.Equal(t, []int{2, 2}, repo.accounts[models.DefaultProductCode])
+
+	_, err = s.UpdateTerms(operatorCtx, "premier", &models.ProductTerms{Currency: "GBP"})
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+	_, err = s.MigrateAccounts(auditorCtx, models.DefaultProductCode)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.ListVersions(auditorCtx, "premier")
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+	_, err = s.ListProducts(ownerCtx)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
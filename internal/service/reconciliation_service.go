@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+func NewReconciliationService(db *sql.DB, reconciliationRepo repository.ReconciliationRepository, opts ...func(*ReconciliationService)) *ReconciliationService {
+	s := &ReconciliationService{
+		db:                 db,
+		reconciliationRepo: reconciliationRepo,
+		policy:             NewRolePolicy(nil),
+		nowFn:              time.Now,
+	}
+	s.beginReadFn = func(ctx context.Context) (*sql.Tx, error) {
+		return s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithReconciliationPolicy sets the authorization policy.
+func WithReconciliationPolicy(policy Policy) func(*ReconciliationService) {
+	return func(s *ReconciliationService) {
+		s.policy = policy
+	}
+}
+
+// ReconciliationService checks that account balances agree with the
+// transaction history. Balances are overwritten by each transfer, so a bug
+// or a manual UPDATE would otherwise go unnoticed. The latest result is kept
+// for the admin endpoint and the metrics.
+type ReconciliationService struct {
+	db                 *sql.DB
+	reconciliationRepo repository.ReconciliationRepository
+	policy             Policy
+	nowFn              func() time.Time
+	beginReadFn        func(ctx context.Context) (*sql.Tx, error)
+	rollbackFn         func(*sql.Tx) error
+
+	mu   sync.Mutex
+	last *models.Reconciliation
+}
+
+// ErrNotReconciled is returned by LastReconciliation before the first run.
+var ErrNotReconciled = errors.New("no reconciliation has run yet")
+
+// Reconcile recomputes every balance from the account's initial balance and
+// its completed transfers, and checks that the total balance still equals
+// the total the accounts were opened with. Finding discrepancies is not an
+// error; they are in the result.
+func (s *ReconciliationService) Reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	if err := authorize(ctx, s.policy, ActionReconcile, Resource{}); err != nil {
+		return nil, err
+	}
+	return s.reconcile(ctx)
+}
+
+func (s *ReconciliationService) reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	started := s.nowFn()
+	tx, err := s.beginReadFn(ctx)
+	if err != nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+	defer s.rollbackFn(tx)
+
+	result := &models.Reconciliation{Discrepancies: []*models.BalanceDiscrepancy{}}
+	err = s.reconciliationRepo.EachBalanceTx(tx, func(c *models.AccountBalanceCheck) error {
+		result.AccountsChecked++
+		result.TotalBalancePennies += c.BalancePennies
+		result.TotalInitialPennies += c.InitialBalancePennies
+		if c.BalancePennies != c.ExpectedBalancePennies {
+			result.Discrepancies = append(result.Discrepancies, &models.BalanceDiscrepancy{
+				AccountNumber:          c.AccountNumber,
+				BalancePennies:         c.BalancePennies,
+				ExpectedBalancePennies: c.ExpectedBalancePennies,
+				DifferencePennies:      c.BalancePennies - c.ExpectedBalancePennies,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("couldn't read balances")
+	}
+
+	finished := s.nowFn()
+	result.CheckedAt = finished.UTC().Format(time.RFC3339)
+	result.DurationMilliseconds = finished.Sub(started).Milliseconds()
+	result.Conserved = result.TotalBalancePennies == result.TotalInitialPennies
+	result.OK = result.Conserved && len(result.Discrepancies) == 0
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+	return result, nil
+}
+
+// LastReconciliation returns the result of the latest run.
+func (s *ReconciliationService) LastReconciliation(ctx context.Context) (*models.Reconciliation, error) {
+	if err := authorize(ctx, s.policy, ActionReadReconciliation, Resource{}); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return nil, ErrNotReconciled
+	}
+	return s.last, nil
+}
+
+// Run reconciles once at start and then every interval until ctx is done,
+// logging any discrepancies found.
+func (s *ReconciliationService) Run(ctx context.Context, interval time.Duration) {
+	for {
+		result, err := s.reconcile(ctx)
+		switch {
+		case err != nil:
+			log.Print("failed to reconcile balances:", err)
+		case !result.OK:
+			for _, d := range result.Discrepancies {
+				log.Printf("reconciliation: account %s balance %d, expected %d from its history",
+					d.AccountNumber, d.BalancePennies, d.ExpectedBalancePennies)
+			}
+			if !result.Conserved {
+				log.Printf("reconciliation: total balance %d, expected %d",
+					result.TotalBalancePennies, result.TotalInitialPennies)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReconciliationRepository struct {
+	checks []*models.AccountBalanceCheck
+	err    error
+}
+
+func (m *mockReconciliationRepository) EachBalanceTx(tx *sql.Tx, fn func(*models.AccountBalanceCheck) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, c := range m.checks {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestReconciliationService(repo *mockReconciliationRepository) *ReconciliationService {
+	s := NewReconciliationService(&sql.DB{}, repo)
+	s.beginReadFn = func(ctx context.Context) (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.nowFn = func() time.Time { return time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestReconcile(t *testing.T) {
+	repo := &mockReconciliationRepository{checks: []*models.AccountBalanceCheck{
+		{AccountID: 1, AccountNumber: "A", InitialBalancePennies: 1000, BalancePennies: 750, ExpectedBalancePennies: 750},
+		{AccountID: 2, AccountNumber: "B", InitialBalancePennies: 0, BalancePennies: 250, ExpectedBalancePennies: 250},
+	}}
+	s := newTestReconciliationService(repo)
+
+	_, err := s.LastReconciliation(operatorCtx)
+	assert.ErrorIs(t, err, ErrNotReconciled)
+
+	result, err := s.Reconcile(adminCtx)
+	if assert.NoError(t, err) {
+		assert.True(t, result.OK)
+		assert.True(t, result.Conserved)
+		assert.Equal(t, 2, result.AccountsChecked)
+		assert.Empty(t, result.Discrepancies)
+		assert.Equal(t, int64(1000), result.TotalBalancePennies)
+		assert.Equal(t, "2024-03-01T17:00:00Z", result.CheckedAt)
+	}
+
+	// A balance overwritten by hand: the account drifts and money appears.
+	repo.checks[1].BalancePennies = 300
+	result, err = s.Reconcile(adminCtx)
+	if assert.NoError(t, err) {
+		assert.False(t, result.OK)
+		assert.False(t, result.Conserved)
+		assert.Equal(t, []*models.BalanceDiscrepancy{
+			{AccountNumber: "B", BalancePennies: 300, ExpectedBalancePennies: 250, DifferencePennies: 50},
+		}, result.Discrepancies)
+		assert.Equal(t, int64(1050), result.TotalBalancePennies)
+		assert.Equal(t, int64(1000), result.TotalInitialPennies)
+	}
+
+	last, err := s.LastReconciliation(auditorCtx)
+	assert.NoError(t, err)
+	assert.Same(t, result, last)
+}
+
+func TestReconcile_Errors(t *testing.T) {
+	s := newTestReconciliationService(&mockReconciliationRepository{err: errors.New("boom")})
+
+	_, err := s.Reconcile(operatorCtx)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.LastReconciliation(ownerCtx)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = s.Reconcile(adminCtx)
+	assert.EqualError(t, err, "couldn't read balances")
+	_, err = s.LastReconciliation(adminCtx)
+	assert.ErrorIs(t, err, ErrNotReconciled)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/screening"
+	"strings"
+)
+
+// heldTransactionResumer settles or rejects a held transfer once its
+// screening cases change.
+type heldTransactionResumer interface {
+	ResumeHeldTransaction(ctx context.Context, id int) (*models.Transaction, error)
+}
+
+func NewScreeningService(db *sql.DB, caseRepo repository.ScreeningCaseRepository, resumer heldTransactionResumer, opts ...func(*ScreeningService)) *ScreeningService {
+	s := &ScreeningService{
+		db:       db,
+		caseRepo: caseRepo,
+		resumer:  resumer,
+		policy:   NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithScreeningAuditLog records case resolutions in the audit log.
+func WithScreeningAuditLog(auditLog repository.AuditLogRepository) func(*ScreeningService) {
+	return func(s *ScreeningService) {
+		s.auditLog = auditLog
+	}
+}
+
+// WithScreeningPolicy sets the authorization policy.
+func WithScreeningPolicy(policy Policy) func(*ScreeningService) {
+	return func(s *ScreeningService) {
+		s.policy = policy
+	}
+}
+
+type ScreeningService struct {
+	db         *sql.DB
+	caseRepo   repository.ScreeningCaseRepository
+	resumer    heldTransactionResumer
+	auditLog   repository.AuditLogRepository
+	policy     Policy
+	beginFn    func() (*sql.Tx, error)
+	rollbackFn func(*sql.Tx) error
+	commitFn   func(*sql.Tx) error
+}
+
+func (s *ScreeningService) ListCases(ctx context.Context, status string) ([]*models.ScreeningCase, error) {
+	if err := authorize(ctx, s.policy, ActionReadScreening, Resource{}); err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case "", models.ScreeningCaseOpen, models.ScreeningCaseCleared, models.ScreeningCaseConfirmed:
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	cases, err := s.caseRepo.List(status)
+	if err != nil {
+		return nil, errors.New("couldn't list screening cases")
+	}
+	return cases, nil
+}
+
+func (s *ScreeningService) GetCase(ctx context.Context, id int) (*models.ScreeningCase, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid case_id")
+	}
+
+	if err := authorize(ctx, s.policy, ActionReadScreening, Resource{}); err != nil {
+		return nil, err
+	}
+
+	c, err := s.caseRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("screening case not found")
+	}
+	return c, nil
+}
+
+// ClearCase marks a hit as a false positive. Every other open case for the
+// same account and list entry is cleared with it, and the transfers they were
+// holding are released if nothing else blocks them.
+func (s *ScreeningService) ClearCase(ctx context.Context, id int, note string) (*models.ScreeningCase, error) {
+	return s.resolve(ctx, id, models.ScreeningCaseCleared, note)
+}
+
+// ConfirmCase marks a hit as a true match and rejects the transfer it holds.
+func (s *ScreeningService) ConfirmCase(ctx context.Context, id int, note string) (*models.ScreeningCase, error) {
+	return s.resolve(ctx, id, models.ScreeningCaseConfirmed, note)
+}
+
+func (s *ScreeningService) resolve(ctx context.Context, id int, status, note string) (*models.ScreeningCase, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid case_id")
+	}
+
+	if err := authorize(ctx, s.policy, ActionResolveScreening, Resource{}); err != nil {
+		return nil, err
+	}
+
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, errors.New("note is required")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	c, err := s.caseRepo.SelectTx(tx, id)
+	if err != nil {
+		return nil, errors.New("screening case not found")
+	}
+	if c.Status != models.ScreeningCaseOpen {
+		return nil, errors.New("screening case already resolved")
+	}
+
+	var resolved []*models.ScreeningCase
+	action := audit.ActionScreeningCaseClear
+	if status == models.ScreeningCaseCleared {
+		resolved, err = s.caseRepo.ClearOpenTx(tx, c.AccountID, c.EntryUID, note)
+	} else {
+		action = audit.ActionScreeningCaseConfirm
+		err = s.caseRepo.ResolveTx(tx, c.ID, status, note)
+		confirmed := *c
+		confirmed.Status = status
+		confirmed.Note = note
+		resolved = []*models.ScreeningCase{&confirmed}
+	}
+	if err != nil {
+		return nil, errors.New("failed to resolve screening case")
+	}
+
+	var held []int
+	for _, r := range resolved {
+		if r.TransactionID != nil {
+			held = append(held, *r.TransactionID)
+		}
+		// Only open cases are resolved, and open cases carry no resolution
+		before := *r
+		before.Status, before.Note, before.ResolvedAt = models.ScreeningCaseOpen, "", nil
+		if err := recordAudit(ctx, tx, s.auditLog, action, audit.EntityScreeningCase, r.ID, &before, r); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+
+	for _, transactionID := range held {
+		if _, err := s.resumer.ResumeHeldTransaction(ctx, transactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	c.Status = status
+	c.Note = note
+	return c, nil
+}
+
+func newScreeningCase(account *models.Account, m screening.Match, transactionID *int) *models.ScreeningCase {
+	return &models.ScreeningCase{
+		AccountID:     account.AccountID,
+		TransactionID: transactionID,
+		ScreenedName:  account.HolderName,
+		EntryUID:      m.EntryUID,
+		ListedName:    m.ListedName,
+		Score:         m.Score,
+		Status:        models.ScreeningCaseOpen,
+	}
+}
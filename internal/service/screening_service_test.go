@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockScreeningCaseRepo struct {
+	createFn       func(*models.ScreeningCase) error
+	createTxFn     func(*sql.Tx, *models.ScreeningCase) error
+	getByIDFn      func(int) (*models.ScreeningCase, error)
+	selectTxFn     func(*sql.Tx, int) (*models.ScreeningCase, error)
+	listFn         func(string) ([]*models.ScreeningCase, error)
+	isClearedTxFn  func(*sql.Tx, int, string) (bool, error)
+	resolveTxFn    func(*sql.Tx, int, string, string) error
+	clearOpenTxFn  func(*sql.Tx, int, string, string) ([]*models.ScreeningCase, error)
+	statusCountsFn func(*sql.Tx, int) (map[string]int, error)
+}
+
+func (m *mockScreeningCaseRepo) Create(c *models.ScreeningCase) error {
+	if m.createFn != nil {
+		return m.createFn(c)
+	}
+	return nil
+}
+
+func (m *mockScreeningCaseRepo) CreateTx(tx *sql.Tx, c *models.ScreeningCase) error {
+	if m.createTxFn != nil {
+		return m.createTxFn(tx, c)
+	}
+	return nil
+}
+
+func (m *mockScreeningCaseRepo) GetByID(id int) (*models.ScreeningCase, error) {
+	if m.getByIDFn != nil {
+		return m.getByIDFn(id)
+	}
+	return nil, nil
+}
+
+func (m *mockScreeningCaseRepo) SelectTx(tx *sql.Tx, id int) (*models.ScreeningCase, error) {
+	if m.selectTxFn != nil {
+		return m.selectTxFn(tx, id)
+	}
+	return nil, nil
+}
+
+func (m *mockScreeningCaseRepo) List(status string) ([]*models.ScreeningCase, error) {
+	if m.listFn != nil {
+		return m.listFn(status)
+	}
+	return nil, nil
+}
+
+func (m *mockScreeningCaseRepo) IsClearedTx(tx *sql.Tx, accountID int, entryUID string) (bool, error) {
+	if m.isClearedTxFn != nil {
+		return m.isClearedTxFn(tx, accountID, entryUID)
+	}
+	return false, nil
+}
+
+func (m *mockScreeningCaseRepo) ResolveTx(tx *sql.Tx, id int, status, note string) error {
+	if m.resolveTxFn != nil {
+		return m.resolveTxFn(tx, id, status, note)
+	}
+	return nil
+}
+
+func (m *mockScreeningCaseRepo) ClearOpenTx(tx *sql.Tx, accountID int, entryUID, note string) ([]*models.ScreeningCase, error) {
+	if m.clearOpenTxFn != nil {
+		return m.clearOpenTxFn(tx, accountID, entryUID, note)
+	}
+	return nil, nil
+}
+
+func (m *mockScreeningCaseRepo) StatusCountsTx(tx *sql.Tx, transactionID int) (map[string]int, error) {
+	if m.statusCountsFn != nil {
+		return m.statusCountsFn(tx, transactionID)
+	}
+	return map[string]int{}, nil
+}
+
+type mockResumer struct {
+	resumed []int
+	err     error
+}
+
+func (m *mockResumer) ResumeHeldTransaction(ctx context.Context, id int) (*models.Transaction, error) {
+	m.resumed = append(m.resumed, id)
+	return &models.Transaction{ID: id}, m.err
+}
+
+func newTestScreeningService(repo *mockScreeningCaseRepo, resumer *mockResumer) *ScreeningService {
+	s := NewScreeningService(&sql.DB{}, repo, resumer)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+func TestListCases(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  string
+		repo    *mockScreeningCaseRepo
+		wantErr string
+		wantLen int
+	}{
+		{"invalid_status", "bogus", &mockScreeningCaseRepo{}, "invalid status", 0},
+		{"repo_error", "open", &mockScreeningCaseRepo{listFn: func(string) ([]*models.ScreeningCase, error) { return nil, errors.New("db") }}, "couldn't list screening cases", 0},
+		{"success", "open", &mockScreeningCaseRepo{listFn: func(status string) ([]*models.ScreeningCase, error) {
+			assert.Equal(t, "open", status)
+			return []*models.ScreeningCase{{ID: 1}, {ID: 2}}, nil
+		}}, "", 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newTestScreeningService(tc.repo, &mockResumer{})
+			got, err := svc.ListCases(operatorCtx, tc.status)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, tc.wantLen)
+		})
+	}
+}
+
+func TestGetCase(t *testing.T) {
+	svc := newTestScreeningService(&mockScreeningCaseRepo{getByIDFn: func(id int) (*models.ScreeningCase, error) {
+		if id == 1 {
+			return &models.ScreeningCase{ID: 1}, nil
+		}
+		return nil, errors.New("screening case not found")
+	}}, &mockResumer{})
+
+	_, err := svc.GetCase(operatorCtx, 0)
+	assert.EqualError(t, err, "invalid case_id")
+
+	_, err = svc.GetCase(operatorCtx, 2)
+	assert.EqualError(t, err, "screening case not found")
+
+	c, err := svc.GetCase(operatorCtx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.ID)
+}
+
+func TestResolveCase(t *testing.T) {
+	txID := 77
+	openCase := func() *models.ScreeningCase {
+		return &models.ScreeningCase{ID: 5, AccountID: 123, TransactionID: &txID, EntryUID: "42", Status: models.ScreeningCaseOpen}
+	}
+
+	cases := []struct {
+		name        string
+		confirm     bool
+		id          int
+		note        string
+		repo        *mockScreeningCaseRepo
+		wantErr     string
+		wantStatus  string
+		wantResumed []int
+	}{
+		{name: "invalid_id", id: 0, note: "x", repo: &mockScreeningCaseRepo{}, wantErr: "invalid case_id"},
+		{name: "missing_note", id: 5, note: "  ", repo: &mockScreeningCaseRepo{}, wantErr: "note is required"},
+		{
+			name: "not_found", id: 5, note: "x",
+			repo:    &mockScreeningCaseRepo{selectTxFn: func(*sql.Tx, int) (*models.ScreeningCase, error) { return nil, errors.New("nope") }},
+			wantErr: "screening case not found",
+		},
+		{
+			name: "already_resolved", id: 5, note: "x",
+			repo: &mockScreeningCaseRepo{selectTxFn: func(*sql.Tx, int) (*models.ScreeningCase, error) {
+				c := openCase()
+				c.Status = models.ScreeningCaseCleared
+				return c, nil
+			}},
+			wantErr: "screening case already resolved",
+		},
+		{
+			name: "clear_releases_all_held", id: 5, note: "different date of birth",
+			repo: &mockScreeningCaseRepo{
+				selectTxFn: func(*sql.Tx, int) (*models.ScreeningCase, error) { return openCase(), nil },
+				clearOpenTxFn: func(_ *sql.Tx, accountID int, entryUID, note string) ([]*models.ScreeningCase, error) {
+					assert.Equal(t, 123, accountID)
+					assert.Equal(t, "42", entryUID)
+					assert.Equal(t, "different date of birth", note)
+					first, second := 77, 78
+					return []*models.ScreeningCase{
+						{ID: 5, TransactionID: &first, Status: models.ScreeningCaseCleared},
+						{ID: 6, TransactionID: &second, Status: models.ScreeningCaseCleared},
+						{ID: 7, Status: models.ScreeningCaseCleared},
+					}, nil
+				},
+			},
+			wantStatus:  models.ScreeningCaseCleared,
+			wantResumed: []int{77, 78},
+		},
+		{
+			name: "clear_error", id: 5, note: "x",
+			repo: &mockScreeningCaseRepo{
+				selectTxFn:    func(*sql.Tx, int) (*models.ScreeningCase, error) { return openCase(), nil },
+				clearOpenTxFn: func(*sql.Tx, int, string, string) ([]*models.ScreeningCase, error) { return nil, errors.New("db") },
+			},
+			wantErr: "failed to resolve screening case",
+		},
+		{
+			name: "confirm_rejects_own_transfer", confirm: true, id: 5, note: "true match",
+			repo: &mockScreeningCaseRepo{
+				selectTxFn: func(*sql.Tx, int) (*models.ScreeningCase, error) { return openCase(), nil },
+				resolveTxFn: func(_ *sql.Tx, id int, status, note string) error {
+					assert.Equal(t, 5, id)
+					assert.Equal(t, models.ScreeningCaseConfirmed, status)
+					return nil
+				},
+			},
+			wantStatus:  models.ScreeningCaseConfirmed,
+			wantResumed: []int{77},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resumer := &mockResumer{}
+			svc := newTestScreeningService(tc.repo, resumer)
+			resolve := svc.ClearCase
+			if tc.confirm {
+				resolve = svc.ConfirmCase
+			}
+			got, err := resolve(operatorCtx, tc.id, tc.note)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				assert.Empty(t, resumer.resumed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantStatus, got.Status)
+			assert.Equal(t, tc.wantResumed, resumer.resumed)
+		})
+	}
+}
@@ -1,28 +1,55 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/events"
 	"fastfunds/internal/models"
 	"fastfunds/internal/repository"
+	"fastfunds/internal/screening"
+	"fastfunds/internal/statements"
 	"fastfunds/internal/util"
+	"sort"
+	"strings"
 	"time"
 )
 
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 200
+
+	defaultStatementBankID = "FASTFUNDS"
+)
+
 func NewTransactionService(
 	db *sql.DB,
 	accountRepo repository.AccountRepository,
 	transactionRepo repository.TransactionRepository,
+	opts ...func(*TransactionService),
 ) *TransactionService {
 	s := &TransactionService{
 		db:              db,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
 		money:           util.DefaultMoneyConverter{},
+		numbers:         util.DefaultAccountNumberScheme(),
 	}
 	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.beginReadFn = func(ctx context.Context) (*sql.Tx, error) {
+		return s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
 	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
 	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	s.nowFn = time.Now
+	s.statementBankID = defaultStatementBankID
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.policy == nil {
+		s.policy = NewRolePolicy(nil)
+	}
 	return s
 }
 
@@ -42,107 +69,1095 @@ func NewTransactionServiceWithDeps(
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
 		money:           money,
+		numbers:         util.DefaultAccountNumberScheme(),
 	}
 	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.beginReadFn = func(ctx context.Context) (*sql.Tx, error) {
+		return s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
 	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
 	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	s.nowFn = time.Now
+	s.statementBankID = defaultStatementBankID
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.policy == nil {
+		s.policy = NewRolePolicy(nil)
+	}
 	return s
 }
 
+// WithTransferScreening screens both parties of every transfer. A hit that
+// has not already been cleared for the account holds the transfer.
+func WithTransferScreening(screener screening.Screener, caseRepo repository.ScreeningCaseRepository) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.screener = screener
+		s.caseRepo = caseRepo
+	}
+}
+
+// WithTransferAccountNumberScheme sets the scheme used to validate the
+// account numbers on transfer requests.
+func WithTransferAccountNumberScheme(scheme util.AccountNumberScheme) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.numbers = scheme
+	}
+}
+
+// WithTransferPolicy sets the authorization policy. The default RolePolicy
+// has no account holders, so customers cannot transfer.
+func WithTransferPolicy(policy Policy) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.policy = policy
+	}
+}
+
+// WithTransferAuditLog records every change to a transfer, and the balance
+// changes it causes, in the audit log.
+func WithTransferAuditLog(auditLog repository.AuditLogRepository) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.auditLog = auditLog
+	}
+}
+
+// WithTransferEvents writes a TransferCompleted or TransferFailed event to the
+// outbox when a transfer settles or ends without moving funds.
+func WithTransferEvents(outbox repository.OutboxRepository) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.outbox = outbox
+	}
+}
+
+// WithTransferActivity adds every transfer status change, and the balance
+// changes it causes, to the activity feeds of both accounts.
+func WithTransferActivity(activityRepo repository.ActivityRepository) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.activityRepo = activityRepo
+	}
+}
+
+// WithApprovalThreshold routes transfers above thresholdPennies to a second
+// person for approval. Requests not approved within ttl expire.
+func WithApprovalThreshold(thresholdPennies int64, ttl time.Duration) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.approvalThreshold = thresholdPennies
+		s.approvalTTL = ttl
+	}
+}
+
+// WithTransferFees charges the source of each completed transfer the
+// transfer fee in its terms, as a transfer of its own into feeAccountID.
+// Transfer fees aren't charged without it.
+func WithTransferFees(feeAccountID int) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.feeAccountID = feeAccountID
+	}
+}
+
+// WithStatements sets the bank identifier written on account statements.
+// Statements are in the account's currency.
+func WithStatements(bankID string) func(*TransactionService) {
+	return func(s *TransactionService) {
+		s.statementBankID = bankID
+	}
+}
+
 type TransactionService struct {
-	db              *sql.DB
-	accountRepo     repository.AccountRepository
-	transactionRepo repository.TransactionRepository
-	money           util.MoneyConverter
-	beginFn         func() (*sql.Tx, error)
-	rollbackFn      func(*sql.Tx) error
-	commitFn        func(*sql.Tx) error
+	db                *sql.DB
+	accountRepo       repository.AccountRepository
+	transactionRepo   repository.TransactionRepository
+	money             util.MoneyConverter
+	numbers           util.AccountNumberScheme
+	screener          screening.Screener
+	caseRepo          repository.ScreeningCaseRepository
+	approvalThreshold int64 // pennies; 0 disables maker-checker
+	approvalTTL       time.Duration
+	auditLog          repository.AuditLogRepository
+	outbox            repository.OutboxRepository
+	activityRepo      repository.ActivityRepository
+	policy            Policy
+	statementBankID   string
+	feeAccountID      int
+	beginFn           func() (*sql.Tx, error)
+	beginReadFn       func(ctx context.Context) (*sql.Tx, error)
+	rollbackFn        func(*sql.Tx) error
+	commitFn          func(*sql.Tx) error
+	nowFn             func() time.Time
+}
+
+func (s *TransactionService) ProcessTransaction(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, error) {
+	return s.processTransaction(ctx, req, nil)
+}
+
+// processTransaction creates a transfer as ProcessTransaction does. When
+// onCreate is set it is called in the transfer's DB transaction just before
+// commit, and an error from it rolls the transfer back.
+func (s *TransactionService) processTransaction(ctx context.Context, req *models.TransactionRequest, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error) {
+	amountPennies, needsApproval, err := s.checkRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start DB transaction
+	tx, err := s.beginFn()
+
+	if err != nil {
+		return nil, internalError("couldn't start DB transaction")
+	}
+	if tx == nil {
+		return nil, internalError("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	// Lock both accounts, and the fee account
+	locked, err := s.lockAccounts(tx, s.transferAccounts(req.SourceAccountID, req.DestinationAccountID)...)
+	if err != nil {
+		return nil, err
+	}
+	sourceAccount, ok := locked[req.SourceAccountID]
+	if !ok {
+		return nil, notFound("source account not found")
+	}
+	destAccount, ok := locked[req.DestinationAccountID]
+	if !ok {
+		return nil, notFound("destination account not found")
+	}
+
+	// Check the source account's terms and balance
+	if err := s.checkTerms(sourceAccount, destAccount, amountPennies); err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		SourceAccountID:          req.SourceAccountID,
+		DestinationAccountID:     req.DestinationAccountID,
+		SourceAccountNumber:      sourceAccount.AccountNumber,
+		DestinationAccountNumber: destAccount.AccountNumber,
+		AmountPennies:            amountPennies,
+		Status:                   models.TransactionStatusCompleted,
+		InitiatedBy:              req.InitiatedBy,
+		CreatedAt:                s.nowFn().Format(time.RFC3339),
+	}
+	if needsApproval {
+		expiresAt := s.nowFn().Add(s.approvalTTL).Format(time.RFC3339)
+		transaction.ExpiresAt = &expiresAt
+	}
+
+	// Screen both parties; uncleared hits hold the transfer without moving funds
+	hits, err := s.screenParties(tx, sourceAccount, destAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hits) > 0 {
+		transaction.Status = models.TransactionStatusHeld
+		if err := s.transactionRepo.CreateTx(tx, transaction); err != nil {
+			return nil, internalError("transaction creation failed")
+		}
+		for _, c := range hits {
+			c.TransactionID = &transaction.ID
+			if err := s.caseRepo.CreateTx(tx, c); err != nil {
+				return nil, internalError("failed to record screening case")
+			}
+		}
+		if err := s.recordActivity(tx, transaction, nil, nil); err != nil {
+			return nil, err
+		}
+		if err := s.auditTransfer(ctx, tx, audit.ActionTransactionCreate, nil, transaction, nil, nil); err != nil {
+			return nil, err
+		}
+		for _, c := range hits {
+			if err := recordAudit(ctx, tx, s.auditLog, audit.ActionScreeningCaseOpen, audit.EntityScreeningCase, c.ID, nil, c); err != nil {
+				return nil, err
+			}
+		}
+		if onCreate != nil {
+			if err := onCreate(tx, transaction); err != nil {
+				return nil, err
+			}
+		}
+		if err = s.commitFn(tx); err != nil {
+			return nil, internalError("couldn't commit db transaction")
+		}
+		return transaction, nil
+	}
+
+	// Large transfers wait for a second person; funds move on approval
+	if needsApproval {
+		transaction.Status = models.TransactionStatusPendingApproval
+		if err := s.transactionRepo.CreateTx(tx, transaction); err != nil {
+			return nil, internalError("transaction creation failed")
+		}
+		if err := s.recordActivity(tx, transaction, nil, nil); err != nil {
+			return nil, err
+		}
+		if err := s.auditTransfer(ctx, tx, audit.ActionTransactionCreate, nil, transaction, nil, nil); err != nil {
+			return nil, err
+		}
+		if onCreate != nil {
+			if err := onCreate(tx, transaction); err != nil {
+				return nil, err
+			}
+		}
+		if err = s.commitFn(tx); err != nil {
+			return nil, internalError("couldn't commit db transaction")
+		}
+		return transaction, nil
+	}
+
+	if err := s.postTransfer(ctx, tx, transaction, sourceAccount, destAccount); err != nil {
+		return nil, err
+	}
+	if err := s.chargeTransferFee(ctx, tx, sourceAccount, locked); err != nil {
+		return nil, err
+	}
+	return s.completeTransfer(tx, transaction, onCreate)
+}
+
+// postTransfer moves the funds of a new completed transfer and records it.
+func (s *TransactionService) postTransfer(ctx context.Context, tx *sql.Tx, transaction *models.Transaction, sourceAccount, destAccount *models.Account) error {
+	if err := s.moveFunds(tx, sourceAccount, destAccount, transaction.AmountPennies); err != nil {
+		return err
+	}
+
+	// Create transaction record
+	if err := s.transactionRepo.CreateTx(tx, transaction); err != nil {
+		if errors.Is(err, repository.ErrBusinessDayClosed) {
+			return err
+		}
+		return internalError("transaction creation failed")
+	}
+
+	if err := s.recordTransferEvent(tx, transaction); err != nil {
+		return err
+	}
+
+	if err := s.recordActivity(tx, transaction, sourceAccount, destAccount); err != nil {
+		return err
+	}
+
+	return s.auditTransfer(ctx, tx, audit.ActionTransactionCreate, nil, transaction, sourceAccount, destAccount)
+}
+
+// completeTransfer calls onCreate, if set, and commits tx.
+func (s *TransactionService) completeTransfer(tx *sql.Tx, transaction *models.Transaction, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error) {
+	if onCreate != nil {
+		if err := onCreate(tx, transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.commitFn(tx); err != nil {
+		return nil, internalError("couldn't commit db transaction")
+	}
+
+	return transaction, nil
+}
+
+// settleTransfer makes a transfer the bank is owed, such as a dispute
+// settlement, without the source account's terms: it completes straight
+// away whatever the source's balance, overdraft and limits, and isn't held
+// for screening or approval. A source that can't cover it goes overdrawn
+// and the shortfall is what its holder owes. onCreate is as for
+// processTransaction.
+func (s *TransactionService) settleTransfer(ctx context.Context, req *models.TransactionRequest, onCreate func(*sql.Tx, *models.Transaction) error) (*models.Transaction, error) {
+	amountPennies, _, err := s.checkRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, internalError("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	locked, err := s.lockAccounts(tx, req.SourceAccountID, req.DestinationAccountID)
+	if err != nil {
+		return nil, err
+	}
+	sourceAccount, ok := locked[req.SourceAccountID]
+	if !ok {
+		return nil, notFound("source account not found")
+	}
+	destAccount, ok := locked[req.DestinationAccountID]
+	if !ok {
+		return nil, notFound("destination account not found")
+	}
+	if sourceAccount.Currency != destAccount.Currency {
+		return nil, failedPrecondition("accounts are in different currencies")
+	}
+
+	transaction := &models.Transaction{
+		SourceAccountID:          req.SourceAccountID,
+		DestinationAccountID:     req.DestinationAccountID,
+		SourceAccountNumber:      sourceAccount.AccountNumber,
+		DestinationAccountNumber: destAccount.AccountNumber,
+		AmountPennies:            amountPennies,
+		Status:                   models.TransactionStatusCompleted,
+		InitiatedBy:              req.InitiatedBy,
+		CreatedAt:                s.nowFn().Format(time.RFC3339),
+	}
+	if err := s.postTransfer(ctx, tx, transaction, sourceAccount, destAccount); err != nil {
+		return nil, err
+	}
+	return s.completeTransfer(tx, transaction, onCreate)
+}
+
+// transferAccounts are the accounts a transfer from source to dest locks:
+// both sides, and the fee account when fees are charged.
+func (s *TransactionService) transferAccounts(source, dest int) []int {
+	if s.feeAccountID == 0 {
+		return []int{source, dest}
+	}
+	return []int{source, dest, s.feeAccountID}
 }
 
-func (s *TransactionService) ProcessTransaction(req *models.TransactionRequest) error {
+// transferFee is what a completed transfer out of source is charged.
+func (s *TransactionService) transferFee(source *models.Account) int64 {
+	if s.feeAccountID == 0 || source.AccountID == s.feeAccountID {
+		return 0
+	}
+	return source.Terms.TransferFee
+}
+
+// chargeTransferFee charges source the transfer fee for a transfer that
+// just completed, as a transfer of its own into the fee account, in tx. The
+// fee account must be in locked.
+func (s *TransactionService) chargeTransferFee(ctx context.Context, tx *sql.Tx, source *models.Account, locked map[int]*models.Account) error {
+	fee := s.transferFee(source)
+	if fee == 0 {
+		return nil
+	}
+	feeAccount, ok := locked[s.feeAccountID]
+	if !ok {
+		return internalError("fee account not found")
+	}
+	if feeAccount.Currency != source.Currency {
+		return internalError("the fee account is in a different currency")
+	}
+	return s.postTransfer(ctx, tx, &models.Transaction{
+		SourceAccountID:          source.AccountID,
+		DestinationAccountID:     feeAccount.AccountID,
+		SourceAccountNumber:      source.AccountNumber,
+		DestinationAccountNumber: feeAccount.AccountNumber,
+		AmountPennies:            fee,
+		Status:                   models.TransactionStatusCompleted,
+		InitiatedBy:              audit.SystemActor,
+		CreatedAt:                s.nowFn().Format(time.RFC3339),
+	}, source, feeAccount)
+}
+
+// checkRequest applies the checks ProcessTransaction makes before touching
+// the accounts, and returns the amount and whether it needs approval.
+func (s *TransactionService) checkRequest(ctx context.Context, req *models.TransactionRequest) (int64, bool, error) {
+	if err := s.resolveAccountNumbers(req); err != nil {
+		return 0, false, err
+	}
+
 	// Validate request
 	if req.SourceAccountID <= 0 || req.DestinationAccountID <= 0 {
-		return errors.New("invalid account IDs")
+		return 0, false, errors.New("invalid account IDs")
+	}
+
+	// Only the source is debited, so only the source needs to be the caller's
+	if err := authorize(ctx, s.policy, ActionDebitAccount, Resource{AccountIDs: []int{req.SourceAccountID}}); err != nil {
+		return 0, false, err
 	}
 
 	if req.SourceAccountID == req.DestinationAccountID {
-		return errors.New("source and destination accounts cannot be the same")
+		return 0, false, errors.New("source and destination accounts cannot be the same")
 	}
 
 	if req.Amount == "" {
-		return errors.New("amount is required")
+		return 0, false, errors.New("amount is required")
 	}
 
 	// Validate and convert amount to pennies
 	amountPennies, err := s.money.DecimalStringToPennies(req.Amount)
 	if err != nil || amountPennies <= 0 {
-		return errors.New("invalid amount format")
+		return 0, false, errors.New("invalid amount format")
+	}
+
+	needsApproval := s.requiresApproval(amountPennies)
+	req.InitiatedBy = strings.TrimSpace(req.InitiatedBy)
+	if needsApproval && req.InitiatedBy == "" {
+		return 0, false, errors.New("initiated_by is required for transfers above the approval threshold")
+	}
+	return amountPennies, needsApproval, nil
+}
+
+// validateTransfer checks req against ProcessTransaction's rules without
+// locking or changing anything. It returns the transfer that would be
+// created, completed or pending approval, and its source account. Screening
+// only happens when the transfer is made.
+func (s *TransactionService) validateTransfer(ctx context.Context, req *models.TransactionRequest) (*models.Transaction, *models.Account, error) {
+	amountPennies, needsApproval, err := s.checkRequest(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sourceAccount, err := s.accountRepo.GetByID(req.SourceAccountID)
+	if err != nil || sourceAccount == nil {
+		return nil, nil, notFound("source account not found")
+	}
+	destAccount, err := s.accountRepo.GetByID(req.DestinationAccountID)
+	if err != nil || destAccount == nil {
+		return nil, nil, notFound("destination account not found")
+	}
+	if err := s.checkTerms(sourceAccount, destAccount, amountPennies); err != nil {
+		return nil, nil, err
+	}
+
+	transaction := &models.Transaction{
+		SourceAccountID:          req.SourceAccountID,
+		DestinationAccountID:     req.DestinationAccountID,
+		SourceAccountNumber:      sourceAccount.AccountNumber,
+		DestinationAccountNumber: destAccount.AccountNumber,
+		AmountPennies:            amountPennies,
+		Status:                   models.TransactionStatusCompleted,
+		InitiatedBy:              req.InitiatedBy,
+	}
+	if needsApproval {
+		transaction.Status = models.TransactionStatusPendingApproval
+	}
+	return transaction, sourceAccount, nil
+}
+
+// resolveAccountNumbers validates the check digits of any external account
+// numbers on the request and fills in the matching internal IDs.
+func (s *TransactionService) resolveAccountNumbers(req *models.TransactionRequest) error {
+	if req.SourceAccountNumber != "" {
+		if s.numbers.Validate(req.SourceAccountNumber) != nil {
+			return errors.New("invalid source account number")
+		}
+		account, err := s.accountRepo.GetByNumber(s.numbers.Normalize(req.SourceAccountNumber))
+		if err != nil {
+			return notFound("source account not found")
+		}
+		req.SourceAccountID = account.AccountID
+	}
+
+	if req.DestinationAccountNumber != "" {
+		if s.numbers.Validate(req.DestinationAccountNumber) != nil {
+			return errors.New("invalid destination account number")
+		}
+		account, err := s.accountRepo.GetByNumber(s.numbers.Normalize(req.DestinationAccountNumber))
+		if err != nil {
+			return notFound("destination account not found")
+		}
+		req.DestinationAccountID = account.AccountID
+	}
+
+	return nil
+}
+
+// ResumeHeldTransaction re-evaluates a held transfer after one of its
+// screening cases was resolved. It is rejected if any case was confirmed,
+// settled (or sent for approval) once every case is cleared and otherwise
+// left held.
+func (s *TransactionService) ResumeHeldTransaction(ctx context.Context, id int) (*models.Transaction, error) {
+	if s.caseRepo == nil {
+		return nil, failedPrecondition("screening is not enabled")
 	}
 
-	// Start DB transaction
 	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, internalError("couldn't start DB transaction")
+	}
 
+	defer s.rollbackFn(tx)
+
+	transaction, err := s.transactionRepo.SelectTx(tx, id)
 	if err != nil {
-		return errors.New("couldn't start DB transaction")
+		return nil, notFound("transaction not found")
 	}
-	if tx == nil {
-		return errors.New("couldn't start DB transaction")
+	if transaction.Status != models.TransactionStatusHeld {
+		return transaction, nil
+	}
+	locked, err := s.lockAccounts(tx, s.transferAccounts(transaction.SourceAccountID, transaction.DestinationAccountID)...)
+	if err != nil {
+		return nil, err
 	}
 
+	counts, err := s.caseRepo.StatusCountsTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *transaction
+	var source, dest *models.Account
+	switch {
+	case counts[models.ScreeningCaseConfirmed] > 0:
+		transaction.Status = models.TransactionStatusRejected
+	case counts[models.ScreeningCaseOpen] > 0:
+		return transaction, nil
+	case s.requiresApproval(transaction.AmountPennies):
+		transaction.Status = models.TransactionStatusPendingApproval
+	default:
+		transaction.Status = models.TransactionStatusCompleted
+		sourceAccount, ok := locked[transaction.SourceAccountID]
+		if !ok {
+			return nil, notFound("source account not found")
+		}
+		destAccount, ok := locked[transaction.DestinationAccountID]
+		if !ok {
+			return nil, notFound("destination account not found")
+		}
+		// Funds were not reserved while held, so the balance may have changed
+		if !canDebit(sourceAccount, transaction.AmountPennies+s.transferFee(sourceAccount)) {
+			transaction.Status = models.TransactionStatusFailed
+		} else if err := s.moveFunds(tx, sourceAccount, destAccount, transaction.AmountPennies); err != nil {
+			return nil, err
+		} else {
+			source, dest = sourceAccount, destAccount
+		}
+	}
+
+	if err := s.transactionRepo.UpdateStatusTx(tx, id, transaction.Status); err != nil {
+		if errors.Is(err, repository.ErrBusinessDayClosed) {
+			return nil, err
+		}
+		return nil, internalError("failed to update transaction status")
+	}
+
+	if err := s.recordTransferEvent(tx, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordActivity(tx, transaction, source, dest); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditTransfer(ctx, tx, audit.ActionTransactionResume, &before, transaction, source, dest); err != nil {
+		return nil, err
+	}
+
+	if source != nil {
+		if err := s.chargeTransferFee(ctx, tx, source, locked); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, internalError("couldn't commit db transaction")
+	}
+
+	return transaction, nil
+}
+
+// GetTransaction returns a transfer to anyone allowed to read either side of it.
+func (s *TransactionService) GetTransaction(ctx context.Context, id int) (*models.Transaction, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid transaction_id")
+	}
+
+	transaction, err := s.transactionRepo.GetByID(id)
+	if err != nil {
+		return nil, notFound("transaction not found")
+	}
+
+	res := Resource{AccountIDs: []int{transaction.SourceAccountID, transaction.DestinationAccountID}}
+	if err := authorize(ctx, s.policy, ActionReadTransaction, res); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *TransactionService) ListTransactions(ctx context.Context, status string) ([]*models.Transaction, error) {
+	if err := authorize(ctx, s.policy, ActionListTransactions, Resource{}); err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case models.TransactionStatusCompleted, models.TransactionStatusHeld, models.TransactionStatusPendingApproval,
+		models.TransactionStatusRejected, models.TransactionStatusExpired, models.TransactionStatusFailed:
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	list, err := s.transactionRepo.ListByStatus(status)
+	if err != nil {
+		return nil, internalError("couldn't list transactions")
+	}
+	return list, nil
+}
+
+// ListAccountTransactions returns a page of the transfers into and out of an
+// account, newest first, to anyone allowed to read the account. The next page
+// starts before the lowest ID of this one; a beforeID of 0 starts from the
+// newest.
+func (s *TransactionService) ListAccountTransactions(ctx context.Context, accountID, beforeID, limit int) ([]*models.Transaction, error) {
+	if accountID <= 0 {
+		return nil, errors.New("invalid account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadAccount, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return nil, err
+	}
+
+	if beforeID < 0 {
+		return nil, errors.New("invalid before_id")
+	}
+	if limit <= 0 {
+		limit = defaultHistoryPageSize
+	}
+	if limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+
+	list, err := s.transactionRepo.ListByAccount(accountID, beforeID, limit)
+	if err != nil {
+		return nil, internalError("couldn't list transactions")
+	}
+	return list, nil
+}
+
+// WriteStatement writes the account's statement for [from, to) to w: the
+// balances at from and at to, then each transfer completed in the period
+// with the running balance after it. Everything is read in one
+// repeatable-read transaction, so the lines add up to the closing balance,
+// and transfers are streamed to w as they are read.
+func (s *TransactionService) WriteStatement(ctx context.Context, accountID int, from, to time.Time, w statements.Writer) error {
+	if accountID <= 0 {
+		return errors.New("invalid account_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadAccount, Resource{AccountIDs: []int{accountID}}); err != nil {
+		return err
+	}
+	if !from.Before(to) {
+		return errors.New("from must be before to")
+	}
+
+	account, err := s.accountRepo.GetByID(accountID)
+	if err != nil {
+		return notFound("account not found")
+	}
+
+	tx, err := s.beginReadFn(ctx)
+	if err != nil {
+		return internalError("couldn't start DB transaction")
+	}
 	defer s.rollbackFn(tx)
 
-	// Get source account
-	sourceAccount, err := s.accountRepo.SelectTx(tx, req.SourceAccountID)
+	opening, err := s.transactionRepo.BalanceAtTx(tx, accountID, from)
+	if err != nil {
+		return internalError("couldn't read opening balance")
+	}
+	closing, err := s.transactionRepo.BalanceAtTx(tx, accountID, to)
 	if err != nil {
-		return errors.New("source account not found")
+		return internalError("couldn't read closing balance")
 	}
 
-	// Get destination account
-	destAccount, err := s.accountRepo.SelectTx(tx, req.DestinationAccountID)
+	statement := &models.Statement{
+		AccountNumber:         account.AccountNumber,
+		HolderName:            account.HolderName,
+		Currency:              account.Currency,
+		BankID:                s.statementBankID,
+		From:                  from,
+		To:                    to,
+		GeneratedAt:           s.nowFn(),
+		OpeningBalancePennies: opening,
+		ClosingBalancePennies: closing,
+	}
+	if err := w.Begin(statement); err != nil {
+		return err
+	}
+
+	balance := opening
+	err = s.transactionRepo.EachCompletedTx(tx, accountID, from, to, func(t *models.Transaction) error {
+		line := &models.StatementLine{TransactionID: t.ID, AmountPennies: t.AmountPennies}
+		if t.SourceAccountID == accountID {
+			line.AmountPennies = -t.AmountPennies
+			line.CounterpartyAccountNumber = t.DestinationAccountNumber
+		} else {
+			line.CounterpartyAccountNumber = t.SourceAccountNumber
+		}
+		if t.CompletedAt != nil {
+			line.PostedAt, _ = time.Parse(time.RFC3339Nano, *t.CompletedAt)
+		}
+		balance += line.AmountPennies
+		line.BalancePennies = balance
+		return w.Line(line)
+	})
 	if err != nil {
-		return errors.New("destination account not found")
+		return err
 	}
 
-	// Check source account balance
-	if sourceAccount.CurrentBalance < amountPennies {
-		return errors.New("insufficient funds")
+	if balance != closing {
+		return internalError("statement lines don't add up to the closing balance")
 	}
+	return w.End(statement)
+}
 
-	// Calculate new balances in pennies
-	newSourceBalance := sourceAccount.CurrentBalance - amountPennies
-	newDestBalance := destAccount.CurrentBalance + amountPennies
+// ApproveTransaction executes a pending transfer on behalf of an approver who
+// is not its initiator. If the source can no longer cover the amount the
+// transfer is marked failed instead.
+func (s *TransactionService) ApproveTransaction(ctx context.Context, id int, approver, note string) (*models.Transaction, error) {
+	return s.review(ctx, id, approver, note, true)
+}
+
+// RejectTransaction declines a pending transfer without moving funds.
+func (s *TransactionService) RejectTransaction(ctx context.Context, id int, approver, note string) (*models.Transaction, error) {
+	return s.review(ctx, id, approver, note, false)
+}
+
+func (s *TransactionService) review(ctx context.Context, id int, approver, note string, approve bool) (*models.Transaction, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid transaction_id")
+	}
+
+	if err := authorize(ctx, s.policy, ActionReviewTransaction, Resource{}); err != nil {
+		return nil, err
+	}
+
+	approver = strings.TrimSpace(approver)
+	if approver == "" {
+		return nil, errors.New("approver is required")
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, internalError("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	transaction, err := s.transactionRepo.SelectTx(tx, id)
+	if err != nil {
+		return nil, notFound("transaction not found")
+	}
+	if transaction.Status != models.TransactionStatusPendingApproval {
+		return nil, failedPrecondition("transaction is not pending approval")
+	}
+	if strings.EqualFold(approver, transaction.InitiatedBy) {
+		return nil, errors.New("approver must differ from initiator")
+	}
+	locked, err := s.lockAccounts(tx, s.transferAccounts(transaction.SourceAccountID, transaction.DestinationAccountID)...)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *transaction
+	if s.approvalExpired(transaction) {
+		if err := s.transactionRepo.UpdateStatusTx(tx, id, models.TransactionStatusExpired); err != nil {
+			return nil, internalError("failed to update transaction status")
+		}
+		transaction.Status = models.TransactionStatusExpired
+		if err := s.recordTransferEvent(tx, transaction); err != nil {
+			return nil, err
+		}
+		if err := s.recordActivity(tx, transaction, nil, nil); err != nil {
+			return nil, err
+		}
+		if err := s.auditTransfer(ctx, tx, audit.ActionTransactionExpire, &before, transaction, nil, nil); err != nil {
+			return nil, err
+		}
+		if err = s.commitFn(tx); err != nil {
+			return nil, internalError("couldn't commit db transaction")
+		}
+		return nil, failedPrecondition("approval request expired")
+	}
+
+	action := audit.ActionTransactionReject
+	var source, dest *models.Account
+	transaction.Status = models.TransactionStatusRejected
+	if approve {
+		action = audit.ActionTransactionApprove
+		transaction.Status = models.TransactionStatusCompleted
+		sourceAccount, ok := locked[transaction.SourceAccountID]
+		if !ok {
+			return nil, notFound("source account not found")
+		}
+		destAccount, ok := locked[transaction.DestinationAccountID]
+		if !ok {
+			return nil, notFound("destination account not found")
+		}
+		if !canDebit(sourceAccount, transaction.AmountPennies+s.transferFee(sourceAccount)) {
+			transaction.Status = models.TransactionStatusFailed
+		} else if err := s.moveFunds(tx, sourceAccount, destAccount, transaction.AmountPennies); err != nil {
+			return nil, err
+		} else {
+			source, dest = sourceAccount, destAccount
+		}
+	}
+
+	if err := s.transactionRepo.ReviewTx(tx, id, transaction.Status, approver, note); err != nil {
+		if errors.Is(err, repository.ErrBusinessDayClosed) {
+			return nil, err
+		}
+		return nil, internalError("failed to update transaction status")
+	}
+
+	transaction.ReviewedBy = approver
+	transaction.ReviewNote = note
+	if err := s.recordTransferEvent(tx, transaction); err != nil {
+		return nil, err
+	}
+	if err := s.recordActivity(tx, transaction, source, dest); err != nil {
+		return nil, err
+	}
+	if err := s.auditTransfer(ctx, tx, action, &before, transaction, source, dest); err != nil {
+		return nil, err
+	}
+	if source != nil {
+		if err := s.chargeTransferFee(ctx, tx, source, locked); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, internalError("couldn't commit db transaction")
+	}
+
+	return transaction, nil
+}
+
+// ExpirePendingApprovals expires every pending transfer past its deadline and
+// returns how many were expired.
+func (s *TransactionService) ExpirePendingApprovals(ctx context.Context) (int64, error) {
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return 0, internalError("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	expired, err := s.transactionRepo.ExpirePendingTx(tx, s.nowFn())
+	if err != nil {
+		return 0, err
+	}
+	var accountIDs []int
+	for _, t := range expired {
+		accountIDs = append(accountIDs, t.SourceAccountID, t.DestinationAccountID)
+	}
+	if _, err := s.lockAccounts(tx, accountIDs...); err != nil {
+		return 0, err
+	}
+	for _, t := range expired {
+		if err := s.recordTransferEvent(tx, t); err != nil {
+			return 0, err
+		}
+		if err := s.recordActivity(tx, t, nil, nil); err != nil {
+			return 0, err
+		}
+	}
+	for _, t := range expired {
+		before := *t
+		before.Status = models.TransactionStatusPendingApproval
+		if err := recordAudit(ctx, tx, s.auditLog, audit.ActionTransactionExpire, audit.EntityTransaction, t.ID, &before, t); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return 0, internalError("couldn't commit db transaction")
+	}
+	return int64(len(expired)), nil
+}
 
-	// Update accounts
-	sourceAccount.CurrentBalance = newSourceBalance
-	destAccount.CurrentBalance = newDestBalance
+func (s *TransactionService) requiresApproval(amountPennies int64) bool {
+	return s.approvalThreshold > 0 && amountPennies > s.approvalThreshold
+}
+
+func (s *TransactionService) approvalExpired(t *models.Transaction) bool {
+	if t.ExpiresAt == nil {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, *t.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return !s.nowFn().Before(expiresAt)
+}
+
+// checkTerms applies the source account's product terms to a transfer of
+// amountPennies out of it: both accounts must be in the same currency, the
+// transfer within the account's limits, and the transfer and its fee within
+// its overdraft.
+func (s *TransactionService) checkTerms(source, dest *models.Account, amountPennies int64) error {
+	if source.Currency != dest.Currency {
+		return failedPrecondition("accounts are in different currencies")
+	}
+	if max := source.Terms.MaxTransfer; max != nil && amountPennies > *max {
+		return failedPrecondition("amount exceeds the source account's per-transfer limit")
+	}
+	if limit := source.Terms.DailyTransferLimit; limit != nil {
+		sent, err := s.transactionRepo.SumOutgoingSince(source.AccountID, s.nowFn().UTC().Truncate(24*time.Hour))
+		if err != nil {
+			return internalError("couldn't check transfer limits")
+		}
+		if sent+amountPennies > *limit {
+			return failedPrecondition("amount exceeds the source account's daily transfer limit")
+		}
+	}
+	if !canDebit(source, amountPennies+s.transferFee(source)) {
+		return failedPrecondition("insufficient funds")
+	}
+	return nil
+}
+
+// canDebit reports whether amountPennies can leave the account without
+// going beyond its overdraft.
+func canDebit(a *models.Account, amountPennies int64) bool {
+	return a.Terms.UnlimitedOverdraft || a.CurrentBalance+a.Terms.OverdraftLimit >= amountPennies
+}
+
+func (s *TransactionService) moveFunds(tx *sql.Tx, sourceAccount, destAccount *models.Account, amountPennies int64) error {
+	// Calculate new balances in pennies
+	sourceAccount.CurrentBalance -= amountPennies
+	destAccount.CurrentBalance += amountPennies
 
 	if err := s.accountRepo.UpdateTx(tx, sourceAccount); err != nil {
-		return errors.New("failed to update source account")
+		return internalError("failed to update source account")
 	}
 
 	if err := s.accountRepo.UpdateTx(tx, destAccount); err != nil {
-		return errors.New("failed to update destination account")
+		return internalError("failed to update destination account")
 	}
 
-	// Create transaction record
-	transaction := &models.Transaction{
-		SourceAccountID:      req.SourceAccountID,
-		DestinationAccountID: req.DestinationAccountID,
-		AmountPennies:        amountPennies,
-		Status:               "completed",
-		CreatedAt:            time.Now().Format(time.RFC3339),
+	return nil
+}
+
+// auditTransfer records a change to a transfer. When funds moved, source and
+// dest are the accounts after the move and a debit and a credit entry are
+// recorded with the balances either side of it.
+func (s *TransactionService) auditTransfer(ctx context.Context, tx *sql.Tx, action string, before, after *models.Transaction, source, dest *models.Account) error {
+	if err := recordAudit(ctx, tx, s.auditLog, action, audit.EntityTransaction, after.ID, before, after); err != nil {
+		return err
+	}
+	if source == nil || dest == nil {
+		return nil
 	}
 
-	if err := s.transactionRepo.CreateTx(tx, transaction); err != nil {
-		return errors.New("transaction creation failed")
+	amount := after.AmountPennies
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionAccountDebit, audit.EntityAccount, source.AccountID,
+		balanceState{Balance: source.CurrentBalance + amount},
+		balanceState{Balance: source.CurrentBalance, TransactionID: after.ID}); err != nil {
+		return err
 	}
+	return recordAudit(ctx, tx, s.auditLog, audit.ActionAccountCredit, audit.EntityAccount, dest.AccountID,
+		balanceState{Balance: dest.CurrentBalance - amount},
+		balanceState{Balance: dest.CurrentBalance, TransactionID: after.ID})
+}
 
-	if err = s.commitFn(tx); err != nil {
-		return errors.New("couldn't commit db transaction")
+// recordTransferEvent writes TransferCompleted or TransferFailed once a
+// transfer reaches a final status. Held and pending transfers have none.
+func (s *TransactionService) recordTransferEvent(tx *sql.Tx, t *models.Transaction) error {
+	var eventType string
+	switch t.Status {
+	case models.TransactionStatusCompleted:
+		eventType = models.EventTransferCompleted
+	case models.TransactionStatusFailed, models.TransactionStatusRejected, models.TransactionStatusExpired:
+		eventType = models.EventTransferFailed
+	default:
+		return nil
+	}
+	return recordEvent(tx, s.outbox, eventType, events.AggregateTransaction, t.ID, models.TransferPayload{
+		TransactionID:            t.ID,
+		SourceAccountNumber:      t.SourceAccountNumber,
+		DestinationAccountNumber: t.DestinationAccountNumber,
+		AmountPennies:            t.AmountPennies,
+		Status:                   t.Status,
+	})
+}
+
+// lockAccounts locks the accounts in tx, in ID order so that writers locking
+// several accounts can't deadlock. Activity entries take their IDs when
+// appended, and streams skip any entry below the last ID they sent, so every
+// writer must hold an account's lock while appending to its feed: otherwise
+// an entry can commit after a later one has already been streamed. It
+// returns the locked accounts by ID; accounts that don't exist are left out.
+func (s *TransactionService) lockAccounts(tx *sql.Tx, accountIDs ...int) (map[int]*models.Account, error) {
+	ids := append([]int(nil), accountIDs...)
+	sort.Ints(ids)
+	locked := make(map[int]*models.Account, len(ids))
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
+		}
+		account, err := s.accountRepo.SelectTx(tx, id)
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, internalError("couldn't lock accounts")
+		}
+		if account != nil {
+			locked[id] = account
+		}
 	}
+	return locked, nil
+}
 
+// recordActivity adds the transfer's status to the activity feeds of both
+// accounts. When funds moved, source and dest are the accounts after the move
+// and each feed also gets a balance entry.
+func (s *TransactionService) recordActivity(tx *sql.Tx, t *models.Transaction, source, dest *models.Account) error {
+	if s.activityRepo == nil {
+		return nil
+	}
+
+	sides := []struct {
+		accountID    int
+		amount       int64
+		counterparty string
+		moved        *models.Account
+	}{
+		{t.SourceAccountID, -t.AmountPennies, t.DestinationAccountNumber, source},
+		{t.DestinationAccountID, t.AmountPennies, t.SourceAccountNumber, dest},
+	}
+	for _, side := range sides {
+		entry := models.AccountActivity{
+			AccountID:                 side.accountID,
+			Type:                      models.ActivityTransfer,
+			TransactionID:             t.ID,
+			Status:                    t.Status,
+			AmountPennies:             side.amount,
+			CounterpartyAccountNumber: side.counterparty,
+		}
+		if err := s.activityRepo.AppendTx(tx, &entry); err != nil {
+			return internalError("couldn't record account activity")
+		}
+		if source == nil || dest == nil {
+			continue
+		}
+
+		balance := side.moved.CurrentBalance
+		change := entry
+		change.Type = models.ActivityBalance
+		change.BalancePennies = &balance
+		if err := s.activityRepo.AppendTx(tx, &change); err != nil {
+			return internalError("couldn't record account activity")
+		}
+	}
 	return nil
 }
+
+// balanceState is an account balance as recorded in the audit log.
+type balanceState struct {
+	Balance       int64 `json:"balance"`
+	TransactionID int   `json:"transaction_id,omitempty"`
+}
+
+func (s *TransactionService) screenParties(tx *sql.Tx, accounts ...*models.Account) ([]*models.ScreeningCase, error) {
+	if s.screener == nil {
+		return nil, nil
+	}
+
+	var hits []*models.ScreeningCase
+	for _, account := range accounts {
+		for _, m := range s.screener.Screen(account.HolderName) {
+			cleared, err := s.caseRepo.IsClearedTx(tx, account.AccountID, m.EntryUID)
+			if err != nil {
+				return nil, internalError("couldn't check screening cases")
+			}
+			if !cleared {
+				hits = append(hits, newScreeningCase(account, m, nil))
+			}
+		}
+	}
+	return hits, nil
+}
@@ -1,10 +1,19 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fastfunds/internal/events"
 	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"fastfunds/internal/util"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // SetBeginFn allows tests to override the beginFn for TransactionService
@@ -12,6 +21,11 @@ func (s *TransactionService) SetBeginFn(fn func() (*sql.Tx, error)) {
 	s.beginFn = fn
 }
 
+// SetBeginReadFn allows tests to override the beginReadFn for TransactionService
+func (s *TransactionService) SetBeginReadFn(fn func(context.Context) (*sql.Tx, error)) {
+	s.beginReadFn = fn
+}
+
 // SetRollbackFn allows tests to override the rollbackFn for TransactionService
 func (s *TransactionService) SetRollbackFn(fn func(*sql.Tx) error) {
 	s.rollbackFn = fn
@@ -23,12 +37,19 @@ func (s *TransactionService) SetCommitFn(fn func(*sql.Tx) error) {
 }
 
 type mockAccountRepo struct {
-	SelectTxFunc func(tx *sql.Tx, id int) (*models.Account, error)
-	UpdateTxFunc func(tx *sql.Tx, account *models.Account) error
+	SelectTxFunc    func(tx *sql.Tx, id int) (*models.Account, error)
+	UpdateTxFunc    func(tx *sql.Tx, account *models.Account) error
+	GetByNumberFunc func(number string) (*models.Account, error)
+	GetByIDFunc     func(id int) (*models.Account, error)
 }
 
-func (m *mockAccountRepo) Create(account *models.Account) error    { return nil }
-func (m *mockAccountRepo) GetByID(id int) (*models.Account, error) { return nil, nil }
+func (m *mockAccountRepo) CreateTx(tx *sql.Tx, account *models.Account) error { return nil }
+func (m *mockAccountRepo) GetByID(id int) (*models.Account, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
 func (m *mockAccountRepo) SelectTx(tx *sql.Tx, id int) (*models.Account, error) {
 	if m.SelectTxFunc != nil {
 		return m.SelectTxFunc(tx, id)
@@ -42,9 +63,25 @@ func (m *mockAccountRepo) UpdateTx(tx *sql.Tx, account *models.Account) error {
 	return nil
 }
 func (m *mockAccountRepo) Exists(id int) (bool, error) { return true, nil }
+func (m *mockAccountRepo) GetByNumber(number string) (*models.Account, error) {
+	if m.GetByNumberFunc != nil {
+		return m.GetByNumberFunc(number)
+	}
+	return nil, errors.New("account not found")
+}
 
 type mockTransactionRepo struct {
-	CreateTxFunc func(tx *sql.Tx, transaction *models.Transaction) error
+	CreateTxFunc       func(tx *sql.Tx, transaction *models.Transaction) error
+	GetByIDFunc        func(id int) (*models.Transaction, error)
+	ListByStatusFunc   func(status string) ([]*models.Transaction, error)
+	ListByAccountFunc  func(accountID, beforeID, limit int) ([]*models.Transaction, error)
+	SelectTxFunc       func(tx *sql.Tx, id int) (*models.Transaction, error)
+	UpdateStatusTxFunc func(tx *sql.Tx, id int, status string) error
+	ReviewTxFunc       func(tx *sql.Tx, id int, status, reviewer, note string) error
+	ExpirePendingFunc  func(tx *sql.Tx, now time.Time) ([]*models.Transaction, error)
+	BalanceAtFunc      func(tx *sql.Tx, accountID int, at time.Time) (int64, error)
+	EachCompletedFunc  func(tx *sql.Tx, accountID int, from, to time.Time, fn func(*models.Transaction) error) error
+	SumOutgoingFunc    func(accountID int, since time.Time) (int64, error)
 }
 
 func (m *mockTransactionRepo) CreateTx(tx *sql.Tx, transaction *models.Transaction) error {
@@ -53,10 +90,69 @@ func (m *mockTransactionRepo) CreateTx(tx *sql.Tx, transaction *models.Transacti
 	}
 	return nil
 }
-func (m *mockTransactionRepo) GetByID(id int) (*models.Transaction, error) { return nil, nil }
+func (m *mockTransactionRepo) GetByID(id int) (*models.Transaction, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
 func (m *mockTransactionRepo) GetByAccountID(accountID int) ([]*models.Transaction, error) {
 	return nil, nil
 }
+func (m *mockTransactionRepo) ListByAccount(accountID, beforeID, limit int) ([]*models.Transaction, error) {
+	if m.ListByAccountFunc != nil {
+		return m.ListByAccountFunc(accountID, beforeID, limit)
+	}
+	return nil, nil
+}
+func (m *mockTransactionRepo) ListByStatus(status string) ([]*models.Transaction, error) {
+	if m.ListByStatusFunc != nil {
+		return m.ListByStatusFunc(status)
+	}
+	return nil, nil
+}
+func (m *mockTransactionRepo) ReviewTx(tx *sql.Tx, id int, status, reviewer, note string) error {
+	if m.ReviewTxFunc != nil {
+		return m.ReviewTxFunc(tx, id, status, reviewer, note)
+	}
+	return nil
+}
+func (m *mockTransactionRepo) ExpirePendingTx(tx *sql.Tx, now time.Time) ([]*models.Transaction, error) {
+	if m.ExpirePendingFunc != nil {
+		return m.ExpirePendingFunc(tx, now)
+	}
+	return nil, nil
+}
+func (m *mockTransactionRepo) SelectTx(tx *sql.Tx, id int) (*models.Transaction, error) {
+	if m.SelectTxFunc != nil {
+		return m.SelectTxFunc(tx, id)
+	}
+	return nil, nil
+}
+func (m *mockTransactionRepo) UpdateStatusTx(tx *sql.Tx, id int, status string) error {
+	if m.UpdateStatusTxFunc != nil {
+		return m.UpdateStatusTxFunc(tx, id, status)
+	}
+	return nil
+}
+func (m *mockTransactionRepo) BalanceAtTx(tx *sql.Tx, accountID int, at time.Time) (int64, error) {
+	if m.BalanceAtFunc != nil {
+		return m.BalanceAtFunc(tx, accountID, at)
+	}
+	return 0, nil
+}
+func (m *mockTransactionRepo) EachCompletedTx(tx *sql.Tx, accountID int, from, to time.Time, fn func(*models.Transaction) error) error {
+	if m.EachCompletedFunc != nil {
+		return m.EachCompletedFunc(tx, accountID, from, to, fn)
+	}
+	return nil
+}
+func (m *mockTransactionRepo) SumOutgoingSince(accountID int, since time.Time) (int64, error) {
+	if m.SumOutgoingFunc != nil {
+		return m.SumOutgoingFunc(accountID, since)
+	}
+	return 0, nil
+}
 
 type transactionMockMoneyConverter struct {
 	decFn func(string) (int64, error)
@@ -79,6 +175,7 @@ func (m transactionMockMoneyConverter) PenniesToDecimalString(p int64) string {
 
 func setTxnFns(ts *TransactionService) {
 	ts.SetBeginFn(func() (*sql.Tx, error) { return &sql.Tx{}, nil })
+	ts.SetBeginReadFn(func(context.Context) (*sql.Tx, error) { return &sql.Tx{}, nil })
 	ts.SetRollbackFn(func(tx *sql.Tx) error { return nil })
 	ts.SetCommitFn(func(tx *sql.Tx) error { return nil })
 }
@@ -113,12 +210,331 @@ func TestProcessTransaction_Success(t *testing.T) {
 		Amount:               "2.00",
 	}
 
-	err := ts.ProcessTransaction(req)
+	_, err := ts.ProcessTransaction(operatorCtx, req)
 	if err != nil {
 		t.Errorf("expected success, got error: %v", err)
 	}
 }
 
+func TestProcessTransaction_OnCreate(t *testing.T) {
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			return &models.Account{AccountID: id, CurrentBalance: 1000}, nil
+		},
+	}
+	transactionRepo := &mockTransactionRepo{
+		CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error {
+			transaction.ID = 9
+			return nil
+		},
+	}
+	ts := NewTransactionService(&sql.DB{}, accountRepo, transactionRepo)
+	setTxnFns(ts)
+	committed := false
+	ts.SetCommitFn(func(tx *sql.Tx) error {
+		committed = true
+		return nil
+	})
+
+	var seen *models.Transaction
+	hookErr := errors.New("import row is not pending")
+	req := &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.00"}
+	_, err := ts.processTransaction(operatorCtx, req, func(tx *sql.Tx, t *models.Transaction) error {
+		seen = t
+		return hookErr
+	})
+	if err != hookErr {
+		t.Errorf("expected the hook's error, got %v", err)
+	}
+	if seen == nil || seen.ID != 9 || seen.Status != models.TransactionStatusCompleted {
+		t.Errorf("expected the hook to see the created transfer, got %+v", seen)
+	}
+	if committed {
+		t.Error("expected no commit after the hook failed")
+	}
+}
+
+func TestSettleTransfer_IgnoresSourceTerms(t *testing.T) {
+	maxTransfer, dailyLimit := int64(100), int64(100)
+	balances := map[int]int64{}
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			if id == 1 {
+				return &models.Account{AccountID: 1, HolderName: "Ivan Petrov", CurrentBalance: 50,
+					Terms: models.ProductTerms{MaxTransfer: &maxTransfer, DailyTransferLimit: &dailyLimit}}, nil
+			}
+			return &models.Account{AccountID: 2, CurrentBalance: 0}, nil
+		},
+		UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error {
+			balances[account.AccountID] = account.CurrentBalance
+			return nil
+		},
+	}
+	transactionRepo := &mockTransactionRepo{
+		CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error { return nil },
+		SumOutgoingFunc: func(accountID int, since time.Time) (int64, error) {
+			t.Error("expected no limit check")
+			return 0, nil
+		},
+	}
+	screener := mockScreener{"Ivan Petrov": {{EntryUID: "42", ListedName: "PETROV, Ivan", Score: 0.97}}}
+	money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 600, nil }}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money,
+		WithApprovalThreshold(500, time.Hour), WithTransferScreening(screener, &mockScreeningCaseRepo{}))
+	setTxnFns(ts)
+
+	req := &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "6.00", InitiatedBy: "ops"}
+	if _, err := ts.ProcessTransaction(operatorCtx, req); err == nil {
+		t.Fatal("expected the source's terms to block a normal transfer")
+	}
+	got, err := ts.settleTransfer(operatorCtx, req, nil)
+	if err != nil {
+		t.Fatalf("expected settlement, got error: %v", err)
+	}
+	if got.Status != models.TransactionStatusCompleted {
+		t.Errorf("expected status completed, got %q", got.Status)
+	}
+	if balances[1] != -550 || balances[2] != 600 {
+		t.Errorf("expected the source overdrawn by the shortfall, got %v", balances)
+	}
+}
+
+func TestProcessTransaction_ChargesTransferFee(t *testing.T) {
+	var created []*models.Transaction
+	balances := map[int]int64{}
+	newService := func(sourceBalance int64) *TransactionService {
+		accountRepo := &mockAccountRepo{
+			SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+				a := &models.Account{AccountID: id, AccountNumber: fmt.Sprintf("ACC%d", id), Currency: "GBP"}
+				switch id {
+				case 1:
+					a.CurrentBalance = sourceBalance
+					a.Terms.TransferFee = 20
+				case 9:
+					a.Terms.UnlimitedOverdraft = true
+				}
+				return a, nil
+			},
+			UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error {
+				balances[account.AccountID] = account.CurrentBalance
+				return nil
+			},
+		}
+		transactionRepo := &mockTransactionRepo{
+			CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error {
+				transaction.ID = len(created) + 1
+				created = append(created, transaction)
+				return nil
+			},
+		}
+		money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }}
+		ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money, WithTransferFees(9))
+		setTxnFns(ts)
+		return ts
+	}
+	req := func() *models.TransactionRequest {
+		return &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.00"}
+	}
+
+	if _, err := newService(210).ProcessTransaction(operatorCtx, req()); err == nil || err.Error() != "insufficient funds" {
+		t.Errorf("expected the fee to count against the balance, got %v", err)
+	}
+
+	got, err := newService(1000).ProcessTransaction(operatorCtx, req())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != 1 || len(created) != 2 {
+		t.Fatalf("expected the transfer and its fee, got %+v", created)
+	}
+	if fee := created[1]; fee.SourceAccountID != 1 || fee.DestinationAccountID != 9 || fee.AmountPennies != 20 || fee.InitiatedBy != "system" {
+		t.Errorf("unexpected fee transfer %+v", fee)
+	}
+	if balances[1] != 780 || balances[2] != 200 || balances[9] != 20 {
+		t.Errorf("unexpected balances %v", balances)
+	}
+
+	// The fee account itself pays no fee
+	created = nil
+	if _, err := newService(1000).ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 9, DestinationAccountID: 1, Amount: "2.00"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 1 {
+		t.Errorf("expected no fee, got %+v", created)
+	}
+}
+
+func TestValidateTransfer(t *testing.T) {
+	accounts := map[int]*models.Account{
+		1: {AccountID: 1, AccountNumber: "A", CurrentBalance: 1000},
+		2: {AccountID: 2, AccountNumber: "B"},
+	}
+	accountRepo := &mockAccountRepo{
+		GetByIDFunc: func(id int) (*models.Account, error) {
+			if a, ok := accounts[id]; ok {
+				return a, nil
+			}
+			return nil, errors.New("account not found")
+		},
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			t.Error("validation must not lock accounts")
+			return nil, errors.New("unexpected")
+		},
+	}
+	ts := NewTransactionService(&sql.DB{}, accountRepo, &mockTransactionRepo{}, WithApprovalThreshold(500, time.Hour))
+	ts.SetBeginFn(func() (*sql.Tx, error) {
+		t.Error("validation must not start a DB transaction")
+		return nil, errors.New("unexpected")
+	})
+
+	cases := []struct {
+		name       string
+		req        models.TransactionRequest
+		wantErr    string
+		wantKind   error // nil: a plain error, answered as a bad request
+		wantStatus string
+	}{
+		{"completes", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.00"}, "", nil, models.TransactionStatusCompleted},
+		{"needs approval", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "6.00", InitiatedBy: "ops"}, "", nil, models.TransactionStatusPendingApproval},
+		{"insufficient funds", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "10.01", InitiatedBy: "ops"}, "insufficient funds", ErrFailedPrecondition, ""},
+		{"unknown destination", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 3, Amount: "2.00"}, "destination account not found", ErrNotFound, ""},
+		{"same account", models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 1, Amount: "2.00"}, "source and destination accounts cannot be the same", nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			transaction, source, err := ts.validateTransfer(operatorCtx, &req)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("expected error %q, got %v", tc.wantErr, err)
+				}
+				for _, kind := range []error{ErrNotFound, ErrFailedPrecondition, ErrInternal} {
+					if errors.Is(err, kind) != (kind == tc.wantKind) {
+						t.Errorf("expected error kind %v, got %v", tc.wantKind, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected success, got error: %v", err)
+			}
+			if transaction.Status != tc.wantStatus || transaction.DestinationAccountNumber != "B" || source.AccountID != 1 {
+				t.Errorf("unexpected result %+v from %+v", transaction, source)
+			}
+		})
+	}
+}
+
+func TestValidateTransfer_ProductTerms(t *testing.T) {
+	maxTransfer, dailyLimit := int64(5000), int64(8000)
+	accounts := map[int]*models.Account{
+		1: {AccountID: 1, AccountNumber: "A", CurrentBalance: 1000, Currency: "GBP",
+			Terms: models.ProductTerms{OverdraftLimit: 2000}},
+		2: {AccountID: 2, AccountNumber: "B", Currency: "GBP"},
+		3: {AccountID: 3, AccountNumber: "C", Currency: "EUR"},
+		4: {AccountID: 4, AccountNumber: "D", CurrentBalance: 100000, Currency: "GBP",
+			Terms: models.ProductTerms{MaxTransfer: &maxTransfer, DailyTransferLimit: &dailyLimit}},
+		5: {AccountID: 5, AccountNumber: "E", CurrentBalance: -900000, Currency: "GBP",
+			Terms: models.ProductTerms{UnlimitedOverdraft: true}},
+	}
+	accountRepo := &mockAccountRepo{GetByIDFunc: func(id int) (*models.Account, error) {
+		return accounts[id], nil
+	}}
+	now := time.Date(2024, 3, 1, 15, 30, 0, 0, time.UTC)
+	transactionRepo := &mockTransactionRepo{SumOutgoingFunc: func(accountID int, since time.Time) (int64, error) {
+		if accountID != 4 || !since.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected daily total query for %d since %s", accountID, since)
+		}
+		return 4000, nil
+	}}
+	ts := NewTransactionService(&sql.DB{}, accountRepo, transactionRepo)
+	ts.nowFn = func() time.Time { return now }
+
+	cases := []struct {
+		name    string
+		source  int
+		dest    int
+		amount  string
+		wantErr string
+	}{
+		{"within overdraft", 1, 2, "30.00", ""},
+		{"beyond overdraft", 1, 2, "30.01", "insufficient funds"},
+		{"different currencies", 1, 3, "1.00", "accounts are in different currencies"},
+		{"per-transfer limit", 4, 2, "50.01", "amount exceeds the source account's per-transfer limit"},
+		{"within daily limit", 4, 2, "40.00", ""},
+		{"daily limit", 4, 2, "40.01", "amount exceeds the source account's daily transfer limit"},
+		{"unlimited overdraft", 5, 2, "1000.00", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := models.TransactionRequest{SourceAccountID: tc.source, DestinationAccountID: tc.dest, Amount: tc.amount}
+			_, _, err := ts.validateTransfer(operatorCtx, &req)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("expected success, got error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProcessTransaction_ResolvesAccountNumbers(t *testing.T) {
+	scheme := util.DefaultAccountNumberScheme()
+	source, _ := scheme.Generate()
+	dest, _ := scheme.Generate()
+	byNumber := map[string]*models.Account{
+		source: {AccountID: 1, AccountNumber: source, CurrentBalance: 1000},
+		dest:   {AccountID: 2, AccountNumber: dest, CurrentBalance: 500},
+	}
+	accountRepo := &mockAccountRepo{
+		GetByNumberFunc: func(number string) (*models.Account, error) {
+			if a, ok := byNumber[number]; ok {
+				return a, nil
+			}
+			return nil, errors.New("account not found")
+		},
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			for _, a := range byNumber {
+				if a.AccountID == id {
+					return a, nil
+				}
+			}
+			return nil, errors.New("account not found")
+		},
+	}
+	money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, &mockTransactionRepo{}, money)
+	setTxnFns(ts)
+
+	got, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{
+		SourceAccountNumber:      strings.ToLower(source),
+		DestinationAccountNumber: dest,
+		Amount:                   "2.00",
+	})
+	if err != nil {
+		t.Fatalf("expected success, got error: %v", err)
+	}
+	if got.SourceAccountID != 1 || got.DestinationAccountNumber != dest {
+		t.Errorf("expected accounts resolved from numbers, got %+v", got)
+	}
+
+	unknown, _ := scheme.Generate()
+	cases := []struct {
+		source, dest, wantErr string
+	}{
+		{"FF00FAST0000000000", dest, "invalid source account number"},
+		{source, "nope", "invalid destination account number"},
+		{source, unknown, "destination account not found"},
+	}
+	for _, tc := range cases {
+		_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountNumber: tc.source, DestinationAccountNumber: tc.dest, Amount: "2.00"})
+		if err == nil || err.Error() != tc.wantErr {
+			t.Errorf("expected %q, got: %v", tc.wantErr, err)
+		}
+	}
+}
+
 func TestProcessTransaction_InvalidAmount(t *testing.T) {
 	money := &transactionMockMoneyConverter{
 		decFn: func(s string) (int64, error) { return 0, errors.New("bad format") },
@@ -132,7 +548,7 @@ func TestProcessTransaction_InvalidAmount(t *testing.T) {
 		Amount:               "bad",
 	}
 
-	err := ts.ProcessTransaction(req)
+	_, err := ts.ProcessTransaction(operatorCtx, req)
 	if err == nil || err.Error() != "invalid amount format" {
 		t.Errorf("expected invalid amount format error, got: %v", err)
 	}
@@ -163,7 +579,7 @@ func TestProcessTransaction_InsufficientFunds(t *testing.T) {
 		Amount:               "2.00",
 	}
 
-	err := ts.ProcessTransaction(req)
+	_, err := ts.ProcessTransaction(operatorCtx, req)
 	if err == nil || err.Error() != "insufficient funds" {
 		t.Errorf("expected insufficient funds error, got: %v", err)
 	}
@@ -179,7 +595,7 @@ func TestProcessTransaction_SameAccount(t *testing.T) {
 		Amount:               "2.00",
 	}
 
-	err := ts.ProcessTransaction(req)
+	_, err := ts.ProcessTransaction(operatorCtx, req)
 	if err == nil || err.Error() != "source and destination accounts cannot be the same" {
 		t.Errorf("expected same account error, got: %v", err)
 	}
@@ -189,7 +605,7 @@ func TestProcessTransaction_SourceAccountNotFound(t *testing.T) {
 	accountRepo := &mockAccountRepo{
 		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
 			if id == 1 {
-				return nil, errors.New("not found")
+				return nil, repository.ErrAccountNotFound
 			}
 			return &models.Account{AccountID: 2, CurrentBalance: 500}, nil
 		},
@@ -204,7 +620,7 @@ func TestProcessTransaction_SourceAccountNotFound(t *testing.T) {
 		Amount:               "2.00",
 	}
 
-	err := ts.ProcessTransaction(req)
+	_, err := ts.ProcessTransaction(operatorCtx, req)
 	if err == nil || err.Error() != "source account not found" {
 		t.Errorf("expected source account not found error, got: %v", err)
 	}
@@ -216,7 +632,7 @@ func TestProcessTransaction_DestinationAccountNotFound(t *testing.T) {
 			if id == 1 {
 				return &models.Account{AccountID: 1, CurrentBalance: 1000}, nil
 			}
-			return nil, errors.New("not found")
+			return nil, repository.ErrAccountNotFound
 		},
 		UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error { return nil },
 	}
@@ -229,8 +645,765 @@ func TestProcessTransaction_DestinationAccountNotFound(t *testing.T) {
 		Amount:               "2.00",
 	}
 
-	err := ts.ProcessTransaction(req)
+	_, err := ts.ProcessTransaction(operatorCtx, req)
 	if err == nil || err.Error() != "destination account not found" {
 		t.Errorf("expected destination account not found error, got: %v", err)
 	}
 }
+
+func TestProcessTransaction_LocksAccountsInIDOrder(t *testing.T) {
+	var order []int
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			order = append(order, id)
+			if id == 1 {
+				return nil, errors.New("deadlock detected")
+			}
+			return &models.Account{AccountID: id, CurrentBalance: 1000}, nil
+		},
+	}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, &mockTransactionRepo{}, &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }})
+	setTxnFns(ts)
+
+	_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 2, DestinationAccountID: 1, Amount: "2.00"})
+	if err == nil || err.Error() != "couldn't lock accounts" {
+		t.Errorf("expected lock error, got: %v", err)
+	}
+	if len(order) != 1 || order[0] != 1 {
+		t.Errorf("expected the lower account ID to be locked first, got %v", order)
+	}
+}
+
+func TestProcessTransaction_ScreeningHitHoldsTransfer(t *testing.T) {
+	var updated int
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			if id == 1 {
+				return &models.Account{AccountID: 1, HolderName: "Jane Doe", CurrentBalance: 1000}, nil
+			}
+			return &models.Account{AccountID: 2, HolderName: "Ivan Petrov", CurrentBalance: 500}, nil
+		},
+		UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error { updated++; return nil },
+	}
+	transactionRepo := &mockTransactionRepo{
+		CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error {
+			transaction.ID = 99
+			return nil
+		},
+	}
+	var created []*models.ScreeningCase
+	caseRepo := &mockScreeningCaseRepo{
+		createTxFn: func(tx *sql.Tx, c *models.ScreeningCase) error {
+			created = append(created, c)
+			return nil
+		},
+	}
+	screener := mockScreener{"Ivan Petrov": {{EntryUID: "42", ListedName: "PETROV, Ivan", Score: 0.97}}}
+	money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }}
+
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money, WithTransferScreening(screener, caseRepo))
+	setTxnFns(ts)
+
+	got, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.00"})
+	if err != nil {
+		t.Fatalf("expected held transfer, got error: %v", err)
+	}
+	if got.Status != models.TransactionStatusHeld {
+		t.Errorf("expected status held, got %q", got.Status)
+	}
+	if updated != 0 {
+		t.Errorf("expected no balance updates, got %d", updated)
+	}
+	if len(created) != 1 || created[0].AccountID != 2 || created[0].TransactionID == nil || *created[0].TransactionID != 99 {
+		t.Errorf("expected one case on account 2 linked to transaction 99, got %+v", created)
+	}
+}
+
+func TestProcessTransaction_ClearedHitDoesNotHold(t *testing.T) {
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			return &models.Account{AccountID: id, HolderName: "Ivan Petrov", CurrentBalance: 1000}, nil
+		},
+	}
+	caseRepo := &mockScreeningCaseRepo{
+		isClearedTxFn: func(tx *sql.Tx, accountID int, entryUID string) (bool, error) { return true, nil },
+		createTxFn: func(tx *sql.Tx, c *models.ScreeningCase) error {
+			t.Error("unexpected screening case")
+			return nil
+		},
+	}
+	screener := mockScreener{"Ivan Petrov": {{EntryUID: "42", Score: 0.97}}}
+	money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }}
+
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, &mockTransactionRepo{}, money, WithTransferScreening(screener, caseRepo))
+	setTxnFns(ts)
+
+	got, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.00"})
+	if err != nil || got.Status != models.TransactionStatusCompleted {
+		t.Errorf("expected completed transfer, got %+v, %v", got, err)
+	}
+}
+
+func TestResumeHeldTransaction(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     string
+		counts     map[string]int
+		srcBalance int64
+		wantStatus string
+		wantMoved  bool
+	}{
+		{"not_held_is_noop", models.TransactionStatusCompleted, nil, 1000, models.TransactionStatusCompleted, false},
+		{"still_open", models.TransactionStatusHeld, map[string]int{models.ScreeningCaseOpen: 1, models.ScreeningCaseCleared: 1}, 1000, models.TransactionStatusHeld, false},
+		{"confirmed_rejects", models.TransactionStatusHeld, map[string]int{models.ScreeningCaseConfirmed: 1}, 1000, models.TransactionStatusRejected, false},
+		{"all_cleared_settles", models.TransactionStatusHeld, map[string]int{models.ScreeningCaseCleared: 2}, 1000, models.TransactionStatusCompleted, true},
+		{"all_cleared_insufficient_funds", models.TransactionStatusHeld, map[string]int{models.ScreeningCaseCleared: 1}, 100, models.TransactionStatusFailed, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			balances := map[int]int64{}
+			accountRepo := &mockAccountRepo{
+				SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+					if id == 1 {
+						return &models.Account{AccountID: 1, CurrentBalance: tc.srcBalance}, nil
+					}
+					return &models.Account{AccountID: 2, CurrentBalance: 500}, nil
+				},
+				UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error {
+					balances[account.AccountID] = account.CurrentBalance
+					return nil
+				},
+			}
+			var storedStatus string
+			transactionRepo := &mockTransactionRepo{
+				SelectTxFunc: func(tx *sql.Tx, id int) (*models.Transaction, error) {
+					return &models.Transaction{ID: id, SourceAccountID: 1, DestinationAccountID: 2, AmountPennies: 200, Status: tc.status}, nil
+				},
+				UpdateStatusTxFunc: func(tx *sql.Tx, id int, status string) error {
+					storedStatus = status
+					return nil
+				},
+			}
+			caseRepo := &mockScreeningCaseRepo{
+				statusCountsFn: func(tx *sql.Tx, id int) (map[string]int, error) { return tc.counts, nil },
+			}
+
+			ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, nil, WithTransferScreening(mockScreener{}, caseRepo))
+			setTxnFns(ts)
+
+			got, err := ts.ResumeHeldTransaction(context.Background(), 5)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Status != tc.wantStatus {
+				t.Errorf("expected status %q, got %q", tc.wantStatus, got.Status)
+			}
+			if tc.wantStatus != tc.status && storedStatus != tc.wantStatus {
+				t.Errorf("expected stored status %q, got %q", tc.wantStatus, storedStatus)
+			}
+			if tc.wantMoved && (balances[1] != tc.srcBalance-200 || balances[2] != 700) {
+				t.Errorf("unexpected balances %v", balances)
+			}
+			if !tc.wantMoved && len(balances) != 0 {
+				t.Errorf("expected no balance updates, got %v", balances)
+			}
+		})
+	}
+}
+
+func TestProcessTransaction_AboveThresholdNeedsApproval(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			return &models.Account{AccountID: id, CurrentBalance: 1000000}, nil
+		},
+		UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error {
+			t.Error("funds must not move before approval")
+			return nil
+		},
+	}
+	var stored *models.Transaction
+	transactionRepo := &mockTransactionRepo{
+		CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error {
+			stored = transaction
+			return nil
+		},
+	}
+	money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 500000, nil }}
+
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money, WithApprovalThreshold(100000, time.Hour))
+	setTxnFns(ts)
+	ts.nowFn = func() time.Time { return now }
+
+	_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "5000.00"})
+	if err == nil || err.Error() != "initiated_by is required for transfers above the approval threshold" {
+		t.Errorf("expected missing initiator error, got: %v", err)
+	}
+
+	got, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "5000.00", InitiatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != models.TransactionStatusPendingApproval || stored != got {
+		t.Errorf("expected stored pending_approval transfer, got %+v", got)
+	}
+	if got.ExpiresAt == nil || *got.ExpiresAt != "2026-01-02T11:00:00Z" {
+		t.Errorf("expected expiry one hour out, got %v", got.ExpiresAt)
+	}
+	if got.InitiatedBy != "alice" {
+		t.Errorf("expected initiator alice, got %q", got.InitiatedBy)
+	}
+}
+
+func TestReviewTransaction(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	future := "2026-01-02T11:00:00Z"
+	past := "2026-01-02T09:00:00Z"
+
+	cases := []struct {
+		name        string
+		reject      bool
+		id          int
+		approver    string
+		stored      *models.Transaction
+		srcBalance  int64
+		wantErr     string
+		wantStatus  string
+		wantStored  string
+		wantMovedTo int64
+	}{
+		{name: "invalid_id", id: 0, approver: "bob", wantErr: "invalid transaction_id"},
+		{name: "missing_approver", id: 1, approver: " ", wantErr: "approver is required"},
+		{name: "not_pending", id: 1, approver: "bob", stored: &models.Transaction{Status: models.TransactionStatusCompleted}, wantErr: "transaction is not pending approval"},
+		{name: "same_person", id: 1, approver: "Alice", stored: &models.Transaction{Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice", ExpiresAt: &future}, wantErr: "approver must differ from initiator"},
+		{name: "expired", id: 1, approver: "bob", stored: &models.Transaction{Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice", ExpiresAt: &past}, wantErr: "approval request expired", wantStored: models.TransactionStatusExpired},
+		{name: "approve_moves_funds", id: 1, approver: "bob", srcBalance: 1000, stored: &models.Transaction{Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice", ExpiresAt: &future}, wantStatus: models.TransactionStatusCompleted, wantStored: models.TransactionStatusCompleted, wantMovedTo: 700},
+		{name: "approve_insufficient_funds", id: 1, approver: "bob", srcBalance: 100, stored: &models.Transaction{Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice", ExpiresAt: &future}, wantStatus: models.TransactionStatusFailed, wantStored: models.TransactionStatusFailed},
+		{name: "reject", reject: true, id: 1, approver: "bob", srcBalance: 1000, stored: &models.Transaction{Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice", ExpiresAt: &future}, wantStatus: models.TransactionStatusRejected, wantStored: models.TransactionStatusRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			balances := map[int]int64{}
+			accountRepo := &mockAccountRepo{
+				SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+					if id == 1 {
+						return &models.Account{AccountID: 1, CurrentBalance: tc.srcBalance}, nil
+					}
+					return &models.Account{AccountID: 2, CurrentBalance: 500}, nil
+				},
+				UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error {
+					balances[account.AccountID] = account.CurrentBalance
+					return nil
+				},
+			}
+			var storedStatus string
+			transactionRepo := &mockTransactionRepo{
+				SelectTxFunc: func(tx *sql.Tx, id int) (*models.Transaction, error) {
+					t := *tc.stored
+					t.ID, t.SourceAccountID, t.DestinationAccountID, t.AmountPennies = id, 1, 2, 200
+					return &t, nil
+				},
+				UpdateStatusTxFunc: func(tx *sql.Tx, id int, status string) error {
+					storedStatus = status
+					return nil
+				},
+				ReviewTxFunc: func(tx *sql.Tx, id int, status, reviewer, note string) error {
+					storedStatus = status
+					if reviewer != tc.approver {
+						t.Errorf("expected reviewer %q, got %q", tc.approver, reviewer)
+					}
+					return nil
+				},
+			}
+
+			ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, nil, WithApprovalThreshold(100, time.Hour))
+			setTxnFns(ts)
+			ts.nowFn = func() time.Time { return now }
+
+			review := ts.ApproveTransaction
+			if tc.reject {
+				review = ts.RejectTransaction
+			}
+			got, err := review(operatorCtx, tc.id, tc.approver, "ok")
+			if storedStatus != tc.wantStored {
+				t.Errorf("expected stored status %q, got %q", tc.wantStored, storedStatus)
+			}
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Status != tc.wantStatus {
+				t.Errorf("expected status %q, got %q", tc.wantStatus, got.Status)
+			}
+			if balances[2] != tc.wantMovedTo {
+				t.Errorf("expected destination balance %d, got %d", tc.wantMovedTo, balances[2])
+			}
+		})
+	}
+}
+
+func TestResumeHeldTransaction_AboveThresholdGoesToApproval(t *testing.T) {
+	transactionRepo := &mockTransactionRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, SourceAccountID: 1, DestinationAccountID: 2, AmountPennies: 500, Status: models.TransactionStatusHeld}, nil
+		},
+	}
+	accountRepo := &mockAccountRepo{
+		UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error {
+			t.Error("funds must not move before approval")
+			return nil
+		},
+	}
+	caseRepo := &mockScreeningCaseRepo{
+		statusCountsFn: func(tx *sql.Tx, id int) (map[string]int, error) {
+			return map[string]int{models.ScreeningCaseCleared: 1}, nil
+		},
+	}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, nil,
+		WithTransferScreening(mockScreener{}, caseRepo), WithApprovalThreshold(100, time.Hour))
+	setTxnFns(ts)
+
+	got, err := ts.ResumeHeldTransaction(context.Background(), 3)
+	if err != nil || got.Status != models.TransactionStatusPendingApproval {
+		t.Errorf("expected pending_approval, got %+v, %v", got, err)
+	}
+}
+
+func TestExpirePendingApprovals(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	transactionRepo := &mockTransactionRepo{
+		ExpirePendingFunc: func(tx *sql.Tx, at time.Time) ([]*models.Transaction, error) {
+			if !at.Equal(now) {
+				t.Errorf("expected cutoff %v, got %v", now, at)
+			}
+			return []*models.Transaction{{ID: 4}, {ID: 5}, {ID: 6}}, nil
+		},
+	}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, &mockAccountRepo{}, transactionRepo, nil)
+	setTxnFns(ts)
+	ts.nowFn = func() time.Time { return now }
+
+	n, err := ts.ExpirePendingApprovals(context.Background())
+	if err != nil || n != 3 {
+		t.Errorf("expected 3 expired, got %d, %v", n, err)
+	}
+}
+
+func TestGetAndListTransactions(t *testing.T) {
+	transactionRepo := &mockTransactionRepo{
+		GetByIDFunc: func(id int) (*models.Transaction, error) {
+			if id == 1 {
+				return &models.Transaction{ID: 1}, nil
+			}
+			return nil, errors.New("transaction not found")
+		},
+		ListByStatusFunc: func(status string) ([]*models.Transaction, error) {
+			return []*models.Transaction{{ID: 1, Status: status}}, nil
+		},
+	}
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, &mockAccountRepo{}, transactionRepo, nil)
+
+	if _, err := ts.GetTransaction(operatorCtx, 0); err == nil || err.Error() != "invalid transaction_id" {
+		t.Errorf("expected invalid id error, got %v", err)
+	}
+	if _, err := ts.GetTransaction(operatorCtx, 2); err == nil || err.Error() != "transaction not found" {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if got, err := ts.GetTransaction(operatorCtx, 1); err != nil || got.ID != 1 {
+		t.Errorf("expected transaction 1, got %+v, %v", got, err)
+	}
+	if _, err := ts.ListTransactions(operatorCtx, "bogus"); err == nil || err.Error() != "invalid status" {
+		t.Errorf("expected invalid status error, got %v", err)
+	}
+	if list, err := ts.ListTransactions(operatorCtx, models.TransactionStatusPendingApproval); err != nil || len(list) != 1 {
+		t.Errorf("expected one pending transfer, got %v, %v", list, err)
+	}
+}
+
+func TestProcessTransaction_RecordsActivity(t *testing.T) {
+	accountRepo := &mockAccountRepo{
+		SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+			return &models.Account{AccountID: id, AccountNumber: fmt.Sprintf("ACC%d", id), CurrentBalance: 1000}, nil
+		},
+		UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error { return nil },
+	}
+	transactionRepo := &mockTransactionRepo{
+		CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error {
+			transaction.ID = 9
+			return nil
+		},
+	}
+	money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }}
+	activity := &mockActivityRepository{}
+
+	ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money,
+		WithTransferActivity(activity), WithApprovalThreshold(500, time.Hour))
+	setTxnFns(ts)
+
+	_, err := ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "2.00"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	balance := func(p int64) *int64 { return &p }
+	want := []*models.AccountActivity{
+		{ID: 1, AccountID: 1, Type: models.ActivityTransfer, TransactionID: 9, Status: models.TransactionStatusCompleted, AmountPennies: -200, CounterpartyAccountNumber: "ACC2"},
+		{ID: 2, AccountID: 1, Type: models.ActivityBalance, TransactionID: 9, Status: models.TransactionStatusCompleted, AmountPennies: -200, CounterpartyAccountNumber: "ACC2", BalancePennies: balance(800)},
+		{ID: 3, AccountID: 2, Type: models.ActivityTransfer, TransactionID: 9, Status: models.TransactionStatusCompleted, AmountPennies: 200, CounterpartyAccountNumber: "ACC1"},
+		{ID: 4, AccountID: 2, Type: models.ActivityBalance, TransactionID: 9, Status: models.TransactionStatusCompleted, AmountPennies: 200, CounterpartyAccountNumber: "ACC1", BalancePennies: balance(1200)},
+	}
+	if !reflect.DeepEqual(activity.entries, want) {
+		t.Errorf("unexpected activity for a completed transfer: %+v", activity.entries)
+	}
+
+	// Above the threshold nothing moves, so there are no balance entries
+	activity.entries = nil
+	money.decFn = func(s string) (int64, error) { return 600, nil }
+	_, err = ts.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "6.00", InitiatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(activity.entries) != 2 {
+		t.Fatalf("expected a transfer entry per account, got %+v", activity.entries)
+	}
+	for _, a := range activity.entries {
+		if a.Type != models.ActivityTransfer || a.Status != models.TransactionStatusPendingApproval {
+			t.Errorf("expected pending transfer entry, got %+v", a)
+		}
+	}
+}
+
+// lockingStore simulates what activity ordering depends on in Postgres:
+// account rows locked until the transaction ends, and activity entries that
+// take their IDs when appended but are only visible once committed.
+// blocked receives the ID of each account a transaction has to wait for.
+type lockingStore struct {
+	activity *mockActivityRepository
+	mu       sync.Mutex
+	rows     map[int]*sync.Mutex
+	held     map[*sql.Tx][]int
+	pending  map[*sql.Tx][]*models.AccountActivity
+	nextID   int64
+	blocked  chan int
+}
+
+func newLockingStore() *lockingStore {
+	return &lockingStore{
+		activity: &mockActivityRepository{},
+		rows:     map[int]*sync.Mutex{},
+		held:     map[*sql.Tx][]int{},
+		pending:  map[*sql.Tx][]*models.AccountActivity{},
+		blocked:  make(chan int, 1),
+	}
+}
+
+func (l *lockingStore) lock(tx *sql.Tx, accountID int) {
+	l.mu.Lock()
+	for _, id := range l.held[tx] {
+		if id == accountID {
+			l.mu.Unlock()
+			return
+		}
+	}
+	row, ok := l.rows[accountID]
+	if !ok {
+		row = &sync.Mutex{}
+		l.rows[accountID] = row
+	}
+	l.mu.Unlock()
+
+	if !row.TryLock() {
+		select {
+		case l.blocked <- accountID:
+		default:
+		}
+		row.Lock()
+	}
+	l.mu.Lock()
+	l.held[tx] = append(l.held[tx], accountID)
+	l.mu.Unlock()
+}
+
+// end commits or rolls back tx, releasing its locks. A rollback after a
+// commit does nothing.
+func (l *lockingStore) end(tx *sql.Tx, commit bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if commit {
+		l.activity.mu.Lock()
+		l.activity.entries = append(l.activity.entries, l.pending[tx]...)
+		l.activity.mu.Unlock()
+	}
+	for _, id := range l.held[tx] {
+		l.rows[id].Unlock()
+	}
+	delete(l.held, tx)
+	delete(l.pending, tx)
+}
+
+func (l *lockingStore) AppendTx(tx *sql.Tx, a *models.AccountActivity) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	a.ID = l.nextID
+	c := *a
+	l.pending[tx] = append(l.pending[tx], &c)
+	return nil
+}
+
+func (l *lockingStore) ListAfter(accountID int, afterID int64, limit int) ([]*models.AccountActivity, error) {
+	return l.activity.ListAfter(accountID, afterID, limit)
+}
+
+func (l *lockingStore) LatestID(accountID int) (int64, error) {
+	return l.activity.LatestID(accountID)
+}
+
+func (l *lockingStore) Prune(olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// A transfer into an account racing a reject or expiry of one of its pending
+// transfers must not move a stream past the reject or expiry entry before it
+// commits.
+func TestActivityStream_NoEntrySkippedByConcurrentTransfer(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus string
+		run        func(ts *TransactionService) error
+	}{
+		{"reject", models.TransactionStatusRejected, func(ts *TransactionService) error {
+			_, err := ts.RejectTransaction(operatorCtx, 8, "bob", "not expected")
+			return err
+		}},
+		{"expire", models.TransactionStatusExpired, func(ts *TransactionService) error {
+			_, err := ts.ExpirePendingApprovals(context.Background())
+			return err
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newLockingStore()
+			accountRepo := &mockAccountRepo{
+				SelectTxFunc: func(tx *sql.Tx, id int) (*models.Account, error) {
+					store.lock(tx, id)
+					return &models.Account{AccountID: id, AccountNumber: fmt.Sprintf("ACC%d", id), CurrentBalance: 1000}, nil
+				},
+				UpdateTxFunc: func(tx *sql.Tx, account *models.Account) error { return nil },
+			}
+			pending := func() *models.Transaction {
+				return &models.Transaction{ID: 8, SourceAccountID: 1, DestinationAccountID: 2, AmountPennies: 600,
+					Status: models.TransactionStatusPendingApproval, InitiatedBy: "alice"}
+			}
+			transactionRepo := &mockTransactionRepo{
+				SelectTxFunc: func(tx *sql.Tx, id int) (*models.Transaction, error) { return pending(), nil },
+				ExpirePendingFunc: func(tx *sql.Tx, now time.Time) ([]*models.Transaction, error) {
+					expired := pending()
+					expired.Status = models.TransactionStatusExpired
+					return []*models.Transaction{expired}, nil
+				},
+				CreateTxFunc: func(tx *sql.Tx, transaction *models.Transaction) error {
+					transaction.ID = 9
+					return nil
+				},
+			}
+			money := &transactionMockMoneyConverter{decFn: func(s string) (int64, error) { return 200, nil }}
+			newService := func(commit func(tx *sql.Tx) error) *TransactionService {
+				ts := NewTransactionServiceWithDeps(&sql.DB{}, accountRepo, transactionRepo, money,
+					WithTransferActivity(store), WithApprovalThreshold(500, time.Hour))
+				ts.SetBeginFn(func() (*sql.Tx, error) { return &sql.Tx{}, nil })
+				ts.SetRollbackFn(func(tx *sql.Tx) error {
+					store.end(tx, false)
+					return nil
+				})
+				ts.SetCommitFn(commit)
+				return ts
+			}
+
+			// The reject or expiry pauses just before committing
+			committing, proceed := make(chan struct{}), make(chan struct{})
+			paused := newService(func(tx *sql.Tx) error {
+				close(committing)
+				<-proceed
+				store.end(tx, true)
+				return nil
+			})
+			transfers := newService(func(tx *sql.Tx) error {
+				store.end(tx, true)
+				return nil
+			})
+
+			accounts := newTestAccountService(&mockAccountRepository{}, nil, WithAccountActivity(store, events.NewActivityHub()))
+			stream, err := accounts.StreamActivity(operatorCtx, 1, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer stream.Close()
+
+			pausedDone := make(chan error, 1)
+			go func() { pausedDone <- tc.run(paused) }()
+			<-committing
+
+			transferDone := make(chan error, 1)
+			go func() {
+				_, err := transfers.ProcessTransaction(operatorCtx, &models.TransactionRequest{SourceAccountID: 3, DestinationAccountID: 1, Amount: "2.00"})
+				transferDone <- err
+			}()
+			// The transfer either waits for account 1 or, if nothing stops
+			// it, commits ahead
+			select {
+			case id := <-store.blocked:
+				if id != 1 {
+					t.Fatalf("expected the transfer to wait for account 1, waited for %d", id)
+				}
+			case err := <-transferDone:
+				transferDone <- err
+			}
+
+			seen, err := stream.Next(context.Background(), time.Millisecond)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			close(proceed)
+			if err := <-pausedDone; err != nil && err.Error() != "approval request expired" {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := <-transferDone; err != nil {
+				t.Fatalf("unexpected transfer error: %v", err)
+			}
+			more, err := stream.Next(context.Background(), time.Millisecond)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			delivered := false
+			for _, a := range append(seen, more...) {
+				if a.TransactionID == 8 && a.Status == tc.wantStatus {
+					delivered = true
+				}
+			}
+			if !delivered {
+				t.Errorf("expected the %s entry to be streamed, got %v then %v", tc.wantStatus, activityIDs(seen), activityIDs(more))
+			}
+		})
+	}
+}
+
+func TestListAccountTransactions(t *testing.T) {
+	var gotLimit, gotBefore int
+	transactionRepo := &mockTransactionRepo{
+		ListByAccountFunc: func(accountID, beforeID, limit int) ([]*models.Transaction, error) {
+			gotBefore, gotLimit = beforeID, limit
+			return []*models.Transaction{{ID: 3}}, nil
+		},
+	}
+	ts := NewTransactionService(&sql.DB{}, &mockAccountRepo{}, transactionRepo, WithTransferPolicy(NewRolePolicy(testHolders())))
+
+	list, err := ts.ListAccountTransactions(ownerCtx, 100, 10, 0)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected the owner to read the history, got %v, %v", list, err)
+	}
+	if gotBefore != 10 || gotLimit != defaultHistoryPageSize {
+		t.Errorf("expected before 10 and the default page size, got %d and %d", gotBefore, gotLimit)
+	}
+
+	if _, err := ts.ListAccountTransactions(operatorCtx, 100, 0, 5000); err != nil || gotLimit != maxHistoryPageSize {
+		t.Errorf("expected the page size capped at %d, got %d (%v)", maxHistoryPageSize, gotLimit, err)
+	}
+	if _, err := ts.ListAccountTransactions(strangerCtx, 100, 0, 0); err != ErrForbidden {
+		t.Errorf("expected forbidden for a stranger, got %v", err)
+	}
+	if _, err := ts.ListAccountTransactions(operatorCtx, 100, -1, 0); err == nil || err.Error() != "invalid before_id" {
+		t.Errorf("expected invalid before_id, got %v", err)
+	}
+}
+
+type recordingStatementWriter struct {
+	begin, end *models.Statement
+	lines      []models.StatementLine
+}
+
+func (w *recordingStatementWriter) Begin(s *models.Statement) error {
+	copied := *s
+	w.begin = &copied
+	return nil
+}
+func (w *recordingStatementWriter) Line(l *models.StatementLine) error {
+	w.lines = append(w.lines, *l)
+	return nil
+}
+func (w *recordingStatementWriter) End(s *models.Statement) error {
+	w.end = s
+	return nil
+}
+
+func TestWriteStatement(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	posted := "2024-01-02T10:00:00.5Z"
+	accountRepo := &mockAccountRepo{GetByIDFunc: func(id int) (*models.Account, error) {
+		return &models.Account{AccountID: id, AccountNumber: "A", HolderName: "Alice", Currency: "EUR"}, nil
+	}}
+	var readTxs int
+	transactionRepo := &mockTransactionRepo{
+		BalanceAtFunc: func(tx *sql.Tx, accountID int, at time.Time) (int64, error) {
+			if at.Equal(to) {
+				return 9250, nil
+			}
+			if !at.Equal(from) {
+				t.Errorf("expected balances at %v and %v, got %v", from, to, at)
+			}
+			return 10000, nil
+		},
+		EachCompletedFunc: func(tx *sql.Tx, accountID int, gotFrom, gotTo time.Time, fn func(*models.Transaction) error) error {
+			for _, tr := range []*models.Transaction{
+				{ID: 31, SourceAccountID: 100, DestinationAccountID: 2, DestinationAccountNumber: "B", AmountPennies: 1250, CompletedAt: &posted},
+				{ID: 40, SourceAccountID: 2, DestinationAccountID: 100, SourceAccountNumber: "B", AmountPennies: 500, CompletedAt: &posted},
+			} {
+				if err := fn(tr); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	ts := NewTransactionService(&sql.DB{}, accountRepo, transactionRepo,
+		WithTransferPolicy(NewRolePolicy(testHolders())), WithStatements("BANK1"))
+	setTxnFns(ts)
+	ts.SetBeginReadFn(func(context.Context) (*sql.Tx, error) {
+		readTxs++
+		return &sql.Tx{}, nil
+	})
+
+	w := &recordingStatementWriter{}
+	if err := ts.WriteStatement(ownerCtx, 100, from, to, w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if readTxs != 1 {
+		t.Errorf("expected one read transaction, got %d", readTxs)
+	}
+	if w.begin == nil || w.begin.OpeningBalancePennies != 10000 || w.begin.ClosingBalancePennies != 9250 || w.begin.Currency != "EUR" || w.begin.HolderName != "Alice" {
+		t.Errorf("unexpected statement header %+v", w.begin)
+	}
+	if len(w.lines) != 2 || w.lines[0].AmountPennies != -1250 || w.lines[0].BalancePennies != 8750 ||
+		w.lines[0].CounterpartyAccountNumber != "B" || w.lines[1].BalancePennies != 9250 {
+		t.Errorf("unexpected lines %+v", w.lines)
+	}
+	if len(w.lines) > 0 && !w.lines[0].PostedAt.Equal(time.Date(2024, 1, 2, 10, 0, 0, 5e8, time.UTC)) {
+		t.Errorf("unexpected posting time %v", w.lines[0].PostedAt)
+	}
+	if w.end == nil || w.end.ClosingBalancePennies != 9250 {
+		t.Errorf("expected a closing balance of 9250, got %+v", w.end)
+	}
+
+	if err := ts.WriteStatement(strangerCtx, 100, from, to, &recordingStatementWriter{}); err != ErrForbidden {
+		t.Errorf("expected forbidden for a stranger, got %v", err)
+	}
+	if err := ts.WriteStatement(ownerCtx, 100, to, from, &recordingStatementWriter{}); err == nil || err.Error() != "from must be before to" {
+		t.Errorf("expected from must be before to, got %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/events"
+	"fastfunds/internal/models"
+	"fastfunds/internal/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Webhook delivery defaults. Retries wait 30s, 1m, 2m, ... up to 6h, so ten
+// attempts span about eight and a half hours.
+const (
+	defaultWebhookMaxAttempts = 10
+	defaultWebhookBatchSize   = 20
+	webhookRetryBase          = 30 * time.Second
+	webhookRetryMax           = 6 * time.Hour
+	webhookDeliveryListLimit  = 100
+	minWebhookSecretLength    = 16
+	maxWebhookErrorLength     = 500
+	// webhookLeaseMargin is added to the time a batch can take to send, so
+	// its lease also covers recording the attempts.
+	webhookLeaseMargin = time.Minute
+)
+
+func NewWebhookService(db *sql.DB, webhookRepo repository.WebhookRepository, opts ...func(*WebhookService)) *WebhookService {
+	s := &WebhookService{
+		db:          db,
+		webhookRepo: webhookRepo,
+		client:      events.NewWebhookClient(),
+		maxAttempts: defaultWebhookMaxAttempts,
+		batchSize:   defaultWebhookBatchSize,
+		secretFn:    events.GenerateSecret,
+		policy:      NewRolePolicy(nil),
+	}
+	s.beginFn = func() (*sql.Tx, error) { return s.db.Begin() }
+	s.rollbackFn = func(tx *sql.Tx) error { return tx.Rollback() }
+	s.commitFn = func(tx *sql.Tx) error { return tx.Commit() }
+	s.nowFn = time.Now
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithWebhookPolicy sets the authorization policy.
+func WithWebhookPolicy(policy Policy) func(*WebhookService) {
+	return func(s *WebhookService) {
+		s.policy = policy
+	}
+}
+
+// WithWebhookAuditLog records subscription changes and replays in the audit
+// log. Signing secrets are never logged.
+func WithWebhookAuditLog(auditLog repository.AuditLogRepository) func(*WebhookService) {
+	return func(s *WebhookService) {
+		s.auditLog = auditLog
+	}
+}
+
+// WithWebhookClient sets the HTTP client deliveries are sent with.
+func WithWebhookClient(client *http.Client) func(*WebhookService) {
+	return func(s *WebhookService) {
+		s.client = client
+	}
+}
+
+// WithWebhookMaxAttempts sets how many times a delivery is tried before it
+// is dead-lettered.
+func WithWebhookMaxAttempts(n int) func(*WebhookService) {
+	return func(s *WebhookService) {
+		if n > 0 {
+			s.maxAttempts = n
+		}
+	}
+}
+
+// WebhookService manages webhook subscriptions and delivers events to them.
+// As an events.Publisher it turns each event into a pending delivery per
+// matching subscription; DeliverBatch then sends them, signed with the
+// subscription's secret.
+type WebhookService struct {
+	db          *sql.DB
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	batchSize   int
+	secretFn    func() (string, error)
+	auditLog    repository.AuditLogRepository
+	policy      Policy
+	beginFn     func() (*sql.Tx, error)
+	rollbackFn  func(*sql.Tx) error
+	commitFn    func(*sql.Tx) error
+	nowFn       func() time.Time
+}
+
+// CreateSubscription registers an endpoint. The secret is only in the
+// returned value.
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *models.WebhookSubscriptionRequest) (*models.CreatedWebhookSubscription, error) {
+	if err := authorize(ctx, s.policy, ActionManageWebhooks, Resource{}); err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSpace(req.URL)
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+
+	if len(req.EventTypes) == 0 {
+		return nil, errors.New("event_types is required")
+	}
+	var types []string
+	for _, t := range req.EventTypes {
+		if !slices.Contains(events.Types, t) {
+			return nil, errors.New("unknown event type " + t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		if secret, err = s.secretFn(); err != nil {
+			return nil, errors.New("couldn't generate webhook secret")
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return nil, errors.New("secret must be at least 16 characters")
+	}
+
+	sub := &models.WebhookSubscription{URL: endpoint, EventTypes: types, Secret: secret}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.webhookRepo.CreateSubscriptionTx(tx, sub); err != nil {
+		return nil, errors.New("couldn't create webhook subscription")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionWebhookCreate, audit.EntityWebhook, sub.ID, nil, sub); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return &models.CreatedWebhookSubscription{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	if err := authorize(ctx, s.policy, ActionReadWebhooks, Resource{}); err != nil {
+		return nil, err
+	}
+
+	list, err := s.webhookRepo.ListSubscriptions()
+	if err != nil {
+		return nil, errors.New("couldn't list webhook subscriptions")
+	}
+	return list, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid subscription_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadWebhooks, Resource{}); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.webhookRepo.GetSubscription(id)
+	if err != nil {
+		return nil, errors.New("webhook subscription not found")
+	}
+	return sub, nil
+}
+
+// DeleteSubscription removes a subscription and its delivery log. Pending
+// deliveries are not sent.
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid subscription_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageWebhooks, Resource{}); err != nil {
+		return err
+	}
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	sub, err := s.webhookRepo.DeleteSubscriptionTx(tx, id)
+	if err != nil {
+		return errors.New("webhook subscription not found")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionWebhookDelete, audit.EntityWebhook, id, sub, nil); err != nil {
+		return err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
+
+// ListDeliveries returns a subscription's latest deliveries, newest first,
+// optionally only those with status.
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int, status string) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	list, err := s.webhookRepo.ListDeliveries(subscriptionID, status, webhookDeliveryListLimit)
+	if err != nil {
+		return nil, errors.New("couldn't list webhook deliveries")
+	}
+	return list, nil
+}
+
+// GetDelivery returns a delivery with its attempt log.
+func (s *WebhookService) GetDelivery(ctx context.Context, subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	if subscriptionID <= 0 || id <= 0 {
+		return nil, errors.New("invalid delivery_id")
+	}
+	if err := authorize(ctx, s.policy, ActionReadWebhooks, Resource{}); err != nil {
+		return nil, err
+	}
+
+	d, err := s.webhookRepo.GetDelivery(subscriptionID, id)
+	if err != nil {
+		return nil, errors.New("webhook delivery not found")
+	}
+	return d, nil
+}
+
+// ReplayDelivery sends a delivered or dead-lettered delivery again, with a
+// fresh set of retries.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	if subscriptionID <= 0 || id <= 0 {
+		return nil, errors.New("invalid delivery_id")
+	}
+	if err := authorize(ctx, s.policy, ActionManageWebhooks, Resource{}); err != nil {
+		return nil, err
+	}
+
+	before, err := s.webhookRepo.GetDelivery(subscriptionID, id)
+	if err != nil {
+		return nil, errors.New("webhook delivery not found")
+	}
+	if before.Status == models.WebhookDeliveryPending {
+		return nil, errors.New("webhook delivery is already pending")
+	}
+	before.AttemptLog = nil
+
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	// Only delivered and dead rows are updated, so a concurrent replay loses
+	d, err := s.webhookRepo.ReplayDeliveryTx(tx, subscriptionID, id)
+	if err != nil {
+		return nil, errors.New("webhook delivery is already pending")
+	}
+	if err := recordAudit(ctx, tx, s.auditLog, audit.ActionWebhookReplay, audit.EntityWebhookDelivery, id, before, d); err != nil {
+		return nil, err
+	}
+
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return d, nil
+}
+
+// Publish queues e for every subscription to its type. It is idempotent, so
+// the outbox relay may offer the same event again.
+func (s *WebhookService) Publish(ctx context.Context, e *models.Event) error {
+	_, err := s.webhookRepo.EnqueueEvent(e)
+	return err
+}
+
+// DeliverBatch sends one batch of due deliveries and returns how many it
+// attempted, accepted or not. A failed delivery is retried with exponential
+// backoff until maxAttempts, then marked dead. No DB transaction is open
+// while sending: the batch is claimed under a lease, and each attempt is
+// recorded on its own.
+func (s *WebhookService) DeliverBatch(ctx context.Context) (int, error) {
+	due, err := s.claimDue()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, d := range due {
+		started := time.Now()
+		sendCtx, cancel := context.WithTimeout(ctx, s.deliveryTimeout())
+		code, err := events.PostWebhook(sendCtx, s.client, d.URL, d.Secret, d.EventID, d.EventType, d.Event, s.nowFn())
+		cancel()
+		attempt := &models.WebhookDeliveryAttempt{DurationMs: time.Since(started).Milliseconds()}
+		if code != 0 {
+			attempt.StatusCode = &code
+		}
+
+		status, retryAfter := models.WebhookDeliveryDelivered, time.Duration(0)
+		if err != nil {
+			attempt.Error = truncate(err.Error(), maxWebhookErrorLength)
+			status = models.WebhookDeliveryPending
+			retryAfter = webhookBackoff(d.Attempts + 1)
+			if d.Attempts+1 >= s.maxAttempts {
+				status = models.WebhookDeliveryDead
+				log.Printf("webhook delivery %d to subscription %d dead after %d attempts: %v", d.ID, d.SubscriptionID, d.Attempts+1, err)
+			}
+		}
+
+		// An attempt that isn't recorded is sent again once the lease ends
+		if err := s.recordAttempt(d.ID, attempt, status, retryAfter); err != nil {
+			log.Printf("failed to record attempt of webhook delivery %d: %v", d.ID, err)
+		}
+	}
+	return len(due), nil
+}
+
+// deliveryTimeout bounds each delivery: the client's timeout, or the
+// default one for a client without.
+func (s *WebhookService) deliveryTimeout() time.Duration {
+	if s.client.Timeout > 0 {
+		return s.client.Timeout
+	}
+	return events.DefaultWebhookTimeout
+}
+
+// claimLease is how long claimed deliveries are kept from other
+// dispatchers: long enough to send a whole batch one after another.
+// Deliveries whose attempt was never recorded are sent again after it.
+func (s *WebhookService) claimLease() time.Duration {
+	return time.Duration(s.batchSize)*s.deliveryTimeout() + webhookLeaseMargin
+}
+
+func (s *WebhookService) claimDue() ([]*models.DueWebhookDelivery, error) {
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return nil, errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	due, err := s.webhookRepo.ClaimDueTx(tx, s.batchSize, s.claimLease())
+	if err != nil {
+		return nil, err
+	}
+	if err = s.commitFn(tx); err != nil {
+		return nil, errors.New("couldn't commit db transaction")
+	}
+	return due, nil
+}
+
+func (s *WebhookService) recordAttempt(id int64, a *models.WebhookDeliveryAttempt, status string, retryAfter time.Duration) error {
+	tx, err := s.beginFn()
+	if err != nil || tx == nil {
+		return errors.New("couldn't start DB transaction")
+	}
+
+	defer s.rollbackFn(tx)
+
+	if err := s.webhookRepo.RecordAttemptTx(tx, id, a, status, retryAfter); err != nil {
+		return err
+	}
+	if err = s.commitFn(tx); err != nil {
+		return errors.New("couldn't commit db transaction")
+	}
+	return nil
+}
+
+// Run delivers webhooks until ctx is cancelled. It sends batches back to
+// back while they come back full, and polls every interval otherwise.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	for {
+		n, err := s.DeliverBatch(ctx)
+		if err != nil {
+			log.Print("failed to deliver webhooks:", err)
+		}
+		if err == nil && n == s.batchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// webhookBackoff is the wait after the attempts-th failed attempt.
+func webhookBackoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return webhookRetryMax
+	}
+	return min(webhookRetryBase<<(attempts-1), webhookRetryMax)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
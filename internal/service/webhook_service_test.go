@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fastfunds/internal/audit"
+	"fastfunds/internal/events"
+	"fastfunds/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockWebhookRepository keeps subscriptions and deliveries in memory. Every
+// pending delivery counts as due; the last retry delay is kept per delivery.
+type mockWebhookRepository struct {
+	subs       []*models.WebhookSubscription
+	deliveries []*models.WebhookDelivery
+	retryAfter map[int64]time.Duration
+	// leased are the claimed deliveries whose attempt isn't recorded yet
+	leased map[int64]bool
+	lease  time.Duration
+}
+
+func (m *mockWebhookRepository) CreateSubscriptionTx(tx *sql.Tx, s *models.WebhookSubscription) error {
+	s.ID = len(m.subs) + 1
+	m.subs = append(m.subs, s)
+	return nil
+}
+
+func (m *mockWebhookRepository) GetSubscription(id int) (*models.WebhookSubscription, error) {
+	for _, s := range m.subs {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, errors.New("webhook subscription not found")
+}
+
+func (m *mockWebhookRepository) ListSubscriptions() ([]*models.WebhookSubscription, error) {
+	return m.subs, nil
+}
+
+func (m *mockWebhookRepository) DeleteSubscriptionTx(tx *sql.Tx, id int) (*models.WebhookSubscription, error) {
+	s, err := m.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	m.subs = slices.DeleteFunc(m.subs, func(s *models.WebhookSubscription) bool { return s.ID == id })
+	return s, nil
+}
+
+func (m *mockWebhookRepository) EnqueueEvent(e *models.Event) (int64, error) {
+	event, _ := json.Marshal(e)
+	var n int64
+	for _, s := range m.subs {
+		if !slices.Contains(s.EventTypes, e.Type) || m.find(s.ID, e.ID) != nil {
+			continue
+		}
+		m.deliveries = append(m.deliveries, &models.WebhookDelivery{
+			ID: int64(len(m.deliveries) + 1), SubscriptionID: s.ID, EventID: e.ID, EventType: e.Type,
+			Event: event, Status: models.WebhookDeliveryPending,
+		})
+		n++
+	}
+	return n, nil
+}
+
+func (m *mockWebhookRepository) find(subscriptionID int, eventID int64) *models.WebhookDelivery {
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.EventID == eventID {
+			return d
+		}
+	}
+	return nil
+}
+
+func (m *mockWebhookRepository) ListDeliveries(subscriptionID int, status string, limit int) ([]*models.WebhookDelivery, error) {
+	var list []*models.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockWebhookRepository) GetDelivery(subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.ID == id {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, errors.New("webhook delivery not found")
+}
+
+func (m *mockWebhookRepository) ClaimDueTx(tx *sql.Tx, limit int, lease time.Duration) ([]*models.DueWebhookDelivery, error) {
+	if m.leased == nil {
+		m.leased = map[int64]bool{}
+	}
+	m.lease = lease
+	var list []*models.DueWebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status != models.WebhookDeliveryPending || m.leased[d.ID] || len(list) == limit {
+			continue
+		}
+		m.leased[d.ID] = true
+		s, _ := m.GetSubscription(d.SubscriptionID)
+		list = append(list, &models.DueWebhookDelivery{WebhookDelivery: d, URL: s.URL, Secret: s.Secret})
+	}
+	return list, nil
+}
+
+func (m *mockWebhookRepository) RecordAttemptTx(tx *sql.Tx, id int64, a *models.WebhookDeliveryAttempt, status string, retryAfter time.Duration) error {
+	delete(m.leased, id)
+	for _, d := range m.deliveries {
+		if d.ID == id {
+			d.Status = status
+			d.Attempts++
+			d.LastStatusCode = a.StatusCode
+			d.LastError = a.Error
+			d.AttemptLog = append(d.AttemptLog, a)
+		}
+	}
+	if m.retryAfter == nil {
+		m.retryAfter = map[int64]time.Duration{}
+	}
+	m.retryAfter[id] = retryAfter
+	return nil
+}
+
+func (m *mockWebhookRepository) ReplayDeliveryTx(tx *sql.Tx, subscriptionID int, id int64) (*models.WebhookDelivery, error) {
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.ID == id && d.Status != models.WebhookDeliveryPending {
+			d.Status = models.WebhookDeliveryPending
+			d.Attempts = 0
+			return d, nil
+		}
+	}
+	return nil, errors.New("webhook delivery not found")
+}
+
+func newTestWebhookService(repo *mockWebhookRepository, opts ...func(*WebhookService)) *WebhookService {
+	s := NewWebhookService(&sql.DB{}, repo, opts...)
+	s.beginFn = func() (*sql.Tx, error) { return &sql.Tx{}, nil }
+	s.rollbackFn = func(tx *sql.Tx) error { return nil }
+	s.commitFn = func(tx *sql.Tx) error { return nil }
+	return s
+}
+
+// receiver is a local webhook endpoint that checks signatures and answers
+// with the next queued status, or 200 once the queue is empty. onRequest,
+// if set, is called as each request arrives.
+type receiver struct {
+	*httptest.Server
+	secret    string
+	mu        sync.Mutex
+	statuses  []int
+	received  []*models.Event
+	badSigs   int
+	onRequest func()
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	r := &receiver{secret: secret, statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.onRequest != nil {
+			r.onRequest()
+		}
+		if events.VerifySignature(r.secret, req.Header.Get(events.HeaderSignature), req.Header.Get(events.HeaderTimestamp), body, 5*time.Minute, time.Now()) != nil {
+			r.badSigs++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		if status == http.StatusOK {
+			var e models.Event
+			json.Unmarshal(body, &e)
+			r.received = append(r.received, &e)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+const testWebhookSecret = "test-secret-0123456789"
+
+func subscribe(t *testing.T, svc *WebhookService, url string, types ...string) *models.CreatedWebhookSubscription {
+	t.Helper()
+	sub, err := svc.CreateSubscription(operatorCtx, &models.WebhookSubscriptionRequest{URL: url, EventTypes: types, Secret: testWebhookSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+func TestCreateSubscription_Validation(t *testing.T) {
+	svc := newTestWebhookService(&mockWebhookRepository{})
+	cases := []struct {
+		name string
+		req  models.WebhookSubscriptionRequest
+		want string
+	}{
+		{"relative url", models.WebhookSubscriptionRequest{URL: "/hook", EventTypes: []string{models.EventAccountCreated}}, "url must be an absolute http or https URL"},
+		{"other scheme", models.WebhookSubscriptionRequest{URL: "ftp://example.com", EventTypes: []string{models.EventAccountCreated}}, "url must be an absolute http or https URL"},
+		{"credentials in url", models.WebhookSubscriptionRequest{URL: "https://u:p@example.com", EventTypes: []string{models.EventAccountCreated}}, "url must be an absolute http or https URL"},
+		{"no types", models.WebhookSubscriptionRequest{URL: "https://example.com"}, "event_types is required"},
+		{"unknown type", models.WebhookSubscriptionRequest{URL: "https://example.com", EventTypes: []string{"Nope"}}, "unknown event type Nope"},
+		{"short secret", models.WebhookSubscriptionRequest{URL: "https://example.com", EventTypes: []string{models.EventAccountCreated}, Secret: "short"}, "secret must be at least 16 characters"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.CreateSubscription(operatorCtx, &tc.req)
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+
+	_, err := svc.CreateSubscription(ownerCtx, &models.WebhookSubscriptionRequest{URL: "https://example.com", EventTypes: []string{models.EventAccountCreated}})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCreateSubscription_GeneratesSecretAndAudits(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	log := &mockAuditLog{}
+	svc := newTestWebhookService(repo, WithWebhookAuditLog(log))
+
+	sub, err := svc.CreateSubscription(operatorCtx, &models.WebhookSubscriptionRequest{
+		URL:        " https://example.com/hook ",
+		EventTypes: []string{models.EventTransferCompleted, models.EventTransferCompleted},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", sub.URL)
+	assert.Equal(t, []string{models.EventTransferCompleted}, sub.EventTypes)
+	assert.Contains(t, sub.Secret, events.SecretPrefix)
+	assert.Equal(t, sub.Secret, repo.subs[0].Secret)
+
+	if assert.Len(t, log.entries, 1) {
+		assert.Equal(t, audit.ActionWebhookCreate, log.entries[0].Action)
+		assert.NotContains(t, string(log.entries[0].After), sub.Secret)
+	}
+}
+
+func TestWebhook_DeliversSignedEventsToSubscribers(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo)
+	accounts := newReceiver(t, testWebhookSecret)
+	transfers := newReceiver(t, testWebhookSecret)
+	subscribe(t, svc, accounts.URL, models.EventAccountCreated)
+	subscribe(t, svc, transfers.URL, models.EventTransferCompleted, models.EventTransferFailed)
+
+	e := &models.Event{ID: 42, Type: models.EventTransferCompleted, AggregateType: events.AggregateTransaction, AggregateID: "31", Payload: json.RawMessage(`{"transaction_id":31}`)}
+	assert.NoError(t, svc.Publish(context.Background(), e))
+	// The relay may offer an event again; it is delivered once per subscription
+	assert.NoError(t, svc.Publish(context.Background(), e))
+
+	n, err := svc.DeliverBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, accounts.received)
+	if assert.Len(t, transfers.received, 1) {
+		assert.Equal(t, int64(42), transfers.received[0].ID)
+		assert.JSONEq(t, `{"transaction_id":31}`, string(transfers.received[0].Payload))
+	}
+	assert.Zero(t, transfers.badSigs)
+
+	d := repo.deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryDelivered, d.Status)
+	if assert.Len(t, d.AttemptLog, 1) && assert.NotNil(t, d.AttemptLog[0].StatusCode) {
+		assert.Equal(t, http.StatusOK, *d.AttemptLog[0].StatusCode)
+	}
+}
+
+func TestWebhook_SendsWithNoTransactionOpen(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo)
+	var open, commits int
+	svc.beginFn = func() (*sql.Tx, error) { open++; return &sql.Tx{}, nil }
+	svc.rollbackFn = func(tx *sql.Tx) error { open = 0; return nil }
+	svc.commitFn = func(tx *sql.Tx) error { open = 0; commits++; return nil }
+
+	var openWhileSending []int
+	rcv := newReceiver(t, testWebhookSecret)
+	rcv.onRequest = func() { openWhileSending = append(openWhileSending, open) }
+	subscribe(t, svc, rcv.URL, models.EventAccountCreated)
+	svc.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventAccountCreated})
+	svc.Publish(context.Background(), &models.Event{ID: 2, Type: models.EventAccountCreated})
+	commits = 0
+
+	n, err := svc.DeliverBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{0, 0}, openWhileSending)
+	assert.Equal(t, 3, commits, "the claim and each attempt commit on their own")
+	assert.Equal(t, 20*10*time.Second+time.Minute, repo.lease, "long enough to send a full batch")
+	assert.Empty(t, repo.leased)
+}
+
+func TestWebhook_RetriesThenDeadLetters(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo, WithWebhookMaxAttempts(3))
+	rcv := newReceiver(t, testWebhookSecret, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	subscribe(t, svc, rcv.URL, models.EventAccountCreated)
+	svc.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventAccountCreated})
+
+	d := repo.deliveries[0]
+	svc.DeliverBatch(context.Background())
+	assert.Equal(t, models.WebhookDeliveryPending, d.Status)
+	assert.Equal(t, 30*time.Second, repo.retryAfter[d.ID])
+	assert.Equal(t, "webhook returned 500 Internal Server Error", d.LastError)
+
+	svc.DeliverBatch(context.Background())
+	assert.Equal(t, models.WebhookDeliveryPending, d.Status)
+	assert.Equal(t, time.Minute, repo.retryAfter[d.ID])
+
+	svc.DeliverBatch(context.Background())
+	assert.Equal(t, models.WebhookDeliveryDead, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Len(t, d.AttemptLog, 3)
+
+	// Dead deliveries are no longer attempted
+	n, err := svc.DeliverBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, d.AttemptLog, 3)
+}
+
+func TestWebhook_UnreachableEndpoint(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo)
+	rcv := newReceiver(t, testWebhookSecret)
+	subscribe(t, svc, rcv.URL, models.EventAccountCreated)
+	rcv.Close()
+	svc.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventAccountCreated})
+
+	svc.DeliverBatch(context.Background())
+	d := repo.deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryPending, d.Status)
+	assert.Nil(t, d.LastStatusCode)
+	assert.NotEmpty(t, d.LastError)
+}
+
+func TestWebhook_Replay(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	log := &mockAuditLog{}
+	svc := newTestWebhookService(repo, WithWebhookMaxAttempts(1), WithWebhookAuditLog(log))
+	rcv := newReceiver(t, testWebhookSecret, http.StatusInternalServerError)
+	sub := subscribe(t, svc, rcv.URL, models.EventAccountCreated)
+	svc.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventAccountCreated})
+
+	svc.DeliverBatch(context.Background())
+	d := repo.deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryDead, d.Status)
+
+	replayed, err := svc.ReplayDelivery(operatorCtx, sub.ID, d.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryPending, replayed.Status)
+	assert.Equal(t, []string{audit.ActionWebhookCreate, audit.ActionWebhookReplay}, log.actions())
+
+	_, err = svc.ReplayDelivery(operatorCtx, sub.ID, d.ID)
+	assert.EqualError(t, err, "webhook delivery is already pending")
+
+	n, _ := svc.DeliverBatch(context.Background())
+	assert.Equal(t, 1, n)
+	assert.Equal(t, models.WebhookDeliveryDelivered, d.Status)
+	assert.Len(t, rcv.received, 1)
+
+	_, err = svc.ReplayDelivery(operatorCtx, sub.ID+1, d.ID)
+	assert.EqualError(t, err, "webhook delivery not found")
+	_, err = svc.ReplayDelivery(auditorCtx, sub.ID, d.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestWebhook_WrongSecretIsRejectedByReceiver(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo)
+	rcv := newReceiver(t, "a-different-secret-value")
+	subscribe(t, svc, rcv.URL, models.EventAccountCreated)
+	svc.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventAccountCreated})
+
+	n, _ := svc.DeliverBatch(context.Background())
+	assert.Equal(t, 1, n, "a rejected delivery was still attempted")
+	assert.Equal(t, models.WebhookDeliveryPending, repo.deliveries[0].Status)
+	assert.Equal(t, 1, rcv.badSigs)
+}
+
+func TestWebhook_FailedDeliveryCountsTowardsAFullBatch(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo, func(s *WebhookService) { s.batchSize = 2 })
+	rcv := newReceiver(t, testWebhookSecret, http.StatusInternalServerError)
+	subscribe(t, svc, rcv.URL, models.EventAccountCreated)
+	for id := int64(1); id <= 3; id++ {
+		svc.Publish(context.Background(), &models.Event{ID: id, Type: models.EventAccountCreated})
+	}
+
+	// A full batch tells Run to send the next one straight away
+	n, err := svc.DeliverBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, rcv.received, 1)
+}
+
+func TestWebhook_LeaseCoversTheBatch(t *testing.T) {
+	svc := newTestWebhookService(&mockWebhookRepository{}, WithWebhookClient(&http.Client{Timeout: 3 * time.Second}))
+	assert.Equal(t, 20*3*time.Second+webhookLeaseMargin, svc.claimLease())
+
+	// A client without a timeout is bounded by the default one
+	svc = newTestWebhookService(&mockWebhookRepository{}, WithWebhookClient(&http.Client{}))
+	assert.Equal(t, events.DefaultWebhookTimeout, svc.deliveryTimeout())
+	assert.Equal(t, 20*events.DefaultWebhookTimeout+webhookLeaseMargin, svc.claimLease())
+}
+
+func TestListDeliveries(t *testing.T) {
+	repo := &mockWebhookRepository{}
+	svc := newTestWebhookService(repo)
+	sub := subscribe(t, svc, "https://example.com", models.EventAccountCreated)
+	svc.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventAccountCreated})
+
+	list, err := svc.ListDeliveries(auditorCtx, sub.ID, models.WebhookDeliveryPending)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	_, err = svc.ListDeliveries(auditorCtx, sub.ID, "bogus")
+	assert.EqualError(t, err, "invalid status")
+	_, err = svc.ListDeliveries(auditorCtx, 99, "")
+	assert.EqualError(t, err, "webhook subscription not found")
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhookBackoff(1))
+	assert.Equal(t, 4*time.Minute, webhookBackoff(4))
+	assert.Equal(t, webhookRetryMax, webhookBackoff(12))
+	assert.Equal(t, webhookRetryMax, webhookBackoff(100))
+}
@@ -0,0 +1,170 @@
+package statements
+
+import (
+	"encoding/xml"
+	"fastfunds/internal/models"
+	"fastfunds/internal/util"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+func init() {
+	Register(Format{Name: "camt053", ContentType: "application/xml", Extension: "xml",
+		New: func(w io.Writer) Writer { return &camt053Writer{enc: xml.NewEncoder(w)} }})
+}
+
+// Camt053Namespace is the ISO 20022 bank-to-customer statement version
+// written, the one most corporate systems accept.
+const Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053Writer writes a BkToCstmrStmt with a single Stmt. The schema puts
+// both balances before the entries, so they are written at Begin and each
+// transfer follows as an Ntry.
+type camt053Writer struct {
+	enc      *xml.Encoder
+	currency string
+}
+
+type camtAccountID struct {
+	ID string `xml:"Othr>Id"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code        string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	DateTime    string     `xml:"Dt>DtTm"`
+}
+
+type camtAccount struct {
+	ID       camtAccountID `xml:"Id"`
+	Currency string        `xml:"Ccy"`
+	Owner    string        `xml:"Ownr>Nm,omitempty"`
+	Servicer string        `xml:"Svcr>FinInstnId>Othr>Id"`
+}
+
+type camtParty struct {
+	Account camtAccountID `xml:"Id"`
+}
+
+type camtEntry struct {
+	Amount          camtAmount `xml:"Amt"`
+	CreditDebit     string     `xml:"CdtDbtInd"`
+	Status          string     `xml:"Sts"`
+	BookingDateTime string     `xml:"BookgDt>DtTm"`
+	ValueDate       string     `xml:"ValDt>Dt"`
+	ServicerRef     string     `xml:"AcctSvcrRef"`
+	Domain          string     `xml:"BkTxCd>Domn>Cd"`
+	Family          string     `xml:"BkTxCd>Domn>Fmly>Cd"`
+	SubFamily       string     `xml:"BkTxCd>Domn>Fmly>SubFmlyCd"`
+	Details         camtDetail `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDetail struct {
+	TransactionID   string     `xml:"Refs>TxId"`
+	DebtorAccount   *camtParty `xml:"RltdPties>DbtrAcct,omitempty"`
+	CreditorAccount *camtParty `xml:"RltdPties>CdtrAcct,omitempty"`
+	Info            string     `xml:"AddtlTxInf"`
+}
+
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// camtAmountOf splits a signed amount into the unsigned value and the
+// CRDT/DBIT indicator ISO 20022 uses instead of a sign.
+func camtAmountOf(currency string, pennies int64) (camtAmount, string) {
+	indicator := "CRDT"
+	if pennies < 0 {
+		indicator = "DBIT"
+		pennies = -pennies
+	}
+	return camtAmount{Currency: currency, Value: util.PenniesToDecimalString(pennies)}, indicator
+}
+
+func camtBalanceOf(code, currency string, pennies int64, at time.Time) camtBalance {
+	amount, indicator := camtAmountOf(currency, pennies)
+	return camtBalance{Code: code, Amount: amount, CreditDebit: indicator, DateTime: camtTime(at)}
+}
+
+func (c *camt053Writer) start(name string) error {
+	return c.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func (c *camt053Writer) end(name string) error {
+	return c.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func (c *camt053Writer) element(name string, v any) error {
+	return c.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func (c *camt053Writer) Begin(s *models.Statement) error {
+	c.currency = s.Currency
+	// Both IDs are limited to 35 characters. The message is identified by
+	// when it was made and the statement by its account and start.
+	msgID := "STMT" + s.GeneratedAt.UTC().Format("20060102150405.000")
+	stmtID := fmt.Sprintf("%.18s-%s", s.AccountNumber, s.From.UTC().Format("20060102T1504"))
+
+	if err := c.enc.EncodeToken(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)}); err != nil {
+		return err
+	}
+	c.enc.EncodeToken(xml.CharData("\n"))
+	c.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Document"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Camt053Namespace}}})
+	c.start("BkToCstmrStmt")
+	c.enc.EncodeElement(struct {
+		MsgID    string `xml:"MsgId"`
+		Creation string `xml:"CreDtTm"`
+	}{msgID, camtTime(s.GeneratedAt)}, xml.StartElement{Name: xml.Name{Local: "GrpHdr"}})
+	c.start("Stmt")
+	c.element("Id", stmtID)
+	c.element("CreDtTm", camtTime(s.GeneratedAt))
+	c.enc.EncodeElement(struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	}{camtTime(s.From), camtTime(s.To)}, xml.StartElement{Name: xml.Name{Local: "FrToDt"}})
+	c.element("Acct", camtAccount{ID: camtAccountID{s.AccountNumber}, Currency: s.Currency, Owner: s.HolderName, Servicer: s.BankID})
+	c.element("Bal", camtBalanceOf("OPBD", s.Currency, s.OpeningBalancePennies, s.From))
+	return c.element("Bal", camtBalanceOf("CLBD", s.Currency, s.ClosingBalancePennies, s.To))
+}
+
+func (c *camt053Writer) Line(l *models.StatementLine) error {
+	amount, indicator := camtAmountOf(c.currency, l.AmountPennies)
+	id := strconv.Itoa(l.TransactionID)
+	entry := camtEntry{
+		Amount:          amount,
+		CreditDebit:     indicator,
+		Status:          "BOOK",
+		BookingDateTime: camtTime(l.PostedAt),
+		ValueDate:       l.PostedAt.UTC().Format("2006-01-02"),
+		ServicerRef:     id,
+		Domain:          "PMNT",
+		Family:          "RCDT",
+		SubFamily:       "BOOK",
+		Details:         camtDetail{TransactionID: id, Info: Description(l)},
+	}
+	counterparty := &camtParty{Account: camtAccountID{l.CounterpartyAccountNumber}}
+	if l.AmountPennies < 0 {
+		entry.Family = "ICDT"
+		entry.Details.CreditorAccount = counterparty
+	} else {
+		entry.Details.DebtorAccount = counterparty
+	}
+	return c.element("Ntry", entry)
+}
+
+func (c *camt053Writer) End(s *models.Statement) error {
+	c.end("Stmt")
+	c.end("BkToCstmrStmt")
+	c.end("Document")
+	c.enc.EncodeToken(xml.CharData("\n"))
+	return c.enc.Flush()
+}
@@ -0,0 +1,45 @@
+package statements
+
+import (
+	"encoding/csv"
+	"fastfunds/internal/models"
+	"fastfunds/internal/util"
+	"io"
+	"strconv"
+	"time"
+)
+
+func init() {
+	Register(Format{Name: "csv", ContentType: "text/csv", Extension: "csv",
+		New: func(w io.Writer) Writer { return &csvWriter{w: csv.NewWriter(w)} }})
+}
+
+// csvWriter writes one row per transfer between an opening and a closing
+// balance row. Amounts are signed decimals; debits are negative.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Begin(s *models.Statement) error {
+	c.w.Write([]string{"date", "transaction_id", "description", "counterparty", "amount", "balance"})
+	return c.w.Write([]string{s.From.UTC().Format(time.RFC3339), "", "Opening balance", "", "",
+		util.PenniesToDecimalString(s.OpeningBalancePennies)})
+}
+
+func (c *csvWriter) Line(l *models.StatementLine) error {
+	return c.w.Write([]string{
+		l.PostedAt.UTC().Format(time.RFC3339),
+		strconv.Itoa(l.TransactionID),
+		Description(l),
+		l.CounterpartyAccountNumber,
+		util.PenniesToDecimalString(l.AmountPennies),
+		util.PenniesToDecimalString(l.BalancePennies),
+	})
+}
+
+func (c *csvWriter) End(s *models.Statement) error {
+	c.w.Write([]string{s.To.UTC().Format(time.RFC3339), "", "Closing balance", "", "",
+		util.PenniesToDecimalString(s.ClosingBalancePennies)})
+	c.w.Flush()
+	return c.w.Error()
+}
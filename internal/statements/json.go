@@ -0,0 +1,91 @@
+package statements
+
+import (
+	"bufio"
+	"encoding/json"
+	"fastfunds/internal/models"
+	"io"
+	"time"
+)
+
+func init() {
+	Register(Format{Name: "json", ContentType: "application/json", Extension: "json",
+		New: func(w io.Writer) Writer { return &jsonWriter{w: bufio.NewWriter(w)} }})
+}
+
+// jsonWriter writes a single JSON object. The header fields and the
+// transactions array are written as they arrive, and the closing balance
+// follows the array since it is only known at the end.
+type jsonWriter struct {
+	w     *bufio.Writer
+	lines int
+}
+
+type jsonStatementHeader struct {
+	AccountNumber         string `json:"account_number"`
+	HolderName            string `json:"holder_name"`
+	Currency              string `json:"currency"`
+	From                  string `json:"from"`
+	To                    string `json:"to"`
+	GeneratedAt           string `json:"generated_at"`
+	OpeningBalancePennies int64  `json:"opening_balance_pennies"`
+}
+
+type jsonStatementLine struct {
+	TransactionID             int    `json:"transaction_id"`
+	PostedAt                  string `json:"posted_at"`
+	Description               string `json:"description"`
+	CounterpartyAccountNumber string `json:"counterparty_account_number"`
+	AmountPennies             int64  `json:"amount_pennies"`
+	BalancePennies            int64  `json:"balance_pennies"`
+}
+
+func (j *jsonWriter) Begin(s *models.Statement) error {
+	header, err := json.Marshal(jsonStatementHeader{
+		AccountNumber:         s.AccountNumber,
+		HolderName:            s.HolderName,
+		Currency:              s.Currency,
+		From:                  s.From.UTC().Format(time.RFC3339),
+		To:                    s.To.UTC().Format(time.RFC3339),
+		GeneratedAt:           s.GeneratedAt.UTC().Format(time.RFC3339),
+		OpeningBalancePennies: s.OpeningBalancePennies,
+	})
+	if err != nil {
+		return err
+	}
+	// Reopen the object to append the remaining fields.
+	j.w.Write(header[:len(header)-1])
+	_, err = j.w.WriteString(`,"transactions":[`)
+	return err
+}
+
+func (j *jsonWriter) Line(l *models.StatementLine) error {
+	b, err := json.Marshal(jsonStatementLine{
+		TransactionID:             l.TransactionID,
+		PostedAt:                  l.PostedAt.UTC().Format(time.RFC3339Nano),
+		Description:               Description(l),
+		CounterpartyAccountNumber: l.CounterpartyAccountNumber,
+		AmountPennies:             l.AmountPennies,
+		BalancePennies:            l.BalancePennies,
+	})
+	if err != nil {
+		return err
+	}
+	if j.lines > 0 {
+		j.w.WriteByte(',')
+	}
+	j.lines++
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonWriter) End(s *models.Statement) error {
+	closing, err := json.Marshal(s.ClosingBalancePennies)
+	if err != nil {
+		return err
+	}
+	j.w.WriteString(`],"closing_balance_pennies":`)
+	j.w.Write(closing)
+	j.w.WriteString("}\n")
+	return j.w.Flush()
+}
@@ -0,0 +1,126 @@
+package statements
+
+import (
+	"bufio"
+	"fastfunds/internal/models"
+	"fastfunds/internal/util"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(Format{Name: "mt940", ContentType: "text/plain; charset=us-ascii", Extension: "sta",
+		New: func(w io.Writer) Writer { return &mt940Writer{w: bufio.NewWriter(w)} }})
+}
+
+const (
+	// mt940LineLength is the longest line SWIFT allows in a field,
+	// counting the tag.
+	mt940LineLength = 65
+	// mt940InfoLines is how many lines :86: may span.
+	mt940InfoLines = 6
+)
+
+// mt940Writer writes the text block of a SWIFT MT940 customer statement,
+// the tag lines the ERP imports read, ending with "-". Both balances are
+// written with the statement's currency; lines are CRLF-terminated.
+type mt940Writer struct {
+	w        *bufio.Writer
+	currency string
+}
+
+// swiftText replaces the characters outside the SWIFT X character set with
+// spaces. A line may not start with ':' or '-', which would be read as a
+// new tag or the end of the message, so those are replaced at start.
+func swiftText(s string) string {
+	b := []byte(strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("/-?:().,'+ ", r):
+		default:
+			return ' '
+		}
+		return r
+	}, s))
+	if len(b) > 0 && (b[0] == ':' || b[0] == '-') {
+		b[0] = '.'
+	}
+	return string(b)
+}
+
+// mt940Amount writes an unsigned amount with a decimal comma, as SWIFT
+// does, and returns the C/D mark for its sign.
+func mt940Amount(pennies int64) (string, string) {
+	mark := "C"
+	if pennies < 0 {
+		mark = "D"
+		pennies = -pennies
+	}
+	return strings.Replace(util.PenniesToDecimalString(pennies), ".", ",", 1), mark
+}
+
+func mt940Date(t time.Time) string {
+	return t.UTC().Format("060102")
+}
+
+func (m *mt940Writer) line(tag, value string) {
+	m.w.WriteString(":" + tag + ":" + value + "\r\n")
+}
+
+func (m *mt940Writer) balance(tag string, pennies int64, at time.Time) {
+	amount, mark := mt940Amount(pennies)
+	m.line(tag, mark+mt940Date(at)+m.currency+amount)
+}
+
+func (m *mt940Writer) Begin(s *models.Statement) error {
+	m.currency = s.Currency
+	// The reference is limited to 16 characters and the statement number
+	// to 5 digits; the day of the year numbers daily statements in order.
+	m.line("20", "STMT"+s.GeneratedAt.UTC().Format("060102150405"))
+	m.line("25", truncate(swiftText(s.AccountNumber), 35))
+	m.line("28C", fmt.Sprintf("%d/1", s.From.UTC().YearDay()))
+	m.balance("60F", s.OpeningBalancePennies, s.From)
+	return nil
+}
+
+func (m *mt940Writer) Line(l *models.StatementLine) error {
+	amount, mark := mt940Amount(l.AmountPennies)
+	// Value date, entry date, mark, amount, transaction type NTRF (a
+	// non-SWIFT transfer), then our reference after "//". The customer
+	// has no reference of their own for a transfer, so it is NONREF.
+	m.line("61", mt940Date(l.PostedAt)+l.PostedAt.UTC().Format("0102")+mark+amount+
+		"NTRFNONREF//"+strconv.Itoa(l.TransactionID))
+
+	text := swiftText(Description(l))
+	first := mt940LineLength - len(":86:")
+	if len(text) <= first {
+		m.line("86", text)
+		return nil
+	}
+	m.line("86", text[:first])
+	text = text[first:]
+	for n := 1; n < mt940InfoLines && text != ""; n++ {
+		part := truncate(text, mt940LineLength)
+		m.w.WriteString(swiftText(part) + "\r\n")
+		text = text[len(part):]
+	}
+	return nil
+}
+
+func (m *mt940Writer) End(s *models.Statement) error {
+	// To is exclusive, so a period ending at midnight closes on the day
+	// before.
+	m.balance("62F", s.ClosingBalancePennies, s.To.Add(-time.Nanosecond))
+	m.w.WriteString("-\r\n")
+	return m.w.Flush()
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package statements
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fastfunds/internal/models"
+	"fastfunds/internal/util"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(Format{Name: "ofx", ContentType: "application/x-ofx", Extension: "ofx",
+		New: func(w io.Writer) Writer { return &ofxWriter{w: bufio.NewWriter(w)} }})
+}
+
+// ofxMaxName is the length limit OFX puts on STMTTRN NAME.
+const ofxMaxName = 32
+
+// ofxWriter writes an OFX 2.2 bank statement response. Transfers become
+// STMTTRN elements inside BANKTRANLIST, and the closing balance is
+// written as LEDGERBAL after the list.
+type ofxWriter struct {
+	w *bufio.Writer
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxText escapes s for use as element content.
+func ofxText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func (o *ofxWriter) Begin(s *models.Statement) error {
+	_, err := fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, ofxTime(s.GeneratedAt), ofxText(s.Currency), ofxText(s.BankID), ofxText(s.AccountNumber),
+		ofxTime(s.From), ofxTime(s.To))
+	return err
+}
+
+func (o *ofxWriter) Line(l *models.StatementLine) error {
+	trnType := "CREDIT"
+	if l.AmountPennies < 0 {
+		trnType = "DEBIT"
+	}
+	name := Description(l)
+	if len(name) > ofxMaxName {
+		name = name[:ofxMaxName]
+	}
+	_, err := fmt.Fprintf(o.w,
+		"<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME></STMTTRN>\n",
+		trnType, ofxTime(l.PostedAt), util.PenniesToDecimalString(l.AmountPennies),
+		strconv.Itoa(l.TransactionID), ofxText(name))
+	return err
+}
+
+func (o *ofxWriter) End(s *models.Statement) error {
+	fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`, util.PenniesToDecimalString(s.ClosingBalancePennies), ofxTime(s.To))
+	return o.w.Flush()
+}
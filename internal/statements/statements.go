@@ -0,0 +1,65 @@
+// Package statements renders account statements. Writers are fed the
+// statement one line at a time, so a statement of any length is written
+// without being held in memory.
+package statements
+
+import (
+	"fastfunds/internal/models"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Writer renders one statement. Begin is called once, Line once per
+// transfer in posting order, and End once after the last line.
+type Writer interface {
+	Begin(s *models.Statement) error
+	Line(l *models.StatementLine) error
+	End(s *models.Statement) error
+}
+
+// Format is an output format statements can be written in.
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	New         func(w io.Writer) Writer
+}
+
+var formats = map[string]Format{}
+
+// Register makes a format available by name. It panics if the name is
+// taken, since formats are registered at init.
+func Register(f Format) {
+	if _, dup := formats[f.Name]; dup {
+		panic("statements: format " + f.Name + " registered twice")
+	}
+	formats[f.Name] = f
+}
+
+// Lookup returns the format with the given name.
+func Lookup(name string) (Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown statement format %q", name)
+	}
+	return f, nil
+}
+
+// Names lists the registered formats in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Description is how a line is described where a format has room for text.
+func Description(l *models.StatementLine) string {
+	if l.AmountPennies < 0 {
+		return "Transfer to " + l.CounterpartyAccountNumber
+	}
+	return "Transfer from " + l.CounterpartyAccountNumber
+}
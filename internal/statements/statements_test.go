@@ -0,0 +1,166 @@
+package statements
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fastfunds/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func render(t *testing.T, format string, lines ...*models.StatementLine) string {
+	t.Helper()
+	f, err := Lookup(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &models.Statement{
+		AccountNumber: "FF17FAST4821930576", HolderName: "Ada & Co", Currency: "GBP", BankID: "FASTFUNDS",
+		From:                  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:                    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		GeneratedAt:           time.Date(2024, 2, 2, 9, 0, 0, 0, time.UTC),
+		OpeningBalancePennies: 10000,
+	}
+	s.ClosingBalancePennies = s.OpeningBalancePennies
+	if len(lines) > 0 {
+		s.ClosingBalancePennies = lines[len(lines)-1].BalancePennies
+	}
+	var buf bytes.Buffer
+	w := f.New(&buf)
+	assert.NoError(t, w.Begin(s))
+	for _, l := range lines {
+		assert.NoError(t, w.Line(l))
+	}
+	assert.NoError(t, w.End(s))
+	return buf.String()
+}
+
+var testLines = []*models.StatementLine{
+	{TransactionID: 31, PostedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), AmountPennies: -1250, BalancePennies: 8750,
+		CounterpartyAccountNumber: "FF14FAST7305618249"},
+	{TransactionID: 40, PostedAt: time.Date(2024, 1, 9, 12, 30, 0, 0, time.UTC), AmountPennies: 500, BalancePennies: 9250,
+		CounterpartyAccountNumber: "FF14FAST7305618249"},
+}
+
+func TestLookup(t *testing.T) {
+	assert.Equal(t, []string{"camt053", "csv", "json", "mt940", "ofx"}, Names())
+	_, err := Lookup("pdf")
+	assert.EqualError(t, err, `unknown statement format "pdf"`)
+}
+
+func TestCSV(t *testing.T) {
+	assert.Equal(t, "date,transaction_id,description,counterparty,amount,balance\n"+
+		"2024-01-01T00:00:00Z,,Opening balance,,,100.00\n"+
+		"2024-01-02T10:00:00Z,31,Transfer to FF14FAST7305618249,FF14FAST7305618249,-12.50,87.50\n"+
+		"2024-01-09T12:30:00Z,40,Transfer from FF14FAST7305618249,FF14FAST7305618249,5.00,92.50\n"+
+		"2024-02-01T00:00:00Z,,Closing balance,,,92.50\n", render(t, "csv", testLines...))
+}
+
+func TestJSON(t *testing.T) {
+	for _, lines := range [][]*models.StatementLine{nil, testLines} {
+		var out struct {
+			HolderName   string `json:"holder_name"`
+			Opening      int64  `json:"opening_balance_pennies"`
+			Closing      int64  `json:"closing_balance_pennies"`
+			Transactions []struct {
+				TransactionID int   `json:"transaction_id"`
+				Balance       int64 `json:"balance_pennies"`
+			} `json:"transactions"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(render(t, "json", lines...)), &out))
+		assert.Equal(t, "Ada & Co", out.HolderName)
+		assert.Equal(t, int64(10000), out.Opening)
+		assert.Len(t, out.Transactions, len(lines))
+		if len(lines) > 0 {
+			assert.Equal(t, 40, out.Transactions[1].TransactionID)
+			assert.Equal(t, int64(9250), out.Closing)
+		}
+	}
+}
+
+func TestOFX(t *testing.T) {
+	out := render(t, "ofx", testLines...)
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0"`))
+	assert.Contains(t, out, "<CURDEF>GBP</CURDEF>")
+	assert.Contains(t, out, "<DTSTART>20240101000000.000[0:GMT]</DTSTART>")
+	assert.Contains(t, out, "<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240102100000.000[0:GMT]</DTPOSTED>"+
+		"<TRNAMT>-12.50</TRNAMT><FITID>31</FITID><NAME>Transfer to FF14FAST7305618249</NAME></STMTTRN>")
+	assert.Contains(t, out, "<TRNTYPE>CREDIT</TRNTYPE>")
+	assert.Contains(t, out, "<NAME>Transfer from FF14FAST7305618249</NAME>")
+	assert.Contains(t, out, "<LEDGERBAL><BALAMT>92.50</BALAMT>")
+}
+
+func TestCamt053(t *testing.T) {
+	out := render(t, "camt053", testLines...)
+
+	var doc struct {
+		XMLName xml.Name
+		Stmt    struct {
+			ID       string `xml:"Id"`
+			Balances []struct {
+				Code   string `xml:"Tp>CdOrPrtry>Cd"`
+				Amount string `xml:"Amt"`
+				Ind    string `xml:"CdtDbtInd"`
+			} `xml:"Bal"`
+			Entries []struct {
+				Amount   string `xml:"Amt"`
+				Ind      string `xml:"CdtDbtInd"`
+				Ref      string `xml:"AcctSvcrRef"`
+				Family   string `xml:"BkTxCd>Domn>Fmly>Cd"`
+				Creditor string `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct>Id>Othr>Id"`
+				Debtor   string `xml:"NtryDtls>TxDtls>RltdPties>DbtrAcct>Id>Othr>Id"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	if !assert.NoError(t, xml.Unmarshal([]byte(out), &doc)) {
+		return
+	}
+	assert.Equal(t, Camt053Namespace, doc.XMLName.Space)
+	assert.Equal(t, "FF17FAST4821930576-20240101T0000", doc.Stmt.ID)
+	assert.LessOrEqual(t, len(doc.Stmt.ID), 35)
+	if assert.Len(t, doc.Stmt.Balances, 2) {
+		assert.Equal(t, "OPBD", doc.Stmt.Balances[0].Code)
+		assert.Equal(t, "100.00", doc.Stmt.Balances[0].Amount)
+		assert.Equal(t, "CLBD", doc.Stmt.Balances[1].Code)
+		assert.Equal(t, "92.50", doc.Stmt.Balances[1].Amount)
+	}
+	if assert.Len(t, doc.Stmt.Entries, 2) {
+		assert.Equal(t, "12.50", doc.Stmt.Entries[0].Amount)
+		assert.Equal(t, "DBIT", doc.Stmt.Entries[0].Ind)
+		assert.Equal(t, "ICDT", doc.Stmt.Entries[0].Family)
+		assert.Equal(t, "31", doc.Stmt.Entries[0].Ref)
+		assert.Equal(t, "FF14FAST7305618249", doc.Stmt.Entries[0].Creditor)
+		assert.Equal(t, "CRDT", doc.Stmt.Entries[1].Ind)
+		assert.Equal(t, "FF14FAST7305618249", doc.Stmt.Entries[1].Debtor)
+	}
+	assert.Contains(t, out, `<Amt Ccy="GBP">12.50</Amt>`)
+}
+
+func TestMT940(t *testing.T) {
+	out := render(t, "mt940", testLines...)
+	assert.Equal(t, ":20:STMT240202090000\r\n"+
+		":25:FF17FAST4821930576\r\n"+
+		":28C:1/1\r\n"+
+		":60F:C240101GBP100,00\r\n"+
+		":61:2401020102D12,50NTRFNONREF//31\r\n"+
+		":86:Transfer to FF14FAST7305618249\r\n"+
+		":61:2401090109C5,00NTRFNONREF//40\r\n"+
+		":86:Transfer from FF14FAST7305618249\r\n"+
+		":62F:C240131GBP92,50\r\n"+
+		"-\r\n", out)
+
+	long := &models.StatementLine{TransactionID: 41, PostedAt: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		AmountPennies: -100, BalancePennies: -100, CounterpartyAccountNumber: strings.Repeat("Ä", 49) + ":-x"}
+	out = render(t, "mt940", long)
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 65, line)
+		assert.NotRegexp(t, `[^A-Za-z0-9/\-?:().,'+ ]`, line)
+	}
+	assert.Contains(t, out, ":86:Transfer to    ")
+	assert.Contains(t, out, "\r\n.-x\r\n")
+	assert.Contains(t, out, ":62F:D240131GBP1,00\r\n")
+}
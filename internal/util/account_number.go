@@ -0,0 +1,181 @@
+package util
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	AccountNumberFormatMod97 = "mod97"
+	AccountNumberFormatLuhn  = "luhn"
+)
+
+// Validation errors, distinguishable so callers can answer 400 rather than 404.
+var (
+	ErrInvalidAccountNumber     = errors.New("invalid account number format")
+	ErrAccountNumberCheckDigits = errors.New("invalid account number check digits")
+)
+
+// AccountNumberScheme generates and validates external account numbers.
+type AccountNumberScheme interface {
+	Generate() (string, error)
+	// Normalize uppercases and strips spaces so numbers can be compared.
+	Normalize(number string) string
+	Validate(number string) error
+}
+
+// NewAccountNumberScheme builds a scheme from configuration.
+//   - mod97: country + 2 IBAN check digits + bankCode + random digits, e.g. FF97FAST0123456789
+//   - luhn: bankCode + random digits + 1 Luhn check digit; bankCode must be numeric
+func NewAccountNumberScheme(format, country, bankCode string, digits int) (AccountNumberScheme, error) {
+	if digits <= 0 {
+		digits = 10
+	}
+	switch format {
+	case "", AccountNumberFormatMod97:
+		country = strings.ToUpper(country)
+		if country == "" {
+			country = "FF"
+		}
+		if len(country) != 2 || !isUpperAlpha(country) {
+			return nil, errors.New("account number country must be two letters")
+		}
+		if bankCode == "" {
+			bankCode = "FAST"
+		}
+		bankCode = strings.ToUpper(bankCode)
+		if !isAlnum(bankCode) {
+			return nil, errors.New("account number bank code must be alphanumeric")
+		}
+		return &mod97Scheme{country: country, bankCode: bankCode, digits: digits}, nil
+	case AccountNumberFormatLuhn:
+		if !isDigits(bankCode) && bankCode != "" {
+			return nil, errors.New("luhn account number bank code must be numeric")
+		}
+		return &luhnScheme{bankCode: bankCode, digits: digits}, nil
+	default:
+		return nil, fmt.Errorf("unknown account number format %q", format)
+	}
+}
+
+// DefaultAccountNumberScheme is the mod97 scheme with default settings.
+func DefaultAccountNumberScheme() AccountNumberScheme {
+	s, _ := NewAccountNumberScheme(AccountNumberFormatMod97, "", "", 0)
+	return s
+}
+
+type mod97Scheme struct {
+	country  string
+	bankCode string
+	digits   int
+}
+
+func (s *mod97Scheme) Generate() (string, error) {
+	body, err := randomDigits(s.digits)
+	if err != nil {
+		return "", err
+	}
+	bban := s.bankCode + body
+	check, err := IBANCheckDigits(s.country, bban)
+	if err != nil {
+		return "", err
+	}
+	return s.country + check + bban, nil
+}
+
+func (s *mod97Scheme) Normalize(number string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(number), " ", ""))
+}
+
+func (s *mod97Scheme) Validate(number string) error {
+	n := s.Normalize(number)
+	if len(n) != 4+len(s.bankCode)+s.digits ||
+		!strings.HasPrefix(n, s.country) ||
+		n[4:4+len(s.bankCode)] != s.bankCode ||
+		!isDigits(n[2:4]) || !isDigits(n[4+len(s.bankCode):]) {
+		return ErrInvalidAccountNumber
+	}
+	if !IBANChecksumValid(n) {
+		return ErrAccountNumberCheckDigits
+	}
+	return nil
+}
+
+type luhnScheme struct {
+	bankCode string
+	digits   int
+}
+
+func (s *luhnScheme) Generate() (string, error) {
+	body, err := randomDigits(s.digits)
+	if err != nil {
+		return "", err
+	}
+	payload := s.bankCode + body
+	check, err := LuhnCheckDigit(payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + string(check), nil
+}
+
+func (s *luhnScheme) Normalize(number string) string {
+	return strings.ReplaceAll(strings.TrimSpace(number), " ", "")
+}
+
+func (s *luhnScheme) Validate(number string) error {
+	n := s.Normalize(number)
+	if len(n) != len(s.bankCode)+s.digits+1 || !strings.HasPrefix(n, s.bankCode) || !isDigits(n) {
+		return ErrInvalidAccountNumber
+	}
+	if !LuhnValid(n) {
+		return ErrAccountNumberCheckDigits
+	}
+	return nil
+}
+
+func randomDigits(n int) (string, error) {
+	b := make([]byte, n)
+	ten := big.NewInt(10)
+	for i := range b {
+		d, err := rand.Int(rand.Reader, ten)
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + d.Int64())
+	}
+	return string(b), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isUpperAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9') && !(r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLuhn(t *testing.T) {
+	d, err := LuhnCheckDigit("7992739871")
+	assert.NoError(t, err)
+	assert.Equal(t, byte('3'), d)
+	assert.True(t, LuhnValid("79927398713"))
+	assert.False(t, LuhnValid("79927398710"))
+	assert.False(t, LuhnValid("7"))
+	_, err = LuhnCheckDigit("12a")
+	assert.Error(t, err)
+}
+
+func TestIBANCheckDigits(t *testing.T) {
+	check, err := IBANCheckDigits("GB", "WEST12345698765432")
+	assert.NoError(t, err)
+	assert.Equal(t, "82", check)
+	assert.True(t, IBANChecksumValid("GB82WEST12345698765432"))
+	assert.False(t, IBANChecksumValid("GB83WEST12345698765432"))
+	assert.False(t, IBANChecksumValid("GB8"))
+	assert.False(t, IBANChecksumValid("GB82WEST1234-698765432"))
+}
+
+func TestAccountNumberScheme(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   string
+		country  string
+		bankCode string
+		digits   int
+		valid    string
+		invalid  map[string]string
+	}{
+		{
+			name:  "mod97_default",
+			valid: "FF17FAST4821930576",
+			invalid: map[string]string{
+				"FF18FAST4821930576": "invalid account number check digits",
+				"FF17FAST482193057":  "invalid account number format",
+				"GB17FAST4821930576": "invalid account number format",
+				"FF17BANK4821930576": "invalid account number format",
+				"FF17FAST48219305X6": "invalid account number format",
+				"":                   "invalid account number format",
+			},
+		},
+		{
+			name:     "luhn",
+			format:   AccountNumberFormatLuhn,
+			bankCode: "400",
+			digits:   6,
+			valid:    "4001234568",
+			invalid: map[string]string{
+				"4001234567": "invalid account number check digits",
+				"5001234568": "invalid account number format",
+				"400123456":  "invalid account number format",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewAccountNumberScheme(tc.format, tc.country, tc.bankCode, tc.digits)
+			assert.NoError(t, err)
+
+			assert.NoError(t, s.Validate(tc.valid))
+			for in, want := range tc.invalid {
+				assert.EqualError(t, s.Validate(in), want, in)
+			}
+
+			for i := 0; i < 20; i++ {
+				n, err := s.Generate()
+				assert.NoError(t, err)
+				assert.NoError(t, s.Validate(n), n)
+			}
+		})
+	}
+}
+
+func TestAccountNumberScheme_Normalize(t *testing.T) {
+	s := DefaultAccountNumberScheme()
+	assert.Equal(t, "FF17FAST4821930576", s.Normalize(" ff17 fast 4821 9305 76 "))
+	assert.NoError(t, s.Validate("ff17 fast 4821 9305 76"))
+}
+
+func TestNewAccountNumberScheme_InvalidConfig(t *testing.T) {
+	_, err := NewAccountNumberScheme("base64", "", "", 0)
+	assert.Error(t, err)
+	_, err = NewAccountNumberScheme(AccountNumberFormatMod97, "F1", "", 0)
+	assert.Error(t, err)
+	_, err = NewAccountNumberScheme(AccountNumberFormatMod97, "FF", "FA-ST", 0)
+	assert.Error(t, err)
+	_, err = NewAccountNumberScheme(AccountNumberFormatLuhn, "", "AB", 0)
+	assert.Error(t, err)
+}
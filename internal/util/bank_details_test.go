@@ -0,0 +1,98 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	cases := []struct {
+		in      string
+		wantErr error
+	}{
+		{"GB82WEST12345698765432", nil},
+		{"gb82 west 1234 5698 7654 32", nil},
+		{"DE89370400440532013000", nil},
+		{"NO9386011117947", nil},
+		{"GB82WEST1234569876543", ErrInvalidIBANLength},
+		{"GB83WEST12345698765432", ErrInvalidIBANCheckDigits},
+		{"ZZ82WEST12345698765432", ErrUnsupportedIBANCountry},
+		{"GB82-WEST", ErrInvalidIBAN},
+		{"", ErrInvalidIBAN},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			assert.Equal(t, tc.wantErr, ValidateIBAN(tc.in))
+		})
+	}
+}
+
+func TestFormatIBAN(t *testing.T) {
+	assert.Equal(t, "GB82 WEST 1234 5698 7654 32", FormatIBAN("gb82west12345698765432"))
+	assert.Equal(t, "NO93 8601 1117 947", FormatIBAN("NO9386011117947"))
+}
+
+func TestValidateBIC(t *testing.T) {
+	assert.NoError(t, ValidateBIC("DEUTDEFF"))
+	assert.NoError(t, ValidateBIC("deutdeff500"))
+	assert.Equal(t, ErrInvalidBIC, ValidateBIC("DEUTDEF"))
+	assert.Equal(t, ErrInvalidBIC, ValidateBIC("DEU1DEFF"))
+}
+
+func TestValidateABARoutingNumber(t *testing.T) {
+	assert.NoError(t, ValidateABARoutingNumber("011000015"))
+	assert.NoError(t, ValidateABARoutingNumber(" 021000021 "))
+	assert.Equal(t, ErrRoutingNumberCheckDigit, ValidateABARoutingNumber("021000022"))
+	assert.Equal(t, ErrInvalidRoutingNumber, ValidateABARoutingNumber("02100002"))
+	assert.Equal(t, ErrInvalidRoutingNumber, ValidateABARoutingNumber("02100002a"))
+}
+
+const testSortCodeRules = `
+089000 089999 MOD10    0    0    0    0    0    0    7    1    3    7    1    3    7    1
+107000 107999 MOD11    0    0    0    0    0    0    8    7    6    5    4    3    2    1
+202900 202999 DBLAL    2    1    2    1    2    1    2    1    2    1    2    1    2    1
+400000 400099 MOD10    0    0    0    0    0    0    7    1    3    7    1    3    7    1
+400000 400099 MOD11    0    0    0    0    0    0    8    7    6    5    4    3    2    1
+500000 500099 MOD11    0    0    0    0    0    0    8    7    6    5    4    3    2    1   4
+600000 600099 MOD11    0    0    0    0    0    0    8    7    6    5    4    3    2    1   7
+700000 700099 MOD11    0    0    0    0    0    0    8    7    6    5    4    3    2    1  12
+`
+
+func TestSortCodeValidator(t *testing.T) {
+	v, err := ParseSortCodeRules(strings.NewReader(testSortCodeRules))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		sortCode string
+		account  string
+		wantErr  error
+	}{
+		{"mod10", "08-99-99", "66374958", nil},
+		{"mod10_fail", "089999", "66374959", ErrUKModulusCheck},
+		{"mod11", "107999", "88837491", nil},
+		{"dblal", "20 29 59", "63748472", nil},
+		{"both_checks_must_pass", "400050", "66374958", ErrUKModulusCheck},
+		{"exception_4_remainder_is_gh", "500001", "00003704", nil},
+		{"exception_7_zeroises_when_g_is_9", "600001", "10001090", nil},
+		{"unsupported_exception_format_only", "700001", "12345678", nil},
+		{"unlisted_sort_code", "999999", "1234567", nil},
+		{"bad_sort_code", "12345", "12345678", ErrInvalidSortCode},
+		{"bad_account", "089999", "12345", ErrInvalidUKAccountNumber},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantErr, v.Validate(tc.sortCode, tc.account))
+		})
+	}
+
+	var none *SortCodeValidator
+	assert.NoError(t, none.Validate("089999", "66374959"))
+
+	_, err = ParseSortCodeRules(strings.NewReader("089000 089999 MOD12 0 0 0 0 0 0 7 1 3 7 1 3 7 1"))
+	assert.Error(t, err)
+	_, err = ParseSortCodeRules(strings.NewReader("089000 089999 MOD10 0 0"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"errors"
+	"strings"
+)
+
+// LuhnCheckDigit returns the Luhn check digit for a string of decimal digits.
+func LuhnCheckDigit(digits string) (byte, error) {
+	sum := 0
+	double := true // the check digit will be appended, so the rightmost payload digit is doubled
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return 0, errors.New("luhn input must be digits")
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// LuhnValid reports whether the last digit of s is a valid Luhn check digit.
+func LuhnValid(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	check, err := LuhnCheckDigit(s[:len(s)-1])
+	return err == nil && check == s[len(s)-1]
+}
+
+// Mod97 computes the ISO 7064 MOD 97-10 remainder of an alphanumeric string,
+// mapping letters A-Z to 10-35 as IBANs do.
+func Mod97(s string) (int, error) {
+	rem := 0
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			rem = (rem*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			rem = (rem*100 + int(r-'A'+10)) % 97
+		default:
+			return 0, errors.New("mod97 input must be alphanumeric")
+		}
+	}
+	return rem, nil
+}
+
+// IBANCheckDigits returns the two check digits for a country code and BBAN.
+func IBANCheckDigits(country, bban string) (string, error) {
+	rem, err := Mod97(bban + country + "00")
+	if err != nil {
+		return "", err
+	}
+	check := 98 - rem
+	return string([]byte{byte('0' + check/10), byte('0' + check%10)}), nil
+}
+
+// IBANChecksumValid reports whether an IBAN-style string (country code, check
+// digits, BBAN) passes the mod-97 check.
+func IBANChecksumValid(s string) bool {
+	if len(s) < 5 {
+		return false
+	}
+	rem, err := Mod97(s[4:] + s[:4])
+	return err == nil && rem == 1
+}
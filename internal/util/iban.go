@@ -0,0 +1,101 @@
+package util
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidIBAN             = errors.New("invalid IBAN format")
+	ErrUnsupportedIBANCountry  = errors.New("unsupported IBAN country")
+	ErrInvalidIBANLength       = errors.New("invalid IBAN length for country")
+	ErrInvalidIBANCheckDigits  = errors.New("invalid IBAN check digits")
+	ErrInvalidBIC              = errors.New("invalid BIC format")
+	ErrInvalidRoutingNumber    = errors.New("invalid routing number format")
+	ErrRoutingNumberCheckDigit = errors.New("invalid routing number check digit")
+)
+
+// ibanLengths is the total IBAN length per country, from the SWIFT IBAN registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27,
+	"MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24, "PL": 28,
+	"PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24, "SC": 31,
+	"SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20, "YE": 30,
+}
+
+// NormalizeIBAN strips spaces and uppercases an IBAN for storage and comparison.
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ValidateIBAN checks the country, its registered length and the mod-97
+// check digits. The input may contain spaces and lowercase letters.
+func ValidateIBAN(iban string) error {
+	n := NormalizeIBAN(iban)
+	if len(n) < 5 || !isUpperAlpha(n[:2]) || !isDigits(n[2:4]) || !isAlnum(n[4:]) {
+		return ErrInvalidIBAN
+	}
+
+	length, ok := ibanLengths[n[:2]]
+	if !ok {
+		return ErrUnsupportedIBANCountry
+	}
+	if len(n) != length {
+		return ErrInvalidIBANLength
+	}
+
+	if !IBANChecksumValid(n) {
+		return ErrInvalidIBANCheckDigits
+	}
+	return nil
+}
+
+// FormatIBAN renders an IBAN in the print format: groups of four separated
+// by spaces.
+func FormatIBAN(iban string) string {
+	n := NormalizeIBAN(iban)
+	var b strings.Builder
+	for i := 0; i < len(n); i += 4 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(n[i:min(i+4, len(n))])
+	}
+	return b.String()
+}
+
+// ValidateBIC checks the shape of an 8 or 11 character SWIFT BIC: bank code,
+// country code, location and optional branch.
+func ValidateBIC(bic string) error {
+	b := strings.ToUpper(strings.TrimSpace(bic))
+	if (len(b) != 8 && len(b) != 11) || !isUpperAlpha(b[:6]) || !isAlnum(b[6:]) {
+		return ErrInvalidBIC
+	}
+	return nil
+}
+
+// ValidateABARoutingNumber checks a nine-digit US ABA routing number against
+// its 3-7-1 weighted check digit.
+func ValidateABARoutingNumber(routing string) error {
+	routing = strings.TrimSpace(routing)
+	if len(routing) != 9 || !isDigits(routing) {
+		return ErrInvalidRoutingNumber
+	}
+
+	weights := [9]int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(routing[i]-'0') * weights[i]
+	}
+	if sum%10 != 0 {
+		return ErrRoutingNumberCheckDigit
+	}
+	return nil
+}
@@ -81,3 +81,17 @@ func SafeMulPercent(pennies int64, basisPoints int64) int64 {
 	// round half up
 	return int64(math.Round(float64(pennies*basisPoints) / 10000.0))
 }
+
+// IsCurrencyCode reports whether s has the form of an ISO 4217 code, such as
+// GBP: three upper-case letters.
+func IsCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
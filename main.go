@@ -1,13 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	_ "fastfunds/docs"
 	"fastfunds/internal/api/handlers"
+	"fastfunds/internal/auth"
+	"fastfunds/internal/calendar"
+	"fastfunds/internal/config"
+	"fastfunds/internal/events"
+	"fastfunds/internal/grpcapi"
+	"fastfunds/internal/ratelimit"
 	"fastfunds/internal/repository"
+	"fastfunds/internal/screening"
 	"fastfunds/internal/service"
+	"fastfunds/internal/util"
 	"log"
+	"net"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -20,8 +31,18 @@ import (
 // @description FastFunds API server
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer <API key or JWT>"
+// @security BearerAuth
 func main() {
-	dsn := os.Getenv("DATABASE_URL")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("invalid configuration:", err)
+	}
+
+	dsn := cfg.DatabaseURL
 	log.Print("Database URL:", dsn)
 
 	db, err := sql.Open("pgx", dsn)
@@ -37,16 +58,317 @@ func main() {
 	// Repositories init
 	accountRepo := repository.NewPostgresAccountRepository(db)
 	transactionRepo := repository.NewPostgresTransactionRepository(db)
+	screeningCaseRepo := repository.NewPostgresScreeningCaseRepository(db)
+	customerRepo := repository.NewPostgresCustomerRepository(db)
+	accountHolderRepo := repository.NewPostgresAccountHolderRepository(db)
+	externalAccountRepo := repository.NewPostgresExternalAccountRepository(db)
+	apiKeyRepo := repository.NewPostgresAPIKeyRepository(db)
+	auditLogRepo := repository.NewPostgresAuditLogRepository(db)
+	outboxRepo := repository.NewPostgresOutboxRepository(db)
+	webhookRepo := repository.NewPostgresWebhookRepository(db)
+	activityRepo := repository.NewPostgresActivityRepository(db)
+	transferImportRepo := repository.NewPostgresTransferImportRepository(db)
+	paymentInitiationRepo := repository.NewPostgresPaymentInitiationRepository(db)
+	payoutRepo := repository.NewPostgresPayoutRepository(db)
+	reconciliationRepo := repository.NewPostgresReconciliationRepository(db)
+	balanceRepo := repository.NewPostgresBalanceRepository(db)
+	businessDayRepo := repository.NewPostgresBusinessDayRepository(db)
+	ledgerRepo := repository.NewPostgresLedgerRepository(db)
+	productRepo := repository.NewPostgresProductRepository(db)
+	escrowRepo := repository.NewPostgresEscrowRepository(db)
+	disputeRepo := repository.NewPostgresDisputeRepository(db)
+	chargeRepo := repository.NewPostgresChargeRepository(db)
+
+	// Authentication init
+	authenticator := auth.Chain{auth.NewAPIKeyAuthenticator(apiKeyRepo)}
+	jwtKeys, err := loadJWTKeys(cfg)
+	if err != nil {
+		log.Fatal("invalid JWT settings:", err)
+	}
+	if !jwtKeys.Empty() {
+		authenticator = append(authenticator, auth.NewJWTAuthenticator(jwtKeys, cfg.JWTIssuer, cfg.JWTAudience))
+	} else {
+		log.Print("no JWT keys configured, only API keys are accepted")
+	}
+
+	// Account number scheme init
+	numbers, err := util.NewAccountNumberScheme(cfg.AccountNumberFormat, cfg.AccountNumberCountry, cfg.AccountNumberBankCode, cfg.AccountNumberDigits)
+	if err != nil {
+		log.Fatal("invalid account number settings:", err)
+	}
+
+	// Account activity streams are woken by Postgres notifications
+	activityHub := events.NewActivityHub()
+
+	// Authorization: roles, and account ownership for customers
+	policy := service.NewRolePolicy(accountHolderRepo)
+
+	accountOpts := []func(*service.AccountService){
+		service.WithAccountHolders(accountHolderRepo, customerRepo),
+		service.WithAccountNumberScheme(numbers),
+		service.WithAccountPolicy(policy),
+		service.WithAccountAuditLog(auditLogRepo),
+		service.WithAccountEvents(outboxRepo),
+		service.WithAccountActivity(activityRepo, activityHub),
+		service.WithAccountProducts(productRepo),
+	}
+	transactionOpts := []func(*service.TransactionService){
+		service.WithTransferAccountNumberScheme(numbers),
+		service.WithTransferPolicy(policy),
+		service.WithTransferAuditLog(auditLogRepo),
+		service.WithTransferEvents(outboxRepo),
+		service.WithTransferActivity(activityRepo),
+		service.WithStatements(cfg.StatementBankID),
+	}
+
+	// Domain events always feed webhook subscriptions; this publisher is optional
+	publisher, err := newEventPublisher(cfg)
+	if err != nil {
+		log.Fatal("invalid event publisher settings:", err)
+	}
+
+	// Sanctions list init
+	if cfg.SanctionsListPath != "" {
+		list, err := screening.LoadFile(cfg.SanctionsListPath)
+		if err != nil {
+			log.Fatal("failed to load sanctions list:", err)
+		}
+		log.Printf("Loaded %d sanctions list entries from %s", len(list.Entries), cfg.SanctionsListPath)
+		screener := screening.NewListScreener(list, cfg.ScreeningThreshold)
+		accountOpts = append(accountOpts, service.WithAccountScreening(screener, screeningCaseRepo))
+		transactionOpts = append(transactionOpts, service.WithTransferScreening(screener, screeningCaseRepo))
+	} else {
+		log.Print("SANCTIONS_LIST_PATH not set, sanctions screening disabled")
+	}
+
+	externalAccountOpts := []func(*service.ExternalAccountService){
+		service.WithExternalAccountPolicy(policy),
+		service.WithExternalAccountAuditLog(auditLogRepo),
+	}
+	if cfg.SortCodeRulesPath != "" {
+		rules, err := util.LoadSortCodeRules(cfg.SortCodeRulesPath)
+		if err != nil {
+			log.Fatal("failed to load sort code rules:", err)
+		}
+		externalAccountOpts = append(externalAccountOpts, service.WithSortCodeRules(rules))
+	} else {
+		log.Print("SORT_CODE_RULES_PATH not set, UK modulus checking disabled")
+	}
+
+	if cfg.ApprovalThresholdPennies > 0 {
+		transactionOpts = append(transactionOpts, service.WithApprovalThreshold(cfg.ApprovalThresholdPennies, cfg.ApprovalTTL))
+	}
+
+	payoutOpts := []func(*service.PayoutService){
+		service.WithPayoutPolicy(policy),
+		service.WithPayoutAuditLog(auditLogRepo),
+	}
+	if cfg.ACHClearingAccount != "" {
+		clearing, err := accountRepo.GetByNumber(numbers.Normalize(cfg.ACHClearingAccount))
+		if err != nil {
+			log.Fatal("ACH_CLEARING_ACCOUNT:", err)
+		}
+		payoutOpts = append(payoutOpts, service.WithACH(cfg.ACHOriginator, clearing.AccountID, cfg.ACHOutputDir))
+	} else {
+		log.Print("ACH_CLEARING_ACCOUNT not set, ACH payouts disabled")
+	}
+
+	disputeOpts := []func(*service.DisputeService){
+		service.WithDisputePolicy(policy),
+		service.WithDisputeAuditLog(auditLogRepo),
+	}
+	if cfg.DisputeSuspenseAccount != "" {
+		suspense, err := accountRepo.GetByNumber(numbers.Normalize(cfg.DisputeSuspenseAccount))
+		if err != nil {
+			log.Fatal("DISPUTE_SUSPENSE_ACCOUNT:", err)
+		}
+		disputeOpts = append(disputeOpts, service.WithDisputeSuspenseAccount(suspense.AccountID))
+	} else {
+		log.Print("DISPUTE_SUSPENSE_ACCOUNT not set, disputes disabled")
+	}
+
+	var chargeOpts []func(*service.ChargeService)
+	if cfg.FeeIncomeAccount != "" {
+		feeIncome, err := accountRepo.GetByNumber(numbers.Normalize(cfg.FeeIncomeAccount))
+		if err != nil {
+			log.Fatal("FEE_INCOME_ACCOUNT:", err)
+		}
+		transactionOpts = append(transactionOpts, service.WithTransferFees(feeIncome.AccountID))
+		chargeOpts = append(chargeOpts, service.WithMonthlyFees(feeIncome.AccountID))
+	} else {
+		log.Print("FEE_INCOME_ACCOUNT not set, fees not charged")
+	}
+	if cfg.InterestExpenseAccount != "" {
+		interestExpense, err := accountRepo.GetByNumber(numbers.Normalize(cfg.InterestExpenseAccount))
+		if err != nil {
+			log.Fatal("INTEREST_EXPENSE_ACCOUNT:", err)
+		}
+		chargeOpts = append(chargeOpts, service.WithInterest(interestExpense.AccountID))
+	} else {
+		log.Print("INTEREST_EXPENSE_ACCOUNT not set, interest not paid")
+	}
+
+	var holidays []time.Time
+	if cfg.HolidaysPath != "" {
+		if holidays, err = calendar.LoadHolidays(cfg.HolidaysPath); err != nil {
+			log.Fatal("failed to load holidays:", err)
+		}
+	} else {
+		log.Print("HOLIDAYS_PATH not set, only weekends are non-business days")
+	}
+	businessDays := calendar.New(cfg.BusinessDayCutoff, holidays...)
 
 	// Services init
-	accountService := service.NewAccountService(accountRepo)
-	transactionService := service.NewTransactionService(db, accountRepo, transactionRepo)
+	accountService := service.NewAccountService(db, accountRepo, accountOpts...)
+	transactionService := service.NewTransactionService(db, accountRepo, transactionRepo, transactionOpts...)
+	screeningService := service.NewScreeningService(db, screeningCaseRepo, transactionService,
+		service.WithScreeningPolicy(policy), service.WithScreeningAuditLog(auditLogRepo))
+	customerService := service.NewCustomerService(db, customerRepo, accountHolderRepo,
+		service.WithCustomerPolicy(policy), service.WithCustomerAuditLog(auditLogRepo))
+	externalAccountService := service.NewExternalAccountService(db, externalAccountRepo, customerRepo, externalAccountOpts...)
+	apiKeyService := service.NewAPIKeyService(db, apiKeyRepo, service.WithAPIKeyPolicy(policy), service.WithAPIKeyAuditLog(auditLogRepo))
+	webhookService := service.NewWebhookService(db, webhookRepo, service.WithWebhookPolicy(policy),
+		service.WithWebhookAuditLog(auditLogRepo), service.WithWebhookMaxAttempts(cfg.WebhookMaxAttempts))
+	importService := service.NewImportService(db, transferImportRepo, transactionService,
+		service.WithImportPolicy(policy), service.WithImportAuditLog(auditLogRepo))
+	paymentInitiationService := service.NewPaymentInitiationService(paymentInitiationRepo, transactionService,
+		service.WithPaymentInitiationPolicy(policy))
+	payoutService := service.NewPayoutService(db, payoutRepo, externalAccountRepo, accountHolderRepo, transactionService, payoutOpts...)
+	reconciliationService := service.NewReconciliationService(db, reconciliationRepo, service.WithReconciliationPolicy(policy))
+	balanceService := service.NewBalanceService(balanceRepo, service.WithBalancePolicy(policy))
+	businessDayService := service.NewBusinessDayService(db, businessDayRepo, businessDays,
+		service.WithBusinessDayPolicy(policy), service.WithBusinessDayAuditLog(auditLogRepo))
+	ledgerService := service.NewLedgerService(db, ledgerRepo,
+		service.WithLedgerPolicy(policy), service.WithLedgerAuditLog(auditLogRepo))
+	productService := service.NewProductService(db, productRepo,
+		service.WithProductPolicy(policy), service.WithProductAuditLog(auditLogRepo))
+	escrowService := service.NewEscrowService(db, escrowRepo, accountService, transactionService,
+		service.WithEscrowPolicy(policy), service.WithEscrowAuditLog(auditLogRepo))
+	disputeService := service.NewDisputeService(db, disputeRepo, transactionRepo, transactionService, disputeOpts...)
+	chargeService := service.NewChargeService(chargeRepo, transactionService, chargeOpts...)
+
+	// One-off commands, e.g. issuing the first API key
+	if len(os.Args) > 1 {
+		if err := runCommand(db, commandServices{
+			accounts:       accountService,
+			transactions:   transactionService,
+			payouts:        payoutService,
+			reconciliation: reconciliationService,
+			businessDays:   businessDayService,
+		}, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Expire unapproved transfers in the background
+	if cfg.ApprovalThresholdPennies > 0 {
+		go func() {
+			for range time.Tick(time.Minute) {
+				if n, err := transactionService.ExpirePendingApprovals(context.Background()); err != nil {
+					log.Print("failed to expire pending approvals:", err)
+				} else if n > 0 {
+					log.Printf("Expired %d pending approvals", n)
+				}
+			}
+		}()
+	}
+
+	// Relay outbox events to webhook subscriptions and the configured publisher
+	fanout := events.Fanout{webhookService}
+	if publisher != nil {
+		fanout = append(events.Fanout{publisher}, fanout...)
+		log.Printf("Publishing domain events to %s", cfg.EventPublisher)
+	}
+	relay := service.NewOutboxRelay(db, outboxRepo, fanout, service.WithRelayBatchSize(cfg.OutboxBatchSize))
+	go relay.Run(context.Background(), cfg.OutboxPollInterval)
+	go webhookService.Run(context.Background(), cfg.WebhookPollInterval)
+	go events.ListenActivity(context.Background(), db, activityHub)
+	go importService.Run(context.Background(), cfg.ImportPollInterval)
+	if cfg.ACHClearingAccount != "" {
+		go payoutService.Run(context.Background(), cfg.ACHFileTime)
+	}
+	if cfg.ReconciliationInterval > 0 {
+		go reconciliationService.Run(context.Background(), cfg.ReconciliationInterval)
+	}
+	if cfg.BalanceSnapshotInterval > 0 {
+		go balanceService.Run(context.Background(), cfg.BalanceSnapshotInterval)
+	}
+	if cfg.EndOfDayClose {
+		go businessDayService.Run(context.Background())
+	}
+	if cfg.EscrowInterval > 0 {
+		go escrowService.Run(context.Background(), cfg.EscrowInterval)
+	}
+	if len(chargeOpts) > 0 {
+		go chargeService.Run(context.Background())
+	}
+	go func() {
+		for range time.Tick(time.Hour) {
+			if _, err := outboxRepo.PrunePublished(cfg.OutboxRetention); err != nil {
+				log.Print("failed to prune published events:", err)
+			}
+			if _, err := activityRepo.Prune(cfg.ActivityRetention); err != nil {
+				log.Print("failed to prune account activity:", err)
+			}
+		}
+	}()
+
+	// Rate limiting init
+	limits := handlers.RateLimits{
+		PerIP:        cfg.RateLimitPerIP,
+		PerPrincipal: cfg.RateLimitPerPrincipal,
+		Transfers:    cfg.RateLimitTransfers,
+	}
+	if cfg.RateLimitStore == "postgres" {
+		limiter := ratelimit.NewPostgresLimiter(db)
+		limits.Limiter = limiter
+		// A bucket idle for longer than its period is full, so it can go
+		idle := max(24*time.Hour, limits.PerIP.Period, limits.PerPrincipal.Period, limits.Transfers.Period)
+		go func() {
+			for range time.Tick(time.Hour) {
+				if _, err := limiter.Prune(context.Background(), idle); err != nil {
+					log.Print("failed to prune rate limit buckets:", err)
+				}
+			}
+		}()
+	} else {
+		limits.Limiter = ratelimit.NewMemoryLimiter()
+	}
+	log.Printf("Rate limits (%s): per IP %s, per principal %s, transfers %s", cfg.RateLimitStore, limits.PerIP, limits.PerPrincipal, limits.Transfers)
+
+	// gRPC API on its own port, sharing the services, authentication and rate limits
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		log.Fatal("failed to listen for gRPC:", err)
+	}
+	grpcServer := grpcapi.NewServer(authenticator, limits, accountService, transactionService)
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal("gRPC server stopped:", err)
+		}
+	}()
+	log.Printf("gRPC API listening on %s", cfg.GRPCAddr)
+
+	// Metrics on their own port, for a scraper inside the network
+	metricsRouter := gin.New()
+	metricsRouter.Use(gin.Recovery())
+	handlers.SetupMetricsRoutes(metricsRouter, reconciliationService)
+	go func() {
+		if err := metricsRouter.Run(cfg.MetricsAddr); err != nil {
+			log.Fatal("metrics server stopped:", err)
+		}
+	}()
+	log.Printf("Metrics listening on %s", cfg.MetricsAddr)
 
 	// Init Gin router
 	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("invalid TRUSTED_PROXIES:", err)
+	}
 
 	// Setup routes
-	handlers.SetupRoutes(router, accountService, transactionService)
+	handlers.SetupRoutes(router, authenticator, limits, accountService, transactionService, screeningService, customerService, externalAccountService, apiKeyService, webhookService, importService, paymentInitiationService, payoutService, reconciliationService, balanceService, businessDayService, ledgerService, productService, escrowService, disputeService)
 
 	// Setup Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -0,0 +1,332 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: fastfunds/v1/accounts.proto
+
+package fastfundsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateAccountRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	HolderName string                 `protobuf:"bytes,1,opt,name=holder_name,json=holderName,proto3" json:"holder_name,omitempty"`
+	// Decimal amount, e.g. "100.00".
+	InitialBalance string `protobuf:"bytes,2,opt,name=initial_balance,json=initialBalance,proto3" json:"initial_balance,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateAccountRequest) Reset() {
+	*x = CreateAccountRequest{}
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccountRequest) ProtoMessage() {}
+
+func (x *CreateAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccountRequest.ProtoReflect.Descriptor instead.
+func (*CreateAccountRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_accounts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateAccountRequest) GetHolderName() string {
+	if x != nil {
+		return x.HolderName
+	}
+	return ""
+}
+
+func (x *CreateAccountRequest) GetInitialBalance() string {
+	if x != nil {
+		return x.InitialBalance
+	}
+	return ""
+}
+
+type GetAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNumber string                 `protobuf:"bytes,1,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountRequest) Reset() {
+	*x = GetAccountRequest{}
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountRequest) ProtoMessage() {}
+
+func (x *GetAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_accounts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetAccountRequest) GetAccountNumber() string {
+	if x != nil {
+		return x.AccountNumber
+	}
+	return ""
+}
+
+type Account struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNumber string                 `protobuf:"bytes,1,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	HolderName    string                 `protobuf:"bytes,2,opt,name=holder_name,json=holderName,proto3" json:"holder_name,omitempty"`
+	// Decimal amount, e.g. "100.00".
+	CurrentBalance string           `protobuf:"bytes,3,opt,name=current_balance,json=currentBalance,proto3" json:"current_balance,omitempty"`
+	Holders        []*AccountHolder `protobuf:"bytes,4,rep,name=holders,proto3" json:"holders,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_accounts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Account) GetAccountNumber() string {
+	if x != nil {
+		return x.AccountNumber
+	}
+	return ""
+}
+
+func (x *Account) GetHolderName() string {
+	if x != nil {
+		return x.HolderName
+	}
+	return ""
+}
+
+func (x *Account) GetCurrentBalance() string {
+	if x != nil {
+		return x.CurrentBalance
+	}
+	return ""
+}
+
+func (x *Account) GetHolders() []*AccountHolder {
+	if x != nil {
+		return x.Holders
+	}
+	return nil
+}
+
+type AccountHolder struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	CustomerId int64                  `protobuf:"varint,1,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	Name       string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// owner, joint or authorized_user.
+	Role          string `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AccountHolder) Reset() {
+	*x = AccountHolder{}
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountHolder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountHolder) ProtoMessage() {}
+
+func (x *AccountHolder) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_accounts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountHolder.ProtoReflect.Descriptor instead.
+func (*AccountHolder) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_accounts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AccountHolder) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *AccountHolder) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AccountHolder) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+var File_fastfunds_v1_accounts_proto protoreflect.FileDescriptor
+
+const file_fastfunds_v1_accounts_proto_rawDesc = "" +
+	"\n" +
+	"\x1bfastfunds/v1/accounts.proto\x12\ffastfunds.v1\"`\n" +
+	"\x14CreateAccountRequest\x12\x1f\n" +
+	"\vholder_name\x18\x01 \x01(\tR\n" +
+	"holderName\x12'\n" +
+	"\x0finitial_balance\x18\x02 \x01(\tR\x0einitialBalance\":\n" +
+	"\x11GetAccountRequest\x12%\n" +
+	"\x0eaccount_number\x18\x01 \x01(\tR\raccountNumber\"\xb1\x01\n" +
+	"\aAccount\x12%\n" +
+	"\x0eaccount_number\x18\x01 \x01(\tR\raccountNumber\x12\x1f\n" +
+	"\vholder_name\x18\x02 \x01(\tR\n" +
+	"holderName\x12'\n" +
+	"\x0fcurrent_balance\x18\x03 \x01(\tR\x0ecurrentBalance\x125\n" +
+	"\aholders\x18\x04 \x03(\v2\x1b.fastfunds.v1.AccountHolderR\aholders\"X\n" +
+	"\rAccountHolder\x12\x1f\n" +
+	"\vcustomer_id\x18\x01 \x01(\x03R\n" +
+	"customerId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role2\xa2\x01\n" +
+	"\x0eAccountService\x12J\n" +
+	"\rCreateAccount\x12\".fastfunds.v1.CreateAccountRequest\x1a\x15.fastfunds.v1.Account\x12D\n" +
+	"\n" +
+	"GetAccount\x12\x1f.fastfunds.v1.GetAccountRequest\x1a\x15.fastfunds.v1.AccountB*Z(fastfunds/proto/fastfunds/v1;fastfundsv1b\x06proto3"
+
+var (
+	file_fastfunds_v1_accounts_proto_rawDescOnce sync.Once
+	file_fastfunds_v1_accounts_proto_rawDescData []byte
+)
+
+func file_fastfunds_v1_accounts_proto_rawDescGZIP() []byte {
+	file_fastfunds_v1_accounts_proto_rawDescOnce.Do(func() {
+		file_fastfunds_v1_accounts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fastfunds_v1_accounts_proto_rawDesc), len(file_fastfunds_v1_accounts_proto_rawDesc)))
+	})
+	return file_fastfunds_v1_accounts_proto_rawDescData
+}
+
+var file_fastfunds_v1_accounts_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_fastfunds_v1_accounts_proto_goTypes = []any{
+	(*CreateAccountRequest)(nil), // 0: fastfunds.v1.CreateAccountRequest
+	(*GetAccountRequest)(nil),    // 1: fastfunds.v1.GetAccountRequest
+	(*Account)(nil),              // 2: fastfunds.v1.Account
+	(*AccountHolder)(nil),        // 3: fastfunds.v1.AccountHolder
+}
+var file_fastfunds_v1_accounts_proto_depIdxs = []int32{
+	3, // 0: fastfunds.v1.Account.holders:type_name -> fastfunds.v1.AccountHolder
+	0, // 1: fastfunds.v1.AccountService.CreateAccount:input_type -> fastfunds.v1.CreateAccountRequest
+	1, // 2: fastfunds.v1.AccountService.GetAccount:input_type -> fastfunds.v1.GetAccountRequest
+	2, // 3: fastfunds.v1.AccountService.CreateAccount:output_type -> fastfunds.v1.Account
+	2, // 4: fastfunds.v1.AccountService.GetAccount:output_type -> fastfunds.v1.Account
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_fastfunds_v1_accounts_proto_init() }
+func file_fastfunds_v1_accounts_proto_init() {
+	if File_fastfunds_v1_accounts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fastfunds_v1_accounts_proto_rawDesc), len(file_fastfunds_v1_accounts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_fastfunds_v1_accounts_proto_goTypes,
+		DependencyIndexes: file_fastfunds_v1_accounts_proto_depIdxs,
+		MessageInfos:      file_fastfunds_v1_accounts_proto_msgTypes,
+	}.Build()
+	File_fastfunds_v1_accounts_proto = out.File
+	file_fastfunds_v1_accounts_proto_goTypes = nil
+	file_fastfunds_v1_accounts_proto_depIdxs = nil
+}
@@ -0,0 +1,6 @@
+// Package fastfundsv1 is the generated code for the fastfunds.v1 gRPC API.
+// Edit the .proto files and regenerate with protoc, protoc-gen-go and
+// protoc-gen-go-grpc on the PATH.
+package fastfundsv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative fastfunds/v1/accounts.proto fastfunds/v1/transactions.proto
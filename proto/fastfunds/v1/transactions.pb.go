@@ -0,0 +1,504 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: fastfunds/v1/transactions.proto
+
+package fastfundsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateTransactionRequest struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	SourceAccountNumber      string                 `protobuf:"bytes,1,opt,name=source_account_number,json=sourceAccountNumber,proto3" json:"source_account_number,omitempty"`
+	DestinationAccountNumber string                 `protobuf:"bytes,2,opt,name=destination_account_number,json=destinationAccountNumber,proto3" json:"destination_account_number,omitempty"`
+	// Decimal amount, e.g. "12.50".
+	Amount        string `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransactionRequest) Reset() {
+	*x = CreateTransactionRequest{}
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransactionRequest) ProtoMessage() {}
+
+func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransactionRequest.ProtoReflect.Descriptor instead.
+func (*CreateTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_transactions_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateTransactionRequest) GetSourceAccountNumber() string {
+	if x != nil {
+		return x.SourceAccountNumber
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetDestinationAccountNumber() string {
+	if x != nil {
+		return x.DestinationAccountNumber
+	}
+	return ""
+}
+
+func (x *CreateTransactionRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+type GetTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransactionRequest) Reset() {
+	*x = GetTransactionRequest{}
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionRequest) ProtoMessage() {}
+
+func (x *GetTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_transactions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetTransactionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListTransactionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// completed, held, pending_approval, rejected, expired or failed.
+	Status        string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsRequest) Reset() {
+	*x = ListTransactionsRequest{}
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsRequest) ProtoMessage() {}
+
+func (x *ListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_transactions_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTransactionsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type GetTransactionHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNumber string                 `protobuf:"bytes,1,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	// Only transfers with a lower ID are sent. Pass the last ID received to
+	// resume an interrupted stream; 0 starts from the newest.
+	BeforeId      int64 `protobuf:"varint,2,opt,name=before_id,json=beforeId,proto3" json:"before_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransactionHistoryRequest) Reset() {
+	*x = GetTransactionHistoryRequest{}
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransactionHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionHistoryRequest) ProtoMessage() {}
+
+func (x *GetTransactionHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_transactions_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetTransactionHistoryRequest) GetAccountNumber() string {
+	if x != nil {
+		return x.AccountNumber
+	}
+	return ""
+}
+
+func (x *GetTransactionHistoryRequest) GetBeforeId() int64 {
+	if x != nil {
+		return x.BeforeId
+	}
+	return 0
+}
+
+type ReviewTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Note          string                 `protobuf:"bytes,2,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewTransactionRequest) Reset() {
+	*x = ReviewTransactionRequest{}
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewTransactionRequest) ProtoMessage() {}
+
+func (x *ReviewTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewTransactionRequest.ProtoReflect.Descriptor instead.
+func (*ReviewTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_transactions_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReviewTransactionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReviewTransactionRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+type Transaction struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	Id                       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceAccountNumber      string                 `protobuf:"bytes,2,opt,name=source_account_number,json=sourceAccountNumber,proto3" json:"source_account_number,omitempty"`
+	DestinationAccountNumber string                 `protobuf:"bytes,3,opt,name=destination_account_number,json=destinationAccountNumber,proto3" json:"destination_account_number,omitempty"`
+	AmountPennies            int64                  `protobuf:"varint,4,opt,name=amount_pennies,json=amountPennies,proto3" json:"amount_pennies,omitempty"`
+	Status                   string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	InitiatedBy              string                 `protobuf:"bytes,6,opt,name=initiated_by,json=initiatedBy,proto3" json:"initiated_by,omitempty"`
+	ReviewedBy               string                 `protobuf:"bytes,7,opt,name=reviewed_by,json=reviewedBy,proto3" json:"reviewed_by,omitempty"`
+	ReviewNote               string                 `protobuf:"bytes,8,opt,name=review_note,json=reviewNote,proto3" json:"review_note,omitempty"`
+	// RFC 3339; set while a transfer awaits approval.
+	ExpiresAt string `protobuf:"bytes,9,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// RFC 3339.
+	CreatedAt     string `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_fastfunds_v1_transactions_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_fastfunds_v1_transactions_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Transaction) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transaction) GetSourceAccountNumber() string {
+	if x != nil {
+		return x.SourceAccountNumber
+	}
+	return ""
+}
+
+func (x *Transaction) GetDestinationAccountNumber() string {
+	if x != nil {
+		return x.DestinationAccountNumber
+	}
+	return ""
+}
+
+func (x *Transaction) GetAmountPennies() int64 {
+	if x != nil {
+		return x.AmountPennies
+	}
+	return 0
+}
+
+func (x *Transaction) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Transaction) GetInitiatedBy() string {
+	if x != nil {
+		return x.InitiatedBy
+	}
+	return ""
+}
+
+func (x *Transaction) GetReviewedBy() string {
+	if x != nil {
+		return x.ReviewedBy
+	}
+	return ""
+}
+
+func (x *Transaction) GetReviewNote() string {
+	if x != nil {
+		return x.ReviewNote
+	}
+	return ""
+}
+
+func (x *Transaction) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *Transaction) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+var File_fastfunds_v1_transactions_proto protoreflect.FileDescriptor
+
+const file_fastfunds_v1_transactions_proto_rawDesc = "" +
+	"\n" +
+	"\x1ffastfunds/v1/transactions.proto\x12\ffastfunds.v1\"\xa4\x01\n" +
+	"\x18CreateTransactionRequest\x122\n" +
+	"\x15source_account_number\x18\x01 \x01(\tR\x13sourceAccountNumber\x12<\n" +
+	"\x1adestination_account_number\x18\x02 \x01(\tR\x18destinationAccountNumber\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\"'\n" +
+	"\x15GetTransactionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"1\n" +
+	"\x17ListTransactionsRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"b\n" +
+	"\x1cGetTransactionHistoryRequest\x12%\n" +
+	"\x0eaccount_number\x18\x01 \x01(\tR\raccountNumber\x12\x1b\n" +
+	"\tbefore_id\x18\x02 \x01(\x03R\bbeforeId\">\n" +
+	"\x18ReviewTransactionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04note\x18\x02 \x01(\tR\x04note\"\xf1\x02\n" +
+	"\vTransaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x122\n" +
+	"\x15source_account_number\x18\x02 \x01(\tR\x13sourceAccountNumber\x12<\n" +
+	"\x1adestination_account_number\x18\x03 \x01(\tR\x18destinationAccountNumber\x12%\n" +
+	"\x0eamount_pennies\x18\x04 \x01(\x03R\ramountPennies\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12!\n" +
+	"\finitiated_by\x18\x06 \x01(\tR\vinitiatedBy\x12\x1f\n" +
+	"\vreviewed_by\x18\a \x01(\tR\n" +
+	"reviewedBy\x12\x1f\n" +
+	"\vreview_note\x18\b \x01(\tR\n" +
+	"reviewNote\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\t \x01(\tR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\tR\tcreatedAt2\xa9\x04\n" +
+	"\x12TransactionService\x12V\n" +
+	"\x11CreateTransaction\x12&.fastfunds.v1.CreateTransactionRequest\x1a\x19.fastfunds.v1.Transaction\x12P\n" +
+	"\x0eGetTransaction\x12#.fastfunds.v1.GetTransactionRequest\x1a\x19.fastfunds.v1.Transaction\x12V\n" +
+	"\x10ListTransactions\x12%.fastfunds.v1.ListTransactionsRequest\x1a\x19.fastfunds.v1.Transaction0\x01\x12`\n" +
+	"\x15GetTransactionHistory\x12*.fastfunds.v1.GetTransactionHistoryRequest\x1a\x19.fastfunds.v1.Transaction0\x01\x12W\n" +
+	"\x12ApproveTransaction\x12&.fastfunds.v1.ReviewTransactionRequest\x1a\x19.fastfunds.v1.Transaction\x12V\n" +
+	"\x11RejectTransaction\x12&.fastfunds.v1.ReviewTransactionRequest\x1a\x19.fastfunds.v1.TransactionB*Z(fastfunds/proto/fastfunds/v1;fastfundsv1b\x06proto3"
+
+var (
+	file_fastfunds_v1_transactions_proto_rawDescOnce sync.Once
+	file_fastfunds_v1_transactions_proto_rawDescData []byte
+)
+
+func file_fastfunds_v1_transactions_proto_rawDescGZIP() []byte {
+	file_fastfunds_v1_transactions_proto_rawDescOnce.Do(func() {
+		file_fastfunds_v1_transactions_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fastfunds_v1_transactions_proto_rawDesc), len(file_fastfunds_v1_transactions_proto_rawDesc)))
+	})
+	return file_fastfunds_v1_transactions_proto_rawDescData
+}
+
+var file_fastfunds_v1_transactions_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_fastfunds_v1_transactions_proto_goTypes = []any{
+	(*CreateTransactionRequest)(nil),     // 0: fastfunds.v1.CreateTransactionRequest
+	(*GetTransactionRequest)(nil),        // 1: fastfunds.v1.GetTransactionRequest
+	(*ListTransactionsRequest)(nil),      // 2: fastfunds.v1.ListTransactionsRequest
+	(*GetTransactionHistoryRequest)(nil), // 3: fastfunds.v1.GetTransactionHistoryRequest
+	(*ReviewTransactionRequest)(nil),     // 4: fastfunds.v1.ReviewTransactionRequest
+	(*Transaction)(nil),                  // 5: fastfunds.v1.Transaction
+}
+var file_fastfunds_v1_transactions_proto_depIdxs = []int32{
+	0, // 0: fastfunds.v1.TransactionService.CreateTransaction:input_type -> fastfunds.v1.CreateTransactionRequest
+	1, // 1: fastfunds.v1.TransactionService.GetTransaction:input_type -> fastfunds.v1.GetTransactionRequest
+	2, // 2: fastfunds.v1.TransactionService.ListTransactions:input_type -> fastfunds.v1.ListTransactionsRequest
+	3, // 3: fastfunds.v1.TransactionService.GetTransactionHistory:input_type -> fastfunds.v1.GetTransactionHistoryRequest
+	4, // 4: fastfunds.v1.TransactionService.ApproveTransaction:input_type -> fastfunds.v1.ReviewTransactionRequest
+	4, // 5: fastfunds.v1.TransactionService.RejectTransaction:input_type -> fastfunds.v1.ReviewTransactionRequest
+	5, // 6: fastfunds.v1.TransactionService.CreateTransaction:output_type -> fastfunds.v1.Transaction
+	5, // 7: fastfunds.v1.TransactionService.GetTransaction:output_type -> fastfunds.v1.Transaction
+	5, // 8: fastfunds.v1.TransactionService.ListTransactions:output_type -> fastfunds.v1.Transaction
+	5, // 9: fastfunds.v1.TransactionService.GetTransactionHistory:output_type -> fastfunds.v1.Transaction
+	5, // 10: fastfunds.v1.TransactionService.ApproveTransaction:output_type -> fastfunds.v1.Transaction
+	5, // 11: fastfunds.v1.TransactionService.RejectTransaction:output_type -> fastfunds.v1.Transaction
+	6, // [6:12] is the sub-list for method output_type
+	0, // [0:6] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_fastfunds_v1_transactions_proto_init() }
+func file_fastfunds_v1_transactions_proto_init() {
+	if File_fastfunds_v1_transactions_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fastfunds_v1_transactions_proto_rawDesc), len(file_fastfunds_v1_transactions_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_fastfunds_v1_transactions_proto_goTypes,
+		DependencyIndexes: file_fastfunds_v1_transactions_proto_depIdxs,
+		MessageInfos:      file_fastfunds_v1_transactions_proto_msgTypes,
+	}.Build()
+	File_fastfunds_v1_transactions_proto = out.File
+	file_fastfunds_v1_transactions_proto_goTypes = nil
+	file_fastfunds_v1_transactions_proto_depIdxs = nil
+}